@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/pkg/client"
+)
+
+func runList(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	var opts client.ListOptions
+	flags.StringVar(&opts.Status, "status", "", "")
+	flags.StringVar(&opts.SortBy, "sort", "", "")
+	flags.StringVar(&opts.Order, "order", "", "")
+	flags.StringVar(&opts.SprintID, "sprint", "", "")
+	flags.StringVar(&opts.EpicID, "epic", "", "")
+	if positional, err := parseArgs(flags, args); err != nil || len(positional) > 0 {
+		return errUsage
+	}
+	todos, err := c.api.ListTodos(ctx, opts)
+	if err != nil {
+		return err
+	}
+	return c.printTodos(todos)
+}
+
+func runGet(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	todo, err := c.api.GetTodo(ctx, id)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(todo)
+	}
+	if err := c.printTodo(todo); err != nil {
+		return err
+	}
+	if todo.Description != "" {
+		fmt.Fprintf(c.stdout, "\n%s\n", todo.Description)
+	}
+	if len(todo.Subtasks) > 0 {
+		fmt.Fprintln(c.stdout)
+		return c.printSubtasks(todo.Subtasks)
+	}
+	return nil
+}
+
+func runAdd(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("add", flag.ContinueOnError)
+	var req models.CreateTodoRequest
+	flags.StringVar(&req.Description, "description", "", "")
+	due := flags.String("due", "", "")
+	flags.StringVar(&req.PriorityKey, "priority", "", "")
+	flags.StringVar(&req.Status, "status", "", "")
+	points := flags.Int("points", 0, "")
+	epicID := flags.Int64("epic", 0, "")
+	positional, err := parseArgs(flags, args)
+	if err != nil || len(positional) != 1 {
+		return errUsage
+	}
+	req.Title = positional[0]
+	if *due != "" {
+		value := resolveDue(*due, time.Now())
+		dueDate, err := models.ParseDateInput(value)
+		if err != nil {
+			return fmt.Errorf("invalid due date %q, expected today, tomorrow, a weekday, +Nd, YYYY-MM-DD or an RFC3339 timestamp", *due)
+		}
+		req.DueDate = &dueDate
+		// Dates are relative to this machine, so resolve them in its zone
+		// when it is known by name
+		req.Timezone = os.Getenv("TZ")
+	}
+	if *points != 0 {
+		req.StoryPoints = points
+	}
+	if *epicID != 0 {
+		req.EpicID = epicID
+	}
+
+	todo, err := c.api.CreateTodo(ctx, req)
+	if err != nil {
+		return err
+	}
+	return c.printTodo(todo)
+}
+
+func runDone(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	todo, err := c.api.CompleteTodo(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.printTodo(todo)
+}
+
+func runRemove(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	if err := c.api.DeleteTodo(ctx, id); err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(map[string]int64{"deleted": id})
+	}
+	fmt.Fprintf(c.stdout, "Deleted todo %d\n", id)
+	return nil
+}
+
+func runSubtask(ctx context.Context, c *cli, args []string) error {
+	if len(args) < 2 {
+		return errUsage
+	}
+	todoID, err := parseID(args[1])
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case args[0] == "list" && len(args) == 2:
+		subtasks, err := c.api.ListSubtasks(ctx, todoID)
+		if err != nil {
+			return err
+		}
+		return c.printSubtasks(subtasks)
+	case args[0] == "add" && len(args) == 3:
+		subtask, err := c.api.CreateSubtask(ctx, todoID, models.CreateSubtaskRequest{Title: args[2]})
+		if err != nil {
+			return err
+		}
+		return c.printSubtask(subtask)
+	case args[0] == "done" && len(args) == 3:
+		subtaskID, err := parseID(args[2])
+		if err != nil {
+			return err
+		}
+		subtask, err := c.api.UpdateSubtask(ctx, todoID, subtaskID, models.UpdateSubtaskRequest{Completed: true})
+		if err != nil {
+			return err
+		}
+		return c.printSubtask(subtask)
+	}
+	return errUsage
+}
+
+// printJSON writes v as indented JSON
+func (c *cli) printJSON(v interface{}) error {
+	encoder := json.NewEncoder(c.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// printTodo writes one todo as a table row or a JSON object
+func (c *cli) printTodo(todo models.Todo) error {
+	if c.json {
+		return c.printJSON(todo)
+	}
+	return c.printTodos([]models.Todo{todo})
+}
+
+// printTodos writes todos as a table or a JSON array
+func (c *cli) printTodos(todos []models.Todo) error {
+	if c.json {
+		if todos == nil {
+			todos = []models.Todo{}
+		}
+		return c.printJSON(todos)
+	}
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tPRIORITY\tDUE\tPOINTS\tTITLE")
+	for _, todo := range todos {
+		due := "-"
+		if todo.DueDate != nil {
+			if todo.AllDay {
+				due = todo.DueDate.In(dueZone(todo)).Format(models.DateLayout)
+			} else {
+				due = todo.DueDate.Local().Format("2006-01-02 15:04")
+			}
+		}
+		points := "-"
+		if todo.StoryPoints != nil {
+			points = strconv.Itoa(*todo.StoryPoints)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", todo.ID, todo.Status, todo.PriorityKey, due, points, todo.Title)
+	}
+	return w.Flush()
+}
+
+// printSubtask writes one subtask as a table row or a JSON object
+func (c *cli) printSubtask(subtask models.Subtask) error {
+	if c.json {
+		return c.printJSON(subtask)
+	}
+	return c.printSubtasks([]models.Subtask{subtask})
+}
+
+// printSubtasks writes subtasks as a table or a JSON array
+func (c *cli) printSubtasks(subtasks []models.Subtask) error {
+	if c.json {
+		if subtasks == nil {
+			subtasks = []models.Subtask{}
+		}
+		return c.printJSON(subtasks)
+	}
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SUBTASK\tDONE\tTITLE")
+	for _, subtask := range subtasks {
+		done := " "
+		if subtask.Completed {
+			done = "x"
+		}
+		fmt.Fprintf(w, "%d\t[%s]\t%s\n", subtask.ID, done, subtask.Title)
+	}
+	return w.Flush()
+}
+
+// dueZone is the zone an all-day due date was set in
+func dueZone(todo models.Todo) *time.Location {
+	if todo.DueTimezone != nil {
+		if loc, err := models.LoadLocation(*todo.DueTimezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
@@ -0,0 +1,213 @@
+// Command flowctl manages todos from the command line through the REST API.
+//
+//	flowctl list --status in_progress --sort due_date
+//	flowctl add "Write release notes" --due tomorrow --priority high
+//	flowctl done 42
+//	flowctl subtask add 42 "write tests"
+//
+// The API URL and token come from -url and -token, then FLOWCTL_URL and
+// FLOWCTL_TOKEN, then the config file (FLOWCTL_CONFIG, by default
+// flowctl/config.json in the user config directory):
+//
+//	{"url": "https://flow.example.com/api/v1", "token": "..."}
+//
+// API errors are printed with the server's message and exit with status 1;
+// usage errors exit with status 2.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"flow-v1/backend/pkg/client"
+)
+
+// errUsage reports a bad command line; the usage has already been printed
+var errUsage = errors.New("usage")
+
+// config is the contents of the config file
+type config struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+// cli holds what every command needs
+type cli struct {
+	api    *client.Client
+	json   bool
+	stdout io.Writer
+}
+
+// command is a subcommand; run gets the arguments after its name
+type command struct {
+	usage string
+	run   func(ctx context.Context, c *cli, args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"list":    {"list [--status KEY] [--sort FIELDS] [--order asc|desc] [--sprint ID|none] [--epic ID|none]", runList},
+		"get":     {"get ID", runGet},
+		"add":     {"add TITLE [--description TEXT] [--due DATE] [--priority KEY] [--status KEY] [--points N] [--epic ID]", runAdd},
+		"done":    {"done ID", runDone},
+		"rm":      {"rm ID", runRemove},
+		"subtask": {"subtask list ID | subtask add ID TITLE | subtask done ID SUBTASK_ID", runSubtask},
+	}
+}
+
+func main() {
+	flags := flag.NewFlagSet("flowctl", flag.ContinueOnError)
+	baseURL := flags.String("url", "", "API base URL (default from FLOWCTL_URL, the config file or "+client.DefaultBaseURL+")")
+	token := flags.String("token", "", "API token (default from FLOWCTL_TOKEN or the config file)")
+	output := flags.String("o", "table", "output format: table or json")
+	flags.Usage = func() { usage(flags) }
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+	if flags.NArg() == 0 || (*output != "table" && *output != "json") {
+		usage(flags)
+		os.Exit(2)
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "flowctl: unknown command %q\n", flags.Arg(0))
+		usage(flags)
+		os.Exit(2)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "flowctl: %v\n", err)
+		os.Exit(1)
+	}
+	if *baseURL != "" {
+		cfg.URL = *baseURL
+	}
+	if *token != "" {
+		cfg.Token = *token
+	}
+
+	c := &cli{api: client.New(cfg.URL, cfg.Token), json: *output == "json", stdout: os.Stdout}
+	if err := cmd.run(context.Background(), c, flags.Args()[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "usage: flowctl %s\n", cmd.usage)
+			os.Exit(2)
+		}
+		var apiErr *client.APIError
+		if errors.As(err, &apiErr) {
+			fmt.Fprintf(os.Stderr, "flowctl: %s\n", apiErr.Error())
+		} else {
+			fmt.Fprintf(os.Stderr, "flowctl: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func usage(flags *flag.FlagSet) {
+	fmt.Fprintln(os.Stderr, "usage: flowctl [-url URL] [-token TOKEN] [-o table|json] COMMAND [ARGS]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, name := range []string{"list", "get", "add", "done", "rm", "subtask"} {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nflags:")
+	flags.PrintDefaults()
+}
+
+// loadConfig reads the config file, if any, and applies FLOWCTL_URL and
+// FLOWCTL_TOKEN over it
+func loadConfig() (config, error) {
+	cfg := config{URL: client.DefaultBaseURL}
+	path := os.Getenv("FLOWCTL_CONFIG")
+	if path == "" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			path = filepath.Join(dir, "flowctl", "config.json")
+		}
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return cfg, err
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, &cfg); err != nil {
+				return cfg, fmt.Errorf("invalid config file %s: %w", path, err)
+			}
+		}
+	}
+	if value := os.Getenv("FLOWCTL_URL"); value != "" {
+		cfg.URL = value
+	}
+	if value := os.Getenv("FLOWCTL_TOKEN"); value != "" {
+		cfg.Token = value
+	}
+	return cfg, nil
+}
+
+// parseArgs parses flags that may appear before, between or after the
+// positional arguments, which it returns
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	flags.SetOutput(io.Discard)
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, errUsage
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// parseID parses a todo or subtask ID argument
+func parseID(value string) (int64, error) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid ID %q", value)
+	}
+	return id, nil
+}
+
+// weekdays maps day names accepted by --due to their time.Weekday
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// resolveDue turns relative due dates (today, tomorrow, a weekday name for
+// its next occurrence, or +Nd) into a YYYY-MM-DD date relative to now. Other
+// values are passed to the server unchanged.
+func resolveDue(value string, now time.Time) string {
+	lower := strings.ToLower(strings.TrimSpace(value))
+	days := -1
+	switch {
+	case lower == "today":
+		days = 0
+	case lower == "tomorrow":
+		days = 1
+	case strings.HasPrefix(lower, "+") && strings.HasSuffix(lower, "d"):
+		if n, err := strconv.Atoi(lower[1 : len(lower)-1]); err == nil && n >= 0 {
+			days = n
+		}
+	default:
+		if weekday, ok := weekdays[lower]; ok {
+			days = (int(weekday)-int(now.Weekday())+6)%7 + 1
+		}
+	}
+	if days < 0 {
+		return value
+	}
+	return now.AddDate(0, 0, days).Format("2006-01-02")
+}
@@ -0,0 +1,40 @@
+// Command rotate-keys re-encrypts every encrypted column with the current
+// key, the first one in ENCRYPTION_KEYS. To rotate, put the new key first
+// while keeping the old one listed, restart the servers so they write with
+// the new key and still read the old one, run this command, then drop the
+// old key.
+//
+//	ENCRYPTION_KEYS=2025b:<new key>,2025a:<old key> go run ./cmd/rotate-keys
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/joho/godotenv"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/secrets"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Printf("No .env file loaded: %v", err)
+	}
+	if err := secrets.Init(); err != nil {
+		log.Fatalf("Invalid encryption keys: %v", err)
+	}
+	if secrets.Keys == nil {
+		log.Fatalf("Refusing to rotate: %v", secrets.ErrNoKey)
+	}
+	if err := db.Init(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	rewritten, err := secrets.Rotate(context.Background(), secrets.Keys)
+	if err != nil {
+		log.Fatalf("Rotation stopped after %d values: %v", rewritten, err)
+	}
+	log.Printf("Re-encrypted %d values with key %q", rewritten, secrets.Keys.CurrentKeyID())
+}
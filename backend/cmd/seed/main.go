@@ -0,0 +1,334 @@
+// Command seed fills a development database with realistic todos, epics,
+// sprints and subtasks. Everything is created through the API handlers so
+// validation, positions, activity and audit entries match real usage.
+//
+//	go run ./cmd/seed -todos 300 -seed 42
+//	go run ./cmd/seed -wipe -yes
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/router"
+)
+
+// maxUnforcedTodos is the todo count above which a database no longer looks
+// like a development one
+const maxUnforcedTodos = 1000
+
+// localHosts are the database hosts treated as development databases
+var localHosts = map[string]bool{"localhost": true, "127.0.0.1": true, "::1": true, "postgres": true, "db": true}
+
+// wipeTables are truncated by -wipe. Configuration such as statuses,
+// priorities and custom fields is kept.
+var wipeTables = []string{"todos", "epics", "sprints", "audit_log"}
+
+var (
+	titleVerbs = []string{"Fix", "Add", "Refactor", "Document", "Review", "Investigate", "Update", "Remove", "Design", "Test"}
+	titleNouns = []string{"login flow", "invoice export", "search results", "onboarding emails", "billing page", "API rate limits",
+		"dark mode", "CSV import", "notification settings", "dashboard charts", "mobile layout", "release notes", "error pages",
+		"sprint report", "webhook retries", "password reset", "audit log", "keyboard shortcuts", "file uploads", "date picker"}
+	subtaskTitles = []string{"Write tests", "Update docs", "Get review", "Check on staging", "Clean up", "Ask design for feedback", "Measure impact"}
+	epics         = []models.CreateEpicRequest{
+		{Title: "Billing revamp", Description: "Everything for the new invoicing flow", Color: "#6366f1"},
+		{Title: "Mobile polish", Description: "Small screen fixes before the app launch", Color: "#10b981"},
+		{Title: "Platform health", Description: "Reliability and developer experience", Color: "#f59e0b"},
+	}
+)
+
+func main() {
+	todoCount := flag.Int("todos", 200, "number of todos to create")
+	seed := flag.Int64("seed", 0, "random seed for reproducible data (defaults to the current time)")
+	wipe := flag.Bool("wipe", false, "truncate todos, epics, sprints and the audit log first")
+	yes := flag.Bool("yes", false, "do not ask before wiping")
+	force := flag.Bool("force", false, "run even if the database looks like production")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("No .env file loaded: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	if reason, err := productionReason(ctx); err != nil {
+		log.Fatalf("Failed to inspect database: %v", err)
+	} else if reason != "" && !*force {
+		log.Fatalf("Refusing to seed: %s. Use -force if this really is a development database.", reason)
+	}
+
+	if *wipe {
+		if !*yes && !confirm("This deletes all todos, epics, sprints and audit entries. Type yes to continue: ") {
+			log.Fatal("Aborted")
+		}
+		if _, err := db.Pool.Exec(ctx, `TRUNCATE `+strings.Join(wipeTables, ", ")+` RESTART IDENTITY CASCADE`); err != nil {
+			log.Fatalf("Failed to wipe data: %v", err)
+		}
+		log.Printf("Wiped %s", strings.Join(wipeTables, ", "))
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	log.Printf("Seeding %d todos with -seed %d", *todoCount, *seed)
+
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = io.Discard
+	s := &seeder{api: router.New(router.Options{}), rng: rand.New(rand.NewSource(*seed)), now: time.Now()}
+	if err := s.run(*todoCount); err != nil {
+		log.Fatalf("Seeding failed: %v", err)
+	}
+	log.Printf("Done")
+}
+
+// productionReason returns why the database looks like production data, or
+// "" when it looks like a development database
+func productionReason(ctx context.Context) (string, error) {
+	if gin.Mode() == gin.ReleaseMode || os.Getenv("GIN_MODE") == gin.ReleaseMode {
+		return "GIN_MODE is release", nil
+	}
+	if parsed, err := url.Parse(os.Getenv("DATABASE_URL")); err == nil && parsed.Hostname() != "" && !localHosts[parsed.Hostname()] {
+		return fmt.Sprintf("database host %s is not local", parsed.Hostname()), nil
+	}
+	var count int
+	if err := db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM todos`).Scan(&count); err != nil {
+		return "", err
+	}
+	if count > maxUnforcedTodos {
+		return fmt.Sprintf("it already holds %d todos", count), nil
+	}
+	return "", nil
+}
+
+// confirm asks question on stdin and reports whether the answer was yes
+func confirm(question string) bool {
+	fmt.Print(question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(answer) == "yes"
+}
+
+// seeder creates data by calling the API in process
+type seeder struct {
+	api *gin.Engine
+	rng *rand.Rand
+	now time.Time
+}
+
+// call sends a request to the API and decodes the response into out. Any
+// status other than want is an error.
+func (s *seeder) call(method, path string, body, out interface{}, want int) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, router.BasePath+path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	s.api.ServeHTTP(recorder, req)
+	if recorder.Code != want {
+		return fmt.Errorf("%s %s: %d %s", method, path, recorder.Code, strings.TrimSpace(recorder.Body.String()))
+	}
+	if out != nil {
+		return json.Unmarshal(recorder.Body.Bytes(), out)
+	}
+	return nil
+}
+
+func (s *seeder) run(todoCount int) error {
+	var statuses []models.Status
+	if err := s.call(http.MethodGet, "/statuses", nil, &statuses, http.StatusOK); err != nil {
+		return err
+	}
+	var priorities []models.Priority
+	if err := s.call(http.MethodGet, "/priorities", nil, &priorities, http.StatusOK); err != nil {
+		return err
+	}
+	if len(statuses) == 0 || len(priorities) == 0 {
+		return fmt.Errorf("statuses and priorities must be configured first; run the migrations")
+	}
+
+	epicIDs := make([]int64, 0, len(epics))
+	for _, req := range epics {
+		var epic models.Epic
+		if err := s.call(http.MethodPost, "/epics", req, &epic, http.StatusCreated); err != nil {
+			return err
+		}
+		epicIDs = append(epicIDs, epic.ID)
+	}
+
+	sprintID, err := s.createSprints()
+	if err != nil {
+		return err
+	}
+
+	var sprintTodos []int64
+	for i := 0; i < todoCount; i++ {
+		todo, err := s.createTodo(statuses, priorities, epicIDs)
+		if err != nil {
+			return err
+		}
+		if todo.CompletedAt == nil && s.rng.Intn(3) == 0 {
+			sprintTodos = append(sprintTodos, todo.ID)
+		}
+		if err := s.createSubtasks(todo); err != nil {
+			return err
+		}
+	}
+
+	if len(sprintTodos) > 0 {
+		path := fmt.Sprintf("/sprints/%d/todos", sprintID)
+		if err := s.call(http.MethodPost, path, models.SprintTodosRequest{TodoIDs: sprintTodos}, nil, http.StatusOK); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createSprints creates a running sprint around today and a planned one
+// after it, returning the running sprint's ID
+func (s *seeder) createSprints() (int64, error) {
+	var current, next models.Sprint
+	capacity := 30
+	err := s.call(http.MethodPost, "/sprints", models.CreateSprintRequest{
+		Name:      "Sprint " + s.now.Format("Jan 2"),
+		StartDate: s.now.AddDate(0, 0, -7).Format(models.DateLayout),
+		EndDate:   s.now.AddDate(0, 0, 6).Format(models.DateLayout),
+		Capacity:  &capacity,
+	}, &current, http.StatusCreated)
+	if err != nil {
+		return 0, err
+	}
+	err = s.call(http.MethodPost, "/sprints", models.CreateSprintRequest{
+		Name:      "Sprint " + s.now.AddDate(0, 0, 7).Format("Jan 2"),
+		StartDate: s.now.AddDate(0, 0, 7).Format(models.DateLayout),
+		EndDate:   s.now.AddDate(0, 0, 20).Format(models.DateLayout),
+		Capacity:  &capacity,
+	}, &next, http.StatusCreated)
+	if err != nil {
+		return 0, err
+	}
+
+	// Another sprint may already be running when seeding without -wipe
+	if err := s.call(http.MethodPost, fmt.Sprintf("/sprints/%d/start", current.ID), nil, nil, http.StatusOK); err != nil {
+		log.Printf("Sprint %d left planned: %v", current.ID, err)
+	}
+	return current.ID, nil
+}
+
+// createTodo creates one todo with a weighted random status and priority.
+// Early board columns and the default priority are the most common; most
+// todos have a due date within a few weeks of today, some of them all-day.
+func (s *seeder) createTodo(statuses []models.Status, priorities []models.Priority, epicIDs []int64) (models.Todo, error) {
+	statusWeights := make([]int, len(statuses))
+	for i, status := range statuses {
+		statusWeights[i] = len(statuses) - i + 1
+		if status.IsDone {
+			statusWeights[i] = 3
+		}
+	}
+	priorityWeights := make([]int, len(priorities))
+	for i, priority := range priorities {
+		priorityWeights[i] = 2
+		if priority.IsDefault {
+			priorityWeights[i] = 5
+		}
+	}
+
+	req := models.CreateTodoRequest{
+		Title:       fmt.Sprintf("%s %s", titleVerbs[s.rng.Intn(len(titleVerbs))], titleNouns[s.rng.Intn(len(titleNouns))]),
+		Status:      statuses[weightedIndex(s.rng, statusWeights)].Key,
+		PriorityKey: priorities[weightedIndex(s.rng, priorityWeights)].Key,
+		Timezone:    "UTC",
+	}
+	if s.rng.Intn(2) == 0 {
+		req.Description = "Seeded todo. " + subtaskTitles[s.rng.Intn(len(subtaskTitles))] + " before closing."
+	}
+	if s.rng.Intn(4) > 0 {
+		points := []int{1, 2, 3, 5, 8}[s.rng.Intn(5)]
+		req.StoryPoints = &points
+	}
+	if s.rng.Intn(2) == 0 {
+		req.EpicID = &epicIDs[s.rng.Intn(len(epicIDs))]
+	}
+
+	body := map[string]interface{}{}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return models.Todo{}, err
+	}
+	if s.rng.Intn(10) < 7 {
+		due := s.now.Add(time.Duration(s.rng.NormFloat64()*10*24) * time.Hour)
+		if s.rng.Intn(3) == 0 {
+			body["due_date"] = due.Format(models.DateLayout)
+		} else {
+			body["due_date"] = due.UTC().Truncate(time.Hour).Format(time.RFC3339)
+		}
+	}
+
+	var todo models.Todo
+	err = s.call(http.MethodPost, "/todos", body, &todo, http.StatusCreated)
+	return todo, err
+}
+
+// createSubtasks adds up to four subtasks to todo, completing roughly half
+func (s *seeder) createSubtasks(todo models.Todo) error {
+	for i, n := 0, s.rng.Intn(5); i < n; i++ {
+		var subtask models.Subtask
+		title := subtaskTitles[s.rng.Intn(len(subtaskTitles))]
+		path := fmt.Sprintf("/todos/%d/subtasks", todo.ID)
+		if err := s.call(http.MethodPost, path, models.CreateSubtaskRequest{Title: title}, &subtask, http.StatusCreated); err != nil {
+			return err
+		}
+		if todo.CompletedAt != nil || s.rng.Intn(2) == 0 {
+			update := models.UpdateSubtaskRequest{Title: title, Completed: true}
+			if err := s.call(http.MethodPut, fmt.Sprintf("%s/%d", path, subtask.ID), update, nil, http.StatusOK); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// weightedIndex picks an index with probability proportional to its weight
+func weightedIndex(rng *rand.Rand, weights []int) int {
+	total := 0
+	for _, weight := range weights {
+		total += weight
+	}
+	pick := rng.Intn(total)
+	for i, weight := range weights {
+		if pick < weight {
+			return i
+		}
+		pick -= weight
+	}
+	return len(weights) - 1
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/handlers"
+	"flow-v1/backend/internal/jobs"
+	"flow-v1/backend/internal/router"
+	"flow-v1/backend/internal/secrets"
+	"flow-v1/backend/internal/server"
+)
+
+// defaultCORSOrigins is used when CORS_ORIGINS is unset and allows the Vite
+// dev server
+const defaultCORSOrigins = "http://localhost:5173"
+
+// @title           Flow API
+// @version         1.0
+// @description     Todo, sprint and epic tracking API.
+// @host            localhost:8080
+// @BasePath        /api/v1
+
+// @securityDefinitions.apikey  AdminToken
+// @in                          header
+// @name                        Authorization
+// @description                 "Bearer " followed by ADMIN_TOKEN
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	if err := db.Init(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if err := secrets.Init(); err != nil {
+		log.Fatalf("Invalid encryption keys: %v", err)
+	}
+	if err := secrets.Check(context.Background(), secrets.Keys); err != nil {
+		log.Fatalf("Encrypted data cannot be read: %v", err)
+	}
+
+	cfg, err := server.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid server configuration: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	runner := jobs.NewRunner()
+	for _, newJob := range []func() (jobs.Job, error){jobs.AuditRetention, jobs.TombstoneRetention, jobs.GitHubSync, jobs.PriorityEscalation} {
+		job, err := newJob()
+		if err != nil {
+			log.Fatalf("Invalid job configuration: %v", err)
+		}
+		runner.Register(job)
+	}
+	runner.Register(jobs.TodoViews())
+	runner.Register(jobs.SubtaskCounts())
+	runner.Register(jobs.EditLocks())
+	runner.Register(jobs.Integrity())
+	runner.Register(jobs.Reminders())
+	runner.Register(jobs.Deferred())
+	runner.Register(jobs.Exports())
+	runner.Register(jobs.ExportRetention())
+
+	middleware := []gin.HandlerFunc{apiCORS(cors.New(corsConfig()))}
+	usage, meterUsage, err := handlers.UsageConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid usage configuration: %v", err)
+	}
+	if meterUsage {
+		middleware = append(middleware, handlers.UsageMetering(usage))
+		runner.Register(jobs.UsageMetering())
+	}
+	runner.Start(ctx)
+
+	errorLog, logErrors, err := handlers.ErrorLogConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	if logErrors {
+		middleware = append(middleware, handlers.ErrorBodyLog(errorLog))
+	}
+
+	statusCfg, err := handlers.StatusConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid status configuration: %v", err)
+	}
+
+	var admin []gin.HandlerFunc
+	if cfg.TLSClientCAFile != "" {
+		admin = append(admin, server.RequireClientCert)
+	}
+	engine := router.New(router.Options{
+		Middleware: middleware,
+		Admin:      admin,
+	})
+	engine.GET("/health", health)
+	engine.GET("/status", handlers.ServiceStatus(statusCfg, runner.Lag))
+
+	if err := server.Serve(ctx, engine, cfg); err != nil {
+		log.Printf("Server error: %v", err)
+	}
+	stop()
+
+	runner.Wait()
+	// Record views and usage still waiting for the next flush
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := handlers.FlushTodoViews(flushCtx); err != nil {
+		log.Printf("Error flushing todo views: %v", err)
+	}
+	if meterUsage {
+		if err := handlers.FlushUsage(flushCtx); err != nil {
+			log.Printf("Error flushing usage: %v", err)
+		}
+	}
+	log.Println("Server stopped")
+}
+
+// corsConfig allows the origins in CORS_ORIGINS (comma-separated) to call
+// the API and read the headers it returns
+func corsConfig() cors.Config {
+	origins := os.Getenv("CORS_ORIGINS")
+	if origins == "" {
+		origins = defaultCORSOrigins
+	}
+	config := cors.DefaultConfig()
+	config.AllowOrigins = strings.Split(origins, ",")
+	config.AllowMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "If-None-Match", "X-Timezone", "X-Request-ID", "X-Workspace"}
+	config.ExposeHeaders = []string{"Location", "ETag", "X-Total-Count", "Retry-After", "X-Edit-Lock-Held-By", "X-Ignored-Params", "X-Board-Version"}
+	return config
+}
+
+// apiCORS runs cors on every request except those for the embed feed, which
+// must be readable from origins outside CORS_ORIGINS and sets the CORS headers
+// for its own origin
+func apiCORS(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, router.BasePath+"/embed/") {
+			c.Next()
+			return
+		}
+		handler(c)
+	}
+}
+
+// health reports whether the API can reach the database. It sits outside
+// the API group so maintenance mode never blocks it.
+func health(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	if err := db.Pool.Ping(ctx); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
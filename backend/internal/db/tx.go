@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// dryRunKey marks contexts from WithDryRun
+type dryRunKey struct{}
+
+// WithDryRun returns a context under which WithTx rolls back every
+// transaction, even when fn succeeds, so a request can run all its work
+// and report the result without changing anything
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// IsDryRun reports whether ctx comes from WithDryRun
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+// WithTx runs fn inside a transaction, committing when fn succeeds and
+// rolling back when it returns an error or ctx is a dry run. The error from
+// fn is returned as-is so callers can still match on pgx.ErrNoRows and
+// friends.
+func WithTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if IsDryRun(ctx) {
+		return nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
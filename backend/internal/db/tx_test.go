@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	if IsDryRun(ctx) {
+		t.Error("a plain context is a dry run")
+	}
+	if !IsDryRun(WithDryRun(ctx)) {
+		t.Error("WithDryRun is not a dry run")
+	}
+	child, cancel := context.WithCancel(WithDryRun(ctx))
+	defer cancel()
+	if !IsDryRun(child) {
+		t.Error("a context derived from WithDryRun is not a dry run")
+	}
+}
+
+// usePool points Pool at TEST_DATABASE_URL for the test, which is skipped
+// when it is unset
+func usePool(t *testing.T) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatalf("connecting to TEST_DATABASE_URL: %v", err)
+	}
+	previous := Pool
+	Pool = pool
+	t.Cleanup(func() {
+		Pool = previous
+		pool.Close()
+	})
+}
+
+func TestWithTx(t *testing.T) {
+	usePool(t)
+	ctx := context.Background()
+	if _, err := Pool.Exec(ctx, `CREATE TABLE with_tx_test (n INTEGER NOT NULL)`); err != nil {
+		t.Fatalf("creating the table: %v", err)
+	}
+	t.Cleanup(func() { Pool.Exec(context.Background(), `DROP TABLE with_tx_test`) })
+
+	insert := func(n int) func(pgx.Tx) error {
+		return func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, `INSERT INTO with_tx_test (n) VALUES ($1)`, n)
+			return err
+		}
+	}
+	stored := func() []int {
+		rows, _ := Pool.Query(ctx, `SELECT n FROM with_tx_test ORDER BY n`)
+		values, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			t.Fatalf("reading the table: %v", err)
+		}
+		return values
+	}
+
+	if err := WithTx(ctx, insert(1)); err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+
+	failed := errors.New("failed")
+	err := WithTx(ctx, func(tx pgx.Tx) error {
+		if err := insert(2)(tx); err != nil {
+			return err
+		}
+		return failed
+	})
+	if err != failed {
+		t.Errorf("WithTx returned %v, want the error from fn as-is", err)
+	}
+
+	// A dry run does all the work, sees its own writes and returns no error,
+	// but leaves nothing behind
+	var seen int
+	err = WithTx(WithDryRun(ctx), func(tx pgx.Tx) error {
+		if err := insert(3)(tx); err != nil {
+			return err
+		}
+		return tx.QueryRow(ctx, `SELECT COUNT(*) FROM with_tx_test`).Scan(&seen)
+	})
+	if err != nil || seen != 2 {
+		t.Errorf("dry run: err %v, saw %d rows, want nil and 2", err, seen)
+	}
+
+	if got := stored(); len(got) != 1 || got[0] != 1 {
+		t.Errorf("stored %v, want only the committed [1]", got)
+	}
+}
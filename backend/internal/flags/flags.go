@@ -0,0 +1,133 @@
+// Package flags evaluates feature flags stored in the feature_flags table.
+// Flags are cached for CacheTTL, so a change made on another instance takes
+// effect within that time.
+package flags
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// CacheTTL is how long flag definitions are cached
+const CacheTTL = 15 * time.Second
+
+// Columns is the column list selected by every flag query; Scan reads it back
+const Columns = `id, name, description, enabled, rollout_percent, allowlist, created_at, updated_at`
+
+// Scan scans a row selected with Columns
+func Scan(row interface{ Scan(...any) error }, flag *models.FeatureFlag) error {
+	return row.Scan(&flag.ID, &flag.Name, &flag.Description, &flag.Enabled, &flag.RolloutPercent, &flag.Allowlist, &flag.CreatedAt, &flag.UpdatedAt)
+}
+
+// cache holds the flag definitions by name. generation counts Resets, so a
+// load that started before one does not store what it read.
+var cache struct {
+	sync.Mutex
+	flags      map[string]models.FeatureFlag
+	loadedAt   time.Time
+	generation int
+}
+
+// Reset drops the cached definitions so the next evaluation reloads them
+func Reset() {
+	cache.Lock()
+	cache.flags = nil
+	cache.generation++
+	cache.Unlock()
+}
+
+// load returns the flag definitions, reading them when the cache is stale.
+// The lock is not held while reading, so a slow database never makes one
+// evaluation wait for another.
+func load(ctx context.Context) (map[string]models.FeatureFlag, error) {
+	cache.Lock()
+	if cache.flags != nil && time.Since(cache.loadedAt) < CacheTTL {
+		defer cache.Unlock()
+		return cache.flags, nil
+	}
+	generation := cache.generation
+	cache.Unlock()
+
+	rows, err := db.Pool.Query(ctx, `SELECT `+Columns+` FROM feature_flags`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load feature flags: %w", err)
+	}
+	defer rows.Close()
+	flags := map[string]models.FeatureFlag{}
+	for rows.Next() {
+		var flag models.FeatureFlag
+		if err := Scan(rows, &flag); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag: %w", err)
+		}
+		flags[flag.Name] = flag
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load feature flags: %w", err)
+	}
+
+	cache.Lock()
+	if cache.generation == generation {
+		cache.flags, cache.loadedAt = flags, time.Now()
+	}
+	cache.Unlock()
+	return flags, nil
+}
+
+type subjectKey struct{}
+
+// WithSubject returns a context whose flag evaluations are for subject,
+// the workspace of a request
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// Bucket returns the rollout bucket, 0 to 99, of subject for flag. It only
+// depends on both names, so a subject keeps its verdict for a flag as long
+// as the percentage does not drop below its bucket, and different flags
+// roll out to different subjects first.
+func Bucket(flag, subject string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(flag))
+	hash.Write([]byte{0})
+	hash.Write([]byte(subject))
+	return int(hash.Sum32() % 100)
+}
+
+// Evaluate reports whether flag is on for subject
+func Evaluate(flag models.FeatureFlag, subject string) bool {
+	if !flag.Enabled {
+		return false
+	}
+	if slices.Contains(flag.Allowlist, subject) {
+		return true
+	}
+	return Bucket(flag.Name, subject) < flag.RolloutPercent
+}
+
+// Enabled reports whether the flag name is on for the subject of ctx.
+// Unknown flags are off, and so is every flag when the definitions cannot
+// be loaded, so a database problem falls back to the established behavior.
+func Enabled(ctx context.Context, name string) bool {
+	if db.Pool == nil {
+		return false
+	}
+	flags, err := load(ctx)
+	if err != nil {
+		log.Printf("Error evaluating feature flag %s: %v", name, err)
+		return false
+	}
+	flag, ok := flags[name]
+	if !ok {
+		return false
+	}
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	return Evaluate(flag, subject)
+}
@@ -0,0 +1,85 @@
+package flags
+
+import (
+	"strconv"
+	"testing"
+
+	"flow-v1/backend/internal/models"
+)
+
+// Buckets are fixed values of the flag and subject names. A change to the
+// hash reshuffles every rollout, so these are pinned.
+func TestBucket(t *testing.T) {
+	for _, tt := range []struct {
+		flag, subject string
+		bucket        int
+	}{
+		{"strict_params", "local", 28},
+		{"strict_params", "alice", 23},
+		{"hard_locking", "local", 65},
+		{"new_board_query", "", 31},
+	} {
+		for range 3 {
+			if got := Bucket(tt.flag, tt.subject); got != tt.bucket {
+				t.Errorf("Bucket(%q, %q) = %d, want %d", tt.flag, tt.subject, got, tt.bucket)
+			}
+		}
+	}
+}
+
+// Raising the percentage only adds subjects, each percent holds about one
+// in a hundred subjects, and flags roll out to different subjects first
+func TestBucketRollout(t *testing.T) {
+	const subjects = 10000
+	counts := make([]int, 100)
+	differ := 0
+	for i := range subjects {
+		subject := "user-" + strconv.Itoa(i)
+		bucket := Bucket("strict_params", subject)
+		if bucket < 0 || bucket > 99 {
+			t.Fatalf("Bucket(strict_params, %s) = %d, want 0 to 99", subject, bucket)
+		}
+		counts[bucket]++
+		if Bucket("hard_locking", subject) != bucket {
+			differ++
+		}
+
+		flag := models.FeatureFlag{Name: "strict_params", Enabled: true}
+		on := false
+		for flag.RolloutPercent = 0; flag.RolloutPercent <= 100; flag.RolloutPercent++ {
+			verdict := Evaluate(flag, subject)
+			if on && !verdict {
+				t.Fatalf("%s is on at %d%% but off at %d%%", subject, flag.RolloutPercent-1, flag.RolloutPercent)
+			}
+			on = verdict
+		}
+	}
+	for bucket, n := range counts {
+		if n < subjects/100/2 || n > subjects/100*2 {
+			t.Errorf("bucket %d holds %d of %d subjects, want about %d", bucket, n, subjects, subjects/100)
+		}
+	}
+	if differ < subjects/2 {
+		t.Errorf("only %d of %d subjects are in different buckets for two flags", differ, subjects)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    models.FeatureFlag
+		subject string
+		want    bool
+	}{
+		{"disabled", models.FeatureFlag{Name: "f", RolloutPercent: 100}, "local", false},
+		{"disabled and allowlisted", models.FeatureFlag{Name: "f", Allowlist: []string{"local"}}, "local", false},
+		{"no rollout", models.FeatureFlag{Name: "f", Enabled: true}, "local", false},
+		{"allowlisted", models.FeatureFlag{Name: "f", Enabled: true, Allowlist: []string{"local"}}, "local", true},
+		{"full rollout", models.FeatureFlag{Name: "f", Enabled: true, RolloutPercent: 100}, "local", true},
+	}
+	for _, tt := range tests {
+		if got := Evaluate(tt.flag, tt.subject); got != tt.want {
+			t.Errorf("%s: Evaluate = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// ErrIssueNotFound is returned when GitHub reports that an issue does not exist
+var ErrIssueNotFound = errors.New("github issue not found")
+
+// Issue states reported by the GitHub API
+const (
+	IssueStateOpen   = "open"
+	IssueStateClosed = "closed"
+)
+
+const defaultBaseURL = "https://api.github.com"
+
+// issueRefPattern matches owner/repo#number references
+var issueRefPattern = regexp.MustCompile(`^([A-Za-z0-9](?:[A-Za-z0-9-]{0,38}))/([A-Za-z0-9._-]{1,100})#([1-9][0-9]{0,9})$`)
+
+// IssueRef identifies an issue in a repository
+type IssueRef struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+// String formats the reference as owner/repo#number
+func (r IssueRef) String() string {
+	return r.Owner + "/" + r.Repo + "#" + strconv.Itoa(r.Number)
+}
+
+// ParseIssueRef parses an owner/repo#number reference
+func ParseIssueRef(s string) (IssueRef, error) {
+	matches := issueRefPattern.FindStringSubmatch(s)
+	if matches == nil {
+		return IssueRef{}, fmt.Errorf("invalid issue reference %q, expected owner/repo#number", s)
+	}
+	number, err := strconv.Atoi(matches[3])
+	if err != nil {
+		return IssueRef{}, fmt.Errorf("invalid issue number in %q", s)
+	}
+	return IssueRef{Owner: matches[1], Repo: matches[2], Number: number}, nil
+}
+
+// Issue is the subset of the GitHub issue payload the app uses
+type Issue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+}
+
+// Client calls the GitHub REST API with a configured token
+type Client struct {
+	token      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// httpClient is shared by every Client, which is created per use with the
+// token configured at the time
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// NewClient creates a client authenticating with token
+func NewClient(token string) *Client {
+	return &Client{
+		token:      token,
+		baseURL:    defaultBaseURL,
+		httpClient: httpClient,
+	}
+}
+
+// GetIssue fetches a single issue
+func (c *Client) GetIssue(ctx context.Context, ref IssueRef) (*Issue, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/%d", c.baseURL, ref.Owner, ref.Repo, ref.Number)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch issue %s: %w", ref, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrIssueNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch issue %s: github returned %s", ref, resp.Status)
+	}
+
+	var issue Issue
+	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+		return nil, fmt.Errorf("failed to decode issue %s: %w", ref, err)
+	}
+	return &issue, nil
+}
+
+// ErrUnauthorized is returned when GitHub rejects the configured token
+var ErrUnauthorized = errors.New("github token was rejected")
+
+// RateLimit is the core API rate limit of the configured token
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"-"`
+}
+
+// RateLimit fetches the token's core rate limit. GitHub does not count this
+// call against the limit, which makes it a cheap check that the token works.
+func (c *Client) RateLimit(ctx context.Context) (*RateLimit, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/rate_limit", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rate limit: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch rate limit: github returned %s", resp.Status)
+	}
+
+	var body struct {
+		Resources struct {
+			Core struct {
+				RateLimit
+				Reset int64 `json:"reset"`
+			} `json:"core"`
+		} `json:"resources"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode rate limit: %w", err)
+	}
+	limit := body.Resources.Core.RateLimit
+	limit.Reset = time.Unix(body.Resources.Core.Reset, 0).UTC()
+	return &limit, nil
+}
@@ -0,0 +1,56 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// VerifySignature checks an X-Hub-Signature-256 header against the HMAC of
+// body computed with secret
+func VerifySignature(secret, body []byte, header string) bool {
+	signature, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// IssuesEvent is the subset of the "issues" webhook payload the app uses
+type IssuesEvent struct {
+	Action     string `json:"action"`
+	Issue      Issue  `json:"issue"`
+	Repository struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repository"`
+}
+
+// Ref returns the reference of the issue the event is about
+func (e *IssuesEvent) Ref() IssueRef {
+	return IssueRef{Owner: e.Repository.Owner.Login, Repo: e.Repository.Name, Number: e.Issue.Number}
+}
+
+// ParseIssuesEvent decodes an "issues" webhook payload
+func ParseIssuesEvent(body []byte) (*IssuesEvent, error) {
+	var event IssuesEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("invalid issues event payload: %w", err)
+	}
+	if event.Repository.Owner.Login == "" || event.Repository.Name == "" || event.Issue.Number == 0 {
+		return nil, fmt.Errorf("issues event payload is missing the repository or issue")
+	}
+	return &event, nil
+}
@@ -0,0 +1,136 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fixture reads a webhook payload from testdata
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+// sign returns the X-Hub-Signature-256 header GitHub sends for body
+func sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	secret := []byte("It's a Secret to Everybody")
+	body := fixture(t, "issues_closed.json")
+	valid := sign(secret, body)
+
+	// The example from GitHub's documentation, so a mistake shared by sign
+	// and VerifySignature still fails
+	if !VerifySignature(secret, []byte("Hello, World!"), "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17") {
+		t.Error("the documented example signature was rejected")
+	}
+
+	tests := []struct {
+		name   string
+		secret []byte
+		body   []byte
+		header string
+		want   bool
+	}{
+		{"valid", secret, body, valid, true},
+		{"other secret", []byte("not the secret"), body, valid, false},
+		{"body changed", secret, append([]byte(" "), body...), valid, false},
+		{"missing header", secret, body, "", false},
+		{"sha1 header", secret, body, "sha1=" + strings.TrimPrefix(valid, "sha256="), false},
+		{"no prefix", secret, body, strings.TrimPrefix(valid, "sha256="), false},
+		{"uppercase hex", secret, body, "sha256=" + strings.ToUpper(strings.TrimPrefix(valid, "sha256=")), true},
+		{"not hex", secret, body, "sha256=" + strings.Repeat("z", 64), false},
+		{"truncated", secret, body, valid[:len(valid)-2], false},
+		{"empty secret", nil, body, sign(nil, body), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifySignature(tt.secret, tt.body, tt.header); got != tt.want {
+				t.Errorf("VerifySignature = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIssuesEvent(t *testing.T) {
+	tests := []struct {
+		fixture string
+		action  string
+		issue   Issue
+		ref     string
+	}{
+		{
+			fixture: "issues_closed.json",
+			action:  "closed",
+			issue: Issue{
+				Number:  42,
+				Title:   "Reminders fire twice after a restart",
+				State:   IssueStateClosed,
+				HTMLURL: "https://github.com/serazng/flow-v1/issues/42",
+			},
+			ref: "serazng/flow-v1#42",
+		},
+		{
+			// The case GitHub uses is kept; the webhook handler matches links
+			// case-insensitively
+			fixture: "issues_reopened.json",
+			action:  "reopened",
+			issue: Issue{
+				Number:  42,
+				Title:   "Reminders fire twice after a restart",
+				State:   IssueStateOpen,
+				HTMLURL: "https://github.com/Serazng/Flow-V1/issues/42",
+			},
+			ref: "Serazng/Flow-V1#42",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			event, err := ParseIssuesEvent(fixture(t, tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.Action != tt.action || event.Issue != tt.issue {
+				t.Errorf("event %s %+v, want %s %+v", event.Action, event.Issue, tt.action, tt.issue)
+			}
+			if ref := event.Ref().String(); ref != tt.ref {
+				t.Errorf("ref %s, want %s", ref, tt.ref)
+			}
+		})
+	}
+}
+
+func TestParseIssuesEventInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+		err  string
+	}{
+		{"ping event", fixture(t, "ping.json"), "missing the repository or issue"},
+		{"truncated", fixture(t, "issues_truncated.json"), "invalid issues event payload"},
+		{"empty", nil, "invalid issues event payload"},
+		{"issue number as string", []byte(`{"action":"closed","issue":{"number":"42"},"repository":{"name":"flow-v1","owner":{"login":"serazng"}}}`), "invalid issues event payload"},
+		{"no owner", []byte(`{"action":"closed","issue":{"number":42},"repository":{"name":"flow-v1"}}`), "missing the repository or issue"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := ParseIssuesEvent(tt.body)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseIssuesEvent = %+v, %v, want an error containing %q", event, err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// recordTodoEvent writes a single timeline event inside the caller's
+// transaction. Every event except those from background jobs also bumps the
+// todo's last_activity_at, which is what stale detection looks at.
+func recordTodoEvent(ctx context.Context, tx pgx.Tx, event models.TodoEvent) error {
+	_, err := tx.Exec(ctx, `
+		WITH touched AS (
+			UPDATE todos SET last_activity_at = NOW()
+			WHERE id = $1 AND $3::text IS DISTINCT FROM $9
+		)
+		INSERT INTO todo_events (todo_id, type, actor, field, old_value, new_value, subtask_id, subtask_title, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+	`, event.TodoID, event.Type, event.Actor, event.Field, event.OldValue, event.NewValue, event.SubtaskID, event.SubtaskTitle, systemActor)
+	if err != nil {
+		return fmt.Errorf("failed to write todo event: %w", err)
+	}
+	return nil
+}
+
+// recordTodoChanges writes one field_changed event per user-visible field
+// that differs between before and after. Description and custom field
+// changes are recorded without values since they can be arbitrarily long.
+// actor is nil for changes made by the requesting user.
+func recordTodoChanges(ctx context.Context, tx pgx.Tx, actor *string, before, after models.Todo) error {
+	changes := []struct {
+		field    string
+		old, new *string
+	}{
+		{"title", &before.Title, &after.Title},
+		{"status", &before.Status, &after.Status},
+		{"priority", &before.Priority, &after.Priority},
+		{"due_date", formatEventDueDate(before), formatEventDueDate(after)},
+		{"story_points", formatEventInt(before.StoryPoints), formatEventInt(after.StoryPoints)},
+		{"estimate_minutes", formatEventInt(before.EstimateMinutes), formatEventInt(after.EstimateMinutes)},
+		{"sprint_id", formatEventID(before.SprintID), formatEventID(after.SprintID)},
+		{"epic_id", formatEventID(before.EpicID), formatEventID(after.EpicID)},
+		{"deferred_until", before.DeferredUntil, after.DeferredUntil},
+	}
+
+	for _, change := range changes {
+		if equalEventValues(change.old, change.new) {
+			continue
+		}
+		field := change.field
+		if err := recordTodoEvent(ctx, tx, models.TodoEvent{
+			TodoID:   after.ID,
+			Type:     models.TodoEventFieldChanged,
+			Actor:    actor,
+			Field:    &field,
+			OldValue: change.old,
+			NewValue: change.new,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Descriptions and custom fields are recorded without values
+	valueless := []struct {
+		field   string
+		changed bool
+	}{
+		{"description", before.Description != after.Description},
+		{"custom_fields", !reflect.DeepEqual(before.CustomFields, after.CustomFields)},
+	}
+	for _, change := range valueless {
+		if !change.changed {
+			continue
+		}
+		field := change.field
+		if err := recordTodoEvent(ctx, tx, models.TodoEvent{
+			TodoID: after.ID,
+			Type:   models.TodoEventFieldChanged,
+			Actor:  actor,
+			Field:  &field,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordTodoNote writes a note explaining a change, attributed to the system
+// since requests carry no user
+func recordTodoNote(ctx context.Context, tx pgx.Tx, todoID int64, note string) error {
+	actor := systemActor
+	return recordTodoEvent(ctx, tx, models.TodoEvent{TodoID: todoID, Type: models.TodoEventNote, Actor: &actor, NewValue: &note})
+}
+
+// recordSubtaskEvent writes a subtask event carrying the subtask's current title
+func recordSubtaskEvent(ctx context.Context, tx pgx.Tx, eventType string, subtask models.Subtask) error {
+	return recordTodoEvent(ctx, tx, models.TodoEvent{
+		TodoID:       subtask.TodoID,
+		Type:         eventType,
+		SubtaskID:    &subtask.ID,
+		SubtaskTitle: &subtask.Title,
+	})
+}
+
+// recordSubtaskChanges writes rename and completion events for a subtask update
+func recordSubtaskChanges(ctx context.Context, tx pgx.Tx, before, after models.Subtask) error {
+	if before.Title != after.Title {
+		if err := recordTodoEvent(ctx, tx, models.TodoEvent{
+			TodoID:       after.TodoID,
+			Type:         models.TodoEventSubtaskRenamed,
+			OldValue:     &before.Title,
+			NewValue:     &after.Title,
+			SubtaskID:    &after.ID,
+			SubtaskTitle: &after.Title,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if before.Completed != after.Completed {
+		eventType := models.TodoEventSubtaskReopened
+		if after.Completed {
+			eventType = models.TodoEventSubtaskCompleted
+		}
+		return recordSubtaskEvent(ctx, tx, eventType, after)
+	}
+	return nil
+}
+
+func formatEventTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(time.RFC3339)
+	return &formatted
+}
+
+// formatEventDueDate formats all-day due dates as a bare date
+func formatEventDueDate(todo models.Todo) *string {
+	if todo.DueDate == nil || !todo.AllDay {
+		return formatEventTime(todo.DueDate)
+	}
+	loc := time.UTC
+	if todo.DueTimezone != nil {
+		if zone, err := models.LoadLocation(*todo.DueTimezone); err == nil {
+			loc = zone
+		}
+	}
+	formatted := todo.DueDate.In(loc).Format(models.DateLayout)
+	return &formatted
+}
+
+func formatEventInt(n *int) *string {
+	if n == nil {
+		return nil
+	}
+	formatted := strconv.Itoa(*n)
+	return &formatted
+}
+
+func formatEventID(id *int64) *string {
+	if id == nil {
+		return nil
+	}
+	formatted := strconv.FormatInt(*id, 10)
+	return &formatted
+}
+
+func equalEventValues(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// GetTodoActivity godoc
+// @Summary      Get a todo's activity timeline
+// @Description  Get a paginated list of events for a todo, newest first. The total number of events is returned in the X-Total-Count header.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int   true   "Todo ID"
+// @Param        limit     query     int   false  "Page size (max 200)"  default(50)
+// @Param        offset    query     int   false  "Number of events to skip"  default(0)
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.TodoEvent  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/activity [get]
+func GetTodoActivity(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit. Must be between 1 and 200"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return
+	}
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify todo exists
+	var todoExists bool
+	err = db.Pool.QueryRow(c.Request.Context(), `
+		SELECT EXISTS(SELECT 1 FROM todos WHERE id = $1)
+	`, todoID).Scan(&todoExists)
+	if err != nil {
+		log.Printf("Error checking todo existence: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify todo", "details": err.Error()})
+		return
+	}
+	if !todoExists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+
+	var total int64
+	if err := db.Pool.QueryRow(c.Request.Context(), `
+		SELECT COUNT(*) FROM todo_events WHERE todo_id = $1
+	`, todoID).Scan(&total); err != nil {
+		log.Printf("Error counting todo events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count activity", "details": err.Error()})
+		return
+	}
+
+	rows, err := db.Pool.Query(c.Request.Context(), `
+		SELECT id, todo_id, type, actor, field, old_value, new_value, subtask_id, subtask_title, created_at
+		FROM todo_events
+		WHERE todo_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, todoID, limit, offset)
+	if err != nil {
+		log.Printf("Error querying todo events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch activity", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	events := []models.TodoEvent{}
+	for rows.Next() {
+		var event models.TodoEvent
+		if err := rows.Scan(&event.ID, &event.TodoID, &event.Type, &event.Actor, &event.Field, &event.OldValue, &event.NewValue, &event.SubtaskID, &event.SubtaskTitle, &event.CreatedAt); err != nil {
+			log.Printf("Error scanning todo event: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan activity", "details": err.Error()})
+			return
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating todo events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating activity", "details": err.Error()})
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	respondList(c, envelope, events, total, limit, offset)
+}
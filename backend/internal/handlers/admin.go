@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin only lets through requests carrying ADMIN_TOKEN as a bearer
+// token. The API has no user accounts yet, so this shared token is what makes
+// a caller an admin; while ADMIN_TOKEN is unset admin routes are disabled.
+func RequireAdmin(c *gin.Context) {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin endpoints are disabled. Set ADMIN_TOKEN to enable them"})
+		return
+	}
+	given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		c.Header("WWW-Authenticate", "Bearer")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Admin token required"})
+		return
+	}
+	c.Next()
+}
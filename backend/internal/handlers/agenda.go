@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/store"
+)
+
+// isoWeekPattern matches ISO week names such as 2025-W12
+var isoWeekPattern = regexp.MustCompile(`^(\d{4})-W(\d{2})$`)
+
+// parseISOWeek returns the Monday starting an ISO week in loc. Week 1 is the
+// week containing January 4th, so it may start in the previous year, and a
+// year has 53 weeks when December 28th falls in week 53.
+func parseISOWeek(s string, loc *time.Location) (time.Time, error) {
+	match := isoWeekPattern.FindStringSubmatch(s)
+	if match == nil {
+		return time.Time{}, errors.New("Invalid week. Use an ISO week such as 2025-W12")
+	}
+	year, _ := strconv.Atoi(match[1])
+	week, _ := strconv.Atoi(match[2])
+	if _, weeks := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek(); week < 1 || week > weeks {
+		return time.Time{}, fmt.Errorf("Invalid week. %d has weeks 01 to %02d", year, weeks)
+	}
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+	return monday.AddDate(0, 0, (week-1)*7), nil
+}
+
+// isoWeekName formats the ISO week containing t, such as 2025-W12
+func isoWeekName(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// agendaDate returns the day a todo is due: the bare date of all-day todos,
+// or the due date's day in loc
+func agendaDate(todo models.Todo, loc *time.Location) string {
+	if todo.AllDay {
+		return *formatEventDueDate(todo)
+	}
+	return todo.DueDate.In(loc).Format(models.DateLayout)
+}
+
+// queryAgendaTodos returns the todos selected by condition that are not
+// deferred, ordered by due date
+func queryAgendaTodos(ctx context.Context, condition string, args ...interface{}) ([]models.Todo, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+todoColumns+`
+		FROM todos
+		WHERE (`+condition+`) AND NOT `+store.DeferredSQL+`
+		ORDER BY due_date NULLS LAST, id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []models.Todo{}
+	for rows.Next() {
+		var todo models.Todo
+		if err := scanTodo(rows, &todo); err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, rows.Err()
+}
+
+// GetAgenda godoc
+// @Summary      Get a weekly agenda
+// @Description  Get the todos due in an ISO week, grouped by day in the given timezone, plus started todos without a due date under in_progress. All-day todos are listed on their date. Done todos are left out unless include_done is true. With format=html, or an Accept header preferring text/html, a printable page is returned instead, one day per page.
+// @Tags         todos
+// @Accept       json
+// @Produce      json,html
+// @Param        week          query     string  false  "ISO week such as 2025-W12 (default: the current week)"
+// @Param        tz            query     string  false  "IANA timezone the days are taken in (default: X-Timezone header, then the user's preference)"
+// @Param        include_done  query     bool    false  "Include done todos"  default(false)
+// @Param        format        query     string  false  "Response format (json, html)"
+// @Success      200  {object}  models.Agenda
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/agenda [get]
+func GetAgenda(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	format := c.Query("format")
+	switch format {
+	case "":
+		format = "json"
+		if c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+			format = "html"
+		}
+	case "json", "html":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format. Must be json or html"})
+		return
+	}
+
+	loc, err := requestLocation(c, c.Query("tz"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	week := c.DefaultQuery("week", isoWeekName(time.Now().In(loc)))
+	start, err := parseISOWeek(week, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	includeDone, err := strconv.ParseBool(c.DefaultQuery("include_done", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid include_done. Must be true or false"})
+		return
+	}
+
+	doneCondition := ``
+	if !includeDone {
+		doneCondition = ` AND status NOT IN (` + doneStatusesSQL + `)`
+	}
+	end := start.AddDate(0, 0, 7)
+
+	// All-day todos are due on their own date whatever loc is, so the range
+	// is widened by a day and todos are placed by their date below
+	due, err := queryAgendaTodos(c.Request.Context(),
+		`due_date >= $1 AND due_date < $2`+doneCondition,
+		start.AddDate(0, 0, -1).UTC(), end.AddDate(0, 0, 1).UTC())
+	if err != nil {
+		log.Printf("Error querying agenda todos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch agenda", "details": err.Error()})
+		return
+	}
+
+	// A todo has started once it has left the first status
+	inProgress, err := queryAgendaTodos(c.Request.Context(), `
+		due_date IS NULL
+		AND status NOT IN (`+doneStatusesSQL+`)
+		AND status <> (SELECT key FROM statuses ORDER BY position, key LIMIT 1)`)
+	if err != nil {
+		log.Printf("Error querying in progress todos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch agenda", "details": err.Error()})
+		return
+	}
+
+	agenda := models.Agenda{
+		Week:       week,
+		Timezone:   loc.String(),
+		StartDate:  start.Format(models.DateLayout),
+		EndDate:    end.AddDate(0, 0, -1).Format(models.DateLayout),
+		Days:       make([]models.AgendaDay, 7),
+		InProgress: inProgress,
+	}
+	dayIndex := map[string]int{}
+	for i := range agenda.Days {
+		day := start.AddDate(0, 0, i)
+		agenda.Days[i] = models.AgendaDay{Date: day.Format(models.DateLayout), Weekday: day.Weekday().String(), Todos: []models.Todo{}}
+		dayIndex[agenda.Days[i].Date] = i
+	}
+	for _, todo := range due {
+		if i, ok := dayIndex[agendaDate(todo, loc)]; ok {
+			agenda.Days[i].Todos = append(agenda.Days[i].Todos, todo)
+		}
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, agenda)
+		return
+	}
+	statuses, err := getStatuses(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching statuses: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch statuses", "details": err.Error()})
+		return
+	}
+	done := map[string]bool{}
+	for _, status := range statuses {
+		done[status.Key] = status.IsDone
+	}
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := agendaTemplate.Execute(c.Writer, agendaPage{Agenda: agenda, Done: done, Location: loc}); err != nil {
+		// Headers are already sent, so the best we can do is log
+		log.Printf("Error rendering agenda: %v", err)
+	}
+}
+
+// agendaPage is the data the printable agenda is rendered from
+type agendaPage struct {
+	models.Agenda
+	Done     map[string]bool
+	Location *time.Location
+}
+
+// Time returns the due time of a todo for the printable agenda, or "" for
+// all-day todos and todos without a due date
+func (p agendaPage) Time(todo models.Todo) string {
+	if todo.DueDate == nil || todo.AllDay {
+		return ""
+	}
+	return todo.DueDate.In(p.Location).Format("15:04")
+}
+
+// agendaTemplate renders the printable agenda. It has no scripts and only
+// inline styles so it prints the same from any browser; each day after the
+// first starts a new page.
+var agendaTemplate = template.Must(template.New("agenda").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Agenda {{.Week}}</title>
+</head>
+<body style="font-family: Helvetica, Arial, sans-serif; color: #111; margin: 24px;">
+<h1 style="font-size: 20px; margin: 0 0 4px;">Agenda {{.Week}}</h1>
+<p style="font-size: 12px; color: #555; margin: 0 0 16px;">{{.StartDate}} to {{.EndDate}} ({{.Timezone}})</p>
+{{range $i, $day := .Days}}
+<section style="{{if $i}}page-break-before: always; break-before: page; {{end}}margin-bottom: 16px;">
+<h2 style="font-size: 16px; border-bottom: 1px solid #999; padding-bottom: 4px; margin: 0 0 8px;">{{$day.Weekday}} {{$day.Date}}</h2>
+{{if $day.Todos}}<ul style="list-style: none; padding: 0; margin: 0;">
+{{range $day.Todos}}<li style="padding: 4px 0; border-bottom: 1px dotted #ccc;{{if index $.Done .Status}} text-decoration: line-through; color: #777;{{end}}">&#9744; {{with $.Time .}}<strong>{{.}}</strong> {{end}}{{.Title}}{{if .Priority}} <span style="font-size: 11px; color: #555;">[{{.Priority}}]</span>{{end}}</li>
+{{end}}</ul>{{else}}<p style="font-size: 12px; color: #777; margin: 0;">Nothing due.</p>{{end}}
+</section>
+{{end}}
+{{if .InProgress}}<section style="page-break-before: always; break-before: page;">
+<h2 style="font-size: 16px; border-bottom: 1px solid #999; padding-bottom: 4px; margin: 0 0 8px;">In progress, unscheduled</h2>
+<ul style="list-style: none; padding: 0; margin: 0;">
+{{range .InProgress}}<li style="padding: 4px 0; border-bottom: 1px dotted #ccc;">&#9744; {{.Title}}{{if .Priority}} <span style="font-size: 11px; color: #555;">[{{.Priority}}]</span>{{end}}</li>
+{{end}}</ul>
+</section>{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,94 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/router"
+	"flow-v1/backend/internal/testsupport"
+)
+
+// The agenda places timed todos on the day they fall on in the requested
+// zone and all-day todos on their own date, so todos due around Asia/Kolkata
+// and UTC midnight land on different days, or different weeks, per zone
+func TestAgendaTimezoneBoundaries(t *testing.T) {
+	pool := testsupport.UseDatabase(t)
+	ctx := context.Background()
+	due := []struct {
+		title  string
+		due    string
+		allDay bool
+		zone   *string
+	}{
+		{"before Kolkata midnight", "2025-03-10 18:29:59+00", false, nil},
+		{"Kolkata midnight", "2025-03-10 18:30:00+00", false, nil},
+		{"before UTC midnight", "2025-03-09 23:59:59+00", false, nil},
+		{"end of the UTC week", "2025-03-16 23:59:59+00", false, nil},
+		{"all-day in Kolkata", "2025-03-11 18:30:00+00", true, stringPointer("Asia/Kolkata")},
+		{"all-day in UTC", "2025-03-10 00:00:00+00", true, stringPointer("UTC")},
+	}
+	titles := map[int64]string{}
+	for _, d := range due {
+		todo := testsupport.NewTodoFactory(t, pool).WithTitle(d.title).Create(ctx)
+		if _, err := pool.Exec(ctx, `
+			UPDATE todos SET due_date = $2, all_day = $3, due_timezone = $4 WHERE id = $1
+		`, todo.ID, d.due, d.allDay, d.zone); err != nil {
+			t.Fatal(err)
+		}
+		titles[todo.ID] = d.title
+	}
+	server := testsupport.NewServer(t, router.New(router.Options{}))
+
+	tests := []struct {
+		zone string
+		want map[string]string
+	}{
+		{"Asia/Kolkata", map[string]string{
+			"before Kolkata midnight": "2025-03-10",
+			"Kolkata midnight":        "2025-03-11",
+			"before UTC midnight":     "2025-03-10",
+			"all-day in Kolkata":      "2025-03-12",
+			"all-day in UTC":          "2025-03-10",
+		}},
+		{"UTC", map[string]string{
+			"before Kolkata midnight": "2025-03-10",
+			"Kolkata midnight":        "2025-03-10",
+			"end of the UTC week":     "2025-03-16",
+			"all-day in Kolkata":      "2025-03-12",
+			"all-day in UTC":          "2025-03-10",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.zone, func(t *testing.T) {
+			var agenda models.Agenda
+			server.JSON(http.MethodGet, router.BasePath+"/todos/agenda?week=2025-W11&tz="+tt.zone, nil, http.StatusOK, &agenda)
+			if agenda.StartDate != "2025-03-10" || agenda.EndDate != "2025-03-16" || agenda.Timezone != tt.zone {
+				t.Errorf("agenda %s to %s in %s, want 2025-03-10 to 2025-03-16 in %s", agenda.StartDate, agenda.EndDate, agenda.Timezone, tt.zone)
+			}
+			got := map[string]string{}
+			for _, day := range agenda.Days {
+				for _, todo := range day.Todos {
+					if title, ok := titles[todo.ID]; ok {
+						got[title] = day.Date
+					}
+				}
+			}
+			for title, date := range tt.want {
+				if got[title] != date {
+					t.Errorf("%q listed on %q, want %s", title, got[title], date)
+				}
+			}
+			for title, date := range got {
+				if _, ok := tt.want[title]; !ok {
+					t.Errorf("%q listed on %s, want it outside the week", title, date)
+				}
+			}
+		})
+	}
+}
+
+func stringPointer(s string) *string {
+	return &s
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/store"
+)
+
+// systemActor is recorded as the actor of changes made by background jobs
+const systemActor = "system"
+
+// recordAudit writes an audit entry for a change in the caller's transaction
+// with store.RecordAudit. The entry is attributed to the requesting user, or
+// to systemActor when c is nil for a change made outside a request.
+func recordAudit(ctx context.Context, tx pgx.Tx, c *gin.Context, action, entityType string, entityID int64, before, after interface{}) error {
+	source := store.AuditSource{Actor: systemActor}
+	if c != nil {
+		source = store.AuditSource{Actor: currentUser(c), RequestID: c.GetHeader("X-Request-ID"), IP: c.ClientIP()}
+	}
+	return store.RecordAudit(ctx, tx, source, action, entityType, entityID, before, after)
+}
+
+// buildAuditFilters translates the audit query parameters into a WHERE clause
+func buildAuditFilters(c *gin.Context) (string, []interface{}, error) {
+	whereConditions := []string{}
+	queryArgs := []interface{}{}
+	argIndex := 1
+
+	for _, filter := range []struct {
+		param  string
+		column string
+	}{
+		{"entity_type", "entity_type"},
+		{"actor", "actor"},
+		{"action", "action"},
+	} {
+		if value := c.Query(filter.param); value != "" {
+			whereConditions = append(whereConditions, filter.column+" = $"+strconv.Itoa(argIndex))
+			queryArgs = append(queryArgs, value)
+			argIndex++
+		}
+	}
+
+	if entityIDStr := c.Query("entity_id"); entityIDStr != "" {
+		entityID, err := strconv.ParseInt(entityIDStr, 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("Invalid entity_id")
+		}
+		whereConditions = append(whereConditions, "entity_id = $"+strconv.Itoa(argIndex))
+		queryArgs = append(queryArgs, entityID)
+		argIndex++
+	}
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			return "", nil, fmt.Errorf("Invalid from timestamp, expected RFC3339")
+		}
+		whereConditions = append(whereConditions, "created_at >= $"+strconv.Itoa(argIndex))
+		queryArgs = append(queryArgs, from)
+		argIndex++
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			return "", nil, fmt.Errorf("Invalid to timestamp, expected RFC3339")
+		}
+		whereConditions = append(whereConditions, "created_at <= $"+strconv.Itoa(argIndex))
+		queryArgs = append(queryArgs, to)
+	}
+
+	whereClause := ""
+	if len(whereConditions) > 0 {
+		whereClause = "WHERE " + strings.Join(whereConditions, " AND ")
+	}
+	return whereClause, queryArgs, nil
+}
+
+// GetAuditLog godoc
+// @Summary      List audit log entries
+// @Description  Get a paginated list of recorded mutations, newest first. The total number of matching entries is returned in the X-Total-Count header. Requires the admin token.
+// @Tags         audit
+// @Accept       json
+// @Produce      json
+// @Security     AdminToken
+// @Param        entity_type  query     string  false  "Filter by entity type (todo, subtask, sprint, epic, link)"
+// @Param        entity_id    query     int     false  "Filter by entity ID"
+// @Param        actor        query     string  false  "Filter by actor"
+// @Param        action       query     string  false  "Filter by action (create, update, delete)"
+// @Param        from         query     string  false  "Only entries at or after this RFC3339 timestamp"
+// @Param        to           query     string  false  "Only entries at or before this RFC3339 timestamp"
+// @Param        limit        query     int     false  "Page size (max 200)"  default(50)
+// @Param        offset       query     int     false  "Number of entries to skip"  default(0)
+// @Param        envelope     query     bool    false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.AuditEntry  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /audit [get]
+func GetAuditLog(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	whereClause, queryArgs, err := buildAuditFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit. Must be between 1 and 200"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return
+	}
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var total int64
+	if err := db.Pool.QueryRow(c.Request.Context(), `SELECT COUNT(*) FROM audit_log `+whereClause, queryArgs...).Scan(&total); err != nil {
+		log.Printf("Error counting audit log: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count audit log", "details": err.Error()})
+		return
+	}
+
+	limitIndex := len(queryArgs) + 1
+	rows, err := db.Pool.Query(c.Request.Context(), `
+		SELECT id, actor, action, entity_type, entity_id, diff, diff_truncated, request_id, ip, created_at
+		FROM audit_log
+		`+whereClause+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $`+strconv.Itoa(limitIndex)+` OFFSET $`+strconv.Itoa(limitIndex+1),
+		append(queryArgs, limit, offset)...)
+	if err != nil {
+		log.Printf("Error querying audit log: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		if err := rows.Scan(&entry.ID, &entry.Actor, &entry.Action, &entry.EntityType, &entry.EntityID, &entry.Diff, &entry.DiffTruncated, &entry.RequestID, &entry.IP, &entry.CreatedAt); err != nil {
+			log.Printf("Error scanning audit entry: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan audit entry", "details": err.Error()})
+			return
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating audit log: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating audit log", "details": err.Error()})
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	respondList(c, envelope, entries, total, limit, offset)
+}
+
+// ExportAuditLog godoc
+// @Summary      Export audit log entries as CSV
+// @Description  Stream every audit log entry matching the filters as a CSV file, newest first. Requires the admin token.
+// @Tags         audit
+// @Produce      text/csv
+// @Security     AdminToken
+// @Param        entity_type  query     string  false  "Filter by entity type (todo, subtask, sprint, epic, link)"
+// @Param        entity_id    query     int     false  "Filter by entity ID"
+// @Param        actor        query     string  false  "Filter by actor"
+// @Param        action       query     string  false  "Filter by action (create, update, delete)"
+// @Param        from         query     string  false  "Only entries at or after this RFC3339 timestamp"
+// @Param        to           query     string  false  "Only entries at or before this RFC3339 timestamp"
+// @Success      200  {string}  string  "CSV file"
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /audit/export [get]
+func ExportAuditLog(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	whereClause, queryArgs, err := buildAuditFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := db.Pool.Query(c.Request.Context(), `
+		SELECT id, COALESCE(actor, ''), action, entity_type, entity_id, COALESCE(diff::text, ''), diff_truncated,
+		       COALESCE(request_id, ''), COALESCE(ip, ''), created_at
+		FROM audit_log
+		`+whereClause+`
+		ORDER BY created_at DESC, id DESC
+	`, queryArgs...)
+	if err != nil {
+		log.Printf("Error querying audit log: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="audit_log.csv"`)
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"id", "actor", "action", "entity_type", "entity_id", "diff", "diff_truncated", "request_id", "ip", "created_at"})
+	for rows.Next() {
+		var (
+			id, entityID                    int64
+			actor, action, entityType, diff string
+			requestID, ip                   string
+			diffTruncated                   bool
+			createdAt                       time.Time
+		)
+		if err := rows.Scan(&id, &actor, &action, &entityType, &entityID, &diff, &diffTruncated, &requestID, &ip, &createdAt); err != nil {
+			// Headers are already sent, so the best we can do is log and stop
+			log.Printf("Error scanning audit entry for export: %v", err)
+			break
+		}
+		writer.Write([]string{
+			strconv.FormatInt(id, 10), actor, action, entityType, strconv.FormatInt(entityID, 10), diff,
+			strconv.FormatBool(diffTruncated), requestID, ip, createdAt.Format(time.RFC3339),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating audit log for export: %v", err)
+	}
+	writer.Flush()
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/store"
+)
+
+// badgesCacheTTL is how long badge counts are served from memory
+const badgesCacheTTL = 30 * time.Second
+
+// badgesAllDayDateSQL is the date an all-day todo is due on, in the zone it
+// was set in
+const badgesAllDayDateSQL = `((due_date AT TIME ZONE 'UTC') AT TIME ZONE COALESCE(due_timezone, 'UTC'))::date`
+
+// badgesCache holds badge counts per user and requested timezone
+var badgesCache = struct {
+	sync.Mutex
+	entries map[string]badgesCacheEntry
+}{entries: map[string]badgesCacheEntry{}}
+
+type badgesCacheEntry struct {
+	badges    models.Badges
+	expiresAt time.Time
+}
+
+// GetBadges godoc
+// @Summary      Get navigation badge counts
+// @Description  Count the open todos that are overdue and those due later today, where today is taken in the tz parameter, the X-Timezone header or the timezone preference. Counts are cached for 30 seconds per user and timezone, so they may lag behind changes. Send the ETag in If-None-Match to get a 304 while they are unchanged.
+// @Tags         preferences
+// @Produce      json
+// @Param        tz   query     string  false  "IANA timezone today is taken in"
+// @Success      200  {object}  models.Badges
+// @Success      304  "Not Modified"
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /me/badges [get]
+func GetBadges(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	// Keyed by what the timezone is taken from, so a hit needs no
+	// preference lookup
+	key := currentUser(c) + "\x00" + c.Query("tz") + "\x00" + c.GetHeader("X-Timezone")
+	now := time.Now()
+	badgesCache.Lock()
+	entry, ok := badgesCache.entries[key]
+	badgesCache.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		respondResource(c, entry.badges)
+		return
+	}
+
+	loc, err := requestLocation(c, c.Query("tz"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	// All-day todos are due on their own date whatever loc is, so the
+	// indexed range is widened by a day and they are compared by date
+	badges := models.Badges{Timezone: loc.String()}
+	if err := db.Pool.QueryRow(c.Request.Context(), `
+		SELECT
+			COUNT(*) FILTER (WHERE CASE WHEN all_day THEN `+badgesAllDayDateSQL+` < $1::date ELSE due_date < $2 END),
+			COUNT(*) FILTER (WHERE CASE WHEN all_day THEN `+badgesAllDayDateSQL+` = $1::date ELSE due_date >= $2 AND due_date < $3 END)
+		FROM todos
+		WHERE due_date < $4 AND status NOT IN (`+doneStatusesSQL+`) AND NOT `+store.DeferredSQL+`
+	`, today.Format(models.DateLayout), now.UTC(), tomorrow.UTC(), tomorrow.AddDate(0, 0, 1).UTC()).Scan(&badges.Overdue, &badges.DueToday); err != nil {
+		log.Printf("Error counting badges: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count badges", "details": err.Error()})
+		return
+	}
+
+	badgesCache.Lock()
+	for cached, entry := range badgesCache.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(badgesCache.entries, cached)
+		}
+	}
+	badgesCache.entries[key] = badgesCacheEntry{badges: badges, expiresAt: now.Add(badgesCacheTTL)}
+	badgesCache.Unlock()
+
+	respondResource(c, badges)
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// businessMetricsTTL is how long business metrics are served before they
+// are computed again
+const businessMetricsTTL = time.Minute
+
+// businessMetricsColdWait bounds how long a request waits for metrics when
+// none have been computed yet
+const businessMetricsColdWait = time.Second
+
+// businessMetricsTimeout bounds computing the metrics
+const businessMetricsTimeout = 30 * time.Second
+
+// businessMetricsWorkspaces is how many workspaces get their own label; the
+// rest are summed under "other"
+const businessMetricsWorkspaces = 10
+
+// businessMetricsContentType is the Prometheus text exposition format
+const businessMetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// businessMetrics holds the latest rendered business metrics. refreshing is
+// closed when the running computation ends, and nil when none is running.
+var businessMetrics struct {
+	sync.Mutex
+	body       []byte
+	computedAt time.Time
+	err        error
+	refreshing chan struct{}
+}
+
+// refreshBusinessMetrics computes the business metrics and stores them
+func refreshBusinessMetrics(done chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), businessMetricsTimeout)
+	defer cancel()
+	body, err := computeBusinessMetrics(ctx)
+	if err != nil {
+		log.Printf("Error computing business metrics: %v", err)
+	}
+
+	businessMetrics.Lock()
+	if err == nil {
+		businessMetrics.body, businessMetrics.computedAt = body, time.Now()
+	}
+	businessMetrics.err = err
+	businessMetrics.refreshing = nil
+	businessMetrics.Unlock()
+	close(done)
+}
+
+// computeBusinessMetrics runs the aggregate queries behind the business
+// metrics and renders them in the Prometheus text format. Days are UTC days.
+func computeBusinessMetrics(ctx context.Context) ([]byte, error) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	var out bytes.Buffer
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT s.key, COUNT(t.id)
+		FROM statuses s
+		LEFT JOIN todos t ON t.status = s.key
+		WHERE NOT s.is_done
+		GROUP BY s.key, s.position
+		ORDER BY s.position, s.key
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count open todos: %w", err)
+	}
+	writeMetricHeader(&out, "flow_todos_open", "Todos in a status that is not done, by status.")
+	for rows.Next() {
+		var status string
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan open todos: %w", err)
+		}
+		fmt.Fprintf(&out, "flow_todos_open{status=\"%s\"} %d\n", escapeLabelValue(status), count)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count open todos: %w", err)
+	}
+
+	var overdue, createdToday, completedToday int64
+	if err := db.Pool.QueryRow(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE due_date IS NOT NULL AND `+todoDeadlineSQL+` < $1 AND status NOT IN (`+doneStatusesSQL+`)),
+			COUNT(*) FILTER (WHERE created_at >= $2),
+			COUNT(*) FILTER (WHERE completed_at >= $2)
+		FROM todos
+	`, now, today).Scan(&overdue, &createdToday, &completedToday); err != nil {
+		return nil, fmt.Errorf("failed to count todos: %w", err)
+	}
+	writeMetricHeader(&out, "flow_todos_overdue", "Todos past their due date and not done.")
+	fmt.Fprintf(&out, "flow_todos_overdue %d\n", overdue)
+	writeMetricHeader(&out, "flow_todos_created_today", "Todos created since midnight UTC.")
+	fmt.Fprintf(&out, "flow_todos_created_today %d\n", createdToday)
+	writeMetricHeader(&out, "flow_todos_completed_today", "Todos completed since midnight UTC.")
+	fmt.Fprintf(&out, "flow_todos_completed_today %d\n", completedToday)
+
+	rows, err = db.Pool.Query(ctx, `
+		SELECT workspace, SUM(requests)::bigint
+		FROM api_usage
+		WHERE day = $1
+		GROUP BY workspace
+		ORDER BY 2 DESC, workspace
+	`, today.Format(models.DateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum API usage: %w", err)
+	}
+	writeMetricHeader(&out, "flow_api_requests_today", "API requests since midnight UTC, by workspace; the workspaces with fewer are summed as other.")
+	var other int64
+	for i := 0; rows.Next(); i++ {
+		var workspace string
+		var requests int64
+		if err := rows.Scan(&workspace, &requests); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan API usage: %w", err)
+		}
+		if i >= businessMetricsWorkspaces {
+			other += requests
+			continue
+		}
+		fmt.Fprintf(&out, "flow_api_requests_today{workspace=\"%s\"} %d\n", escapeLabelValue(workspace), requests)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to sum API usage: %w", err)
+	}
+	if other > 0 {
+		fmt.Fprintf(&out, "flow_api_requests_today{workspace=\"other\"} %d\n", other)
+	}
+
+	return out.Bytes(), nil
+}
+
+// writeMetricHeader writes the HELP and TYPE lines of a gauge
+func writeMetricHeader(out *bytes.Buffer, name, help string) {
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
+
+// labelValueEscaper escapes a Prometheus label value
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue escapes s for use as a quoted label value
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+// GetBusinessMetrics godoc
+// @Summary      Get business metrics
+// @Description  Get business numbers as Prometheus gauges: open todos by status, overdue todos, todos created and completed today, and today's API requests for the 10 busiest workspaces plus other. Days are UTC days. Metrics are cached for a minute; a stale snapshot is served while a new one is computed, and a 503 with Retry-After is returned if none is ready within a second. Requires the admin token.
+// @Tags         admin
+// @Produce      plain
+// @Security     AdminToken
+// @Success      200  {string}  string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /metrics/business [get]
+func GetBusinessMetrics(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	businessMetrics.Lock()
+	body := businessMetrics.body
+	if body == nil || time.Since(businessMetrics.computedAt) >= businessMetricsTTL {
+		if businessMetrics.refreshing == nil {
+			businessMetrics.refreshing = make(chan struct{})
+			go refreshBusinessMetrics(businessMetrics.refreshing)
+		}
+	}
+	refreshing := businessMetrics.refreshing
+	businessMetrics.Unlock()
+
+	if body == nil {
+		select {
+		case <-refreshing:
+		case <-time.After(businessMetricsColdWait):
+			c.Header("Retry-After", "1")
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Business metrics are being computed"})
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+		businessMetrics.Lock()
+		body = businessMetrics.body
+		err := businessMetrics.err
+		businessMetrics.Unlock()
+		if body == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute business metrics", "details": err.Error()})
+			return
+		}
+	}
+
+	c.Data(http.StatusOK, businessMetricsContentType, body)
+}
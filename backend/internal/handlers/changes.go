@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// GetTodoChangesCount godoc
+// @Summary      Count todo changes
+// @Description  Count the todos created, updated or deleted after since, for an unread badge polled every few seconds. latest_change_at is the newest of those changes; send it as since after refreshing the list. The response may be cached for 5 seconds and carries an ETag, so an unchanged count answers 304.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        since  query     string  true  "RFC3339 timestamp, usually a previous latest_change_at"
+// @Success      200    {object}  models.TodoChanges
+// @Success      304    "Not Modified"
+// @Failure      400    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /todos/changes-count [get]
+func GetTodoChangesCount(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	since, err := time.Parse(time.RFC3339Nano, c.Query("since"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since. Use an RFC3339 timestamp"})
+		return
+	}
+
+	// Both halves are range scans on their updated_at and deleted_at indexes
+	var changes models.TodoChanges
+	if err := db.Pool.QueryRow(c.Request.Context(), `
+		SELECT
+			(SELECT COUNT(*) FROM todos WHERE updated_at > $1) + (SELECT COUNT(*) FROM todo_tombstones WHERE deleted_at > $1),
+			GREATEST((SELECT MAX(updated_at) FROM todos WHERE updated_at > $1), (SELECT MAX(deleted_at) FROM todo_tombstones WHERE deleted_at > $1))
+	`, since).Scan(&changes.Count, &changes.LatestChangeAt); err != nil {
+		log.Printf("Error counting todo changes: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count todo changes", "details": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=5")
+	respondResource(c, changes)
+}
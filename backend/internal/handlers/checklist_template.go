@@ -0,0 +1,495 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// checklistTemplateColumns is the column list selected by every checklist
+// template query; scanChecklistTemplate reads it back
+const checklistTemplateColumns = `id, name, items, created_at, updated_at`
+
+// scanChecklistTemplate scans a row selected with checklistTemplateColumns into template
+func scanChecklistTemplate(row pgx.Row, template *models.ChecklistTemplate) error {
+	return row.Scan(&template.ID, &template.Name, &template.Items, &template.CreatedAt, &template.UpdatedAt)
+}
+
+// errTodoNotFound and errChecklistTemplateNotFound tell apart the two lookups
+// that can fail with pgx.ErrNoRows when applying a template
+var (
+	errTodoNotFound              = errors.New("todo not found")
+	errChecklistTemplateNotFound = errors.New("checklist template not found")
+)
+
+// GetChecklistTemplates godoc
+// @Summary      List checklist templates
+// @Description  Get all checklist templates, ordered by name
+// @Tags         checklist-templates
+// @Accept       json
+// @Produce      json
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.ChecklistTemplate  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /checklist-templates [get]
+func GetChecklistTemplates(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := db.Pool.Query(c.Request.Context(), `
+		SELECT `+checklistTemplateColumns+` FROM checklist_templates ORDER BY name
+	`)
+	if err != nil {
+		log.Printf("Error querying checklist templates: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch checklist templates", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	templates := []models.ChecklistTemplate{}
+	for rows.Next() {
+		var template models.ChecklistTemplate
+		if err := scanChecklistTemplate(rows, &template); err != nil {
+			log.Printf("Error scanning checklist template: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan checklist template", "details": err.Error()})
+			return
+		}
+		templates = append(templates, template)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating checklist templates: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating checklist templates", "details": err.Error()})
+		return
+	}
+
+	respondList(c, envelope, templates, int64(len(templates)), 0, 0)
+}
+
+// GetChecklistTemplate godoc
+// @Summary      Get a checklist template
+// @Description  Get a single checklist template by its ID
+// @Tags         checklist-templates
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Checklist template ID"
+// @Success      200  {object}  models.ChecklistTemplate
+// @Success      304  "Not Modified"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /checklist-templates/{id} [get]
+func GetChecklistTemplate(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid checklist template ID"})
+		return
+	}
+
+	var template models.ChecklistTemplate
+	err = scanChecklistTemplate(db.Pool.QueryRow(c.Request.Context(), `
+		SELECT `+checklistTemplateColumns+` FROM checklist_templates WHERE id = $1
+	`, id), &template)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Checklist template not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error querying checklist template: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch checklist template", "details": err.Error()})
+		return
+	}
+
+	respondResource(c, template)
+}
+
+// CreateChecklistTemplate godoc
+// @Summary      Create a checklist template
+// @Description  Create a named, ordered list of subtask titles that can be applied to any todo
+// @Tags         checklist-templates
+// @Accept       json
+// @Produce      json
+// @Param        template  body      models.CreateChecklistTemplateRequest  true  "Template data"
+// @Success      201       {object}  models.ChecklistTemplate
+// @Header       201       {string}  Location  "URL of the created resource"
+// @Header       201       {string}  ETag      "Strong ETag of the created resource"
+// @Failure      400       {object}  map[string]string
+// @Failure      409       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /checklist-templates [post]
+func CreateChecklistTemplate(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.CreateChecklistTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be blank"})
+		return
+	}
+
+	var template models.ChecklistTemplate
+	err := scanChecklistTemplate(db.Pool.QueryRow(c.Request.Context(), `
+		INSERT INTO checklist_templates (name, items, created_at, updated_at)
+		VALUES ($1, $2, NOW(), NOW())
+		RETURNING `+checklistTemplateColumns+`
+	`, name, req.Items), &template)
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A checklist template with this name already exists"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating checklist template: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create checklist template", "details": err.Error()})
+		return
+	}
+
+	respondCreated(c, fmt.Sprintf("/checklist-templates/%d", template.ID), template)
+}
+
+// UpdateChecklistTemplate godoc
+// @Summary      Update a checklist template
+// @Description  Rename a checklist template or replace its items. Todos it was applied to keep their subtasks.
+// @Tags         checklist-templates
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int  true  "Checklist template ID"
+// @Param        template  body      models.UpdateChecklistTemplateRequest  true  "Template changes"
+// @Success      200       {object}  models.ChecklistTemplate
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      409       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /checklist-templates/{id} [put]
+func UpdateChecklistTemplate(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid checklist template ID"})
+		return
+	}
+
+	var req models.UpdateChecklistTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var template models.ChecklistTemplate
+	err = scanChecklistTemplate(db.Pool.QueryRow(c.Request.Context(), `
+		UPDATE checklist_templates
+		SET name = COALESCE(NULLIF($1, ''), name),
+		    items = COALESCE($2, items),
+		    updated_at = NOW()
+		WHERE id = $3
+		RETURNING `+checklistTemplateColumns+`
+	`, strings.TrimSpace(req.Name), req.Items, id), &template)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Checklist template not found"})
+		return
+	}
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A checklist template with this name already exists"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating checklist template: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update checklist template", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteChecklistTemplate godoc
+// @Summary      Delete a checklist template
+// @Description  Delete a checklist template. Subtasks created from it are kept.
+// @Tags         checklist-templates
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Checklist template ID"
+// @Success      204  "No Content"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /checklist-templates/{id} [delete]
+func DeleteChecklistTemplate(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid checklist template ID"})
+		return
+	}
+
+	result, err := db.Pool.Exec(c.Request.Context(), `DELETE FROM checklist_templates WHERE id = $1`, id)
+	if err != nil {
+		log.Printf("Error deleting checklist template: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete checklist template", "details": err.Error()})
+		return
+	}
+	if result.RowsAffected() == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Checklist template not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ApplyChecklistTemplate godoc
+// @Summary      Apply a checklist template to a todo
+// @Description  Append the template's items to the todo as uncompleted subtasks, after its existing subtasks and in template order. With skip_existing=true, items whose title the todo already has (ignoring case and surrounding spaces) are left out. Returns the todo's full subtask list. A done todo gets a 409 while the lock_done_todos policy is on, unless reopen=true moves it out of done first.
+// @Tags         subtasks
+// @Accept       json
+// @Produce      json
+// @Param        id             path      int   true   "Todo ID"
+// @Param        templateId     path      int   true   "Checklist template ID"
+// @Param        skip_existing  query     bool  false  "Skip items the todo already has a subtask for"
+// @Param        reopen         query     bool  false  "Move a done todo to the first status that is not done first"
+// @Success      200            {array}   models.Subtask
+// @Failure      400            {object}  map[string]string
+// @Failure      404            {object}  map[string]string
+// @Failure      409            {object}  map[string]interface{}
+// @Failure      500            {object}  map[string]string
+// @Router       /todos/{id}/subtasks/apply-template/{templateId} [post]
+func ApplyChecklistTemplate(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	templateID, err := strconv.ParseInt(c.Param("templateId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid checklist template ID"})
+		return
+	}
+	skipExisting := c.Query("skip_existing") == "true"
+	reopen, err := parseReopen(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var subtasks []models.Subtask
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		// Locking the todo serializes concurrent applies, so skip_existing
+		// sees the subtasks another apply just added
+		if err := unlockTodoSubtasks(ctx, tx, c, todoID, reopen); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errTodoNotFound
+			}
+			return err
+		}
+
+		var template models.ChecklistTemplate
+		if err := scanChecklistTemplate(tx.QueryRow(ctx, `
+			SELECT `+checklistTemplateColumns+` FROM checklist_templates WHERE id = $1
+		`, templateID), &template); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errChecklistTemplateNotFound
+			}
+			return err
+		}
+
+		existing, err := loadSubtasks(ctx, tx, todoID)
+		if err != nil {
+			return err
+		}
+		seen := map[string]bool{}
+		for _, subtask := range existing {
+			seen[strings.ToLower(strings.TrimSpace(subtask.Title))] = true
+		}
+
+		added := 0
+		for _, title := range template.Items {
+			key := strings.ToLower(strings.TrimSpace(title))
+			if skipExisting && seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			// clock_timestamp keeps the items in template order, since
+			// subtasks are listed by created_at
+			var subtask models.Subtask
+			if err := scanSubtask(tx.QueryRow(ctx, `
+				INSERT INTO subtasks (todo_id, title, completed, created_at, updated_at)
+				VALUES ($1, $2, FALSE, clock_timestamp(), clock_timestamp())
+				RETURNING `+subtaskColumns+`
+			`, todoID, title), &subtask); err != nil {
+				return err
+			}
+			if err := recordSubtaskEvent(ctx, tx, models.TodoEventSubtaskAdded, subtask); err != nil {
+				return err
+			}
+			if err := recordAudit(ctx, tx, c, models.AuditActionCreate, models.AuditEntitySubtask, subtask.ID, nil, subtask); err != nil {
+				return err
+			}
+			added++
+		}
+		if err := adjustSubtaskCounts(ctx, tx, todoID, added, 0); err != nil {
+			return err
+		}
+
+		subtasks, err = loadSubtasks(ctx, tx, todoID)
+		return err
+	})
+	if errors.Is(err, errTodoNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	if errors.Is(err, errChecklistTemplateNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Checklist template not found"})
+		return
+	}
+	if respondTransitionError(c, err) {
+		return
+	}
+	if respondDoneLockedError(c, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error applying checklist template: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply checklist template", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, subtasks)
+}
+
+// SaveSubtasksAsTemplate godoc
+// @Summary      Save a todo's subtasks as a checklist template
+// @Description  Create a checklist template whose items are the titles of the todo's subtasks, in order. Completion is not saved.
+// @Tags         subtasks
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int  true  "Todo ID"
+// @Param        template  body      models.SaveChecklistTemplateRequest  true  "Template name"
+// @Success      201       {object}  models.ChecklistTemplate
+// @Header       201       {string}  Location  "URL of the created resource"
+// @Header       201       {string}  ETag      "Strong ETag of the created resource"
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      409       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /todos/{id}/subtasks/save-as-template [post]
+func SaveSubtasksAsTemplate(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	var req models.SaveChecklistTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be blank"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var todoExists bool
+	err = db.Pool.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM todos WHERE id = $1)
+	`, todoID).Scan(&todoExists)
+	if err != nil {
+		log.Printf("Error checking todo existence: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify todo", "details": err.Error()})
+		return
+	}
+	if !todoExists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+
+	subtasks, err := loadSubtasks(ctx, db.Pool, todoID)
+	if err != nil {
+		log.Printf("Error querying subtasks: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subtasks", "details": err.Error()})
+		return
+	}
+	if len(subtasks) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Todo has no subtasks to save"})
+		return
+	}
+	items := make([]string, len(subtasks))
+	for i, subtask := range subtasks {
+		items[i] = subtask.Title
+	}
+
+	var template models.ChecklistTemplate
+	err = scanChecklistTemplate(db.Pool.QueryRow(ctx, `
+		INSERT INTO checklist_templates (name, items, created_at, updated_at)
+		VALUES ($1, $2, NOW(), NOW())
+		RETURNING `+checklistTemplateColumns+`
+	`, name, items), &template)
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A checklist template with this name already exists"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating checklist template: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create checklist template", "details": err.Error()})
+		return
+	}
+
+	respondCreated(c, fmt.Sprintf("/checklist-templates/%d", template.ID), template)
+}
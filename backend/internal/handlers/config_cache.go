@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// configCacheTTL bounds how stale a configCache can be when another instance
+// changes the underlying table
+const configCacheTTL = time.Minute
+
+// configCache caches the rows of a small configuration table, such as the
+// statuses or priorities. Handlers that change the table reset it; otherwise
+// it is reloaded after ttl, or configCacheTTL when ttl is zero.
+type configCache[T any] struct {
+	load func(ctx context.Context) ([]T, error)
+	ttl  time.Duration
+
+	mu       sync.RWMutex
+	items    []T
+	loadedAt time.Time
+}
+
+// get returns the cached rows, loading them if the cache is empty or stale
+func (c *configCache[T]) get(ctx context.Context) ([]T, error) {
+	ttl := c.ttl
+	if ttl == 0 {
+		ttl = configCacheTTL
+	}
+	c.mu.RLock()
+	if c.items != nil && time.Since(c.loadedAt) < ttl {
+		items := c.items
+		c.mu.RUnlock()
+		return items, nil
+	}
+	c.mu.RUnlock()
+
+	items, err := c.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.items = items
+	c.loadedAt = time.Now()
+	c.mu.Unlock()
+	return items, nil
+}
+
+// reset drops the cached rows so the next get reloads them
+func (c *configCache[T]) reset() {
+	c.mu.Lock()
+	c.items = nil
+	c.mu.Unlock()
+}
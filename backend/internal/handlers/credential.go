@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/secrets"
+	"flow-v1/backend/internal/store"
+)
+
+// credentialName returns the name path parameter when it is a known
+// credential, answering 404 otherwise
+func credentialName(c *gin.Context) (string, bool) {
+	name := c.Param("name")
+	if !slices.Contains(store.Credentials, name) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown credential. Use one of: " + strings.Join(store.Credentials, ", ")})
+		return "", false
+	}
+	return name, true
+}
+
+// ListCredentials godoc
+// @Summary      List integration credentials
+// @Description  Report which integration credentials are configured, and whether by environment variable, which overrides the stored value. Values are never returned. Requires the admin token.
+// @Tags         admin
+// @Produce      json
+// @Security     AdminToken
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.Credential  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/credentials [get]
+func ListCredentials(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates, err := store.CredentialUpdates(c.Request.Context(), db.Pool)
+	if err != nil {
+		log.Printf("Error fetching credentials: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch credentials", "details": err.Error()})
+		return
+	}
+
+	credentials := make([]models.Credential, 0, len(store.Credentials))
+	for _, name := range store.Credentials {
+		credential := models.Credential{Name: name, FromEnv: os.Getenv(strings.ToUpper(name)) != ""}
+		if updatedAt, ok := updates[name]; ok {
+			credential.UpdatedAt = &updatedAt
+		}
+		credential.Configured = credential.FromEnv || credential.UpdatedAt != nil
+		credentials = append(credentials, credential)
+	}
+	respondList(c, envelope, credentials, int64(len(credentials)), 0, 0)
+}
+
+// SetCredential godoc
+// @Summary      Store an integration credential
+// @Description  Store github_token or github_webhook_secret, encrypted with the current ENCRYPTION_KEYS key, replacing any stored value. The environment variable of the same name in upper case still takes precedence. Without an encryption key nothing is stored and the response is 503. Requires the admin token.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     AdminToken
+// @Param        name        path      string                       true  "Credential name"
+// @Param        credential  body      models.SetCredentialRequest  true  "Credential value"
+// @Success      200         {object}  models.Credential
+// @Failure      400         {object}  map[string]string
+// @Failure      401         {object}  map[string]string
+// @Failure      403         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Failure      503         {object}  map[string]string
+// @Router       /admin/credentials/{name} [put]
+func SetCredential(c *gin.Context) {
+	// The body is the secret itself, so it is never logged rather than left
+	// to the redact keys
+	omitRequestBody(c)
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	name, ok := credentialName(c)
+	if !ok {
+		return
+	}
+	var req models.SetCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updatedAt, err := store.SetCredential(c.Request.Context(), db.Pool, name, req.Value)
+	if errors.Is(err, secrets.ErrNoKey) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Credentials cannot be stored without an encryption key. Set ENCRYPTION_KEYS"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error storing credential %s: %v", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store credential", "details": err.Error()})
+		return
+	}
+	log.Printf("Credential %s stored", name)
+
+	c.JSON(http.StatusOK, models.Credential{
+		Name:       name,
+		Configured: true,
+		FromEnv:    os.Getenv(strings.ToUpper(name)) != "",
+		UpdatedAt:  &updatedAt,
+	})
+}
+
+// DeleteCredential godoc
+// @Summary      Delete a stored integration credential
+// @Description  Remove a stored credential. Its environment variable, if set, still applies. Requires the admin token.
+// @Tags         admin
+// @Produce      json
+// @Security     AdminToken
+// @Param        name  path  string  true  "Credential name"
+// @Success      204
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/credentials/{name} [delete]
+func DeleteCredential(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	name, ok := credentialName(c)
+	if !ok {
+		return
+	}
+	deleted, err := store.DeleteCredential(c.Request.Context(), db.Pool, name)
+	if err != nil {
+		log.Printf("Error deleting credential %s: %v", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete credential", "details": err.Error()})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Credential " + name + " is not stored"})
+		return
+	}
+	log.Printf("Credential %s deleted", name)
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,440 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// customFieldColumns is the column list selected by every custom field query; scanCustomField reads it back
+const customFieldColumns = `id, name, type, options, required, created_at, updated_at`
+
+// scanCustomField scans a row selected with customFieldColumns into field
+func scanCustomField(row pgx.Row, field *models.CustomFieldDefinition) error {
+	return row.Scan(&field.ID, &field.Name, &field.Type, &field.Options, &field.Required, &field.CreatedAt, &field.UpdatedAt)
+}
+
+// customFieldNamePattern matches custom field names, which also appear in
+// cf.<name> query parameters
+var customFieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// customFieldFilterPrefix marks GET /todos query parameters that filter on custom fields
+const customFieldFilterPrefix = "cf."
+
+// loadCustomFields returns the custom field definitions keyed by name
+func loadCustomFields(ctx context.Context) (map[string]models.CustomFieldDefinition, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT `+customFieldColumns+` FROM custom_field_definitions`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load custom fields: %w", err)
+	}
+	fields, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.CustomFieldDefinition, error) {
+		var field models.CustomFieldDefinition
+		err := scanCustomField(row, &field)
+		return field, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load custom fields: %w", err)
+	}
+
+	byName := make(map[string]models.CustomFieldDefinition, len(fields))
+	for _, field := range fields {
+		byName[field.Name] = field
+	}
+	return byName, nil
+}
+
+// coerceCustomFieldValue converts a submitted value to the stored form for
+// the field's type: numbers as JSON numbers, dates as YYYY-MM-DD strings
+func coerceCustomFieldValue(field models.CustomFieldDefinition, value interface{}) (interface{}, error) {
+	switch field.Type {
+	case models.CustomFieldNumber:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case string:
+			if n, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return n, nil
+			}
+		}
+		return nil, fmt.Errorf("Custom field %s must be a number", field.Name)
+	case models.CustomFieldDate:
+		if v, ok := value.(string); ok {
+			if t, err := time.Parse(dateLayout, v); err == nil {
+				return t.Format(dateLayout), nil
+			}
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				return t.Format(dateLayout), nil
+			}
+		}
+		return nil, fmt.Errorf("Custom field %s must be a date (YYYY-MM-DD)", field.Name)
+	case models.CustomFieldSelect:
+		if v, ok := value.(string); ok {
+			for _, option := range field.Options {
+				if v == option {
+					return v, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("Custom field %s must be one of: %s", field.Name, strings.Join(field.Options, ", "))
+	default:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case float64, bool:
+			return fmt.Sprint(v), nil
+		}
+		return nil, fmt.Errorf("Custom field %s must be text", field.Name)
+	}
+}
+
+// validateCustomFields checks submitted values against the definitions and
+// returns them coerced. Null values are rejected for required fields and
+// otherwise kept so updates can remove fields. With requireAll (on create),
+// nulls are dropped and every required field must be present.
+func validateCustomFields(fields map[string]models.CustomFieldDefinition, values map[string]interface{}, requireAll bool) (map[string]interface{}, error) {
+	coerced := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		field, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown custom field %s", name)
+		}
+		if value == nil {
+			if field.Required {
+				return nil, fmt.Errorf("Custom field %s cannot be null", name)
+			}
+			if !requireAll {
+				coerced[name] = nil
+			}
+			continue
+		}
+		v, err := coerceCustomFieldValue(field, value)
+		if err != nil {
+			return nil, err
+		}
+		coerced[name] = v
+	}
+
+	if requireAll {
+		for name, field := range fields {
+			if _, ok := coerced[name]; field.Required && !ok {
+				return nil, fmt.Errorf("Custom field %s is required", name)
+			}
+		}
+	}
+	return coerced, nil
+}
+
+// customFieldFilters compiles cf.<name>=<value> query parameters into a
+// single JSONB containment document. Parameters naming unknown fields or
+// with values that do not fit the field's type are left out and returned
+// in dropped, sorted.
+func customFieldFilters(ctx context.Context, query map[string][]string) ([]byte, []string, error) {
+	var fields map[string]models.CustomFieldDefinition
+	var dropped []string
+	filter := map[string]interface{}{}
+	for param, values := range query {
+		name, ok := strings.CutPrefix(param, customFieldFilterPrefix)
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if fields == nil {
+			var err error
+			if fields, err = loadCustomFields(ctx); err != nil {
+				return nil, nil, err
+			}
+		}
+		field, ok := fields[name]
+		if !ok {
+			dropped = append(dropped, param)
+			continue
+		}
+		value, err := coerceCustomFieldValue(field, values[0])
+		if err != nil {
+			dropped = append(dropped, param)
+			continue
+		}
+		filter[name] = value
+	}
+	sort.Strings(dropped)
+
+	if len(filter) == 0 {
+		return nil, dropped, nil
+	}
+	document, err := json.Marshal(filter)
+	return document, dropped, err
+}
+
+// GetCustomFields godoc
+// @Summary      List custom fields
+// @Description  Get the custom field definitions todos may carry
+// @Tags         custom-fields
+// @Accept       json
+// @Produce      json
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.CustomFieldDefinition  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /custom-fields [get]
+func GetCustomFields(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := db.Pool.Query(c.Request.Context(), `
+		SELECT `+customFieldColumns+` FROM custom_field_definitions ORDER BY name
+	`)
+	if err != nil {
+		log.Printf("Error querying custom fields: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch custom fields", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	fields := []models.CustomFieldDefinition{}
+	for rows.Next() {
+		var field models.CustomFieldDefinition
+		if err := scanCustomField(rows, &field); err != nil {
+			log.Printf("Error scanning custom field: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan custom field", "details": err.Error()})
+			return
+		}
+		fields = append(fields, field)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating custom fields: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating custom fields", "details": err.Error()})
+		return
+	}
+
+	respondList(c, envelope, fields, int64(len(fields)), 0, 0)
+}
+
+// CreateCustomField godoc
+// @Summary      Define a custom field
+// @Description  Add a custom field definition. Select fields need at least one option. Marking a field required only affects todos created or updated afterwards.
+// @Tags         custom-fields
+// @Accept       json
+// @Produce      json
+// @Param        field  body      models.CreateCustomFieldRequest  true  "Field definition"
+// @Success      201    {object}  models.CustomFieldDefinition
+// @Header       201    {string}  Location  "URL of the created resource"
+// @Header       201    {string}  ETag      "Strong ETag of the created resource"
+// @Failure      400    {object}  map[string]string
+// @Failure      409    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /custom-fields [post]
+func CreateCustomField(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.CreateCustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !customFieldNamePattern.MatchString(req.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid name. Use up to 50 lowercase letters, digits and underscores"})
+		return
+	}
+	options := req.Options
+	if options == nil {
+		options = []string{}
+	}
+	if req.Type == models.CustomFieldSelect && len(options) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Select fields need at least one option"})
+		return
+	}
+	if req.Type != models.CustomFieldSelect && len(options) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only select fields have options"})
+		return
+	}
+
+	var field models.CustomFieldDefinition
+	err := scanCustomField(db.Pool.QueryRow(c.Request.Context(), `
+		INSERT INTO custom_field_definitions (name, type, options, required, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		RETURNING `+customFieldColumns+`
+	`, req.Name, req.Type, options, req.Required), &field)
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A custom field with this name already exists"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating custom field: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create custom field", "details": err.Error()})
+		return
+	}
+
+	respondCreated(c, fmt.Sprintf("/custom-fields/%d", field.ID), field)
+}
+
+// UpdateCustomField godoc
+// @Summary      Update a custom field
+// @Description  Change a custom field's select options or required flag. Existing values are not revalidated.
+// @Tags         custom-fields
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int  true  "Custom field ID"
+// @Param        field  body      models.UpdateCustomFieldRequest  true  "Field changes"
+// @Success      200    {object}  models.CustomFieldDefinition
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /custom-fields/{id} [put]
+func UpdateCustomField(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid custom field ID"})
+		return
+	}
+
+	var req models.UpdateCustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var field models.CustomFieldDefinition
+	var validationErr error
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := scanCustomField(tx.QueryRow(ctx, `
+			SELECT `+customFieldColumns+` FROM custom_field_definitions WHERE id = $1 FOR UPDATE
+		`, id), &field); err != nil {
+			return err
+		}
+		if req.Options != nil {
+			if field.Type != models.CustomFieldSelect {
+				validationErr = errors.New("Only select fields have options")
+				return validationErr
+			}
+			if len(req.Options) == 0 {
+				validationErr = errors.New("Select fields need at least one option")
+				return validationErr
+			}
+		}
+
+		return scanCustomField(tx.QueryRow(ctx, `
+			UPDATE custom_field_definitions
+			SET options = COALESCE($1, options),
+			    required = COALESCE($2, required),
+			    updated_at = NOW()
+			WHERE id = $3
+			RETURNING `+customFieldColumns+`
+		`, req.Options, req.Required, id), &field)
+	})
+	if validationErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Custom field not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating custom field: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update custom field", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, field)
+}
+
+// DeleteCustomField godoc
+// @Summary      Delete a custom field
+// @Description  Remove a custom field definition. With scrub=true the field's values are also removed from every todo, recorded as an update on each; otherwise they stay in custom_fields untouched.
+// @Tags         custom-fields
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int   true   "Custom field ID"
+// @Param        scrub  query     bool  false  "Remove the field's values from todos"
+// @Success      204  "No Content"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /custom-fields/{id} [delete]
+func DeleteCustomField(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid custom field ID"})
+		return
+	}
+	scrub := c.Query("scrub") == "true"
+
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var name string
+		if err := tx.QueryRow(ctx, `
+			DELETE FROM custom_field_definitions WHERE id = $1 RETURNING name
+		`, id).Scan(&name); err != nil {
+			return err
+		}
+		if !scrub {
+			return nil
+		}
+
+		rows, err := tx.Query(ctx, `SELECT id FROM todos WHERE custom_fields ? $1 ORDER BY id`, name)
+		if err != nil {
+			return err
+		}
+		todoIDs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+		if err != nil {
+			return err
+		}
+		for _, todoID := range todoIDs {
+			if _, err := applyTodoUpdate(ctx, tx, c, nil, todoID, `custom_fields = custom_fields - $2::text, updated_at = NOW()`, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Custom field not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting custom field: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete custom field", "details": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
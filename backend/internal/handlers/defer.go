@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// maxDeferYears bounds how far ahead a todo can be deferred
+const maxDeferYears = 5
+
+// deferDurationPattern matches a defer date relative to today, such as +3d,
+// +2w or +1m
+var deferDurationPattern = regexp.MustCompile(`^\+(\d{1,3})([dwm])$`)
+
+// errInvalidDeferUntil is returned for an until parseDeferUntil does not accept
+var errInvalidDeferUntil = fmt.Errorf("Invalid until. Use a YYYY-MM-DD date or +<n>d, +<n>w or +<n>m, after today and within %d years", maxDeferYears)
+
+// errDeferDone is returned when deferring a todo that is already done
+var errDeferDone = errors.New("A done todo cannot be deferred")
+
+// deferTodayUTC returns today's date in UTC, the zone deferred dates are
+// compared in
+func deferTodayUTC() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// parseDeferUntil returns the date a todo deferred with until comes back:
+// a YYYY-MM-DD date, or a number of days, weeks or months after today
+func parseDeferUntil(until string, today time.Time) (time.Time, error) {
+	var date time.Time
+	if match := deferDurationPattern.FindStringSubmatch(until); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		switch match[2] {
+		case "d":
+			date = today.AddDate(0, 0, n)
+		case "w":
+			date = today.AddDate(0, 0, 7*n)
+		case "m":
+			date = today.AddDate(0, n, 0)
+		}
+	} else {
+		parsed, err := time.Parse(models.DateLayout, until)
+		if err != nil {
+			return date, errInvalidDeferUntil
+		}
+		date = parsed
+	}
+	if !date.After(today) || date.After(today.AddDate(maxDeferYears, 0, 0)) {
+		return date, errInvalidDeferUntil
+	}
+	return date, nil
+}
+
+// ResurfaceDeferredTodos clears the defer date of todos whose date has
+// arrived. They already show up again from that date on; clearing it records
+// their return in their activity, by the system, so it is not missed.
+func ResurfaceDeferredTodos(ctx context.Context) error {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id FROM todos
+		WHERE deferred_until <= (NOW() AT TIME ZONE 'UTC')::date
+		ORDER BY deferred_until, id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to list deferred todos: %w", err)
+	}
+	todoIDs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return fmt.Errorf("failed to list deferred todos: %w", err)
+	}
+
+	actor := systemActor
+	resurfaced := 0
+	for _, id := range todoIDs {
+		err := db.WithTx(ctx, func(tx pgx.Tx) error {
+			// Re-check under lock in case the todo was deferred again since it was listed
+			err := tx.QueryRow(ctx, `
+				SELECT id FROM todos
+				WHERE id = $1 AND deferred_until <= (NOW() AT TIME ZONE 'UTC')::date
+				FOR UPDATE
+			`, id).Scan(&id)
+			if err == pgx.ErrNoRows {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if _, err := applyTodoUpdate(ctx, tx, nil, &actor, id, `deferred_until = NULL, updated_at = NOW()`); err != nil {
+				return err
+			}
+			resurfaced++
+			return nil
+		})
+		if err != nil {
+			log.Printf("Error resurfacing todo %d: %v", id, err)
+		}
+	}
+
+	if resurfaced > 0 {
+		log.Printf("Resurfaced %d deferred todos", resurfaced)
+	}
+	return nil
+}
+
+// DeferTodo godoc
+// @Summary      Defer a todo
+// @Description  Hide a todo from the default list, board, agenda and badge counts until a date, given as YYYY-MM-DD or relative to today as +3d, +2w or +1m. Dates are in UTC and must be after today and within 5 years. GET /todos?deferred=true lists the deferred todos. From the date on the todo shows up again, and the defer date is cleared by the system with an entry in its activity. Deferring again moves the date. A done todo cannot be deferred.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                      true  "Todo ID"
+// @Param        defer  body      models.DeferTodoRequest  true  "Date to defer the todo until"
+// @Success      200    {object}  models.Todo
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      409    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /todos/{id}/defer [post]
+func DeferTodo(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	var req models.DeferTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	until, err := parseDeferUntil(req.Until, deferTodayUTC())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var todo models.Todo
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var done bool
+		if err := tx.QueryRow(ctx, `
+			SELECT status IN (`+doneStatusesSQL+`) FROM todos WHERE id = $1 FOR UPDATE
+		`, id).Scan(&done); err != nil {
+			return err
+		}
+		if done {
+			return errDeferDone
+		}
+		todo, err = applyTodoUpdate(ctx, tx, c, nil, id, `deferred_until = $2, updated_at = NOW()`, until.Format(models.DateLayout))
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	if errors.Is(err, errDeferDone) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error deferring todo: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to defer todo", "details": err.Error()})
+		return
+	}
+
+	todo.Warnings = requestWarnings(c)
+	c.JSON(http.StatusOK, todo)
+}
+
+// UndeferTodo godoc
+// @Summary      Bring back a deferred todo
+// @Description  Clear a todo's defer date so it shows up in the default views again right away. A todo that is not deferred is returned unchanged.
+// @Tags         todos
+// @Produce      json
+// @Param        id   path      int  true  "Todo ID"
+// @Success      200  {object}  models.Todo
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/defer [delete]
+func UndeferTodo(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	var todo models.Todo
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := scanTodo(tx.QueryRow(ctx, `
+			SELECT `+todoColumns+` FROM todos WHERE id = $1 FOR UPDATE
+		`, id), &todo); err != nil {
+			return err
+		}
+		if todo.DeferredUntil == nil {
+			return nil
+		}
+		todo, err = applyTodoUpdate(ctx, tx, c, nil, id, `deferred_until = NULL, updated_at = NOW()`)
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error undeferring todo: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bring back todo", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, todo)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/markdown"
+)
+
+// descriptionHTMLCacheSize bounds how many rendered descriptions are kept in
+// memory; the cache starts over once it is full
+const descriptionHTMLCacheSize = 1000
+
+// errInvalidRender is returned for a render parameter other than html
+var errInvalidRender = errors.New("Invalid render. Must be html")
+
+// descriptionHTMLCache holds rendered descriptions keyed by the SHA-256 of
+// their source. Rendering only depends on the source and the image prefixes,
+// which are fixed for the process, so entries never go stale.
+var descriptionHTMLCache = struct {
+	sync.Mutex
+	entries map[[sha256.Size]byte]string
+}{entries: map[[sha256.Size]byte]string{}}
+
+// markdownImagePrefixes returns the URL prefixes description images may be
+// loaded from, from the comma-separated MARKDOWN_IMAGE_PREFIXES
+func markdownImagePrefixes() []string {
+	var prefixes []string
+	for _, prefix := range strings.Split(os.Getenv("MARKDOWN_IMAGE_PREFIXES"), ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// parseRender reports whether the request asks for the description rendered
+// as HTML with render=html
+func parseRender(c *gin.Context) (bool, error) {
+	switch c.Query("render") {
+	case "":
+		return false, nil
+	case "html":
+		return true, nil
+	}
+	return false, errInvalidRender
+}
+
+// renderDescriptionHTML returns a description rendered from Markdown to
+// sanitized HTML, from the cache when it was rendered before
+func renderDescriptionHTML(description string) string {
+	key := sha256.Sum256([]byte(description))
+	descriptionHTMLCache.Lock()
+	rendered, ok := descriptionHTMLCache.entries[key]
+	descriptionHTMLCache.Unlock()
+	if ok {
+		return rendered
+	}
+
+	rendered = markdown.ToHTML(description, markdown.Options{ImagePrefixes: markdownImagePrefixes()})
+	descriptionHTMLCache.Lock()
+	if len(descriptionHTMLCache.entries) >= descriptionHTMLCacheSize {
+		descriptionHTMLCache.entries = map[[sha256.Size]byte]string{}
+	}
+	descriptionHTMLCache.entries[key] = rendered
+	descriptionHTMLCache.Unlock()
+	return rendered
+}
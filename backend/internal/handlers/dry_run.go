@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/db"
+)
+
+// errInvalidDryRun is returned for a dry_run parameter that is not a boolean
+var errInvalidDryRun = errors.New("Invalid dry_run. Must be true or false")
+
+// parseDryRun reads the dry_run query parameter. When it is true the request's
+// context is marked so db.WithTx rolls back instead of committing; read the
+// context after calling it. Work outside transactions, such as cache resets,
+// is the handler's to skip.
+func parseDryRun(c *gin.Context) (bool, error) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		return false, errInvalidDryRun
+	}
+	if dryRun {
+		c.Request = c.Request.WithContext(db.WithDryRun(c.Request.Context()))
+	}
+	return dryRun, nil
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"testing"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/testsupport"
+)
+
+func TestParseDryRun(t *testing.T) {
+	tests := []struct {
+		query  string
+		dryRun bool
+		err    bool
+	}{
+		{"", false, false},
+		{"dry_run=false", false, false},
+		{"dry_run=0", false, false},
+		{"dry_run=true", true, false},
+		{"dry_run=1", true, false},
+		{"dry_run=TRUE", true, false},
+		{"dry_run=yes", false, true},
+		{"dry_run=", false, true},
+	}
+	for _, tt := range tests {
+		c := queryContext(tt.query)
+		dryRun, err := parseDryRun(c)
+		if dryRun != tt.dryRun || (err != nil) != tt.err {
+			t.Errorf("parseDryRun(%q) = %t, %v, want %t, error %t", tt.query, dryRun, err, tt.dryRun, tt.err)
+		}
+		if got := db.IsDryRun(c.Request.Context()); got != tt.dryRun {
+			t.Errorf("parseDryRun(%q) marked the context a dry run: %t, want %t", tt.query, got, tt.dryRun)
+		}
+	}
+}
+
+// usePool points db.Pool at TEST_DATABASE_URL, a database with the
+// migrations applied, for the test, which is skipped when it is unset
+func usePool(t *testing.T) {
+	t.Helper()
+	testsupport.UseDatabase(t)
+	resetConfigCaches()
+	t.Cleanup(resetConfigCaches)
+}
+
+// resetConfigCaches drops the cached configuration so it is loaded from the
+// database in use
+func resetConfigCaches() {
+	statusCache.reset()
+	priorityCache.reset()
+	storyPointScaleCache.reset()
+	maintenanceCache.reset()
+	todoDefaultsCache.reset()
+}
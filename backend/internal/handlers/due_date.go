@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/models"
+)
+
+// errInvalidTimezone is returned for a timezone that is not an IANA name
+var errInvalidTimezone = errors.New("Invalid timezone. Use an IANA name such as Europe/Berlin")
+
+// requestLocation returns the zone used to read dates and zone-less
+// timestamps: the request field, then the X-Timezone header, then the
+// user's timezone preference, then UTC
+func requestLocation(c *gin.Context, field string) (*time.Location, error) {
+	name := field
+	if name == "" {
+		name = c.GetHeader("X-Timezone")
+	}
+	if name == "" {
+		preferences, err := loadPreferences(c.Request.Context(), currentUser(c))
+		if err != nil {
+			log.Printf("Error fetching preferences: %v", err)
+			return time.UTC, nil
+		}
+		name = preferences.Timezone
+	}
+	loc, err := models.LoadLocation(name)
+	if err != nil {
+		return nil, errInvalidTimezone
+	}
+	return loc, nil
+}
+
+// dueDateArgs are the query arguments for due_date, all_day and due_timezone
+type dueDateArgs struct {
+	dueDate  interface{}
+	allDay   bool
+	timezone interface{}
+}
+
+// resolveDueDate turns a requested due date into the values to store. Due
+// dates are stored in UTC; all-day ones also keep the zone they were set in.
+// A nil input yields nil arguments.
+func resolveDueDate(c *gin.Context, input *models.DueDateInput, timezone string) (dueDateArgs, error) {
+	if input == nil {
+		return dueDateArgs{}, nil
+	}
+	loc, err := requestLocation(c, timezone)
+	if err != nil {
+		return dueDateArgs{}, err
+	}
+	args := dueDateArgs{dueDate: input.In(loc).UTC(), allDay: input.DateOnly}
+	if input.DateOnly {
+		args.timezone = loc.String()
+	}
+	return args, nil
+}
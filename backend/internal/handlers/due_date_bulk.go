@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// dueDateShiftPattern matches a due date shift: an optional sign followed by
+// one or more counts of days or weeks, such as +7d, -2w or +1w3d
+var dueDateShiftPattern = regexp.MustCompile(`^([+-]?)((?:\d{1,4}[dw])+)$`)
+
+// dueDateShiftPart matches one count and unit of a shift
+var dueDateShiftPart = regexp.MustCompile(`(\d+)([dw])`)
+
+// parseDueDateShift returns the number of days a shift such as +1w3d moves
+// due dates by
+func parseDueDateShift(s string) (int, error) {
+	match := dueDateShiftPattern.FindStringSubmatch(s)
+	if match == nil {
+		return 0, errors.New("Invalid shift. Use a signed number of days or weeks such as +7d, -2w or +1w3d")
+	}
+	days := 0
+	for _, part := range dueDateShiftPart.FindAllStringSubmatch(match[2], -1) {
+		n, _ := strconv.Atoi(part[1])
+		if part[2] == "w" {
+			n *= 7
+		}
+		days += n
+	}
+	if match[1] == "-" {
+		days = -days
+	}
+	return days, nil
+}
+
+// shiftDueDate moves a due date by whole calendar days. All-day dates move
+// in the zone they were set in and others in loc, so the time of day stays
+// the same across DST changes and month ends.
+func shiftDueDate(todo models.Todo, days int, loc *time.Location) time.Time {
+	if todo.AllDay {
+		loc = time.UTC
+		if todo.DueTimezone != nil {
+			if zone, err := models.LoadLocation(*todo.DueTimezone); err == nil {
+				loc = zone
+			}
+		}
+	}
+	return todo.DueDate.In(loc).AddDate(0, 0, days).UTC()
+}
+
+// SetTodoDueDates godoc
+// @Summary      Set or shift the due dates of todos
+// @Description  Change the due dates of up to 100 todos in one transaction, either to due_date or by shift, a signed number of days and weeks such as +7d, -2w or +1w3d. Shifts move by calendar days: all-day todos in the zone their date was set in, others in the timezone field, the X-Timezone header or the timezone preference, so the time of day is kept across DST changes. Todos without a due date are left out of a shift and listed in skipped. If any todo does not exist nothing is changed and the missing IDs are returned. Each change is recorded in the todo's activity. With dry_run=true the changes are made and rolled back, so nothing changes.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        due_dates  body      models.SetDueDatesRequest  true   "Todos and their new due date or shift"
+// @Param        dry_run    query     bool                       false  "Only report what would change"  default(false)
+// @Success      200        {object}  models.SetDueDatesResult
+// @Failure      400        {object}  map[string]string
+// @Failure      404        {object}  map[string]interface{}
+// @Failure      500        {object}  map[string]string
+// @Router       /todos/bulk/due-dates [post]
+func SetTodoDueDates(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var req models.SetDueDatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (req.DueDate == nil) == (req.Shift == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Set either due_date or shift"})
+		return
+	}
+	var days int
+	if req.Shift != "" {
+		if days, err = parseDueDateShift(req.Shift); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	loc, err := requestLocation(c, req.Timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dueDate, err := resolveDueDate(c, req.DueDate, req.Timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := models.SetDueDatesResult{Todos: []models.TodoDueDate{}, Skipped: []int64{}, DryRun: dryRun}
+	var missing []int64
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		if missing, err = missingTodos(ctx, tx, req.IDs); err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return errTodosNotFound
+		}
+
+		seen := map[int64]bool{}
+		for _, id := range req.IDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			var todo models.Todo
+			if req.Shift == "" {
+				todo, err = applyTodoUpdate(ctx, tx, c, nil, id, `
+					due_date = $2, all_day = $3, due_timezone = $4, updated_at = NOW()
+				`, dueDate.dueDate, dueDate.allDay, dueDate.timezone)
+			} else {
+				var current models.Todo
+				if err := scanTodo(tx.QueryRow(ctx, `
+					SELECT `+todoColumns+` FROM todos WHERE id = $1 FOR UPDATE
+				`, id), &current); err != nil {
+					return err
+				}
+				if current.DueDate == nil {
+					result.Skipped = append(result.Skipped, id)
+					continue
+				}
+				todo, err = applyTodoUpdate(ctx, tx, c, nil, id, `due_date = $2, updated_at = NOW()`, shiftDueDate(current, days, loc))
+			}
+			if err != nil {
+				return err
+			}
+			result.Todos = append(result.Todos, models.TodoDueDate{ID: todo.ID, DueDate: *formatEventDueDate(todo)})
+		}
+		return nil
+	})
+	if errors.Is(err, errTodosNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found", "todo_ids": missing})
+		return
+	}
+	if err != nil {
+		log.Printf("Error setting due dates: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set due dates", "details": err.Error()})
+		return
+	}
+	result.Warnings = requestWarnings(c)
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/models"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := models.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q): %v", name, err)
+	}
+	return loc
+}
+
+// A timed todo is due on the day its instant falls on in the requested zone,
+// so a second either side of local midnight moves it a day. An all-day todo
+// stays on the date it was set for whatever zone is requested.
+func TestAgendaDateAtMidnight(t *testing.T) {
+	kolkata := "Asia/Kolkata"
+	utc := "UTC"
+	timed := func(due time.Time) models.Todo {
+		return models.Todo{DueDate: &due}
+	}
+	allDay := func(due time.Time, zone *string) models.Todo {
+		return models.Todo{DueDate: &due, AllDay: true, DueTimezone: zone}
+	}
+	tests := []struct {
+		name string
+		todo models.Todo
+		zone string
+		want string
+	}{
+		{"before Kolkata midnight", timed(time.Date(2025, 3, 10, 18, 29, 59, 0, time.UTC)), kolkata, "2025-03-10"},
+		{"Kolkata midnight", timed(time.Date(2025, 3, 10, 18, 30, 0, 0, time.UTC)), kolkata, "2025-03-11"},
+		{"before UTC midnight", timed(time.Date(2025, 3, 10, 23, 59, 59, 0, time.UTC)), utc, "2025-03-10"},
+		{"UTC midnight", timed(time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC)), utc, "2025-03-11"},
+		{"UTC midnight in Kolkata", timed(time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC)), kolkata, "2025-03-11"},
+		{"Kolkata midnight in UTC", timed(time.Date(2025, 3, 10, 18, 30, 0, 0, time.UTC)), utc, "2025-03-10"},
+		{"year end in Kolkata", timed(time.Date(2024, 12, 31, 18, 30, 0, 0, time.UTC)), kolkata, "2025-01-01"},
+		{"year end in UTC", timed(time.Date(2024, 12, 31, 18, 30, 0, 0, time.UTC)), utc, "2024-12-31"},
+		{"all-day Kolkata date in UTC", allDay(time.Date(2025, 3, 11, 18, 30, 0, 0, time.UTC), &kolkata), utc, "2025-03-12"},
+		{"all-day UTC date in Kolkata", allDay(time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC), &utc), kolkata, "2025-03-12"},
+		{"all-day without a zone", allDay(time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC), nil), kolkata, "2025-03-12"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := agendaDate(tt.todo, mustLocation(t, tt.zone)); got != tt.want {
+				t.Errorf("agendaDate = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// A week starts at Monday midnight in the requested zone, which for
+// Asia/Kolkata is Sunday evening in UTC
+func TestParseISOWeekInZone(t *testing.T) {
+	tests := []struct {
+		week string
+		zone string
+		want time.Time
+	}{
+		{"2025-W11", "Asia/Kolkata", time.Date(2025, 3, 9, 18, 30, 0, 0, time.UTC)},
+		{"2025-W11", "UTC", time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{"2025-W01", "Asia/Kolkata", time.Date(2024, 12, 29, 18, 30, 0, 0, time.UTC)},
+		{"2025-W01", "UTC", time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.week+"/"+tt.zone, func(t *testing.T) {
+			start, err := parseISOWeek(tt.week, mustLocation(t, tt.zone))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !start.Equal(tt.want) {
+				t.Errorf("week starts %s, want %s", start.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+// The request field wins over the X-Timezone header, and a name that is not
+// an IANA zone is rejected
+func TestRequestLocation(t *testing.T) {
+	tests := []struct {
+		field  string
+		header string
+		want   string
+	}{
+		{"Asia/Kolkata", "", "Asia/Kolkata"},
+		{"", "Asia/Kolkata", "Asia/Kolkata"},
+		{"UTC", "Asia/Kolkata", "UTC"},
+		{"", "Not/AZone", ""},
+		{"+05:30", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field+"|"+tt.header, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Request.Header.Set("X-Timezone", tt.header)
+			loc, err := requestLocation(c, tt.field)
+			if tt.want == "" {
+				if err != errInvalidTimezone {
+					t.Errorf("requestLocation = %v, %v, want errInvalidTimezone", loc, err)
+				}
+				return
+			}
+			if err != nil || loc.String() != tt.want {
+				t.Errorf("requestLocation = %v, %v, want %s", loc, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// defaultDuplicateThreshold is used when DUPLICATE_SIMILARITY_THRESHOLD is unset
+const defaultDuplicateThreshold = 0.6
+
+// duplicateWindowDays bounds duplicate matching to recently created todos so
+// the trigram scan stays small
+const duplicateWindowDays = 90
+
+// maxSimilarTodos caps how many matches are returned
+const maxSimilarTodos = 5
+
+// errPossibleDuplicates is returned when a strict create finds similar todos
+var errPossibleDuplicates = errors.New("possible duplicates")
+
+var (
+	duplicateThresholdOnce sync.Once
+	duplicateThresholdVal  float64
+)
+
+// duplicateThreshold returns DUPLICATE_SIMILARITY_THRESHOLD, a similarity
+// between 0 and 1 above which titles count as possible duplicates
+func duplicateThreshold() float64 {
+	duplicateThresholdOnce.Do(func() {
+		duplicateThresholdVal = defaultDuplicateThreshold
+		if value := os.Getenv("DUPLICATE_SIMILARITY_THRESHOLD"); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed <= 0 || parsed > 1 {
+				log.Printf("Invalid DUPLICATE_SIMILARITY_THRESHOLD %q, using %.2f", value, defaultDuplicateThreshold)
+				return
+			}
+			duplicateThresholdVal = parsed
+		}
+	})
+	return duplicateThresholdVal
+}
+
+// findSimilarTodos returns open todos created in the last duplicateWindowDays
+// whose title has a similarity of at least threshold to title, best match
+// first. It must run in a transaction since it sets the trigram threshold
+// for the index-assisted % operator.
+func findSimilarTodos(ctx context.Context, tx pgx.Tx, title string, threshold float64) ([]models.SimilarTodo, error) {
+	if _, err := tx.Exec(ctx, `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, strconv.FormatFloat(threshold, 'f', -1, 64)); err != nil {
+		return nil, fmt.Errorf("failed to set similarity threshold: %w", err)
+	}
+	rows, err := tx.Query(ctx, `
+		SELECT id, title, similarity(title, $1) AS score
+		FROM todos
+		WHERE title % $1
+		  AND status NOT IN (`+doneStatusesSQL+`)
+		  AND created_at > NOW() - make_interval(days => $2)
+		ORDER BY score DESC, id DESC
+		LIMIT $3
+	`, title, duplicateWindowDays, maxSimilarTodos)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find similar todos: %w", err)
+	}
+	similar, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.SimilarTodo, error) {
+		var todo models.SimilarTodo
+		err := row.Scan(&todo.ID, &todo.Title, &todo.Similarity)
+		return todo, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find similar todos: %w", err)
+	}
+	return similar, nil
+}
+
+// GetSimilarTodos godoc
+// @Summary      Find similar todos
+// @Description  Get open, recently created todos whose title resembles the given title, best match first. Uses the same matcher as duplicate detection on create.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        title      query     string  true   "Title to match"
+// @Param        threshold  query     number  false  "Minimum similarity between 0 and 1 (defaults to DUPLICATE_SIMILARITY_THRESHOLD)"
+// @Param        envelope   query     bool    false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.SimilarTodo  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/similar [get]
+func GetSimilarTodos(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	title := c.Query("title")
+	if title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "title is required"})
+		return
+	}
+	threshold := duplicateThreshold()
+	if value := c.Query("threshold"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 || parsed > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid threshold. Must be greater than 0 and at most 1"})
+			return
+		}
+		threshold = parsed
+	}
+
+	var similar []models.SimilarTodo
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		similar, err = findSimilarTodos(ctx, tx, title, threshold)
+		return err
+	})
+	if err != nil {
+		log.Printf("Error finding similar todos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find similar todos", "details": err.Error()})
+		return
+	}
+	if similar == nil {
+		similar = []models.SimilarTodo{}
+	}
+
+	respondList(c, envelope, similar, int64(len(similar)), 0, 0)
+}
@@ -0,0 +1,488 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/store"
+)
+
+// embedColumns is the column list selected by every embed query; scanEmbed reads it back
+const embedColumns = `id, name, origin, filters, created_at`
+
+// scanEmbed scans a row selected with embedColumns into embed
+func scanEmbed(row pgx.Row, embed *models.Embed) error {
+	return row.Scan(&embed.ID, &embed.Name, &embed.Origin, &embed.Filters, &embed.CreatedAt)
+}
+
+// maxEmbedTodos caps how many todos an embed returns
+const maxEmbedTodos = 200
+
+// embedCacheTTL is how long an embed's response is reused before its todos
+// are queried again
+const embedCacheTTL = 30 * time.Second
+
+// embedRateLimit is how many requests one embed may serve per
+// embedRateWindow on each instance
+const (
+	embedRateLimit  = 600
+	embedRateWindow = time.Minute
+)
+
+// embedResponse is a cached embed response body and its ETag, in both
+// list shapes
+type embedResponse struct {
+	body     []byte
+	etag     string
+	loadedAt time.Time
+	// enveloped is body wrapped for envelope=true, with its own ETag
+	enveloped     []byte
+	envelopedETag string
+}
+
+// embedCache holds the latest response of each embed. An embed's filters
+// never change, so only a delete needs to drop its entry.
+var embedCache = struct {
+	sync.Mutex
+	responses map[int64]embedResponse
+}{responses: map[int64]embedResponse{}}
+
+// embedRequests counts each embed's requests in the current rate window
+var embedRequests = struct {
+	sync.Mutex
+	window time.Time
+	counts map[int64]int
+}{counts: map[int64]int{}}
+
+// allowEmbedRequest counts a request against an embed's rate limit and
+// reports whether it may be served, and if not, when the window resets
+func allowEmbedRequest(id int64, now time.Time) (bool, time.Time) {
+	embedRequests.Lock()
+	defer embedRequests.Unlock()
+	window := now.Truncate(embedRateWindow)
+	if !embedRequests.window.Equal(window) {
+		embedRequests.window = window
+		embedRequests.counts = map[int64]int{}
+	}
+	embedRequests.counts[id]++
+	return embedRequests.counts[id] <= embedRateLimit, window.Add(embedRateWindow)
+}
+
+// embedSigningKey returns the key embed tokens are signed with, from
+// EMBED_SIGNING_KEY, or nil when it is unset
+func embedSigningKey() []byte {
+	if key := os.Getenv("EMBED_SIGNING_KEY"); key != "" {
+		return []byte(key)
+	}
+	return nil
+}
+
+// embedSignature signs an embed ID with key
+func embedSignature(key []byte, id int64) []byte {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "embed:%d", id)
+	return mac.Sum(nil)
+}
+
+// embedToken returns the token of an embed: its ID and signature. Tokens do
+// not expire; deleting the embed revokes its token.
+func embedToken(key []byte, id int64) string {
+	return strconv.FormatInt(id, 10) + "." + base64.RawURLEncoding.EncodeToString(embedSignature(key, id))
+}
+
+// parseEmbedToken returns the embed ID of a token whose signature is valid
+func parseEmbedToken(key []byte, token string) (int64, bool) {
+	idPart, signaturePart, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(signaturePart)
+	if err != nil || !hmac.Equal(signature, embedSignature(key, id)) {
+		return 0, false
+	}
+	return id, true
+}
+
+// normalizeOrigin returns the scheme://host[:port] origin of value, which must
+// be an http or https URL without a path beyond "/"
+func normalizeOrigin(value string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(value))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", errors.New("origin must be an http or https URL such as https://wiki.example.com")
+	}
+	if (parsed.Path != "" && parsed.Path != "/") || parsed.RawQuery != "" || parsed.Fragment != "" || parsed.User != nil {
+		return "", errors.New("origin must not have a path, query, fragment or credentials")
+	}
+	return parsed.Scheme + "://" + strings.ToLower(parsed.Host), nil
+}
+
+// GetEmbeds godoc
+// @Summary      List embeds
+// @Description  Get all embeds, newest first. Tokens are only returned when an embed is created.
+// @Tags         embeds
+// @Accept       json
+// @Produce      json
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.Embed  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /embeds [get]
+func GetEmbeds(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := db.Pool.Query(c.Request.Context(), `
+		SELECT `+embedColumns+` FROM embeds ORDER BY created_at DESC, id DESC
+	`)
+	if err != nil {
+		log.Printf("Error querying embeds: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch embeds", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	embeds := []models.Embed{}
+	for rows.Next() {
+		var embed models.Embed
+		if err := scanEmbed(rows, &embed); err != nil {
+			log.Printf("Error scanning embed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan embed", "details": err.Error()})
+			return
+		}
+		embeds = append(embeds, embed)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating embeds: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch embeds", "details": err.Error()})
+		return
+	}
+
+	respondList(c, envelope, embeds, int64(len(embeds)), 0, 0)
+}
+
+// CreateEmbed godoc
+// @Summary      Create an embed
+// @Description  Create a read-only feed of todos for another site and return it with its token. The token is signed with EMBED_SIGNING_KEY, does not expire and is only shown here; GET /embed/todos?token=... then serves the todos matching the filters to pages on origin. Without statuses the feed shows todos that are not done.
+// @Tags         embeds
+// @Accept       json
+// @Produce      json
+// @Param        embed  body      models.CreateEmbedRequest  true  "Embed to create"
+// @Success      201    {object}  models.CreatedEmbed
+// @Header       201    {string}  Location  "URL of the created embed"
+// @Failure      400    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Failure      503    {object}  map[string]string
+// @Router       /embeds [post]
+func CreateEmbed(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	key := embedSigningKey()
+	if key == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Embed signing key is not configured"})
+		return
+	}
+
+	var req models.CreateEmbedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name cannot be empty"})
+		return
+	}
+	origin, err := normalizeOrigin(req.Origin)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	for _, status := range req.Filters.Statuses {
+		_, known, err := lookupStatus(ctx, status)
+		if err != nil {
+			log.Printf("Error fetching statuses: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create embed", "details": err.Error()})
+			return
+		}
+		if !known {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unknown status %q", status)})
+			return
+		}
+	}
+	for _, parent := range []struct {
+		table string
+		id    *int64
+		name  string
+	}{{"epics", req.Filters.EpicID, "Epic"}, {"sprints", req.Filters.SprintID, "Sprint"}} {
+		if parent.id == nil {
+			continue
+		}
+		var exists bool
+		if err := db.Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM `+parent.table+` WHERE id = $1)`, *parent.id).Scan(&exists); err != nil {
+			log.Printf("Error checking %s: %v", parent.table, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create embed", "details": err.Error()})
+			return
+		}
+		if !exists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": parent.name + " not found"})
+			return
+		}
+	}
+
+	var embed models.Embed
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := scanEmbed(tx.QueryRow(ctx, `
+			INSERT INTO embeds (name, origin, filters, created_at)
+			VALUES ($1, $2, $3, NOW())
+			RETURNING `+embedColumns+`
+		`, name, origin, req.Filters), &embed); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionCreate, models.AuditEntityEmbed, embed.ID, nil, embed)
+	})
+	if err != nil {
+		log.Printf("Error creating embed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create embed", "details": err.Error()})
+		return
+	}
+
+	respondCreatedAs(c, fmt.Sprintf("/embeds/%d", embed.ID), models.CreatedEmbed{Embed: embed, Token: embedToken(key, embed.ID)}, embed)
+}
+
+// DeleteEmbed godoc
+// @Summary      Delete an embed
+// @Description  Delete an embed, revoking its token. Requests with the token fail from then on, on every instance.
+// @Tags         embeds
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Embed ID"
+// @Success      204  "No Content"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /embeds/{id} [delete]
+func DeleteEmbed(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid embed ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var before models.Embed
+		if err := scanEmbed(tx.QueryRow(ctx, `
+			DELETE FROM embeds WHERE id = $1 RETURNING `+embedColumns+`
+		`, id), &before); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionDelete, models.AuditEntityEmbed, before.ID, before, nil)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Embed not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting embed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete embed", "details": err.Error()})
+		return
+	}
+
+	embedCache.Lock()
+	delete(embedCache.responses, id)
+	embedCache.Unlock()
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetEmbedTodos godoc
+// @Summary      Get an embed's todos
+// @Description  Public read-only feed for embedding: the title, status, due date (a bare date for all-day todos) and priority of up to 200 todos matching the embed's filters, soonest due first. Deferred todos are hidden until their date. Only the embed's origin may read it cross-origin. Responses are cached for 30 seconds and carry an ETag; clients must revalidate, and the token is checked on every request, so deleting the embed takes effect at once. Each embed may serve 600 requests a minute per instance before getting a 429.
+// @Tags         embeds
+// @Produce      json
+// @Param        token     query     string  true   "Embed token"
+// @Param        envelope  query     bool    false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200       {array}   models.EmbedTodo  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Success      304       "Not Modified"
+// @Failure      400       {object}  map[string]string
+// @Failure      401       {object}  map[string]string
+// @Failure      403       {object}  map[string]string
+// @Failure      429       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /embed/todos [get]
+func GetEmbedTodos(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	key := embedSigningKey()
+	if key == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Embed signing key is not configured"})
+		return
+	}
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Forged tokens are turned away before they cost a query
+	id, ok := parseEmbedToken(key, c.Query("token"))
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid embed token"})
+		return
+	}
+	now := time.Now()
+	if allowed, reset := allowEmbedRequest(id, now); !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(reset.Sub(now).Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Embed rate limit exceeded"})
+		return
+	}
+
+	// The embed is looked up on every request so a revoked token stops
+	// working at once, even while its response is cached
+	ctx := c.Request.Context()
+	var embed models.Embed
+	err = scanEmbed(db.Pool.QueryRow(ctx, `SELECT `+embedColumns+` FROM embeds WHERE id = $1`, id), &embed)
+	if errors.Is(err, pgx.ErrNoRows) {
+		embedCache.Lock()
+		delete(embedCache.responses, id)
+		embedCache.Unlock()
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Embed token has been revoked"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching embed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch embed", "details": err.Error()})
+		return
+	}
+
+	if origin := c.GetHeader("Origin"); origin != "" && origin != embed.Origin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Origin is not allowed to read this embed"})
+		return
+	}
+	c.Header("Access-Control-Allow-Origin", embed.Origin)
+	c.Header("Vary", "Origin")
+	c.Header("Cache-Control", "no-cache")
+
+	embedCache.Lock()
+	response, cached := embedCache.responses[id]
+	embedCache.Unlock()
+	if !cached || now.Sub(response.loadedAt) >= embedCacheTTL {
+		todos, err := queryEmbedTodos(c, embed.Filters)
+		if err != nil {
+			log.Printf("Error querying embed todos: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch todos", "details": err.Error()})
+			return
+		}
+		body, err := json.Marshal(todos)
+		if err != nil {
+			log.Printf("Error encoding response: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode response", "details": err.Error()})
+			return
+		}
+		enveloped, err := json.Marshal(models.ListEnvelope{Data: json.RawMessage(body), Meta: models.ListMeta{Total: int64(len(todos))}})
+		if err != nil {
+			log.Printf("Error encoding response: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode response", "details": err.Error()})
+			return
+		}
+		response = embedResponse{
+			body:          body,
+			etag:          resourceETag(body),
+			loadedAt:      now,
+			enveloped:     enveloped,
+			envelopedETag: resourceETag(enveloped),
+		}
+		embedCache.Lock()
+		embedCache.responses[id] = response
+		embedCache.Unlock()
+	}
+
+	body, etag := response.body, response.etag
+	if envelope {
+		body, etag = response.enveloped, response.envelopedETag
+	}
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// queryEmbedTodos returns the projection of the todos matching filters,
+// leaving out deferred ones. Todos without a priority show the default.
+func queryEmbedTodos(c *gin.Context, filters models.EmbedFilters) ([]models.EmbedTodo, error) {
+	var qb store.QueryBuilder
+	if len(filters.Statuses) > 0 {
+		qb.Where("status = ANY(" + qb.Arg(filters.Statuses) + ")")
+	} else {
+		qb.Where("status NOT IN (" + doneStatusesSQL + ")")
+	}
+	if filters.EpicID != nil {
+		qb.Where("epic_id = " + qb.Arg(*filters.EpicID))
+	}
+	if filters.SprintID != nil {
+		qb.Where("sprint_id = " + qb.Arg(*filters.SprintID))
+	}
+	qb.Where("NOT " + store.DeferredSQL)
+	qb.OrderBy("due_date ASC NULLS LAST", "created_at DESC", "id DESC")
+	qb.Page(maxEmbedTodos, 0)
+	query, args := qb.Build("SELECT title, status, due_date, all_day, due_timezone, " + store.PriorityKeySQL + " FROM todos")
+
+	rows, err := db.Pool.Query(c.Request.Context(), query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.EmbedTodo, error) {
+		var todo models.EmbedTodo
+		err := row.Scan(&todo.Title, &todo.Status, &todo.DueDate, &todo.AllDay, &todo.DueTimezone, &todo.Priority)
+		return todo, err
+	})
+}
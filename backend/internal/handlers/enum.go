@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"flow-v1/backend/internal/models"
+)
+
+// enumSeparators are folded into underscores by normalizeEnumValue
+var enumSeparators = strings.NewReplacer(" ", "_", "-", "_")
+
+// statusAliases maps spellings integrations commonly send to the keys of the
+// default statuses. An alias only applies while its target status exists.
+var statusAliases = map[string]string{
+	"to_do":      "todo",
+	"open":       "todo",
+	"new":        "todo",
+	"doing":      "in_progress",
+	"inprogress": "in_progress",
+	"started":    "in_progress",
+	"wip":        "in_progress",
+	"complete":   "done",
+	"completed":  "done",
+	"closed":     "done",
+	"finished":   "done",
+	"resolved":   "done",
+}
+
+// priorityAliases maps alternative spellings to the keys of the default priorities
+var priorityAliases = map[string]string{
+	"hi":     "high",
+	"normal": "medium",
+	"med":    "medium",
+	"lo":     "low",
+}
+
+// normalizeEnumValue folds a status or priority as sent by a client into
+// key form: trimmed, lowercase, with spaces and hyphens as single underscores.
+// "In Progress", "in-progress" and "IN_PROGRESS" all become "in_progress".
+func normalizeEnumValue(value string) string {
+	normalized := enumSeparators.Replace(strings.ToLower(strings.TrimSpace(value)))
+	for strings.Contains(normalized, "__") {
+		normalized = strings.ReplaceAll(normalized, "__", "_")
+	}
+	return normalized
+}
+
+// resolveStatus finds the status a client means by value, matching keys and
+// labels after normalization and then statusAliases
+func resolveStatus(ctx context.Context, value string) (models.Status, bool, error) {
+	statuses, err := getStatuses(ctx)
+	if err != nil {
+		return models.Status{}, false, err
+	}
+	normalized := normalizeEnumValue(value)
+	for _, status := range statuses {
+		if status.Key == normalized || normalizeEnumValue(status.Label) == normalized {
+			return status, true, nil
+		}
+	}
+	if alias, ok := statusAliases[normalized]; ok {
+		for _, status := range statuses {
+			if status.Key == alias {
+				return status, true, nil
+			}
+		}
+	}
+	return models.Status{}, false, nil
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"flow-v1/backend/internal/models"
+)
+
+// seedConfigCache fills cache with items for the duration of the test, so
+// code reading it does not need a database
+func seedConfigCache[T any](t *testing.T, cache *configCache[T], items []T) {
+	t.Helper()
+	cache.mu.Lock()
+	cache.items, cache.loadedAt = items, time.Now()
+	cache.mu.Unlock()
+	t.Cleanup(cache.reset)
+}
+
+// seedDefaultStatuses seeds the status cache with the statuses created by
+// the migrations plus a custom one
+func seedDefaultStatuses(t *testing.T) {
+	seedConfigCache(t, statusCache, []models.Status{
+		{Key: "todo", Label: "To Do", Position: 0},
+		{Key: "in_progress", Label: "In Progress", Position: 1},
+		{Key: "review", Label: "In Review", Position: 2},
+		{Key: "done", Label: "Done", Position: 3, IsDone: true},
+	})
+}
+
+// seedDefaultPriorities seeds the priority cache with the priorities created
+// by the migrations
+func seedDefaultPriorities(t *testing.T) {
+	seedConfigCache(t, priorityCache, []models.Priority{
+		{Key: "high", Label: "High", Weight: 300},
+		{Key: "medium", Label: "Medium", Weight: 200, IsDefault: true},
+		{Key: "low", Label: "Low", Weight: 100},
+	})
+}
+
+func TestNormalizeEnumValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"todo", "todo"},
+		{"TODO", "todo"},
+		{"In Progress", "in_progress"},
+		{"in-progress", "in_progress"},
+		{"IN_PROGRESS", "in_progress"},
+		{"  in progress  ", "in_progress"},
+		{"in  -  progress", "in_progress"},
+		{"in__progress", "in_progress"},
+		{"\tdone\n", "done"},
+		{"", ""},
+		{"   ", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeEnumValue(tt.value); got != tt.want {
+			t.Errorf("normalizeEnumValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestResolveStatus(t *testing.T) {
+	seedDefaultStatuses(t)
+	tests := []struct {
+		value string
+		want  string // "" when the value matches no status
+	}{
+		{"todo", "todo"},
+		{"To Do", "todo"},
+		{"to-do", "todo"},
+		{"open", "todo"},
+		{"In Progress", "in_progress"},
+		{"WIP", "in_progress"},
+		{"inprogress", "in_progress"},
+		{"in review", "review"},
+		{"Review", "review"},
+		{"Completed", "done"},
+		{"closed", "done"},
+		{"blocked", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			status, ok, err := resolveStatus(context.Background(), tt.value)
+			if err != nil {
+				t.Fatalf("resolveStatus: %v", err)
+			}
+			if ok != (tt.want != "") || status.Key != tt.want {
+				t.Errorf("resolveStatus(%q) = %q, %t, want %q", tt.value, status.Key, ok, tt.want)
+			}
+		})
+	}
+}
+
+// An alias only applies while its target status exists; a custom status
+// whose key or label matches wins over an alias
+func TestResolveStatusAliasTargets(t *testing.T) {
+	seedConfigCache(t, statusCache, []models.Status{
+		{Key: "backlog", Label: "Backlog"},
+		{Key: "open", Label: "Open for pickup"},
+		{Key: "shipped", Label: "Shipped", IsDone: true},
+	})
+	for value, want := range map[string]string{"open": "open", "completed": "", "Open for pickup": "open"} {
+		status, ok, err := resolveStatus(context.Background(), value)
+		if err != nil {
+			t.Fatalf("resolveStatus: %v", err)
+		}
+		if ok != (want != "") || status.Key != want {
+			t.Errorf("resolveStatus(%q) = %q, %t, want %q", value, status.Key, ok, want)
+		}
+	}
+}
+
+func TestResolvePriority(t *testing.T) {
+	seedDefaultPriorities(t)
+	tests := []struct {
+		key, label string
+		want       string
+	}{
+		{"high", "", "high"},
+		{"HIGH", "", "high"},
+		{" Medium ", "", "medium"},
+		{"hi", "", "high"},
+		{"normal", "", "medium"},
+		{"lo", "", "low"},
+		{"", "Low", "low"},
+		{"", "med", "medium"},
+		{"high", "low", "high"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		got, err := resolvePriority(context.Background(), tt.key, tt.label)
+		if err != nil {
+			t.Errorf("resolvePriority(%q, %q): %v", tt.key, tt.label, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("resolvePriority(%q, %q) = %q, want %q", tt.key, tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestResolvePriorityUnknown(t *testing.T) {
+	seedDefaultPriorities(t)
+	_, err := resolvePriority(context.Background(), "urgent", "")
+	var priorityErr *priorityError
+	if !errors.As(err, &priorityErr) {
+		t.Fatalf("resolvePriority(urgent) error = %v, want a *priorityError", err)
+	}
+	if want := "Invalid priority. Must be one of: high, medium, low"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+}
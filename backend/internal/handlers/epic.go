@@ -0,0 +1,563 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// defaultColor is used when an epic or status is created without a color
+const defaultColor = "#64748b"
+
+// epicColumns is the column list selected by every epic query; scanEpic reads it back
+const epicColumns = `e.id, e.title, COALESCE(e.description, '') AS description, e.color, e.status, e.created_at, e.updated_at`
+
+// scanEpic scans a row selected with epicColumns into epic
+func scanEpic(row pgx.Row, epic *models.Epic) error {
+	return row.Scan(&epic.ID, &epic.Title, &epic.Description, &epic.Color, &epic.Status, &epic.CreatedAt, &epic.UpdatedAt)
+}
+
+// hexColorPattern matches #RRGGBB hex colors
+var hexColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// errEpicHasTodos is returned when deleting an epic that still has todos
+// without saying where they should go
+var errEpicHasTodos = errors.New("epic has todos")
+
+// queryEpics loads epics matching whereClause (written against alias e) with
+// their rolled-up progress, grouping todos by epic and status in one query.
+// Subtasks are counted from the counters kept on each todo, and tracked time
+// includes running timers as getTrackedSeconds does.
+func queryEpics(ctx context.Context, whereClause string, args ...interface{}) ([]models.Epic, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+epicColumns+`, t.status, COALESCE(s.is_done, FALSE), COUNT(t.id), COALESCE(SUM(t.story_points), 0),
+			COALESCE(SUM(t.subtask_count), 0), COALESCE(SUM(t.subtask_completed_count), 0),
+			COALESCE(SUM(t.story_points * CASE
+				WHEN s.is_done THEN 1
+				WHEN t.subtask_count > 0 THEN t.subtask_completed_count::float8 / t.subtask_count
+				ELSE 0
+			END), 0),
+			COALESCE(SUM(t.estimate_minutes), 0),
+			COALESCE(SUM((
+				SELECT SUM(EXTRACT(EPOCH FROM COALESCE(te.ended_at, LOCALTIMESTAMP) - te.started_at))
+				FROM time_entries te WHERE te.todo_id = t.id
+			)), 0)::bigint
+		FROM epics e
+		LEFT JOIN todos t ON t.epic_id = e.id
+		LEFT JOIN statuses s ON s.key = t.status
+		`+whereClause+`
+		GROUP BY e.id, t.status, s.is_done
+		ORDER BY e.created_at DESC, e.id DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	epics := []models.Epic{}
+	// Subtask and weighted point totals per epic, turned into percentages
+	// once every status group is read
+	type epicRollup struct {
+		subtasks, subtasksDone int
+		pointsComplete         float64
+	}
+	rollups := map[int64]*epicRollup{}
+	for rows.Next() {
+		var epic models.Epic
+		var status *string
+		var isDone bool
+		var count, points, subtasks, subtasksDone, estimate int
+		var pointsComplete float64
+		var tracked int64
+		err := rows.Scan(&epic.ID, &epic.Title, &epic.Description, &epic.Color, &epic.Status, &epic.CreatedAt, &epic.UpdatedAt, &status, &isDone, &count, &points, &subtasks, &subtasksDone, &pointsComplete, &estimate, &tracked)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(epics) == 0 || epics[len(epics)-1].ID != epic.ID {
+			epic.Progress = &models.EpicProgress{TodosByStatus: map[string]int{}}
+			epics = append(epics, epic)
+			rollups[epic.ID] = &epicRollup{}
+		}
+		if status == nil {
+			continue
+		}
+		rollup := rollups[epic.ID]
+		rollup.subtasks += subtasks
+		rollup.subtasksDone += subtasksDone
+		rollup.pointsComplete += pointsComplete
+
+		progress := epics[len(epics)-1].Progress
+		progress.TodosByStatus[*status] = count
+		progress.TotalTodos += count
+		progress.PointsTotal += points
+		progress.EstimateMinutes += estimate
+		progress.TrackedSeconds += tracked
+		if isDone {
+			progress.TodosDone += count
+			progress.PointsDone += points
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, epic := range epics {
+		progress := epic.Progress
+		switch {
+		case progress.PointsTotal > 0:
+			progress.PercentComplete = progress.PointsDone * 100 / progress.PointsTotal
+		case progress.TotalTodos > 0:
+			progress.PercentComplete = progress.TodosDone * 100 / progress.TotalTodos
+		}
+		rollup := rollups[epic.ID]
+		if rollup.subtasks > 0 {
+			percent := rollup.subtasksDone * 100 / rollup.subtasks
+			progress.SubtaskProgress = &percent
+		}
+		if progress.PointsTotal > 0 {
+			percent := int(rollup.pointsComplete * 100 / float64(progress.PointsTotal))
+			progress.PointsProgress = &percent
+		}
+	}
+	return epics, nil
+}
+
+// GetEpics godoc
+// @Summary      List epics
+// @Description  Get all epics, newest first, each with its todo counts by status, story points done/total, percent complete, and the subtask and story-point-weighted progress of its todos (null when there is nothing to measure)
+// @Tags         epics
+// @Accept       json
+// @Produce      json
+// @Param        status    query     string  false  "Filter by status (open, closed)"
+// @Param        envelope  query     bool    false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200       {array}   models.Epic  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /epics [get]
+func GetEpics(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	whereClause := ""
+	queryArgs := []interface{}{}
+	if status := c.Query("status"); status != "" {
+		if status != models.EpicStatusOpen && status != models.EpicStatusClosed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Must be one of: open, closed"})
+			return
+		}
+		whereClause = "WHERE e.status = $1"
+		queryArgs = append(queryArgs, status)
+	}
+
+	epics, err := queryEpics(c.Request.Context(), whereClause, queryArgs...)
+	if err != nil {
+		log.Printf("Error querying epics: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch epics", "details": err.Error()})
+		return
+	}
+
+	respondList(c, envelope, epics, int64(len(epics)), 0, 0)
+}
+
+// GetEpic godoc
+// @Summary      Get an epic by ID
+// @Description  Get a single epic with its rolled-up progress
+// @Tags         epics
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Epic ID"
+// @Success      200  {object}  models.Epic
+// @Header       200  {string}  ETag  "Strong ETag of the resource"
+// @Success      304  "Not Modified"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /epics/{id} [get]
+func GetEpic(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid epic ID"})
+		return
+	}
+
+	epics, err := queryEpics(c.Request.Context(), "WHERE e.id = $1", id)
+	if err != nil {
+		log.Printf("Error fetching epic: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch epic", "details": err.Error()})
+		return
+	}
+	if len(epics) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Epic not found"})
+		return
+	}
+
+	respondResource(c, epics[0])
+}
+
+// CreateEpic godoc
+// @Summary      Create an epic
+// @Description  Create a new open epic
+// @Tags         epics
+// @Accept       json
+// @Produce      json
+// @Param        epic  body      models.CreateEpicRequest  true  "Epic data"
+// @Success      201   {object}  models.Epic
+// @Header       201   {string}  Location  "URL of the created resource"
+// @Header       201   {string}  ETag      "Strong ETag of the created resource"
+// @Failure      400   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /epics [post]
+func CreateEpic(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.CreateEpicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	color := req.Color
+	if color == "" {
+		color = defaultColor
+	}
+	if !hexColorPattern.MatchString(color) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid color. Use #RRGGBB"})
+		return
+	}
+
+	var description interface{}
+	if req.Description != "" {
+		description = req.Description
+	}
+
+	var epic models.Epic
+	ctx := c.Request.Context()
+	err := db.WithTx(ctx, func(tx pgx.Tx) error {
+		err := scanEpic(tx.QueryRow(ctx, `
+			INSERT INTO epics AS e (title, description, color, status, created_at, updated_at)
+			VALUES ($1, $2, $3, 'open', NOW(), NOW())
+			RETURNING `+epicColumns+`
+		`, req.Title, description, color), &epic)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionCreate, models.AuditEntityEpic, epic.ID, nil, epic)
+	})
+	if err != nil {
+		log.Printf("Error creating epic: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create epic", "details": err.Error()})
+		return
+	}
+
+	epic.Progress = &models.EpicProgress{TodosByStatus: map[string]int{}}
+	respondCreated(c, fmt.Sprintf("/epics/%d", epic.ID), epic)
+}
+
+// UpdateEpic godoc
+// @Summary      Update an epic
+// @Description  Update an epic's title, description, color or status
+// @Tags         epics
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int  true  "Epic ID"
+// @Param        epic  body      models.UpdateEpicRequest  true  "Epic data"
+// @Success      200   {object}  models.Epic
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /epics/{id} [put]
+func UpdateEpic(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid epic ID"})
+		return
+	}
+
+	var req models.UpdateEpicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Color != "" && !hexColorPattern.MatchString(req.Color) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid color. Use #RRGGBB"})
+		return
+	}
+
+	// An explicit empty description clears it; an omitted one leaves it alone
+	var description interface{}
+	if req.Description != nil {
+		description = *req.Description
+	}
+
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var before, after models.Epic
+		if err := scanEpic(tx.QueryRow(ctx, `
+			SELECT `+epicColumns+` FROM epics e WHERE e.id = $1 FOR UPDATE
+		`, id), &before); err != nil {
+			return err
+		}
+
+		err := scanEpic(tx.QueryRow(ctx, `
+			UPDATE epics AS e
+			SET title = COALESCE(NULLIF($1, ''), title),
+			    description = CASE WHEN $2::text IS NULL THEN description ELSE NULLIF($2::text, '') END,
+			    color = COALESCE(NULLIF($3, ''), color),
+			    status = COALESCE(NULLIF($4, ''), status),
+			    updated_at = NOW()
+			WHERE e.id = $5
+			RETURNING `+epicColumns+`
+		`, req.Title, description, req.Color, req.Status, id), &after)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionUpdate, models.AuditEntityEpic, after.ID, before, after)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Epic not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating epic: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update epic", "details": err.Error()})
+		return
+	}
+
+	epics, err := queryEpics(ctx, "WHERE e.id = $1", id)
+	if err != nil || len(epics) == 0 {
+		log.Printf("Error fetching epic: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch epic"})
+		return
+	}
+
+	c.JSON(http.StatusOK, epics[0])
+}
+
+// DeleteEpic godoc
+// @Summary      Delete an epic
+// @Description  Delete an epic. If it still has todos, either reassign_to another epic or detach them; otherwise the request is rejected with 409.
+// @Tags         epics
+// @Accept       json
+// @Produce      json
+// @Param        id           path      int     true   "Epic ID"
+// @Param        reassign_to  query     int     false  "Epic to move the todos to"
+// @Param        detach       query     bool    false  "Remove the todos from any epic"
+// @Success      204  "No Content"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /epics/{id} [delete]
+func DeleteEpic(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid epic ID"})
+		return
+	}
+
+	var reassignTo *int64
+	if value := c.Query("reassign_to"); value != "" {
+		target, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || target == id {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reassign_to. Must be another epic's ID"})
+			return
+		}
+		reassignTo = &target
+	}
+	detach := c.Query("detach") == "true"
+	if reassignTo != nil && detach {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Use either reassign_to or detach, not both"})
+		return
+	}
+
+	var todoCount int
+	var targetMissing bool
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var before models.Epic
+		if err := scanEpic(tx.QueryRow(ctx, `
+			SELECT `+epicColumns+` FROM epics e WHERE e.id = $1 FOR UPDATE
+		`, id), &before); err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, `SELECT id FROM todos WHERE epic_id = $1 ORDER BY id`, id)
+		if err != nil {
+			return err
+		}
+		todoIDs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+		if err != nil {
+			return err
+		}
+
+		if len(todoIDs) > 0 {
+			if reassignTo == nil && !detach {
+				todoCount = len(todoIDs)
+				return errEpicHasTodos
+			}
+			if reassignTo != nil {
+				var exists bool
+				if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM epics WHERE id = $1)`, *reassignTo).Scan(&exists); err != nil {
+					return err
+				}
+				if !exists {
+					targetMissing = true
+					return pgx.ErrNoRows
+				}
+			}
+			if _, err := moveTodos(ctx, tx, c, todoIDs, "epic_id", reassignTo); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM epics WHERE id = $1`, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionDelete, models.AuditEntityEpic, before.ID, before, nil)
+	})
+	if targetMissing {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Epic to reassign to not found"})
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Epic not found"})
+		return
+	}
+	if errors.Is(err, errEpicHasTodos) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Epic has todos. Pass reassign_to or detach=true", "todo_count": todoCount})
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting epic: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete epic", "details": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AddEpicTodos godoc
+// @Summary      Add todos to an epic
+// @Description  Assign todos to an epic, moving them out of any other epic. Todos already in the epic are left as they are.
+// @Tags         epics
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int  true  "Epic ID"
+// @Param        todos  body      models.EpicTodosRequest  true  "Todo IDs"
+// @Success      200    {array}   models.Todo
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /epics/{id}/todos [post]
+func AddEpicTodos(c *gin.Context) {
+	updateEpicTodos(c, true)
+}
+
+// RemoveEpicTodos godoc
+// @Summary      Remove todos from an epic
+// @Description  Detach todos from an epic. Todos that are not in the epic are ignored.
+// @Tags         epics
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int  true  "Epic ID"
+// @Param        todos  body      models.EpicTodosRequest  true  "Todo IDs"
+// @Success      200    {array}   models.Todo
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /epics/{id}/todos [delete]
+func RemoveEpicTodos(c *gin.Context) {
+	updateEpicTodos(c, false)
+}
+
+// updateEpicTodos implements AddEpicTodos and RemoveEpicTodos and responds
+// with the todos that changed
+func updateEpicTodos(c *gin.Context, add bool) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid epic ID"})
+		return
+	}
+
+	var req models.EpicTodosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var moved []models.Todo
+	var missing []int64
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var epicID int64
+		if err := tx.QueryRow(ctx, `SELECT id FROM epics WHERE id = $1 FOR SHARE`, id).Scan(&epicID); err != nil {
+			return err
+		}
+		var err error
+		moved, missing, err = assignTodos(ctx, tx, c, req.TodoIDs, "epic_id", id, add)
+		return err
+	})
+	if errors.Is(err, errTodosNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found", "todo_ids": missing})
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Epic not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating epic todos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update epic", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, moved)
+}
@@ -0,0 +1,461 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/linkpreview"
+	"flow-v1/backend/internal/models"
+)
+
+// maxEpicArchiveBytes bounds the size of an uploaded epic archive
+const maxEpicArchiveBytes = 32 << 20
+
+// Files of an epic archive
+const (
+	epicArchiveManifest = "manifest.json"
+	epicArchiveEpic     = "epic.json"
+	epicArchiveTodos    = "todos.json"
+)
+
+// archivedTodoSQL selects todos t in their archived form, one per row, with
+// their subtasks and links aggregated as JSON so an export streams row by
+// row. Timestamps are converted to timestamptz so their JSON carries a zone.
+const archivedTodoSQL = `
+	SELECT t.title, COALESCE(t.description, ''), t.status, t.priority_key, t.due_date, t.all_day, t.due_timezone,
+		t.story_points, t.estimate_minutes, t.custom_fields, t.completed_at, t.created_at,
+		COALESCE((
+			SELECT json_agg(json_build_object(
+				'title', s.title, 'completed', s.completed, 'completed_at', s.completed_at AT TIME ZONE 'UTC'
+			) ORDER BY s.created_at, s.id)
+			FROM subtasks s WHERE s.todo_id = t.id
+		), '[]'),
+		COALESCE((
+			SELECT json_agg(json_build_object(
+				'url', l.url, 'title', l.title, 'favicon_url', l.favicon_url
+			) ORDER BY l.created_at, l.id)
+			FROM todo_links l WHERE l.todo_id = t.id
+		), '[]')
+	FROM todos t
+`
+
+// epicArchiveTodoSQL selects the todos of an epic archive
+const epicArchiveTodoSQL = archivedTodoSQL + `
+	WHERE t.epic_id = $1
+	ORDER BY t.created_at, t.id
+`
+
+// scanArchivedTodo scans a row of archivedTodoSQL
+func scanArchivedTodo(row pgx.Row, todo *models.EpicArchiveTodo) error {
+	return row.Scan(&todo.Title, &todo.Description, &todo.Status, &todo.PriorityKey, &todo.DueDate, &todo.AllDay, &todo.DueTimezone,
+		&todo.StoryPoints, &todo.Estimate, &todo.CustomFields, &todo.CompletedAt, &todo.CreatedAt, &todo.Subtasks, &todo.Links)
+}
+
+// writeArchiveJSON adds a JSON file to an archive
+func writeArchiveJSON(archive *zip.Writer, name string, value interface{}) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// readArchiveJSON decodes a JSON file of an archive into value
+func readArchiveJSON(archive *zip.Reader, name string, value interface{}) error {
+	file, err := archive.Open(name)
+	if err != nil {
+		return fmt.Errorf("archive has no %s", name)
+	}
+	defer file.Close()
+	if err := json.NewDecoder(file).Decode(value); err != nil {
+		return fmt.Errorf("invalid %s: %v", name, err)
+	}
+	return nil
+}
+
+// ExportEpic godoc
+// @Summary      Export an epic as a ZIP archive
+// @Description  Download an epic with all its todos as a ZIP archive holding epic.json, todos.json (each todo with its subtasks and links) and manifest.json (format version and counts). The archive is streamed as it is built. Sprints and time entries are not included. POST /epics/import reads it back.
+// @Tags         epics
+// @Produce      application/zip
+// @Param        id   path      int  true  "Epic ID"
+// @Success      200  {file}    file
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /epics/{id}/export.zip [get]
+func ExportEpic(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid epic ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var epic models.Epic
+	err = scanEpic(db.Pool.QueryRow(ctx, `SELECT `+epicColumns+` FROM epics e WHERE e.id = $1`, id), &epic)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Epic not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching epic: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch epic", "details": err.Error()})
+		return
+	}
+
+	rows, err := db.Pool.Query(ctx, epicArchiveTodoSQL, id)
+	if err != nil {
+		log.Printf("Error querying epic todos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export epic", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="epic-%d.zip"`, id))
+	c.Status(http.StatusOK)
+
+	archive := zip.NewWriter(c.Writer)
+	manifest := models.EpicArchiveManifest{Version: models.EpicArchiveVersion, ExportedAt: time.Now().UTC(), EpicID: id}
+	err = func() error {
+		if err := writeArchiveJSON(archive, epicArchiveEpic, models.EpicArchiveEpic{
+			Title: epic.Title, Description: epic.Description, Color: epic.Color, Status: epic.Status,
+		}); err != nil {
+			return err
+		}
+
+		// todos.json is written one todo at a time as the rows arrive
+		file, err := archive.Create(epicArchiveTodos)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, "["); err != nil {
+			return err
+		}
+		for rows.Next() {
+			var todo models.EpicArchiveTodo
+			if err := scanArchivedTodo(rows, &todo); err != nil {
+				return err
+			}
+			data, err := json.Marshal(todo)
+			if err != nil {
+				return err
+			}
+			separator := ",\n"
+			if manifest.Todos == 0 {
+				separator = "\n"
+			}
+			if _, err := io.WriteString(file, separator); err != nil {
+				return err
+			}
+			if _, err := file.Write(data); err != nil {
+				return err
+			}
+			manifest.Todos++
+			manifest.Subtasks += len(todo.Subtasks)
+			manifest.Links += len(todo.Links)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, "\n]\n"); err != nil {
+			return err
+		}
+
+		if err := writeArchiveJSON(archive, epicArchiveManifest, manifest); err != nil {
+			return err
+		}
+		return archive.Close()
+	}()
+	if err != nil {
+		// Headers are already sent, so the best we can do is log and stop;
+		// the archive is left without its central directory and will not open
+		log.Printf("Error exporting epic %d: %v", id, err)
+	}
+}
+
+// ImportEpic godoc
+// @Summary      Import an epic from a ZIP archive
+// @Description  Create a new epic with its todos, subtasks and links from an archive made by GET /epics/{id}/export.zip, sent as the request body (at most 32 MB). Statuses, priorities, story points and custom fields must be valid here; todos whose priority no longer exists get the default priority. Everything is created in one transaction. With dry_run=true the import is run and rolled back and the result returned with 200; its IDs are not kept.
+// @Tags         epics
+// @Accept       application/zip
+// @Produce      json
+// @Param        archive  body      string  true   "Epic archive"
+// @Param        dry_run  query     bool    false  "Only report what would be created"  default(false)
+// @Success      200      {object}  models.EpicImportResult
+// @Success      201      {object}  models.EpicImportResult
+// @Header       201      {string}  Location  "URL of the created epic"
+// @Failure      400      {object}  map[string]string
+// @Failure      413      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Router       /epics/import [post]
+func ImportEpic(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxEpicArchiveBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Archive is larger than 32 MB"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read archive", "details": err.Error()})
+		return
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request body is not a ZIP archive"})
+		return
+	}
+
+	var manifest models.EpicArchiveManifest
+	var epic models.EpicArchiveEpic
+	var todos []models.EpicArchiveTodo
+	for _, file := range []struct {
+		name  string
+		value interface{}
+	}{{epicArchiveManifest, &manifest}, {epicArchiveEpic, &epic}, {epicArchiveTodos, &todos}} {
+		if err := readArchiveJSON(archive, file.name, file.value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if manifest.Version != models.EpicArchiveVersion {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unsupported archive version %d. Expected %d", manifest.Version, models.EpicArchiveVersion)})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if message, err := validateEpicArchive(c, &epic, todos); err != nil {
+		log.Printf("Error validating epic archive: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import epic", "details": err.Error()})
+		return
+	} else if message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+
+	result := models.EpicImportResult{Todos: len(todos), DryRun: dryRun}
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var description interface{}
+		if epic.Description != "" {
+			description = epic.Description
+		}
+		if err := scanEpic(tx.QueryRow(ctx, `
+			INSERT INTO epics AS e (title, description, color, status, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, NOW(), NOW())
+			RETURNING `+epicColumns+`
+		`, epic.Title, description, epic.Color, epic.Status), &result.Epic); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, tx, c, models.AuditActionCreate, models.AuditEntityEpic, result.Epic.ID, nil, result.Epic); err != nil {
+			return err
+		}
+
+		for _, archived := range todos {
+			if _, err := insertArchivedTodo(ctx, tx, c, archived, &result.Epic.ID); err != nil {
+				return err
+			}
+			result.Subtasks += len(archived.Subtasks)
+			result.Links += len(archived.Links)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error importing epic: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import epic", "details": err.Error()})
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	epics, err := queryEpics(ctx, "WHERE e.id = $1", result.Epic.ID)
+	if err != nil || len(epics) == 0 {
+		log.Printf("Error fetching imported epic: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch imported epic"})
+		return
+	}
+	result.Epic = epics[0]
+
+	respondCreatedAs(c, fmt.Sprintf("/epics/%d", result.Epic.ID), result, result.Epic)
+}
+
+// validateEpicArchive checks an archive's epic and todos against the current
+// configuration, normalizing them in place. It returns a message for the
+// client when the archive is invalid, and an error only when the
+// configuration could not be loaded.
+func validateEpicArchive(c *gin.Context, epic *models.EpicArchiveEpic, todos []models.EpicArchiveTodo) (string, error) {
+	ctx := c.Request.Context()
+	if epic.Title == "" {
+		return "epic.json has no title", nil
+	}
+	if epic.Color == "" {
+		epic.Color = defaultColor
+	}
+	if !hexColorPattern.MatchString(epic.Color) {
+		return fmt.Sprintf("epic.json has invalid color %q", epic.Color), nil
+	}
+	if epic.Status != models.EpicStatusOpen && epic.Status != models.EpicStatusClosed {
+		return fmt.Sprintf("epic.json has invalid status %q", epic.Status), nil
+	}
+
+	customFields, err := loadCustomFields(ctx)
+	if err != nil {
+		return "", err
+	}
+	for i := range todos {
+		if message, err := validateArchivedTodo(ctx, customFields, &todos[i], fmt.Sprintf("todos.json item %d", i+1)); message != "" || err != nil {
+			return message, err
+		}
+	}
+	return "", nil
+}
+
+// validateArchivedTodo checks an archived todo against the current
+// configuration like validateEpicArchive, naming it position in messages
+func validateArchivedTodo(ctx context.Context, customFields map[string]models.CustomFieldDefinition, todo *models.EpicArchiveTodo, position string) (string, error) {
+	if todo.Title == "" {
+		return fmt.Sprintf("%s has no title", position), nil
+	}
+	_, known, err := lookupStatus(ctx, todo.Status)
+	if err != nil {
+		return "", err
+	}
+	if !known {
+		return fmt.Sprintf("%s has unknown status %q", position, todo.Status), nil
+	}
+	if todo.PriorityKey != nil {
+		key, err := resolvePriority(ctx, *todo.PriorityKey, "")
+		var priorityErr *priorityError
+		if errors.As(err, &priorityErr) {
+			todo.PriorityKey = nil
+		} else if err != nil {
+			return "", err
+		} else {
+			todo.PriorityKey = &key
+		}
+	}
+	if message, err := checkStoryPoints(ctx, todo.StoryPoints); err != nil {
+		return "", err
+	} else if message != "" {
+		return fmt.Sprintf("%s has invalid story points %d", position, *todo.StoryPoints), nil
+	}
+	if checkEstimate(todo.Estimate) != "" {
+		return fmt.Sprintf("%s has invalid estimate_minutes %d", position, *todo.Estimate), nil
+	}
+	if todo.CustomFields, err = validateCustomFields(customFields, todo.CustomFields, false); err != nil {
+		return fmt.Sprintf("%s: %v", position, err), nil
+	}
+	if todo.CustomFields == nil {
+		todo.CustomFields = map[string]interface{}{}
+	}
+	if todo.CreatedAt.IsZero() {
+		todo.CreatedAt = time.Now().UTC()
+	}
+	for _, subtask := range todo.Subtasks {
+		if subtask.Title == "" {
+			return fmt.Sprintf("%s has a subtask without a title", position), nil
+		}
+	}
+	for _, link := range todo.Links {
+		if _, err := linkpreview.ValidateURL(link.URL); err != nil {
+			return fmt.Sprintf("%s has invalid link %q", position, link.URL), nil
+		}
+	}
+	return "", nil
+}
+
+// insertArchivedTodo creates a todo with its subtasks and links from its
+// archived form, validated by validateArchivedTodo, in epic epicID or
+// outside any epic when nil
+func insertArchivedTodo(ctx context.Context, tx pgx.Tx, c *gin.Context, archived models.EpicArchiveTodo, epicID *int64) (models.Todo, error) {
+	var description interface{}
+	if archived.Description != "" {
+		description = archived.Description
+	}
+	completed := 0
+	for _, subtask := range archived.Subtasks {
+		if subtask.Completed {
+			completed++
+		}
+	}
+
+	var todo models.Todo
+	if err := scanTodo(tx.QueryRow(ctx, `
+		INSERT INTO todos (title, description, status, due_date, all_day, due_timezone, priority_key, story_points, epic_id, custom_fields,
+			completed_at, subtask_count, subtask_completed_count, created_at, updated_at, estimate_minutes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+			CASE WHEN $3 IN (`+doneStatusesSQL+`) THEN COALESCE($11, NOW()) END, $12, $13, $14, NOW(), $15)
+		RETURNING `+todoColumns+`
+	`, archived.Title, description, archived.Status, archived.DueDate, archived.AllDay, archived.DueTimezone, archived.PriorityKey,
+		archived.StoryPoints, epicID, archived.CustomFields, archived.CompletedAt, len(archived.Subtasks), completed, archived.CreatedAt,
+		archived.Estimate), &todo); err != nil {
+		return todo, err
+	}
+
+	// clock_timestamp keeps the subtasks in their archived order
+	for _, subtask := range archived.Subtasks {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO subtasks (todo_id, title, completed, completed_at, created_at, updated_at)
+			VALUES ($1, $2, $3, CASE WHEN $3 THEN COALESCE($4, NOW()) END, clock_timestamp(), clock_timestamp())
+		`, todo.ID, subtask.Title, subtask.Completed, subtask.CompletedAt); err != nil {
+			return todo, err
+		}
+	}
+	// Links keep their archived metadata rather than being fetched again
+	for _, link := range archived.Links {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO todo_links (todo_id, url, title, favicon_url, metadata_status, created_at)
+			VALUES ($1, $2, $3, $4, $5, clock_timestamp())
+		`, todo.ID, link.URL, link.Title, link.FaviconURL, models.LinkMetadataSkipped); err != nil {
+			return todo, err
+		}
+	}
+
+	if err := syncTodoReferences(ctx, tx, todo); err != nil {
+		return todo, err
+	}
+	if err := recordTodoEvent(ctx, tx, models.TodoEvent{TodoID: todo.ID, Type: models.TodoEventCreated}); err != nil {
+		return todo, err
+	}
+	if err := recordEstimateChange(ctx, tx, c, nil, todo.ID, nil, todo.StoryPoints, models.EstimateChangeImport); err != nil {
+		return todo, err
+	}
+	if err := recordAudit(ctx, tx, c, models.AuditActionCreate, models.AuditEntityTodo, todo.ID, nil, todo); err != nil {
+		return todo, err
+	}
+	return todo, nil
+}
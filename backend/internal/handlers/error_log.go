@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultErrorLogMaxBytes caps how much of each request and response body
+// ErrorBodyLog keeps
+const defaultErrorLogMaxBytes = 8 << 10
+
+// defaultRedactKeys are the JSON keys whose values ErrorBodyLog never logs.
+// A key is redacted when its lowercased name contains any of them.
+var defaultRedactKeys = []string{"password", "token", "secret", "authorization", "api_key", "apikey"}
+
+// redactedValue replaces the value of every redacted key
+const redactedValue = "[REDACTED]"
+
+// omitBodyKey is the gin context key set by omitRequestBody
+const omitBodyKey = "omit_request_body"
+
+// omittedBody is logged in place of a request body kept out of the log
+const omittedBody = "[OMITTED]"
+
+// omitRequestBody keeps the request body of c out of the error log whatever
+// the redact keys, for requests whose body is a secret as a whole
+func omitRequestBody(c *gin.Context) {
+	c.Set(omitBodyKey, true)
+}
+
+// ErrorLogConfig configures ErrorBodyLog
+type ErrorLogConfig struct {
+	// MaxBytes caps the captured request and response bodies
+	MaxBytes int
+	// RedactKeys are matched, lowercased, against every JSON key at any depth
+	RedactKeys []string
+}
+
+// ErrorLogConfigFromEnv reads LOG_ERROR_BODIES, which turns the logging on,
+// LOG_BODY_MAX_BYTES and LOG_REDACT_KEYS (comma-separated, replacing the
+// defaults). enabled is false unless LOG_ERROR_BODIES is true.
+func ErrorLogConfigFromEnv() (cfg ErrorLogConfig, enabled bool, err error) {
+	cfg = ErrorLogConfig{MaxBytes: defaultErrorLogMaxBytes, RedactKeys: defaultRedactKeys}
+	if value := os.Getenv("LOG_ERROR_BODIES"); value != "" {
+		if enabled, err = strconv.ParseBool(value); err != nil {
+			return cfg, false, fmt.Errorf("invalid LOG_ERROR_BODIES: %q", value)
+		}
+	}
+	if value := os.Getenv("LOG_BODY_MAX_BYTES"); value != "" {
+		if cfg.MaxBytes, err = strconv.Atoi(value); err != nil || cfg.MaxBytes <= 0 {
+			return cfg, false, fmt.Errorf("invalid LOG_BODY_MAX_BYTES: %q", value)
+		}
+	}
+	if value := os.Getenv("LOG_REDACT_KEYS"); value != "" {
+		cfg.RedactKeys = nil
+		for _, key := range strings.Split(value, ",") {
+			if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+				cfg.RedactKeys = append(cfg.RedactKeys, key)
+			}
+		}
+	}
+	return cfg, enabled, nil
+}
+
+// boundedBuffer keeps the first max bytes written to it and drops the rest
+type boundedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *boundedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); len(p) > room {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// teeBody reads a request body while copying it into a boundedBuffer
+type teeBody struct {
+	io.Reader
+	io.Closer
+}
+
+// errorCaptureWriter copies error responses into a boundedBuffer. Success
+// responses and anything flushed, such as a stream, pass straight through.
+type errorCaptureWriter struct {
+	gin.ResponseWriter
+	body      *boundedBuffer
+	streaming bool
+}
+
+func (w *errorCaptureWriter) capturing() bool {
+	return !w.streaming && w.Status() >= http.StatusBadRequest && isJSON(w.Header().Get("Content-Type"))
+}
+
+func (w *errorCaptureWriter) Write(data []byte) (int, error) {
+	if w.capturing() {
+		w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *errorCaptureWriter) WriteString(s string) (int, error) {
+	if w.capturing() {
+		w.body.Write([]byte(s))
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *errorCaptureWriter) Flush() {
+	w.streaming = true
+	w.ResponseWriter.Flush()
+}
+
+// isJSON reports whether a Content-Type header is JSON
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// ErrorBodyLog logs the request and response bodies of every request that
+// ends with a 4xx or 5xx, with the request ID and user, so a failed call can
+// be replayed from the log. Only JSON bodies are captured, each up to
+// cfg.MaxBytes, so uploads and streams are never buffered; values under
+// cfg.RedactKeys are replaced before logging, in the bodies and in the query
+// string, and request bodies a handler omits with omitRequestBody are not
+// logged at all. Successful requests only pay
+// for copying the request body into the bounded buffer as it is read.
+func ErrorBodyLog(cfg ErrorLogConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		request := &boundedBuffer{max: cfg.MaxBytes}
+		if c.Request.Body != nil && c.Request.Body != http.NoBody && isJSON(c.GetHeader("Content-Type")) {
+			c.Request.Body = teeBody{io.TeeReader(c.Request.Body, request), c.Request.Body}
+		}
+		writer := &errorCaptureWriter{ResponseWriter: c.Writer, body: &boundedBuffer{max: cfg.MaxBytes}}
+		c.Writer = writer
+
+		c.Next()
+
+		status := writer.Status()
+		if status < http.StatusBadRequest {
+			return
+		}
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" {
+			requestID = "-"
+		}
+		requestBody := omittedBody
+		if !c.GetBool(omitBodyKey) {
+			requestBody = redactBody(request, cfg.RedactKeys)
+		}
+		log.Printf("WARN %s %s %d request_id=%s user=%s request=%s response=%s",
+			c.Request.Method, redactRequestURI(c.Request.URL, cfg.RedactKeys), status, requestID, currentUser(c),
+			requestBody, redactBody(writer.body, cfg.RedactKeys))
+	}
+}
+
+// redactRequestURI returns the path and query of u for logging, with the
+// values of query parameters whose name matches keys replaced. Parameters
+// keep their order and encoding otherwise.
+func redactRequestURI(u *url.URL, keys []string) string {
+	if u.RawQuery == "" {
+		return u.RequestURI()
+	}
+	params := strings.Split(u.RawQuery, "&")
+	for i, param := range params {
+		rawName, _, _ := strings.Cut(param, "=")
+		name, err := url.QueryUnescape(rawName)
+		if err != nil {
+			name = rawName
+		}
+		if sensitiveKey(name, keys) {
+			params[i] = rawName + "=" + redactedValue
+		}
+	}
+	redacted := *u
+	redacted.RawQuery = strings.Join(params, "&")
+	return redacted.RequestURI()
+}
+
+// truncatedJSONField matches a "key": value pair in JSON that may be cut
+// off, where the value is a string, possibly unterminated, a scalar, or the
+// opening bracket of an object or array
+var truncatedJSONField = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"\s*:\s*("(?:[^"\\]|\\.)*"?|[{\[]|[^,{}\[\]"\s]+)`)
+
+// redactBody returns a captured body for logging with sensitive values
+// replaced. Truncated bodies are not valid JSON, so their key-value pairs
+// are redacted by pattern and "..." marks the cut.
+func redactBody(body *boundedBuffer, keys []string) string {
+	if body.buf.Len() == 0 {
+		return "-"
+	}
+	if !body.truncated {
+		var value interface{}
+		if err := json.Unmarshal(body.buf.Bytes(), &value); err == nil {
+			if redacted, err := json.Marshal(redactValue(value, keys)); err == nil {
+				return string(redacted)
+			}
+		}
+	}
+	text := body.buf.String()
+	var redacted strings.Builder
+	for {
+		match := truncatedJSONField.FindStringSubmatchIndex(text)
+		if match == nil {
+			redacted.WriteString(text)
+			break
+		}
+		key, value := text[match[2]:match[3]], text[match[4]:match[5]]
+		end := match[1]
+		if !sensitiveKey(key, keys) {
+			// The pairs inside an object or array are matched on their own
+			redacted.WriteString(text[:end])
+			text = text[end:]
+			continue
+		}
+		if value == "{" || value == "[" {
+			end = match[4] + closingBracket(text[match[4]:])
+		}
+		redacted.WriteString(text[:match[0]] + `"` + key + `":"` + redactedValue + `"`)
+		text = text[end:]
+	}
+	if body.truncated {
+		redacted.WriteString("...")
+	}
+	return redacted.String()
+}
+
+// closingBracket returns the length of the object or array that text starts
+// with, or of all of text when it is cut off before the closing bracket
+func closingBracket(text string) int {
+	depth, inString := 0, false
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			if depth--; depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(text)
+}
+
+// redactValue replaces the values of sensitive keys anywhere in a decoded
+// JSON value
+func redactValue(value interface{}, keys []string) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if sensitiveKey(key, keys) {
+				value[key] = redactedValue
+			} else {
+				value[key] = redactValue(field, keys)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactValue(item, keys)
+		}
+	}
+	return value
+}
+
+// sensitiveKey reports whether key contains any of keys, ignoring case
+func sensitiveKey(key string, keys []string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range keys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
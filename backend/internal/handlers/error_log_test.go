@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"flow-v1/backend/internal/db"
+)
+
+// captured returns a boundedBuffer holding body as ErrorBodyLog would have
+// captured it with max bytes
+func captured(body string, max int) *boundedBuffer {
+	buffer := &boundedBuffer{max: max}
+	buffer.Write([]byte(body))
+	return buffer
+}
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		max  int
+		want string
+	}{
+		{"empty", "", 100, "-"},
+		{"nothing sensitive", `{"title":"Write docs","status":"todo"}`, 100, `{"status":"todo","title":"Write docs"}`},
+		{"top-level keys", `{"password":"hunter2","title":"x"}`, 100, `{"password":"[REDACTED]","title":"x"}`},
+		{"keys are matched by substring ignoring case", `{"GitHub_Token":"abc","newPassword":"p","ApiKey":1}`, 100,
+			`{"ApiKey":"[REDACTED]","GitHub_Token":"[REDACTED]","newPassword":"[REDACTED]"}`},
+		{"nested objects and arrays", `{"items":[{"secret":"s","n":1}],"auth":{"authorization":"Bearer x"}}`, 100,
+			`{"auth":{"authorization":"[REDACTED]"},"items":[{"n":1,"secret":"[REDACTED]"}]}`},
+		{"objects under a sensitive key go whole", `{"tokens":{"a":"b"}}`, 100, `{"tokens":"[REDACTED]"}`},
+		{"invalid JSON is redacted by pattern", `{"token":"abc", oops}`, 100, `{"token":"[REDACTED]", oops}`},
+		{"truncated inside a value", `{"title":"x","password":"hunter2hunter2"}`, 30, `{"title":"x","password":"[REDACTED]"...`},
+		{"truncated after a key", `{"title":"x","secret":"abcdef"}`, 22, `{"title":"x","secret":...`},
+		{"truncated object under a sensitive key", `{"tokens":{"a":"b","c":"d"},"x":1}`, 24, `{"tokens":"[REDACTED]"...`},
+		{"invalid object under a sensitive key", `{"tokens":{"a":"b"} oops,"x":"y"}`, 100, `{"tokens":"[REDACTED]" oops,"x":"y"}`},
+		{"truncated nested object", `{"user":{"password":"hunter2hunter2"},"x":1}`, 30, `{"user":{"password":"[REDACTED]"...`},
+		{"truncated scalar", `{"api_key":12345678,"x":1}`, 15, `{"api_key":"[REDACTED]"...`},
+		{"escaped quotes in values", `{"token":"a\"b","title":"c\"d"}`, 100, `{"title":"c\"d","token":"[REDACTED]"}`},
+	}
+	keys := defaultRedactKeys
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactBody(captured(tt.body, tt.max), keys); got != tt.want {
+				t.Errorf("redactBody = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactBodyNeverLogsSecrets(t *testing.T) {
+	body := `{"user":{"password":"hunter2"},"list":[{"token":"tok-123"}],"secrets":{"k":"shh"},"x":1}`
+	for max := 1; max <= len(body)+1; max++ {
+		got := redactBody(captured(body, max), defaultRedactKeys)
+		for _, secret := range []string{"hunter2", "tok-123", "shh"} {
+			if strings.Contains(got, secret) {
+				t.Errorf("max %d: %s leaks %s", max, got, secret)
+			}
+		}
+	}
+}
+
+func TestRedactRequestURI(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{"/api/v1/todos", "/api/v1/todos"},
+		{"/api/v1/todos?status=todo&limit=10", "/api/v1/todos?status=todo&limit=10"},
+		{"/api/v1/embed/todos?token=abc&limit=5", "/api/v1/embed/todos?token=[REDACTED]&limit=5"},
+		{"/api/v1/x?access_token=abc&Client_Secret=def&q=a%20b", "/api/v1/x?access_token=[REDACTED]&Client_Secret=[REDACTED]&q=a%20b"},
+		{"/api/v1/x?api%5Fkey=abc", "/api/v1/x?api%5Fkey=[REDACTED]"},
+		{"/api/v1/x?token&password=", "/api/v1/x?token=[REDACTED]&password=[REDACTED]"},
+		{"/api/v1/x?token=a&token=b", "/api/v1/x?token=[REDACTED]&token=[REDACTED]"},
+		{"/api/v1/x?%zz=1", "/api/v1/x?%zz=1"},
+	}
+	for _, tt := range tests {
+		u, err := url.ParseRequestURI(tt.uri)
+		if err != nil {
+			t.Fatalf("ParseRequestURI(%q): %v", tt.uri, err)
+		}
+		if got := redactRequestURI(u, defaultRedactKeys); got != tt.want {
+			t.Errorf("redactRequestURI(%s) = %s, want %s", tt.uri, got, tt.want)
+		}
+	}
+}
+
+func TestErrorBodyLog(t *testing.T) {
+	var logged bytes.Buffer
+	output := log.Writer()
+	log.SetOutput(&logged)
+	t.Cleanup(func() { log.SetOutput(output) })
+
+	engine := gin.New()
+	engine.Use(ErrorBodyLog(ErrorLogConfig{MaxBytes: 1 << 10, RedactKeys: defaultRedactKeys}))
+	engine.POST("/fail", func(c *gin.Context) {
+		var body map[string]interface{}
+		c.ShouldBindJSON(&body)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad", "token": "response-token"})
+	})
+	engine.POST("/ok", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	for _, path := range []string{"/ok?token=ok-token", "/fail?token=query-token&limit=5"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"password":"hunter2","title":"x"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Request-ID", "req-1")
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	out := logged.String()
+	if strings.Contains(out, "/ok") {
+		t.Errorf("a successful request was logged: %s", out)
+	}
+	for _, secret := range []string{"query-token", "hunter2", "response-token"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log leaks %s: %s", secret, out)
+		}
+	}
+	want := `WARN POST /fail?token=[REDACTED]&limit=5 400 request_id=req-1`
+	if !strings.Contains(out, want) || !strings.Contains(out, `"title":"x"`) {
+		t.Errorf("log = %s, want the failed request with %q", out, want)
+	}
+}
+
+// A credential value is never logged, even with no redact keys at all
+func TestErrorBodyLogOmitsCredentials(t *testing.T) {
+	var logged bytes.Buffer
+	output := log.Writer()
+	log.SetOutput(&logged)
+	t.Cleanup(func() { log.SetOutput(output) })
+
+	// The pool connects lazily, and the request fails validation before
+	// SetCredential uses it
+	pool, err := pgxpool.New(context.Background(), "postgres://flow@127.0.0.1:1/flow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := db.Pool
+	db.Pool = pool
+	t.Cleanup(func() {
+		db.Pool = previous
+		pool.Close()
+	})
+
+	engine := gin.New()
+	engine.Use(ErrorBodyLog(ErrorLogConfig{MaxBytes: 1 << 20}))
+	engine.PUT("/admin/credentials/:name", SetCredential)
+
+	value := "ghp_leaked" + strings.Repeat("x", 4096)
+	req := httptest.NewRequest(http.MethodPut, "/admin/credentials/github_token", strings.NewReader(`{"value":"`+value+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	out := logged.String()
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400 for a value over the limit: %s", w.Code, w.Body)
+	}
+	if strings.Contains(out, "ghp_leaked") {
+		t.Errorf("log leaks the credential: %s", out)
+	}
+	if !strings.Contains(out, "WARN PUT /admin/credentials/github_token 400") || !strings.Contains(out, "request="+omittedBody) {
+		t.Errorf("log = %s, want the failed request with its body omitted", out)
+	}
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// escalationCandidatesSQL matches todos the escalation rule applies to. $1 is
+// the target priority key and $2 the due window in hours. Todos escalated
+// before are skipped, so a manual priority change after an escalation sticks.
+const escalationCandidatesSQL = `
+	escalated_at IS NULL
+	AND due_date IS NOT NULL
+	AND due_date <= NOW() + make_interval(hours => $2)
+	AND status NOT IN (` + doneStatusesSQL + `)
+	AND COALESCE(` + todoPriorityWeightSQL + `, 0) < (SELECT weight FROM priorities WHERE key = $1)
+`
+
+// escalationSettingsColumns is the column list selected by every escalation
+// settings query; scanEscalationSettings reads it back
+const escalationSettingsColumns = `enabled, due_within_hours, target_priority_key, updated_at`
+
+// scanEscalationSettings scans a row selected with escalationSettingsColumns
+func scanEscalationSettings(row pgx.Row) (models.EscalationSettings, error) {
+	var settings models.EscalationSettings
+	err := row.Scan(&settings.Enabled, &settings.DueWithinHours, &settings.TargetPriorityKey, &settings.UpdatedAt)
+	return settings, err
+}
+
+// EscalatePriorities raises the priority of todos matching the escalation
+// rule. It is a no-op while the rule is disabled or its target priority was
+// deleted. Failures on individual todos are logged and do not stop the run.
+func EscalatePriorities(ctx context.Context) error {
+	settings, err := scanEscalationSettings(db.Pool.QueryRow(ctx, `
+		SELECT `+escalationSettingsColumns+` FROM escalation_settings
+	`))
+	if err == pgx.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load escalation settings: %w", err)
+	}
+	if !settings.Enabled || settings.TargetPriorityKey == nil {
+		return nil
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id FROM todos WHERE `+escalationCandidatesSQL+` ORDER BY due_date, id
+	`, *settings.TargetPriorityKey, settings.DueWithinHours)
+	if err != nil {
+		return fmt.Errorf("failed to list todos to escalate: %w", err)
+	}
+	todoIDs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return fmt.Errorf("failed to list todos to escalate: %w", err)
+	}
+
+	actor := systemActor
+	escalated := 0
+	for _, id := range todoIDs {
+		err := db.WithTx(ctx, func(tx pgx.Tx) error {
+			// Re-check under lock in case the todo changed since it was listed
+			err := tx.QueryRow(ctx, `
+				SELECT id FROM todos WHERE id = $3 AND `+escalationCandidatesSQL+` FOR UPDATE
+			`, *settings.TargetPriorityKey, settings.DueWithinHours, id).Scan(&id)
+			if err == pgx.ErrNoRows {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			_, err = applyTodoUpdate(ctx, tx, nil, &actor, id, `
+				priority_key = $2, escalated_at = NOW(), updated_at = NOW()
+			`, *settings.TargetPriorityKey)
+			if err != nil {
+				return err
+			}
+			escalated++
+			return nil
+		})
+		if err != nil {
+			log.Printf("Error escalating todo %d: %v", id, err)
+		}
+	}
+
+	if escalated > 0 {
+		log.Printf("Escalated %d todos to priority %s", escalated, *settings.TargetPriorityKey)
+	}
+	return nil
+}
+
+// GetEscalationSettings godoc
+// @Summary      Get the escalation rule
+// @Description  Get the rule that raises the priority of todos as their due date approaches
+// @Tags         settings
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  models.EscalationSettings
+// @Failure      500  {object}  map[string]string
+// @Router       /settings/escalation [get]
+func GetEscalationSettings(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	settings, err := scanEscalationSettings(db.Pool.QueryRow(ctx, `
+		SELECT `+escalationSettingsColumns+` FROM escalation_settings
+	`))
+	if err != nil {
+		log.Printf("Error fetching escalation settings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch escalation settings", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateEscalationSettings godoc
+// @Summary      Update the escalation rule
+// @Description  Enable or disable priority escalation, or change its due window or target priority
+// @Tags         settings
+// @Accept       json
+// @Produce      json
+// @Param        settings  body      models.UpdateEscalationSettingsRequest  true  "Escalation rule"
+// @Success      200       {object}  models.EscalationSettings
+// @Failure      400       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /settings/escalation [put]
+func UpdateEscalationSettings(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.UpdateEscalationSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if req.TargetPriorityKey != nil {
+		if _, err := resolvePriority(ctx, *req.TargetPriorityKey, ""); respondPriorityError(c, err) {
+			return
+		} else if err != nil {
+			log.Printf("Error fetching priorities: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update escalation settings", "details": err.Error()})
+			return
+		}
+	}
+
+	settings, err := scanEscalationSettings(db.Pool.QueryRow(ctx, `
+		INSERT INTO escalation_settings (id, enabled, due_within_hours, target_priority_key, updated_at)
+		VALUES (TRUE, COALESCE($1, FALSE), COALESCE($2, 24), $3, NOW())
+		ON CONFLICT (id) DO UPDATE SET
+			enabled = COALESCE($1, escalation_settings.enabled),
+			due_within_hours = COALESCE($2, escalation_settings.due_within_hours),
+			target_priority_key = COALESCE($3, escalation_settings.target_priority_key),
+			updated_at = NOW()
+		RETURNING `+escalationSettingsColumns+`
+	`, req.Enabled, req.DueWithinHours, req.TargetPriorityKey))
+	if isForeignKeyViolation(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Priority not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating escalation settings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update escalation settings", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import "fmt"
+
+// maxEstimateMinutes caps a todo's estimate at 30 days of work, matching the
+// check on todos.estimate_minutes
+const maxEstimateMinutes = 30 * 24 * 60
+
+// checkEstimate returns the message to reject an estimate with when it is
+// set and not a positive number of minutes within maxEstimateMinutes, or an
+// empty string
+func checkEstimate(minutes *int) string {
+	if minutes == nil || (*minutes > 0 && *minutes <= maxEstimateMinutes) {
+		return ""
+	}
+	return fmt.Sprintf("Invalid estimate_minutes. Must be between 1 and %d", maxEstimateMinutes)
+}
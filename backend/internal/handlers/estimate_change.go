@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// recordEstimateChange writes an estimate_changes row when a todo's story
+// points went from before to after, in the caller's transaction. Nothing is
+// written when they are equal. actor is nil for changes made by the
+// requesting user; changes outside a request are attributed to systemActor.
+func recordEstimateChange(ctx context.Context, tx pgx.Tx, c *gin.Context, actor *string, todoID int64, before, after *int, source string) error {
+	if (before == nil && after == nil) || (before != nil && after != nil && *before == *after) {
+		return nil
+	}
+	name := systemActor
+	if actor != nil {
+		name = *actor
+	} else if c != nil {
+		name = currentUser(c)
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO estimate_changes (todo_id, old_points, new_points, source, actor, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+	`, todoID, before, after, source, name)
+	if err != nil {
+		return fmt.Errorf("failed to write estimate change: %w", err)
+	}
+	return nil
+}
+
+// GetEstimateHistory godoc
+// @Summary      List a todo's story point changes
+// @Description  Get every change of a todo's story points, newest first, with who made it. Updates of any kind, bulk ones included, and imports are recorded; an update that leaves the points as they were is not.
+// @Tags         todos
+// @Produce      json
+// @Param        id        path      int   true   "Todo ID"
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.EstimateChange  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/estimate-history [get]
+func GetEstimateHistory(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var exists bool
+	if err := db.Pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM todos WHERE id = $1)`, todoID).Scan(&exists); err != nil {
+		log.Printf("Error fetching todo: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch estimate history", "details": err.Error()})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, todo_id, old_points, new_points, new_points - old_points, source, actor, created_at
+		FROM estimate_changes
+		WHERE todo_id = $1
+		ORDER BY created_at DESC, id DESC
+	`, todoID)
+	if err != nil {
+		log.Printf("Error fetching estimate history: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch estimate history", "details": err.Error()})
+		return
+	}
+	changes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.EstimateChange, error) {
+		var change models.EstimateChange
+		err := row.Scan(&change.ID, &change.TodoID, &change.OldPoints, &change.NewPoints, &change.Delta, &change.Source, &change.Actor, &change.CreatedAt)
+		return change, err
+	})
+	if err != nil {
+		log.Printf("Error scanning estimate history: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch estimate history", "details": err.Error()})
+		return
+	}
+
+	respondList(c, envelope, changes, int64(len(changes)), 0, 0)
+}
+
+// GetEstimationDrift godoc
+// @Summary      Get story point estimation drift
+// @Description  Count how often story points grew or shrank in a date range, how often they were set or cleared, and the average change of the re-estimations.
+// @Tags         todos
+// @Produce      json
+// @Param        from  query     string  false  "Only changes at or after this RFC3339 timestamp or YYYY-MM-DD date"
+// @Param        to    query     string  false  "Only changes before this RFC3339 timestamp or YYYY-MM-DD date"
+// @Param        tz    query     string  false  "IANA timezone dates are read in; defaults to the X-Timezone header, the timezone preference and UTC"
+// @Success      200   {object}  models.EstimationDrift
+// @Failure      400   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /todos/stats/estimation-drift [get]
+func GetEstimationDrift(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	loc, err := requestLocation(c, c.Query("tz"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var drift models.EstimationDrift
+	whereConditions := []string{}
+	queryArgs := []interface{}{}
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := parseTimeParam(fromStr, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from, expected RFC3339 or YYYY-MM-DD"})
+			return
+		}
+		queryArgs = append(queryArgs, from)
+		whereConditions = append(whereConditions, "created_at >= $"+strconv.Itoa(len(queryArgs)))
+		drift.From = &from
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := parseTimeParam(toStr, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to, expected RFC3339 or YYYY-MM-DD"})
+			return
+		}
+		queryArgs = append(queryArgs, to)
+		whereConditions = append(whereConditions, "created_at < $"+strconv.Itoa(len(queryArgs)))
+		drift.To = &to
+	}
+	if drift.From != nil && drift.To != nil && !drift.From.Before(*drift.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+
+	whereClause := ""
+	if len(whereConditions) > 0 {
+		whereClause = "WHERE " + strings.Join(whereConditions, " AND ")
+	}
+
+	err = db.Pool.QueryRow(c.Request.Context(), `
+		SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE new_points > old_points),
+			COUNT(*) FILTER (WHERE new_points < old_points),
+			COUNT(*) FILTER (WHERE old_points IS NULL),
+			COUNT(*) FILTER (WHERE new_points IS NULL),
+			AVG(new_points - old_points)::float8,
+			AVG(ABS(new_points - old_points))::float8
+		FROM estimate_changes
+		`+whereClause, queryArgs...).Scan(&drift.Changes, &drift.Grew, &drift.Shrank, &drift.Set, &drift.Cleared,
+		&drift.AverageDelta, &drift.AverageAbsoluteDelta)
+	if err != nil {
+		log.Printf("Error aggregating estimate changes: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build estimation drift", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, drift)
+}
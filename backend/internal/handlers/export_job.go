@@ -0,0 +1,717 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/store"
+)
+
+const (
+	// defaultExportTTL is how long a finished export can be downloaded when
+	// EXPORT_TTL is unset
+	defaultExportTTL = 24 * time.Hour
+	// defaultExportMaxPending is how many queued or running exports one user
+	// may have when EXPORT_MAX_PENDING is unset
+	defaultExportMaxPending = 3
+	// exportProgressInterval is how often a running export stores its
+	// progress and heartbeat
+	exportProgressInterval = 2 * time.Second
+	// exportStaleAfter is how long a running export may go without a
+	// heartbeat before it counts as abandoned, e.g. after a crash
+	exportStaleAfter = 2 * time.Minute
+	// exportPurgeBatchSize bounds how many expired files one transaction
+	// removes
+	exportPurgeBatchSize = 100
+)
+
+// exportContentTypes maps each export format to its download content type
+var exportContentTypes = map[string]string{
+	"json": "application/json",
+	"csv":  "text/csv",
+	"zip":  "application/zip",
+}
+
+// exportCSVHeader is the header row of a CSV export
+var exportCSVHeader = []string{
+	"id", "title", "description", "status", "priority_key", "due_date", "due_timezone", "story_points",
+	"estimate_minutes", "sprint_id", "epic_id", "deferred_until", "completed_at", "created_at", "updated_at",
+}
+
+var (
+	exportConfigOnce sync.Once
+	exportTTLVal     time.Duration
+	exportMaxPending int
+)
+
+// loadExportConfig reads EXPORT_TTL, how long a finished export can be
+// downloaded, and EXPORT_MAX_PENDING, how many queued or running exports a
+// user may have
+func loadExportConfig() {
+	exportConfigOnce.Do(func() {
+		exportTTLVal = defaultExportTTL
+		if value := os.Getenv("EXPORT_TTL"); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil || parsed <= 0 {
+				log.Printf("Invalid EXPORT_TTL %q, using %s", value, defaultExportTTL)
+			} else {
+				exportTTLVal = parsed
+			}
+		}
+		exportMaxPending = defaultExportMaxPending
+		if value := os.Getenv("EXPORT_MAX_PENDING"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				log.Printf("Invalid EXPORT_MAX_PENDING %q, using %d", value, defaultExportMaxPending)
+			} else {
+				exportMaxPending = parsed
+			}
+		}
+	})
+}
+
+// exportJobColumns is the column list selected by every export job query;
+// scanExportJob reads it back
+const exportJobColumns = `id, format, filters, status, rows_total, rows_processed, artifact_bytes, error, attempts,
+	requested_by, created_at, started_at, finished_at, expires_at`
+
+// scanExportJob scans a row selected with exportJobColumns
+func scanExportJob(row pgx.Row) (models.ExportJob, error) {
+	var job models.ExportJob
+	err := row.Scan(&job.ID, &job.Format, &job.Filters, &job.Status, &job.RowsTotal, &job.RowsProcessed, &job.Bytes, &job.Error,
+		&job.Attempts, &job.RequestedBy, &job.CreatedAt, &job.StartedAt, &job.FinishedAt, &job.ExpiresAt)
+	return job, err
+}
+
+// exportJobID parses the :id of an export job route, writing a 400 when it
+// is invalid
+func exportJobID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+// CreateExport godoc
+// @Summary      Start an export
+// @Description  Queue an export of every todo matching the filters, deferred ones included, as a JSON array, a CSV file or a ZIP archive holding todos.json. Exports run one at a time in the background, oldest first; poll GET /exports/{id} for progress and download the file from GET /exports/{id}/download once it is completed. Each user may have EXPORT_MAX_PENDING exports queued or running (default 3); more are rejected with 429.
+// @Tags         exports
+// @Accept       json
+// @Produce      json
+// @Param        export  body      models.CreateExportRequest  true  "Export format and filters"
+// @Success      202     {object}  models.ExportJob
+// @Header       202     {string}  Location  "URL of the export job"
+// @Failure      400     {object}  map[string]string
+// @Failure      429     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /exports [post]
+func CreateExport(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+	loadExportConfig()
+
+	var req models.CreateExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if req.Filters.Status != nil {
+		status, ok, err := resolveStatus(ctx, *req.Filters.Status)
+		if err != nil {
+			log.Printf("Error fetching statuses: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create export", "details": err.Error()})
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": invalidStatusError(ctx).Error()})
+			return
+		}
+		req.Filters.Status = &status.Key
+	}
+	if after, before := req.Filters.CreatedAfter, req.Filters.CreatedBefore; after != nil && before != nil && !after.Before(*before) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "created_after must be before created_before"})
+		return
+	}
+	filters, err := json.Marshal(req.Filters)
+	if err != nil {
+		log.Printf("Error encoding export filters: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create export", "details": err.Error()})
+		return
+	}
+
+	user := currentUser(c)
+	job, err := scanExportJob(db.Pool.QueryRow(ctx, `
+		INSERT INTO export_jobs (format, filters, requested_by)
+		SELECT $1, $2::jsonb, $3
+		WHERE (SELECT COUNT(*) FROM export_jobs WHERE requested_by = $3 AND status IN ('queued', 'running')) < $4
+		RETURNING `+exportJobColumns+`
+	`, req.Format, filters, user, exportMaxPending))
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("You already have %d exports queued or running. Wait for one to finish", exportMaxPending)})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating export: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create export", "details": err.Error()})
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("%s/exports/%d", apiBasePath, job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetExport godoc
+// @Summary      Get an export
+// @Description  Get an export job with its status and progress. rows_processed is updated every few seconds while it runs; a failed export holds the error and can be retried.
+// @Tags         exports
+// @Produce      json
+// @Param        id   path      int  true  "Export ID"
+// @Success      200  {object}  models.ExportJob
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /exports/{id} [get]
+func GetExport(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, ok := exportJobID(c)
+	if !ok {
+		return
+	}
+
+	job, err := scanExportJob(db.Pool.QueryRow(c.Request.Context(), `SELECT `+exportJobColumns+` FROM export_jobs WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching export: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch export", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// DownloadExport godoc
+// @Summary      Download an export
+// @Description  Stream the file of a completed export. It can be downloaded until expires_at (EXPORT_TTL after it finished, default 24 hours), after which it is removed and 410 is returned.
+// @Tags         exports
+// @Produce      application/json
+// @Produce      text/csv
+// @Produce      application/zip
+// @Param        id   path      int  true  "Export ID"
+// @Success      200  {file}    file
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      410  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /exports/{id}/download [get]
+func DownloadExport(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, ok := exportJobID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to download export", "details": err.Error()})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// The share lock keeps the retention job from removing the file while it
+	// is streamed
+	var (
+		format, status string
+		oid            *uint32
+		size           *int64
+		expired        bool
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT format, status, artifact_oid, artifact_bytes, COALESCE(expires_at <= NOW(), FALSE)
+		FROM export_jobs WHERE id = $1
+		FOR SHARE
+	`, id).Scan(&format, &status, &oid, &size, &expired)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching export: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to download export", "details": err.Error()})
+		return
+	}
+	if status == models.ExportExpired || (status == models.ExportCompleted && expired) {
+		c.JSON(http.StatusGone, gin.H{"error": "Export has expired. Start a new one"})
+		return
+	}
+	if status != models.ExportCompleted || oid == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Export is %s, not completed", status), "status": status})
+		return
+	}
+
+	objects := tx.LargeObjects()
+	file, err := objects.Open(ctx, *oid, pgx.LargeObjectModeRead)
+	if err != nil {
+		log.Printf("Error opening export file: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to download export", "details": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", exportContentTypes[format])
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="todos-export-%d.%s"`, id, format))
+	if size != nil {
+		c.Header("Content-Length", strconv.FormatInt(*size, 10))
+	}
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, bufio.NewReaderSize(file, 256<<10)); err != nil {
+		// Headers are already sent, so the best we can do is log and stop
+		log.Printf("Error streaming export %d: %v", id, err)
+	}
+}
+
+// RetryExport godoc
+// @Summary      Retry a failed export
+// @Description  Queue a failed export again with the same format and filters. It starts over from the first row.
+// @Tags         exports
+// @Produce      json
+// @Param        id   path      int  true  "Export ID"
+// @Success      202  {object}  models.ExportJob
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /exports/{id}/retry [post]
+func RetryExport(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, ok := exportJobID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	job, err := scanExportJob(db.Pool.QueryRow(ctx, `
+		UPDATE export_jobs
+		SET status = 'queued', rows_total = NULL, rows_processed = 0, started_at = NULL, heartbeat_at = NULL, finished_at = NULL
+		WHERE id = $1 AND status = 'failed'
+		RETURNING `+exportJobColumns+`
+	`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		var status string
+		err = db.Pool.QueryRow(ctx, `SELECT status FROM export_jobs WHERE id = $1`, id).Scan(&status)
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+			return
+		}
+		if err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Only failed exports can be retried; this one is %s", status), "status": status})
+			return
+		}
+	}
+	if err != nil {
+		log.Printf("Error retrying export: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry export", "details": err.Error()})
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("%s/exports/%d", apiBasePath, job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+// RunExportJobs fails running exports whose worker stopped sending
+// heartbeats, then runs queued exports oldest first until none are left.
+// Only one export runs at a time across all instances; when another
+// instance holds the running slot this returns and tries again later.
+func RunExportJobs(ctx context.Context) error {
+	loadExportConfig()
+
+	if _, err := db.Pool.Exec(ctx, `
+		UPDATE export_jobs
+		SET status = 'failed', error = 'Export stopped responding', finished_at = NOW()
+		WHERE status = 'running' AND heartbeat_at < NOW() - make_interval(secs => $1)
+	`, exportStaleAfter.Seconds()); err != nil {
+		return fmt.Errorf("failed to fail stale exports: %w", err)
+	}
+
+	for ctx.Err() == nil {
+		var (
+			id      int64
+			format  string
+			filters models.ExportFilters
+		)
+		err := db.Pool.QueryRow(ctx, `
+			UPDATE export_jobs
+			SET status = 'running', attempts = attempts + 1, error = NULL, started_at = NOW(), heartbeat_at = NOW()
+			WHERE id = (
+				SELECT id FROM export_jobs WHERE status = 'queued'
+				ORDER BY created_at, id LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, format, filters
+		`).Scan(&id, &format, &filters)
+		if errors.Is(err, pgx.ErrNoRows) || isUniqueViolation(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to claim export: %w", err)
+		}
+
+		if err := runExport(ctx, id, format, filters); err != nil {
+			message := err.Error()
+			if ctx.Err() != nil {
+				message = "Export was interrupted by a server shutdown"
+			}
+			log.Printf("Export %d failed: %v", id, err)
+			// The job's context may be cancelled, so record the failure on
+			// one of its own
+			failCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			_, err := db.Pool.Exec(failCtx, `
+				UPDATE export_jobs SET status = 'failed', error = $2, finished_at = NOW()
+				WHERE id = $1 AND status = 'running'
+			`, id, message)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("failed to record export failure: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// exportEncoder writes todos in one export format
+type exportEncoder interface {
+	encode(todo models.Todo) error
+	close() error
+}
+
+// newExportEncoder returns the encoder writing format to w
+func newExportEncoder(format string, w io.Writer) (exportEncoder, error) {
+	switch format {
+	case "json":
+		return &jsonExportEncoder{w: w}, nil
+	case "csv":
+		writer := csv.NewWriter(w)
+		return &csvExportEncoder{writer: writer}, writer.Write(exportCSVHeader)
+	case "zip":
+		archive := zip.NewWriter(w)
+		file, err := archive.Create("todos.json")
+		if err != nil {
+			return nil, err
+		}
+		return &zipExportEncoder{jsonExportEncoder{w: file}, archive}, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+// jsonExportEncoder writes todos as a JSON array, one todo per line
+type jsonExportEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonExportEncoder) encode(todo models.Todo) error {
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return err
+	}
+	separator := ",\n"
+	if e.count == 0 {
+		separator = "[\n"
+	}
+	e.count++
+	if _, err := io.WriteString(e.w, separator); err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonExportEncoder) close() error {
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+// zipExportEncoder writes todos as todos.json in a ZIP archive
+type zipExportEncoder struct {
+	jsonExportEncoder
+	archive *zip.Writer
+}
+
+func (e *zipExportEncoder) close() error {
+	if err := e.jsonExportEncoder.close(); err != nil {
+		return err
+	}
+	return e.archive.Close()
+}
+
+// csvExportEncoder writes todos as CSV rows under exportCSVHeader
+type csvExportEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvExportEncoder) encode(todo models.Todo) error {
+	dueDate := ""
+	if todo.DueDate != nil {
+		dueDate = todo.DueDate.Format(time.RFC3339)
+		if todo.AllDay {
+			dueDate = todo.DueDate.Format(time.DateOnly)
+		}
+	}
+	return e.writer.Write([]string{
+		strconv.FormatInt(todo.ID, 10), todo.Title, todo.Description, todo.Status, todo.PriorityKey, dueDate,
+		optionalCSV(todo.DueTimezone, func(v string) string { return v }),
+		optionalCSV(todo.StoryPoints, strconv.Itoa),
+		optionalCSV(todo.EstimateMinutes, strconv.Itoa),
+		optionalCSV(todo.SprintID, func(v int64) string { return strconv.FormatInt(v, 10) }),
+		optionalCSV(todo.EpicID, func(v int64) string { return strconv.FormatInt(v, 10) }),
+		optionalCSV(todo.DeferredUntil, func(v string) string { return v }),
+		optionalCSV(todo.CompletedAt, func(v time.Time) string { return v.Format(time.RFC3339) }),
+		todo.CreatedAt.Format(time.RFC3339), todo.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+func (e *csvExportEncoder) close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// optionalCSV formats a nullable CSV cell, leaving it empty when unset
+func optionalCSV[T any](value *T, format func(T) string) string {
+	if value == nil {
+		return ""
+	}
+	return format(*value)
+}
+
+// runExport writes the todos matching filters to a new large object and
+// marks the job completed. The file is written in the same transaction that
+// completes the job, so a failed export leaves nothing behind. Progress is
+// stored outside it every exportProgressInterval so it is visible while the
+// export runs.
+func runExport(ctx context.Context, id int64, format string, filters models.ExportFilters) error {
+	params := store.ListTodosParams{
+		Status:        filters.Status,
+		SprintID:      filters.SprintID,
+		EpicID:        filters.EpicID,
+		CreatedAfter:  filters.CreatedAfter,
+		CreatedBefore: filters.CreatedBefore,
+		AnyDeferred:   true,
+		Sort:          []store.TodoSort{{Field: "created_at"}},
+	}
+	total, err := store.CountTodos(ctx, db.Pool, params)
+	if err != nil {
+		return fmt.Errorf("failed to count todos: %w", err)
+	}
+	if _, err := db.Pool.Exec(ctx, `UPDATE export_jobs SET rows_total = $2, heartbeat_at = NOW() WHERE id = $1`, id, total); err != nil {
+		return fmt.Errorf("failed to store export progress: %w", err)
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	objects := tx.LargeObjects()
+	oid, err := objects.Create(ctx, 0)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	file, err := objects.Open(ctx, oid, pgx.LargeObjectModeWrite)
+	if err != nil {
+		return fmt.Errorf("failed to open export file: %w", err)
+	}
+	counter := &countingWriter{w: file}
+	buffered := bufio.NewWriterSize(counter, 256<<10)
+	encoder, err := newExportEncoder(format, buffered)
+	if err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	rows, err := store.ListTodos(ctx, db.Pool, todoColumns, params)
+	if err != nil {
+		return fmt.Errorf("failed to query todos: %w", err)
+	}
+	defer rows.Close()
+
+	var processed int64
+	lastProgress := time.Now()
+	for rows.Next() {
+		var todo models.Todo
+		if err := scanTodo(rows, &todo); err != nil {
+			return fmt.Errorf("failed to scan todo: %w", err)
+		}
+		if err := encoder.encode(todo); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		processed++
+		if time.Since(lastProgress) >= exportProgressInterval {
+			if _, err := db.Pool.Exec(ctx, `UPDATE export_jobs SET rows_processed = $2, heartbeat_at = NOW() WHERE id = $1`, id, processed); err != nil {
+				return fmt.Errorf("failed to store export progress: %w", err)
+			}
+			lastProgress = time.Now()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read todos: %w", err)
+	}
+	if err := encoder.close(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close export file: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE export_jobs
+		SET status = 'completed', artifact_oid = $2, artifact_bytes = $3, rows_processed = $4,
+			heartbeat_at = NOW(), finished_at = NOW(), expires_at = NOW() + make_interval(secs => $5)
+		WHERE id = $1
+	`, id, oid, counter.n, processed, exportTTLVal.Seconds()); err != nil {
+		return fmt.Errorf("failed to complete export: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit export: %w", err)
+	}
+	log.Printf("Export %d completed: %d todos, %d bytes", id, processed, counter.n)
+	return nil
+}
+
+// PurgeExpiredExports removes the files of completed exports past their
+// expiry and marks them expired. Files being downloaded are skipped and
+// removed on a later run.
+func PurgeExpiredExports(ctx context.Context) error {
+	var total int
+	for {
+		purged, err := purgeExpiredExportBatch(ctx)
+		if err != nil {
+			return err
+		}
+		total += purged
+		if purged < exportPurgeBatchSize {
+			break
+		}
+	}
+	if total > 0 {
+		log.Printf("Removed %d expired exports", total)
+	}
+	return nil
+}
+
+// purgeExpiredExportBatch removes up to exportPurgeBatchSize expired exports
+// and returns how many it removed
+func purgeExpiredExportBatch(ctx context.Context) (int, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, artifact_oid FROM export_jobs
+		WHERE status = 'completed' AND expires_at <= NOW()
+		ORDER BY expires_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, exportPurgeBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find expired exports: %w", err)
+	}
+	type expiredExport struct {
+		id  int64
+		oid *uint32
+	}
+	expired, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (expiredExport, error) {
+		var export expiredExport
+		err := row.Scan(&export.id, &export.oid)
+		return export, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find expired exports: %w", err)
+	}
+
+	objects := tx.LargeObjects()
+	ids := make([]int64, 0, len(expired))
+	for _, export := range expired {
+		if export.oid != nil {
+			if err := objects.Unlink(ctx, *export.oid); err != nil {
+				return 0, fmt.Errorf("failed to remove export %d file: %w", export.id, err)
+			}
+		}
+		ids = append(ids, export.id)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE export_jobs SET status = 'expired', artifact_oid = NULL WHERE id = ANY($1)
+	`, ids); err != nil {
+		return 0, fmt.Errorf("failed to expire exports: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit expired exports: %w", err)
+	}
+	return len(ids), nil
+}
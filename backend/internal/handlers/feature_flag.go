@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/flags"
+	"flow-v1/backend/internal/models"
+)
+
+// flagNamePattern matches a valid feature flag name
+var flagNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// flagEnabled reports whether the feature flag name is on for the workspace
+// of the request, the one its usage is metered under. There are no user
+// accounts to tell callers apart by, so rollouts go by workspace, and a
+// request naming none gets the verdict of the default workspace.
+func flagEnabled(c *gin.Context, name string) bool {
+	workspace, err := requestWorkspace(c)
+	if err != nil {
+		workspace = defaultWorkspace
+	}
+	return flags.Enabled(flags.WithSubject(c.Request.Context(), workspace), name)
+}
+
+// ListFeatureFlags godoc
+// @Summary      List feature flags
+// @Description  Get every feature flag by name. Requires the admin token.
+// @Tags         admin
+// @Produce      json
+// @Security     AdminToken
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.FeatureFlag  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/flags [get]
+func ListFeatureFlags(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := db.Pool.Query(c.Request.Context(), `SELECT `+flags.Columns+` FROM feature_flags ORDER BY name`)
+	if err != nil {
+		log.Printf("Error fetching feature flags: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch feature flags", "details": err.Error()})
+		return
+	}
+	list, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.FeatureFlag, error) {
+		var flag models.FeatureFlag
+		err := flags.Scan(row, &flag)
+		return flag, err
+	})
+	if err != nil {
+		log.Printf("Error scanning feature flags: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch feature flags", "details": err.Error()})
+		return
+	}
+
+	respondList(c, envelope, list, int64(len(list)), 0, 0)
+}
+
+// UpdateFeatureFlag godoc
+// @Summary      Create or replace a feature flag
+// @Description  Set a feature flag, creating it if needed. A flag is on for a request when it is enabled and the request's workspace, named by its X-Workspace header or default, is in the allowlist or in the rollout percentage; the percentage picks workspaces by a hash of the flag and workspace names, so raising it only ever adds workspaces. Changes apply on every instance within 15 seconds and are recorded in the audit log. Requires the admin token.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     AdminToken
+// @Param        name  path      string                           true  "Flag name"
+// @Param        flag  body      models.UpdateFeatureFlagRequest  true  "Flag settings"
+// @Success      200   {object}  models.FeatureFlag
+// @Failure      400   {object}  map[string]string
+// @Failure      401   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /admin/flags/{name} [put]
+func UpdateFeatureFlag(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	name := c.Param("name")
+	if !flagNamePattern.MatchString(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid flag name. Use lowercase letters, digits and underscores, starting with a letter"})
+		return
+	}
+	var req models.UpdateFeatureFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Allowlist == nil {
+		req.Allowlist = []string{}
+	}
+
+	var flag models.FeatureFlag
+	ctx := c.Request.Context()
+	err := db.WithTx(ctx, func(tx pgx.Tx) error {
+		var before *models.FeatureFlag
+		var existing models.FeatureFlag
+		err := flags.Scan(tx.QueryRow(ctx, `SELECT `+flags.Columns+` FROM feature_flags WHERE name = $1 FOR UPDATE`, name), &existing)
+		if err == nil {
+			before = &existing
+		} else if err != pgx.ErrNoRows {
+			return err
+		}
+
+		err = flags.Scan(tx.QueryRow(ctx, `
+			INSERT INTO feature_flags (name, description, enabled, rollout_percent, allowlist, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+			ON CONFLICT (name) DO UPDATE SET
+				description = EXCLUDED.description,
+				enabled = EXCLUDED.enabled,
+				rollout_percent = EXCLUDED.rollout_percent,
+				allowlist = EXCLUDED.allowlist,
+				updated_at = NOW()
+			RETURNING `+flags.Columns+`
+		`, name, req.Description, req.Enabled, req.RolloutPercent, req.Allowlist), &flag)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return recordAudit(ctx, tx, c, models.AuditActionCreate, models.AuditEntityFlag, flag.ID, nil, flag)
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionUpdate, models.AuditEntityFlag, flag.ID, before, flag)
+	})
+	if err != nil {
+		log.Printf("Error updating feature flag %s: %v", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update feature flag", "details": err.Error()})
+		return
+	}
+	flags.Reset()
+	log.Printf("Feature flag %s enabled=%t rollout=%d%%", flag.Name, flag.Enabled, flag.RolloutPercent)
+
+	c.JSON(http.StatusOK, flag)
+}
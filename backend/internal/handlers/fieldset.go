@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/models"
+)
+
+// todoScan holds a todo being scanned along with the epic columns used to
+// build its embedded epic and the counters behind its subtask progress
+type todoScan struct {
+	todo                           *models.Todo
+	epicTitle, epicColor           *string
+	subtaskCount, subtaskCompleted int
+}
+
+// todoColumn is one selectable expression of a todo and where it scans to
+type todoColumn struct {
+	name string
+	sql  string
+	dest func(s *todoScan) interface{}
+}
+
+// todoColumnList is every column of a todo, in the order todoColumns selects
+// them. The epic's title and color are looked up so todos can embed a slim
+// epic, the priority falls back to the default priority, and subtask progress
+// comes from the counters kept on the todo. Sparse queries only ever select
+// from this fixed list, so no client input reaches the SQL.
+var todoColumnList = []todoColumn{
+	{"id", "id", func(s *todoScan) interface{} { return &s.todo.ID }},
+	{"title", "title", func(s *todoScan) interface{} { return &s.todo.Title }},
+	{"description", "COALESCE(description, '') AS description", func(s *todoScan) interface{} { return &s.todo.Description }},
+	{"status", "status", func(s *todoScan) interface{} { return &s.todo.Status }},
+	{"due_date", "due_date", func(s *todoScan) interface{} { return &s.todo.DueDate }},
+	{"all_day", "all_day", func(s *todoScan) interface{} { return &s.todo.AllDay }},
+	{"due_timezone", "due_timezone", func(s *todoScan) interface{} { return &s.todo.DueTimezone }},
+	{"priority_key", "COALESCE(" + todoPriorityKeySQL + ", '') AS priority_key", func(s *todoScan) interface{} { return &s.todo.PriorityKey }},
+	{"priority", "COALESCE((SELECT label FROM priorities WHERE key = " + todoPriorityKeySQL + "), '') AS priority", func(s *todoScan) interface{} { return &s.todo.Priority }},
+	{"story_points", "story_points", func(s *todoScan) interface{} { return &s.todo.StoryPoints }},
+	{"estimate_minutes", "estimate_minutes", func(s *todoScan) interface{} { return &s.todo.EstimateMinutes }},
+	{"sprint_id", "sprint_id", func(s *todoScan) interface{} { return &s.todo.SprintID }},
+	{"completed_at", "completed_at", func(s *todoScan) interface{} { return &s.todo.CompletedAt }},
+	{"escalated_at", "escalated_at", func(s *todoScan) interface{} { return &s.todo.EscalatedAt }},
+	{"deferred_until", "to_char(deferred_until, 'YYYY-MM-DD') AS deferred_until", func(s *todoScan) interface{} { return &s.todo.DeferredUntil }},
+	{"epic_id", "epic_id", func(s *todoScan) interface{} { return &s.todo.EpicID }},
+	{"epic_title", "(SELECT title FROM epics WHERE epics.id = todos.epic_id) AS epic_title", func(s *todoScan) interface{} { return &s.epicTitle }},
+	{"epic_color", "(SELECT color FROM epics WHERE epics.id = todos.epic_id) AS epic_color", func(s *todoScan) interface{} { return &s.epicColor }},
+	{"custom_fields", "custom_fields", func(s *todoScan) interface{} { return &s.todo.CustomFields }},
+	{"last_activity_at", "last_activity_at", func(s *todoScan) interface{} { return &s.todo.LastActivityAt }},
+	{"subtask_count", "subtask_count", func(s *todoScan) interface{} { return &s.subtaskCount }},
+	{"subtask_completed_count", "subtask_completed_count", func(s *todoScan) interface{} { return &s.subtaskCompleted }},
+	{"created_at", "created_at", func(s *todoScan) interface{} { return &s.todo.CreatedAt }},
+	{"updated_at", "updated_at", func(s *todoScan) interface{} { return &s.todo.UpdatedAt }},
+}
+
+// todoFieldColumns maps each field a client may request to the columns it
+// needs. Fields not listed need only the column of the same name; due_date
+// needs all_day and due_timezone to be written as a bare date, and
+// subtask_progress is built from the subtask counters.
+var todoFieldColumns = map[string][]string{
+	"due_date":         {"due_date", "all_day", "due_timezone"},
+	"epic":             {"epic_id", "epic_title", "epic_color"},
+	"subtask_progress": {"subtask_count", "subtask_completed_count"},
+}
+
+// hiddenTodoColumns are selected only to build another field and cannot be
+// requested themselves
+var hiddenTodoColumns = map[string]bool{
+	"epic_title":              true,
+	"epic_color":              true,
+	"subtask_count":           true,
+	"subtask_completed_count": true,
+}
+
+// compactTodoFields is the fields=... preset selected by compact=true, enough
+// to render a board card
+var compactTodoFields = []string{"id", "title", "status", "priority_key", "priority", "due_date", "all_day"}
+
+// todoFieldSet is a validated sparse fieldset for todo lists
+type todoFieldSet struct {
+	fields  map[string]bool
+	columns []todoColumn
+}
+
+// validTodoFields returns the field names accepted by fields=, sorted
+func validTodoFields() []string {
+	names := make([]string, 0, len(todoColumnList)+len(todoFieldColumns))
+	for _, column := range todoColumnList {
+		if !hiddenTodoColumns[column.name] {
+			names = append(names, column.name)
+		}
+	}
+	for name := range todoFieldColumns {
+		if _, ok := lookupTodoColumn(name); !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupTodoColumn(name string) (todoColumn, bool) {
+	for _, column := range todoColumnList {
+		if column.name == name {
+			return column, true
+		}
+	}
+	return todoColumn{}, false
+}
+
+// parseTodoFieldSet reads the fields and compact query parameters. It returns
+// nil when the full todo was asked for and an error naming the first unknown
+// field otherwise. The id is always included.
+func parseTodoFieldSet(c *gin.Context) (*todoFieldSet, error) {
+	var requested []string
+	if value := c.Query("fields"); value != "" {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				requested = append(requested, field)
+			}
+		}
+	} else if c.Query("compact") == "true" {
+		requested = compactTodoFields
+	}
+	if len(requested) == 0 {
+		return nil, nil
+	}
+
+	set := &todoFieldSet{fields: map[string]bool{"id": true}}
+	needed := map[string]bool{"id": true}
+	for _, field := range requested {
+		columns, ok := todoFieldColumns[field]
+		if !ok {
+			if _, known := lookupTodoColumn(field); !known || hiddenTodoColumns[field] {
+				return nil, fmt.Errorf("Invalid field %q. Must be one of: %s", field, strings.Join(validTodoFields(), ", "))
+			}
+			columns = []string{field}
+		}
+		set.fields[field] = true
+		for _, column := range columns {
+			needed[column] = true
+		}
+	}
+	for _, column := range todoColumnList {
+		if needed[column.name] {
+			set.columns = append(set.columns, column)
+		}
+	}
+	return set, nil
+}
+
+// sql returns the select list for the fieldset's columns
+func (s *todoFieldSet) sql() string {
+	expressions := make([]string, len(s.columns))
+	for i, column := range s.columns {
+		expressions[i] = column.sql
+	}
+	return strings.Join(expressions, ", ")
+}
+
+// scan scans a row selected with sql into todo
+func (s *todoFieldSet) scan(row pgx.Row, todo *models.Todo) error {
+	target := todoScan{todo: todo}
+	dest := make([]interface{}, len(s.columns))
+	for i, column := range s.columns {
+		dest[i] = column.dest(&target)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	todo.Epic = nil
+	if todo.EpicID != nil && target.epicTitle != nil && target.epicColor != nil {
+		todo.Epic = &models.TodoEpic{ID: *todo.EpicID, Title: *target.epicTitle, Color: *target.epicColor}
+	}
+	todo.SubtaskProgress = subtaskProgress(target.subtaskCount, target.subtaskCompleted)
+	return nil
+}
+
+// project writes todo as JSON with only the fieldset's fields. It goes
+// through Todo's own marshaling so values look the same as in full responses.
+// Search hits in matched_subtasks are kept, since they explain why the todo
+// is listed.
+func (s *todoFieldSet) project(todo models.Todo) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	projected := make(map[string]json.RawMessage, len(s.fields))
+	for field := range s.fields {
+		if value, ok := all[field]; ok {
+			projected[field] = value
+		}
+	}
+	if value, ok := all["matched_subtasks"]; ok {
+		projected["matched_subtasks"] = value
+	}
+	return projected, nil
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/models"
+)
+
+// queryContext returns a gin context for a GET request with query
+func queryContext(query string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/todos?"+query, nil)
+	return c
+}
+
+// columnNames returns the names of columns
+func columnNames(columns []todoColumn) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.name
+	}
+	return names
+}
+
+func TestParseTodoFieldSet(t *testing.T) {
+	tests := []struct {
+		query   string
+		fields  []string
+		columns []string
+	}{
+		{"fields=title", []string{"id", "title"}, []string{"id", "title"}},
+		{"fields=title,status", []string{"id", "status", "title"}, []string{"id", "title", "status"}},
+		{"fields=%20status%20,,title,", []string{"id", "status", "title"}, []string{"id", "title", "status"}},
+		{"fields=id", []string{"id"}, []string{"id"}},
+		{"fields=due_date", []string{"due_date", "id"}, []string{"id", "due_date", "all_day", "due_timezone"}},
+		{"fields=epic", []string{"epic", "id"}, []string{"id", "epic_id", "epic_title", "epic_color"}},
+		{"fields=subtask_progress", []string{"id", "subtask_progress"}, []string{"id", "subtask_count", "subtask_completed_count"}},
+		{"fields=epic,epic_id", []string{"epic", "epic_id", "id"}, []string{"id", "epic_id", "epic_title", "epic_color"}},
+		{"compact=true", []string{"all_day", "due_date", "id", "priority", "priority_key", "status", "title"}, []string{"id", "title", "status", "due_date", "all_day", "due_timezone", "priority_key", "priority"}},
+		{"fields=title&compact=true", []string{"id", "title"}, []string{"id", "title"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			set, err := parseTodoFieldSet(queryContext(tt.query))
+			if err != nil {
+				t.Fatalf("parseTodoFieldSet: %v", err)
+			}
+			var fields []string
+			for field := range set.fields {
+				fields = append(fields, field)
+			}
+			slices.Sort(fields)
+			if !slices.Equal(fields, tt.fields) {
+				t.Errorf("fields = %v, want %v", fields, tt.fields)
+			}
+			if got := columnNames(set.columns); !slices.Equal(got, tt.columns) {
+				t.Errorf("columns = %v, want %v", got, tt.columns)
+			}
+		})
+	}
+}
+
+func TestParseTodoFieldSetFull(t *testing.T) {
+	for _, query := range []string{"", "fields=", "fields=,", "compact=false", "compact=1"} {
+		set, err := parseTodoFieldSet(queryContext(query))
+		if err != nil || set != nil {
+			t.Errorf("parseTodoFieldSet(%q) = %v, %v, want the full todo", query, set, err)
+		}
+	}
+}
+
+func TestParseTodoFieldSetRejects(t *testing.T) {
+	for _, field := range []string{"nope", "epic_title", "subtask_count", "TITLE", "title;DROP TABLE todos"} {
+		_, err := parseTodoFieldSet(queryContext("fields=title," + url.QueryEscape(field)))
+		if err == nil {
+			t.Errorf("fields=%s was accepted", field)
+			continue
+		}
+		if !strings.Contains(err.Error(), `"`+field+`"`) || !strings.Contains(err.Error(), "subtask_progress") {
+			t.Errorf("fields=%s error = %q, want it to name the field and the valid ones", field, err)
+		}
+	}
+}
+
+// Every expression in a fieldset's SQL comes from todoColumnList, in its
+// order, so nothing the client sends reaches the query
+func TestTodoFieldSetSQL(t *testing.T) {
+	set, err := parseTodoFieldSet(queryContext("fields=status,title"))
+	if err != nil {
+		t.Fatalf("parseTodoFieldSet: %v", err)
+	}
+	if got, want := set.sql(), "id, title, status"; got != want {
+		t.Errorf("sql() = %q, want %q", got, want)
+	}
+	if !strings.HasPrefix(todoColumns, "id, title, COALESCE(description, '') AS description, status, ") ||
+		!strings.HasSuffix(todoColumns, ", created_at, updated_at") {
+		t.Errorf("todoColumns = %q, want every column of todoColumnList", todoColumns)
+	}
+}
+
+// fakeRow scans values positionally into its destinations
+type fakeRow []any
+
+func (r fakeRow) Scan(dest ...any) error {
+	for i, value := range r {
+		if value != nil {
+			reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(value))
+		}
+	}
+	return nil
+}
+
+func TestTodoFieldSetScanAndProject(t *testing.T) {
+	set, err := parseTodoFieldSet(queryContext("fields=title,epic,subtask_progress"))
+	if err != nil {
+		t.Fatalf("parseTodoFieldSet: %v", err)
+	}
+	epicID, epicTitle, epicColor := int64(7), "Launch", "#ff0000"
+	row := fakeRow{int64(3), "Write docs", &epicID, &epicTitle, &epicColor, 4, 1}
+	var todo models.Todo
+	if err := set.scan(row, &todo); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	projected, err := set.project(todo)
+	if err != nil {
+		t.Fatalf("project: %v", err)
+	}
+	data, err := json.Marshal(projected)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `{"epic":{"id":7,"title":"Launch","color":"#ff0000"},"id":3,"subtask_progress":"1/4","title":"Write docs"}`
+	if string(data) != want {
+		t.Errorf("projected = %s, want %s", data, want)
+	}
+}
+
+// scanTodo reads the full column list in the same order as todoColumnList
+func TestScanTodo(t *testing.T) {
+	now := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+	row := make(fakeRow, len(todoColumnList))
+	for i, column := range todoColumnList {
+		switch column.name {
+		case "id":
+			row[i] = int64(3)
+		case "title":
+			row[i] = "Write docs"
+		case "status":
+			row[i] = "todo"
+		case "priority_key":
+			row[i] = "high"
+		case "subtask_count":
+			row[i] = 2
+		case "subtask_completed_count":
+			row[i] = 2
+		case "created_at", "updated_at":
+			row[i] = now
+		}
+	}
+	var todo models.Todo
+	if err := scanTodo(row, &todo); err != nil {
+		t.Fatalf("scanTodo: %v", err)
+	}
+	if todo.ID != 3 || todo.Title != "Write docs" || todo.Status != "todo" || todo.PriorityKey != "high" ||
+		todo.SubtaskProgress != "2/2" || !todo.CreatedAt.Equal(now) || !todo.UpdatedAt.Equal(now) || todo.Epic != nil {
+		t.Errorf("scanTodo gave %+v", todo)
+	}
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// defaultForecastWeeks is how many weeks of history a forecast uses when
+// weeks is not given
+const defaultForecastWeeks = 4
+
+// minForecastHistory is how long ago a scope's first todo must have been
+// completed for its pace to be forecast
+const minForecastHistory = 14 * 24 * time.Hour
+
+// forecastScopeColumns maps the scope kinds of a forecast to their column
+var forecastScopeColumns = map[string]string{
+	"epic":   "epic_id",
+	"sprint": "sprint_id",
+}
+
+// forecastDate returns the date in loc on which remaining work is done at
+// perWeek from now, rounding partial days up
+func forecastDate(now time.Time, loc *time.Location, remaining int, perWeek float64) *string {
+	days := int(math.Ceil(float64(remaining) / perWeek * 7))
+	date := now.In(loc).AddDate(0, 0, days).Format(models.DateLayout)
+	return &date
+}
+
+// GetForecast godoc
+// @Summary      Forecast when open todos will be done
+// @Description  Project when the open todos of a scope will be done at the pace of the last weeks. Pace is measured in story points when every open todo is estimated and in todos otherwise, over rolling 7-day weeks ending now. The projected date uses the average week, the optimistic and pessimistic dates the best and worst week. With less than two weeks since the scope's first completion, or no work completed in the period, the status is insufficient_data and the dates are left out; the weekly numbers are always returned. Dates are in the tz parameter, the X-Timezone header or the timezone preference.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        scope  query     string  false  "epic:<id> or sprint:<id>; all todos when left out"
+// @Param        weeks  query     int     false  "Weeks of history (max 52)"  default(4)
+// @Param        tz     query     string  false  "IANA timezone for the dates"
+// @Success      200    {object}  models.Forecast
+// @Failure      400    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /todos/forecast [get]
+func GetForecast(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	weeks, err := strconv.Atoi(c.DefaultQuery("weeks", strconv.Itoa(defaultForecastWeeks)))
+	if err != nil || weeks <= 0 || weeks > 52 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid weeks. Must be between 1 and 52"})
+		return
+	}
+	loc, err := requestLocation(c, c.Query("tz"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	forecast := models.Forecast{Scope: "all", Weeks: []models.ForecastWeek{}}
+	var scopeColumn string
+	var scopeArgs []interface{}
+	if scope := c.Query("scope"); scope != "" {
+		kind, value, _ := strings.Cut(scope, ":")
+		column, ok := forecastScopeColumns[kind]
+		id, err := strconv.ParseInt(value, 10, 64)
+		if !ok || err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scope. Use epic:<id> or sprint:<id>"})
+			return
+		}
+		forecast.Scope = fmt.Sprintf("%s:%d", kind, id)
+		scopeColumn = column
+		scopeArgs = append(scopeArgs, id)
+	}
+	// inScope is the scope condition on todos t with the scope ID as
+	// placeholder n
+	inScope := func(n int) string {
+		if scopeColumn == "" {
+			return "TRUE"
+		}
+		return fmt.Sprintf("t.%s = $%d", scopeColumn, n)
+	}
+
+	ctx := c.Request.Context()
+	var remainingTodos, remainingPoints, unestimated int
+	var enoughHistory bool
+	if err := db.Pool.QueryRow(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE t.status NOT IN (`+doneStatusesSQL+`)),
+			COALESCE(SUM(t.story_points) FILTER (WHERE t.status NOT IN (`+doneStatusesSQL+`)), 0),
+			COUNT(*) FILTER (WHERE t.status NOT IN (`+doneStatusesSQL+`) AND t.story_points IS NULL),
+			COALESCE(MIN(t.completed_at) <= NOW() - make_interval(secs => $`+strconv.Itoa(len(scopeArgs)+1)+`), FALSE)
+		FROM todos t
+		WHERE `+inScope(1)+`
+	`, append(scopeArgs, minForecastHistory.Seconds())...).Scan(&remainingTodos, &remainingPoints, &unestimated, &enoughHistory); err != nil {
+		log.Printf("Error measuring open work: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute forecast", "details": err.Error()})
+		return
+	}
+
+	// Week w covers the 7 days ending (weeks - w) weeks before now
+	rows, err := db.Pool.Query(ctx, `
+		SELECT w, COUNT(t.id), COALESCE(SUM(t.story_points), 0)
+		FROM generate_series(1, $1::int) AS w
+		LEFT JOIN todos t ON `+inScope(2)+`
+			AND t.completed_at >= NOW() - make_interval(days => 7 * ($1::int - w + 1))
+			AND t.completed_at < NOW() - make_interval(days => 7 * ($1::int - w))
+		GROUP BY w
+		ORDER BY w
+	`, append([]interface{}{weeks}, scopeArgs...)...)
+	if err != nil {
+		log.Printf("Error querying velocity: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute forecast", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var todoCounts, pointCounts []int
+	for rows.Next() {
+		var week, todos, points int
+		if err := rows.Scan(&week, &todos, &points); err != nil {
+			log.Printf("Error scanning velocity: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan velocity", "details": err.Error()})
+			return
+		}
+		todoCounts = append(todoCounts, todos)
+		pointCounts = append(pointCounts, points)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating velocity: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute forecast", "details": err.Error()})
+		return
+	}
+
+	// Points are only comparable when all open work is estimated and the
+	// history has points to measure
+	pointsDone := 0
+	for _, points := range pointCounts {
+		pointsDone += points
+	}
+	forecast.Unit, forecast.Remaining = models.ForecastUnitTodos, remainingTodos
+	completed := todoCounts
+	if unestimated == 0 && remainingTodos > 0 && pointsDone > 0 {
+		forecast.Unit, forecast.Remaining = models.ForecastUnitPoints, remainingPoints
+		completed = pointCounts
+	}
+
+	total := 0
+	for i, done := range completed {
+		end := now.AddDate(0, 0, -7*(weeks-i-1))
+		forecast.Weeks = append(forecast.Weeks, models.ForecastWeek{
+			StartDate: end.AddDate(0, 0, -7).In(loc).Format(models.DateLayout),
+			EndDate:   end.In(loc).Format(models.DateLayout),
+			Completed: done,
+		})
+		total += done
+		if i == 0 || done > forecast.BestWeek {
+			forecast.BestWeek = done
+		}
+		if i == 0 || done < forecast.WorstWeek {
+			forecast.WorstWeek = done
+		}
+	}
+	forecast.Velocity = math.Round(float64(total)/float64(weeks)*100) / 100
+
+	switch {
+	case !enoughHistory:
+		forecast.Status = models.ForecastInsufficientData
+		forecast.Reason = "Less than two weeks of completed work to measure"
+	case total == 0:
+		forecast.Status = models.ForecastInsufficientData
+		forecast.Reason = "Nothing was completed in the period"
+	default:
+		forecast.Status = models.ForecastOK
+		forecast.ProjectedDate = forecastDate(now, loc, forecast.Remaining, float64(total)/float64(weeks))
+		forecast.OptimisticDate = forecastDate(now, loc, forecast.Remaining, float64(forecast.BestWeek))
+		if forecast.WorstWeek > 0 {
+			forecast.PessimisticDate = forecastDate(now, loc, forecast.Remaining, float64(forecast.WorstWeek))
+		}
+	}
+
+	c.JSON(http.StatusOK, forecast)
+}
@@ -0,0 +1,445 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/github"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/store"
+)
+
+// githubActor is recorded as the actor of changes pushed from GitHub
+const githubActor = "github"
+
+// maxGitHubWebhookBytes caps the webhook payload we are willing to read
+const maxGitHubWebhookBytes = 5 << 20
+
+// integrationCredential returns the credential name from its environment
+// variable, or else as stored with PUT /admin/credentials/{name}; "" when it
+// is set in neither
+func integrationCredential(ctx context.Context, name string) (string, error) {
+	if value := os.Getenv(strings.ToUpper(name)); value != "" {
+		return value, nil
+	}
+	if db.Pool == nil {
+		return "", nil
+	}
+	return store.GetCredential(ctx, db.Pool, name)
+}
+
+// githubClient returns a client for the configured GitHub token, or nil when
+// no token is configured
+func githubClient(ctx context.Context) (*github.Client, error) {
+	token, err := integrationCredential(ctx, store.CredentialGitHubToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the GitHub token: %w", err)
+	}
+	if token == "" {
+		return nil, nil
+	}
+	return github.NewClient(token), nil
+}
+
+// githubLinkColumns is the column list selected by every link query; scanGitHubLink reads it back
+const githubLinkColumns = `todo_id, owner, repo, issue_number, sync_status, issue_title, issue_state, synced_at, created_at`
+
+// scanGitHubLink scans a row selected with githubLinkColumns into link
+func scanGitHubLink(row pgx.Row, link *models.GitHubLink) error {
+	return row.Scan(&link.TodoID, &link.Owner, &link.Repo, &link.IssueNumber, &link.SyncStatus, &link.IssueTitle, &link.IssueState, &link.SyncedAt, &link.CreatedAt)
+}
+
+// getGitHubLink loads the link of a todo, returning nil when it has none
+func getGitHubLink(ctx context.Context, todoID int64) (*models.GitHubLink, error) {
+	var link models.GitHubLink
+	err := scanGitHubLink(db.Pool.QueryRow(ctx, `
+		SELECT `+githubLinkColumns+` FROM todo_github_links WHERE todo_id = $1
+	`, todoID), &link)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// applyGitHubIssue stores the latest issue title/state on a link and, when
+// sync_status is on, moves the todo to the first done status once the issue
+// closes. A reopened issue on a todo that is already done, or a close the
+// workflow does not allow, is surfaced as a github_conflict activity event
+// instead of changing the status.
+func applyGitHubIssue(ctx context.Context, tx pgx.Tx, c *gin.Context, link models.GitHubLink, issue github.Issue) error {
+	var previousState string
+	if link.IssueState != nil {
+		previousState = *link.IssueState
+	}
+
+	_, err := tx.Exec(ctx, `
+		UPDATE todo_github_links
+		SET issue_title = $1, issue_state = $2, synced_at = NOW()
+		WHERE todo_id = $3
+	`, issue.Title, issue.State, link.TodoID)
+	if err != nil {
+		return fmt.Errorf("failed to update github link: %w", err)
+	}
+
+	if !link.SyncStatus || previousState == issue.State {
+		return nil
+	}
+
+	var before models.Todo
+	if err := scanTodo(tx.QueryRow(ctx, `
+		SELECT `+todoColumns+` FROM todos WHERE id = $1 FOR UPDATE
+	`, link.TodoID), &before); err != nil {
+		return err
+	}
+
+	current, _, err := lookupStatus(ctx, before.Status)
+	if err != nil {
+		return err
+	}
+
+	actor := githubActor
+	switch {
+	case issue.State == github.IssueStateClosed && !current.IsDone:
+		done, ok, err := firstDoneStatus(ctx)
+		if err != nil || !ok {
+			return err
+		}
+		// Run the update in a savepoint so a workflow rule can veto it
+		// without failing the whole sync
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = applyTodoUpdate(ctx, savepoint, c, &actor, link.TodoID, `status = $2, updated_at = NOW()`, done.Key)
+		var transitionErr *transitionError
+		if errors.As(err, &transitionErr) {
+			if err := savepoint.Rollback(ctx); err != nil {
+				return err
+			}
+			return recordGitHubConflict(ctx, tx, link.TodoID, previousState, issue.State)
+		}
+		if err != nil {
+			return err
+		}
+		return savepoint.Commit(ctx)
+	case issue.State == github.IssueStateOpen && current.IsDone:
+		return recordGitHubConflict(ctx, tx, link.TodoID, previousState, issue.State)
+	}
+	return nil
+}
+
+// recordGitHubConflict records that an issue changed state in a way the todo
+// could not follow
+func recordGitHubConflict(ctx context.Context, tx pgx.Tx, todoID int64, oldState, newState string) error {
+	actor := githubActor
+	return recordTodoEvent(ctx, tx, models.TodoEvent{
+		TodoID:   todoID,
+		Type:     models.TodoEventGitHubConflict,
+		Actor:    &actor,
+		OldValue: &oldState,
+		NewValue: &newState,
+	})
+}
+
+// LinkGitHubIssue godoc
+// @Summary      Link a todo to a GitHub issue
+// @Description  Attach an owner/repo#number issue to a todo, replacing any existing link. When a GitHub token is configured (GITHUB_TOKEN or the stored github_token credential) the issue title and state are fetched immediately, and with sync_status the todo is marked done when the issue closes.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int  true  "Todo ID"
+// @Param        link  body      models.CreateGitHubLinkRequest  true  "Issue reference"
+// @Success      200   {object}  models.GitHubLink
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      422   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Failure      502   {object}  map[string]string
+// @Router       /todos/{id}/github-link [post]
+func LinkGitHubIssue(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	var req models.CreateGitHubLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ref, err := github.ParseIssueRef(req.Issue)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Fetch outside the transaction so a slow GitHub doesn't hold row locks
+	var issue *github.Issue
+	client, err := githubClient(c.Request.Context())
+	if err != nil {
+		log.Printf("Error loading GitHub client: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load the GitHub token", "details": err.Error()})
+		return
+	}
+	if client != nil {
+		issue, err = client.GetIssue(c.Request.Context(), ref)
+		if errors.Is(err, github.ErrIssueNotFound) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "GitHub issue " + ref.String() + " not found"})
+			return
+		}
+		if err != nil {
+			log.Printf("Error fetching GitHub issue: %v", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch GitHub issue", "details": err.Error()})
+			return
+		}
+	}
+
+	var link models.GitHubLink
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		err := scanGitHubLink(tx.QueryRow(ctx, `
+			INSERT INTO todo_github_links (todo_id, owner, repo, issue_number, sync_status, created_at)
+			SELECT id, $2, $3, $4, $5, NOW() FROM todos WHERE id = $1
+			ON CONFLICT (todo_id) DO UPDATE
+			SET owner = EXCLUDED.owner,
+			    repo = EXCLUDED.repo,
+			    issue_number = EXCLUDED.issue_number,
+			    sync_status = EXCLUDED.sync_status,
+			    issue_title = NULL,
+			    issue_state = NULL,
+			    synced_at = NULL
+			RETURNING `+githubLinkColumns+`
+		`, todoID, ref.Owner, ref.Repo, ref.Number, req.SyncStatus), &link)
+		if err != nil {
+			return err
+		}
+		if issue != nil {
+			if err := applyGitHubIssue(ctx, tx, c, link, *issue); err != nil {
+				return err
+			}
+		}
+		return scanGitHubLink(tx.QueryRow(ctx, `
+			SELECT `+githubLinkColumns+` FROM todo_github_links WHERE todo_id = $1
+		`, todoID), &link)
+	})
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error linking GitHub issue: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link GitHub issue", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, link)
+}
+
+// UnlinkGitHubIssue godoc
+// @Summary      Remove a todo's GitHub issue link
+// @Description  Detach the GitHub issue from a todo
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Todo ID"
+// @Success      204  "No Content"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/github-link [delete]
+func UnlinkGitHubIssue(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	result, err := db.Pool.Exec(c.Request.Context(), `
+		DELETE FROM todo_github_links WHERE todo_id = $1
+	`, todoID)
+	if err != nil {
+		log.Printf("Error unlinking GitHub issue: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink GitHub issue", "details": err.Error()})
+		return
+	}
+
+	if result.RowsAffected() == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "GitHub link not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GitHubWebhook godoc
+// @Summary      Receive GitHub issue webhooks
+// @Description  Receiver for GitHub "issues" events so issue closure is applied immediately instead of waiting for the periodic sync. Requests must carry a valid X-Hub-Signature-256 computed with the webhook secret, GITHUB_WEBHOOK_SECRET or the stored github_webhook_secret credential.
+// @Tags         integrations
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  map[string]int
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /integrations/github/webhook [post]
+func GitHubWebhook(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	secret, err := integrationCredential(c.Request.Context(), store.CredentialGitHubWebhookSecret)
+	if err != nil {
+		log.Printf("Error loading GitHub webhook secret: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load the GitHub webhook secret", "details": err.Error()})
+		return
+	}
+	if secret == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "GitHub webhook secret is not configured"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxGitHubWebhookBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	if !github.VerifySignature([]byte(secret), body, c.GetHeader("X-Hub-Signature-256")) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid webhook signature"})
+		return
+	}
+
+	// Only issue events carry state changes; acknowledge everything else (e.g. ping)
+	if c.GetHeader("X-GitHub-Event") != "issues" {
+		c.JSON(http.StatusOK, gin.H{"updated": 0})
+		return
+	}
+
+	event, err := github.ParseIssuesEvent(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ref := event.Ref()
+	updated := 0
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			SELECT `+githubLinkColumns+`
+			FROM todo_github_links
+			WHERE LOWER(owner) = LOWER($1) AND LOWER(repo) = LOWER($2) AND issue_number = $3
+			FOR UPDATE
+		`, ref.Owner, ref.Repo, ref.Number)
+		if err != nil {
+			return err
+		}
+		links, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.GitHubLink, error) {
+			var link models.GitHubLink
+			err := scanGitHubLink(row, &link)
+			return link, err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, link := range links {
+			if err := applyGitHubIssue(ctx, tx, c, link, event.Issue); err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error applying GitHub webhook: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply GitHub event", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
+
+// SyncGitHubLinks refreshes every linked issue from GitHub. It is a no-op
+// when no GitHub token is configured. Failures on individual issues are
+// logged and do not stop the rest of the sync.
+func SyncGitHubLinks(ctx context.Context) error {
+	client, err := githubClient(ctx)
+	if err != nil || client == nil {
+		return err
+	}
+
+	rows, err := db.Pool.Query(ctx, `SELECT `+githubLinkColumns+` FROM todo_github_links ORDER BY synced_at ASC NULLS FIRST`)
+	if err != nil {
+		return fmt.Errorf("failed to list github links: %w", err)
+	}
+	links, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.GitHubLink, error) {
+		var link models.GitHubLink
+		err := scanGitHubLink(row, &link)
+		return link, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan github links: %w", err)
+	}
+
+	for _, link := range links {
+		ref := github.IssueRef{Owner: link.Owner, Repo: link.Repo, Number: link.IssueNumber}
+		issue, err := client.GetIssue(ctx, ref)
+		if err != nil {
+			log.Printf("Error syncing GitHub issue %s: %v", ref, err)
+			continue
+		}
+
+		err = db.WithTx(ctx, func(tx pgx.Tx) error {
+			// Re-read under lock so a concurrent webhook isn't overwritten with stale state
+			var current models.GitHubLink
+			err := scanGitHubLink(tx.QueryRow(ctx, `
+				SELECT `+githubLinkColumns+` FROM todo_github_links WHERE todo_id = $1 FOR UPDATE
+			`, link.TodoID), &current)
+			if err == pgx.ErrNoRows {
+				// Unlinked while we were talking to GitHub
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return applyGitHubIssue(ctx, tx, nil, current, *issue)
+		})
+		if err != nil {
+			log.Printf("Error applying GitHub issue %s: %v", ref, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/models"
+)
+
+// integrationHealthTTL is how long integration checks are served before
+// they run again, so the endpoint cannot be used to hammer third parties
+const integrationHealthTTL = 3 * time.Minute
+
+// integrationCheckTimeout bounds a single integration check
+const integrationCheckTimeout = 5 * time.Second
+
+// integrationCheck checks one integration. run is nil when the integration
+// is not configured; otherwise it returns a short message on success.
+type integrationCheck struct {
+	name string
+	run  func(ctx context.Context) (string, error)
+}
+
+// integrationChecks returns the checks of every integration the server has
+func integrationChecks(ctx context.Context) []integrationCheck {
+	github := integrationCheck{name: "github"}
+	client, err := githubClient(ctx)
+	if err != nil {
+		github.run = func(context.Context) (string, error) { return "", err }
+	} else if client != nil {
+		github.run = func(ctx context.Context) (string, error) {
+			limit, err := client.RateLimit(ctx)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d of %d requests left", limit.Remaining, limit.Limit), nil
+		}
+	}
+	return []integrationCheck{github}
+}
+
+// integrationHealth holds the latest result of each integration check
+var integrationHealth = struct {
+	sync.Mutex
+	results   map[string]models.IntegrationHealth
+	checkedAt time.Time
+}{results: map[string]models.IntegrationHealth{}}
+
+// checkIntegrations runs every integration check, carrying the last success
+// and error over from the previous result. A change from ok to failing, or
+// back, is logged once rather than on every check.
+func checkIntegrations(ctx context.Context) []models.IntegrationHealth {
+	checks := integrationChecks(ctx)
+	results := make([]models.IntegrationHealth, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		previous := integrationHealth.results[check.name]
+		result := models.IntegrationHealth{
+			Name:          check.name,
+			Status:        models.IntegrationNotConfigured,
+			LastSuccessAt: previous.LastSuccessAt,
+			LastError:     previous.LastError,
+			LastErrorAt:   previous.LastErrorAt,
+		}
+		if check.run == nil {
+			results[i] = result
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, integrationCheckTimeout)
+			defer cancel()
+			start := time.Now()
+			message, err := check.run(checkCtx)
+			now := time.Now().UTC()
+			latency := time.Since(start).Milliseconds()
+			result.LatencyMS, result.CheckedAt = &latency, &now
+			if err != nil {
+				errMessage := err.Error()
+				result.Status, result.LastError, result.LastErrorAt = models.IntegrationFailing, &errMessage, &now
+				if previous.Status != models.IntegrationFailing {
+					log.Printf("Integration %s is failing: %v", check.name, err)
+				}
+			} else {
+				result.Status, result.Message, result.LastSuccessAt = models.IntegrationOK, message, &now
+				if previous.Status == models.IntegrationFailing {
+					log.Printf("Integration %s recovered", check.name)
+				}
+			}
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// GetIntegrationHealth godoc
+// @Summary      Check the health of integrations
+// @Description  Check each integration the server has: the GitHub token through the rate limit endpoint, which GitHub does not count. Unconfigured integrations are reported as not_configured. Results are cached for 3 minutes, so repeated calls never reach the integrations more often than that. Requires the admin token.
+// @Tags         admin
+// @Produce      json
+// @Security     AdminToken
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.IntegrationHealth  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Router       /admin/integrations/health [get]
+func GetIntegrationHealth(c *gin.Context) {
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	integrationHealth.Lock()
+	defer integrationHealth.Unlock()
+
+	if time.Since(integrationHealth.checkedAt) >= integrationHealthTTL {
+		// The results are shared, so a client hanging up must not fail them
+		for _, result := range checkIntegrations(context.WithoutCancel(c.Request.Context())) {
+			integrationHealth.results[result.Name] = result
+		}
+		integrationHealth.checkedAt = time.Now()
+	}
+
+	checks := integrationChecks(c.Request.Context())
+	results := make([]models.IntegrationHealth, 0, len(checks))
+	for _, check := range checks {
+		results = append(results, integrationHealth.results[check.name])
+	}
+	respondList(c, envelope, results, int64(len(results)), 0, 0)
+}
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// maxIntegrityIDs caps the offending IDs a check lists
+const maxIntegrityIDs = 100
+
+// integrityFixBatch is how many offending rows one cleanup transaction fixes
+const integrityFixBatch = 500
+
+// integrityCheck is a read-only consistency check and its cleanup. find
+// selects the offending rows' IDs as id; fix cleans up the rows among ids
+// that still match find, so rows fixed in the meantime are left alone, and
+// returns how many it fixed. Fixes to todos and subtasks go through the same
+// mutation path as the API, recording revisions, events and audit rows in
+// tx.
+type integrityCheck struct {
+	name        string
+	description string
+	find        string
+	fix         func(ctx context.Context, tx pgx.Tx, c *gin.Context, ids []int64) (int, error)
+}
+
+// integrityChecks are the consistency checks run by GET /admin/integrity and
+// the weekly integrity job. Tables with foreign keys need none; add a check
+// for data whose consistency the schema does not enforce.
+var integrityChecks = []integrityCheck{
+	{
+		name:        "tombstones_of_live_todos",
+		description: "Deletion tombstones of todos that still exist",
+		find: `
+			SELECT tb.todo_id AS id FROM todo_tombstones tb
+			WHERE EXISTS (SELECT 1 FROM todos t WHERE t.id = tb.todo_id)`,
+		fix: fixTombstonesOfLiveTodos,
+	},
+	{
+		name:        "orphaned_custom_field_values",
+		description: "Todos with values for custom fields that are no longer defined",
+		find: `
+			SELECT t.id FROM todos t
+			WHERE EXISTS (` + orphanedCustomFieldsSQL + `)`,
+		fix: fixOrphanedCustomFields,
+	},
+	{
+		name:        "todo_completed_at",
+		description: "Todos whose completed_at does not match whether their status is done",
+		find: `
+			SELECT t.id FROM todos t
+			WHERE ` + todoCompletedAtMismatchSQL,
+		fix: fixTodoCompletedAt,
+	},
+	{
+		name:        "subtask_completed_at",
+		description: "Subtasks whose completed_at does not match whether they are completed",
+		find: `
+			SELECT s.id FROM subtasks s
+			WHERE ` + subtaskCompletedAtMismatchSQL,
+		fix: fixSubtaskCompletedAt,
+	},
+}
+
+// fixTombstonesOfLiveTodos deletes the tombstones among ids of todos that
+// exist. Tombstones are sync bookkeeping, so nothing else is recorded.
+func fixTombstonesOfLiveTodos(ctx context.Context, tx pgx.Tx, c *gin.Context, ids []int64) (int, error) {
+	tag, err := tx.Exec(ctx, `
+		DELETE FROM todo_tombstones tb
+		WHERE tb.todo_id = ANY($1) AND EXISTS (SELECT 1 FROM todos t WHERE t.id = tb.todo_id)
+	`, ids)
+	return int(tag.RowsAffected()), err
+}
+
+// fixOrphanedCustomFields removes the values of undefined custom fields from
+// the todos among ids
+func fixOrphanedCustomFields(ctx context.Context, tx pgx.Tx, c *gin.Context, ids []int64) (int, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT t.id, ARRAY(`+orphanedCustomFieldsSQL+`) FROM todos t
+		WHERE t.id = ANY($1) AND EXISTS (`+orphanedCustomFieldsSQL+`)
+		ORDER BY t.id
+		FOR UPDATE OF t
+	`, ids)
+	if err != nil {
+		return 0, err
+	}
+	type orphans struct {
+		id     int64
+		fields []string
+	}
+	todos, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (orphans, error) {
+		var todo orphans
+		err := row.Scan(&todo.id, &todo.fields)
+		return todo, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, todo := range todos {
+		if _, err := applyTodoUpdate(ctx, tx, c, nil, todo.id, `
+			custom_fields = custom_fields - $2::text[], updated_at = NOW()
+		`, todo.fields); err != nil {
+			return 0, err
+		}
+	}
+	return len(todos), nil
+}
+
+// fixTodoCompletedAt sets completed_at of the done todos among ids that
+// have none to when they were last updated, and clears it on open ones
+func fixTodoCompletedAt(ctx context.Context, tx pgx.Tx, c *gin.Context, ids []int64) (int, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT t.id FROM todos t
+		WHERE t.id = ANY($1) AND `+todoCompletedAtMismatchSQL+`
+		ORDER BY t.id
+		FOR UPDATE
+	`, ids)
+	if err != nil {
+		return 0, err
+	}
+	found, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range found {
+		if _, err := applyTodoUpdate(ctx, tx, c, nil, id, `
+			completed_at = CASE WHEN status IN (`+doneStatusesSQL+`) THEN COALESCE(completed_at, updated_at) END
+		`); err != nil {
+			return 0, err
+		}
+	}
+	return len(found), nil
+}
+
+// fixSubtaskCompletedAt sets completed_at of the completed subtasks among
+// ids that have none to when they were last updated, and clears it on open
+// ones
+func fixSubtaskCompletedAt(ctx context.Context, tx pgx.Tx, c *gin.Context, ids []int64) (int, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT `+subtaskColumns+` FROM subtasks s
+		WHERE s.id = ANY($1) AND `+subtaskCompletedAtMismatchSQL+`
+		ORDER BY s.id
+		FOR UPDATE
+	`, ids)
+	if err != nil {
+		return 0, err
+	}
+	found, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Subtask, error) {
+		var subtask models.Subtask
+		err := scanSubtask(row, &subtask)
+		return subtask, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, before := range found {
+		var subtask models.Subtask
+		if err := scanSubtask(tx.QueryRow(ctx, `
+			UPDATE subtasks
+			SET completed_at = CASE WHEN completed THEN updated_at END
+			WHERE id = $1
+			RETURNING `+subtaskColumns+`
+		`, before.ID), &subtask); err != nil {
+			return 0, err
+		}
+		if err := recordAudit(ctx, tx, c, models.AuditActionUpdate, models.AuditEntitySubtask, subtask.ID, before, subtask); err != nil {
+			return 0, err
+		}
+	}
+	return len(found), nil
+}
+
+// orphanedCustomFieldsSQL selects the custom field names of todo t that have
+// no definition
+const orphanedCustomFieldsSQL = `
+	SELECT k.name FROM jsonb_object_keys(t.custom_fields) AS k(name)
+	WHERE k.name NOT IN (SELECT name FROM custom_field_definitions)`
+
+// todoCompletedAtMismatchSQL matches todos t done without completed_at or
+// open with one
+const todoCompletedAtMismatchSQL = `(
+	(t.status IN (` + doneStatusesSQL + `) AND t.completed_at IS NULL)
+	OR (t.status NOT IN (` + doneStatusesSQL + `) AND t.completed_at IS NOT NULL))`
+
+// subtaskCompletedAtMismatchSQL matches subtasks s completed without
+// completed_at or open with one
+const subtaskCompletedAtMismatchSQL = `(
+	(s.completed AND s.completed_at IS NULL)
+	OR (NOT s.completed AND s.completed_at IS NOT NULL))`
+
+// lastIntegrityReport is the report of the latest integrity job run,
+// published in /admin/metrics as integrity_report
+var lastIntegrityReport struct {
+	sync.Mutex
+	report *models.IntegrityReport
+}
+
+func init() {
+	expvar.Publish("integrity_report", expvar.Func(func() interface{} {
+		lastIntegrityReport.Lock()
+		defer lastIntegrityReport.Unlock()
+		return lastIntegrityReport.report
+	}))
+}
+
+// runIntegrityChecks runs every consistency check
+func runIntegrityChecks(ctx context.Context) (models.IntegrityReport, error) {
+	report := models.IntegrityReport{Checks: []models.IntegrityCheckResult{}, CheckedAt: time.Now()}
+	for _, check := range integrityChecks {
+		result := models.IntegrityCheckResult{Name: check.name, Description: check.description, IDs: []int64{}}
+		rows, err := db.Pool.Query(ctx, `
+			SELECT id, COUNT(*) OVER () FROM (`+check.find+`) found
+			ORDER BY id
+			LIMIT $1
+		`, maxIntegrityIDs)
+		if err != nil {
+			return report, fmt.Errorf("failed to run check %s: %w", check.name, err)
+		}
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id, &result.Count); err != nil {
+				rows.Close()
+				return report, fmt.Errorf("failed to run check %s: %w", check.name, err)
+			}
+			result.IDs = append(result.IDs, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return report, fmt.Errorf("failed to run check %s: %w", check.name, err)
+		}
+		result.Truncated = result.Count > len(result.IDs)
+		report.Checks = append(report.Checks, result)
+	}
+	return report, nil
+}
+
+// fixIntegrityCheck cleans up what check finds in transactions of up to
+// integrityFixBatch rows and returns how many it found and fixed. In a dry
+// run the transactions are rolled back, so Fixed is what would be fixed.
+func fixIntegrityCheck(ctx context.Context, c *gin.Context, check integrityCheck) (models.IntegrityFix, error) {
+	fix := models.IntegrityFix{Name: check.name}
+	rows, err := db.Pool.Query(ctx, `SELECT id FROM (`+check.find+`) found ORDER BY id`)
+	if err != nil {
+		return fix, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return fix, err
+	}
+	fix.Found = len(ids)
+
+	for start := 0; start < len(ids); start += integrityFixBatch {
+		batch := ids[start:min(start+integrityFixBatch, len(ids))]
+		err := db.WithTx(ctx, func(tx pgx.Tx) error {
+			fixed, err := check.fix(ctx, tx, c, batch)
+			if err != nil {
+				return err
+			}
+			fix.Fixed += fixed
+			return nil
+		})
+		if err != nil {
+			return fix, err
+		}
+	}
+	return fix, nil
+}
+
+// RecordIntegrityReport runs every consistency check, logs the ones that
+// found something and keeps the report for /admin/metrics. It never fixes
+// anything.
+func RecordIntegrityReport(ctx context.Context) error {
+	report, err := runIntegrityChecks(ctx)
+	if err != nil {
+		return err
+	}
+	for _, check := range report.Checks {
+		if check.Count > 0 {
+			log.Printf("Integrity check %s found %d rows: %s", check.Name, check.Count, check.Description)
+		}
+	}
+	lastIntegrityReport.Lock()
+	lastIntegrityReport.report = &report
+	lastIntegrityReport.Unlock()
+	return nil
+}
+
+// GetIntegrity godoc
+// @Summary      Run consistency checks
+// @Description  Run read-only checks for data the schema does not keep consistent, such as custom field values without a definition, and list what each found, up to 100 IDs per check. The integrity job runs the same checks every week and publishes the latest report as integrity_report in /admin/metrics. Requires the admin token.
+// @Tags         admin
+// @Produce      json
+// @Security     AdminToken
+// @Success      200  {object}  models.IntegrityReport
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/integrity [get]
+func GetIntegrity(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	report, err := runIntegrityChecks(c.Request.Context())
+	if err != nil {
+		log.Printf("Error running integrity checks: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to run integrity checks", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// FixIntegrity godoc
+// @Summary      Clean up consistency check findings
+// @Description  Clean up what the selected consistency checks find, or all of them when checks is empty, in transactions of up to 500 rows, and count per check what was found and fixed. Todos and subtasks are fixed like any other update, with revisions, activity and audit entries. Rows fixed by someone else in the meantime are not counted. With dry_run the fixes are rolled back, so nothing changes and fixed counts what would be fixed. Requires the admin token.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     AdminToken
+// @Param        fix      body      models.FixIntegrityRequest  true   "Checks to clean up"
+// @Param        dry_run  query     bool                        false  "Only report what would be fixed"  default(false)
+// @Success      200  {object}  models.IntegrityFixResult
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/integrity/fix [post]
+func FixIntegrity(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var req models.FixIntegrityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	checks := integrityChecks
+	if len(req.Checks) > 0 {
+		checks = nil
+		names := make([]string, 0, len(integrityChecks))
+		byName := map[string]integrityCheck{}
+		for _, check := range integrityChecks {
+			names = append(names, check.name)
+			byName[check.name] = check
+		}
+		for _, name := range req.Checks {
+			check, ok := byName[name]
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check " + name + ". Must be one of: " + strings.Join(names, ", ")})
+				return
+			}
+			checks = append(checks, check)
+		}
+	}
+
+	result := models.IntegrityFixResult{DryRun: dryRun, Fixes: []models.IntegrityFix{}}
+	for _, check := range checks {
+		fix, err := fixIntegrityCheck(c.Request.Context(), c, check)
+		if err != nil {
+			log.Printf("Error fixing integrity check %s: %v", check.name, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fix " + check.name, "details": err.Error(), "fixes": append(result.Fixes, fix)})
+			return
+		}
+		result.Fixes = append(result.Fixes, fix)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/testsupport"
+)
+
+// Fixing a todo records it like any other update, and a dry run records
+// nothing
+func TestFixIntegrityOrphanedCustomFields(t *testing.T) {
+	usePool(t)
+	ctx := context.Background()
+	id := testsupport.NewTodoFactory(t, db.Pool).
+		WithTitle("Integrity").
+		WithCustomFields(map[string]interface{}{"integrity_test_undefined": 1}).
+		Create(ctx).ID
+
+	engine := gin.New()
+	engine.POST("/admin/integrity/fix", FixIntegrity)
+	fix := func(query string) models.IntegrityFixResult {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/admin/integrity/fix"+query, strings.NewReader(`{"checks": ["orphaned_custom_field_values"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", w.Code, w.Body)
+		}
+		var result models.IntegrityFixResult
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("decoding %s: %v", w.Body, err)
+		}
+		return result
+	}
+	audits := func() (n int) {
+		t.Helper()
+		if err := db.Pool.QueryRow(ctx, `
+			SELECT COUNT(*) FROM audit_log WHERE entity_type = $1 AND entity_id = $2
+		`, models.AuditEntityTodo, id).Scan(&n); err != nil {
+			t.Fatalf("counting audit rows: %v", err)
+		}
+		return n
+	}
+
+	before := testsupport.SideEffects(t, db.Pool, id)
+	if result := fix("?dry_run=true"); !result.DryRun || len(result.Fixes) != 1 || result.Fixes[0].Fixed < 1 {
+		t.Errorf("dry run = %+v, want the todo counted as fixed", result)
+	}
+	if after := testsupport.SideEffects(t, db.Pool, id); after != before {
+		t.Errorf("the dry run changed something:\nbefore %s\nafter  %s", before, after)
+	}
+
+	if result := fix(""); result.DryRun || result.Fixes[0].Fixed < 1 {
+		t.Errorf("fix = %+v, want the todo fixed", result)
+	}
+	var fields string
+	if err := db.Pool.QueryRow(ctx, `SELECT custom_fields::text FROM todos WHERE id = $1`, id).Scan(&fields); err != nil || fields != "{}" {
+		t.Errorf("custom_fields = %s, %v, want {}", fields, err)
+	}
+	if n := audits(); n != 1 {
+		t.Errorf("%d audit rows for the todo, want 1", n)
+	}
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/linkpreview"
+	"flow-v1/backend/internal/models"
+)
+
+// linkColumns is the column list selected by every link query; scanLink reads it back
+const linkColumns = `id, todo_id, url, title, favicon_url, metadata_status, created_at`
+
+// scanLink scans a row selected with linkColumns into link
+func scanLink(row pgx.Row, link *models.TodoLink) error {
+	return row.Scan(&link.ID, &link.TodoID, &link.URL, &link.Title, &link.FaviconURL, &link.MetadataStatus, &link.CreatedAt)
+}
+
+// maxLinkTitleLength matches the title column of todo_links
+const maxLinkTitleLength = 300
+
+// linkMetadataTimeout bounds a background metadata fetch including the update
+const linkMetadataTimeout = 15 * time.Second
+
+var (
+	linkFetcher = linkpreview.NewFetcher()
+	// linkFetchSlots limits how many metadata fetches run at once
+	linkFetchSlots = make(chan struct{}, 4)
+)
+
+// getTodoLinks returns the links of a todo in the order they were added
+func getTodoLinks(ctx context.Context, todoID int64) ([]models.TodoLink, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+linkColumns+` FROM todo_links WHERE todo_id = $1 ORDER BY created_at ASC, id ASC
+	`, todoID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.TodoLink, error) {
+		var link models.TodoLink
+		err := scanLink(row, &link)
+		return link, err
+	})
+}
+
+// fetchLinkMetadata fetches the page behind a link and stores its title and
+// favicon. It is best-effort: failures only mark the link as failed, leaving
+// the bare URL. A title given when the link was created is kept.
+func fetchLinkMetadata(linkID int64, url string) {
+	linkFetchSlots <- struct{}{}
+	defer func() { <-linkFetchSlots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), linkMetadataTimeout)
+	defer cancel()
+
+	status := models.LinkMetadataFetched
+	metadata, err := linkFetcher.Fetch(ctx, url)
+	if err != nil {
+		log.Printf("Error fetching link metadata for %s: %v", url, err)
+		status = models.LinkMetadataFailed
+	}
+	title := []rune(metadata.Title)
+	if len(title) > maxLinkTitleLength {
+		title = title[:maxLinkTitleLength]
+	}
+
+	_, err = db.Pool.Exec(ctx, `
+		UPDATE todo_links
+		SET title = COALESCE(title, NULLIF($2, '')),
+		    favicon_url = NULLIF($3, ''),
+		    metadata_status = $4
+		WHERE id = $1
+	`, linkID, string(title), metadata.FaviconURL, status)
+	if err != nil {
+		log.Printf("Error storing link metadata for link %d: %v", linkID, err)
+	}
+}
+
+// GetTodoLinks godoc
+// @Summary      List links of a todo
+// @Description  Get the URLs attached to a todo in the order they were added
+// @Tags         links
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int   true   "Todo ID"
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.TodoLink  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/links [get]
+func GetTodoLinks(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	var todoExists bool
+	err = db.Pool.QueryRow(c.Request.Context(), `
+		SELECT EXISTS(SELECT 1 FROM todos WHERE id = $1)
+	`, todoID).Scan(&todoExists)
+	if err != nil {
+		log.Printf("Error checking todo existence: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify todo", "details": err.Error()})
+		return
+	}
+	if !todoExists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+
+	links, err := getTodoLinks(c.Request.Context(), todoID)
+	if err != nil {
+		log.Printf("Error fetching todo links: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch links", "details": err.Error()})
+		return
+	}
+
+	respondList(c, envelope, links, int64(len(links)), 0, 0)
+}
+
+// CreateTodoLink godoc
+// @Summary      Attach a link to a todo
+// @Description  Attach an http or https URL to a todo. Unless fetch_metadata is false, the page title and favicon are fetched in the background and the link is updated when the fetch completes; until then metadata_status is pending.
+// @Tags         links
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int  true  "Todo ID"
+// @Param        link  body      models.CreateTodoLinkRequest  true  "Link data"
+// @Success      201   {object}  models.TodoLink
+// @Header       201   {string}  Location  "URL of the created resource"
+// @Header       201   {string}  ETag      "Strong ETag of the created resource"
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /todos/{id}/links [post]
+func CreateTodoLink(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	var req models.CreateTodoLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	target, err := linkpreview.ValidateURL(req.URL)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fetch := req.FetchMetadata == nil || *req.FetchMetadata
+	status := models.LinkMetadataPending
+	if !fetch {
+		status = models.LinkMetadataSkipped
+	}
+	var title interface{}
+	if req.Title != "" {
+		title = req.Title
+	}
+
+	var link models.TodoLink
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		err := scanLink(tx.QueryRow(ctx, `
+			INSERT INTO todo_links (todo_id, url, title, metadata_status, created_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			RETURNING `+linkColumns+`
+		`, todoID, target.String(), title, status), &link)
+		if err != nil {
+			return err
+		}
+		if err := recordTodoEvent(ctx, tx, models.TodoEvent{TodoID: todoID, Type: models.TodoEventLinkAdded, NewValue: &link.URL}); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionCreate, models.AuditEntityLink, link.ID, nil, link)
+	})
+	if isForeignKeyViolation(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating todo link: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create link", "details": err.Error()})
+		return
+	}
+
+	if fetch {
+		go fetchLinkMetadata(link.ID, link.URL)
+	}
+
+	respondCreated(c, fmt.Sprintf("/todos/%d/links/%d", link.TodoID, link.ID), link)
+}
+
+// DeleteTodoLink godoc
+// @Summary      Remove a link from a todo
+// @Description  Delete a link by its ID
+// @Tags         links
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int  true  "Todo ID"
+// @Param        linkId  path      int  true  "Link ID"
+// @Success      204  "No Content"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/links/{linkId} [delete]
+func DeleteTodoLink(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	linkID, err := strconv.ParseInt(c.Param("linkId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var before models.TodoLink
+		if err := scanLink(tx.QueryRow(ctx, `
+			DELETE FROM todo_links WHERE id = $1 AND todo_id = $2
+			RETURNING `+linkColumns+`
+		`, linkID, todoID), &before); err != nil {
+			return err
+		}
+		if err := recordTodoEvent(ctx, tx, models.TodoEvent{TodoID: todoID, Type: models.TodoEventLinkRemoved, OldValue: &before.URL}); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionDelete, models.AuditEntityLink, before.ID, before, nil)
+	})
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting todo link: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete link", "details": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
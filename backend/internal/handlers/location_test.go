@@ -0,0 +1,137 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"flow-v1/backend/internal/router"
+	"flow-v1/backend/internal/testsupport"
+)
+
+// createdResource is what a create response says about the new resource
+type createdResource struct {
+	ID   int64  `json:"id"`
+	Key  string `json:"key"`
+	Todo struct {
+		ID int64 `json:"id"`
+	} `json:"todo"`
+}
+
+// Every route that creates a resource answers 201 with a Location for it
+// and a strong ETag. Where the location can be fetched, a conditional GET
+// with that ETag gets a 304.
+func TestCreateLocation(t *testing.T) {
+	pool := testsupport.UseDatabase(t)
+	t.Setenv("EMBED_SIGNING_KEY", "location-test-key")
+	engine := router.New(router.Options{})
+	server := testsupport.NewServer(t, engine)
+	id := func(id int64) string { return strconv.FormatInt(id, 10) }
+
+	// create checks the response to a create and returns the resource
+	create := func(t *testing.T, path string, body interface{}, location func(createdResource) string) (createdResource, string) {
+		t.Helper()
+		w := server.Do(http.MethodPost, router.BasePath+path, body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("POST %s: %d %s", path, w.Code, w.Body)
+		}
+		var resource createdResource
+		if err := json.Unmarshal(w.Body.Bytes(), &resource); err != nil {
+			t.Fatal(err)
+		}
+		want := router.BasePath + location(resource)
+		if got := w.Header().Get("Location"); got != want {
+			t.Errorf("POST %s: Location %q, want %q", path, got, want)
+		}
+		etag := w.Header().Get("ETag")
+		if !strings.HasPrefix(etag, `"`) {
+			t.Errorf("POST %s: ETag %q, want a strong one", path, etag)
+		}
+		return resource, etag
+	}
+	// deleteTodo removes a created todo, and what hangs off it, at the end
+	deleteTodo := func(todoID int64) {
+		t.Cleanup(func() { pool.Exec(context.Background(), `DELETE FROM todos WHERE id = $1`, todoID) })
+	}
+
+	todo, etag := create(t, "/todos", map[string]interface{}{"title": "Location", "due_date": "2030-01-02"},
+		func(r createdResource) string { return "/todos/" + id(r.ID) })
+	deleteTodo(todo.ID)
+	todoPath := "/todos/" + id(todo.ID)
+	conditionalGet := func(t *testing.T, location, etag string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, location, nil)
+		req.Header.Set("If-None-Match", etag)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != http.StatusNotModified {
+			t.Errorf("GET %s with the ETag from the create: %d, want 304", location, w.Code)
+		}
+	}
+	conditionalGet(t, router.BasePath+todoPath, etag)
+
+	tests := []struct {
+		name     string
+		path     string
+		body     interface{}
+		location func(createdResource) string
+		// get is set when a GET of the location returns the resource
+		get bool
+		// remove is set when the location deletes what was created; the
+		// rest goes with the todo
+		remove bool
+	}{
+		{"subtask", todoPath + "/subtasks", map[string]string{"title": "Write docs"},
+			func(r createdResource) string { return todoPath + "/subtasks/" + id(r.ID) }, false, false},
+		{"link", todoPath + "/links", map[string]interface{}{"url": "https://example.com/spec", "fetch_metadata": false},
+			func(r createdResource) string { return todoPath + "/links/" + id(r.ID) }, false, false},
+		{"reminder", todoPath + "/reminders", map[string]string{"offset": "-PT2H"},
+			func(r createdResource) string { return todoPath + "/reminders/" + id(r.ID) }, false, false},
+		{"time entry", todoPath + "/time-entries", map[string]string{"started_at": "2025-03-10T09:00:00Z", "ended_at": "2025-03-10T10:30:00Z"},
+			func(r createdResource) string { return todoPath + "/time-entries/" + id(r.ID) }, false, false},
+		{"epic", "/epics", map[string]string{"title": "Location epic"},
+			func(r createdResource) string { return "/epics/" + id(r.ID) }, true, true},
+		{"sprint", "/sprints", map[string]string{"name": "Location sprint", "start_date": "2030-01-07", "end_date": "2030-01-18"},
+			func(r createdResource) string { return "/sprints/" + id(r.ID) }, true, true},
+		{"checklist template", "/checklist-templates", map[string]interface{}{"name": "Location template", "items": []string{"Tests written"}},
+			func(r createdResource) string { return "/checklist-templates/" + id(r.ID) }, true, true},
+		// Saves the subtask created above
+		{"template from subtasks", todoPath + "/subtasks/save-as-template", map[string]string{"name": "Location saved template"},
+			func(r createdResource) string { return "/checklist-templates/" + id(r.ID) }, true, true},
+		{"status", "/statuses", map[string]string{"key": "location_test", "label": "Location test"},
+			func(r createdResource) string { return "/statuses/" + r.Key }, false, true},
+		{"priority", "/priorities", map[string]string{"key": "location_test", "label": "Location test"},
+			func(r createdResource) string { return "/priorities/" + r.Key }, false, true},
+		{"custom field", "/custom-fields", map[string]string{"name": "location_test", "type": "text"},
+			func(r createdResource) string { return "/custom-fields/" + id(r.ID) }, false, true},
+		{"embed", "/embeds", map[string]string{"name": "Location embed", "origin": "https://wiki.example.com"},
+			func(r createdResource) string { return "/embeds/" + id(r.ID) }, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource, etag := create(t, tt.path, tt.body, tt.location)
+			location := router.BasePath + tt.location(resource)
+			if tt.remove {
+				t.Cleanup(func() { server.Do(http.MethodDelete, location, nil) })
+			}
+			if tt.get {
+				conditionalGet(t, location, etag)
+			}
+		})
+	}
+
+	t.Run("import", func(t *testing.T) {
+		w := server.Get(router.BasePath + todoPath + "/export")
+		if w.Code != http.StatusOK {
+			t.Fatalf("export: %d %s", w.Code, w.Body)
+		}
+		imported, etag := create(t, "/todos/import", w.Body.Bytes(),
+			func(r createdResource) string { return "/todos/" + id(r.Todo.ID) })
+		deleteTodo(imported.Todo.ID)
+		conditionalGet(t, router.BasePath+"/todos/"+id(imported.Todo.ID), etag)
+	})
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/store"
+)
+
+// editLockTTL is how long an editing lock lasts without a heartbeat
+const editLockTTL = 2 * time.Minute
+
+// editLockHeader is set on todo updates made while another user holds the
+// todo's editing lock
+const editLockHeader = "X-Edit-Lock-Held-By"
+
+// todoLockColumns is the column list selected by every lock query; scanTodoLock reads it back
+const todoLockColumns = `todo_id, holder, acquired_at, expires_at`
+
+// scanTodoLock scans a row selected with todoLockColumns into lock
+func scanTodoLock(row pgx.Row, lock *models.TodoLock) error {
+	return row.Scan(&lock.TodoID, &lock.LockedBy, &lock.AcquiredAt, &lock.ExpiresAt)
+}
+
+// getEditLock returns the unexpired editing lock on a todo, or nil when
+// nobody holds one
+func getEditLock(ctx context.Context, q store.Querier, todoID int64) (*models.TodoLock, error) {
+	var lock models.TodoLock
+	err := scanTodoLock(q.QueryRow(ctx, `
+		SELECT `+todoLockColumns+` FROM todo_locks WHERE todo_id = $1 AND expires_at > NOW()
+	`, todoID), &lock)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// editLockHeldByOther returns the holder of a todo's editing lock when it is
+// someone other than user, and "" otherwise
+func editLockHeldByOther(ctx context.Context, q store.Querier, todoID int64, user string) (string, error) {
+	lock, err := getEditLock(ctx, q, todoID)
+	if err != nil || lock == nil || lock.LockedBy == user {
+		return "", err
+	}
+	return lock.LockedBy, nil
+}
+
+// PurgeExpiredEditLocks deletes editing locks whose holder stopped sending
+// heartbeats. Expired locks are already ignored; this only keeps the table small.
+func PurgeExpiredEditLocks(ctx context.Context) error {
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM todo_locks WHERE expires_at <= NOW()`); err != nil {
+		return fmt.Errorf("failed to purge edit locks: %w", err)
+	}
+	return nil
+}
+
+// AcquireTodoLock godoc
+// @Summary      Lock a todo for editing
+// @Description  Take the advisory editing lock on a todo for the current user, or renew it if they already hold it. The lock expires 2 minutes after it was last taken or renewed. While someone else holds it the request fails with 409 and the current lock; updates by others still succeed but carry an X-Edit-Lock-Held-By header.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Todo ID"
+// @Success      200  {object}  models.TodoLock
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/lock [post]
+func AcquireTodoLock(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	// An existing lock is only taken over once it has expired
+	ctx := c.Request.Context()
+	var lock models.TodoLock
+	err = scanTodoLock(db.Pool.QueryRow(ctx, `
+		INSERT INTO todo_locks (todo_id, holder, acquired_at, expires_at)
+		VALUES ($1, $2, NOW(), NOW() + make_interval(secs => $3))
+		ON CONFLICT (todo_id) DO UPDATE
+		SET holder = EXCLUDED.holder,
+		    acquired_at = CASE WHEN todo_locks.holder = EXCLUDED.holder AND todo_locks.expires_at > NOW() THEN todo_locks.acquired_at ELSE NOW() END,
+		    expires_at = EXCLUDED.expires_at
+		WHERE todo_locks.holder = EXCLUDED.holder OR todo_locks.expires_at <= NOW()
+		RETURNING `+todoLockColumns+`
+	`, todoID, currentUser(c), editLockTTL.Seconds()), &lock)
+	if isForeignKeyViolation(err) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		held, err := getEditLock(ctx, db.Pool, todoID)
+		if err != nil {
+			log.Printf("Error fetching edit lock: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock todo", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "Todo is being edited by someone else", "lock": held})
+		return
+	}
+	if err != nil {
+		log.Printf("Error locking todo: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock todo", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, lock)
+}
+
+// RenewTodoLock godoc
+// @Summary      Renew a todo editing lock
+// @Description  Heartbeat for an editing lock held by the current user, extending it to 2 minutes from now. Fails with 404 once the lock has expired or was released, so the client knows to take it again.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Todo ID"
+// @Success      200  {object}  models.TodoLock
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/lock [put]
+func RenewTodoLock(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	var lock models.TodoLock
+	err = scanTodoLock(db.Pool.QueryRow(c.Request.Context(), `
+		UPDATE todo_locks
+		SET expires_at = NOW() + make_interval(secs => $3)
+		WHERE todo_id = $1 AND holder = $2 AND expires_at > NOW()
+		RETURNING `+todoLockColumns+`
+	`, todoID, currentUser(c), editLockTTL.Seconds()), &lock)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You do not hold a lock on this todo"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error renewing todo lock: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to renew todo lock", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, lock)
+}
+
+// ReleaseTodoLock godoc
+// @Summary      Release a todo editing lock
+// @Description  Release the editing lock the current user holds on a todo. Releasing a lock that is not held succeeds without doing anything.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Todo ID"
+// @Success      204  "No Content"
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/lock [delete]
+func ReleaseTodoLock(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	if _, err := db.Pool.Exec(c.Request.Context(), `
+		DELETE FROM todo_locks WHERE todo_id = $1 AND holder = $2
+	`, todoID, currentUser(c)); err != nil {
+		log.Printf("Error releasing todo lock: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to release todo lock", "details": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
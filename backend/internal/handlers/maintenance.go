@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// maintenanceCacheTTL bounds how long a change to maintenance mode takes to
+// reach every instance
+const maintenanceCacheTTL = 3 * time.Second
+
+// maintenanceSettingsColumns is the column list selected by every maintenance
+// settings query; scanMaintenanceSettings reads it back
+const maintenanceSettingsColumns = `enabled, read_only, message, retry_after_seconds, updated_at`
+
+// scanMaintenanceSettings scans a row selected with maintenanceSettingsColumns
+func scanMaintenanceSettings(row pgx.Row) (models.MaintenanceSettings, error) {
+	var settings models.MaintenanceSettings
+	err := row.Scan(&settings.Enabled, &settings.ReadOnly, &settings.Message, &settings.RetryAfterSeconds, &settings.UpdatedAt)
+	return settings, err
+}
+
+// maintenanceCache holds the maintenance settings so checking them does not
+// cost a query per request
+var maintenanceCache = &configCache[models.MaintenanceSettings]{ttl: maintenanceCacheTTL, load: func(ctx context.Context) ([]models.MaintenanceSettings, error) {
+	settings, err := scanMaintenanceSettings(db.Pool.QueryRow(ctx, `
+		SELECT `+maintenanceSettingsColumns+` FROM maintenance_settings
+	`))
+	if err == pgx.ErrNoRows {
+		return []models.MaintenanceSettings{{ReadOnly: true}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []models.MaintenanceSettings{settings}, nil
+}}
+
+// readMethods are the methods still served in read-only maintenance mode
+var readMethods = map[string]bool{http.MethodGet: true, http.MethodHead: true, http.MethodOptions: true}
+
+// MaintenanceMode answers 503 with Retry-After while maintenance mode is on:
+// to writes in read-only mode and to everything otherwise. The maintenance
+// endpoints stay reachable so it can be switched off again. If the flag
+// cannot be read the request is let through.
+func MaintenanceMode(c *gin.Context) {
+	if db.Pool == nil || strings.HasSuffix(c.FullPath(), "/admin/maintenance") {
+		c.Next()
+		return
+	}
+	settings, err := maintenanceCache.get(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching maintenance settings: %v", err)
+		c.Next()
+		return
+	}
+	if len(settings) == 0 || !settings[0].Enabled || (settings[0].ReadOnly && readMethods[c.Request.Method]) {
+		c.Next()
+		return
+	}
+
+	c.Header("Retry-After", strconv.Itoa(settings[0].RetryAfterSeconds))
+	c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": settings[0].Message, "maintenance": true})
+}
+
+// GetMaintenance godoc
+// @Summary      Get maintenance mode
+// @Description  Get whether maintenance mode is on and what it blocks. Requires the admin token.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     AdminToken
+// @Success      200  {object}  models.MaintenanceSettings
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/maintenance [get]
+func GetMaintenance(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	settings, err := scanMaintenanceSettings(db.Pool.QueryRow(c.Request.Context(), `
+		SELECT `+maintenanceSettingsColumns+` FROM maintenance_settings
+	`))
+	if err != nil {
+		log.Printf("Error fetching maintenance settings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch maintenance settings", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateMaintenance godoc
+// @Summary      Toggle maintenance mode
+// @Description  Turn maintenance mode on or off for every instance; changes apply within a few seconds. In read-only mode writes get a 503 and reads still work; with read_only false everything but these endpoints gets a 503. Requires the admin token.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     AdminToken
+// @Param        settings  body      models.UpdateMaintenanceRequest  true  "Maintenance settings"
+// @Success      200       {object}  models.MaintenanceSettings
+// @Failure      400       {object}  map[string]string
+// @Failure      401       {object}  map[string]string
+// @Failure      403       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /admin/maintenance [put]
+func UpdateMaintenance(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.UpdateMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Message != nil && strings.TrimSpace(*req.Message) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "message must not be empty"})
+		return
+	}
+
+	settings, err := scanMaintenanceSettings(db.Pool.QueryRow(c.Request.Context(), `
+		INSERT INTO maintenance_settings (id, enabled, read_only, message, retry_after_seconds, updated_at)
+		VALUES (TRUE, COALESCE($1, FALSE), COALESCE($2, TRUE), COALESCE($3, 'The service is undergoing maintenance. Please try again shortly.'), COALESCE($4, 300), NOW())
+		ON CONFLICT (id) DO UPDATE SET
+			enabled = COALESCE($1, maintenance_settings.enabled),
+			read_only = COALESCE($2, maintenance_settings.read_only),
+			message = COALESCE($3, maintenance_settings.message),
+			retry_after_seconds = COALESCE($4, maintenance_settings.retry_after_seconds),
+			updated_at = NOW()
+		RETURNING `+maintenanceSettingsColumns+`
+	`, req.Enabled, req.ReadOnly, req.Message, req.RetryAfterSeconds))
+	if err != nil {
+		log.Printf("Error updating maintenance settings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update maintenance settings", "details": err.Error()})
+		return
+	}
+	maintenanceCache.reset()
+	log.Printf("Maintenance mode enabled=%t read_only=%t", settings.Enabled, settings.ReadOnly)
+
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"expvar"
+
+	"github.com/gin-gonic/gin"
+)
+
+// todoUpdateConflicts counts todo updates sent with an updated_at older than
+// the stored one
+var todoUpdateConflicts = expvar.NewInt("todo_update_conflicts")
+
+// GetMetrics godoc
+// @Summary      Get server metrics
+// @Description  Get the server's counters and Go runtime statistics as expvar JSON, including todo_update_conflicts, todo_lists_coalesced and usage_events_dropped, and the latest weekly integrity_report. Requires the admin token.
+// @Tags         admin
+// @Produce      json
+// @Security     AdminToken
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Router       /admin/metrics [get]
+func GetMetrics(c *gin.Context) {
+	expvar.Handler().ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// errMoveSprintNotFound and errMoveEpicNotFound tell a missing target apart
+// from a todo deleted during the move
+var (
+	errMoveSprintNotFound = errors.New("sprint not found")
+	errMoveEpicNotFound   = errors.New("epic not found")
+)
+
+// warnSprintCapacity records a warning for c when the sprint's todos add up
+// to more story points than its capacity
+func warnSprintCapacity(ctx context.Context, tx pgx.Tx, c *gin.Context, sprint models.Sprint) error {
+	if sprint.Capacity == nil {
+		return nil
+	}
+	var points int
+	if err := tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(story_points), 0) FROM todos WHERE sprint_id = $1
+	`, sprint.ID).Scan(&points); err != nil {
+		return err
+	}
+	if points > *sprint.Capacity {
+		addWarning(c, models.WarningSprintOverCapacity, fmt.Sprintf("Sprint %s is over capacity: %d of %d story points", sprint.Name, points, *sprint.Capacity), sprint.ID)
+	}
+	return nil
+}
+
+// MoveTodos godoc
+// @Summary      Move todos to a sprint or epic
+// @Description  Move up to 100 todos to a sprint, an epic or both in one transaction. A null sprint_id moves them to the backlog and a null epic_id out of their epic; a missing field leaves it unchanged. Todos already where they are asked to go are left as they are. If any todo does not exist nothing is moved and the missing IDs are returned. Going over the sprint's capacity is reported in warnings rather than refused. A note is recorded on every todo moved. With dry_run=true the move is run and rolled back, so nothing changes.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        move     body      models.MoveTodosRequest  true   "Todos and where to move them"
+// @Param        dry_run  query     bool                     false  "Only report what would be moved"  default(false)
+// @Success      200   {object}  models.MoveTodosResult
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]interface{}
+// @Failure      409   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /todos/bulk/move [post]
+func MoveTodos(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var req models.MoveTodosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.SprintID.Set && !req.EpicID.Set {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Set sprint_id, epic_id or both"})
+		return
+	}
+
+	result := models.MoveTodosResult{Todos: []models.Todo{}, DryRun: dryRun}
+	var missing []int64
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var sprint models.Sprint
+		if req.SprintID.ID != nil {
+			err := lockSprint(ctx, tx, *req.SprintID.ID, &sprint)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errMoveSprintNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if sprint.State == models.SprintStateClosed {
+				return errSprintClosed
+			}
+		}
+		if req.EpicID.ID != nil {
+			var epicID int64
+			err := tx.QueryRow(ctx, `SELECT id FROM epics WHERE id = $1 FOR SHARE`, *req.EpicID.ID).Scan(&epicID)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errMoveEpicNotFound
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		if missing, err = missingTodos(ctx, tx, req.TodoIDs); err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return errTodosNotFound
+		}
+
+		// A todo moved in both columns is reported once, as it ends up
+		var order []int64
+		moved := map[int64]models.Todo{}
+		for _, move := range []struct {
+			column string
+			target models.MoveTarget
+		}{{"sprint_id", req.SprintID}, {"epic_id", req.EpicID}} {
+			if !move.target.Set {
+				continue
+			}
+			todos, err := moveTodos(ctx, tx, c, req.TodoIDs, move.column, move.target.ID)
+			if err != nil {
+				return err
+			}
+			for _, todo := range todos {
+				if _, ok := moved[todo.ID]; !ok {
+					order = append(order, todo.ID)
+				}
+				moved[todo.ID] = todo
+			}
+		}
+		for _, id := range order {
+			result.Todos = append(result.Todos, moved[id])
+			if req.Note != "" {
+				if err := recordTodoNote(ctx, tx, id, req.Note); err != nil {
+					return err
+				}
+			}
+		}
+
+		if req.SprintID.ID != nil {
+			return warnSprintCapacity(ctx, tx, c, sprint)
+		}
+		return nil
+	})
+	if errors.Is(err, errTodosNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found", "todo_ids": missing})
+		return
+	}
+	if errors.Is(err, errMoveSprintNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sprint not found"})
+		return
+	}
+	if errors.Is(err, errMoveEpicNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Epic not found"})
+		return
+	}
+	if errors.Is(err, errSprintClosed) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error moving todos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move todos", "details": err.Error()})
+		return
+	}
+	result.Warnings = requestWarnings(c)
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ignoredParamsHeader lists the query parameters a lenient list request
+// dropped
+const ignoredParamsHeader = "X-Ignored-Params"
+
+// errInvalidStrict is returned for a strict parameter that is not a boolean
+var errInvalidStrict = errors.New("Invalid strict. Must be true or false")
+
+// queryParams tracks the query parameters of a list request that cannot be
+// used. In lenient mode they are dropped and named in X-Ignored-Params; in
+// strict mode, set by strict=true, STRICT_QUERY_PARAMS or the strict_params
+// feature flag, the first one fails the request with a 400.
+type queryParams struct {
+	c        *gin.Context
+	known    map[string]bool
+	prefixes []string
+	strict   bool
+	ignored  []string
+	invalid  *invalidParam
+}
+
+// invalidParam is a query parameter a strict request cannot use
+type invalidParam struct {
+	name     string
+	expected string
+}
+
+// newQueryParams starts checking the query of c against the parameter
+// names an endpoint knows, plus names starting with one of prefixes. It
+// returns an error for an invalid strict parameter.
+func newQueryParams(c *gin.Context, names []string, prefixes ...string) (*queryParams, error) {
+	p := &queryParams{c: c, known: map[string]bool{"strict": true}, prefixes: prefixes}
+	for _, name := range names {
+		p.known[name] = true
+	}
+	strictDefault := os.Getenv("STRICT_QUERY_PARAMS") == "true"
+	if _, ok := c.GetQuery("strict"); !ok && !strictDefault {
+		strictDefault = flagEnabled(c, "strict_params")
+	}
+	strict, err := strconv.ParseBool(c.DefaultQuery("strict", strconv.FormatBool(strictDefault)))
+	if err != nil {
+		return nil, errInvalidStrict
+	}
+	p.strict = strict
+	return p, nil
+}
+
+// drop records that the value of the parameter name was not used because it
+// is not what expected describes
+func (p *queryParams) drop(name, expected string) {
+	if p.strict && p.invalid == nil {
+		p.invalid = &invalidParam{name: name, expected: expected}
+	}
+	p.ignored = append(p.ignored, name)
+}
+
+// respond finishes the check: unknown parameter names are dropped too. It
+// writes a 400 for a strict request with a dropped parameter and reports
+// whether it did; otherwise it names the dropped parameters in
+// X-Ignored-Params.
+func (p *queryParams) respond() bool {
+	var unknown []string
+	for name := range p.c.Request.URL.Query() {
+		if !p.known[name] && !p.hasKnownPrefix(name) {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		p.drop(name, "a known parameter")
+	}
+
+	if p.invalid != nil {
+		p.c.JSON(http.StatusBadRequest, gin.H{
+			"error":    "Invalid query parameter " + p.invalid.name + ". Expected " + p.invalid.expected,
+			"param":    p.invalid.name,
+			"expected": p.invalid.expected,
+		})
+		return true
+	}
+	if len(p.ignored) > 0 {
+		p.c.Header(ignoredParamsHeader, strings.Join(p.ignored, ","))
+	}
+	return false
+}
+
+func (p *queryParams) hasKnownPrefix(name string) bool {
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// localUser is the user every request acts as. The API has no
+// authentication yet, so there is exactly one set of preferences.
+const localUser = "local"
+
+// currentUser returns the key of the user making the request
+func currentUser(c *gin.Context) string {
+	return localUser
+}
+
+// preferenceRule validates one preference. Objects have fields, leaves a check.
+type preferenceRule struct {
+	check  func(value interface{}) error
+	fields map[string]preferenceRule
+}
+
+// preferenceSchema describes every preference a user may store; anything
+// else is rejected. It mirrors models.Preferences.
+var preferenceSchema = map[string]preferenceRule{
+	"default_sort": {fields: map[string]preferenceRule{
+		"sort_by": {check: oneOf("due_date", "priority", "status", "created_at", "updated_at", "title", "story_points", "estimate")},
+		"order":   {check: oneOf("asc", "desc")},
+	}},
+	"default_view": {check: oneOf("list", "board", "calendar")},
+	"page_size":    {check: intBetween(10, 200)},
+	"timezone":     {check: validTimezone},
+	"week_start":   {check: oneOf("monday", "sunday", "saturday")},
+	"notifications": {fields: map[string]preferenceRule{
+		"email_digest":  {check: isBool},
+		"due_reminders": {check: isBool},
+		"digest_hour":   {check: intBetween(0, 23)},
+	}},
+}
+
+// preferenceError names the preference that failed validation
+type preferenceError struct {
+	Key     string
+	Message string
+}
+
+func (e *preferenceError) Error() string {
+	return e.Key + ": " + e.Message
+}
+
+func oneOf(values ...string) func(interface{}) error {
+	return func(value interface{}) error {
+		s, ok := value.(string)
+		if ok {
+			for _, allowed := range values {
+				if s == allowed {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("must be one of %v", values)
+	}
+}
+
+func intBetween(min, max int) func(interface{}) error {
+	return func(value interface{}) error {
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) || n < float64(min) || n > float64(max) {
+			return fmt.Errorf("must be a whole number between %d and %d", min, max)
+		}
+		return nil
+	}
+}
+
+func isBool(value interface{}) error {
+	if _, ok := value.(bool); !ok {
+		return errors.New("must be true or false")
+	}
+	return nil
+}
+
+func validTimezone(value interface{}) error {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return errors.New("must be an IANA time zone name")
+	}
+	if _, err := time.LoadLocation(s); err != nil {
+		return errors.New("must be an IANA time zone name")
+	}
+	return nil
+}
+
+// validatePreferences checks a preferences document against schema. Null
+// values are allowed since they reset a preference to its default.
+func validatePreferences(schema map[string]preferenceRule, doc map[string]interface{}, prefix string) error {
+	for key, value := range doc {
+		path := prefix + key
+		rule, ok := schema[key]
+		if !ok {
+			return &preferenceError{Key: path, Message: "unknown preference"}
+		}
+		if value == nil {
+			continue
+		}
+		if rule.fields != nil {
+			nested, ok := value.(map[string]interface{})
+			if !ok {
+				return &preferenceError{Key: path, Message: "must be an object"}
+			}
+			if err := validatePreferences(rule.fields, nested, path+"."); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := rule.check(value); err != nil {
+			return &preferenceError{Key: path, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
+// mergePreferences deep merges patch into stored. A null removes the stored
+// value so the default applies again; objects left empty are dropped.
+func mergePreferences(stored, patch map[string]interface{}) {
+	for key, value := range patch {
+		if value == nil {
+			delete(stored, key)
+			continue
+		}
+		nestedPatch, patchIsObject := value.(map[string]interface{})
+		nestedStored, storedIsObject := stored[key].(map[string]interface{})
+		if patchIsObject && storedIsObject {
+			mergePreferences(nestedStored, nestedPatch)
+			if len(nestedStored) == 0 {
+				delete(stored, key)
+			}
+			continue
+		}
+		if patchIsObject {
+			nestedStored = map[string]interface{}{}
+			mergePreferences(nestedStored, nestedPatch)
+			if len(nestedStored) > 0 {
+				stored[key] = nestedStored
+			}
+			continue
+		}
+		stored[key] = value
+	}
+}
+
+// effectivePreferences applies stored values over the defaults
+func effectivePreferences(stored []byte) (models.Preferences, error) {
+	preferences := models.DefaultPreferences()
+	if len(stored) > 0 {
+		if err := json.Unmarshal(stored, &preferences); err != nil {
+			return preferences, fmt.Errorf("failed to decode preferences: %w", err)
+		}
+	}
+	return preferences, nil
+}
+
+// loadPreferences returns a user's preferences, falling back to the defaults
+// when nothing is stored
+func loadPreferences(ctx context.Context, user string) (models.Preferences, error) {
+	var stored []byte
+	err := db.Pool.QueryRow(ctx, `
+		SELECT preferences FROM user_preferences WHERE user_key = $1
+	`, user).Scan(&stored)
+	if err != nil && err != pgx.ErrNoRows {
+		return models.Preferences{}, fmt.Errorf("failed to load preferences: %w", err)
+	}
+	return effectivePreferences(stored)
+}
+
+// GetPreferences godoc
+// @Summary      Get my preferences
+// @Description  Get the current user's preferences, with defaults for anything not set
+// @Tags         preferences
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  models.Preferences
+// @Failure      500  {object}  map[string]string
+// @Router       /me/preferences [get]
+func GetPreferences(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	preferences, err := loadPreferences(c.Request.Context(), currentUser(c))
+	if err != nil {
+		log.Printf("Error fetching preferences: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch preferences", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// UpdatePreferences godoc
+// @Summary      Update my preferences
+// @Description  Deep merge the given values into the current user's preferences. A null resets a preference to its default. Unknown keys and invalid values are rejected with the offending key.
+// @Tags         preferences
+// @Accept       json
+// @Produce      json
+// @Param        preferences  body      object  true  "Preferences to change, shaped like models.Preferences"
+// @Success      200          {object}  models.Preferences
+// @Failure      400          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /me/preferences [patch]
+func UpdatePreferences(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var patch map[string]interface{}
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request body must be a JSON object"})
+		return
+	}
+	var prefErr *preferenceError
+	if err := validatePreferences(preferenceSchema, patch, ""); errors.As(err, &prefErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": prefErr.Error(), "key": prefErr.Key})
+		return
+	}
+
+	user := currentUser(c)
+	var stored []byte
+	ctx := c.Request.Context()
+	err := db.WithTx(ctx, func(tx pgx.Tx) error {
+		var current []byte
+		err := tx.QueryRow(ctx, `
+			SELECT preferences FROM user_preferences WHERE user_key = $1 FOR UPDATE
+		`, user).Scan(&current)
+		if err != nil && err != pgx.ErrNoRows {
+			return err
+		}
+		doc := map[string]interface{}{}
+		if len(current) > 0 {
+			if err := json.Unmarshal(current, &doc); err != nil {
+				return fmt.Errorf("failed to decode preferences: %w", err)
+			}
+		}
+		mergePreferences(doc, patch)
+		if stored, err = json.Marshal(doc); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO user_preferences (user_key, preferences, updated_at)
+			VALUES ($1, $2, NOW())
+			ON CONFLICT (user_key) DO UPDATE SET preferences = EXCLUDED.preferences, updated_at = NOW()
+		`, user, stored)
+		return err
+	})
+	if err != nil {
+		log.Printf("Error updating preferences: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update preferences", "details": err.Error()})
+		return
+	}
+
+	preferences, err := effectivePreferences(stored)
+	if err != nil {
+		log.Printf("Error decoding preferences: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update preferences", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/store"
+)
+
+// priorityColumns is the column list selected by every priority query; scanPriority reads it back
+const priorityColumns = `key, label, weight, color, is_default, created_at, updated_at`
+
+// scanPriority scans a row selected with priorityColumns into priority
+func scanPriority(row pgx.Row, priority *models.Priority) error {
+	return row.Scan(&priority.Key, &priority.Label, &priority.Weight, &priority.Color, &priority.IsDefault, &priority.CreatedAt, &priority.UpdatedAt)
+}
+
+// todoPriorityKeySQL is a todo's effective priority key: its own, or the
+// default priority when it has none or its priority was deleted
+const todoPriorityKeySQL = store.PriorityKeySQL
+
+// todoPriorityWeightSQL is the weight of a todo's effective priority
+const todoPriorityWeightSQL = store.PriorityWeightSQL
+
+// priorityWeightStep is the gap between weights assigned by reordering
+const priorityWeightStep = 100
+
+// errDefaultPriority is returned when deleting the default priority
+var errDefaultPriority = errors.New("cannot delete the default priority")
+
+// priorityCache holds the priorities from most to least urgent
+var priorityCache = &configCache[models.Priority]{load: func(ctx context.Context) ([]models.Priority, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT `+priorityColumns+` FROM priorities ORDER BY weight DESC, key`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load priorities: %w", err)
+	}
+	priorities, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Priority, error) {
+		var priority models.Priority
+		err := scanPriority(row, &priority)
+		return priority, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load priorities: %w", err)
+	}
+	return priorities, nil
+}}
+
+// resolvePriority maps the priority fields of a todo request to a priority
+// key. The deprecated label field is only used when key is empty. Either is
+// matched against keys and labels after normalization and then
+// priorityAliases. It returns "" when neither is set.
+func resolvePriority(ctx context.Context, key, label string) (string, error) {
+	value := key
+	if value == "" {
+		value = label
+	}
+	if value == "" {
+		return "", nil
+	}
+	priorities, err := priorityCache.get(ctx)
+	if err != nil {
+		return "", err
+	}
+	normalized := normalizeEnumValue(value)
+	for _, priority := range priorities {
+		if priority.Key == normalized || normalizeEnumValue(priority.Label) == normalized {
+			return priority.Key, nil
+		}
+	}
+	if alias, ok := priorityAliases[normalized]; ok {
+		for _, priority := range priorities {
+			if priority.Key == alias {
+				return priority.Key, nil
+			}
+		}
+	}
+
+	keys := make([]string, len(priorities))
+	for i, priority := range priorities {
+		keys[i] = priority.Key
+	}
+	return "", &priorityError{valid: keys}
+}
+
+// priorityError is returned by resolvePriority for an unknown priority
+type priorityError struct {
+	valid []string
+}
+
+func (e *priorityError) Error() string {
+	return "Invalid priority. Must be one of: " + strings.Join(e.valid, ", ")
+}
+
+// respondPriorityError writes a 400 if err is a *priorityError and reports
+// whether it did
+func respondPriorityError(c *gin.Context, err error) bool {
+	var priorityErr *priorityError
+	if !errors.As(err, &priorityErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": priorityErr.Error()})
+	return true
+}
+
+// GetPriorities godoc
+// @Summary      List priorities
+// @Description  Get the configured priorities from most to least urgent
+// @Tags         priorities
+// @Accept       json
+// @Produce      json
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.Priority  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /priorities [get]
+func GetPriorities(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	priorities, err := priorityCache.get(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching priorities: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch priorities", "details": err.Error()})
+		return
+	}
+
+	respondList(c, envelope, priorities, int64(len(priorities)), 0, 0)
+}
+
+// CreatePriority godoc
+// @Summary      Create a priority
+// @Description  Add a priority. Without a weight it becomes the most urgent. With is_default it replaces the current default.
+// @Tags         priorities
+// @Accept       json
+// @Produce      json
+// @Param        priority  body      models.CreatePriorityRequest  true  "Priority data"
+// @Success      201       {object}  models.Priority
+// @Header       201       {string}  Location  "URL of the created resource"
+// @Header       201       {string}  ETag      "Strong ETag of the created resource"
+// @Failure      400       {object}  map[string]string
+// @Failure      409       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /priorities [post]
+func CreatePriority(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.CreatePriorityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !statusKeyPattern.MatchString(req.Key) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid key. Use up to 20 lowercase letters, digits and underscores"})
+		return
+	}
+	color := req.Color
+	if color == "" {
+		color = defaultColor
+	}
+	if !hexColorPattern.MatchString(color) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid color. Use #RRGGBB"})
+		return
+	}
+
+	var priority models.Priority
+	ctx := c.Request.Context()
+	err := db.WithTx(ctx, func(tx pgx.Tx) error {
+		weight := 0
+		if req.Weight != nil {
+			weight = *req.Weight
+		} else if err := tx.QueryRow(ctx, `
+			SELECT COALESCE(MAX(weight), 0) + $1 FROM priorities
+		`, priorityWeightStep).Scan(&weight); err != nil {
+			return err
+		}
+		if req.IsDefault {
+			if _, err := tx.Exec(ctx, `UPDATE priorities SET is_default = FALSE, updated_at = NOW() WHERE is_default`); err != nil {
+				return err
+			}
+		}
+
+		return scanPriority(tx.QueryRow(ctx, `
+			INSERT INTO priorities (key, label, weight, color, is_default, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+			RETURNING `+priorityColumns+`
+		`, req.Key, req.Label, weight, color, req.IsDefault), &priority)
+	})
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A priority with this key already exists"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating priority: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create priority", "details": err.Error()})
+		return
+	}
+	priorityCache.reset()
+
+	respondCreated(c, "/priorities/"+url.PathEscape(priority.Key), priority)
+}
+
+// UpdatePriority godoc
+// @Summary      Update a priority
+// @Description  Update a priority's label or color, or make it the default. Keys cannot be changed; use reorder to change weights.
+// @Tags         priorities
+// @Accept       json
+// @Produce      json
+// @Param        key       path      string  true  "Priority key"
+// @Param        priority  body      models.UpdatePriorityRequest  true  "Priority data"
+// @Success      200       {object}  models.Priority
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /priorities/{key} [put]
+func UpdatePriority(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.UpdatePriorityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Color != "" && !hexColorPattern.MatchString(req.Color) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid color. Use #RRGGBB"})
+		return
+	}
+
+	var priority models.Priority
+	key := c.Param("key")
+	ctx := c.Request.Context()
+	err := db.WithTx(ctx, func(tx pgx.Tx) error {
+		if req.IsDefault {
+			if _, err := tx.Exec(ctx, `
+				UPDATE priorities SET is_default = FALSE, updated_at = NOW() WHERE is_default AND key <> $1
+			`, key); err != nil {
+				return err
+			}
+		}
+		return scanPriority(tx.QueryRow(ctx, `
+			UPDATE priorities
+			SET label = COALESCE(NULLIF($1, ''), label),
+			    color = COALESCE(NULLIF($2, ''), color),
+			    is_default = is_default OR $3,
+			    updated_at = NOW()
+			WHERE key = $4
+			RETURNING `+priorityColumns+`
+		`, req.Label, req.Color, req.IsDefault, key), &priority)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Priority not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating priority: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update priority", "details": err.Error()})
+		return
+	}
+	priorityCache.reset()
+
+	c.JSON(http.StatusOK, priority)
+}
+
+// ReorderPriorities godoc
+// @Summary      Reorder priorities
+// @Description  Set the priority order, most urgent first. keys must list every priority exactly once; weights are reassigned to match.
+// @Tags         priorities
+// @Accept       json
+// @Produce      json
+// @Param        order  body      models.ReorderPrioritiesRequest  true  "Priority keys from most to least urgent"
+// @Success      200    {array}   models.Priority
+// @Failure      400    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /priorities/reorder [post]
+func ReorderPriorities(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.ReorderPrioritiesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var keysMismatch bool
+	ctx := c.Request.Context()
+	err := db.WithTx(ctx, func(tx pgx.Tx) error {
+		var matches bool
+		if err := tx.QueryRow(ctx, `
+			SELECT (SELECT array_agg(key ORDER BY key) FROM priorities) =
+			       (SELECT array_agg(k ORDER BY k) FROM unnest($1::text[]) AS k)
+		`, req.Keys).Scan(&matches); err != nil {
+			return err
+		}
+		if !matches {
+			keysMismatch = true
+			return errors.New("keys do not match the existing priorities")
+		}
+
+		_, err := tx.Exec(ctx, `
+			UPDATE priorities
+			SET weight = (cardinality($1::text[]) - ordered.position + 1) * $2, updated_at = NOW()
+			FROM unnest($1::text[]) WITH ORDINALITY AS ordered(key, position)
+			WHERE priorities.key = ordered.key
+		`, req.Keys, priorityWeightStep)
+		return err
+	})
+	if keysMismatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "keys must list every priority exactly once"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error reordering priorities: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder priorities", "details": err.Error()})
+		return
+	}
+	priorityCache.reset()
+
+	priorities, err := priorityCache.get(ctx)
+	if err != nil {
+		log.Printf("Error fetching priorities: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch priorities", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, priorities)
+}
+
+// DeletePriority godoc
+// @Summary      Delete a priority
+// @Description  Delete a priority. Todos that had it fall back to the default priority. The default priority cannot be deleted.
+// @Tags         priorities
+// @Accept       json
+// @Produce      json
+// @Param        key  path      string  true  "Priority key"
+// @Success      204  "No Content"
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /priorities/{key} [delete]
+func DeletePriority(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	err := db.WithTx(ctx, func(tx pgx.Tx) error {
+		var isDefault bool
+		if err := tx.QueryRow(ctx, `
+			SELECT is_default FROM priorities WHERE key = $1 FOR UPDATE
+		`, c.Param("key")).Scan(&isDefault); err != nil {
+			return err
+		}
+		if isDefault {
+			return errDefaultPriority
+		}
+		// todos.priority_key is ON DELETE SET NULL, so its todos fall back to the default
+		_, err := tx.Exec(ctx, `DELETE FROM priorities WHERE key = $1`, c.Param("key"))
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Priority not found"})
+		return
+	}
+	if errors.Is(err, errDefaultPriority) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The default priority cannot be deleted; make another priority the default first"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting priority: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete priority", "details": err.Error()})
+		return
+	}
+	priorityCache.reset()
+
+	c.Status(http.StatusNoContent)
+}
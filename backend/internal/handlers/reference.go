@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// maxTodoReferences caps the references taken from one description
+const maxTodoReferences = 100
+
+// todoReferencePattern matches a #<id> reference; parseTodoReferences checks
+// what surrounds it
+var todoReferencePattern = regexp.MustCompile(`#(\d{1,18})`)
+
+// inlineCodePattern matches a Markdown code span
+var inlineCodePattern = regexp.MustCompile("`[^`\n]*`")
+
+// isReferenceWordRune reports whether r joins a #<id> to the text around it,
+// as in abc#42, &#42; or #42nd
+func isReferenceWordRune(r rune) bool {
+	return r == '_' || r == '&' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// parseTodoReferences returns the distinct todo IDs referenced as #<id> in a
+// description, in order of appearance, skipping fenced code blocks and code
+// spans. A reference must stand on its own: a # preceded or an ID followed by
+// a letter, digit or underscore is not one.
+func parseTodoReferences(description string) []int64 {
+	var ids []int64
+	seen := map[int64]bool{}
+	var fence string
+	for _, line := range strings.Split(description, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		line = inlineCodePattern.ReplaceAllString(line, " ")
+		for _, match := range todoReferencePattern.FindAllStringSubmatchIndex(line, -1) {
+			if before, _ := utf8.DecodeLastRuneInString(line[:match[0]]); match[0] > 0 && isReferenceWordRune(before) {
+				continue
+			}
+			if after, _ := utf8.DecodeRuneInString(line[match[1]:]); match[1] < len(line) && isReferenceWordRune(after) {
+				continue
+			}
+			id, err := strconv.ParseInt(line[match[2]:match[3]], 10, 64)
+			if err != nil || id <= 0 || seen[id] {
+				continue
+			}
+			seen[id] = true
+			ids = append(ids, id)
+			if len(ids) == maxTodoReferences {
+				return ids
+			}
+		}
+	}
+	return ids
+}
+
+// syncTodoReferences replaces the stored references of a todo with the ones
+// in its description. References to the todo itself are left out.
+func syncTodoReferences(ctx context.Context, tx pgx.Tx, todo models.Todo) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM todo_references WHERE todo_id = $1`, todo.ID); err != nil {
+		return err
+	}
+	ids := parseTodoReferences(todo.Description)
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO todo_references (todo_id, referenced_id)
+		SELECT $1, referenced_id FROM unnest($2::bigint[]) AS referenced_id
+		WHERE referenced_id <> $1
+	`, todo.ID, ids)
+	return err
+}
+
+// getTodoReferences returns the existing todos a description references, in
+// order of appearance
+func getTodoReferences(ctx context.Context, todo models.Todo) ([]models.TodoReference, error) {
+	ids := parseTodoReferences(todo.Description)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := db.Pool.Query(ctx, `
+		SELECT t.id, t.title, t.status
+		FROM unnest($1::bigint[]) WITH ORDINALITY AS r(id, position)
+		JOIN todos t ON t.id = r.id
+		WHERE t.id <> $2
+		ORDER BY r.position
+	`, ids, todo.ID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[models.TodoReference])
+}
+
+// GetTodoBacklinks godoc
+// @Summary      List the todos referencing a todo
+// @Description  List the todos whose description references this one as #<id>, outside code, ordered by ID.
+// @Tags         todos
+// @Produce      json
+// @Param        id        path      int   true   "Todo ID"
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.TodoReference  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/backlinks [get]
+func GetTodoBacklinks(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	var exists bool
+	if err := db.Pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM todos WHERE id = $1)`, id).Scan(&exists); err != nil {
+		log.Printf("Error checking todo existence: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch backlinks", "details": err.Error()})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT t.id, t.title, t.status
+		FROM todo_references r
+		JOIN todos t ON t.id = r.todo_id
+		WHERE r.referenced_id = $1
+		ORDER BY t.id
+	`, id)
+	if err != nil {
+		log.Printf("Error querying backlinks: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch backlinks", "details": err.Error()})
+		return
+	}
+	backlinks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.TodoReference])
+	if err != nil {
+		log.Printf("Error scanning backlinks: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch backlinks", "details": err.Error()})
+		return
+	}
+
+	respondList(c, envelope, backlinks, int64(len(backlinks)), 0, 0)
+}
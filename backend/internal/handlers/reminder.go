@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// maxTodoReminders caps the reminders of one todo
+const maxTodoReminders = 10
+
+// maxReminderOffsetSeconds bounds a reminder offset to a year either way
+const maxReminderOffsetSeconds = 365 * 24 * 60 * 60
+
+// reminderOffsetPattern matches the ISO 8601 durations reminders accept:
+// weeks, days, hours, minutes and seconds, with an optional sign. Years and
+// months are left out because their length varies.
+var reminderOffsetPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// errInvalidReminderOffset is returned for an offset parseReminderOffset
+// does not accept
+var errInvalidReminderOffset = errors.New("Invalid offset. Use an ISO 8601 duration of weeks, days, hours, minutes and seconds within a year, such as -P2D or -PT2H")
+
+// Errors of adding a reminder, told apart for the response
+var (
+	errReminderNoDueDate   = errors.New("Todo has no due date. Set one before adding a reminder")
+	errTooManyReminders    = fmt.Errorf("A todo can have at most %d reminders", maxTodoReminders)
+	errReminderOffsetTaken = errors.New("Todo already has a reminder at this offset")
+)
+
+// parseReminderOffset returns the seconds an ISO 8601 duration such as
+// -P2D or -PT2H adds to a due date
+func parseReminderOffset(s string) (int, error) {
+	s = strings.ToUpper(s)
+	match := reminderOffsetPattern.FindStringSubmatch(s)
+	if match == nil || strings.HasSuffix(s, "T") {
+		return 0, errInvalidReminderOffset
+	}
+	seconds, components := 0, 0
+	for i, unit := range []int{7 * 24 * 60 * 60, 24 * 60 * 60, 60 * 60, 60, 1} {
+		if match[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(match[i+2])
+		if err != nil || n > maxReminderOffsetSeconds/unit {
+			return 0, errInvalidReminderOffset
+		}
+		seconds += n * unit
+		components++
+	}
+	if components == 0 || seconds > maxReminderOffsetSeconds {
+		return 0, errInvalidReminderOffset
+	}
+	if match[1] == "-" {
+		seconds = -seconds
+	}
+	return seconds, nil
+}
+
+// reminderColumns is the column list selected by every reminder query,
+// from todo_reminders r joined with todos t; scanReminder reads it back.
+// fired_at only counts when the reminder fired for the current due date.
+const reminderColumns = `r.id, r.todo_id, r.reminder_offset,
+	t.due_date + make_interval(secs => r.offset_seconds),
+	CASE WHEN r.fired_for = t.due_date THEN r.fired_at END,
+	r.created_at`
+
+// scanReminder scans a row selected with reminderColumns into reminder
+func scanReminder(row pgx.Row, reminder *models.TodoReminder) error {
+	return row.Scan(&reminder.ID, &reminder.TodoID, &reminder.Offset, &reminder.RemindAt, &reminder.FiredAt, &reminder.CreatedAt)
+}
+
+// FireDueReminders fires every reminder whose time has come and that has not
+// fired for its todo's current due date yet, recording it in the todo's
+// activity. Reminders of done todos, and all reminders while the
+// due_reminders preference is off, are marked as fired without being
+// recorded, so they do not all go off later.
+func FireDueReminders(ctx context.Context) error {
+	preferences, err := loadPreferences(ctx, localUser)
+	if err != nil {
+		return fmt.Errorf("failed to load preferences: %w", err)
+	}
+
+	actor := systemActor
+	fired := 0
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			UPDATE todo_reminders r
+			SET fired_for = t.due_date, fired_at = NOW()
+			FROM todos t
+			WHERE t.id = r.todo_id
+			  AND t.due_date + make_interval(secs => r.offset_seconds) <= NOW()
+			  AND r.fired_for IS DISTINCT FROM t.due_date
+			RETURNING r.todo_id, r.reminder_offset, t.status IN (`+doneStatusesSQL+`)
+		`)
+		if err != nil {
+			return err
+		}
+		type firedReminder struct {
+			todoID int64
+			offset string
+			done   bool
+		}
+		reminders, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (firedReminder, error) {
+			var reminder firedReminder
+			err := row.Scan(&reminder.todoID, &reminder.offset, &reminder.done)
+			return reminder, err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, reminder := range reminders {
+			if reminder.done || !preferences.Notifications.DueReminders {
+				continue
+			}
+			if err := recordTodoEvent(ctx, tx, models.TodoEvent{
+				TodoID: reminder.todoID, Type: models.TodoEventReminder, Actor: &actor, NewValue: &reminder.offset,
+			}); err != nil {
+				return err
+			}
+			fired++
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fire reminders: %w", err)
+	}
+	if fired > 0 {
+		log.Printf("Fired %d due date reminders", fired)
+	}
+	return nil
+}
+
+// GetTodoReminders godoc
+// @Summary      List reminders of a todo
+// @Description  Get a todo's reminders, earliest offset first, with the time each goes off for the current due date
+// @Tags         reminders
+// @Produce      json
+// @Param        id        path      int   true   "Todo ID"
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.TodoReminder  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/reminders [get]
+func GetTodoReminders(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	var todoExists bool
+	err = db.Pool.QueryRow(c.Request.Context(), `
+		SELECT EXISTS(SELECT 1 FROM todos WHERE id = $1)
+	`, todoID).Scan(&todoExists)
+	if err != nil {
+		log.Printf("Error checking todo existence: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify todo", "details": err.Error()})
+		return
+	}
+	if !todoExists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+
+	rows, err := db.Pool.Query(c.Request.Context(), `
+		SELECT `+reminderColumns+`
+		FROM todo_reminders r
+		JOIN todos t ON t.id = r.todo_id
+		WHERE r.todo_id = $1
+		ORDER BY r.offset_seconds, r.id
+	`, todoID)
+	if err != nil {
+		log.Printf("Error querying reminders: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminders", "details": err.Error()})
+		return
+	}
+	reminders, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.TodoReminder, error) {
+		var reminder models.TodoReminder
+		err := scanReminder(row, &reminder)
+		return reminder, err
+	})
+	if err != nil {
+		log.Printf("Error scanning reminders: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminders", "details": err.Error()})
+		return
+	}
+
+	respondList(c, envelope, reminders, int64(len(reminders)), 0, 0)
+}
+
+// CreateTodoReminder godoc
+// @Summary      Add a reminder to a todo
+// @Description  Add a reminder that goes off at the todo's due date plus offset, an ISO 8601 duration of weeks, days, hours, minutes and seconds such as -P2D (two days before) or -PT2H, within a year either way. The reminder follows the due date when it moves and goes off once for every due date. A todo without a due date is rejected with 422. Going off is recorded in the todo's activity unless the due_reminders preference is off.
+// @Tags         reminders
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int                               true  "Todo ID"
+// @Param        reminder  body      models.CreateTodoReminderRequest  true  "Reminder offset"
+// @Success      201       {object}  models.TodoReminder
+// @Header       201       {string}  Location  "URL of the created resource"
+// @Header       201       {string}  ETag      "Strong ETag of the created resource"
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      409       {object}  map[string]string
+// @Failure      422       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /todos/{id}/reminders [post]
+func CreateTodoReminder(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	var req models.CreateTodoReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	offsetSeconds, err := parseReminderOffset(req.Offset)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var reminder models.TodoReminder
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var hasDueDate bool
+		var reminders int
+		if err := tx.QueryRow(ctx, `
+			SELECT due_date IS NOT NULL, (SELECT COUNT(*) FROM todo_reminders WHERE todo_id = $1)
+			FROM todos WHERE id = $1 FOR UPDATE
+		`, todoID).Scan(&hasDueDate, &reminders); err != nil {
+			return err
+		}
+		if !hasDueDate {
+			return errReminderNoDueDate
+		}
+		if reminders >= maxTodoReminders {
+			return errTooManyReminders
+		}
+
+		var id int64
+		err := tx.QueryRow(ctx, `
+			INSERT INTO todo_reminders (todo_id, reminder_offset, offset_seconds, created_at)
+			VALUES ($1, $2, $3, NOW())
+			ON CONFLICT (todo_id, offset_seconds) DO NOTHING
+			RETURNING id
+		`, todoID, strings.ToUpper(req.Offset), offsetSeconds).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errReminderOffsetTaken
+		}
+		if err != nil {
+			return err
+		}
+		if err := scanReminder(tx.QueryRow(ctx, `
+			SELECT `+reminderColumns+`
+			FROM todo_reminders r
+			JOIN todos t ON t.id = r.todo_id
+			WHERE r.id = $1
+		`, id), &reminder); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionCreate, models.AuditEntityReminder, reminder.ID, nil, reminder)
+	})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	case errors.Is(err, errReminderNoDueDate):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, errTooManyReminders):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, errReminderOffsetTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("Error creating reminder: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create reminder", "details": err.Error()})
+		return
+	}
+
+	respondCreated(c, fmt.Sprintf("/todos/%d/reminders/%d", reminder.TodoID, reminder.ID), reminder)
+}
+
+// DeleteTodoReminder godoc
+// @Summary      Remove a reminder from a todo
+// @Description  Delete a reminder by its ID
+// @Tags         reminders
+// @Produce      json
+// @Param        id          path      int  true  "Todo ID"
+// @Param        reminderId  path      int  true  "Reminder ID"
+// @Success      204  "No Content"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/reminders/{reminderId} [delete]
+func DeleteTodoReminder(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	reminderID, err := strconv.ParseInt(c.Param("reminderId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var before models.TodoReminder
+		if err := scanReminder(tx.QueryRow(ctx, `
+			SELECT `+reminderColumns+`
+			FROM todo_reminders r
+			JOIN todos t ON t.id = r.todo_id
+			WHERE r.id = $1 AND r.todo_id = $2
+			FOR UPDATE OF r
+		`, reminderID, todoID), &before); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM todo_reminders WHERE id = $1`, reminderID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionDelete, models.AuditEntityReminder, before.ID, before, nil)
+	})
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reminder not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting reminder: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reminder", "details": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
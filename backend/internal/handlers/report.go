@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// todoDeadlineSQL is when a todo becomes overdue: its due time, or the end
+// of its due date for all-day todos
+const todoDeadlineSQL = `(due_date + CASE WHEN all_day THEN INTERVAL '1 day' ELSE INTERVAL '0' END)`
+
+// reportTodo is a todo in a weekly report with the title of its epic
+type reportTodo struct {
+	models.WeeklyReportTodo
+	epicTitle *string
+}
+
+// queryReportTodos returns the todos selected by condition with at as their
+// time in the report, ordered by epic title and then at
+func queryReportTodos(ctx context.Context, at, condition string, args ...interface{}) ([]reportTodo, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, title, status, story_points, epic_id,
+			(SELECT title FROM epics WHERE epics.id = todos.epic_id) AS epic_title,
+			`+at+` AS at
+		FROM todos
+		WHERE `+condition+`
+		ORDER BY epic_title NULLS LAST, epic_id, at, id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var todos []reportTodo
+	for rows.Next() {
+		var todo reportTodo
+		if err := rows.Scan(&todo.ID, &todo.Title, &todo.Status, &todo.StoryPoints, &todo.EpicID, &todo.epicTitle, &todo.At); err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, rows.Err()
+}
+
+// reportSection lists todos with their count and story points
+func reportSection(todos []reportTodo) models.WeeklyReportSection {
+	section := models.WeeklyReportSection{Todos: []models.WeeklyReportTodo{}}
+	for _, todo := range todos {
+		section.Count++
+		if todo.StoryPoints != nil {
+			section.StoryPoints += *todo.StoryPoints
+		}
+		section.Todos = append(section.Todos, todo.WeeklyReportTodo)
+	}
+	return section
+}
+
+// reportCompleted groups completed todos, already ordered by epic, by epic
+func reportCompleted(todos []reportTodo) models.WeeklyReportCompleted {
+	completed := models.WeeklyReportCompleted{ByEpic: []models.WeeklyReportEpic{}}
+	for _, todo := range todos {
+		last := len(completed.ByEpic) - 1
+		if last < 0 || !sameEpic(completed.ByEpic[last].EpicID, todo.EpicID) {
+			completed.ByEpic = append(completed.ByEpic, models.WeeklyReportEpic{EpicID: todo.EpicID, EpicTitle: todo.epicTitle, Todos: []models.WeeklyReportTodo{}})
+			last++
+		}
+		group := &completed.ByEpic[last]
+		group.Count++
+		completed.Count++
+		if todo.StoryPoints != nil {
+			group.StoryPoints += *todo.StoryPoints
+			completed.StoryPoints += *todo.StoryPoints
+		}
+		group.Todos = append(group.Todos, todo.WeeklyReportTodo)
+	}
+	return completed
+}
+
+// sameEpic reports whether two optional epic IDs are equal
+func sameEpic(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// reportWIP returns the open todos in every started status. A todo has
+// started once it has left the first status.
+func reportWIP(ctx context.Context) (models.WeeklyReportWIP, error) {
+	wip := models.WeeklyReportWIP{ByStatus: []models.WeeklyReportStatus{}}
+	rows, err := db.Pool.Query(ctx, `
+		SELECT s.key, s.label, COUNT(t.id), COALESCE(SUM(t.story_points), 0)
+		FROM statuses s
+		LEFT JOIN todos t ON t.status = s.key
+		WHERE NOT s.is_done
+		  AND s.key <> (SELECT key FROM statuses ORDER BY position, key LIMIT 1)
+		GROUP BY s.key, s.label, s.position
+		ORDER BY s.position, s.key
+	`)
+	if err != nil {
+		return wip, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status models.WeeklyReportStatus
+		if err := rows.Scan(&status.Status, &status.Label, &status.Count, &status.StoryPoints); err != nil {
+			return wip, err
+		}
+		wip.Count += status.Count
+		wip.StoryPoints += status.StoryPoints
+		wip.ByStatus = append(wip.ByStatus, status)
+	}
+	return wip, rows.Err()
+}
+
+// GetWeeklyReport godoc
+// @Summary      Get a weekly summary
+// @Description  Summarize an ISO week: todos completed during it grouped by epic, todos created, todos that became overdue (due during the week, or by now, and not completed by their due time; all-day todos at the end of their date), and the work in progress in every started status when the report is made. Sections are always present, zeroed for a week without activity. The week runs Monday to Sunday in the tz parameter, the X-Timezone header or the timezone preference.
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Param        week  query     string  false  "ISO week such as 2025-W12 (default: last week)"
+// @Param        tz    query     string  false  "IANA timezone the week is taken in"
+// @Success      200   {object}  models.WeeklyReport
+// @Failure      400   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /reports/weekly [get]
+func GetWeeklyReport(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	loc, err := requestLocation(c, c.Query("tz"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	week := c.DefaultQuery("week", isoWeekName(time.Now().In(loc).AddDate(0, 0, -7)))
+	start, err := parseISOWeek(week, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	end := start.AddDate(0, 0, 7)
+
+	ctx := c.Request.Context()
+	report := models.WeeklyReport{
+		Week:      week,
+		Timezone:  loc.String(),
+		StartDate: start.Format(models.DateLayout),
+		EndDate:   end.AddDate(0, 0, -1).Format(models.DateLayout),
+	}
+
+	completed, err := queryReportTodos(ctx, `completed_at`, `
+		completed_at >= $1 AND completed_at < $2 AND status IN (`+doneStatusesSQL+`)
+	`, start.UTC(), end.UTC())
+	if err != nil {
+		log.Printf("Error querying completed todos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build weekly report", "details": err.Error()})
+		return
+	}
+	report.Completed = reportCompleted(completed)
+
+	created, err := queryReportTodos(ctx, `created_at`, `created_at >= $1 AND created_at < $2`, start.UTC(), end.UTC())
+	if err != nil {
+		log.Printf("Error querying created todos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build weekly report", "details": err.Error()})
+		return
+	}
+	report.Created = reportSection(created)
+
+	// Deadlines in the future have not passed yet, even in the current week
+	overdue, err := queryReportTodos(ctx, todoDeadlineSQL, `
+		`+todoDeadlineSQL+` >= $1 AND `+todoDeadlineSQL+` < LEAST($2, NOW() AT TIME ZONE 'UTC')
+		AND (completed_at IS NULL OR completed_at > `+todoDeadlineSQL+`)
+	`, start.UTC(), end.UTC())
+	if err != nil {
+		log.Printf("Error querying overdue todos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build weekly report", "details": err.Error()})
+		return
+	}
+	report.BecameOverdue = reportSection(overdue)
+
+	if report.WIP, err = reportWIP(ctx); err != nil {
+		log.Printf("Error querying work in progress: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build weekly report", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/models"
+)
+
+// apiBasePath is the prefix every route is mounted under; it matches
+// router.BasePath
+const apiBasePath = "/api/v1"
+
+// resourceETag returns the strong ETag of a resource's JSON representation
+func resourceETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// respondCreated writes a 201 with resource as the body, a Location header
+// for path (relative to apiBasePath) and the resource's ETag, so a
+// conditional GET right after a create can return 304. Every handler that
+// creates a resource responds through here.
+func respondCreated(c *gin.Context, path string, resource interface{}) {
+	respondCreatedAs(c, path, resource, nil)
+}
+
+// respondCreatedAs is respondCreated for creates whose body adds to the
+// resource. The ETag is taken from representation, which must be what a GET
+// of path returns; nil means the body is the resource.
+func respondCreatedAs(c *gin.Context, path string, body, representation interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("Error encoding response: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode response", "details": err.Error()})
+		return
+	}
+	etagData := data
+	if representation != nil {
+		if etagData, err = json.Marshal(representation); err != nil {
+			log.Printf("Error encoding response: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode response", "details": err.Error()})
+			return
+		}
+	}
+
+	c.Header("Location", apiBasePath+path)
+	c.Header("ETag", resourceETag(etagData))
+	c.Data(http.StatusCreated, "application/json; charset=utf-8", data)
+}
+
+// respondResource writes resource with its ETag, or a 304 when the request's
+// If-None-Match already names that ETag
+func respondResource(c *gin.Context, resource interface{}) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		log.Printf("Error encoding response: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode response", "details": err.Error()})
+		return
+	}
+	etag := resourceETag(data)
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// etagMatches reports whether an If-None-Match header names etag. Weak tags
+// match their strong counterpart, as RFC 9110 requires for If-None-Match.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// errInvalidEnvelope is returned for an envelope parameter that is not a
+// boolean
+var errInvalidEnvelope = errors.New("Invalid envelope. Must be true or false")
+
+// parseEnvelope reads the envelope query parameter of a list endpoint. Read
+// it before doing any work so a bad value fails fast.
+func parseEnvelope(c *gin.Context) (bool, error) {
+	envelope, err := strconv.ParseBool(c.DefaultQuery("envelope", "false"))
+	if err != nil {
+		return false, errInvalidEnvelope
+	}
+	return envelope, nil
+}
+
+// respondList writes the items of a list endpoint: a bare array by default,
+// or a models.ListEnvelope with its paging metadata when envelope is set.
+// total counts every matching item and limit is 0 for lists that were not
+// paged; the next cursor is the offset of the next page. Every list endpoint
+// with paging metadata responds through here or respondCursorList.
+func respondList(c *gin.Context, envelope bool, items interface{}, total int64, limit, offset int) {
+	var next string
+	if limit > 0 && int64(offset+limit) < total {
+		next = strconv.Itoa(offset + limit)
+	}
+	respondCursorList(c, envelope, items, total, limit, offset, next)
+}
+
+// respondCursorList is respondList for lists paged by cursor, where next is
+// the cursor of the next page or "" on the last one
+func respondCursorList(c *gin.Context, envelope bool, items interface{}, total int64, limit, offset int, next string) {
+	if !envelope {
+		c.JSON(http.StatusOK, items)
+		return
+	}
+	// A nil slice is encoded as null; in the envelope data is always an array
+	if value := reflect.ValueOf(items); value.Kind() == reflect.Slice && value.IsNil() {
+		items = []struct{}{}
+	}
+	meta := models.ListMeta{Total: total, Offset: offset}
+	if limit > 0 {
+		meta.Limit = &limit
+	}
+	if next != "" {
+		meta.NextCursor = &next
+	}
+	c.JSON(http.StatusOK, models.ListEnvelope{Data: items, Meta: meta})
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/models"
+)
+
+func TestParseEnvelope(t *testing.T) {
+	tests := []struct {
+		query    string
+		envelope bool
+		err      bool
+	}{
+		{"", false, false},
+		{"envelope=false", false, false},
+		{"envelope=0", false, false},
+		{"envelope=true", true, false},
+		{"envelope=1", true, false},
+		{"envelope=yes", false, true},
+		{"envelope=", false, true},
+	}
+	for _, tt := range tests {
+		envelope, err := parseEnvelope(queryContext(tt.query))
+		if envelope != tt.envelope || (err != nil) != tt.err {
+			t.Errorf("parseEnvelope(%q) = %t, %v, want %t, error %t", tt.query, envelope, err, tt.envelope, tt.err)
+		}
+	}
+}
+
+// respondListBody returns the response respondList writes
+func respondListBody(t *testing.T, envelope bool, items interface{}, total int64, limit, offset int) []byte {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	respondList(c, envelope, items, total, limit, offset)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	return w.Body.Bytes()
+}
+
+// Without envelope the body is exactly what the endpoints wrote before it
+// existed: c.JSON of the items
+func TestRespondListDefaultUnchanged(t *testing.T) {
+	due := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+	lists := []interface{}{
+		[]models.Subtask{{ID: 1, TodoID: 2, Title: "Write <docs> & \"tests\"", CreatedAt: due, UpdatedAt: due}},
+		[]models.Suggestion{{Type: "todo", ID: 1, Title: "Write docs"}, {Type: "epic", ID: 2, Title: "Docs"}},
+		[]models.Subtask(nil),
+		[]models.Subtask{},
+	}
+	for _, items := range lists {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.JSON(http.StatusOK, items)
+		if got := respondListBody(t, false, items, 99, 10, 20); !bytes.Equal(got, w.Body.Bytes()) {
+			t.Errorf("respondList wrote %s, want %s", got, w.Body)
+		}
+	}
+	if got := string(respondListBody(t, false, []models.Suggestion{{Type: "todo", ID: 1, Title: "Write docs"}}, 1, 0, 0)); got != `[{"type":"todo","id":1,"title":"Write docs"}]` {
+		t.Errorf("respondList wrote %s", got)
+	}
+	if got := string(respondListBody(t, false, []models.Subtask(nil), 0, 0, 0)); got != `null` {
+		t.Errorf("respondList wrote %s for no subtasks, want null as before", got)
+	}
+}
+
+func TestRespondListEnvelope(t *testing.T) {
+	items := []models.Suggestion{{Type: "todo", ID: 1, Title: "Write docs"}}
+	data := `[{"type":"todo","id":1,"title":"Write docs"}]`
+	tests := []struct {
+		name          string
+		items         interface{}
+		total         int64
+		limit, offset int
+		want          string
+	}{
+		{"unpaged", items, 1, 0, 0, `{"data":` + data + `,"meta":{"total":1,"offset":0}}`},
+		{"first page", items, 3, 1, 0, `{"data":` + data + `,"meta":{"total":3,"limit":1,"offset":0,"next_cursor":"1"}}`},
+		{"middle page", items, 3, 1, 1, `{"data":` + data + `,"meta":{"total":3,"limit":1,"offset":1,"next_cursor":"2"}}`},
+		{"last page", items, 3, 1, 2, `{"data":` + data + `,"meta":{"total":3,"limit":1,"offset":2}}`},
+		{"past the end", []models.Suggestion{}, 3, 1, 5, `{"data":[],"meta":{"total":3,"limit":1,"offset":5}}`},
+		{"nil slice", []models.Subtask(nil), 0, 0, 0, `{"data":[],"meta":{"total":0,"offset":0}}`},
+	}
+	for _, tt := range tests {
+		if got := string(respondListBody(t, true, tt.items, tt.total, tt.limit, tt.offset)); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	respondCursorList(c, true, items, 10, 1, 0, "eyJpZCI6MX0")
+	if want := `{"data":` + data + `,"meta":{"total":10,"limit":1,"offset":0,"next_cursor":"eyJpZCI6MX0"}}`; w.Body.String() != want {
+		t.Errorf("respondCursorList wrote %s, want %s", w.Body, want)
+	}
+}
+
+// GetIntegrationHealth needs no database, so it shows a list endpoint end to
+// end: the default body is unchanged and envelope wraps the same items
+func TestGetIntegrationHealthEnvelope(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	integrationHealth.Lock()
+	integrationHealth.checkedAt = time.Time{}
+	integrationHealth.Unlock()
+	engine := gin.New()
+	engine.GET("/admin/integrations/health", GetIntegrationHealth)
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/integrations/health"+query, nil))
+		return w
+	}
+
+	const list = `[{"name":"github","status":"not_configured"}]`
+	for query, want := range map[string]string{
+		"":                list,
+		"?envelope=false": list,
+		"?envelope=true":  `{"data":` + list + `,"meta":{"total":1,"offset":0}}`,
+	} {
+		if w := get(query); w.Code != http.StatusOK || w.Body.String() != want {
+			t.Errorf("GET %q: %d %s, want %s", query, w.Code, w.Body, want)
+		}
+	}
+	if w := get("?envelope=maybe"); w.Code != http.StatusBadRequest {
+		t.Errorf("an invalid envelope got %d, want 400", w.Code)
+	}
+}
+
+// A create answers 201 with a Location under the API base path and the ETag
+// a GET of that location returns, so a conditional GET right after it gets
+// a 304
+func TestRespondCreated(t *testing.T) {
+	subtask := models.Subtask{ID: 3, TodoID: 2, Title: "Write docs"}
+	result := models.TodoImportResult{Todo: models.Todo{ID: 2, Title: "Imported"}, Subtasks: 1}
+	tests := []struct {
+		name           string
+		respond        func(c *gin.Context)
+		location       string
+		representation interface{}
+	}{
+		{"resource", func(c *gin.Context) { respondCreated(c, "/todos/2/subtasks/3", subtask) }, "/api/v1/todos/2/subtasks/3", subtask},
+		{"body with the resource", func(c *gin.Context) { respondCreatedAs(c, "/todos/2", result, result.Todo) }, "/api/v1/todos/2", result.Todo},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			tt.respond(c)
+			if w.Code != http.StatusCreated || w.Header().Get("Location") != tt.location {
+				t.Errorf("%d with Location %q, want 201 with %q", w.Code, w.Header().Get("Location"), tt.location)
+			}
+
+			get := httptest.NewRecorder()
+			c, _ = gin.CreateTestContext(get)
+			c.Request = httptest.NewRequest(http.MethodGet, tt.location, nil)
+			c.Request.Header.Set("If-None-Match", w.Header().Get("ETag"))
+			respondResource(c, tt.representation)
+			c.Writer.WriteHeaderNow()
+			if etag := w.Header().Get("ETag"); strings.HasPrefix(etag, "W/") || etag != get.Header().Get("ETag") || get.Code != http.StatusNotModified {
+				t.Errorf("created with ETag %s, GET of it %d with %s, want the same strong ETag and 304", etag, get.Code, get.Header().Get("ETag"))
+			}
+		})
+	}
+}
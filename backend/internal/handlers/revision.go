@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/store"
+)
+
+// maxTodoRevisions is the number of revisions kept per todo; older ones are pruned
+const maxTodoRevisions = 50
+
+// errRevisionNotFound is returned when a requested revision version does not exist
+var errRevisionNotFound = errors.New("revision not found")
+
+// writeTodoRevision stores the state of a todo before an update as the next
+// version and prunes versions beyond maxTodoRevisions
+func writeTodoRevision(ctx context.Context, tx pgx.Tx, before models.Todo) error {
+	snapshot, err := json.Marshal(before)
+	if err != nil {
+		return fmt.Errorf("failed to encode revision snapshot: %w", err)
+	}
+
+	var version int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO todo_revisions (todo_id, version, snapshot, created_at)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, NOW()
+		FROM todo_revisions
+		WHERE todo_id = $1
+		RETURNING version
+	`, before.ID, snapshot).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("failed to write todo revision: %w", err)
+	}
+
+	if version > maxTodoRevisions {
+		_, err = tx.Exec(ctx, `
+			DELETE FROM todo_revisions WHERE todo_id = $1 AND version <= $2
+		`, before.ID, version-maxTodoRevisions)
+		if err != nil {
+			return fmt.Errorf("failed to prune todo revisions: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetTodoRevisions godoc
+// @Summary      List a todo's revisions
+// @Description  Get the stored snapshots of a todo, newest first, each with the field-level changes made by the update that followed it. The total number of revisions is returned in the X-Total-Count header.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int   true   "Todo ID"
+// @Param        limit     query     int   false  "Page size (max 50)"  default(20)
+// @Param        offset    query     int   false  "Number of revisions to skip"  default(0)
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.TodoRevision  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/revisions [get]
+func GetTodoRevisions(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > maxTodoRevisions {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit. Must be between 1 and 50"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return
+	}
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The current row is the "next" state of the newest revision
+	var current models.Todo
+	err = scanTodo(db.Pool.QueryRow(c.Request.Context(), `
+		SELECT `+todoColumns+` FROM todos WHERE id = $1
+	`, todoID), &current)
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching todo: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch todo", "details": err.Error()})
+		return
+	}
+	currentMap, err := store.ToJSONMap(current)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode todo", "details": err.Error()})
+		return
+	}
+
+	var total int64
+	if err := db.Pool.QueryRow(c.Request.Context(), `
+		SELECT COUNT(*) FROM todo_revisions WHERE todo_id = $1
+	`, todoID).Scan(&total); err != nil {
+		log.Printf("Error counting todo revisions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count revisions", "details": err.Error()})
+		return
+	}
+
+	rows, err := db.Pool.Query(c.Request.Context(), `
+		SELECT id, todo_id, version, snapshot, actor, created_at, next_snapshot
+		FROM (
+			SELECT id, todo_id, version, snapshot, actor, created_at,
+			       LEAD(snapshot) OVER (ORDER BY version) AS next_snapshot
+			FROM todo_revisions
+			WHERE todo_id = $1
+		) revisions
+		ORDER BY version DESC
+		LIMIT $2 OFFSET $3
+	`, todoID, limit, offset)
+	if err != nil {
+		log.Printf("Error querying todo revisions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revisions", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	revisions := []models.TodoRevision{}
+	for rows.Next() {
+		var revision models.TodoRevision
+		var nextSnapshot []byte
+		if err := rows.Scan(&revision.ID, &revision.TodoID, &revision.Version, &revision.Snapshot, &revision.Actor, &revision.CreatedAt, &nextSnapshot); err != nil {
+			log.Printf("Error scanning todo revision: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan revision", "details": err.Error()})
+			return
+		}
+
+		var snapshotMap map[string]interface{}
+		if err := json.Unmarshal(revision.Snapshot, &snapshotMap); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode revision", "details": err.Error()})
+			return
+		}
+		nextMap := currentMap
+		if nextSnapshot != nil {
+			nextMap = nil
+			if err := json.Unmarshal(nextSnapshot, &nextMap); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode revision", "details": err.Error()})
+				return
+			}
+		}
+		revision.Changes = store.DiffJSONMaps(snapshotMap, nextMap, "updated_at")
+		revisions = append(revisions, revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating todo revisions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating revisions", "details": err.Error()})
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	respondList(c, envelope, revisions, total, limit, offset)
+}
+
+// RestoreTodoRevision godoc
+// @Summary      Restore a todo to a previous revision
+// @Description  Apply the snapshot of a revision as a new update. The current state is stored as another revision first, so history is never rewritten.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int  true  "Todo ID"
+// @Param        version  path      int  true  "Revision version"
+// @Success      200  {object}  models.Todo
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      422  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/revisions/{version}/restore [post]
+func RestoreTodoRevision(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision version"})
+		return
+	}
+
+	var todo models.Todo
+	var validationErr error
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var snapshotJSON []byte
+		err := tx.QueryRow(ctx, `
+			SELECT snapshot FROM todo_revisions WHERE todo_id = $1 AND version = $2
+		`, todoID, version).Scan(&snapshotJSON)
+		if err == pgx.ErrNoRows {
+			var exists bool
+			if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM todos WHERE id = $1)`, todoID).Scan(&exists); err != nil {
+				return err
+			}
+			if !exists {
+				return pgx.ErrNoRows
+			}
+			return errRevisionNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var snapshot models.Todo
+		if err := json.Unmarshal(snapshotJSON, &snapshot); err != nil {
+			return fmt.Errorf("failed to decode revision snapshot: %w", err)
+		}
+
+		// Old snapshots may predate the current enum rules
+		_, knownStatus, err := lookupStatus(ctx, snapshot.Status)
+		if err != nil {
+			return err
+		}
+		// Snapshots taken before priority keys only carry the label
+		priorityKey, err := resolvePriority(ctx, snapshot.PriorityKey, snapshot.Priority)
+		var priorityErr *priorityError
+		if err != nil && !errors.As(err, &priorityErr) {
+			return err
+		}
+		pointsMessage, err := checkStoryPoints(ctx, snapshot.StoryPoints)
+		if err != nil {
+			return err
+		}
+		if validationErr = validateRestoredTodo(snapshot, knownStatus, priorityErr == nil, pointsMessage == ""); validationErr != nil {
+			return validationErr
+		}
+		var priority interface{}
+		if priorityKey != "" {
+			priority = priorityKey
+		}
+
+		var description interface{}
+		if snapshot.Description != "" {
+			description = snapshot.Description
+		}
+
+		todo, err = applyTodoUpdate(ctx, tx, c, nil, todoID, `
+			title = $2,
+			description = $3,
+			status = $4,
+			due_date = $5,
+			priority_key = $6,
+			story_points = $7,
+			all_day = $8,
+			due_timezone = $9,
+			estimate_minutes = $10,
+			updated_at = NOW()
+		`, snapshot.Title, description, snapshot.Status, snapshot.DueDate, priority, snapshot.StoryPoints, snapshot.AllDay, snapshot.DueTimezone, snapshot.EstimateMinutes)
+		return err
+	})
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	if errors.Is(err, errRevisionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+	if validationErr != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": validationErr.Error()})
+		return
+	}
+	if respondTransitionError(c, err) {
+		return
+	}
+	if respondDoneLockedError(c, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error restoring todo revision: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore revision", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, todo)
+}
+
+// validateRestoredTodo checks a snapshot against the current enum rules.
+// knownStatus, knownPriority and onScale report whether the snapshot's
+// status and priority still exist and its story points are on the scale.
+func validateRestoredTodo(snapshot models.Todo, knownStatus, knownPriority, onScale bool) error {
+	if !knownStatus {
+		return fmt.Errorf("Revision has status %q which is no longer valid", snapshot.Status)
+	}
+	if !knownPriority {
+		return fmt.Errorf("Revision has priority %q which is no longer valid", snapshot.Priority)
+	}
+	if !onScale {
+		return fmt.Errorf("Revision has story points %d which are no longer valid", *snapshot.StoryPoints)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// searchPerType caps the results of each type GET /search returns
+const searchPerType = 5
+
+// searchSubtitleSQL is the subtitle of a search result r: the epic of a
+// todo, the number of todos in an epic or the dates of a sprint
+const searchSubtitleSQL = `CASE r.type
+	WHEN 'todo' THEN (SELECT e.title FROM todos t JOIN epics e ON e.id = t.epic_id WHERE t.id = r.id)
+	WHEN 'epic' THEN (SELECT COUNT(*) || CASE WHEN COUNT(*) = 1 THEN ' todo' ELSE ' todos' END FROM todos WHERE epic_id = r.id)
+	WHEN 'sprint' THEN (SELECT to_char(start_date, 'YYYY-MM-DD') || ' to ' || to_char(end_date, 'YYYY-MM-DD') FROM sprints WHERE id = r.id)
+END`
+
+// searchSQL gathers prefix and trigram matches on todo and epic titles and
+// sprint names, each branch served by an index and capped like suggestSQL,
+// keeps the best $5 of each type and ranks them: prefix matches first, then
+// todos the caller viewed most recently, then closer matches. $1 is the
+// query, $2 the escaped lowercase prefix pattern, $3 the cap per branch and
+// $4 the caller.
+const searchSQL = `
+	WITH candidates AS (
+		(SELECT 'todo' AS type, id, title FROM todos WHERE LOWER(title) LIKE $2 ORDER BY LOWER(title) LIMIT $3)
+		UNION ALL
+		(SELECT 'todo', id, title FROM todos WHERE $1 <% title ORDER BY word_similarity($1, title) DESC LIMIT $3)
+		UNION ALL
+		(SELECT 'epic', id, title FROM epics WHERE LOWER(title) LIKE $2 ORDER BY LOWER(title) LIMIT $3)
+		UNION ALL
+		(SELECT 'epic', id, title FROM epics WHERE $1 <% title ORDER BY word_similarity($1, title) DESC LIMIT $3)
+		UNION ALL
+		(SELECT 'sprint', id, name FROM sprints WHERE LOWER(name) LIKE $2 ORDER BY LOWER(name) LIMIT $3)
+		UNION ALL
+		(SELECT 'sprint', id, name FROM sprints WHERE $1 <% name ORDER BY word_similarity($1, name) DESC LIMIT $3)
+	),
+	ranked AS (
+		SELECT c.type, c.id, c.title,
+		       LOWER(c.title) LIKE $2 AS prefix,
+		       v.viewed_at,
+		       word_similarity($1, c.title) AS similarity
+		FROM (SELECT DISTINCT type, id, title FROM candidates) c
+		LEFT JOIN todo_views v ON c.type = 'todo' AND v.todo_id = c.id AND v.user_key = $4
+	),
+	r AS (
+		SELECT *, ROW_NUMBER() OVER (
+			PARTITION BY type
+			ORDER BY prefix DESC, viewed_at DESC NULLS LAST, similarity DESC, LOWER(title), id
+		) AS type_rank
+		FROM ranked
+	)
+	SELECT r.type, r.id, r.title, ` + searchSubtitleSQL + `
+	FROM r
+	WHERE r.type_rank <= $5
+	ORDER BY r.prefix DESC, r.viewed_at DESC NULLS LAST, r.similarity DESC, LOWER(r.title), r.type, r.id
+`
+
+// searchRecentSQL selects the todos the caller $1 viewed most recently, at
+// most $2
+const searchRecentSQL = `
+	WITH r AS (
+		SELECT 'todo' AS type, t.id, t.title, v.viewed_at
+		FROM todo_views v
+		JOIN todos t ON t.id = v.todo_id
+		WHERE v.user_key = $1
+		ORDER BY v.viewed_at DESC
+		LIMIT $2
+	)
+	SELECT r.type, r.id, r.title, ` + searchSubtitleSQL + `
+	FROM r
+	ORDER BY r.viewed_at DESC
+`
+
+// Search godoc
+// @Summary      Search todos, epics and sprints
+// @Description  Find todos and epics by title and sprints by name, by prefix and trigram similarity, for a command palette. At most 5 results of each type are returned, grouped by type; prefix matches rank first, then todos the caller viewed recently, then closer matches, and groups are ordered by their best result. An empty q returns the caller's recently viewed todos.
+// @Tags         search
+// @Produce      json
+// @Param        q         query     string  false  "Text typed so far"
+// @Param        envelope  query     bool    false  "Return the groups as {\"data\": [...], \"meta\": {total, offset}} (models.ListEnvelope) instead of {\"groups\": [...]}"  default(false)
+// @Success      200       {object}  models.SearchResults  "The groups, or with envelope=true a models.ListEnvelope holding them as data"
+// @Failure      400       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /search [get]
+func Search(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rows pgx.Rows
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		rows, err = db.Pool.Query(c.Request.Context(), searchRecentSQL, currentUser(c), searchPerType)
+	} else {
+		prefix := likeEscaper.Replace(strings.ToLower(query)) + "%"
+		rows, err = db.Pool.Query(c.Request.Context(), searchSQL, query, prefix, suggestCandidates, currentUser(c), searchPerType)
+	}
+	if err != nil {
+		log.Printf("Error searching: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search", "details": err.Error()})
+		return
+	}
+	found, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.SearchResult, error) {
+		var result models.SearchResult
+		err := row.Scan(&result.Type, &result.ID, &result.Title, &result.Subtitle)
+		return result, err
+	})
+	if err != nil {
+		log.Printf("Error scanning search results: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search", "details": err.Error()})
+		return
+	}
+
+	// Results come ranked overall, so each group takes the place of its best one
+	results := models.SearchResults{Groups: []models.SearchGroup{}}
+	groups := map[string]int{}
+	for _, result := range found {
+		i, ok := groups[result.Type]
+		if !ok {
+			i = len(results.Groups)
+			groups[result.Type] = i
+			results.Groups = append(results.Groups, models.SearchGroup{Type: result.Type})
+		}
+		results.Groups[i].Results = append(results.Groups[i].Results, result)
+	}
+
+	if envelope {
+		respondList(c, envelope, results.Groups, int64(len(results.Groups)), 0, 0)
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
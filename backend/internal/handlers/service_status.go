@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// statusHistorySize is how many evaluations GET /status keeps for its history
+const statusHistorySize = 60
+
+// statusPingTimeout bounds the database ping of a status evaluation
+const statusPingTimeout = 2 * time.Second
+
+// StatusConfig configures ServiceStatus
+type StatusConfig struct {
+	// CacheTTL is how long an evaluation is served before the next one
+	CacheTTL time.Duration
+	// DatabaseSlow degrades the database check when a ping takes longer
+	DatabaseSlow time.Duration
+	// JobLagDegraded and JobLagDown are how long a background job may be
+	// overdue, past its interval since it last ran without error, before the
+	// jobs check is degraded or down
+	JobLagDegraded time.Duration
+	JobLagDown     time.Duration
+}
+
+// StatusConfigFromEnv reads STATUS_CACHE_TTL (default 30s),
+// STATUS_DB_SLOW (default 500ms), STATUS_JOB_LAG_DEGRADED (default 5m) and
+// STATUS_JOB_LAG_DOWN (default 30m), all Go durations
+func StatusConfigFromEnv() (StatusConfig, error) {
+	cfg := StatusConfig{
+		CacheTTL:       30 * time.Second,
+		DatabaseSlow:   500 * time.Millisecond,
+		JobLagDegraded: 5 * time.Minute,
+		JobLagDown:     30 * time.Minute,
+	}
+	for _, setting := range []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"STATUS_CACHE_TTL", &cfg.CacheTTL},
+		{"STATUS_DB_SLOW", &cfg.DatabaseSlow},
+		{"STATUS_JOB_LAG_DEGRADED", &cfg.JobLagDegraded},
+		{"STATUS_JOB_LAG_DOWN", &cfg.JobLagDown},
+	} {
+		value := os.Getenv(setting.name)
+		if value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			return cfg, fmt.Errorf("invalid %s: %q", setting.name, value)
+		}
+		*setting.value = duration
+	}
+	if cfg.JobLagDown <= cfg.JobLagDegraded {
+		return cfg, fmt.Errorf("STATUS_JOB_LAG_DOWN must be longer than STATUS_JOB_LAG_DEGRADED")
+	}
+	return cfg, nil
+}
+
+// statusSeverity orders the service statuses so the worst one wins
+var statusSeverity = map[string]int{
+	models.ServiceStatusOK:       0,
+	models.ServiceStatusDegraded: 1,
+	models.ServiceStatusDown:     2,
+}
+
+// serviceStatus holds the latest status evaluation and the ring buffer of
+// past ones
+type serviceStatus struct {
+	sync.Mutex
+	current models.ServiceStatus
+	history [statusHistorySize]models.ServiceStatusSample
+	next    int
+	samples int
+}
+
+// record adds an evaluation to the history and returns it with the history
+// filled in, oldest first
+func (s *serviceStatus) record(status models.ServiceStatus) models.ServiceStatus {
+	s.history[s.next] = models.ServiceStatusSample{Status: status.Status, CheckedAt: status.CheckedAt}
+	s.next = (s.next + 1) % statusHistorySize
+	s.samples = min(s.samples+1, statusHistorySize)
+
+	ok := 0
+	status.History = make([]models.ServiceStatusSample, 0, s.samples)
+	for i := range s.samples {
+		sample := s.history[(s.next-s.samples+i+statusHistorySize)%statusHistorySize]
+		if sample.Status == models.ServiceStatusOK {
+			ok++
+		}
+		status.History = append(status.History, sample)
+	}
+	status.Uptime = float64(ok) / float64(s.samples)
+	return status
+}
+
+// checkDatabase reports whether the database answers a ping, and in time
+func checkDatabase(ctx context.Context, cfg StatusConfig) models.ServiceCheck {
+	check := models.ServiceCheck{Name: "database", Status: models.ServiceStatusDown, Message: "Database is unreachable"}
+	if db.Pool == nil {
+		return check
+	}
+	ctx, cancel := context.WithTimeout(ctx, statusPingTimeout)
+	defer cancel()
+	start := time.Now()
+	if err := db.Pool.Ping(ctx); err != nil {
+		return check
+	}
+	if time.Since(start) > cfg.DatabaseSlow {
+		check.Status, check.Message = models.ServiceStatusDegraded, "Database is responding slowly"
+		return check
+	}
+	check.Status, check.Message = models.ServiceStatusOK, "Database is reachable"
+	return check
+}
+
+// checkJobs reports how far the most overdue background job is behind, so
+// one stuck job is not hidden by the others
+func checkJobs(cfg StatusConfig, jobLag func() time.Duration) models.ServiceCheck {
+	check := models.ServiceCheck{Name: "background_jobs"}
+	lag := jobLag()
+	switch {
+	case lag > cfg.JobLagDown:
+		check.Status, check.Message = models.ServiceStatusDown, fmt.Sprintf("Background jobs are overdue by %s", lag.Round(time.Minute))
+	case lag > cfg.JobLagDegraded:
+		check.Status, check.Message = models.ServiceStatusDegraded, fmt.Sprintf("Background jobs are delayed by %s", lag.Round(time.Minute))
+	default:
+		check.Status, check.Message = models.ServiceStatusOK, "Background jobs are running"
+	}
+	return check
+}
+
+// ServiceStatus serves the public health of the service for a status page:
+// ok, degraded or down for the database and the background jobs, whose
+// worst lag jobLag returns, and overall. An evaluation is served to
+// every request for cfg.CacheTTL, which is also what clients may cache it
+// for, and the last 60 evaluations are returned as history. The response is
+// 200 whatever the status; messages never carry internal details. It sits
+// outside the API group so neither tokens nor maintenance mode apply.
+func ServiceStatus(cfg StatusConfig, jobLag func() time.Duration) gin.HandlerFunc {
+	var status serviceStatus
+	return func(c *gin.Context) {
+		status.Lock()
+		defer status.Unlock()
+
+		if time.Since(status.current.CheckedAt) >= cfg.CacheTTL {
+			current := models.ServiceStatus{
+				Status: models.ServiceStatusOK,
+				Checks: []models.ServiceCheck{
+					checkDatabase(c.Request.Context(), cfg),
+					checkJobs(cfg, jobLag),
+				},
+				CheckedAt: time.Now().UTC(),
+			}
+			for _, check := range current.Checks {
+				if statusSeverity[check.Status] > statusSeverity[current.Status] {
+					current.Status = check.Status
+				}
+			}
+			status.current = status.record(current)
+		}
+
+		maxAge := max(0, int((cfg.CacheTTL - time.Since(status.current.CheckedAt)).Seconds()))
+		c.Header("Cache-Control", "public, max-age="+strconv.Itoa(maxAge))
+		c.JSON(http.StatusOK, status.current)
+	}
+}
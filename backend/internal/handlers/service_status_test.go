@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"flow-v1/backend/internal/models"
+)
+
+func TestCheckJobs(t *testing.T) {
+	cfg := StatusConfig{JobLagDegraded: 5 * time.Minute, JobLagDown: 30 * time.Minute}
+	tests := []struct {
+		lag    time.Duration
+		status string
+	}{
+		{0, models.ServiceStatusOK},
+		{5 * time.Minute, models.ServiceStatusOK},
+		{6 * time.Minute, models.ServiceStatusDegraded},
+		{30 * time.Minute, models.ServiceStatusDegraded},
+		{2 * time.Hour, models.ServiceStatusDown},
+	}
+	for _, tt := range tests {
+		check := checkJobs(cfg, func() time.Duration { return tt.lag })
+		if check.Status != tt.status || check.Name != "background_jobs" || check.Message == "" {
+			t.Errorf("lag %v: %+v, want %s", tt.lag, check, tt.status)
+		}
+	}
+}
@@ -0,0 +1,669 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/store"
+)
+
+// errInvalidSettings is wrapped by every validation error of a settings import
+var errInvalidSettings = errors.New("invalid settings")
+
+// loadSettingsDocument reads the current configuration as a settings document
+func loadSettingsDocument(ctx context.Context, q store.Querier) (models.SettingsDocument, error) {
+	doc := models.SettingsDocument{Version: models.SettingsFormatVersion}
+
+	rows, err := q.Query(ctx, `SELECT key, label, color, position, is_done FROM statuses ORDER BY position, key`)
+	if err != nil {
+		return doc, err
+	}
+	if doc.Statuses, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.SettingsStatus, error) {
+		var status models.SettingsStatus
+		err := row.Scan(&status.Key, &status.Label, &status.Color, &status.Position, &status.IsDone)
+		return status, err
+	}); err != nil {
+		return doc, err
+	}
+
+	rows, err = q.Query(ctx, `SELECT key, label, weight, color, is_default FROM priorities ORDER BY weight DESC, key`)
+	if err != nil {
+		return doc, err
+	}
+	if doc.Priorities, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.SettingsPriority, error) {
+		var priority models.SettingsPriority
+		err := row.Scan(&priority.Key, &priority.Label, &priority.Weight, &priority.Color, &priority.IsDefault)
+		return priority, err
+	}); err != nil {
+		return doc, err
+	}
+
+	rows, err = q.Query(ctx, `SELECT name, type, options, required FROM custom_field_definitions ORDER BY name`)
+	if err != nil {
+		return doc, err
+	}
+	if doc.CustomFields, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.SettingsCustomField, error) {
+		var field models.SettingsCustomField
+		err := row.Scan(&field.Name, &field.Type, &field.Options, &field.Required)
+		return field, err
+	}); err != nil {
+		return doc, err
+	}
+
+	rows, err = q.Query(ctx, `SELECT from_status, to_status FROM status_transitions ORDER BY from_status, to_status`)
+	if err != nil {
+		return doc, err
+	}
+	if doc.Workflow, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.StatusTransition, error) {
+		var transition models.StatusTransition
+		err := row.Scan(&transition.From, &transition.To)
+		return transition, err
+	}); err != nil {
+		return doc, err
+	}
+
+	if doc.NoteRules, err = loadNoteRules(ctx, q); err != nil {
+		return doc, err
+	}
+
+	if err := q.QueryRow(ctx, `
+		SELECT ARRAY(SELECT unnest(point_values) ORDER BY 1) FROM story_point_scale
+	`).Scan(&doc.StoryPoints); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return doc, err
+	}
+
+	var escalation models.SettingsEscalation
+	err = q.QueryRow(ctx, `
+		SELECT enabled, due_within_hours, target_priority_key FROM escalation_settings
+	`).Scan(&escalation.Enabled, &escalation.DueWithinHours, &escalation.TargetPriorityKey)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return doc, err
+	}
+	if err == nil {
+		doc.Escalation = &escalation
+	}
+	return doc, nil
+}
+
+// validateSettingsDocument checks a document on its own, before it is
+// compared with the current configuration
+func validateSettingsDocument(doc *models.SettingsDocument) error {
+	if doc.Version != models.SettingsFormatVersion {
+		return fmt.Errorf("%w: unsupported version %d, expected %d", errInvalidSettings, doc.Version, models.SettingsFormatVersion)
+	}
+	seen := map[string]bool{}
+	for i := range doc.Statuses {
+		status := &doc.Statuses[i]
+		if !statusKeyPattern.MatchString(status.Key) || seen[status.Key] {
+			return fmt.Errorf("%w: status key %q is invalid or repeated", errInvalidSettings, status.Key)
+		}
+		seen[status.Key] = true
+		if status.Label == "" || !hexColorPattern.MatchString(status.Color) {
+			return fmt.Errorf("%w: status %q needs a label and a #RRGGBB color", errInvalidSettings, status.Key)
+		}
+	}
+	seen = map[string]bool{}
+	defaults := 0
+	for _, priority := range doc.Priorities {
+		if !statusKeyPattern.MatchString(priority.Key) || seen[priority.Key] {
+			return fmt.Errorf("%w: priority key %q is invalid or repeated", errInvalidSettings, priority.Key)
+		}
+		seen[priority.Key] = true
+		if priority.Label == "" || !hexColorPattern.MatchString(priority.Color) {
+			return fmt.Errorf("%w: priority %q needs a label and a #RRGGBB color", errInvalidSettings, priority.Key)
+		}
+		if priority.IsDefault {
+			defaults++
+		}
+	}
+	if defaults > 1 {
+		return fmt.Errorf("%w: only one priority may be the default", errInvalidSettings)
+	}
+	seen = map[string]bool{}
+	for i := range doc.CustomFields {
+		field := &doc.CustomFields[i]
+		if !customFieldNamePattern.MatchString(field.Name) || seen[field.Name] {
+			return fmt.Errorf("%w: custom field name %q is invalid or repeated", errInvalidSettings, field.Name)
+		}
+		seen[field.Name] = true
+		if field.Options == nil {
+			field.Options = []string{}
+		}
+		switch field.Type {
+		case models.CustomFieldText, models.CustomFieldNumber, models.CustomFieldDate:
+			if len(field.Options) > 0 {
+				return fmt.Errorf("%w: custom field %q: only select fields have options", errInvalidSettings, field.Name)
+			}
+		case models.CustomFieldSelect:
+			if len(field.Options) == 0 {
+				return fmt.Errorf("%w: custom field %q: select fields need at least one option", errInvalidSettings, field.Name)
+			}
+		default:
+			return fmt.Errorf("%w: custom field %q has unknown type %q", errInvalidSettings, field.Name, field.Type)
+		}
+	}
+	for _, transition := range doc.Workflow {
+		if transition.From == "" || transition.To == "" || transition.From == transition.To {
+			return fmt.Errorf("%w: workflow transitions need two different statuses", errInvalidSettings)
+		}
+	}
+	for _, rule := range doc.NoteRules {
+		if rule.Field != models.NoteRuleStatus && rule.Field != models.NoteRulePriority {
+			return fmt.Errorf("%w: note rule field %q must be status or priority", errInvalidSettings, rule.Field)
+		}
+		if (rule.From == nil && rule.To == nil) || (rule.From != nil && rule.To != nil && *rule.From == *rule.To) {
+			return fmt.Errorf("%w: note rules need from, to or two different values", errInvalidSettings)
+		}
+	}
+	if doc.Escalation != nil && doc.Escalation.DueWithinHours <= 0 {
+		return fmt.Errorf("%w: escalation due_within_hours must be positive", errInvalidSettings)
+	}
+	if doc.StoryPoints != nil {
+		if len(doc.StoryPoints) == 0 || len(doc.StoryPoints) > 20 || slices.Min(doc.StoryPoints) <= 0 {
+			return fmt.Errorf("%w: story_points must be 1 to 20 positive values", errInvalidSettings)
+		}
+		points, err := normalizeStoryPointScale(doc.StoryPoints)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errInvalidSettings, err)
+		}
+		doc.StoryPoints = points
+	}
+	return nil
+}
+
+// settingsImport compares a document with the current configuration and,
+// when apply is set, writes it inside tx
+type settingsImport struct {
+	ctx       context.Context
+	tx        pgx.Tx
+	current   models.SettingsDocument
+	overwrite bool
+	apply     bool
+	items     []models.SettingsImportItem
+}
+
+// resolve records the outcome of an item whose existing version differs in
+// conflicts, and reports whether it should be written
+func (s *settingsImport) resolve(kind, key string, exists bool, conflicts []string) bool {
+	item := models.SettingsImportItem{Kind: kind, Key: key, Conflicts: conflicts}
+	switch {
+	case !exists:
+		item.Action = models.SettingsActionCreate
+	case len(conflicts) == 0:
+		item.Action = models.SettingsActionUnchanged
+	case s.overwrite:
+		item.Action = models.SettingsActionOverwrite
+	default:
+		item.Action = models.SettingsActionSkip
+	}
+	s.items = append(s.items, item)
+	return item.Action == models.SettingsActionCreate || item.Action == models.SettingsActionOverwrite
+}
+
+// exec runs a write when the import is applied
+func (s *settingsImport) exec(sql string, args ...interface{}) error {
+	if !s.apply {
+		return nil
+	}
+	_, err := s.tx.Exec(s.ctx, sql, args...)
+	return err
+}
+
+// statuses imports statuses. New ones become the last columns, in the order
+// of their positions in the document.
+func (s *settingsImport) statuses(statuses []models.SettingsStatus) error {
+	existing := map[string]models.SettingsStatus{}
+	position := 0
+	for _, status := range s.current.Statuses {
+		existing[status.Key] = status
+		position = max(position, status.Position)
+	}
+	sorted := slices.Clone(statuses)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Position < sorted[j].Position })
+
+	for _, status := range sorted {
+		old, exists := existing[status.Key]
+		var conflicts []string
+		if exists {
+			if old.Label != status.Label {
+				conflicts = append(conflicts, "label")
+			}
+			if old.Color != status.Color {
+				conflicts = append(conflicts, "color")
+			}
+			if old.IsDone != status.IsDone {
+				conflicts = append(conflicts, "is_done")
+			}
+		}
+		if !s.resolve("status", status.Key, exists, conflicts) {
+			continue
+		}
+		if !exists {
+			position++
+			if err := s.exec(`
+				INSERT INTO statuses (key, label, color, position, is_done, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+			`, status.Key, status.Label, status.Color, position, status.IsDone); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.exec(`
+			UPDATE statuses SET label = $2, color = $3, is_done = $4, updated_at = NOW() WHERE key = $1
+		`, status.Key, status.Label, status.Color, status.IsDone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// priorities imports priorities. The default moves to the document's
+// default priority unless another priority is the default and conflicts are
+// skipped, in which case is_default is reported as a conflict.
+func (s *settingsImport) priorities(priorities []models.SettingsPriority) error {
+	existing := map[string]models.SettingsPriority{}
+	currentDefault := ""
+	for _, priority := range s.current.Priorities {
+		existing[priority.Key] = priority
+		if priority.IsDefault {
+			currentDefault = priority.Key
+		}
+	}
+	importedDefault := ""
+	for _, priority := range priorities {
+		if priority.IsDefault {
+			importedDefault = priority.Key
+		}
+	}
+	moveDefault := importedDefault != "" && importedDefault != currentDefault && (s.overwrite || currentDefault == "")
+
+	for _, priority := range priorities {
+		old, exists := existing[priority.Key]
+		var conflicts []string
+		if exists {
+			if old.Label != priority.Label {
+				conflicts = append(conflicts, "label")
+			}
+			if old.Weight != priority.Weight {
+				conflicts = append(conflicts, "weight")
+			}
+			if old.Color != priority.Color {
+				conflicts = append(conflicts, "color")
+			}
+		}
+		makeDefault := priority.Key == importedDefault && moveDefault
+		if priority.Key == importedDefault && importedDefault != currentDefault && !moveDefault {
+			conflicts = append(conflicts, "is_default")
+		}
+		if makeDefault {
+			if err := s.exec(`UPDATE priorities SET is_default = FALSE, updated_at = NOW() WHERE is_default`); err != nil {
+				return err
+			}
+		}
+
+		write := s.resolve("priority", priority.Key, exists, conflicts)
+		switch {
+		case !exists:
+			if err := s.exec(`
+				INSERT INTO priorities (key, label, weight, color, is_default, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+			`, priority.Key, priority.Label, priority.Weight, priority.Color, makeDefault); err != nil {
+				return err
+			}
+		case write:
+			if err := s.exec(`
+				UPDATE priorities SET label = $2, weight = $3, color = $4, is_default = is_default OR $5, updated_at = NOW()
+				WHERE key = $1
+			`, priority.Key, priority.Label, priority.Weight, priority.Color, makeDefault); err != nil {
+				return err
+			}
+		case makeDefault:
+			// Only the default moved; the other fields were equal
+			if err := s.exec(`UPDATE priorities SET is_default = TRUE, updated_at = NOW() WHERE key = $1`, priority.Key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// customFields imports custom field definitions. A field's type cannot
+// change, so a type conflict is always skipped.
+func (s *settingsImport) customFields(fields []models.SettingsCustomField) error {
+	existing := map[string]models.SettingsCustomField{}
+	for _, field := range s.current.CustomFields {
+		existing[field.Name] = field
+	}
+	for _, field := range fields {
+		old, exists := existing[field.Name]
+		var conflicts []string
+		if exists {
+			if old.Type != field.Type {
+				conflicts = append(conflicts, "type")
+			}
+			if !slices.Equal(old.Options, field.Options) {
+				conflicts = append(conflicts, "options")
+			}
+			if old.Required != field.Required {
+				conflicts = append(conflicts, "required")
+			}
+		}
+		if exists && old.Type != field.Type {
+			s.items = append(s.items, models.SettingsImportItem{Kind: "custom_field", Key: field.Name, Action: models.SettingsActionSkip, Conflicts: conflicts})
+			continue
+		}
+		if !s.resolve("custom_field", field.Name, exists, conflicts) {
+			continue
+		}
+		if err := s.exec(`
+			INSERT INTO custom_field_definitions (name, type, options, required, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, NOW(), NOW())
+			ON CONFLICT (name) DO UPDATE SET options = EXCLUDED.options, required = EXCLUDED.required, updated_at = NOW()
+		`, field.Name, field.Type, field.Options, field.Required); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// workflow imports the status transitions as a whole: they either match the
+// existing workflow or replace it
+func (s *settingsImport) workflow(transitions []models.StatusTransition, statuses []models.SettingsStatus) error {
+	known := map[string]bool{}
+	for _, status := range append(slices.Clone(s.current.Statuses), statuses...) {
+		known[status.Key] = true
+	}
+	key := func(t models.StatusTransition) string { return t.From + "\x00" + t.To }
+	wanted := map[string]bool{}
+	for _, transition := range transitions {
+		if !known[transition.From] || !known[transition.To] {
+			return fmt.Errorf("%w: workflow transition %s to %s names an unknown status", errInvalidSettings, transition.From, transition.To)
+		}
+		wanted[key(transition)] = true
+	}
+	have := map[string]bool{}
+	for _, transition := range s.current.Workflow {
+		have[key(transition)] = true
+	}
+
+	var conflicts []string
+	if !maps.Equal(have, wanted) {
+		conflicts = []string{"transitions"}
+	}
+	// An empty workflow allows everything, so filling it in is a create
+	exists := len(s.current.Workflow) > 0 || len(transitions) == 0
+	if !s.resolve("workflow", "", exists, conflicts) {
+		return nil
+	}
+	if err := s.exec(`DELETE FROM status_transitions`); err != nil {
+		return err
+	}
+	for _, transition := range transitions {
+		if err := s.exec(`
+			INSERT INTO status_transitions (from_status, to_status, created_at)
+			VALUES ($1, $2, NOW())
+			ON CONFLICT DO NOTHING
+		`, transition.From, transition.To); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// noteRules imports the note rules as a whole, like the workflow
+func (s *settingsImport) noteRules(rules []models.NoteRule, statuses []models.SettingsStatus, priorities []models.SettingsPriority) error {
+	known := map[string]bool{}
+	for _, status := range append(slices.Clone(s.current.Statuses), statuses...) {
+		known[models.NoteRuleStatus+"\x00"+status.Key] = true
+	}
+	for _, priority := range append(slices.Clone(s.current.Priorities), priorities...) {
+		known[models.NoteRulePriority+"\x00"+priority.Key] = true
+	}
+	key := func(r models.NoteRule) string {
+		side := func(key *string) string {
+			if key == nil {
+				return ""
+			}
+			return *key
+		}
+		return r.Field + "\x00" + side(r.From) + "\x00" + side(r.To)
+	}
+	wanted := map[string]bool{}
+	for _, rule := range rules {
+		for _, side := range []*string{rule.From, rule.To} {
+			if side != nil && !known[rule.Field+"\x00"+*side] {
+				return fmt.Errorf("%w: note rule names unknown %s %q", errInvalidSettings, rule.Field, *side)
+			}
+		}
+		wanted[key(rule)] = true
+	}
+	have := map[string]bool{}
+	for _, rule := range s.current.NoteRules {
+		have[key(rule)] = true
+	}
+
+	var conflicts []string
+	if !maps.Equal(have, wanted) {
+		conflicts = []string{"rules"}
+	}
+	exists := len(s.current.NoteRules) > 0 || len(rules) == 0
+	if !s.resolve("note_rules", "", exists, conflicts) || !s.apply {
+		return nil
+	}
+	return replaceNoteRules(s.ctx, s.tx, rules)
+}
+
+// escalation imports the escalation rule
+func (s *settingsImport) escalation(rule models.SettingsEscalation, priorities []models.SettingsPriority) error {
+	if rule.TargetPriorityKey != nil {
+		known := slices.ContainsFunc(s.current.Priorities, func(p models.SettingsPriority) bool { return p.Key == *rule.TargetPriorityKey }) ||
+			slices.ContainsFunc(priorities, func(p models.SettingsPriority) bool { return p.Key == *rule.TargetPriorityKey })
+		if !known {
+			return fmt.Errorf("%w: escalation target priority %q does not exist", errInvalidSettings, *rule.TargetPriorityKey)
+		}
+	}
+
+	old := s.current.Escalation
+	var conflicts []string
+	if old != nil {
+		if old.Enabled != rule.Enabled {
+			conflicts = append(conflicts, "enabled")
+		}
+		if old.DueWithinHours != rule.DueWithinHours {
+			conflicts = append(conflicts, "due_within_hours")
+		}
+		if (old.TargetPriorityKey == nil) != (rule.TargetPriorityKey == nil) ||
+			(old.TargetPriorityKey != nil && *old.TargetPriorityKey != *rule.TargetPriorityKey) {
+			conflicts = append(conflicts, "target_priority_key")
+		}
+	}
+	if !s.resolve("escalation", "", old != nil, conflicts) {
+		return nil
+	}
+	return s.exec(`
+		INSERT INTO escalation_settings (id, enabled, due_within_hours, target_priority_key, updated_at)
+		VALUES (TRUE, $1, $2, $3, NOW())
+		ON CONFLICT (id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			due_within_hours = EXCLUDED.due_within_hours,
+			target_priority_key = EXCLUDED.target_priority_key,
+			updated_at = NOW()
+	`, rule.Enabled, rule.DueWithinHours, rule.TargetPriorityKey)
+}
+
+// storyPoints imports the story point scale as a whole
+func (s *settingsImport) storyPoints(points []int) error {
+	old := s.current.StoryPoints
+	var conflicts []string
+	if old != nil && !slices.Equal(old, points) {
+		conflicts = []string{"values"}
+	}
+	if !s.resolve("story_points", "", old != nil, conflicts) {
+		return nil
+	}
+	return s.exec(`
+		INSERT INTO story_point_scale (id, point_values, updated_at)
+		VALUES (TRUE, $1, NOW())
+		ON CONFLICT (id) DO UPDATE SET point_values = EXCLUDED.point_values, updated_at = NOW()
+	`, points)
+}
+
+// ExportSettings godoc
+// @Summary      Export settings
+// @Description  Export the statuses, priorities, custom field definitions, workflow, escalation rule, note rules and story point scale as one document for POST /settings/import. No todo data is included. Requires the admin token.
+// @Tags         settings
+// @Accept       json
+// @Produce      json
+// @Security     AdminToken
+// @Success      200  {object}  models.SettingsDocument
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /settings/export [get]
+func ExportSettings(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	doc, err := loadSettingsDocument(c.Request.Context(), db.Pool)
+	if err != nil {
+		log.Printf("Error exporting settings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export settings", "details": err.Error()})
+		return
+	}
+	now := time.Now().UTC()
+	doc.ExportedAt = &now
+
+	c.Header("Content-Disposition", `attachment; filename="settings.json"`)
+	c.JSON(http.StatusOK, doc)
+}
+
+// ImportSettings godoc
+// @Summary      Import settings
+// @Description  Apply a document from GET /settings/export in one transaction. Statuses and priorities are matched by key and custom fields by name; missing ones are created. Items that differ from the existing ones are conflicts: on_conflict=skip (the default) keeps the existing item, overwrite replaces it. A custom field's type is never changed. The workflow, escalation rule, note rules and story point scale are compared as a whole and are left alone when omitted. With dry_run=true nothing is written and the result lists what would happen to each item. Requires the admin token.
+// @Tags         settings
+// @Accept       json
+// @Produce      json
+// @Security     AdminToken
+// @Param        settings     body      models.SettingsDocument  true   "Settings document"
+// @Param        dry_run      query     bool                     false  "Only report what would change"  default(false)
+// @Param        on_conflict  query     string                   false  "Conflict strategy (skip, overwrite)"  default(skip)
+// @Success      200  {object}  models.SettingsImportResult
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /settings/import [post]
+func ImportSettings(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	onConflict := c.DefaultQuery("on_conflict", models.SettingsConflictSkip)
+	if onConflict != models.SettingsConflictSkip && onConflict != models.SettingsConflictOverwrite {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid on_conflict. Must be skip or overwrite"})
+		return
+	}
+	var doc models.SettingsDocument
+	if err := c.ShouldBindJSON(&doc); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateSettingsDocument(&doc); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	result := models.SettingsImportResult{DryRun: dryRun, OnConflict: onConflict}
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		// Imports are serialized so two cannot interleave their comparisons
+		if _, err := tx.Exec(ctx, `LOCK TABLE statuses, priorities, custom_field_definitions, status_transitions, escalation_settings, status_note_rules, priority_note_rules, story_point_scale IN EXCLUSIVE MODE`); err != nil {
+			return err
+		}
+		current, err := loadSettingsDocument(ctx, tx)
+		if err != nil {
+			return err
+		}
+		s := &settingsImport{ctx: ctx, tx: tx, current: current, overwrite: onConflict == models.SettingsConflictOverwrite, apply: !dryRun}
+		if err := s.statuses(doc.Statuses); err != nil {
+			return err
+		}
+		if err := s.priorities(doc.Priorities); err != nil {
+			return err
+		}
+		if err := s.customFields(doc.CustomFields); err != nil {
+			return err
+		}
+		if doc.Workflow != nil {
+			if err := s.workflow(doc.Workflow, doc.Statuses); err != nil {
+				return err
+			}
+		}
+		if doc.Escalation != nil {
+			if err := s.escalation(*doc.Escalation, doc.Priorities); err != nil {
+				return err
+			}
+		}
+		if doc.NoteRules != nil {
+			if err := s.noteRules(doc.NoteRules, doc.Statuses, doc.Priorities); err != nil {
+				return err
+			}
+		}
+		if doc.StoryPoints != nil {
+			if err := s.storyPoints(doc.StoryPoints); err != nil {
+				return err
+			}
+		}
+		result.Items = s.items
+		// Statuses created or overwritten change the board
+		boardChanged := slices.ContainsFunc(s.items, func(item models.SettingsImportItem) bool {
+			return item.Kind == "status" && (item.Action == models.SettingsActionCreate || item.Action == models.SettingsActionOverwrite)
+		})
+		if boardChanged && !dryRun {
+			_, err := bumpBoardVersion(ctx, tx)
+			return err
+		}
+		return nil
+	})
+	if errors.Is(err, errInvalidSettings) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error importing settings: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import settings", "details": err.Error()})
+		return
+	}
+	if !dryRun {
+		statusCache.reset()
+		priorityCache.reset()
+		storyPointScaleCache.reset()
+	}
+	if result.Items == nil {
+		result.Items = []models.SettingsImportItem{}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
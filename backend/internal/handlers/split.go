@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/store"
+)
+
+var (
+	// errSubtasksNotOwned is returned when a split names subtasks that do not
+	// belong to the todo being split
+	errSubtasksNotOwned = errors.New("subtasks do not belong to the todo")
+	// errNoDoneStatus is returned when a todo must be closed but no status counts as done
+	errNoDoneStatus = errors.New("no done status configured")
+)
+
+// loadSubtasks returns the subtasks of a todo in creation order
+func loadSubtasks(ctx context.Context, q store.Querier, todoID int64) ([]models.Subtask, error) {
+	rows, err := q.Query(ctx, `
+		SELECT `+subtaskColumns+` FROM subtasks WHERE todo_id = $1 ORDER BY created_at ASC, id ASC
+	`, todoID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Subtask, error) {
+		var subtask models.Subtask
+		err := scanSubtask(row, &subtask)
+		return subtask, err
+	})
+}
+
+// SplitTodo godoc
+// @Summary      Split a todo
+// @Description  Create new todos from an existing one. Each new todo inherits the original's priority, sprint, epic and custom fields and can take over some of its subtasks. The original is kept, minus the moved subtasks, or is moved to the first done status with close_original.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                      true  "Todo ID"
+// @Param        split  body      models.SplitTodoRequest  true  "New todos"
+// @Success      201    {object}  models.SplitTodoResult
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      409    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /todos/{id}/split [post]
+func SplitTodo(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	var req models.SplitTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A subtask can only move to one of the new todos
+	var subtaskIDs []int64
+	seen := map[int64]bool{}
+	for _, item := range req.Todos {
+		for _, subtaskID := range item.SubtaskIDs {
+			if seen[subtaskID] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Subtask " + strconv.FormatInt(subtaskID, 10) + " is assigned to more than one todo"})
+				return
+			}
+			seen[subtaskID] = true
+			subtaskIDs = append(subtaskIDs, subtaskID)
+		}
+	}
+
+	ctx := c.Request.Context()
+	statuses, err := getStatuses(ctx)
+	if err != nil {
+		log.Printf("Error fetching statuses: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to split todo", "details": err.Error()})
+		return
+	}
+	var status string
+	if len(statuses) > 0 {
+		status = statuses[0].Key
+	}
+
+	var result models.SplitTodoResult
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var original models.Todo
+		if err := scanTodo(tx.QueryRow(ctx, `
+			SELECT `+todoColumns+` FROM todos WHERE id = $1 FOR UPDATE
+		`, id), &original); err != nil {
+			return err
+		}
+
+		if len(subtaskIDs) > 0 {
+			var owned int
+			if err := tx.QueryRow(ctx, `
+				SELECT COUNT(*) FROM subtasks WHERE todo_id = $1 AND id = ANY($2)
+			`, id, subtaskIDs).Scan(&owned); err != nil {
+				return err
+			}
+			if owned != len(subtaskIDs) {
+				return errSubtasksNotOwned
+			}
+		}
+
+		for _, item := range req.Todos {
+			var todo models.Todo
+			err := scanTodo(tx.QueryRow(ctx, `
+				INSERT INTO todos (title, status, priority_key, sprint_id, epic_id, custom_fields, completed_at, created_at, updated_at)
+				SELECT $2, $3, priority_key, sprint_id, epic_id, custom_fields,
+				       CASE WHEN $3 IN (`+doneStatusesSQL+`) THEN NOW() END, NOW(), NOW()
+				FROM todos WHERE id = $1
+				RETURNING `+todoColumns+`
+			`, id, item.Title, status), &todo)
+			if err != nil {
+				return err
+			}
+			if err := recordTodoEvent(ctx, tx, models.TodoEvent{TodoID: todo.ID, Type: models.TodoEventCreated}); err != nil {
+				return err
+			}
+			if err := recordTodoEvent(ctx, tx, models.TodoEvent{TodoID: todo.ID, Type: models.TodoEventSplitFrom, OldValue: formatEventID(&id)}); err != nil {
+				return err
+			}
+			if err := recordTodoEvent(ctx, tx, models.TodoEvent{TodoID: id, Type: models.TodoEventSplitInto, NewValue: formatEventID(&todo.ID)}); err != nil {
+				return err
+			}
+			if err := recordAudit(ctx, tx, c, models.AuditActionCreate, models.AuditEntityTodo, todo.ID, nil, todo); err != nil {
+				return err
+			}
+
+			if err := moveSubtasks(ctx, tx, c, id, todo.ID, item.SubtaskIDs); err != nil {
+				return err
+			}
+			result.Todos = append(result.Todos, todo)
+		}
+
+		if req.CloseOriginal {
+			done, ok, err := firstDoneStatus(ctx)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errNoDoneStatus
+			}
+			if _, err := applyTodoUpdate(ctx, tx, c, nil, id, `status = $2, updated_at = NOW()`, done.Key); err != nil {
+				return err
+			}
+		}
+
+		// Reload so the response reflects the moved subtasks and closing
+		if err := scanTodo(tx.QueryRow(ctx, `SELECT `+todoColumns+` FROM todos WHERE id = $1`, id), &result.Original); err != nil {
+			return err
+		}
+		if result.Original.Subtasks, err = loadSubtasks(ctx, tx, id); err != nil {
+			return err
+		}
+		for i := range result.Todos {
+			if result.Todos[i].Subtasks, err = loadSubtasks(ctx, tx, result.Todos[i].ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	if errors.Is(err, errSubtasksNotOwned) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "All subtask_ids must belong to the todo being split"})
+		return
+	}
+	if errors.Is(err, errNoDoneStatus) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot close the original todo because no status is marked as done"})
+		return
+	}
+	if respondTransitionError(c, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error splitting todo: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to split todo", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// moveSubtasks moves subtaskIDs from one todo to another, recording the move
+// on both timelines
+func moveSubtasks(ctx context.Context, tx pgx.Tx, c *gin.Context, fromID, toID int64, subtaskIDs []int64) error {
+	if len(subtaskIDs) == 0 {
+		return nil
+	}
+	rows, err := tx.Query(ctx, `
+		SELECT `+subtaskColumns+` FROM subtasks WHERE todo_id = $1 AND id = ANY($2) ORDER BY id FOR UPDATE
+	`, fromID, subtaskIDs)
+	if err != nil {
+		return err
+	}
+	befores, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Subtask, error) {
+		var subtask models.Subtask
+		err := scanSubtask(row, &subtask)
+		return subtask, err
+	})
+	if err != nil {
+		return err
+	}
+
+	completed := 0
+	for _, before := range befores {
+		if before.Completed {
+			completed++
+		}
+	}
+	if err := adjustSubtaskCounts(ctx, tx, fromID, -len(befores), -completed); err != nil {
+		return err
+	}
+	if err := adjustSubtaskCounts(ctx, tx, toID, len(befores), completed); err != nil {
+		return err
+	}
+
+	for _, before := range befores {
+		var after models.Subtask
+		if err := scanSubtask(tx.QueryRow(ctx, `
+			UPDATE subtasks SET todo_id = $2, updated_at = NOW() WHERE id = $1
+			RETURNING `+subtaskColumns+`
+		`, before.ID, toID), &after); err != nil {
+			return err
+		}
+		for _, todoID := range []int64{fromID, toID} {
+			if err := recordTodoEvent(ctx, tx, models.TodoEvent{
+				TodoID:       todoID,
+				Type:         models.TodoEventSubtaskMoved,
+				OldValue:     formatEventID(&fromID),
+				NewValue:     formatEventID(&toID),
+				SubtaskID:    &after.ID,
+				SubtaskTitle: &after.Title,
+			}); err != nil {
+				return err
+			}
+		}
+		if err := recordAudit(ctx, tx, c, models.AuditActionUpdate, models.AuditEntitySubtask, after.ID, before, after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,718 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// dateLayout is the format of calendar-day values such as sprint dates
+const dateLayout = "2006-01-02"
+
+// sprintColumns is the column list selected by every sprint query; scanSprint reads it back
+const sprintColumns = `id, name, to_char(start_date, 'YYYY-MM-DD'), to_char(end_date, 'YYYY-MM-DD'), capacity, state, created_at, updated_at`
+
+// scanSprint scans a row selected with sprintColumns into sprint
+func scanSprint(row pgx.Row, sprint *models.Sprint) error {
+	return row.Scan(&sprint.ID, &sprint.Name, &sprint.StartDate, &sprint.EndDate, &sprint.Capacity, &sprint.State, &sprint.CreatedAt, &sprint.UpdatedAt)
+}
+
+// Sprint state errors, reported to the caller as 409 Conflict
+var (
+	errSprintNotPlanned = errors.New("Only planned sprints can be started")
+	errSprintNotActive  = errors.New("Only active sprints can be closed")
+	errSprintClosed     = errors.New("Sprint is closed")
+	errSprintActive     = errors.New("Close the sprint before deleting it")
+)
+
+// validateSprintDates checks that start and end are YYYY-MM-DD dates in order
+func validateSprintDates(start, end string) error {
+	startDate, err := time.Parse(dateLayout, start)
+	if err != nil {
+		return errors.New("Invalid start_date. Use YYYY-MM-DD")
+	}
+	endDate, err := time.Parse(dateLayout, end)
+	if err != nil {
+		return errors.New("Invalid end_date. Use YYYY-MM-DD")
+	}
+	if endDate.Before(startDate) {
+		return errors.New("end_date must not be before start_date")
+	}
+	return nil
+}
+
+// lockSprint selects a sprint FOR UPDATE inside the caller's transaction
+func lockSprint(ctx context.Context, tx pgx.Tx, id int64, sprint *models.Sprint) error {
+	return scanSprint(tx.QueryRow(ctx, `
+		SELECT `+sprintColumns+` FROM sprints WHERE id = $1 FOR UPDATE
+	`, id), sprint)
+}
+
+// respondSprintError writes the response for errors shared by the sprint handlers
+func respondSprintError(c *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sprint not found"})
+	case errors.Is(err, errSprintNotPlanned), errors.Is(err, errSprintNotActive),
+		errors.Is(err, errSprintClosed), errors.Is(err, errSprintActive):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Error %s sprint: %v", action, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + action + " sprint", "details": err.Error()})
+	}
+}
+
+// GetSprints godoc
+// @Summary      List sprints
+// @Description  Get all sprints, newest first, optionally filtered by state
+// @Tags         sprints
+// @Accept       json
+// @Produce      json
+// @Param        state     query     string  false  "Filter by state (planned, active, closed)"
+// @Param        envelope  query     bool    false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200       {array}   models.Sprint  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /sprints [get]
+func GetSprints(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	whereClause := ""
+	queryArgs := []interface{}{}
+	if state := c.Query("state"); state != "" {
+		if state != models.SprintStatePlanned && state != models.SprintStateActive && state != models.SprintStateClosed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid state. Must be one of: planned, active, closed"})
+			return
+		}
+		whereClause = "WHERE state = $1"
+		queryArgs = append(queryArgs, state)
+	}
+
+	rows, err := db.Pool.Query(c.Request.Context(), `
+		SELECT `+sprintColumns+`
+		FROM sprints
+		`+whereClause+`
+		ORDER BY start_date DESC, id DESC
+	`, queryArgs...)
+	if err != nil {
+		log.Printf("Error querying sprints: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sprints", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	sprints := []models.Sprint{}
+	for rows.Next() {
+		var sprint models.Sprint
+		if err := scanSprint(rows, &sprint); err != nil {
+			log.Printf("Error scanning sprint: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan sprint", "details": err.Error()})
+			return
+		}
+		sprints = append(sprints, sprint)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating sprints: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating sprints", "details": err.Error()})
+		return
+	}
+
+	respondList(c, envelope, sprints, int64(len(sprints)), 0, 0)
+}
+
+// GetSprint godoc
+// @Summary      Get a sprint by ID
+// @Description  Get a single sprint by its ID
+// @Tags         sprints
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Sprint ID"
+// @Success      200  {object}  models.Sprint
+// @Header       200  {string}  ETag  "Strong ETag of the resource"
+// @Success      304  "Not Modified"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /sprints/{id} [get]
+func GetSprint(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sprint ID"})
+		return
+	}
+
+	var sprint models.Sprint
+	err = scanSprint(db.Pool.QueryRow(c.Request.Context(), `
+		SELECT `+sprintColumns+` FROM sprints WHERE id = $1
+	`, id), &sprint)
+	if err != nil {
+		respondSprintError(c, err, "fetch")
+		return
+	}
+
+	respondResource(c, sprint)
+}
+
+// CreateSprint godoc
+// @Summary      Create a sprint
+// @Description  Create a new sprint in the planned state
+// @Tags         sprints
+// @Accept       json
+// @Produce      json
+// @Param        sprint  body      models.CreateSprintRequest  true  "Sprint data"
+// @Success      201     {object}  models.Sprint
+// @Header       201     {string}  Location  "URL of the created resource"
+// @Header       201     {string}  ETag      "Strong ETag of the created resource"
+// @Failure      400     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /sprints [post]
+func CreateSprint(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.CreateSprintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateSprintDates(req.StartDate, req.EndDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var sprint models.Sprint
+	ctx := c.Request.Context()
+	err := db.WithTx(ctx, func(tx pgx.Tx) error {
+		err := scanSprint(tx.QueryRow(ctx, `
+			INSERT INTO sprints (name, start_date, end_date, capacity, state, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, 'planned', NOW(), NOW())
+			RETURNING `+sprintColumns+`
+		`, req.Name, req.StartDate, req.EndDate, req.Capacity), &sprint)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionCreate, models.AuditEntitySprint, sprint.ID, nil, sprint)
+	})
+	if err != nil {
+		respondSprintError(c, err, "create")
+		return
+	}
+
+	respondCreated(c, fmt.Sprintf("/sprints/%d", sprint.ID), sprint)
+}
+
+// UpdateSprint godoc
+// @Summary      Update a sprint
+// @Description  Update a sprint's name, dates or capacity. State changes go through the start and close endpoints.
+// @Tags         sprints
+// @Accept       json
+// @Produce      json
+// @Param        id      path      int  true  "Sprint ID"
+// @Param        sprint  body      models.UpdateSprintRequest  true  "Sprint data"
+// @Success      200     {object}  models.Sprint
+// @Failure      400     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /sprints/{id} [put]
+func UpdateSprint(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sprint ID"})
+		return
+	}
+
+	var req models.UpdateSprintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var sprint models.Sprint
+	var validationErr error
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var before models.Sprint
+		if err := lockSprint(ctx, tx, id, &before); err != nil {
+			return err
+		}
+
+		after := before
+		if req.Name != "" {
+			after.Name = req.Name
+		}
+		if req.StartDate != "" {
+			after.StartDate = req.StartDate
+		}
+		if req.EndDate != "" {
+			after.EndDate = req.EndDate
+		}
+		if req.Capacity != nil {
+			after.Capacity = req.Capacity
+		}
+		if validationErr = validateSprintDates(after.StartDate, after.EndDate); validationErr != nil {
+			return validationErr
+		}
+
+		err := scanSprint(tx.QueryRow(ctx, `
+			UPDATE sprints
+			SET name = $1, start_date = $2, end_date = $3, capacity = $4, updated_at = NOW()
+			WHERE id = $5
+			RETURNING `+sprintColumns+`
+		`, after.Name, after.StartDate, after.EndDate, after.Capacity, id), &sprint)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionUpdate, models.AuditEntitySprint, sprint.ID, before, sprint)
+	})
+	if validationErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+		return
+	}
+	if err != nil {
+		respondSprintError(c, err, "update")
+		return
+	}
+
+	c.JSON(http.StatusOK, sprint)
+}
+
+// DeleteSprint godoc
+// @Summary      Delete a sprint
+// @Description  Delete a planned or closed sprint. Todos still in the sprint move to the backlog.
+// @Tags         sprints
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Sprint ID"
+// @Success      204  "No Content"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /sprints/{id} [delete]
+func DeleteSprint(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sprint ID"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var before models.Sprint
+		if err := lockSprint(ctx, tx, id, &before); err != nil {
+			return err
+		}
+		if before.State == models.SprintStateActive {
+			return errSprintActive
+		}
+
+		// Move members out explicitly so each todo's history records it
+		rows, err := tx.Query(ctx, `SELECT id FROM todos WHERE sprint_id = $1 ORDER BY id`, id)
+		if err != nil {
+			return err
+		}
+		todoIDs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+		if err != nil {
+			return err
+		}
+		if _, err := moveTodos(ctx, tx, c, todoIDs, "sprint_id", nil); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM sprints WHERE id = $1`, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionDelete, models.AuditEntitySprint, before.ID, before, nil)
+	})
+	if err != nil {
+		respondSprintError(c, err, "delete")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// StartSprint godoc
+// @Summary      Start a sprint
+// @Description  Move a planned sprint to active. Only one sprint may be active at a time.
+// @Tags         sprints
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Sprint ID"
+// @Success      200  {object}  models.Sprint
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /sprints/{id}/start [post]
+func StartSprint(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sprint ID"})
+		return
+	}
+
+	var sprint models.Sprint
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var before models.Sprint
+		if err := lockSprint(ctx, tx, id, &before); err != nil {
+			return err
+		}
+		if before.State != models.SprintStatePlanned {
+			return errSprintNotPlanned
+		}
+
+		err := scanSprint(tx.QueryRow(ctx, `
+			UPDATE sprints SET state = 'active', updated_at = NOW()
+			WHERE id = $1
+			RETURNING `+sprintColumns+`
+		`, id), &sprint)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionUpdate, models.AuditEntitySprint, sprint.ID, before, sprint)
+	})
+	if isUniqueViolation(err) {
+		var activeID int64
+		if err := db.Pool.QueryRow(ctx, `SELECT id FROM sprints WHERE state = 'active'`).Scan(&activeID); err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another sprint is already active", "active_sprint_id": activeID})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "Another sprint is already active"})
+		return
+	}
+	if err != nil {
+		respondSprintError(c, err, "start")
+		return
+	}
+
+	c.JSON(http.StatusOK, sprint)
+}
+
+// CloseSprint godoc
+// @Summary      Close a sprint
+// @Description  Close the active sprint. Todos that are not in a done status move to next_sprint_id, or to the backlog when it is omitted, and each move is recorded in the todo's activity.
+// @Tags         sprints
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int  true  "Sprint ID"
+// @Param        close  body      models.CloseSprintRequest  false  "Where to move incomplete todos"
+// @Success      200    {object}  models.CloseSprintResult
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      409    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /sprints/{id}/close [post]
+func CloseSprint(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sprint ID"})
+		return
+	}
+
+	var req models.CloseSprintRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.NextSprintID != nil && *req.NextSprintID == id {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "next_sprint_id must be a different sprint"})
+		return
+	}
+
+	result := models.CloseSprintResult{MovedTodoIDs: []int64{}}
+	var nextSprintErr error
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var before models.Sprint
+		if err := lockSprint(ctx, tx, id, &before); err != nil {
+			return err
+		}
+		if before.State != models.SprintStateActive {
+			return errSprintNotActive
+		}
+
+		if req.NextSprintID != nil {
+			var next models.Sprint
+			err := lockSprint(ctx, tx, *req.NextSprintID, &next)
+			if err == pgx.ErrNoRows {
+				nextSprintErr = errors.New("Next sprint not found")
+				return nextSprintErr
+			}
+			if err != nil {
+				return err
+			}
+			if next.State == models.SprintStateClosed {
+				nextSprintErr = errors.New("Next sprint is closed")
+				return nextSprintErr
+			}
+		}
+
+		rows, err := tx.Query(ctx, `
+			SELECT id FROM todos WHERE sprint_id = $1 AND status NOT IN (`+doneStatusesSQL+`) ORDER BY id
+		`, id)
+		if err != nil {
+			return err
+		}
+		todoIDs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+		if err != nil {
+			return err
+		}
+		moved, err := moveTodos(ctx, tx, c, todoIDs, "sprint_id", req.NextSprintID)
+		if err != nil {
+			return err
+		}
+		for _, todo := range moved {
+			result.MovedTodoIDs = append(result.MovedTodoIDs, todo.ID)
+		}
+
+		err = scanSprint(tx.QueryRow(ctx, `
+			UPDATE sprints SET state = 'closed', updated_at = NOW()
+			WHERE id = $1
+			RETURNING `+sprintColumns+`
+		`, id), &result.Sprint)
+		if err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionUpdate, models.AuditEntitySprint, id, before, result.Sprint)
+	})
+	if nextSprintErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": nextSprintErr.Error()})
+		return
+	}
+	if err != nil {
+		respondSprintError(c, err, "close")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// AddSprintTodos godoc
+// @Summary      Add todos to a sprint
+// @Description  Assign todos to a sprint, moving them out of the backlog or another sprint. Todos already in the sprint are left as they are.
+// @Tags         sprints
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int  true  "Sprint ID"
+// @Param        todos  body      models.SprintTodosRequest  true  "Todo IDs"
+// @Success      200    {array}   models.Todo
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      409    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /sprints/{id}/todos [post]
+func AddSprintTodos(c *gin.Context) {
+	updateSprintTodos(c, true)
+}
+
+// RemoveSprintTodos godoc
+// @Summary      Remove todos from a sprint
+// @Description  Move todos from a sprint back to the backlog. Todos that are not in the sprint are ignored.
+// @Tags         sprints
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int  true  "Sprint ID"
+// @Param        todos  body      models.SprintTodosRequest  true  "Todo IDs"
+// @Success      200    {array}   models.Todo
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /sprints/{id}/todos [delete]
+func RemoveSprintTodos(c *gin.Context) {
+	updateSprintTodos(c, false)
+}
+
+// updateSprintTodos implements AddSprintTodos and RemoveSprintTodos and
+// responds with the todos that changed
+func updateSprintTodos(c *gin.Context, add bool) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sprint ID"})
+		return
+	}
+
+	var req models.SprintTodosRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var moved []models.Todo
+	var missing []int64
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var sprint models.Sprint
+		if err := lockSprint(ctx, tx, id, &sprint); err != nil {
+			return err
+		}
+
+		if add && sprint.State == models.SprintStateClosed {
+			return errSprintClosed
+		}
+		var err error
+		moved, missing, err = assignTodos(ctx, tx, c, req.TodoIDs, "sprint_id", id, add)
+		return err
+	})
+	if errors.Is(err, errTodosNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found", "todo_ids": missing})
+		return
+	}
+	if err != nil {
+		respondSprintError(c, err, "update")
+		return
+	}
+
+	c.JSON(http.StatusOK, moved)
+}
+
+// GetSprintBurndown godoc
+// @Summary      Get a sprint's burndown
+// @Description  Get the story points and todos remaining at the end of each day of a sprint, up to today, based on when the sprint's todos were completed. Days end at midnight in the tz parameter, the X-Timezone header or the timezone preference. Todos without story points count towards remaining_todos only.
+// @Tags         sprints
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int     true   "Sprint ID"
+// @Param        tz   query     string  false  "IANA timezone of the days"
+// @Success      200  {object}  models.Burndown
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /sprints/{id}/burndown [get]
+func GetSprintBurndown(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sprint ID"})
+		return
+	}
+
+	loc, err := requestLocation(c, c.Query("tz"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var sprint models.Sprint
+	err = scanSprint(db.Pool.QueryRow(c.Request.Context(), `
+		SELECT `+sprintColumns+` FROM sprints WHERE id = $1
+	`, id), &sprint)
+	if err != nil {
+		respondSprintError(c, err, "fetch")
+		return
+	}
+
+	burndown := models.Burndown{SprintID: sprint.ID, Capacity: sprint.Capacity, Days: []models.BurndownDay{}}
+	if err := db.Pool.QueryRow(c.Request.Context(), `
+		SELECT COALESCE(SUM(story_points), 0) FROM todos WHERE sprint_id = $1
+	`, id).Scan(&burndown.TotalPoints); err != nil {
+		log.Printf("Error summing sprint points: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch burndown", "details": err.Error()})
+		return
+	}
+
+	// A todo is remaining at the end of a day unless it was completed before
+	// the next day began in loc. completed_at is stored in UTC.
+	rows, err := db.Pool.Query(c.Request.Context(), `
+		SELECT to_char(day, 'YYYY-MM-DD'),
+		       COALESCE(SUM(t.story_points) FILTER (WHERE t.completed_at IS NULL OR t.completed_at AT TIME ZONE 'UTC' >= day_end), 0),
+		       COUNT(t.id) FILTER (WHERE t.completed_at IS NULL OR t.completed_at AT TIME ZONE 'UTC' >= day_end)
+		FROM generate_series($2::date, LEAST($3::date, (NOW() AT TIME ZONE $4)::date), INTERVAL '1 day') AS days(day)
+		CROSS JOIN LATERAL (SELECT (day::date + 1)::timestamp AT TIME ZONE $4 AS day_end) AS bounds
+		LEFT JOIN todos t ON t.sprint_id = $1
+		GROUP BY day, day_end
+		ORDER BY day
+	`, id, sprint.StartDate, sprint.EndDate, loc.String())
+	if err != nil {
+		log.Printf("Error querying burndown: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch burndown", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var day models.BurndownDay
+		if err := rows.Scan(&day.Date, &day.RemainingPoints, &day.RemainingTodos); err != nil {
+			log.Printf("Error scanning burndown day: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan burndown", "details": err.Error()})
+			return
+		}
+		burndown.Days = append(burndown.Days, day)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating burndown: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating burndown", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, burndown)
+}
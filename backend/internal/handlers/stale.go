@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// defaultStaleDays is the inactivity threshold used when days is not given
+const defaultStaleDays = 14
+
+// GetStaleTodos godoc
+// @Summary      List stale todos
+// @Description  Get todos that are not done and have had no activity, including on their subtasks, for at least the given number of days. The longest inactive come first. The total number of stale todos is returned in the X-Total-Count header.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        days      query     int   false  "Inactivity threshold in days"  default(14)
+// @Param        limit     query     int   false  "Page size (max 200)"  default(50)
+// @Param        offset    query     int   false  "Number of todos to skip"  default(0)
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.StaleTodo  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/stale [get]
+func GetStaleTodos(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultStaleDays)))
+	if err != nil || days <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days. Must be a positive number"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit. Must be between 1 and 200"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return
+	}
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	staleCondition := `status NOT IN (` + doneStatusesSQL + `) AND last_activity_at < NOW() - make_interval(days => $1)`
+
+	var total int64
+	if err := db.Pool.QueryRow(c.Request.Context(), `
+		SELECT COUNT(*) FROM todos WHERE `+staleCondition,
+		days).Scan(&total); err != nil {
+		log.Printf("Error counting stale todos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count stale todos", "details": err.Error()})
+		return
+	}
+
+	rows, err := db.Pool.Query(c.Request.Context(), `
+		SELECT `+todoColumns+`
+		FROM todos
+		WHERE `+staleCondition+`
+		ORDER BY last_activity_at ASC, id ASC
+		LIMIT $2 OFFSET $3
+	`, days, limit, offset)
+	if err != nil {
+		log.Printf("Error querying stale todos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stale todos", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	todos := []models.StaleTodo{}
+	for rows.Next() {
+		var todo models.StaleTodo
+		if err := scanTodo(rows, &todo.Todo); err != nil {
+			log.Printf("Error scanning stale todo: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan stale todo", "details": err.Error()})
+			return
+		}
+		inactive := now.Sub(todo.LastActivityAt)
+		todo.InactiveSeconds = int64(inactive.Seconds())
+		todo.InactiveDays = int(inactive.Hours() / 24)
+		todos = append(todos, todo)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating stale todos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating stale todos", "details": err.Error()})
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	respondList(c, envelope, todos, total, limit, offset)
+}
@@ -0,0 +1,521 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// statusColumns is the column list selected by every status query; scanStatus reads it back
+const statusColumns = `key, label, color, position, is_done, created_at, updated_at`
+
+// scanStatus scans a row selected with statusColumns into status
+func scanStatus(row pgx.Row, status *models.Status) error {
+	return row.Scan(&status.Key, &status.Label, &status.Color, &status.Position, &status.IsDone, &status.CreatedAt, &status.UpdatedAt)
+}
+
+// doneStatusesSQL selects the keys of statuses that count as completed
+const doneStatusesSQL = `SELECT key FROM statuses WHERE is_done`
+
+// statusKeyPattern matches status keys: lowercase words joined by underscores
+var statusKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,19}$`)
+
+// statusCache holds the statuses in position order
+var statusCache = &configCache[models.Status]{load: func(ctx context.Context) ([]models.Status, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT `+statusColumns+` FROM statuses ORDER BY position, key`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load statuses: %w", err)
+	}
+	statuses, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Status, error) {
+		var status models.Status
+		err := scanStatus(row, &status)
+		return status, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load statuses: %w", err)
+	}
+	return statuses, nil
+}}
+
+// errStatusInUse is returned when deleting a status that todos still use
+// without a migration target
+var errStatusInUse = errors.New("status in use")
+
+// getStatuses returns all statuses in position order
+func getStatuses(ctx context.Context) ([]models.Status, error) {
+	return statusCache.get(ctx)
+}
+
+// lookupStatus returns the status with the given key, if it exists
+func lookupStatus(ctx context.Context, key string) (models.Status, bool, error) {
+	statuses, err := getStatuses(ctx)
+	if err != nil {
+		return models.Status{}, false, err
+	}
+	for _, status := range statuses {
+		if status.Key == key {
+			return status, true, nil
+		}
+	}
+	return models.Status{}, false, nil
+}
+
+// invalidStatusError builds the validation error listing the valid status keys
+func invalidStatusError(ctx context.Context) error {
+	statuses, err := getStatuses(ctx)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, len(statuses))
+	for i, status := range statuses {
+		keys[i] = status.Key
+	}
+	return fmt.Errorf("Invalid status. Must be one of: %s", strings.Join(keys, ", "))
+}
+
+// firstDoneStatus returns the done status with the lowest position, if any
+func firstDoneStatus(ctx context.Context) (models.Status, bool, error) {
+	statuses, err := getStatuses(ctx)
+	if err != nil {
+		return models.Status{}, false, err
+	}
+	for _, status := range statuses {
+		if status.IsDone {
+			return status, true, nil
+		}
+	}
+	return models.Status{}, false, nil
+}
+
+// boardVersionHeader carries the board version on status responses
+const boardVersionHeader = "X-Board-Version"
+
+// lockBoard serializes changes to the board columns. Every transaction that
+// changes statuses takes it first, so their position updates never
+// interleave; reads are not blocked.
+func lockBoard(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, `LOCK TABLE statuses IN EXCLUSIVE MODE`)
+	return err
+}
+
+// bumpBoardVersion counts a change to the board columns and returns the new
+// board version
+func bumpBoardVersion(ctx context.Context, tx pgx.Tx) (int64, error) {
+	var version int64
+	err := tx.QueryRow(ctx, `
+		UPDATE board_version SET version = version + 1, updated_at = NOW() RETURNING version
+	`).Scan(&version)
+	return version, err
+}
+
+// GetStatuses godoc
+// @Summary      List statuses
+// @Description  Get the configured todo statuses in board column order. X-Board-Version goes up with every change to them, so a client holding an older version should refetch its board.
+// @Tags         statuses
+// @Accept       json
+// @Produce      json
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.Status  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Header       200  {integer} X-Board-Version  "Version of the board columns"
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /statuses [get]
+func GetStatuses(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The version is read first, so statuses newer than it only cause a
+	// needless refetch later
+	var version int64
+	if err := db.Pool.QueryRow(c.Request.Context(), `SELECT version FROM board_version`).Scan(&version); err != nil {
+		log.Printf("Error fetching board version: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch statuses", "details": err.Error()})
+		return
+	}
+	statuses, err := getStatuses(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching statuses: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch statuses", "details": err.Error()})
+		return
+	}
+
+	c.Header(boardVersionHeader, strconv.FormatInt(version, 10))
+	respondList(c, envelope, statuses, int64(len(statuses)), 0, 0)
+}
+
+// CreateStatus godoc
+// @Summary      Create a status
+// @Description  Add a todo status. Statuses at or after the given position shift right; without a position it becomes the last column.
+// @Tags         statuses
+// @Accept       json
+// @Produce      json
+// @Param        status  body      models.CreateStatusRequest  true  "Status data"
+// @Success      201     {object}  models.Status
+// @Header       201     {string}  Location  "URL of the created resource"
+// @Header       201     {string}  ETag      "Strong ETag of the created resource"
+// @Header       201     {integer} X-Board-Version  "Version of the board columns after the change"
+// @Failure      400     {object}  map[string]string
+// @Failure      409     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /statuses [post]
+func CreateStatus(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.CreateStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !statusKeyPattern.MatchString(req.Key) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid key. Use up to 20 lowercase letters, digits and underscores"})
+		return
+	}
+	color := req.Color
+	if color == "" {
+		color = defaultColor
+	}
+	if !hexColorPattern.MatchString(color) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid color. Use #RRGGBB"})
+		return
+	}
+
+	var status models.Status
+	var version int64
+	ctx := c.Request.Context()
+	err := db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := lockBoard(ctx, tx); err != nil {
+			return err
+		}
+		var last int
+		if err := tx.QueryRow(ctx, `SELECT COALESCE(MAX(position), 0) FROM statuses`).Scan(&last); err != nil {
+			return err
+		}
+		position := last + 1
+		if req.Position != nil && *req.Position < position {
+			position = *req.Position
+			if _, err := tx.Exec(ctx, `
+				UPDATE statuses SET position = position + 1, updated_at = NOW() WHERE position >= $1
+			`, position); err != nil {
+				return err
+			}
+		}
+
+		if err := scanStatus(tx.QueryRow(ctx, `
+			INSERT INTO statuses (key, label, color, position, is_done, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+			RETURNING `+statusColumns+`
+		`, req.Key, req.Label, color, position, req.IsDone), &status); err != nil {
+			return err
+		}
+		var err error
+		version, err = bumpBoardVersion(ctx, tx)
+		return err
+	})
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A status with this key already exists"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating status: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create status", "details": err.Error()})
+		return
+	}
+	statusCache.reset()
+
+	c.Header(boardVersionHeader, strconv.FormatInt(version, 10))
+	respondCreated(c, "/statuses/"+url.PathEscape(status.Key), status)
+}
+
+// UpdateStatus godoc
+// @Summary      Update a status
+// @Description  Update a status's label, color or done flag. Keys cannot be changed. Changes to statuses are serialized and each one raises the board version.
+// @Tags         statuses
+// @Accept       json
+// @Produce      json
+// @Param        key     path      string  true  "Status key"
+// @Param        status  body      models.UpdateStatusRequest  true  "Status data"
+// @Success      200     {object}  models.Status
+// @Header       200     {integer} X-Board-Version  "Version of the board columns after the change"
+// @Failure      400     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /statuses/{key} [put]
+func UpdateStatus(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.UpdateStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Color != "" && !hexColorPattern.MatchString(req.Color) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid color. Use #RRGGBB"})
+		return
+	}
+
+	var status models.Status
+	var version int64
+	ctx := c.Request.Context()
+	err := db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := lockBoard(ctx, tx); err != nil {
+			return err
+		}
+		if err := scanStatus(tx.QueryRow(ctx, `
+			UPDATE statuses
+			SET label = COALESCE(NULLIF($1, ''), label),
+			    color = COALESCE(NULLIF($2, ''), color),
+			    is_done = COALESCE($3, is_done),
+			    updated_at = NOW()
+			WHERE key = $4
+			RETURNING `+statusColumns+`
+		`, req.Label, req.Color, req.IsDone, c.Param("key")), &status); err != nil {
+			return err
+		}
+		var err error
+		version, err = bumpBoardVersion(ctx, tx)
+		return err
+	})
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Status not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating status: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status", "details": err.Error()})
+		return
+	}
+	statusCache.reset()
+
+	c.Header(boardVersionHeader, strconv.FormatInt(version, 10))
+	c.JSON(http.StatusOK, status)
+}
+
+// ReorderStatuses godoc
+// @Summary      Reorder statuses
+// @Description  Set the board column order in one transaction. keys must list every status exactly once.
+// @Tags         statuses
+// @Accept       json
+// @Produce      json
+// @Param        order  body      models.ReorderStatusesRequest  true  "Status keys in order"
+// @Success      200    {array}   models.Status
+// @Header       200    {integer} X-Board-Version  "Version of the board columns after the change"
+// @Failure      400    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /statuses/reorder [post]
+func ReorderStatuses(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.ReorderStatusesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var keysMismatch bool
+	var version int64
+	ctx := c.Request.Context()
+	err := db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := lockBoard(ctx, tx); err != nil {
+			return err
+		}
+		var matches bool
+		if err := tx.QueryRow(ctx, `
+			SELECT (SELECT array_agg(key ORDER BY key) FROM statuses) =
+			       (SELECT array_agg(k ORDER BY k) FROM unnest($1::text[]) AS k)
+		`, req.Keys).Scan(&matches); err != nil {
+			return err
+		}
+		if !matches {
+			keysMismatch = true
+			return errors.New("keys do not match the existing statuses")
+		}
+
+		if _, err := tx.Exec(ctx, `
+			UPDATE statuses
+			SET position = ordered.position, updated_at = NOW()
+			FROM unnest($1::text[]) WITH ORDINALITY AS ordered(key, position)
+			WHERE statuses.key = ordered.key
+		`, req.Keys); err != nil {
+			return err
+		}
+		var err error
+		version, err = bumpBoardVersion(ctx, tx)
+		return err
+	})
+	if keysMismatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "keys must list every status exactly once"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error reordering statuses: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder statuses", "details": err.Error()})
+		return
+	}
+	statusCache.reset()
+
+	statuses, err := getStatuses(ctx)
+	if err != nil {
+		log.Printf("Error fetching statuses: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch statuses", "details": err.Error()})
+		return
+	}
+
+	c.Header(boardVersionHeader, strconv.FormatInt(version, 10))
+	c.JSON(http.StatusOK, statuses)
+}
+
+// DeleteStatus godoc
+// @Summary      Delete a status
+// @Description  Delete a status. Todos still in it must be moved with migrate_to, otherwise the request is rejected with 409. The last remaining status cannot be deleted.
+// @Tags         statuses
+// @Accept       json
+// @Produce      json
+// @Param        key         path      string  true   "Status key"
+// @Param        migrate_to  query     string  false  "Status to move the todos to"
+// @Success      204  "No Content"
+// @Header       204  {integer} X-Board-Version  "Version of the board columns after the change"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /statuses/{key} [delete]
+func DeleteStatus(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	key := c.Param("key")
+	migrateTo := c.Query("migrate_to")
+	ctx := c.Request.Context()
+
+	if migrateTo != "" {
+		if migrateTo == key {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "migrate_to must be a different status"})
+			return
+		}
+		target, ok, err := resolveStatus(ctx, migrateTo)
+		if err != nil {
+			log.Printf("Error fetching statuses: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete status", "details": err.Error()})
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Status to migrate to not found"})
+			return
+		}
+		migrateTo = target.Key
+	}
+
+	var todoCount int
+	var lastStatus bool
+	var version int64
+	err := db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := lockBoard(ctx, tx); err != nil {
+			return err
+		}
+		var position int
+		if err := tx.QueryRow(ctx, `
+			SELECT position FROM statuses WHERE key = $1 FOR UPDATE
+		`, key).Scan(&position); err != nil {
+			return err
+		}
+
+		var remaining int
+		if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM statuses WHERE key <> $1`, key).Scan(&remaining); err != nil {
+			return err
+		}
+		if remaining == 0 {
+			lastStatus = true
+			return errors.New("cannot delete the last status")
+		}
+
+		rows, err := tx.Query(ctx, `SELECT id FROM todos WHERE status = $1 ORDER BY id`, key)
+		if err != nil {
+			return err
+		}
+		todoIDs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+		if err != nil {
+			return err
+		}
+		if len(todoIDs) > 0 && migrateTo == "" {
+			todoCount = len(todoIDs)
+			return errStatusInUse
+		}
+		// The status is going away, so its outgoing rules must not block the migration
+		if _, err := tx.Exec(ctx, `DELETE FROM status_transitions WHERE from_status = $1`, key); err != nil {
+			return err
+		}
+		for _, todoID := range todoIDs {
+			if _, err := applyTodoUpdate(ctx, tx, c, nil, todoID, `status = $2, updated_at = NOW()`, migrateTo); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM statuses WHERE key = $1`, key); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE statuses SET position = position - 1, updated_at = NOW() WHERE position > $1
+		`, position); err != nil {
+			return err
+		}
+		version, err = bumpBoardVersion(ctx, tx)
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Status not found"})
+		return
+	}
+	if lastStatus {
+		c.JSON(http.StatusConflict, gin.H{"error": "Cannot delete the last status"})
+		return
+	}
+	if errors.Is(err, errStatusInUse) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Status is in use. Pass migrate_to to move its todos", "todo_count": todoCount})
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting status: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete status", "details": err.Error()})
+		return
+	}
+	statusCache.reset()
+
+	c.Header(boardVersionHeader, strconv.FormatInt(version, 10))
+	c.Status(http.StatusNoContent)
+}
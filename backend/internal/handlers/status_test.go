@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/db"
+)
+
+// concurrencyStatus is the status the board tests rename
+const concurrencyStatus = "concurrency_test"
+
+// useBoard adds concurrencyStatus as the last board column for the test and
+// returns an engine serving the status routes
+func useBoard(t *testing.T) *gin.Engine {
+	t.Helper()
+	usePool(t)
+	if _, err := db.Pool.Exec(context.Background(), `
+		INSERT INTO statuses (key, label, color, position, is_done)
+		SELECT $1, 'Concurrency', '#64748b', COALESCE(MAX(position), 0) + 1, FALSE FROM statuses
+	`, concurrencyStatus); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Pool.Exec(context.Background(), `DELETE FROM statuses WHERE key = $1`, concurrencyStatus)
+		statusCache.reset()
+	})
+	statusCache.reset()
+
+	engine := gin.New()
+	engine.PUT("/statuses/:key", UpdateStatus)
+	engine.POST("/statuses/reorder", ReorderStatuses)
+	return engine
+}
+
+// boardChange is a status change sent to the board
+type boardChange struct {
+	path string
+	body string
+	// order is the column order a reorder asks for
+	order   []string
+	code    int
+	version int64
+}
+
+// send sends the change to engine and records the status code and board
+// version of the response
+func (change *boardChange) send(engine *gin.Engine) {
+	method := http.MethodPut
+	if change.path == "/statuses/reorder" {
+		method = http.MethodPost
+	}
+	req := httptest.NewRequest(method, change.path, strings.NewReader(change.body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	change.code = w.Code
+	change.version, _ = strconv.ParseInt(w.Header().Get(boardVersionHeader), 10, 64)
+}
+
+// boardState returns the board version, the status keys in column order and
+// whether two columns share a position
+func boardState(t *testing.T) (version int64, keys []string, duplicates bool) {
+	t.Helper()
+	ctx := context.Background()
+	if err := db.Pool.QueryRow(ctx, `SELECT version FROM board_version`).Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Pool.QueryRow(ctx, `
+		SELECT array_agg(key ORDER BY position, key), COUNT(*) <> COUNT(DISTINCT position) FROM statuses
+	`).Scan(&keys, &duplicates); err != nil {
+		t.Fatal(err)
+	}
+	return version, keys, duplicates
+}
+
+// Renames and reorders sent at once are applied one at a time: each gets its
+// own board version, the last rename's label and the last reorder's order
+// win, and no two columns end up sharing a position
+func TestConcurrentStatusRenames(t *testing.T) {
+	engine := useBoard(t)
+	before, keys, _ := boardState(t)
+
+	var changes []*boardChange
+	for i := range 8 {
+		changes = append(changes, &boardChange{path: "/statuses/" + concurrencyStatus, body: `{"label": "Concurrency ` + strconv.Itoa(i) + `"}`})
+		order := append(slices.Clone(keys[i%len(keys):]), keys[:i%len(keys)]...)
+		encoded, _ := json.Marshal(map[string][]string{"keys": order})
+		changes = append(changes, &boardChange{path: "/statuses/reorder", body: string(encoded), order: order})
+	}
+	var wg sync.WaitGroup
+	for _, change := range changes {
+		wg.Go(func() { change.send(engine) })
+	}
+	wg.Wait()
+
+	var versions []int64
+	var lastRename, lastReorder *boardChange
+	for _, change := range changes {
+		if change.code != http.StatusOK {
+			t.Fatalf("%s %s: status %d", change.path, change.body, change.code)
+		}
+		versions = append(versions, change.version)
+		if change.path == "/statuses/reorder" {
+			if lastReorder == nil || change.version > lastReorder.version {
+				lastReorder = change
+			}
+		} else if lastRename == nil || change.version > lastRename.version {
+			lastRename = change
+		}
+	}
+	slices.Sort(versions)
+	for i, version := range versions {
+		if version != before+int64(i)+1 {
+			t.Fatalf("board versions %v after %d, want one per change with none shared or skipped", versions, before)
+		}
+	}
+
+	after, order, duplicates := boardState(t)
+	if after != before+int64(len(changes)) || duplicates {
+		t.Errorf("board version %d, duplicate positions %t, want version %d and none", after, duplicates, before+int64(len(changes)))
+	}
+	if !slices.Equal(order, lastReorder.order) {
+		t.Errorf("column order %v, want the last reorder's %v", order, lastReorder.order)
+	}
+	var label string
+	if err := db.Pool.QueryRow(context.Background(), `SELECT label FROM statuses WHERE key = $1`, concurrencyStatus).Scan(&label); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(lastRename.body, `"`+label+`"`) {
+		t.Errorf("label %q, want the last rename's %s", label, lastRename.body)
+	}
+}
+
+// A change to the board that dies mid-transaction, with columns sharing a
+// position, leaves nothing behind, and a rename waiting on it goes through
+// once it is gone
+func TestStatusChangeKilledMidTransaction(t *testing.T) {
+	engine := useBoard(t)
+	ctx := context.Background()
+	before, keys, _ := boardState(t)
+
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Release()
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lockBoard(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE statuses SET position = 1`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bumpBoardVersion(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	rename := &boardChange{path: "/statuses/" + concurrencyStatus, body: `{"label": "After the crash"}`}
+	done := make(chan struct{})
+	go func() {
+		rename.send(engine)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatalf("the rename finished with status %d while the board was locked", rename.code)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Closing the connection is what a crash does: the server rolls the
+	// transaction back and releases the lock
+	conn.Conn().Close(ctx)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the rename was still waiting after the transaction died")
+	}
+
+	after, order, duplicates := boardState(t)
+	if rename.code != http.StatusOK || rename.version != before+1 || after != before+1 {
+		t.Errorf("rename %d at version %d, board at %d, want 200 and both at %d", rename.code, rename.version, after, before+1)
+	}
+	if duplicates || !slices.Equal(order, keys) {
+		t.Errorf("columns %v, duplicate positions %t, want %v as before", order, duplicates, keys)
+	}
+}
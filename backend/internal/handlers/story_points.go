@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// storyPointScaleCache holds the allowed story point values in ascending order
+var storyPointScaleCache = &configCache[int]{load: func(ctx context.Context) ([]int, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT unnest(point_values) AS value FROM story_point_scale ORDER BY value`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load story point scale: %w", err)
+	}
+	scale, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("failed to load story point scale: %w", err)
+	}
+	return scale, nil
+}}
+
+// getStoryPointScale returns the allowed story point values in ascending order
+func getStoryPointScale(ctx context.Context) ([]int, error) {
+	return storyPointScaleCache.get(ctx)
+}
+
+// checkStoryPoints returns the message to reject points with when they are
+// set and not on the story point scale, or an empty string
+func checkStoryPoints(ctx context.Context, points *int) (string, error) {
+	if points == nil {
+		return "", nil
+	}
+	scale, err := getStoryPointScale(ctx)
+	if err != nil {
+		return "", err
+	}
+	if slices.Contains(scale, *points) {
+		return "", nil
+	}
+	values := make([]string, len(scale))
+	for i, value := range scale {
+		values[i] = strconv.Itoa(value)
+	}
+	return "Invalid story points value. Must be one of: " + strings.Join(values, ", "), nil
+}
+
+// normalizeStoryPointScale sorts a new scale and rejects repeated values
+func normalizeStoryPointScale(values []int) ([]int, error) {
+	values = slices.Clone(values)
+	slices.Sort(values)
+	for i := 1; i < len(values); i++ {
+		if values[i] == values[i-1] {
+			return nil, fmt.Errorf("Story point value %d is repeated", values[i])
+		}
+	}
+	return values, nil
+}
+
+// storyPointScaleColumns is the column list selected by every story point
+// scale query; scanStoryPointScale reads it back
+const storyPointScaleColumns = `ARRAY(SELECT unnest(point_values) ORDER BY 1), updated_at`
+
+// scanStoryPointScale scans a row selected with storyPointScaleColumns
+func scanStoryPointScale(row pgx.Row) (models.StoryPointScale, error) {
+	var scale models.StoryPointScale
+	err := row.Scan(&scale.Values, &scale.UpdatedAt)
+	return scale, err
+}
+
+// GetStoryPointScale godoc
+// @Summary      Get the story point scale
+// @Description  Get the story point values todos may be given, in ascending order, for rendering a picker
+// @Tags         settings
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  models.StoryPointScale
+// @Failure      500  {object}  map[string]string
+// @Router       /settings/story-points [get]
+func GetStoryPointScale(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	scale, err := scanStoryPointScale(db.Pool.QueryRow(c.Request.Context(), `
+		SELECT `+storyPointScaleColumns+` FROM story_point_scale
+	`))
+	if err != nil {
+		log.Printf("Error fetching story point scale: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch story point scale", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, scale)
+}
+
+// UpdateStoryPointScale godoc
+// @Summary      Replace the story point scale
+// @Description  Replace the story point values todos may be given with 1 to 20 distinct positive values. Todos keep values the new scale leaves out; updating such a todo without changing its story points returns a story_points_off_scale warning.
+// @Tags         settings
+// @Accept       json
+// @Produce      json
+// @Param        scale  body      models.UpdateStoryPointScaleRequest  true  "Story point values"
+// @Success      200    {object}  models.StoryPointScale
+// @Failure      400    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /settings/story-points [put]
+func UpdateStoryPointScale(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.UpdateStoryPointScaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	values, err := normalizeStoryPointScale(req.Values)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scale, err := scanStoryPointScale(db.Pool.QueryRow(c.Request.Context(), `
+		INSERT INTO story_point_scale (id, point_values, updated_at)
+		VALUES (TRUE, $1, NOW())
+		ON CONFLICT (id) DO UPDATE SET point_values = EXCLUDED.point_values, updated_at = NOW()
+		RETURNING `+storyPointScaleColumns+`
+	`, values))
+	if err != nil {
+		log.Printf("Error updating story point scale: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update story point scale", "details": err.Error()})
+		return
+	}
+	storyPointScaleCache.reset()
+
+	c.JSON(http.StatusOK, scale)
+}
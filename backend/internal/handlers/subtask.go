@@ -1,26 +1,101 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
 
 	"flow-v1/backend/internal/db"
 	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/store"
 )
 
+// subtaskColumns is the column list selected by every subtask query; scanSubtask reads it back
+const subtaskColumns = `id, todo_id, title, completed, completed_at, created_at, updated_at`
+
+// scanSubtask scans a row selected with subtaskColumns into subtask
+func scanSubtask(row pgx.Row, subtask *models.Subtask) error {
+	return row.Scan(&subtask.ID, &subtask.TodoID, &subtask.Title, &subtask.Completed, &subtask.CompletedAt, &subtask.CreatedAt, &subtask.UpdatedAt)
+}
+
+// getLastSubtaskCompletedAt returns when a todo's most recently completed
+// subtask was completed, or nil when none is completed
+func getLastSubtaskCompletedAt(ctx context.Context, todoID int64) (*time.Time, error) {
+	var completedAt *time.Time
+	err := db.Pool.QueryRow(ctx, `
+		SELECT MAX(completed_at) FROM subtasks WHERE todo_id = $1
+	`, todoID).Scan(&completedAt)
+	return completedAt, err
+}
+
+// loadMatchedSubtasks sets MatchedSubtasks on each of todos to its subtasks
+// whose title matches the ILIKE pattern, in display order
+func loadMatchedSubtasks(ctx context.Context, todos []models.Todo, pattern string) error {
+	if len(todos) == 0 {
+		return nil
+	}
+	index := make(map[int64]int, len(todos))
+	ids := make([]int64, len(todos))
+	for i, todo := range todos {
+		index[todo.ID] = i
+		ids[i] = todo.ID
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT todo_id, id, title FROM subtasks
+		WHERE todo_id = ANY($1) AND title ILIKE $2
+		ORDER BY created_at ASC, id ASC
+	`, ids, pattern)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var todoID int64
+		var match models.MatchedSubtask
+		if err := rows.Scan(&todoID, &match.ID, &match.Title); err != nil {
+			return err
+		}
+		todo := &todos[index[todoID]]
+		todo.MatchedSubtasks = append(todo.MatchedSubtasks, match)
+	}
+	return rows.Err()
+}
+
+// subtaskSortTerms maps each sort= value of GET /todos/:id/subtasks to its
+// ORDER BY terms. Subtasks have no manual order yet, so position is their
+// creation order; every sort ends with it so ties stay stable.
+var subtaskSortTerms = map[string][]string{
+	"position":         {"created_at ASC", "id ASC"},
+	"created_at":       {"created_at ASC", "id ASC"},
+	"completed_first":  {"completed DESC", "created_at ASC", "id ASC"},
+	"incomplete_first": {"completed ASC", "created_at ASC", "id ASC"},
+}
+
 // GetSubtasks godoc
 // @Summary      List all subtasks for a todo
-// @Description  Get a list of all subtasks belonging to a specific todo
+// @Description  Get a list of all subtasks belonging to a specific todo, optionally filtered by completion. With limit, the total number of matching subtasks is returned in the X-Total-Count header.
 // @Tags         subtasks
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "Todo ID"
-// @Success      200  {array}   models.Subtask
+// @Param        id         path      int     true   "Todo ID"
+// @Param        completed  query     bool    false  "Only completed (true) or open (false) subtasks"
+// @Param        sort       query     string  false  "Sort order"  Enums(position, created_at, completed_first, incomplete_first)  default(position)
+// @Param        limit      query     int     false  "Page size (max 200); without it every matching subtask is returned"
+// @Param        offset     query     int     false  "Number of subtasks to skip; requires limit"  default(0)
+// @Param        envelope   query     bool    false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Param        strict     query     bool    false  "Reject unknown parameters with a 400 instead of dropping them; defaults to STRICT_QUERY_PARAMS or the strict_params flag"
+// @Success      200  {array}   models.Subtask  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Header       200  {string}  X-Ignored-Params  "Comma-separated unknown parameters that were dropped"
 // @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /todos/{id}/subtasks [get]
 func GetSubtasks(c *gin.Context) {
@@ -36,6 +111,54 @@ func GetSubtasks(c *gin.Context) {
 		return
 	}
 
+	params, err := newQueryParams(c, []string{"completed", "sort", "limit", "offset", "envelope"})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if params.respond() {
+		return
+	}
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var query store.QueryBuilder
+	query.Where("todo_id = " + query.Arg(todoID))
+
+	if value := c.Query("completed"); value != "" {
+		completed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid completed. Must be true or false"})
+			return
+		}
+		query.Where("completed = " + query.Arg(completed))
+	}
+
+	// Unknown sorts are rejected rather than falling back to the default
+	sort := c.DefaultQuery("sort", "position")
+	terms, ok := subtaskSortTerms[sort]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort. Must be one of: position, created_at, completed_first, incomplete_first"})
+		return
+	}
+
+	var limit, offset int
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 || limit > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit. Must be between 1 and 200"})
+			return
+		}
+	}
+	if value := c.Query("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 || limit == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset. Must be zero or more and used with limit"})
+			return
+		}
+	}
+
 	// Verify todo exists
 	var todoExists bool
 	err = db.Pool.QueryRow(c.Request.Context(), `
@@ -51,12 +174,22 @@ func GetSubtasks(c *gin.Context) {
 		return
 	}
 
-	rows, err := db.Pool.Query(c.Request.Context(), `
-		SELECT id, todo_id, title, completed, created_at, updated_at 
-		FROM subtasks 
-		WHERE todo_id = $1
-		ORDER BY created_at ASC
-	`, todoID)
+	var total int64
+	if limit > 0 {
+		// Built before the order and page are added, so only the filters apply
+		countSQL, countArgs := query.Build("SELECT COUNT(*) FROM subtasks")
+		if err := db.Pool.QueryRow(c.Request.Context(), countSQL, countArgs...).Scan(&total); err != nil {
+			log.Printf("Error counting subtasks: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count subtasks", "details": err.Error()})
+			return
+		}
+		c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	}
+	query.OrderBy(terms...)
+	query.Page(limit, offset)
+
+	listSQL, listArgs := query.Build("SELECT " + subtaskColumns + " FROM subtasks")
+	rows, err := db.Pool.Query(c.Request.Context(), listSQL, listArgs...)
 	if err != nil {
 		log.Printf("Error querying subtasks: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subtasks", "details": err.Error()})
@@ -67,7 +200,7 @@ func GetSubtasks(c *gin.Context) {
 	var subtasks []models.Subtask
 	for rows.Next() {
 		var subtask models.Subtask
-		if err := rows.Scan(&subtask.ID, &subtask.TodoID, &subtask.Title, &subtask.Completed, &subtask.CreatedAt, &subtask.UpdatedAt); err != nil {
+		if err := scanSubtask(rows, &subtask); err != nil {
 			log.Printf("Error scanning subtask: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan subtask", "details": err.Error()})
 			return
@@ -81,20 +214,27 @@ func GetSubtasks(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, subtasks)
+	if limit == 0 {
+		total = int64(len(subtasks))
+	}
+	respondList(c, envelope, subtasks, total, limit, offset)
 }
 
 // CreateSubtask godoc
 // @Summary      Create a new subtask
-// @Description  Create a new subtask for a specific todo
+// @Description  Create a new subtask for a specific todo. A done todo gets a 409 while the lock_done_todos policy is on, unless reopen=true moves it out of done first.
 // @Tags         subtasks
 // @Accept       json
 // @Produce      json
-// @Param        id    path      int  true  "Todo ID"
+// @Param        id       path      int   true   "Todo ID"
+// @Param        reopen   query     bool  false  "Move a done todo to the first status that is not done first"
 // @Param        subtask  body      models.CreateSubtaskRequest  true  "Subtask data"
 // @Success      201   {object}  models.Subtask
+// @Header       201   {string}  Location  "URL of the created resource"
+// @Header       201   {string}  ETag      "Strong ETag of the created resource"
 // @Failure      400   {object}  map[string]string
 // @Failure      404   {object}  map[string]string
+// @Failure      409   {object}  map[string]interface{}
 // @Failure      500   {object}  map[string]string
 // @Router       /todos/{id}/subtasks [post]
 func CreateSubtask(c *gin.Context) {
@@ -110,6 +250,12 @@ func CreateSubtask(c *gin.Context) {
 		return
 	}
 
+	reopen, err := parseReopen(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Verify todo exists
 	var todoExists bool
 	err = db.Pool.QueryRow(c.Request.Context(), `
@@ -132,35 +278,57 @@ func CreateSubtask(c *gin.Context) {
 	}
 
 	var subtask models.Subtask
-	err = db.Pool.QueryRow(c.Request.Context(), `
-		INSERT INTO subtasks (todo_id, title, completed, created_at, updated_at)
-		VALUES ($1, $2, $3, NOW(), NOW())
-		RETURNING id, todo_id, title, completed, created_at, updated_at
-	`, todoID, req.Title, false).Scan(
-		&subtask.ID, &subtask.TodoID, &subtask.Title, &subtask.Completed, &subtask.CreatedAt, &subtask.UpdatedAt,
-	)
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := unlockTodoSubtasks(ctx, tx, c, todoID, reopen); err != nil {
+			return err
+		}
+		err := scanSubtask(tx.QueryRow(ctx, `
+			INSERT INTO subtasks (todo_id, title, completed, created_at, updated_at)
+			VALUES ($1, $2, $3, NOW(), NOW())
+			RETURNING `+subtaskColumns+`
+		`, todoID, req.Title, false), &subtask)
+		if err != nil {
+			return err
+		}
+		if err := adjustSubtaskCounts(ctx, tx, subtask.TodoID, 1, 0); err != nil {
+			return err
+		}
+		if err := recordSubtaskEvent(ctx, tx, models.TodoEventSubtaskAdded, subtask); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionCreate, models.AuditEntitySubtask, subtask.ID, nil, subtask)
+	})
 
+	if respondTransitionError(c, err) {
+		return
+	}
+	if respondDoneLockedError(c, err) {
+		return
+	}
 	if err != nil {
 		log.Printf("Error creating subtask: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subtask", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, subtask)
+	respondCreated(c, fmt.Sprintf("/todos/%d/subtasks/%d", subtask.TodoID, subtask.ID), subtask)
 }
 
 // UpdateSubtask godoc
 // @Summary      Update a subtask
-// @Description  Update an existing subtask
+// @Description  Update an existing subtask. A done todo gets a 409 while the lock_done_todos policy is on, unless reopen=true moves it out of done first.
 // @Tags         subtasks
 // @Accept       json
 // @Produce      json
-// @Param        id         path      int  true  "Todo ID"
-// @Param        subtaskId  path      int  true  "Subtask ID"
+// @Param        id         path      int   true   "Todo ID"
+// @Param        subtaskId  path      int   true   "Subtask ID"
+// @Param        reopen     query     bool  false  "Move a done todo to the first status that is not done first"
 // @Param        subtask    body      models.UpdateSubtaskRequest  true  "Subtask data"
 // @Success      200   {object}  models.Subtask
 // @Failure      400   {object}  map[string]string
 // @Failure      404   {object}  map[string]string
+// @Failure      409   {object}  map[string]interface{}
 // @Failure      500   {object}  map[string]string
 // @Router       /todos/{id}/subtasks/{subtaskId} [put]
 func UpdateSubtask(c *gin.Context) {
@@ -182,6 +350,12 @@ func UpdateSubtask(c *gin.Context) {
 		return
 	}
 
+	reopen, err := parseReopen(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var req models.UpdateSubtaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -189,25 +363,59 @@ func UpdateSubtask(c *gin.Context) {
 	}
 
 	var subtask models.Subtask
-	// Use CASE to only update title if provided, and always update completed
-	err = db.Pool.QueryRow(c.Request.Context(), `
-		UPDATE subtasks 
-		SET title = CASE 
-			WHEN $1 != '' THEN $1 
-			ELSE title 
-		END,
-		completed = $2,
-		updated_at = NOW()
-		WHERE id = $3 AND todo_id = $4
-		RETURNING id, todo_id, title, completed, created_at, updated_at
-	`, req.Title, req.Completed, subtaskID, todoID).Scan(
-		&subtask.ID, &subtask.TodoID, &subtask.Title, &subtask.Completed, &subtask.CreatedAt, &subtask.UpdatedAt,
-	)
-
-	if err == pgx.ErrNoRows {
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := unlockTodoSubtasks(ctx, tx, c, todoID, reopen); err != nil {
+			return err
+		}
+		var before models.Subtask
+		if err := scanSubtask(tx.QueryRow(ctx, `
+			SELECT `+subtaskColumns+` FROM subtasks WHERE id = $1 AND todo_id = $2 FOR UPDATE
+		`, subtaskID, todoID), &before); err != nil {
+			return err
+		}
+
+		// Use CASE to only update title if provided, and always update completed.
+		// completed_at is only stamped when completed flips to true, so
+		// completing an already completed subtask keeps its timestamp.
+		err := scanSubtask(tx.QueryRow(ctx, `
+			UPDATE subtasks 
+			SET title = CASE 
+				WHEN $1 != '' THEN $1 
+				ELSE title 
+			END,
+			completed = $2,
+			completed_at = CASE
+				WHEN NOT $2 THEN NULL
+				WHEN completed THEN completed_at
+				ELSE NOW()
+			END,
+			updated_at = NOW()
+			WHERE id = $3 AND todo_id = $4
+			RETURNING `+subtaskColumns+`
+		`, req.Title, req.Completed, subtaskID, todoID), &subtask)
+		if err != nil {
+			return err
+		}
+		if err := adjustSubtaskCounts(ctx, tx, todoID, 0, completedDelta(before.Completed, subtask.Completed)); err != nil {
+			return err
+		}
+		if err := recordSubtaskChanges(ctx, tx, before, subtask); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionUpdate, models.AuditEntitySubtask, subtask.ID, before, subtask)
+	})
+
+	if errors.Is(err, pgx.ErrNoRows) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Subtask not found"})
 		return
 	}
+	if respondTransitionError(c, err) {
+		return
+	}
+	if respondDoneLockedError(c, err) {
+		return
+	}
 	if err != nil {
 		log.Printf("Error updating subtask: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update subtask", "details": err.Error()})
@@ -219,15 +427,17 @@ func UpdateSubtask(c *gin.Context) {
 
 // DeleteSubtask godoc
 // @Summary      Delete a subtask
-// @Description  Delete a subtask by its ID
+// @Description  Delete a subtask by its ID. A done todo gets a 409 while the lock_done_todos policy is on, unless reopen=true moves it out of done first.
 // @Tags         subtasks
 // @Accept       json
 // @Produce      json
-// @Param        id         path      int  true  "Todo ID"
-// @Param        subtaskId  path      int  true  "Subtask ID"
-// @Success      204  {string}  string  "No Content"
+// @Param        id         path      int   true   "Todo ID"
+// @Param        subtaskId  path      int   true   "Subtask ID"
+// @Param        reopen     query     bool  false  "Move a done todo to the first status that is not done first"
+// @Success      204  "No Content"
 // @Failure      400  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]interface{}
 // @Failure      500  {object}  map[string]string
 // @Router       /todos/{id}/subtasks/{subtaskId} [delete]
 func DeleteSubtask(c *gin.Context) {
@@ -249,20 +459,48 @@ func DeleteSubtask(c *gin.Context) {
 		return
 	}
 
-	result, err := db.Pool.Exec(c.Request.Context(), `
-		DELETE FROM subtasks WHERE id = $1 AND todo_id = $2
-	`, subtaskID, todoID)
-
+	reopen, err := parseReopen(c)
 	if err != nil {
-		log.Printf("Error deleting subtask: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete subtask", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if result.RowsAffected() == 0 {
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := unlockTodoSubtasks(ctx, tx, c, todoID, reopen); err != nil {
+			return err
+		}
+		var before models.Subtask
+		if err := scanSubtask(tx.QueryRow(ctx, `
+			DELETE FROM subtasks WHERE id = $1 AND todo_id = $2
+			RETURNING `+subtaskColumns+`
+		`, subtaskID, todoID), &before); err != nil {
+			return err
+		}
+		if err := adjustSubtaskCounts(ctx, tx, todoID, -1, completedDelta(before.Completed, false)); err != nil {
+			return err
+		}
+		if err := recordSubtaskEvent(ctx, tx, models.TodoEventSubtaskDeleted, before); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionDelete, models.AuditEntitySubtask, before.ID, before, nil)
+	})
+
+	if errors.Is(err, pgx.ErrNoRows) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Subtask not found"})
 		return
 	}
+	if respondTransitionError(c, err) {
+		return
+	}
+	if respondDoneLockedError(c, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting subtask: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete subtask", "details": err.Error()})
+		return
+	}
 
 	c.Status(http.StatusNoContent)
 }
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// adjustSubtaskCounts moves a todo's stored subtask counters by the given
+// deltas. It must run in the transaction that changes the subtasks, so the
+// counters commit or roll back with them.
+func adjustSubtaskCounts(ctx context.Context, tx pgx.Tx, todoID int64, total, completed int) error {
+	if total == 0 && completed == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `
+		UPDATE todos
+		SET subtask_count = subtask_count + $2,
+		    subtask_completed_count = subtask_completed_count + $3
+		WHERE id = $1
+	`, todoID, total, completed)
+	return err
+}
+
+// completedDelta is the change to a completed counter when a subtask goes
+// from before to after
+func completedDelta(before, after bool) int {
+	switch {
+	case after && !before:
+		return 1
+	case before && !after:
+		return -1
+	}
+	return 0
+}
+
+// RecountSubtasks recomputes every todo's subtask counters from its subtasks
+// and returns the todos whose stored counters were wrong. Candidates are
+// found without locks; each is then locked and recounted in its own
+// transaction, so a concurrent subtask write is never overwritten.
+func RecountSubtasks(ctx context.Context) ([]models.SubtaskCountDrift, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT t.id
+		FROM todos t
+		LEFT JOIN subtasks s ON s.todo_id = t.id
+		GROUP BY t.id
+		HAVING t.subtask_count <> COUNT(s.id)
+		    OR t.subtask_completed_count <> COUNT(s.id) FILTER (WHERE s.completed)
+		ORDER BY t.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find subtask count drift: %w", err)
+	}
+	candidates, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, fmt.Errorf("failed to find subtask count drift: %w", err)
+	}
+
+	drifted := []models.SubtaskCountDrift{}
+	for _, todoID := range candidates {
+		drift := models.SubtaskCountDrift{TodoID: todoID}
+		var changed bool
+		err := db.WithTx(ctx, func(tx pgx.Tx) error {
+			if err := tx.QueryRow(ctx, `
+				SELECT subtask_count, subtask_completed_count FROM todos WHERE id = $1 FOR UPDATE
+			`, todoID).Scan(&drift.StoredCount, &drift.StoredCompleted); err != nil {
+				return err
+			}
+			if err := tx.QueryRow(ctx, `
+				SELECT COUNT(*), COUNT(*) FILTER (WHERE completed) FROM subtasks WHERE todo_id = $1
+			`, todoID).Scan(&drift.ActualCount, &drift.ActualCompleted); err != nil {
+				return err
+			}
+			changed = drift.StoredCount != drift.ActualCount || drift.StoredCompleted != drift.ActualCompleted
+			if !changed {
+				return nil
+			}
+			_, err := tx.Exec(ctx, `
+				UPDATE todos SET subtask_count = $2, subtask_completed_count = $3 WHERE id = $1
+			`, todoID, drift.ActualCount, drift.ActualCompleted)
+			return err
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Deleted since the scan
+			continue
+		}
+		if err != nil {
+			return drifted, fmt.Errorf("failed to recount subtasks of todo %d: %w", todoID, err)
+		}
+		if changed {
+			drifted = append(drifted, drift)
+		}
+	}
+	return drifted, nil
+}
+
+// ReconcileSubtaskCounts godoc
+// @Summary      Reconcile subtask counters
+// @Description  Recompute every todo's stored subtask counters from its subtasks, fix the ones that drifted and list them with their stored and actual values. The subtask_counts job does the same every hour. Requires the admin token.
+// @Tags         admin
+// @Produce      json
+// @Security     AdminToken
+// @Success      200  {object}  models.SubtaskCountReconciliation
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/subtask-counts/reconcile [post]
+func ReconcileSubtaskCounts(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	drifted, err := RecountSubtasks(c.Request.Context())
+	if err != nil {
+		log.Printf("Error reconciling subtask counts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile subtask counts", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SubtaskCountReconciliation{Drifted: drifted})
+}
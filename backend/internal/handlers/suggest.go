@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// maxSuggestions caps how many suggestions are returned
+const maxSuggestions = 10
+
+// suggestCandidates bounds each branch of the suggestion query so no branch
+// scans more than a handful of index entries
+const suggestCandidates = 50
+
+// likeEscaper escapes the LIKE wildcards in user input
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// suggestSQL gathers prefix and trigram matches on todo and epic titles, each
+// branch served by an index and capped, then ranks open items first, prefix
+// matches before fuzzy ones, and closer matches before weaker ones. $1 is the
+// query and $2 the escaped lowercase prefix pattern.
+const suggestSQL = `
+	WITH candidates AS (
+		(SELECT 'todo' AS type, id, title, status NOT IN (` + doneStatusesSQL + `) AS open
+		 FROM todos WHERE LOWER(title) LIKE $2
+		 ORDER BY LOWER(title) LIMIT $3)
+		UNION ALL
+		(SELECT 'todo', id, title, status NOT IN (` + doneStatusesSQL + `)
+		 FROM todos WHERE $1 <% title
+		 ORDER BY word_similarity($1, title) DESC LIMIT $3)
+		UNION ALL
+		(SELECT 'epic', id, title, status = 'open'
+		 FROM epics WHERE LOWER(title) LIKE $2
+		 ORDER BY LOWER(title) LIMIT $3)
+		UNION ALL
+		(SELECT 'epic', id, title, status = 'open'
+		 FROM epics WHERE $1 <% title
+		 ORDER BY word_similarity($1, title) DESC LIMIT $3)
+	)
+	SELECT type, id, title
+	FROM (SELECT DISTINCT type, id, title, open FROM candidates) matches
+	ORDER BY open DESC,
+	         LOWER(title) LIKE $2 DESC,
+	         word_similarity($1, title) DESC,
+	         LOWER(title),
+	         id
+	LIMIT $4
+`
+
+// GetSuggestions godoc
+// @Summary      Suggest titles
+// @Description  Type-ahead matches on todo and epic titles by prefix and trigram similarity. Open items rank above done ones. Queries shorter than two characters return no suggestions.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        q         query     string  true   "Text typed so far"
+// @Param        envelope  query     bool    false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.Suggestion  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/suggest [get]
+func GetSuggestions(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := strings.TrimSpace(c.Query("q"))
+	if utf8.RuneCountInString(query) < 2 {
+		c.JSON(http.StatusOK, []models.Suggestion{})
+		return
+	}
+	prefix := likeEscaper.Replace(strings.ToLower(query)) + "%"
+
+	rows, err := db.Pool.Query(c.Request.Context(), suggestSQL, query, prefix, suggestCandidates, maxSuggestions)
+	if err != nil {
+		log.Printf("Error querying suggestions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions", "details": err.Error()})
+		return
+	}
+	suggestions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Suggestion, error) {
+		var suggestion models.Suggestion
+		err := row.Scan(&suggestion.Type, &suggestion.ID, &suggestion.Title)
+		return suggestion, err
+	})
+	if err != nil {
+		log.Printf("Error scanning suggestions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions", "details": err.Error()})
+		return
+	}
+
+	respondList(c, envelope, suggestions, int64(len(suggestions)), 0, 0)
+}
@@ -0,0 +1,736 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// timeEntryColumns is the column list selected by every time entry query; scanTimeEntry reads it back.
+// Running timers are measured up to now.
+const timeEntryColumns = `id, todo_id, started_at, ended_at,
+	EXTRACT(EPOCH FROM COALESCE(ended_at, LOCALTIMESTAMP) - started_at)::bigint AS duration_seconds,
+	COALESCE(note, '') AS note, created_at, updated_at`
+
+// scanTimeEntry scans a row selected with timeEntryColumns into entry
+func scanTimeEntry(row pgx.Row, entry *models.TimeEntry) error {
+	return row.Scan(&entry.ID, &entry.TodoID, &entry.StartedAt, &entry.EndedAt, &entry.DurationSeconds, &entry.Note, &entry.CreatedAt, &entry.UpdatedAt)
+}
+
+// errTimeEntryOverlap is returned when an entry would overlap another entry
+var errTimeEntryOverlap = errors.New("time entry overlaps an existing entry")
+
+// parseTimeParam parses a query parameter given either as an RFC3339
+// timestamp or as a YYYY-MM-DD date (midnight in loc), returning it in UTC
+// like the stored timestamps it is compared with
+func parseTimeParam(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.ParseInLocation(models.DateLayout, value, loc)
+	return t.UTC(), err
+}
+
+// checkTimeEntryOverlap fails with errTimeEntryOverlap when [start, end)
+// overlaps any other entry, treating running timers as ending now
+func checkTimeEntryOverlap(ctx context.Context, tx pgx.Tx, start, end time.Time, excludeID int64) error {
+	var overlaps bool
+	err := tx.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM time_entries
+			WHERE started_at < $2 AND COALESCE(ended_at, LOCALTIMESTAMP) > $1 AND id <> $3
+		)
+	`, start, end, excludeID).Scan(&overlaps)
+	if err != nil {
+		return err
+	}
+	if overlaps {
+		return errTimeEntryOverlap
+	}
+	return nil
+}
+
+// getTrackedSeconds sums the tracked time of a todo, including a running timer
+func getTrackedSeconds(ctx context.Context, todoID int64) (int64, error) {
+	var seconds int64
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COALESCE(SUM(EXTRACT(EPOCH FROM COALESCE(ended_at, LOCALTIMESTAMP) - started_at)), 0)::bigint
+		FROM time_entries
+		WHERE todo_id = $1
+	`, todoID).Scan(&seconds)
+	return seconds, err
+}
+
+// StartTimer godoc
+// @Summary      Start a timer on a todo
+// @Description  Start tracking time against a todo. Only one timer can run at a time across all todos.
+// @Tags         time-entries
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int  true   "Todo ID"
+// @Param        timer  body      models.StartTimerRequest  false  "Timer data"
+// @Success      201  {object}  models.TimeEntry
+// @Header       201  {string}  Location  "URL of the created resource"
+// @Header       201  {string}  ETag      "Strong ETag of the created resource"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/timer/start [post]
+func StartTimer(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	var req models.StartTimerRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var note interface{}
+	if req.Note != "" {
+		note = req.Note
+	}
+
+	var entry models.TimeEntry
+	err = scanTimeEntry(db.Pool.QueryRow(c.Request.Context(), `
+		INSERT INTO time_entries (todo_id, started_at, note, created_at, updated_at)
+		SELECT id, NOW(), $2, NOW(), NOW() FROM todos WHERE id = $1
+		RETURNING `+timeEntryColumns+`
+	`, todoID, note), &entry)
+
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	if isUniqueViolation(err) {
+		var running models.TimeEntry
+		if err := scanTimeEntry(db.Pool.QueryRow(c.Request.Context(), `
+			SELECT `+timeEntryColumns+` FROM time_entries WHERE ended_at IS NULL
+		`), &running); err != nil {
+			log.Printf("Error fetching running timer: %v", err)
+			c.JSON(http.StatusConflict, gin.H{"error": "A timer is already running"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "A timer is already running", "running": running})
+		return
+	}
+	if err != nil {
+		log.Printf("Error starting timer: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start timer", "details": err.Error()})
+		return
+	}
+
+	respondCreated(c, fmt.Sprintf("/todos/%d/time-entries/%d", entry.TodoID, entry.ID), entry)
+}
+
+// StopTimer godoc
+// @Summary      Stop the timer on a todo
+// @Description  Stop the running timer of a todo. A timer running across midnight is kept as a single entry spanning both days.
+// @Tags         time-entries
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Todo ID"
+// @Success      200  {object}  models.TimeEntry
+// @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/timer/stop [post]
+func StopTimer(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	var entry models.TimeEntry
+	err = scanTimeEntry(db.Pool.QueryRow(c.Request.Context(), `
+		UPDATE time_entries
+		SET ended_at = NOW(), updated_at = NOW()
+		WHERE todo_id = $1 AND ended_at IS NULL
+		RETURNING `+timeEntryColumns+`
+	`, todoID), &entry)
+
+	if err == pgx.ErrNoRows {
+		c.JSON(http.StatusConflict, gin.H{"error": "No timer is running for this todo"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error stopping timer: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stop timer", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// GetTimeEntries godoc
+// @Summary      List time entries for a todo
+// @Description  Get every time entry tracked against a todo, most recent first
+// @Tags         time-entries
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int   true   "Todo ID"
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.TimeEntry  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/time-entries [get]
+func GetTimeEntries(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	// Verify todo exists
+	var todoExists bool
+	err = db.Pool.QueryRow(c.Request.Context(), `
+		SELECT EXISTS(SELECT 1 FROM todos WHERE id = $1)
+	`, todoID).Scan(&todoExists)
+	if err != nil {
+		log.Printf("Error checking todo existence: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify todo", "details": err.Error()})
+		return
+	}
+	if !todoExists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+
+	rows, err := db.Pool.Query(c.Request.Context(), `
+		SELECT `+timeEntryColumns+`
+		FROM time_entries
+		WHERE todo_id = $1
+		ORDER BY started_at DESC
+	`, todoID)
+	if err != nil {
+		log.Printf("Error querying time entries: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch time entries", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	entries := []models.TimeEntry{}
+	for rows.Next() {
+		var entry models.TimeEntry
+		if err := scanTimeEntry(rows, &entry); err != nil {
+			log.Printf("Error scanning time entry: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan time entry", "details": err.Error()})
+			return
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating time entries: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating time entries", "details": err.Error()})
+		return
+	}
+
+	respondList(c, envelope, entries, int64(len(entries)), 0, 0)
+}
+
+// CreateTimeEntry godoc
+// @Summary      Add a manual time entry
+// @Description  Record a completed span of time against a todo. The span must not overlap any other entry.
+// @Tags         time-entries
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int  true  "Todo ID"
+// @Param        entry  body      models.CreateTimeEntryRequest  true  "Time entry data"
+// @Success      201  {object}  models.TimeEntry
+// @Header       201  {string}  Location  "URL of the created resource"
+// @Header       201  {string}  ETag      "Strong ETag of the created resource"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/time-entries [post]
+func CreateTimeEntry(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	var req models.CreateTimeEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !req.EndedAt.After(req.StartedAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ended_at must be after started_at"})
+		return
+	}
+
+	var note interface{}
+	if req.Note != "" {
+		note = req.Note
+	}
+
+	var entry models.TimeEntry
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := checkTimeEntryOverlap(ctx, tx, req.StartedAt, req.EndedAt, 0); err != nil {
+			return err
+		}
+		return scanTimeEntry(tx.QueryRow(ctx, `
+			INSERT INTO time_entries (todo_id, started_at, ended_at, note, created_at, updated_at)
+			SELECT id, $2, $3, $4, NOW(), NOW() FROM todos WHERE id = $1
+			RETURNING `+timeEntryColumns+`
+		`, todoID, req.StartedAt, req.EndedAt, note), &entry)
+	})
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	if errors.Is(err, errTimeEntryOverlap) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Time entry overlaps an existing entry"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating time entry: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create time entry", "details": err.Error()})
+		return
+	}
+
+	respondCreated(c, fmt.Sprintf("/todos/%d/time-entries/%d", entry.TodoID, entry.ID), entry)
+}
+
+// UpdateTimeEntry godoc
+// @Summary      Edit a time entry
+// @Description  Change the start, end or note of a time entry. The resulting span must not overlap any other entry.
+// @Tags         time-entries
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int  true  "Todo ID"
+// @Param        entryId  path      int  true  "Time entry ID"
+// @Param        entry    body      models.UpdateTimeEntryRequest  true  "Time entry data"
+// @Success      200  {object}  models.TimeEntry
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/time-entries/{entryId} [put]
+func UpdateTimeEntry(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	entryID, err := strconv.ParseInt(c.Param("entryId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time entry ID"})
+		return
+	}
+
+	var req models.UpdateTimeEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var entry models.TimeEntry
+	var rangeErr error
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var current models.TimeEntry
+		if err := scanTimeEntry(tx.QueryRow(ctx, `
+			SELECT `+timeEntryColumns+` FROM time_entries WHERE id = $1 AND todo_id = $2 FOR UPDATE
+		`, entryID, todoID), &current); err != nil {
+			return err
+		}
+
+		startedAt := current.StartedAt
+		if req.StartedAt != nil {
+			startedAt = *req.StartedAt
+		}
+		endedAt := current.EndedAt
+		if req.EndedAt != nil {
+			endedAt = req.EndedAt
+		}
+		note := current.Note
+		if req.Note != nil {
+			note = *req.Note
+		}
+
+		overlapEnd := time.Now()
+		if endedAt != nil {
+			if !endedAt.After(startedAt) {
+				rangeErr = fmt.Errorf("ended_at must be after started_at")
+				return rangeErr
+			}
+			overlapEnd = *endedAt
+		}
+		if err := checkTimeEntryOverlap(ctx, tx, startedAt, overlapEnd, entryID); err != nil {
+			return err
+		}
+
+		var noteValue interface{}
+		if note != "" {
+			noteValue = note
+		}
+		return scanTimeEntry(tx.QueryRow(ctx, `
+			UPDATE time_entries
+			SET started_at = $1, ended_at = $2, note = $3, updated_at = NOW()
+			WHERE id = $4
+			RETURNING `+timeEntryColumns+`
+		`, startedAt, endedAt, noteValue, entryID), &entry)
+	})
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Time entry not found"})
+		return
+	}
+	if rangeErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": rangeErr.Error()})
+		return
+	}
+	if errors.Is(err, errTimeEntryOverlap) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Time entry overlaps an existing entry"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating time entry: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update time entry", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// DeleteTimeEntry godoc
+// @Summary      Delete a time entry
+// @Description  Delete a time entry by its ID
+// @Tags         time-entries
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int  true  "Todo ID"
+// @Param        entryId  path      int  true  "Time entry ID"
+// @Success      204  "No Content"
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/time-entries/{entryId} [delete]
+func DeleteTimeEntry(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	todoID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	entryID, err := strconv.ParseInt(c.Param("entryId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time entry ID"})
+		return
+	}
+
+	result, err := db.Pool.Exec(c.Request.Context(), `
+		DELETE FROM time_entries WHERE id = $1 AND todo_id = $2
+	`, entryID, todoID)
+	if err != nil {
+		log.Printf("Error deleting time entry: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete time entry", "details": err.Error()})
+		return
+	}
+
+	if result.RowsAffected() == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Time entry not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetTimeReport godoc
+// @Summary      Report tracked time
+// @Description  Aggregate tracked time per todo and per day for entries overlapping the range. Days, and from and to given as dates, are taken in the tz parameter, the X-Timezone header or the timezone preference. Entries spanning midnight count towards each day they cover. Use format=csv to download the individual entries.
+// @Tags         time-entries
+// @Accept       json
+// @Produce      json
+// @Produce      text/csv
+// @Param        from     query     string  false  "Range start (RFC3339 or YYYY-MM-DD)"
+// @Param        to       query     string  false  "Range end, exclusive (RFC3339 or YYYY-MM-DD)"
+// @Param        todo_id  query     int     false  "Only entries of this todo"
+// @Param        tz       query     string  false  "IANA timezone of the days"
+// @Param        format   query     string  false  "Response format (json, csv)"  default(json)
+// @Success      200  {object}  models.TimeReport
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /time-entries [get]
+func GetTimeReport(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format. Must be one of: json, csv"})
+		return
+	}
+
+	loc, err := requestLocation(c, c.Query("tz"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	whereConditions := []string{}
+	queryArgs := []interface{}{}
+	argIndex := 1
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := parseTimeParam(fromStr, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from, expected RFC3339 or YYYY-MM-DD"})
+			return
+		}
+		whereConditions = append(whereConditions, "COALESCE(e.ended_at, LOCALTIMESTAMP) > $"+strconv.Itoa(argIndex))
+		queryArgs = append(queryArgs, from)
+		argIndex++
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := parseTimeParam(toStr, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to, expected RFC3339 or YYYY-MM-DD"})
+			return
+		}
+		whereConditions = append(whereConditions, "e.started_at < $"+strconv.Itoa(argIndex))
+		queryArgs = append(queryArgs, to)
+		argIndex++
+	}
+
+	if todoIDStr := c.Query("todo_id"); todoIDStr != "" {
+		todoID, err := strconv.ParseInt(todoIDStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo_id"})
+			return
+		}
+		whereConditions = append(whereConditions, "e.todo_id = $"+strconv.Itoa(argIndex))
+		queryArgs = append(queryArgs, todoID)
+	}
+
+	whereClause := ""
+	if len(whereConditions) > 0 {
+		whereClause = "WHERE " + strings.Join(whereConditions, " AND ")
+	}
+
+	ctx := c.Request.Context()
+	if format == "csv" {
+		exportTimeEntries(c, whereClause, queryArgs)
+		return
+	}
+
+	report := models.TimeReport{
+		ByTodo:  []models.TodoTimeTotal{},
+		ByDay:   []models.DayTimeTotal{},
+		Entries: []models.TimeEntry{},
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT e.todo_id, t.title, SUM(EXTRACT(EPOCH FROM COALESCE(e.ended_at, LOCALTIMESTAMP) - e.started_at))::bigint
+		FROM time_entries e
+		JOIN todos t ON t.id = e.todo_id
+		`+whereClause+`
+		GROUP BY e.todo_id, t.title
+		ORDER BY 3 DESC
+	`, queryArgs...)
+	if err != nil {
+		log.Printf("Error aggregating time per todo: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build time report", "details": err.Error()})
+		return
+	}
+	report.ByTodo, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.TodoTimeTotal, error) {
+		var total models.TodoTimeTotal
+		err := row.Scan(&total.TodoID, &total.Title, &total.Seconds)
+		return total, err
+	})
+	if err != nil {
+		log.Printf("Error scanning time per todo: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build time report", "details": err.Error()})
+		return
+	}
+	for _, total := range report.ByTodo {
+		report.TotalSeconds += total.Seconds
+	}
+
+	// Split each entry over the calendar days it covers in loc. Entries are
+	// stored in UTC; days are bounded by local midnights, so a day across a
+	// DST change counts its 23 or 25 hours.
+	tzArg := "$" + strconv.Itoa(len(queryArgs)+1)
+	rows, err = db.Pool.Query(ctx, `
+		SELECT to_char(d, 'YYYY-MM-DD'),
+		       SUM(EXTRACT(EPOCH FROM
+		           LEAST(l.ended, (d + INTERVAL '1 day') AT TIME ZONE `+tzArg+`) - GREATEST(l.started, d AT TIME ZONE `+tzArg+`)
+		       ))::bigint
+		FROM (
+			SELECT e.started_at AT TIME ZONE 'UTC' AS started, COALESCE(e.ended_at, LOCALTIMESTAMP) AT TIME ZONE 'UTC' AS ended
+			FROM time_entries e
+			`+whereClause+`
+		) AS l
+		CROSS JOIN LATERAL generate_series(
+			date_trunc('day', l.started AT TIME ZONE `+tzArg+`),
+			date_trunc('day', l.ended AT TIME ZONE `+tzArg+`),
+			INTERVAL '1 day'
+		) AS d
+		GROUP BY d
+		ORDER BY d
+	`, append(queryArgs, loc.String())...)
+	if err != nil {
+		log.Printf("Error aggregating time per day: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build time report", "details": err.Error()})
+		return
+	}
+	report.ByDay, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.DayTimeTotal, error) {
+		var total models.DayTimeTotal
+		err := row.Scan(&total.Date, &total.Seconds)
+		return total, err
+	})
+	if err != nil {
+		log.Printf("Error scanning time per day: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build time report", "details": err.Error()})
+		return
+	}
+
+	rows, err = db.Pool.Query(ctx, `
+		SELECT `+timeEntryColumns+`
+		FROM time_entries e
+		`+whereClause+`
+		ORDER BY e.started_at DESC
+	`, queryArgs...)
+	if err != nil {
+		log.Printf("Error querying time entries: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build time report", "details": err.Error()})
+		return
+	}
+	report.Entries, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.TimeEntry, error) {
+		var entry models.TimeEntry
+		err := scanTimeEntry(row, &entry)
+		return entry, err
+	})
+	if err != nil {
+		log.Printf("Error scanning time entries: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build time report", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// exportTimeEntries streams the entries matching whereClause as CSV
+func exportTimeEntries(c *gin.Context, whereClause string, queryArgs []interface{}) {
+	rows, err := db.Pool.Query(c.Request.Context(), `
+		SELECT e.id, e.todo_id, t.title, e.started_at, e.ended_at,
+		       EXTRACT(EPOCH FROM COALESCE(e.ended_at, LOCALTIMESTAMP) - e.started_at)::bigint,
+		       COALESCE(e.note, '')
+		FROM time_entries e
+		JOIN todos t ON t.id = e.todo_id
+		`+whereClause+`
+		ORDER BY e.started_at ASC
+	`, queryArgs...)
+	if err != nil {
+		log.Printf("Error querying time entries: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export time entries", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="time_entries.csv"`)
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"id", "todo_id", "todo_title", "started_at", "ended_at", "duration_seconds", "note"})
+	for rows.Next() {
+		var (
+			id, todoID, seconds int64
+			title, note         string
+			startedAt           time.Time
+			endedAt             *time.Time
+		)
+		if err := rows.Scan(&id, &todoID, &title, &startedAt, &endedAt, &seconds, &note); err != nil {
+			// Headers are already sent, so the best we can do is log and stop
+			log.Printf("Error scanning time entry for export: %v", err)
+			break
+		}
+		ended := ""
+		if endedAt != nil {
+			ended = endedAt.Format(time.RFC3339)
+		}
+		writer.Write([]string{
+			strconv.FormatInt(id, 10), strconv.FormatInt(todoID, 10), title, startedAt.Format(time.RFC3339),
+			ended, strconv.FormatInt(seconds, 10), note,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating time entries for export: %v", err)
+	}
+	writer.Flush()
+}
@@ -1,29 +1,251 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
 
 	"flow-v1/backend/internal/db"
 	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/store"
 )
 
+// allTodoColumns selects every column of todoColumnList
+var allTodoColumns = &todoFieldSet{columns: todoColumnList}
+
+// todoColumns is the column list selected by every todo query; scanTodo reads
+// it back. Both come from todoColumnList.
+var todoColumns = allTodoColumns.sql()
+
+// scanTodo scans a row selected with todoColumns into todo
+func scanTodo(row pgx.Row, todo *models.Todo) error {
+	return allTodoColumns.scan(row, todo)
+}
+
+// subtaskProgress formats subtask counters as completed/total, or returns ""
+// for a todo without subtasks
+func subtaskProgress(total, completed int) string {
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", completed, total)
+}
+
+// applyTodoUpdate locks a todo, stores its current state as a revision and
+// runs setClause against it. $1 in setClause is the todo ID and args bind from
+// $2 onwards. Status changes must be allowed by the workflow, otherwise a
+// *transitionError is returned, and edits the lock_done_todos policy locks
+// fail with a *doneLockedError. completed_at is kept in step with status, and
+// the resulting changes are written to the activity timeline and audit log.
+// actor is nil for changes made by the requesting user; c is nil for changes
+// made by the system.
+func applyTodoUpdate(ctx context.Context, tx pgx.Tx, c *gin.Context, actor *string, id int64, setClause string, args ...interface{}) (models.Todo, error) {
+	return applyNotedTodoUpdate(ctx, tx, c, actor, nil, id, setClause, args...)
+}
+
+// applyNotedTodoUpdate is applyTodoUpdate for changes that are checked
+// against the note rules. With a nil note the rules are not checked. An
+// empty note makes a change matching a rule fail with a *noteRequiredError;
+// any other note is recorded on the todo as a note by the system.
+func applyNotedTodoUpdate(ctx context.Context, tx pgx.Tx, c *gin.Context, actor, note *string, id int64, setClause string, args ...interface{}) (models.Todo, error) {
+	var before, todo models.Todo
+	if err := scanTodo(tx.QueryRow(ctx, `
+		SELECT `+todoColumns+` FROM todos WHERE id = $1 FOR UPDATE
+	`, id), &before); err != nil {
+		return todo, err
+	}
+	if err := writeTodoRevision(ctx, tx, before); err != nil {
+		return todo, err
+	}
+
+	err := scanTodo(tx.QueryRow(ctx, `
+		UPDATE todos
+		SET `+setClause+`
+		WHERE id = $1
+		RETURNING `+todoColumns+`
+	`, append([]interface{}{id}, args...)...), &todo)
+	if err != nil {
+		return todo, err
+	}
+
+	if todo.Status != before.Status {
+		if err := checkTransition(ctx, tx, before.Status, todo.Status); err != nil {
+			return todo, err
+		}
+		err = scanTodo(tx.QueryRow(ctx, `
+			UPDATE todos
+			SET completed_at = CASE WHEN status IN (`+doneStatusesSQL+`) THEN COALESCE(completed_at, NOW()) END
+			WHERE id = $1
+			RETURNING `+todoColumns+`
+		`, id), &todo)
+		if err != nil {
+			return todo, err
+		}
+	}
+	if err := checkDoneLock(ctx, tx, before, todo); err != nil {
+		return todo, err
+	}
+	if note != nil && *note == "" {
+		if err := checkNoteRules(ctx, tx, before, todo); err != nil {
+			return todo, err
+		}
+	}
+
+	if todo.Description != before.Description {
+		if err := syncTodoReferences(ctx, tx, todo); err != nil {
+			return todo, err
+		}
+	}
+	if err := recordTodoChanges(ctx, tx, actor, before, todo); err != nil {
+		return todo, err
+	}
+	if err := recordEstimateChange(ctx, tx, c, actor, todo.ID, before.StoryPoints, todo.StoryPoints, models.EstimateChangeUpdate); err != nil {
+		return todo, err
+	}
+	if err := warnTodoChanges(ctx, c, before, todo); err != nil {
+		return todo, err
+	}
+	if note != nil && *note != "" {
+		if err := recordTodoNote(ctx, tx, todo.ID, *note); err != nil {
+			return todo, err
+		}
+	}
+	return todo, recordAudit(ctx, tx, c, models.AuditActionUpdate, models.AuditEntityTodo, todo.ID, before, todo)
+}
+
+// errTodosNotFound is returned when a bulk request names todos that do not exist
+var errTodosNotFound = errors.New("todos not found")
+
+// assignTodos adds todoIDs to (add) or removes them from the sprint or epic
+// with the given id, where column is sprint_id or epic_id. Adding fails with
+// errTodosNotFound and the missing IDs if any todo does not exist; removing
+// ignores todos that are not members.
+func assignTodos(ctx context.Context, tx pgx.Tx, c *gin.Context, todoIDs []int64, column string, id int64, add bool) ([]models.Todo, []int64, error) {
+	if !add {
+		rows, err := tx.Query(ctx, `
+			SELECT id FROM todos WHERE id = ANY($1) AND `+column+` = $2 ORDER BY id
+		`, todoIDs, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		members, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+		if err != nil {
+			return nil, nil, err
+		}
+		moved, err := moveTodos(ctx, tx, c, members, column, nil)
+		return moved, nil, err
+	}
+
+	missing, err := missingTodos(ctx, tx, todoIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(missing) > 0 {
+		return nil, missing, errTodosNotFound
+	}
+	moved, err := moveTodos(ctx, tx, c, todoIDs, column, &id)
+	return moved, nil, err
+}
+
+// missingTodos returns the IDs in todoIDs that no todo has
+func missingTodos(ctx context.Context, tx pgx.Tx, todoIDs []int64) ([]int64, error) {
+	var missing []int64
+	err := tx.QueryRow(ctx, `
+		SELECT COALESCE(array_agg(requested.id), '{}')
+		FROM unnest($1::bigint[]) AS requested(id)
+		WHERE NOT EXISTS (SELECT 1 FROM todos WHERE todos.id = requested.id)
+	`, todoIDs).Scan(&missing)
+	return missing, err
+}
+
+// moveTodos sets column (sprint_id or epic_id) to target on each of todoIDs
+// whose value differs, recording the change on each todo, and returns the
+// todos it changed. A nil target clears the column.
+func moveTodos(ctx context.Context, tx pgx.Tx, c *gin.Context, todoIDs []int64, column string, target *int64) ([]models.Todo, error) {
+	moved := []models.Todo{}
+	for _, todoID := range todoIDs {
+		var current *int64
+		err := tx.QueryRow(ctx, `SELECT `+column+` FROM todos WHERE id = $1`, todoID).Scan(&current)
+		if err != nil {
+			return nil, err
+		}
+		if equalEventValues(formatEventID(current), formatEventID(target)) {
+			continue
+		}
+
+		todo, err := applyTodoUpdate(ctx, tx, c, nil, todoID, column+` = $2, updated_at = NOW()`, target)
+		if err != nil {
+			return nil, err
+		}
+		moved = append(moved, todo)
+	}
+	return moved, nil
+}
+
+// todoTimeFilters are the range filters on todo timestamps and the bound
+// each sets. Lower bounds are inclusive and upper bounds exclusive, so
+// created_after=2025-03-01&created_before=2025-03-02 selects one day.
+var todoTimeFilters = []struct {
+	param string
+	bound func(params *store.ListTodosParams) **time.Time
+}{
+	{"created_after", func(params *store.ListTodosParams) **time.Time { return &params.CreatedAfter }},
+	{"created_before", func(params *store.ListTodosParams) **time.Time { return &params.CreatedBefore }},
+	{"updated_after", func(params *store.ListTodosParams) **time.Time { return &params.UpdatedAfter }},
+	{"updated_before", func(params *store.ListTodosParams) **time.Time { return &params.UpdatedBefore }},
+}
+
+// todoListParams are the query parameters GET /todos knows besides cf.<name>
+var todoListParams = []string{
+	"sort_by", "order", "q", "search_in", "status", "story_points_min", "story_points_max",
+	"estimate_min", "estimate_max", "sprint_id", "epic_id", "deferred", "created_after", "created_before", "updated_after", "updated_before",
+	"fields", "compact", "limit", "offset", "cursor", "envelope",
+}
+
 // GetTodos godoc
 // @Summary      List all todos
-// @Description  Get a list of all todo items with optional sorting and status filtering
+// @Description  Get a list of all todo items with optional sorting and status filtering. With limit, the total number of matching todos is returned in the X-Total-Count header.
 // @Tags         todos
 // @Accept       json
 // @Produce      json
-// @Param        sort_by         query     string  false  "Sort by field (due_date, priority, created_at)"  default(created_at)
-// @Param        order           query     string  false  "Sort order (asc, desc)"  default(desc)
-// @Param        status          query     string  false  "Filter by status (todo, in_progress, done)"
+// @Param        sort_by         query     string  false  "Comma-separated sort fields (due_date, priority, status, created_at, updated_at, title, story_points, estimate), e.g. priority,due_date; defaults to the default_sort preference"
+// @Param        order           query     string  false  "Comma-separated sort order per field (asc, desc), e.g. desc,asc"  default(desc)
+// @Param        q               query     string  false  "Only todos containing this text, ignoring case, in their title or description or in a subtask title; subtask hits are listed in matched_subtasks"
+// @Param        search_in       query     string  false  "Where q looks: todos (title and description), subtasks (subtask titles) or all"  Enums(all, todos, subtasks)  default(all)
+// @Param        status          query     string  false  "Filter by status key (see /statuses)"
 // @Param        story_points_min  query     int     false  "Minimum story points for filtering"
 // @Param        story_points_max  query     int     false  "Maximum story points for filtering"
-// @Success      200      {array}   models.Todo
+// @Param        estimate_min    query     int     false  "Minimum estimate in minutes; todos without an estimate are left out"
+// @Param        estimate_max    query     int     false  "Maximum estimate in minutes; todos without an estimate are left out"
+// @Param        sprint_id       query     string  false  "Filter by sprint ID, or \"none\" for the backlog"
+// @Param        epic_id         query     string  false  "Filter by epic ID, or \"none\" for todos outside any epic"
+// @Param        deferred        query     bool    false  "List only the todos deferred until a later date instead of leaving them out"  default(false)
+// @Param        cf.{name}       query     string  false  "Filter by a custom field value, e.g. cf.environment=prod"
+// @Param        created_after   query     string  false  "Only todos created at or after this RFC3339 timestamp or date"
+// @Param        created_before  query     string  false  "Only todos created before this RFC3339 timestamp or date"
+// @Param        updated_after   query     string  false  "Only todos updated at or after this RFC3339 timestamp or date"
+// @Param        updated_before  query     string  false  "Only todos updated before this RFC3339 timestamp or date"
+// @Param        fields          query     string  false  "Comma-separated fields to return, e.g. title,status,due_date; id is always included"
+// @Param        compact         query     bool    false  "Return only the fields a board card needs; ignored when fields is set"
+// @Param        limit           query     int     false  "Page size (max 200); without it every matching todo is returned"
+// @Param        offset          query     int     false  "Number of todos to skip; requires limit"  default(0)
+// @Param        cursor          query     string  false  "Return the page after this next_cursor of the previous page, requested with the same sort_by and order; requires limit and cannot be combined with offset"
+// @Param        envelope        query     bool    false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Param        strict          query     bool    false  "Reject unknown parameters and unusable values with a 400 instead of dropping them; defaults to STRICT_QUERY_PARAMS or the strict_params flag"
+// @Success      200      {array}   models.Todo  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Header       200      {string}  X-Ignored-Params  "Comma-separated parameters dropped because they are unknown or their value is unusable"
+// @Header       200      {string}  X-Next-Cursor     "Cursor of the next page, when the list is paged and another page follows"
+// @Failure      400      {object}  map[string]string
 // @Failure      500      {object}  map[string]string
 // @Router       /todos [get]
 func GetTodos(c *gin.Context) {
@@ -33,156 +255,317 @@ func GetTodos(c *gin.Context) {
 		return
 	}
 
-	// Get sorting parameters
-	sortBy := c.DefaultQuery("sort_by", "created_at")
+	query, err := newQueryParams(c, todoListParams, customFieldFilterPrefix)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get sorting parameters, falling back to the user's default sort
+	sortBy := c.Query("sort_by")
+	sortFields := store.TodoSortFields()
+	for _, field := range strings.Split(sortBy, ",") {
+		if field = strings.TrimSpace(field); field != "" && !slices.Contains(sortFields, field) {
+			query.drop("sort_by", "comma-separated sort fields ("+strings.Join(sortFields, ", ")+")")
+			break
+		}
+	}
+	for _, value := range strings.Split(c.Query("order"), ",") {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" && value != "asc" && value != "desc" {
+			query.drop("order", "comma-separated sort orders (asc, desc)")
+			break
+		}
+	}
 	order := c.DefaultQuery("order", "desc")
-	statusFilter := c.Query("status")
-	storyPointsMinStr := c.Query("story_points_min")
-	storyPointsMaxStr := c.Query("story_points_max")
+	if sortBy == "" {
+		preferences, err := loadPreferences(c.Request.Context(), currentUser(c))
+		if err != nil {
+			log.Printf("Error fetching preferences: %v", err)
+			preferences = models.DefaultPreferences()
+		}
+		sortBy = preferences.DefaultSort.SortBy
+		order = c.DefaultQuery("order", preferences.DefaultSort.Order)
+	}
+	params := store.ListTodosParams{Sort: store.ParseTodoSort(sortBy, order)}
 
-	// Validate sort_by field
-	validSortFields := map[string]bool{
-		"due_date":   true,
-		"priority":   true,
-		"created_at": true,
+	if value := c.Query("compact"); value != "" {
+		if _, err := strconv.ParseBool(value); err != nil {
+			query.drop("compact", "true or false")
+		}
 	}
-	if !validSortFields[sortBy] {
-		sortBy = "created_at"
+	fieldSet, err := parseTodoFieldSet(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	columns, scan := todoColumns, scanTodo
+	if fieldSet != nil {
+		columns, scan = fieldSet.sql(), fieldSet.scan
 	}
 
-	// Validate order
-	if order != "asc" && order != "desc" {
-		order = "desc"
+	// Pages are opt-in so existing clients keep getting every todo
+	if value := c.Query("limit"); value != "" {
+		if params.Limit, err = strconv.Atoi(value); err != nil || params.Limit <= 0 || params.Limit > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit. Must be between 1 and 200"})
+			return
+		}
+	}
+	if value := c.Query("offset"); value != "" {
+		if params.Offset, err = strconv.Atoi(value); err != nil || params.Offset < 0 || params.Limit == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset. Must be zero or more and used with limit"})
+			return
+		}
+	}
+	// Pages after the first are found by the sort values of the previous
+	// page's last todo, so todos created or deleted meanwhile do not shift them
+	params.SelectCursor = params.Limit > 0
+	if value := c.Query("cursor"); value != "" {
+		if params.Limit == 0 || c.Query("offset") != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor. Must be used with limit and without offset"})
+			return
+		}
+		cursor, err := store.DecodeTodoCursor(params.Sort, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		params.After = &cursor
 	}
 
 	// Validate status filter
-	validStatuses := map[string]bool{
-		"todo":        true,
-		"in_progress": true,
-		"done":        true,
+	if statusFilter := c.Query("status"); statusFilter != "" {
+		status, ok, err := resolveStatus(c.Request.Context(), statusFilter)
+		if err != nil {
+			log.Printf("Error fetching statuses: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch todos", "details": err.Error()})
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": invalidStatusError(c.Request.Context()).Error()})
+			return
+		}
+		params.Status = &status.Key
 	}
-	if statusFilter != "" && !validStatuses[statusFilter] {
-		statusFilter = ""
+
+	// Search titles and descriptions and, unless search_in=todos, subtask titles
+	if search := strings.TrimSpace(c.Query("q")); search != "" {
+		switch c.DefaultQuery("search_in", "all") {
+		case "all":
+			params.SearchTodos, params.SearchSubtasks = true, true
+		case "todos":
+			params.SearchTodos = true
+		case "subtasks":
+			params.SearchSubtasks = true
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid search_in. Must be one of: all, todos, subtasks"})
+			return
+		}
+		pattern := "%" + likeEscaper.Replace(search) + "%"
+		params.Search = &pattern
 	}
 
-	var orderByClause string
-	switch sortBy {
-	case "due_date":
-		if order == "asc" {
-			orderByClause = "ORDER BY due_date ASC NULLS LAST"
-		} else {
-			orderByClause = "ORDER BY due_date DESC NULLS LAST"
+	// Story point and estimate bounds that are not whole numbers of zero or
+	// more are dropped
+	for _, bound := range []struct {
+		param string
+		value **int
+	}{
+		{"story_points_min", &params.StoryPointsMin}, {"story_points_max", &params.StoryPointsMax},
+		{"estimate_min", &params.EstimateMin}, {"estimate_max", &params.EstimateMax},
+	} {
+		value := c.Query(bound.param)
+		if value == "" {
+			continue
 		}
-	case "priority":
-		// Priority order: High > Medium > Low
-		if order == "asc" {
-			orderByClause = "ORDER BY CASE priority WHEN 'High' THEN 1 WHEN 'Medium' THEN 2 WHEN 'Low' THEN 3 END ASC"
+		if points, err := strconv.Atoi(value); err == nil && points >= 0 {
+			*bound.value = &points
 		} else {
-			orderByClause = "ORDER BY CASE priority WHEN 'High' THEN 1 WHEN 'Medium' THEN 2 WHEN 'Low' THEN 3 END DESC"
+			query.drop(bound.param, "a whole number of zero or more")
 		}
-	default:
-		orderByClause = "ORDER BY created_at " + order
 	}
 
-	// Build WHERE clause for filters
-	whereConditions := []string{}
-	queryArgs := []interface{}{}
-	argIndex := 1
+	// Filter by sprint; "none" selects the backlog
+	if sprintIDStr := c.Query("sprint_id"); sprintIDStr == "none" {
+		params.NoSprint = true
+	} else if sprintID, err := strconv.ParseInt(sprintIDStr, 10, 64); err == nil {
+		params.SprintID = &sprintID
+	} else if sprintIDStr != "" {
+		query.drop("sprint_id", "a sprint ID or none")
+	}
 
-	if statusFilter != "" {
-		whereConditions = append(whereConditions, "status = $"+strconv.Itoa(argIndex))
-		queryArgs = append(queryArgs, statusFilter)
-		argIndex++
+	// Filter by epic; "none" selects todos outside any epic
+	if epicIDStr := c.Query("epic_id"); epicIDStr == "none" {
+		params.NoEpic = true
+	} else if epicID, err := strconv.ParseInt(epicIDStr, 10, 64); err == nil {
+		params.EpicID = &epicID
+	} else if epicIDStr != "" {
+		query.drop("epic_id", "an epic ID or none")
 	}
 
-	// Parse and validate story points min
-	if storyPointsMinStr != "" {
-		storyPointsMin, err := strconv.Atoi(storyPointsMinStr)
-		if err == nil && storyPointsMin >= 0 {
-			whereConditions = append(whereConditions, "story_points >= $"+strconv.Itoa(argIndex))
-			queryArgs = append(queryArgs, storyPointsMin)
-			argIndex++
+	// Deferred todos are left out unless they are asked for
+	if value := c.Query("deferred"); value != "" {
+		if deferred, err := strconv.ParseBool(value); err == nil {
+			params.Deferred = deferred
+		} else {
+			query.drop("deferred", "true or false")
 		}
 	}
 
-	// Parse and validate story points max
-	if storyPointsMaxStr != "" {
-		storyPointsMax, err := strconv.Atoi(storyPointsMaxStr)
-		if err == nil && storyPointsMax >= 0 {
-			whereConditions = append(whereConditions, "story_points <= $"+strconv.Itoa(argIndex))
-			queryArgs = append(queryArgs, storyPointsMax)
-			argIndex++
+	// Filter by created and updated ranges. Dates and zone-less timestamps
+	// are read in the request's timezone.
+	var rangeLocation *time.Location
+	for _, filter := range todoTimeFilters {
+		value := c.Query(filter.param)
+		if value == "" {
+			continue
+		}
+		input, err := models.ParseDateInput(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + filter.param + ". Use an RFC3339 timestamp or a YYYY-MM-DD date"})
+			return
 		}
+		if rangeLocation == nil {
+			if rangeLocation, err = requestLocation(c, ""); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		bound := input.In(rangeLocation)
+		*filter.bound(&params) = &bound
 	}
 
-	whereClause := ""
-	if len(whereConditions) > 0 {
-		whereClause = "WHERE " + whereConditions[0]
-		for i := 1; i < len(whereConditions); i++ {
-			whereClause += " AND " + whereConditions[i]
-		}
+	// Filter by custom fields (cf.<name>=<value>) using JSONB containment
+	var droppedFields []string
+	params.CustomFields, droppedFields, err = customFieldFilters(c.Request.Context(), c.Request.URL.Query())
+	if err != nil {
+		log.Printf("Error fetching custom fields: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch todos", "details": err.Error()})
+		return
+	}
+	for _, param := range droppedFields {
+		query.drop(param, "a value of a known custom field's type")
+	}
+	if query.respond() {
+		return
 	}
 
-	rows, err := db.Pool.Query(c.Request.Context(), `
-		SELECT id, title, COALESCE(description, '') as description, status, due_date, priority, story_points, created_at, updated_at 
-		FROM todos 
-		`+whereClause+`
-		`+orderByClause+`
-	`, queryArgs...)
+	list, err := listTodos(c.Request.Context(), columns, scan, params)
 	if err != nil {
-		log.Printf("Error querying todos: %v", err)
+		log.Printf("Error listing todos: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch todos", "details": err.Error()})
 		return
 	}
-	defer rows.Close()
+	if params.Limit > 0 {
+		c.Header("X-Total-Count", strconv.FormatInt(list.Total, 10))
+	}
+	todos := list.Todos
+	if params.Limit == 0 {
+		list.Total = int64(len(todos))
+	}
+	var next string
+	if list.Next != nil {
+		next = store.EncodeTodoCursor(params.Sort, *list.Next)
+		c.Header("X-Next-Cursor", next)
+	}
 
-	todos := []models.Todo{} // Initialize as empty slice to ensure JSON serializes to [] not null
-	for rows.Next() {
-		var todo models.Todo
-		if err := rows.Scan(&todo.ID, &todo.Title, &todo.Description, &todo.Status, &todo.DueDate, &todo.Priority, &todo.StoryPoints, &todo.CreatedAt, &todo.UpdatedAt); err != nil {
-			log.Printf("Error scanning todo: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan todo", "details": err.Error()})
+	if fieldSet == nil {
+		respondCursorList(c, envelope, todos, list.Total, params.Limit, params.Offset, next)
+		return
+	}
+	projected := make([]map[string]json.RawMessage, len(todos))
+	for i, todo := range todos {
+		if projected[i], err = fieldSet.project(todo); err != nil {
+			log.Printf("Error encoding todo: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch todos", "details": err.Error()})
 			return
 		}
-		todos = append(todos, todo)
 	}
+	respondCursorList(c, envelope, projected, list.Total, params.Limit, params.Offset, next)
+}
 
-	if err := rows.Err(); err != nil {
-		log.Printf("Error iterating todos: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating todos", "details": err.Error()})
-		return
+// loadTodoDetails fills in what GET /todos/:id adds to a todo: its GitHub
+// link, URL links, the todos its description references, tracked time,
+// latest subtask completion, editing lock and allowed transitions
+func loadTodoDetails(ctx context.Context, todo *models.Todo) error {
+	var err error
+	if todo.GitHubLink, err = getGitHubLink(ctx, todo.ID); err != nil {
+		return fmt.Errorf("failed to fetch GitHub link: %w", err)
 	}
-
-	c.JSON(http.StatusOK, todos)
+	if todo.Links, err = getTodoLinks(ctx, todo.ID); err != nil {
+		return fmt.Errorf("failed to fetch links: %w", err)
+	}
+	if todo.References, err = getTodoReferences(ctx, *todo); err != nil {
+		return fmt.Errorf("failed to fetch references: %w", err)
+	}
+	trackedSeconds, err := getTrackedSeconds(ctx, todo.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch tracked time: %w", err)
+	}
+	todo.TrackedSeconds = &trackedSeconds
+	if todo.LastSubtaskCompletedAt, err = getLastSubtaskCompletedAt(ctx, todo.ID); err != nil {
+		return fmt.Errorf("failed to fetch subtask completion: %w", err)
+	}
+	lock, err := getEditLock(ctx, db.Pool, todo.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch edit lock: %w", err)
+	}
+	if lock != nil {
+		todo.LockedBy, todo.LockExpiresAt = &lock.LockedBy, &lock.ExpiresAt
+	}
+	if todo.AllowedTransitions, err = allowedTransitions(ctx, db.Pool, todo.Status); err != nil {
+		return fmt.Errorf("failed to fetch allowed transitions: %w", err)
+	}
+	return nil
 }
 
 // GetTodo godoc
 // @Summary      Get a todo by ID
-// @Description  Get a single todo item by its ID
+// @Description  Get a single todo item by its ID. A deleted todo answers 410 with its deleted_at until its tombstone is purged after TOMBSTONE_RETENTION_DAYS; unknown IDs answer 404. references lists the existing todos its description names as #<id>, outside code, so they can be shown with their title and status. With render=html the Markdown description is also returned as sanitized HTML in description_html: raw HTML is escaped, links are limited to http, https and mailto, and images to the MARKDOWN_IMAGE_PREFIXES URLs.
 // @Tags         todos
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "Todo ID"
+// @Param        id      path      int     true   "Todo ID"
+// @Param        render  query     string  false  "Also render the description as HTML"  Enums(html)
 // @Success      200  {object}  models.Todo
+// @Header       200  {string}  ETag  "Strong ETag of the resource"
+// @Success      304  "Not Modified"
+// @Failure      400  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
+// @Failure      410  {object}  models.TodoGone
 // @Failure      500  {object}  map[string]string
 // @Router       /todos/{id} [get]
 func GetTodo(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
 		return
 	}
+	render, err := parseRender(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	var todo models.Todo
-	err = db.Pool.QueryRow(c.Request.Context(), `
-		SELECT id, title, COALESCE(description, '') as description, status, due_date, priority, story_points, created_at, updated_at 
+	err = scanTodo(db.Pool.QueryRow(c.Request.Context(), `
+		SELECT `+todoColumns+`
 		FROM todos 
 		WHERE id = $1
-	`, id).Scan(&todo.ID, &todo.Title, &todo.Description, &todo.Status, &todo.DueDate, &todo.Priority, &todo.StoryPoints, &todo.CreatedAt, &todo.UpdatedAt)
+	`, id), &todo)
 
 	if err == pgx.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		respondTodoNotFound(c, id)
 		return
 	}
 	if err != nil {
@@ -191,26 +574,53 @@ func GetTodo(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, todo)
+	if err := loadTodoDetails(c.Request.Context(), &todo); err != nil {
+		log.Printf("Error fetching todo details: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch todo", "details": err.Error()})
+		return
+	}
+	recordTodoView(currentUser(c), todo.ID)
+	if render {
+		descriptionHTML := renderDescriptionHTML(todo.Description)
+		todo.DescriptionHTML = &descriptionHTML
+	}
+
+	respondResource(c, todo)
 }
 
 // CreateTodo godoc
 // @Summary      Create a new todo
-// @Description  Create a new todo item
+// @Description  Create a new todo item. Open todos with a similar title are returned in possible_duplicates. Questionable values that are still accepted, such as similar todos, a due date in the past or story points on a done todo, are listed in warnings. Fields left out take the todo defaults (see /settings/todo-defaults), which are listed in applied_defaults.
 // @Tags         todos
 // @Accept       json
 // @Produce      json
-// @Param        todo  body      models.CreateTodoRequest  true  "Todo data"
+// @Param        todo               body      models.CreateTodoRequest  true   "Todo data"
+// @Param        strict_duplicates  query     bool                      false  "Reject the todo with 409 instead of warning when similar open todos exist"
 // @Success      201   {object}  models.Todo
+// @Header       201   {string}  Location  "URL of the created resource"
+// @Header       201   {string}  ETag      "Strong ETag of the created resource"
 // @Failure      400   {object}  map[string]string
+// @Failure      409   {object}  map[string]interface{}
 // @Failure      500   {object}  map[string]string
 // @Router       /todos [post]
 func CreateTodo(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
 	var req models.CreateTodoRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	appliedDefaults, err := applyTodoDefaults(c.Request.Context(), c, &req)
+	if err != nil {
+		log.Printf("Error applying todo defaults: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create todo", "details": err.Error()})
+		return
+	}
 
 	// Convert empty description to NULL
 	var description interface{}
@@ -220,65 +630,178 @@ func CreateTodo(c *gin.Context) {
 		description = req.Description
 	}
 
-	// Set default priority if not provided
-	priority := req.Priority
-	if priority == "" {
-		priority = "Medium"
+	dueDate, err := resolveDueDate(c, req.DueDate, req.Timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Set default status if not provided
-	status := req.Status
-	if status == "" {
-		status = "todo"
+	// Without a priority the todo follows the default priority
+	var priority interface{}
+	if key, err := resolvePriority(c.Request.Context(), req.PriorityKey, req.Priority); respondPriorityError(c, err) {
+		return
+	} else if err != nil {
+		log.Printf("Error fetching priorities: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create todo", "details": err.Error()})
+		return
+	} else if key != "" {
+		priority = key
 	}
 
-	// Validate story points if provided
-	if req.StoryPoints != nil {
-		validStoryPoints := map[int]bool{1: true, 2: true, 3: true, 5: true, 8: true}
-		if !validStoryPoints[*req.StoryPoints] {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid story points value. Must be one of: 1, 2, 3, 5, 8"})
+	// Set default status to the first board column if not provided
+	status := req.Status
+	if status == "" {
+		statuses, err := getStatuses(c.Request.Context())
+		if err != nil {
+			log.Printf("Error fetching statuses: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create todo", "details": err.Error()})
 			return
 		}
+		if len(statuses) > 0 {
+			status = statuses[0].Key
+		}
+	} else if resolved, ok, err := resolveStatus(c.Request.Context(), status); err != nil {
+		log.Printf("Error fetching statuses: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create todo", "details": err.Error()})
+		return
+	} else if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalidStatusError(c.Request.Context()).Error()})
+		return
+	} else {
+		status = resolved.Key
 	}
 
+	// Validate story points against the scale if provided
+	if message, err := checkStoryPoints(c.Request.Context(), req.StoryPoints); err != nil {
+		log.Printf("Error fetching story point scale: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create todo", "details": err.Error()})
+		return
+	} else if message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+	if message := checkEstimate(req.Estimate); message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+
+	customFields, err := loadCustomFields(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching custom fields: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create todo", "details": err.Error()})
+		return
+	}
+	customValues, err := validateCustomFields(customFields, req.CustomFields, true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	strictDuplicates := c.Query("strict_duplicates") == "true"
+
 	var todo models.Todo
-	err := db.Pool.QueryRow(c.Request.Context(), `
-		INSERT INTO todos (title, description, status, due_date, priority, story_points, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
-		RETURNING id, title, COALESCE(description, '') as description, status, due_date, priority, story_points, created_at, updated_at
-	`, req.Title, description, status, req.DueDate, priority, req.StoryPoints).Scan(
-		&todo.ID, &todo.Title, &todo.Description, &todo.Status, &todo.DueDate, &todo.Priority, &todo.StoryPoints, &todo.CreatedAt, &todo.UpdatedAt,
-	)
+	var duplicates []models.SimilarTodo
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		duplicates, err = findSimilarTodos(ctx, tx, req.Title, duplicateThreshold())
+		if err != nil {
+			return err
+		}
+		if strictDuplicates && len(duplicates) > 0 {
+			return errPossibleDuplicates
+		}
+
+		err = scanTodo(tx.QueryRow(ctx, `
+			INSERT INTO todos (title, description, status, due_date, all_day, due_timezone, priority_key, story_points, estimate_minutes, epic_id, custom_fields, completed_at, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, CASE WHEN $3 IN (`+doneStatusesSQL+`) THEN NOW() END, NOW(), NOW())
+			RETURNING `+todoColumns+`
+		`, req.Title, description, status, dueDate.dueDate, dueDate.allDay, dueDate.timezone, priority, req.StoryPoints, req.Estimate, req.EpicID, customValues), &todo)
+		if err != nil {
+			return err
+		}
+		if err := syncTodoReferences(ctx, tx, todo); err != nil {
+			return err
+		}
+		if err := recordTodoEvent(ctx, tx, models.TodoEvent{TodoID: todo.ID, Type: models.TodoEventCreated}); err != nil {
+			return err
+		}
+		if err := warnTodoChanges(ctx, c, models.Todo{}, todo); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionCreate, models.AuditEntityTodo, todo.ID, nil, todo)
+	})
 
+	if errors.Is(err, errPossibleDuplicates) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Similar open todos already exist", "possible_duplicates": duplicates})
+		return
+	}
+	if isForeignKeyViolation(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Epic not found"})
+		return
+	}
 	if err != nil {
 		log.Printf("Error creating todo: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create todo", "details": err.Error()})
 		return
 	}
+	if err := loadTodoDetails(ctx, &todo); err != nil {
+		log.Printf("Error fetching todo details: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create todo", "details": err.Error()})
+		return
+	}
+	created := todo
+	todo.PossibleDuplicates = duplicates
+	if len(duplicates) > 0 {
+		ids := make([]int64, len(duplicates))
+		for i, duplicate := range duplicates {
+			ids[i] = duplicate.ID
+		}
+		addWarning(c, models.WarningPossibleDuplicate, "Similar open todos already exist", ids...)
+	}
+	todo.Warnings = requestWarnings(c)
+	todo.AppliedDefaults = appliedDefaults
 
-	c.JSON(http.StatusCreated, todo)
+	respondCreatedAs(c, "/todos/"+strconv.FormatInt(todo.ID, 10), todo, created)
 }
 
 // UpdateTodo godoc
 // @Summary      Update a todo
-// @Description  Update an existing todo item
+// @Description  Update an existing todo item. When updated_at is sent and the todo has changed since, the update still applies, the response carries X-Conflict: true and the todo's values before the update are returned under previous. Updates also apply while someone else holds the todo's editing lock; the response then names them in X-Edit-Lock-Held-By. A status or priority change matching a note rule needs a note, otherwise it gets a 422 naming the rule; the note is recorded in the todo's activity. A due date set in the past or story points set on a done todo are listed in warnings. A null estimate_minutes clears the estimate. While the lock_done_todos policy is on, changing the title, description or story points of a done todo gets a 409 unless the update also moves it out of done; reopen=true does so, to the first status the workflow allows that is not done.
 // @Tags         todos
 // @Accept       json
 // @Produce      json
-// @Param        id    path      int  true  "Todo ID"
-// @Param        todo  body      models.UpdateTodoRequest  true  "Todo data"
-// @Success      200   {object}  models.Todo
+// @Param        id      path      int   true   "Todo ID"
+// @Param        reopen  query     bool  false  "Move a done todo to the first status that is not done before applying the update"
+// @Param        todo    body      models.UpdateTodoRequest  true  "Todo data"
+// @Success      200   {object}  models.ConflictingTodo
+// @Header       200   {string}  X-Conflict  "true when the todo changed after the client's updated_at"
+// @Header       200   {string}  X-Edit-Lock-Held-By  "Who holds the todo's editing lock, when it is someone else"
 // @Failure      400   {object}  map[string]string
 // @Failure      404   {object}  map[string]string
+// @Failure      409   {object}  map[string]interface{}
+// @Failure      422   {object}  map[string]interface{}
 // @Failure      500   {object}  map[string]string
 // @Router       /todos/{id} [put]
 func UpdateTodo(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
 		return
 	}
 
+	reopen, err := parseReopen(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var req models.UpdateTodoRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -298,43 +821,163 @@ func UpdateTodo(c *gin.Context) {
 	var status interface{}
 	if req.Status == "" {
 		status = nil
+	} else if resolved, ok, err := resolveStatus(c.Request.Context(), req.Status); err != nil {
+		log.Printf("Error fetching statuses: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update todo"})
+		return
+	} else if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalidStatusError(c.Request.Context()).Error()})
+		return
 	} else {
-		status = req.Status
+		status = resolved.Key
 	}
 
-	// Validate story points if provided
-	if req.StoryPoints != nil {
-		validStoryPoints := map[int]bool{1: true, 2: true, 3: true, 5: true, 8: true}
-		if !validStoryPoints[*req.StoryPoints] {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid story points value. Must be one of: 1, 2, 3, 5, 8"})
+	dueDate, err := resolveDueDate(c, req.DueDate, req.Timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var priority interface{}
+	if key, err := resolvePriority(c.Request.Context(), req.PriorityKey, req.Priority); respondPriorityError(c, err) {
+		return
+	} else if err != nil {
+		log.Printf("Error fetching priorities: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update todo"})
+		return
+	} else if key != "" {
+		priority = key
+	}
+
+	// Validate story points against the scale if provided
+	if message, err := checkStoryPoints(c.Request.Context(), req.StoryPoints); err != nil {
+		log.Printf("Error fetching story point scale: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update todo", "details": err.Error()})
+		return
+	} else if message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+	if message := checkEstimate(req.Estimate.Minutes); message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+
+	// Custom fields are merged into the existing values; nulls remove fields
+	var customValues []byte
+	if req.CustomFields != nil {
+		customFields, err := loadCustomFields(c.Request.Context())
+		if err != nil {
+			log.Printf("Error fetching custom fields: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update todo"})
+			return
+		}
+		coerced, err := validateCustomFields(customFields, req.CustomFields, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if customValues, err = json.Marshal(coerced); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update todo"})
 			return
 		}
 	}
 
-	err = db.Pool.QueryRow(c.Request.Context(), `
-		UPDATE todos 
-		SET title = COALESCE($1, title),
-		    description = COALESCE($2, description),
-		    status = COALESCE($3, status),
-		    due_date = COALESCE($4, due_date),
-		    priority = COALESCE($5, priority),
-		    story_points = COALESCE($6, story_points),
-		    updated_at = NOW()
-		WHERE id = $7
-		RETURNING id, title, COALESCE(description, '') as description, status, due_date, priority, story_points, created_at, updated_at
-	`, req.Title, description, status, req.DueDate, req.Priority, req.StoryPoints, id).Scan(
-		&todo.ID, &todo.Title, &todo.Description, &todo.Status, &todo.DueDate, &todo.Priority, &todo.StoryPoints, &todo.CreatedAt, &todo.UpdatedAt,
-	)
+	ctx := c.Request.Context()
+	var previous *models.Todo
+	var lockHolder string
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		// Writes over a version the client has not seen still apply; they
+		// are only counted and reported
+		if req.UpdatedAt != nil {
+			var current models.Todo
+			if err := scanTodo(tx.QueryRow(ctx, `
+				SELECT `+todoColumns+` FROM todos WHERE id = $1 FOR UPDATE
+			`, id), &current); err != nil {
+				return err
+			}
+			if !req.UpdatedAt.Truncate(time.Microsecond).Equal(current.UpdatedAt) {
+				previous = &current
+			}
+		}
 
-	if err == pgx.ErrNoRows {
+		// reopen moves a done todo out of done in the same update, so the
+		// edit passes the lock_done_todos policy
+		if reopen && status == nil {
+			var current string
+			if err := tx.QueryRow(ctx, `SELECT status FROM todos WHERE id = $1 FOR UPDATE`, id).Scan(&current); err != nil {
+				return err
+			}
+			currentStatus, _, err := lookupStatus(ctx, current)
+			if err != nil {
+				return err
+			}
+			if currentStatus.IsDone {
+				if status, err = reopenStatus(ctx, tx, current); err != nil {
+					return err
+				}
+			}
+		}
+
+		var err error
+		todo, err = applyNotedTodoUpdate(ctx, tx, c, nil, &req.Note, id, `
+			title = COALESCE($2, title),
+			description = COALESCE($3, description),
+			status = COALESCE($4, status),
+			due_date = COALESCE($5, due_date),
+			all_day = CASE WHEN $5::timestamp IS NULL THEN all_day ELSE $10 END,
+			due_timezone = CASE WHEN $5::timestamp IS NULL THEN due_timezone ELSE $11 END,
+			priority_key = COALESCE($6, priority_key),
+			story_points = COALESCE($7, story_points),
+			estimate_minutes = CASE WHEN $12 THEN $13 ELSE estimate_minutes END,
+			epic_id = COALESCE($8, epic_id),
+			custom_fields = CASE WHEN $9::jsonb IS NULL THEN custom_fields ELSE jsonb_strip_nulls(custom_fields || $9::jsonb) END,
+			updated_at = NOW()
+		`, req.Title, description, status, dueDate.dueDate, priority, req.StoryPoints, req.EpicID, customValues, dueDate.allDay, dueDate.timezone,
+			req.Estimate.Set, req.Estimate.Minutes)
+		if err != nil {
+			return err
+		}
+
+		// Editing locks are advisory: the update applies, and the client is
+		// told who else is editing so it can warn
+		lockHolder, err = editLockHeldByOther(ctx, tx, id, currentUser(c))
+		return err
+	})
+
+	if errors.Is(err, pgx.ErrNoRows) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
 		return
 	}
+	if isForeignKeyViolation(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Epic not found"})
+		return
+	}
+	if respondTransitionError(c, err) {
+		return
+	}
+	if respondDoneLockedError(c, err) {
+		return
+	}
+	if respondNoteRequiredError(c, err) {
+		return
+	}
 	if err != nil {
+		log.Printf("Error updating todo: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update todo"})
 		return
 	}
 
+	if lockHolder != "" {
+		c.Header(editLockHeader, lockHolder)
+	}
+	todo.Warnings = requestWarnings(c)
+	if previous != nil {
+		todoUpdateConflicts.Add(1)
+		c.Header("X-Conflict", "true")
+		c.JSON(http.StatusOK, models.ConflictingTodo{Todo: todo, Previous: *previous})
+		return
+	}
 	c.JSON(http.StatusOK, todo)
 }
 
@@ -345,29 +988,49 @@ func UpdateTodo(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        id   path      int  true  "Todo ID"
-// @Success      204  {string}  string  "No Content"
+// @Success      204  "No Content"
 // @Failure      400  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /todos/{id} [delete]
 func DeleteTodo(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
 		return
 	}
 
-	result, err := db.Pool.Exec(c.Request.Context(), `
-		DELETE FROM todos WHERE id = $1
-	`, id)
+	ctx := c.Request.Context()
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var before models.Todo
+		if err := scanTodo(tx.QueryRow(ctx, `
+			DELETE FROM todos WHERE id = $1
+			RETURNING `+todoColumns+`
+		`, id), &before); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO todo_tombstones (todo_id, deleted_at) VALUES ($1, NOW())
+			ON CONFLICT (todo_id) DO UPDATE SET deleted_at = EXCLUDED.deleted_at
+		`, before.ID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionDelete, models.AuditEntityTodo, before.ID, before, nil)
+	})
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete todo"})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
 		return
 	}
-
-	if result.RowsAffected() == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+	if err != nil {
+		log.Printf("Error deleting todo: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete todo"})
 		return
 	}
 
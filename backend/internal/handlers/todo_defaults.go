@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// todoDefaultsColumns is the column list selected by every todo defaults
+// query; scanTodoDefaults reads it back
+const todoDefaultsColumns = `status, priority_key, story_points, estimate_minutes, updated_at`
+
+// scanTodoDefaults scans a row selected with todoDefaultsColumns
+func scanTodoDefaults(row pgx.Row) (models.TodoDefaults, error) {
+	var defaults models.TodoDefaults
+	err := row.Scan(&defaults.Status, &defaults.PriorityKey, &defaults.StoryPoints, &defaults.Estimate, &defaults.UpdatedAt)
+	return defaults, err
+}
+
+// todoDefaultsCache holds the single todo defaults row
+var todoDefaultsCache = &configCache[models.TodoDefaults]{load: func(ctx context.Context) ([]models.TodoDefaults, error) {
+	defaults, err := scanTodoDefaults(db.Pool.QueryRow(ctx, `SELECT `+todoDefaultsColumns+` FROM todo_defaults`))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return []models.TodoDefaults{{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load todo defaults: %w", err)
+	}
+	return []models.TodoDefaults{defaults}, nil
+}}
+
+// applyTodoDefaults fills the fields a create request left out with the todo
+// defaults and returns the ones it applied, or nil when none were. Defaults
+// are checked again since their status or priority may have been deleted or
+// the story point scale changed; those are skipped with a warning so the
+// built-in default applies.
+func applyTodoDefaults(ctx context.Context, c *gin.Context, req *models.CreateTodoRequest) (*models.AppliedTodoDefaults, error) {
+	cached, err := todoDefaultsCache.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defaults := cached[0]
+
+	var applied models.AppliedTodoDefaults
+	if req.Status == "" && defaults.Status != nil {
+		status, ok, err := resolveStatus(ctx, *defaults.Status)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			req.Status = status.Key
+			applied.Status = &status.Key
+		} else {
+			addWarning(c, models.WarningDefaultUnavailable, fmt.Sprintf("Default status %q no longer exists; the first board column was used", *defaults.Status))
+		}
+	}
+	if req.PriorityKey == "" && req.Priority == "" && defaults.PriorityKey != nil {
+		key, err := resolvePriority(ctx, *defaults.PriorityKey, "")
+		var priorityErr *priorityError
+		if errors.As(err, &priorityErr) {
+			addWarning(c, models.WarningDefaultUnavailable, fmt.Sprintf("Default priority %q no longer exists; the default priority was used", *defaults.PriorityKey))
+		} else if err != nil {
+			return nil, err
+		} else {
+			req.PriorityKey = key
+			applied.PriorityKey = &key
+		}
+	}
+	if req.StoryPoints == nil && defaults.StoryPoints != nil {
+		message, err := checkStoryPoints(ctx, defaults.StoryPoints)
+		if err != nil {
+			return nil, err
+		}
+		if message == "" {
+			req.StoryPoints = defaults.StoryPoints
+			applied.StoryPoints = defaults.StoryPoints
+		} else {
+			addWarning(c, models.WarningDefaultUnavailable, fmt.Sprintf("Default story points %d are no longer on the scale and were not set", *defaults.StoryPoints))
+		}
+	}
+	if req.Estimate == nil && defaults.Estimate != nil {
+		req.Estimate = defaults.Estimate
+		applied.Estimate = defaults.Estimate
+	}
+
+	if applied == (models.AppliedTodoDefaults{}) {
+		return nil, nil
+	}
+	return &applied, nil
+}
+
+// GetTodoDefaults godoc
+// @Summary      Get the todo defaults
+// @Description  Get the status, priority, story points and estimate new todos get when their create request leaves them out. Null fields keep the built-in behavior: the first board column, the default priority and no story points or estimate.
+// @Tags         settings
+// @Produce      json
+// @Success      200  {object}  models.TodoDefaults
+// @Failure      500  {object}  map[string]string
+// @Router       /settings/todo-defaults [get]
+func GetTodoDefaults(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	defaults, err := scanTodoDefaults(db.Pool.QueryRow(c.Request.Context(), `
+		SELECT `+todoDefaultsColumns+` FROM todo_defaults
+	`))
+	if err != nil {
+		log.Printf("Error fetching todo defaults: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch todo defaults", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, defaults)
+}
+
+// UpdateTodoDefaults godoc
+// @Summary      Replace the todo defaults
+// @Description  Replace the values new todos get for fields their create request leaves out; missing or null fields are unset. The status and priority must exist and the story points be on the scale. If the status or priority is deleted or the scale changes later, todos are created without that default and with a default_unavailable warning. Created todos list the defaults they got in applied_defaults.
+// @Tags         settings
+// @Accept       json
+// @Produce      json
+// @Param        defaults  body      models.UpdateTodoDefaultsRequest  true  "Todo defaults"
+// @Success      200       {object}  models.TodoDefaults
+// @Failure      400       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /settings/todo-defaults [put]
+func UpdateTodoDefaults(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.UpdateTodoDefaultsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if req.Status != nil {
+		status, ok, err := resolveStatus(ctx, *req.Status)
+		if err != nil {
+			log.Printf("Error fetching statuses: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update todo defaults", "details": err.Error()})
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": invalidStatusError(ctx).Error()})
+			return
+		}
+		req.Status = &status.Key
+	}
+	if req.PriorityKey != nil {
+		key, err := resolvePriority(ctx, *req.PriorityKey, "")
+		if respondPriorityError(c, err) {
+			return
+		}
+		if err != nil {
+			log.Printf("Error fetching priorities: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update todo defaults", "details": err.Error()})
+			return
+		}
+		req.PriorityKey = &key
+	}
+	if message, err := checkStoryPoints(ctx, req.StoryPoints); err != nil {
+		log.Printf("Error fetching story point scale: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update todo defaults", "details": err.Error()})
+		return
+	} else if message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+	if message := checkEstimate(req.Estimate); message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+
+	defaults, err := scanTodoDefaults(db.Pool.QueryRow(ctx, `
+		INSERT INTO todo_defaults (id, status, priority_key, story_points, estimate_minutes, updated_at)
+		VALUES (TRUE, $1, $2, $3, $4, NOW())
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			priority_key = EXCLUDED.priority_key,
+			story_points = EXCLUDED.story_points,
+			estimate_minutes = EXCLUDED.estimate_minutes,
+			updated_at = NOW()
+		RETURNING `+todoDefaultsColumns+`
+	`, req.Status, req.PriorityKey, req.StoryPoints, req.Estimate))
+	if err != nil {
+		log.Printf("Error updating todo defaults: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update todo defaults", "details": err.Error()})
+		return
+	}
+	todoDefaultsCache.reset()
+
+	c.JSON(http.StatusOK, defaults)
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// errTodoAlreadyImported is returned when a concurrent import of the same
+// export got there first
+var errTodoAlreadyImported = errors.New("todo export already imported")
+
+// importedTodo returns the todo an earlier import of exportID created, or
+// pgx.ErrNoRows
+func importedTodo(ctx context.Context, exportID string) (models.Todo, error) {
+	var todo models.Todo
+	err := scanTodo(db.Pool.QueryRow(ctx, `
+		SELECT `+todoColumns+` FROM todos
+		WHERE id = (SELECT todo_id FROM todo_imports WHERE export_id = $1)
+	`, exportID), &todo)
+	return todo, err
+}
+
+// ExportTodo godoc
+// @Summary      Export a todo
+// @Description  Get a todo with its subtasks and links as a portable JSON document that POST /todos/import recreates in another environment. Its sprint and epic are left out, as are time entries and activity. Each export gets a new export_id.
+// @Tags         todos
+// @Produce      json
+// @Param        id   path      int  true  "Todo ID"
+// @Success      200  {object}  models.TodoExport
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/{id}/export [get]
+func ExportTodo(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+
+	export := models.TodoExport{SchemaVersion: models.TodoExportVersion, ExportID: rand.Text(), ExportedAt: time.Now().UTC()}
+	err = scanArchivedTodo(db.Pool.QueryRow(c.Request.Context(), archivedTodoSQL+` WHERE t.id = $1`, id), &export.Todo)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error exporting todo: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export todo", "details": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="todo-%d.json"`, id))
+	c.JSON(http.StatusOK, export)
+}
+
+// ImportTodo godoc
+// @Summary      Import a todo
+// @Description  Create a todo with its subtasks and links from a document made by GET /todos/{id}/export, outside any sprint or epic. Statuses, story points and custom fields must be valid here; a priority that no longer exists becomes the default one. An export that was imported before returns the todo it created with 200 and already_imported, unless force is set; a deleted todo can be imported again. With dry_run=true the import is run and rolled back and the result returned with 200; its IDs are not kept.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        export   body      models.TodoExport  true   "Todo export"
+// @Param        force    query     bool               false  "Create a new todo even if this export was imported before"
+// @Param        dry_run  query     bool               false  "Only report what would be created"  default(false)
+// @Success      200      {object}  models.TodoImportResult
+// @Success      201      {object}  models.TodoImportResult
+// @Header       201      {string}  Location  "URL of the created todo"
+// @Failure      400      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Router       /todos/import [post]
+func ImportTodo(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	force := false
+	if value := c.Query("force"); value != "" {
+		if force, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid force. Must be true or false"})
+			return
+		}
+	}
+	var export models.TodoExport
+	if err := c.ShouldBindJSON(&export); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if export.SchemaVersion != models.TodoExportVersion {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unsupported schema version %d. Expected %d", export.SchemaVersion, models.TodoExportVersion)})
+		return
+	}
+	if export.ExportID == "" || len(export.ExportID) > 64 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export_id. Must be 1 to 64 characters"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	result := models.TodoImportResult{Subtasks: len(export.Todo.Subtasks), Links: len(export.Todo.Links), DryRun: dryRun}
+	if !force {
+		todo, err := importedTodo(ctx, export.ExportID)
+		if err == nil {
+			result.Todo, result.AlreadyImported = todo, true
+			c.JSON(http.StatusOK, result)
+			return
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("Error looking up todo import: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import todo", "details": err.Error()})
+			return
+		}
+	}
+
+	customFields, err := loadCustomFields(ctx)
+	if err != nil {
+		log.Printf("Error fetching custom fields: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import todo", "details": err.Error()})
+		return
+	}
+	if message, err := validateArchivedTodo(ctx, customFields, &export.Todo, "todo"); err != nil {
+		log.Printf("Error validating todo export: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import todo", "details": err.Error()})
+		return
+	} else if message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+
+	err = db.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		if result.Todo, err = insertArchivedTodo(ctx, tx, c, export.Todo, nil); err != nil {
+			return err
+		}
+		// A forced import leaves the export pointing at the first todo
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO todo_imports (export_id, todo_id) VALUES ($1, $2)
+			ON CONFLICT (export_id) DO NOTHING
+		`, export.ExportID, result.Todo.ID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 && !force {
+			return errTodoAlreadyImported
+		}
+		return nil
+	})
+	if errors.Is(err, errTodoAlreadyImported) {
+		if result.Todo, err = importedTodo(ctx, export.ExportID); err == nil {
+			result.AlreadyImported = true
+			c.JSON(http.StatusOK, result)
+			return
+		}
+	}
+	if err != nil {
+		log.Printf("Error importing todo: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import todo", "details": err.Error()})
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	respondCreatedAs(c, fmt.Sprintf("/todos/%d", result.Todo.ID), result, result.Todo)
+}
@@ -0,0 +1,101 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/router"
+	"flow-v1/backend/internal/testsupport"
+)
+
+// importTodo posts an export to /todos/import and deletes the todo it
+// creates when the test ends
+func importTodo(t *testing.T, pool *pgxpool.Pool, server *testsupport.Server, query string, export []byte, status int) models.TodoImportResult {
+	t.Helper()
+	var result models.TodoImportResult
+	server.JSON(http.MethodPost, router.BasePath+"/todos/import"+query, export, status, &result)
+	if status == http.StatusCreated {
+		t.Cleanup(func() {
+			pool.Exec(context.Background(), `DELETE FROM todos WHERE id = $1`, result.Todo.ID)
+		})
+	}
+	return result
+}
+
+// exportedTodo returns the todo of an export, normalized, so two exports
+// compare equal when they hold the same todo
+func exportedTodo(t *testing.T, export []byte) []byte {
+	t.Helper()
+	var document struct {
+		Todo json.RawMessage `json:"todo"`
+	}
+	if err := json.Unmarshal(export, &document); err != nil {
+		t.Fatalf("decoding the export %s: %v", export, err)
+	}
+	todo, err := testsupport.Normalize(document.Todo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return todo
+}
+
+// Importing an export and exporting the new todo gives back the same
+// document, apart from its ids and timestamps, and a second import of the
+// same export returns the first todo unless forced
+func TestTodoExportImportRoundTrip(t *testing.T) {
+	pool := testsupport.UseDatabase(t)
+	ctx := context.Background()
+	todo := testsupport.NewTodoFactory(t, pool).
+		WithTitle("Move to staging").
+		WithDescription("Steps are in the **runbook**").
+		WithSubtasks(3).
+		WithCompletedSubtasks(1).
+		Create(ctx)
+	_, err := pool.Exec(ctx, `
+		UPDATE todos SET due_date = '2030-01-02 17:00:00+00', due_timezone = 'Europe/Berlin', story_points = 3, estimate_minutes = 90
+		WHERE id = $1
+	`, todo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := testsupport.NewServer(t, router.New(router.Options{}))
+	noFetch := false
+	server.JSON(http.MethodPost, todoPath(todo.ID)+"/links",
+		models.CreateTodoLinkRequest{URL: "https://example.com/runbook", Title: "Runbook", FetchMetadata: &noFetch}, http.StatusCreated, nil)
+
+	w := server.Get(todoPath(todo.ID) + "/export")
+	if w.Code != http.StatusOK {
+		t.Fatalf("export: %d %s", w.Code, w.Body)
+	}
+	export := w.Body.Bytes()
+
+	imported := importTodo(t, pool, server, "", export, http.StatusCreated)
+	if imported.Todo.ID == todo.ID || imported.AlreadyImported || imported.Subtasks != 3 || imported.Links != 1 {
+		t.Errorf("import result %+v, want a new todo with 3 subtasks and a link", imported)
+	}
+	w = server.Get(todoPath(imported.Todo.ID) + "/export")
+	if w.Code != http.StatusOK {
+		t.Fatalf("export of the imported todo: %d %s", w.Code, w.Body)
+	}
+	if got, want := exportedTodo(t, w.Body.Bytes()), exportedTodo(t, export); !bytes.Equal(got, want) {
+		t.Errorf("the imported todo exports differently:\ngot\n%s\nwant\n%s", got, want)
+	}
+
+	again := importTodo(t, pool, server, "", export, http.StatusOK)
+	if !again.AlreadyImported || again.Todo.ID != imported.Todo.ID {
+		t.Errorf("second import %+v, want todo %d already imported", again, imported.Todo.ID)
+	}
+	forced := importTodo(t, pool, server, "?force=true", export, http.StatusCreated)
+	if forced.AlreadyImported || forced.Todo.ID == imported.Todo.ID {
+		t.Errorf("forced import %+v, want another new todo", forced)
+	}
+	if got := server.Get(todoPath(forced.Todo.ID)); got.Code != http.StatusOK {
+		t.Errorf("GET the forced import: %d", got.Code)
+	}
+}
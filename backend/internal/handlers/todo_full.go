@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// fullTodoActivityLimit is how many of the latest events GET /todos/:id/full
+// embeds
+const fullTodoActivityLimit = 5
+
+// fullTodoSections are the sections of GET /todos/:id/full that exclude may
+// leave out
+var fullTodoSections = map[string]bool{"subtasks": true, "activity": true}
+
+// GetTodoFull godoc
+// @Summary      Get a todo with its card details
+// @Description  Get what a todo's detail card shows in one request: the todo as GET /todos/:id returns it (with links, references, tracked time, editing lock and allowed transitions), its subtasks with completion counts, and its number of activity events with the latest five. The todo, subtasks and activity are read in one batched round trip. Sections can be left out with exclude. render=html adds description_html as GET /todos/:id does.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int     true   "Todo ID"
+// @Param        exclude  query     string  false  "Comma-separated sections to leave out (subtasks, activity)"
+// @Param        render   query     string  false  "Also render the description as HTML"  Enums(html)
+// @Success      200      {object}  models.TodoFull
+// @Header       200      {string}  ETag  "Strong ETag of the resource"
+// @Success      304      "Not Modified"
+// @Failure      400      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      410      {object}  models.TodoGone
+// @Failure      500      {object}  map[string]string
+// @Router       /todos/{id}/full [get]
+func GetTodoFull(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return
+	}
+	exclude := map[string]bool{}
+	if value := c.Query("exclude"); value != "" {
+		for _, section := range strings.Split(value, ",") {
+			section = strings.TrimSpace(section)
+			if !fullTodoSections[section] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exclude. Must be a comma-separated list of: subtasks, activity"})
+				return
+			}
+			exclude[section] = true
+		}
+	}
+	render, err := parseRender(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	batch := &pgx.Batch{}
+	batch.Queue(`SELECT `+todoColumns+` FROM todos WHERE id = $1`, id)
+	if !exclude["subtasks"] {
+		batch.Queue(`
+			SELECT `+subtaskColumns+` FROM subtasks WHERE todo_id = $1 ORDER BY `+strings.Join(subtaskSortTerms["position"], ", "), id)
+	}
+	if !exclude["activity"] {
+		batch.Queue(`
+			SELECT id, todo_id, type, actor, field, old_value, new_value, subtask_id, subtask_title, created_at,
+				COUNT(*) OVER ()
+			FROM todo_events
+			WHERE todo_id = $1
+			ORDER BY created_at DESC, id DESC
+			LIMIT $2
+		`, id, fullTodoActivityLimit)
+	}
+
+	var full models.TodoFull
+	err = func() error {
+		results := db.Pool.SendBatch(ctx, batch)
+		defer results.Close()
+
+		if err := scanTodo(results.QueryRow(), &full.Todo); err != nil {
+			return err
+		}
+		if !exclude["subtasks"] {
+			rows, err := results.Query()
+			if err != nil {
+				return err
+			}
+			full.Subtasks = &models.TodoSubtasks{Items: []models.Subtask{}}
+			for rows.Next() {
+				var subtask models.Subtask
+				if err := scanSubtask(rows, &subtask); err != nil {
+					rows.Close()
+					return err
+				}
+				full.Subtasks.Total++
+				if subtask.Completed {
+					full.Subtasks.Completed++
+				}
+				full.Subtasks.Items = append(full.Subtasks.Items, subtask)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+		}
+		if !exclude["activity"] {
+			rows, err := results.Query()
+			if err != nil {
+				return err
+			}
+			full.Activity = &models.TodoActivitySummary{Latest: []models.TodoEvent{}}
+			for rows.Next() {
+				var event models.TodoEvent
+				if err := rows.Scan(&event.ID, &event.TodoID, &event.Type, &event.Actor, &event.Field, &event.OldValue, &event.NewValue, &event.SubtaskID, &event.SubtaskTitle, &event.CreatedAt, &full.Activity.Total); err != nil {
+					rows.Close()
+					return err
+				}
+				full.Activity.Latest = append(full.Activity.Latest, event)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	if errors.Is(err, pgx.ErrNoRows) {
+		respondTodoNotFound(c, id)
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching full todo: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch todo", "details": err.Error()})
+		return
+	}
+
+	if err := loadTodoDetails(ctx, &full.Todo); err != nil {
+		log.Printf("Error fetching todo details: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch todo", "details": err.Error()})
+		return
+	}
+	recordTodoView(currentUser(c), full.Todo.ID)
+	if render {
+		descriptionHTML := renderDescriptionHTML(full.Todo.Description)
+		full.Todo.DescriptionHTML = &descriptionHTML
+	}
+
+	respondResource(c, full)
+}
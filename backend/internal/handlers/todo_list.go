@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/sync/singleflight"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/store"
+)
+
+// todoListsCoalesced counts GET /todos requests answered by a query another
+// request had already started
+var todoListsCoalesced = expvar.NewInt("todo_lists_coalesced")
+
+// todoLists shares one execution among identical concurrent list queries
+var todoLists singleflight.Group
+
+// todoList is a page of todos with the number of todos matching its
+// filters, which is only counted when the page has a limit. Next is the
+// cursor of the page's last todo when the page was selected with
+// SelectCursor and more todos follow it.
+type todoList struct {
+	Todos []models.Todo
+	Total int64
+	Next  *store.TodoCursor
+}
+
+// todoListQueryTimeout bounds a shared list query. It runs without its
+// callers' cancellation, so without a deadline one slow query would hold
+// every waiter and a pool connection.
+const todoListQueryTimeout = 30 * time.Second
+
+// listTodos returns the todos selected by columns and params, scanned with
+// scan. Concurrent calls for the same query share one execution, keyed by
+// the built SQL and its arguments, and each caller gets its own copy of the
+// slice. Results only depend on the query, since nothing in it is scoped
+// to the requesting user; a user-scoped filter would have to become part
+// of params to keep callers apart.
+func listTodos(ctx context.Context, columns string, scan func(pgx.Row, *models.Todo) error, params store.ListTodosParams) (todoList, error) {
+	query := params
+	if params.SelectCursor {
+		// One more todo than the page tells whether another page follows
+		query.Limit++
+	}
+	sql, args := store.BuildListTodos(columns, query)
+	key := fmt.Sprintf("%s\x00%#v", sql, args)
+	return coalesceTodoList(ctx, key, func(ctx context.Context) (todoList, error) {
+		return queryTodoList(ctx, scan, params, sql, args)
+	})
+}
+
+// coalesceTodoList runs query once for concurrent calls with the same key.
+// The shared query runs without the callers' cancellation, so one caller
+// going away does not fail the others, but within todoListQueryTimeout; a
+// caller whose ctx ends stops waiting.
+func coalesceTodoList(ctx context.Context, key string, query func(context.Context) (todoList, error)) (todoList, error) {
+	ran := false
+	results := todoLists.DoChan(key, func() (interface{}, error) {
+		ran = true
+		queryCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), todoListQueryTimeout)
+		defer cancel()
+		return query(queryCtx)
+	})
+	select {
+	case <-ctx.Done():
+		return todoList{}, ctx.Err()
+	case result := <-results:
+		if !ran {
+			todoListsCoalesced.Add(1)
+		}
+		if result.Err != nil {
+			return todoList{}, result.Err
+		}
+		list := result.Val.(todoList)
+		list.Todos = slices.Clone(list.Todos)
+		return list, nil
+	}
+}
+
+// queryTodoList runs a list query built by store.BuildListTodos, counting
+// the matches first when the list is paged and loading matched subtasks
+// when subtasks are searched. With SelectCursor the query selects one todo
+// more than params.Limit, which is left out of the page.
+func queryTodoList(ctx context.Context, scan func(pgx.Row, *models.Todo) error, params store.ListTodosParams, sql string, args []interface{}) (todoList, error) {
+	var list todoList
+	if params.Limit > 0 {
+		var err error
+		if list.Total, err = store.CountTodos(ctx, db.Pool, params); err != nil {
+			return list, fmt.Errorf("failed to count todos: %w", err)
+		}
+	}
+
+	rows, err := db.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return list, fmt.Errorf("failed to query todos: %w", err)
+	}
+	defer rows.Close()
+
+	list.Todos = []models.Todo{} // Initialize as empty slice to ensure JSON serializes to [] not null
+	var last store.TodoCursor
+	for rows.Next() {
+		if params.SelectCursor && len(list.Todos) == params.Limit {
+			list.Next = &last
+			break
+		}
+		var todo models.Todo
+		var row pgx.Row = rows
+		var cursorRow *store.TodoCursorRow
+		if params.SelectCursor {
+			cursorRow = store.NewTodoCursorRow(rows, params)
+			row = cursorRow
+		}
+		if err := scan(row, &todo); err != nil {
+			return list, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		if cursorRow != nil {
+			last = cursorRow.Cursor(todo.ID)
+		}
+		list.Todos = append(list.Todos, todo)
+	}
+	if err := rows.Err(); err != nil {
+		return list, fmt.Errorf("failed to iterate todos: %w", err)
+	}
+
+	if params.SearchSubtasks {
+		if err := loadMatchedSubtasks(ctx, list.Todos, *params.Search); err != nil {
+			return list, fmt.Errorf("failed to fetch matched subtasks: %w", err)
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"flow-v1/backend/internal/models"
+)
+
+// Concurrent calls with the same key share one query and get their own
+// copies of its todos
+func TestCoalesceTodoList(t *testing.T) {
+	const callers = 20
+	var queries atomic.Int64
+	release := make(chan struct{})
+	query := func(ctx context.Context) (todoList, error) {
+		queries.Add(1)
+		<-release
+		return todoList{Todos: []models.Todo{{ID: 1, Title: "Write docs"}}, Total: 1}, nil
+	}
+
+	coalesced := todoListsCoalesced.Value()
+	var started, done sync.WaitGroup
+	lists := make([]todoList, callers)
+	for i := range lists {
+		started.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			started.Done()
+			var err error
+			if lists[i], err = coalesceTodoList(context.Background(), "key", query); err != nil {
+				t.Errorf("caller %d: %v", i, err)
+			}
+		}()
+	}
+	started.Wait()
+	// Give every caller time to join the query before it returns
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	done.Wait()
+
+	if n := queries.Load(); n != 1 {
+		t.Errorf("%d queries ran, want 1", n)
+	}
+	if n := todoListsCoalesced.Value() - coalesced; n != callers-1 {
+		t.Errorf("todo_lists_coalesced grew by %d, want %d", n, callers-1)
+	}
+	lists[0].Todos[0].Title = "Changed"
+	for i, list := range lists[1:] {
+		if list.Total != 1 || len(list.Todos) != 1 || list.Todos[0].Title != "Write docs" {
+			t.Errorf("caller %d got %+v", i+1, list)
+		}
+	}
+}
+
+// A caller going away stops waiting without cancelling the query the
+// others share, which still has a deadline
+func TestCoalesceTodoListCancel(t *testing.T) {
+	release := make(chan struct{})
+	queried := make(chan context.Context, 1)
+	query := func(ctx context.Context) (todoList, error) {
+		queried <- ctx
+		<-release
+		return todoList{Total: 3}, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	go func() {
+		_, err := coalesceTodoList(ctx, "cancel", query)
+		cancelled <- err
+	}()
+	queryCtx := <-queried
+	other := make(chan todoList)
+	go func() {
+		list, err := coalesceTodoList(context.Background(), "cancel", query)
+		if err != nil {
+			t.Errorf("the remaining caller failed: %v", err)
+		}
+		other <- list
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Errorf("the cancelled caller got %v, want context.Canceled", err)
+	}
+	if err := queryCtx.Err(); err != nil {
+		t.Errorf("the shared query was cancelled: %v", err)
+	}
+	deadline, ok := queryCtx.Deadline()
+	if !ok || time.Until(deadline) > todoListQueryTimeout {
+		t.Errorf("the shared query has deadline %v, %t, want one within %v", deadline, ok, todoListQueryTimeout)
+	}
+	close(release)
+	if list := <-other; list.Total != 3 {
+		t.Errorf("the remaining caller got %+v", list)
+	}
+}
+
+func TestCoalesceTodoListError(t *testing.T) {
+	failed := errors.New("failed to query todos")
+	_, err := coalesceTodoList(context.Background(), "error", func(context.Context) (todoList, error) {
+		return todoList{}, failed
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("got %v, want %v", err, failed)
+	}
+}
+
+// BenchmarkCoalesceTodoList compares the queries run for concurrent
+// identical list requests with and without coalescing, each query taking a
+// millisecond; queries/op is the share of requests that reached the
+// database
+func BenchmarkCoalesceTodoList(b *testing.B) {
+	for _, keys := range []int{1, 10} {
+		for _, coalesce := range []bool{false, true} {
+			name := "keys=" + strconv.Itoa(keys) + "/coalesced=" + strconv.FormatBool(coalesce)
+			b.Run(name, func(b *testing.B) {
+				var queries, next atomic.Int64
+				query := func(context.Context) (todoList, error) {
+					queries.Add(1)
+					time.Sleep(time.Millisecond)
+					return todoList{Todos: make([]models.Todo, 50)}, nil
+				}
+				b.SetParallelism(10)
+				b.RunParallel(func(pb *testing.PB) {
+					ctx := context.Background()
+					for pb.Next() {
+						key := "key" + strconv.FormatInt(next.Add(1)%int64(keys), 10)
+						if coalesce {
+							coalesceTodoList(ctx, key, query)
+						} else {
+							query(ctx)
+						}
+					}
+				})
+				b.ReportMetric(float64(queries.Load())/float64(b.N), "queries/op")
+			})
+		}
+	}
+}
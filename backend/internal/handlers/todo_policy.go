@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// todoPolicyColumns is the column list selected by every todo policy query;
+// scanTodoPolicy reads it back
+const todoPolicyColumns = `lock_done_todos, updated_at`
+
+// todoPolicyEntityID is the entity_id of the audit entries of the todo
+// policy, which is a single row
+const todoPolicyEntityID = 1
+
+// scanTodoPolicy scans a row selected with todoPolicyColumns
+func scanTodoPolicy(row pgx.Row) (models.TodoPolicy, error) {
+	var policy models.TodoPolicy
+	err := row.Scan(&policy.LockDoneTodos, &policy.UpdatedAt)
+	return policy, err
+}
+
+// GetTodoPolicy godoc
+// @Summary      Get the todo policy
+// @Description  Get the editing policies of todos
+// @Tags         settings
+// @Accept       json
+// @Produce      json
+// @Security     AdminToken
+// @Success      200  {object}  models.TodoPolicy
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /settings/todo-policy [get]
+func GetTodoPolicy(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	policy, err := scanTodoPolicy(db.Pool.QueryRow(c.Request.Context(), `
+		SELECT `+todoPolicyColumns+` FROM todo_policy
+	`))
+	if err != nil {
+		log.Printf("Error fetching todo policy: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch todo policy", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdateTodoPolicy godoc
+// @Summary      Update the todo policy
+// @Description  Change the editing policies of todos. With lock_done_todos, changes to the title, description, story points or subtasks of a done todo get a 409 unless the same request moves it out of done, for example with reopen=true. Other fields, status changes and notes stay open. The change applies to the next request and is recorded in the audit log. Requires the admin token, since turning lock_done_todos off lifts the lock.
+// @Tags         settings
+// @Accept       json
+// @Produce      json
+// @Security     AdminToken
+// @Param        policy  body      models.UpdateTodoPolicyRequest  true  "Todo policy"
+// @Success      200     {object}  models.TodoPolicy
+// @Failure      400     {object}  map[string]string
+// @Failure      401     {object}  map[string]string
+// @Failure      403     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /settings/todo-policy [put]
+func UpdateTodoPolicy(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.UpdateTodoPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var policy models.TodoPolicy
+	ctx := c.Request.Context()
+	err := db.WithTx(ctx, func(tx pgx.Tx) error {
+		var before *models.TodoPolicy
+		existing, err := scanTodoPolicy(tx.QueryRow(ctx, `SELECT `+todoPolicyColumns+` FROM todo_policy FOR UPDATE`))
+		if err == nil {
+			before = &existing
+		} else if err != pgx.ErrNoRows {
+			return err
+		}
+
+		policy, err = scanTodoPolicy(tx.QueryRow(ctx, `
+			INSERT INTO todo_policy (id, lock_done_todos, updated_at)
+			VALUES (TRUE, COALESCE($1, FALSE), NOW())
+			ON CONFLICT (id) DO UPDATE SET
+				lock_done_todos = COALESCE($1, todo_policy.lock_done_todos),
+				updated_at = NOW()
+			RETURNING `+todoPolicyColumns+`
+		`, req.LockDoneTodos))
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return recordAudit(ctx, tx, c, models.AuditActionCreate, models.AuditEntityTodoPolicy, todoPolicyEntityID, nil, policy)
+		}
+		return recordAudit(ctx, tx, c, models.AuditActionUpdate, models.AuditEntityTodoPolicy, todoPolicyEntityID, before, policy)
+	})
+	if err != nil {
+		log.Printf("Error updating todo policy: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update todo policy", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
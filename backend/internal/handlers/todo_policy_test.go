@@ -0,0 +1,57 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/router"
+	"flow-v1/backend/internal/testsupport"
+)
+
+// Only the admin can change the todo policy, and each change is in the audit
+// log with the policy before and after it
+func TestUpdateTodoPolicy(t *testing.T) {
+	pool := testsupport.UseDatabase(t)
+	ctx := context.Background()
+	var locked bool
+	if err := pool.QueryRow(ctx, `SELECT lock_done_todos FROM todo_policy`).Scan(&locked); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pool.Exec(context.Background(), `UPDATE todo_policy SET lock_done_todos = $1`, locked) })
+	server := testsupport.NewServer(t, router.New(router.Options{}))
+	path := router.BasePath + "/settings/todo-policy"
+	body := map[string]bool{"lock_done_todos": !locked}
+
+	if w := server.Do(http.MethodPut, path, body); w.Code != http.StatusUnauthorized {
+		t.Fatalf("PUT without the admin token: %d %s, want 401", w.Code, w.Body)
+	}
+	var unchanged bool
+	if err := pool.QueryRow(ctx, `SELECT lock_done_todos FROM todo_policy`).Scan(&unchanged); err != nil || unchanged != locked {
+		t.Fatalf("lock_done_todos %t, %v after a refused PUT, want %t", unchanged, err, locked)
+	}
+
+	var policy models.TodoPolicy
+	server.Admin().JSON(http.MethodPut, path, body, http.StatusOK, &policy)
+	if policy.LockDoneTodos == locked {
+		t.Errorf("lock_done_todos %t after the PUT, want %t", policy.LockDoneTodos, !locked)
+	}
+
+	var entry models.AuditEntry
+	if err := pool.QueryRow(ctx, `
+		SELECT action, diff FROM audit_log WHERE entity_type = $1 ORDER BY id DESC LIMIT 1
+	`, models.AuditEntityTodoPolicy).Scan(&entry.Action, &entry.Diff); err != nil {
+		t.Fatalf("reading the audit entry: %v", err)
+	}
+	var diff map[string]struct {
+		Before, After bool
+	}
+	if err := json.Unmarshal(entry.Diff, &diff); err != nil {
+		t.Fatal(err)
+	}
+	if change, ok := diff["lock_done_todos"]; entry.Action != models.AuditActionUpdate || !ok || change.Before != locked || change.After != !locked {
+		t.Errorf("audit entry %s %s, want an update of lock_done_todos from %t to %t", entry.Action, entry.Diff, locked, !locked)
+	}
+}
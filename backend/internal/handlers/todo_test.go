@@ -0,0 +1,128 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/router"
+	"flow-v1/backend/internal/testsupport"
+)
+
+// These tests go through router.New, as requests to the server do, so they
+// live outside the handlers package, which the router imports.
+
+// todoPath returns the API path of a todo
+func todoPath(id int64) string {
+	return router.BasePath + "/todos/" + strconv.FormatInt(id, 10)
+}
+
+// A dry run reports the change it would make and leaves no trace of it
+func TestSetTodoDueDatesDryRun(t *testing.T) {
+	pool := testsupport.UseDatabase(t)
+	todo := testsupport.NewTodoFactory(t, pool).WithTitle("Dry run").Create(context.Background())
+	server := testsupport.NewServer(t, router.New(router.Options{}))
+	before := testsupport.SideEffects(t, pool, todo.ID)
+
+	var result models.SetDueDatesResult
+	server.JSON(http.MethodPost, router.BasePath+"/todos/bulk/due-dates?dry_run=true",
+		`{"ids": [`+strconv.FormatInt(todo.ID, 10)+`], "due_date": "2030-01-02"}`, http.StatusOK, &result)
+
+	if !result.DryRun || len(result.Todos) != 1 || result.Todos[0].ID != todo.ID || result.Todos[0].DueDate != "2030-01-02" {
+		t.Errorf("result %+v, want the would-be due date marked as a dry run", result)
+	}
+	if after := testsupport.SideEffects(t, pool, todo.ID); after != before {
+		t.Errorf("the dry run changed something:\nbefore %s\nafter  %s", before, after)
+	}
+}
+
+// Subtasks are listed in the order they were created, completed ones with
+// when they were
+func TestGetSubtasks(t *testing.T) {
+	pool := testsupport.UseDatabase(t)
+	todo := testsupport.NewTodoFactory(t, pool).WithSubtasks(3).WithCompletedSubtasks(1).Create(context.Background())
+	server := testsupport.NewServer(t, router.New(router.Options{}))
+
+	w := server.Get(todoPath(todo.ID) + "/subtasks")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	testsupport.AssertGolden(t, "subtasks", w.Body.Bytes())
+
+	var open []models.Subtask
+	server.JSON(http.MethodGet, todoPath(todo.ID)+"/subtasks?completed=false", nil, http.StatusOK, &open)
+	if len(open) != 2 || open[0].ID != todo.Subtasks[1].ID || open[1].ID != todo.Subtasks[2].ID {
+		t.Errorf("open subtasks %+v, want the last two", open)
+	}
+}
+
+// Every list endpoint wraps exactly the items it returns by default
+func TestListEnvelopes(t *testing.T) {
+	pool := testsupport.UseDatabase(t)
+	todo := testsupport.NewTodoFactory(t, pool).WithTitle("Envelope").WithSubtasks(2).Create(context.Background())
+	server := testsupport.NewServer(t, router.New(router.Options{})).Admin()
+
+	path := todoPath(todo.ID)
+	lists := []string{
+		"/todos?limit=1",
+		"/todos/stale?limit=1",
+		"/todos/recently-viewed",
+		"/todos/similar?title=Envelope",
+		"/todos/suggest?q=Envelope",
+		path + "/subtasks",
+		path + "/activity",
+		path + "/revisions",
+		path + "/time-entries",
+		path + "/links",
+		path + "/reminders",
+		path + "/backlinks",
+		path + "/estimate-history",
+		"/epics",
+		"/sprints",
+		"/statuses",
+		"/priorities",
+		"/custom-fields",
+		"/checklist-templates",
+		"/embeds",
+		"/audit?limit=1",
+		"/admin/flags",
+		"/admin/credentials",
+	}
+	for _, list := range lists {
+		if !strings.HasPrefix(list, router.BasePath) {
+			list = router.BasePath + list
+		}
+		separator := "?"
+		if strings.Contains(list, "?") {
+			separator = "&"
+		}
+		w := server.Get(list)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: %d %s", list, w.Code, w.Body)
+		}
+		bare := w.Body.Bytes()
+		var envelope struct {
+			Data json.RawMessage `json:"data"`
+			Meta models.ListMeta `json:"meta"`
+		}
+		server.JSON(http.MethodGet, list+separator+"envelope=true", nil, http.StatusOK, &envelope)
+		if string(bare) == "null" {
+			bare = []byte("[]")
+		}
+		if !bytes.Equal(envelope.Data, bare) {
+			t.Errorf("GET %s: the envelope holds %s, want the default body %s", list, envelope.Data, bare)
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(bare, &items); err != nil {
+			t.Fatalf("GET %s: the default body is not an array: %s", list, bare)
+		}
+		if envelope.Meta.Limit == nil && envelope.Meta.Total != int64(len(items)) {
+			t.Errorf("GET %s: total %d for %d unpaged items", list, envelope.Meta.Total, len(items))
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// todoView is a todo fetched by a user, waiting to be written
+type todoView struct {
+	user   string
+	todoID int64
+}
+
+// pendingTodoViews buffers views between flushes. When it is full new views
+// are dropped; losing a view only makes the recently viewed list less fresh.
+var pendingTodoViews = make(chan todoView, 1000)
+
+// todoViewDebounce is how long repeated views of a todo are not written
+const todoViewDebounce = time.Minute
+
+// recordTodoView queues a view for FlushTodoViews without blocking the request
+func recordTodoView(user string, todoID int64) {
+	select {
+	case pendingTodoViews <- todoView{user: user, todoID: todoID}:
+	default:
+		log.Printf("Dropping view of todo %d: view buffer is full", todoID)
+	}
+}
+
+// FlushTodoViews writes queued todo views in one statement, stamped with the
+// flush time. Views of a todo within todoViewDebounce of the stored view are
+// skipped.
+func FlushTodoViews(ctx context.Context) error {
+	views := map[todoView]bool{}
+drain:
+	for {
+		select {
+		case view := <-pendingTodoViews:
+			views[view] = true
+		default:
+			break drain
+		}
+	}
+	if len(views) == 0 {
+		return nil
+	}
+
+	users := make([]string, 0, len(views))
+	todoIDs := make([]int64, 0, len(views))
+	for view := range views {
+		users = append(users, view.user)
+		todoIDs = append(todoIDs, view.todoID)
+	}
+
+	// Todos deleted since they were viewed are skipped by the join
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO todo_views (user_key, todo_id, viewed_at)
+		SELECT v.user_key, v.todo_id, NOW()
+		FROM unnest($1::text[], $2::bigint[]) AS v(user_key, todo_id)
+		JOIN todos ON todos.id = v.todo_id
+		ON CONFLICT (user_key, todo_id) DO UPDATE SET viewed_at = EXCLUDED.viewed_at
+		WHERE todo_views.viewed_at < EXCLUDED.viewed_at - make_interval(secs => $3)
+	`, users, todoIDs, todoViewDebounce.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to record todo views: %w", err)
+	}
+	return nil
+}
+
+// GetRecentlyViewedTodos godoc
+// @Summary      List recently viewed todos
+// @Description  Get the todos the current user opened most recently, newest first. Views are recorded in the background, so a todo may take a moment to appear.
+// @Tags         todos
+// @Accept       json
+// @Produce      json
+// @Param        limit     query     int   false  "Number of todos (max 50)"  default(10)
+// @Param        envelope  query     bool  false  "Wrap the list as {\"data\": [...], \"meta\": {total, limit, offset, next_cursor}} (models.ListEnvelope) instead of a bare array"  default(false)
+// @Success      200  {array}   models.Todo  "The list, or with envelope=true a models.ListEnvelope holding it as data"
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /todos/recently-viewed [get]
+func GetRecentlyViewedTodos(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	envelope, err := parseEnvelope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 || limit > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit. Must be between 1 and 50"})
+		return
+	}
+
+	rows, err := db.Pool.Query(c.Request.Context(), `
+		SELECT `+todoColumns+`
+		FROM todo_views
+		JOIN todos ON todos.id = todo_views.todo_id
+		WHERE todo_views.user_key = $1
+		ORDER BY todo_views.viewed_at DESC
+		LIMIT $2
+	`, currentUser(c), limit)
+	if err != nil {
+		log.Printf("Error querying recently viewed todos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recently viewed todos", "details": err.Error()})
+		return
+	}
+	todos, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Todo, error) {
+		var todo models.Todo
+		err := scanTodo(row, &todo)
+		return todo, err
+	})
+	if err != nil {
+		log.Printf("Error scanning recently viewed todos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recently viewed todos", "details": err.Error()})
+		return
+	}
+
+	respondList(c, envelope, todos, int64(len(todos)), 0, 0)
+}
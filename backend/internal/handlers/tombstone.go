@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// respondTodoNotFound answers a request for a todo that does not exist: 410
+// with its deletion time when its tombstone is still kept, so clients can
+// drop it from their cache, or 404 when it never existed or its tombstone
+// was purged
+func respondTodoNotFound(c *gin.Context, id int64) {
+	var deletedAt time.Time
+	err := db.Pool.QueryRow(c.Request.Context(), `
+		SELECT deleted_at FROM todo_tombstones WHERE todo_id = $1
+	`, id).Scan(&deletedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching todo tombstone: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch todo", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusGone, models.TodoGone{Error: "Todo was deleted", DeletedAt: deletedAt})
+}
@@ -0,0 +1,62 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"flow-v1/backend/internal/jobs"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/router"
+	"flow-v1/backend/internal/testsupport"
+)
+
+// A deleted todo answers 410 with when it was deleted, on both of its GET
+// routes, until its tombstone is purged; then it answers 404 like an ID that
+// never existed
+func TestDeletedTodoGone(t *testing.T) {
+	pool := testsupport.UseDatabase(t)
+	ctx := context.Background()
+	todo := testsupport.NewTodoFactory(t, pool).WithTitle("Deleted").Create(ctx)
+	t.Cleanup(func() { pool.Exec(context.Background(), `DELETE FROM todo_tombstones WHERE todo_id = $1`, todo.ID) })
+	server := testsupport.NewServer(t, router.New(router.Options{}))
+
+	before := time.Now()
+	if w := server.Do(http.MethodDelete, todoPath(todo.ID), nil); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE: %d %s", w.Code, w.Body)
+	}
+	for _, path := range []string{todoPath(todo.ID), todoPath(todo.ID) + "/full"} {
+		w := server.Get(path)
+		if w.Code != http.StatusGone {
+			t.Fatalf("GET %s: %d %s, want 410", path, w.Code, w.Body)
+		}
+		var gone models.TodoGone
+		if err := json.Unmarshal(w.Body.Bytes(), &gone); err != nil {
+			t.Fatal(err)
+		}
+		if gone.Error == "" || gone.DeletedAt.Before(before.Add(-time.Minute)) || gone.DeletedAt.After(time.Now().Add(time.Minute)) {
+			t.Errorf("GET %s: %+v, want the time of the DELETE", path, gone)
+		}
+	}
+
+	if w := server.Get(todoPath(2147483647)); w.Code != http.StatusNotFound {
+		t.Errorf("GET of an ID that never existed: %d, want 404", w.Code)
+	}
+
+	if _, err := pool.Exec(ctx, `UPDATE todo_tombstones SET deleted_at = NOW() - INTERVAL '91 days' WHERE todo_id = $1`, todo.ID); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TOMBSTONE_RETENTION_DAYS", "90")
+	job, err := jobs.TombstoneRetention()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := job.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if w := server.Get(todoPath(todo.ID)); w.Code != http.StatusNotFound {
+		t.Errorf("GET after the tombstone was purged: %d %s, want 404", w.Code, w.Body)
+	}
+}
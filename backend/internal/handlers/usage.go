@@ -0,0 +1,418 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// maxWorkspaceLength matches api_usage.workspace; longer names are cut on a
+// rune boundary
+const maxWorkspaceLength = 100
+
+// defaultWorkspace meters requests that name no workspace
+const defaultWorkspace = "default"
+
+// usageEventsDropped counts metered requests lost because the buffer was
+// full or a flush failed
+var usageEventsDropped = expvar.NewInt("usage_events_dropped")
+
+// UsageConfig configures UsageMetering
+type UsageConfig struct {
+	// MonthlyQuota caps each workspace's requests per UTC month; 0 means none
+	MonthlyQuota int64
+	// Quotas overrides MonthlyQuota for the named workspaces
+	Quotas map[string]int64
+}
+
+// quota returns a workspace's monthly request quota, or 0 for none
+func (cfg UsageConfig) quota(workspace string) int64 {
+	if quota, ok := cfg.Quotas[workspace]; ok {
+		return quota
+	}
+	return cfg.MonthlyQuota
+}
+
+// UsageConfigFromEnv reads USAGE_METERING, which turns metering on,
+// USAGE_MONTHLY_QUOTA and USAGE_QUOTAS (comma-separated workspace=quota
+// pairs).
+// enabled is false unless USAGE_METERING is true.
+func UsageConfigFromEnv() (cfg UsageConfig, enabled bool, err error) {
+	if value := os.Getenv("USAGE_METERING"); value != "" {
+		if enabled, err = strconv.ParseBool(value); err != nil {
+			return cfg, false, fmt.Errorf("invalid USAGE_METERING: %q", value)
+		}
+	}
+	if value := os.Getenv("USAGE_MONTHLY_QUOTA"); value != "" {
+		if cfg.MonthlyQuota, err = strconv.ParseInt(value, 10, 64); err != nil || cfg.MonthlyQuota < 0 {
+			return cfg, false, fmt.Errorf("invalid USAGE_MONTHLY_QUOTA: %q", value)
+		}
+	}
+	if value := os.Getenv("USAGE_QUOTAS"); value != "" {
+		cfg.Quotas = map[string]int64{}
+		for _, pair := range strings.Split(value, ",") {
+			workspace, quota, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			limit, err := strconv.ParseInt(quota, 10, 64)
+			if !ok || workspace == "" || err != nil || limit < 0 {
+				return cfg, false, fmt.Errorf("invalid USAGE_QUOTAS entry: %q", pair)
+			}
+			cfg.Quotas[workspace] = limit
+		}
+	}
+	return cfg, enabled, nil
+}
+
+// usageKey identifies an api_usage row
+type usageKey struct {
+	day       string
+	workspace string
+	token     string
+}
+
+// usageEvent is a metered request waiting to be written
+type usageEvent struct {
+	key           usageKey
+	requestBytes  int64
+	responseBytes int64
+}
+
+// usageQuotas is the quota configuration passed to UsageMetering
+var usageQuotas UsageConfig
+
+// pendingUsage buffers metered requests between flushes. When it is full new
+// events are dropped and counted in usageEventsDropped, so a slow database
+// never holds up a request.
+var pendingUsage = make(chan usageEvent, 10000)
+
+// monthUsage is each workspace's request count for the current UTC month, kept
+// as the stored totals as of the last flush and the requests queued since,
+// which are not in them yet
+var monthUsage = struct {
+	sync.Mutex
+	month   string
+	stored  map[string]int64
+	pending map[string]int64
+}{stored: map[string]int64{}, pending: map[string]int64{}}
+
+// resetMonthUsage starts counting month if the counts are for another one.
+// The caller holds monthUsage.
+func resetMonthUsage(month string) {
+	if monthUsage.month != month {
+		monthUsage.month = month
+		monthUsage.stored = map[string]int64{}
+		monthUsage.pending = map[string]int64{}
+	}
+}
+
+// addMonthUsage counts n queued requests towards a workspace's month and
+// returns the workspace's count
+func addMonthUsage(month, workspace string, n int64) int64 {
+	monthUsage.Lock()
+	defer monthUsage.Unlock()
+	resetMonthUsage(month)
+	if n != 0 {
+		monthUsage.pending[workspace] += n
+	}
+	return monthUsage.stored[workspace] + monthUsage.pending[workspace]
+}
+
+// settleMonthUsage stops counting flushed requests as pending. Written ones
+// are then counted in stored, the totals loaded after they were written, or
+// added to the previous totals when stored is nil. Requests queued while the
+// flush ran stay pending.
+func settleMonthUsage(month string, flushed map[string]int64, written bool, stored map[string]int64) {
+	monthUsage.Lock()
+	defer monthUsage.Unlock()
+	resetMonthUsage(month)
+	for workspace, n := range flushed {
+		if monthUsage.pending[workspace] -= n; monthUsage.pending[workspace] <= 0 {
+			delete(monthUsage.pending, workspace)
+		}
+		if written && stored == nil {
+			monthUsage.stored[workspace] += n
+		}
+	}
+	if stored != nil {
+		monthUsage.stored = stored
+	}
+}
+
+// errInvalidWorkspace is returned by requestWorkspace for an X-Workspace
+// header that is not valid UTF-8, which api_usage cannot store
+var errInvalidWorkspace = errors.New("Invalid X-Workspace header. It must be valid UTF-8")
+
+// requestWorkspace returns the workspace a request is metered and limited
+// under, named by its X-Workspace header. The API has no accounts, so the
+// header is taken on trust: usage by workspace is advisory, and a client
+// can escape its quota by naming another workspace. Quotas stop runaway
+// scripts, not callers set on going over them.
+func requestWorkspace(c *gin.Context) (string, error) {
+	workspace := strings.TrimSpace(c.GetHeader("X-Workspace"))
+	if workspace == "" {
+		return defaultWorkspace, nil
+	}
+	if !utf8.ValidString(workspace) {
+		return "", errInvalidWorkspace
+	}
+	if utf8.RuneCountInString(workspace) > maxWorkspaceLength {
+		workspace = string([]rune(workspace)[:maxWorkspaceLength])
+	}
+	return workspace, nil
+}
+
+// requestToken returns a fingerprint of the request's API key, so usage can
+// be told apart by key without storing it, or "" when it has none. The admin
+// token is the only key the API checks, so other bearer tokens, which any
+// client can make up, are not recorded.
+func requestToken(c *gin.Context) string {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	admin := os.Getenv("ADMIN_TOKEN")
+	if !ok || token == "" || admin == "" || subtle.ConstantTimeCompare([]byte(token), []byte(admin)) != 1 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:6])
+}
+
+// UsageMetering counts each request and its request and response bytes
+// against the caller's workspace and API key for FlushUsage to write. Once a
+// workspace has used its monthly quota, writes get a 429 with the code
+// quota_exceeded until the next UTC month; reads stay allowed. Health and
+// status checks are not metered. The quotas are also what GetUsage reports.
+func UsageMetering(cfg UsageConfig) gin.HandlerFunc {
+	usageQuotas = cfg
+	return func(c *gin.Context) {
+		if c.Request.URL.Path == "/health" || c.Request.URL.Path == "/status" {
+			c.Next()
+			return
+		}
+		now := time.Now().UTC()
+		month := now.Format("2006-01")
+		workspace, err := requestWorkspace(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if quota := cfg.quota(workspace); quota > 0 && !readMethods[c.Request.Method] && addMonthUsage(month, workspace, 0) >= quota {
+			nextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			c.Header("Retry-After", strconv.Itoa(int(nextMonth.Sub(now).Seconds())+1))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": fmt.Sprintf("Workspace %s has used its monthly quota of %d requests", workspace, quota),
+				"code":  "quota_exceeded",
+			})
+			return
+		}
+
+		c.Next()
+
+		event := usageEvent{key: usageKey{day: now.Format(models.DateLayout), workspace: workspace, token: requestToken(c)}}
+		if c.Request.ContentLength > 0 {
+			event.requestBytes = c.Request.ContentLength
+		}
+		if size := c.Writer.Size(); size > 0 {
+			event.responseBytes = int64(size)
+		}
+		select {
+		case pendingUsage <- event:
+			addMonthUsage(month, workspace, 1)
+		default:
+			usageEventsDropped.Add(1)
+		}
+	}
+}
+
+// usageTotals are the counters of one api_usage row
+type usageTotals struct {
+	requests      int64
+	requestBytes  int64
+	responseBytes int64
+}
+
+// FlushUsage adds the queued requests to api_usage in one statement, then
+// reloads each workspace's month totals so quotas also see requests served by
+// other instances. Events that cannot be written are dropped and counted, so
+// a crash or an unreachable database loses at most one flush interval.
+func FlushUsage(ctx context.Context) error {
+	totals := map[usageKey]*usageTotals{}
+	flushed := map[string]int64{}
+	var count int64
+drain:
+	for {
+		select {
+		case event := <-pendingUsage:
+			count++
+			flushed[event.key.workspace]++
+			row := totals[event.key]
+			if row == nil {
+				row = &usageTotals{}
+				totals[event.key] = row
+			}
+			row.requests++
+			row.requestBytes += event.requestBytes
+			row.responseBytes += event.responseBytes
+		default:
+			break drain
+		}
+	}
+
+	now := time.Now().UTC()
+	month := now.Format("2006-01")
+	if len(totals) > 0 {
+		var days, workspaces, tokens []string
+		var requests, requestBytes, responseBytes []int64
+		for key, row := range totals {
+			days = append(days, key.day)
+			workspaces = append(workspaces, key.workspace)
+			tokens = append(tokens, key.token)
+			requests = append(requests, row.requests)
+			requestBytes = append(requestBytes, row.requestBytes)
+			responseBytes = append(responseBytes, row.responseBytes)
+		}
+		_, err := db.Pool.Exec(ctx, `
+			INSERT INTO api_usage (day, workspace, token, requests, request_bytes, response_bytes)
+			SELECT * FROM unnest($1::date[], $2::text[], $3::text[], $4::bigint[], $5::bigint[], $6::bigint[])
+			ON CONFLICT (day, workspace, token) DO UPDATE SET
+				requests = api_usage.requests + EXCLUDED.requests,
+				request_bytes = api_usage.request_bytes + EXCLUDED.request_bytes,
+				response_bytes = api_usage.response_bytes + EXCLUDED.response_bytes
+		`, days, workspaces, tokens, requests, requestBytes, responseBytes)
+		if err != nil {
+			usageEventsDropped.Add(count)
+			settleMonthUsage(month, flushed, false, nil)
+			return fmt.Errorf("failed to record usage: %w", err)
+		}
+	}
+
+	stored, err := loadMonthUsage(ctx, now)
+	settleMonthUsage(month, flushed, true, stored)
+	return err
+}
+
+// loadMonthUsage sums each workspace's stored requests for the UTC month of now
+func loadMonthUsage(ctx context.Context, now time.Time) (map[string]int64, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT workspace, SUM(requests) FROM api_usage WHERE day >= $1 GROUP BY workspace
+	`, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load month usage: %w", err)
+	}
+	defer rows.Close()
+	stored := map[string]int64{}
+	for rows.Next() {
+		var workspace string
+		var requests int64
+		if err := rows.Scan(&workspace, &requests); err != nil {
+			return nil, fmt.Errorf("failed to load month usage: %w", err)
+		}
+		stored[workspace] = requests
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load month usage: %w", err)
+	}
+	return stored, nil
+}
+
+// GetUsage godoc
+// @Summary      Get API usage
+// @Description  Get the requests and bytes each workspace sent and received per day and API key, between two UTC dates inclusive, with each workspace's requests this month and its quota. Workspaces are named by the client's X-Workspace header, so the report is advisory. Usage is written every few seconds, so the latest requests may be missing. Requires the admin token.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     AdminToken
+// @Param        workspace  query     string  false  "Only this workspace"
+// @Param        from       query     string  false  "First day, YYYY-MM-DD (default: 30 days ago)"
+// @Param        to         query     string  false  "Last day, YYYY-MM-DD (default: today)"
+// @Success      200  {object}  models.UsageReport
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/usage [get]
+func GetUsage(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from, err := time.Parse(models.DateLayout, c.DefaultQuery("from", today.AddDate(0, 0, -30).Format(models.DateLayout)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from. Use YYYY-MM-DD"})
+		return
+	}
+	to, err := time.Parse(models.DateLayout, c.DefaultQuery("to", today.Format(models.DateLayout)))
+	if err != nil || to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to. Use YYYY-MM-DD, on or after from"})
+		return
+	}
+
+	condition := `day BETWEEN $1 AND $2`
+	args := []interface{}{from, to}
+	if workspace := c.Query("workspace"); workspace != "" {
+		condition += ` AND workspace = $3`
+		args = append(args, workspace)
+	}
+	rows, err := db.Pool.Query(c.Request.Context(), `
+		SELECT workspace, to_char(day, 'YYYY-MM-DD'), token, requests, request_bytes, response_bytes
+		FROM api_usage
+		WHERE `+condition+`
+		ORDER BY workspace, day, token
+	`, args...)
+	if err != nil {
+		log.Printf("Error querying usage: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch usage", "details": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	report := models.UsageReport{From: from.Format(models.DateLayout), To: to.Format(models.DateLayout), Workspaces: []models.WorkspaceUsage{}}
+	for rows.Next() {
+		var workspace string
+		var day models.UsageDay
+		if err := rows.Scan(&workspace, &day.Date, &day.Token, &day.Requests, &day.RequestBytes, &day.ResponseBytes); err != nil {
+			log.Printf("Error scanning usage: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan usage", "details": err.Error()})
+			return
+		}
+		if n := len(report.Workspaces); n == 0 || report.Workspaces[n-1].Workspace != workspace {
+			report.Workspaces = append(report.Workspaces, models.WorkspaceUsage{Workspace: workspace, Days: []models.UsageDay{}})
+		}
+		current := &report.Workspaces[len(report.Workspaces)-1]
+		current.Days = append(current.Days, day)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Error iterating usage: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating usage", "details": err.Error()})
+		return
+	}
+
+	month := time.Now().UTC().Format("2006-01")
+	for i := range report.Workspaces {
+		usage := &report.Workspaces[i]
+		usage.MonthRequests = addMonthUsage(month, usage.Workspace, 0)
+		if quota := usageQuotas.quota(usage.Workspace); quota > 0 {
+			usage.MonthlyQuota = &quota
+		}
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resetUsage empties the usage queue and month counts for a test
+func resetUsage(t *testing.T) {
+	t.Helper()
+	empty := func() {
+		for len(pendingUsage) > 0 {
+			<-pendingUsage
+		}
+		monthUsage.Lock()
+		monthUsage.month = ""
+		monthUsage.stored = map[string]int64{}
+		monthUsage.pending = map[string]int64{}
+		monthUsage.Unlock()
+	}
+	empty()
+	t.Cleanup(empty)
+}
+
+// Requests queued while a flush runs are still counted after it settles
+func TestSettleMonthUsage(t *testing.T) {
+	resetUsage(t)
+	const month = "2025-03"
+	addMonthUsage(month, "team-a", 3)
+	flushed := map[string]int64{"team-a": 3}
+	addMonthUsage(month, "team-a", 2) // queued during the flush
+
+	settleMonthUsage(month, flushed, true, map[string]int64{"team-a": 103})
+	if got := addMonthUsage(month, "team-a", 0); got != 105 {
+		t.Errorf("after a flush: %d requests, want 105", got)
+	}
+
+	// The stored totals could not be reloaded: the written requests move to
+	// them instead
+	settleMonthUsage(month, map[string]int64{"team-a": 2}, true, nil)
+	if got := addMonthUsage(month, "team-a", 0); got != 105 {
+		t.Errorf("after a flush without a reload: %d requests, want 105", got)
+	}
+
+	// Requests that could not be written are dropped
+	addMonthUsage(month, "team-a", 4)
+	settleMonthUsage(month, map[string]int64{"team-a": 4}, false, nil)
+	if got := addMonthUsage(month, "team-a", 0); got != 105 {
+		t.Errorf("after a failed flush: %d requests, want 105", got)
+	}
+
+	if got := addMonthUsage("2025-04", "team-a", 1); got != 1 {
+		t.Errorf("in a new month: %d requests, want 1", got)
+	}
+}
+
+// The quota is per workspace, counted under default without the header,
+// and the bearer token does not matter unless it is the admin token
+func TestUsageMeteringQuota(t *testing.T) {
+	resetUsage(t)
+	t.Setenv("ADMIN_TOKEN", "")
+	engine := gin.New()
+	engine.Use(UsageMetering(UsageConfig{MonthlyQuota: 2, Quotas: map[string]int64{"team-b": 0}}))
+	engine.POST("/todos", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	engine.GET("/todos", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i, tt := range []struct {
+		method    string
+		workspace string
+		want      int
+	}{
+		{http.MethodPost, "team-a", http.StatusCreated},
+		{http.MethodPost, " team-a ", http.StatusCreated},
+		{http.MethodPost, "team-a", http.StatusTooManyRequests},
+		{http.MethodGet, "team-a", http.StatusOK},
+		{http.MethodPost, "", http.StatusCreated},
+		{http.MethodPost, "team-b", http.StatusCreated},
+		{http.MethodPost, "team-b", http.StatusCreated},
+		{http.MethodPost, "team-b", http.StatusCreated},
+		{http.MethodPost, "team-\xff", http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(tt.method, "/todos", nil)
+		if tt.workspace != "" {
+			req.Header.Set("X-Workspace", tt.workspace)
+		}
+		req.Header.Set("Authorization", "Bearer made-up-"+strconv.Itoa(i))
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%d: %s as %q: status %d, want %d", i, tt.method, tt.workspace, w.Code, tt.want)
+		}
+	}
+
+	counts := map[string]int{}
+	for len(pendingUsage) > 0 {
+		event := <-pendingUsage
+		counts[event.key.workspace]++
+		if event.key.token != "" {
+			t.Errorf("metered under %+v, want no token", event.key)
+		}
+	}
+	if counts["team-a"] != 3 || counts[defaultWorkspace] != 1 || counts["team-b"] != 3 || len(counts) != 3 {
+		t.Errorf("metered %v, want 3 for team-a, 1 for default and 3 for team-b", counts)
+	}
+}
+
+// Long workspace names are cut on a rune boundary to fit api_usage
+func TestRequestWorkspaceLength(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set("X-Workspace", strings.Repeat("é", maxWorkspaceLength+1))
+	workspace, err := requestWorkspace(c)
+	if err != nil || workspace != strings.Repeat("é", maxWorkspaceLength) {
+		t.Errorf("requestWorkspace = %q, %v, want %d runes", workspace, err, maxWorkspaceLength)
+	}
+}
+
+func TestRequestToken(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "admin-token")
+	for header, want := range map[string]bool{
+		"":                   false,
+		"Bearer ":            false,
+		"Bearer made-up":     false,
+		"admin-token":        false,
+		"Bearer admin-token": true,
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set("Authorization", header)
+		if got := requestToken(c); (got != "") != want || (want && len(got) != 12) {
+			t.Errorf("requestToken(%q) = %q, want a fingerprint: %t", header, got, want)
+		}
+	}
+}
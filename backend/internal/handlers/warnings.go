@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/models"
+)
+
+// warningsKey is the gin context key holding a request's warnings
+const warningsKey = "warnings"
+
+// addWarning records a warning for the response to c. It does nothing when
+// c is nil, so code shared with background jobs can call it freely.
+func addWarning(c *gin.Context, code, message string, relatedIDs ...int64) {
+	if c == nil {
+		return
+	}
+	warnings := requestWarnings(c)
+	c.Set(warningsKey, append(warnings, models.Warning{Code: code, Message: message, RelatedIDs: relatedIDs}))
+}
+
+// requestWarnings returns the warnings recorded for the response to c, in
+// the order they were added; an empty slice when there are none
+func requestWarnings(c *gin.Context) []models.Warning {
+	if warnings, ok := c.Get(warningsKey); ok {
+		return warnings.([]models.Warning)
+	}
+	return []models.Warning{}
+}
+
+// dueDatePassed reports whether a todo's due date is before now. All-day
+// todos are due until the end of their date in the zone it was set in.
+func dueDatePassed(todo models.Todo, now time.Time) bool {
+	if todo.DueDate == nil {
+		return false
+	}
+	if !todo.AllDay {
+		return todo.DueDate.Before(now)
+	}
+	loc := time.UTC
+	if todo.DueTimezone != nil {
+		if zone, err := models.LoadLocation(*todo.DueTimezone); err == nil {
+			loc = zone
+		}
+	}
+	return todo.DueDate.In(loc).AddDate(0, 0, 1).Before(now)
+}
+
+// warnTodoChanges records warnings for a todo changed from before to after
+// by the request c: a due date set in the past, story points set on a done
+// todo, and story points left off the scale by an update that did not change
+// them. before is the zero Todo for a new todo.
+func warnTodoChanges(ctx context.Context, c *gin.Context, before, after models.Todo) error {
+	if c == nil {
+		return nil
+	}
+	dueChanged := !equalEventValues(formatEventDueDate(before), formatEventDueDate(after))
+	if dueChanged && dueDatePassed(after, time.Now()) {
+		addWarning(c, models.WarningDueDatePast, "The due date is in the past", after.ID)
+	}
+
+	pointsChanged := !equalEventValues(formatEventInt(before.StoryPoints), formatEventInt(after.StoryPoints))
+	if after.StoryPoints != nil && pointsChanged {
+		status, _, err := lookupStatus(ctx, after.Status)
+		if err != nil {
+			return err
+		}
+		if status.IsDone {
+			addWarning(c, models.WarningStoryPointsOnDone, "Story points were set on a todo that is already done", after.ID)
+		}
+	}
+	if after.StoryPoints != nil && !pointsChanged && before.ID != 0 {
+		message, err := checkStoryPoints(ctx, after.StoryPoints)
+		if err != nil {
+			return err
+		}
+		if message != "" {
+			addWarning(c, models.WarningStoryPointsOffScale, fmt.Sprintf("Story points %d are no longer on the scale", *after.StoryPoints), after.ID)
+		}
+	}
+	return nil
+}
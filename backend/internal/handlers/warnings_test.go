@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/testsupport"
+)
+
+// Warnings come back in the order they were added, and adding one without a
+// request does nothing
+func TestAddWarning(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if warnings := requestWarnings(c); warnings == nil || len(warnings) != 0 {
+		t.Errorf("requestWarnings = %#v before any, want an empty slice", warnings)
+	}
+	addWarning(c, models.WarningDueDatePast, "first", 1)
+	addWarning(c, models.WarningPossibleDuplicate, "second", 2, 3)
+	want := []models.Warning{
+		{Code: models.WarningDueDatePast, Message: "first", RelatedIDs: []int64{1}},
+		{Code: models.WarningPossibleDuplicate, Message: "second", RelatedIDs: []int64{2, 3}},
+	}
+	if got := requestWarnings(c); len(got) != 2 || got[0].Code != want[0].Code || !slices.Equal(got[1].RelatedIDs, want[1].RelatedIDs) {
+		t.Errorf("requestWarnings = %+v, want %+v", got, want)
+	}
+	addWarning(nil, models.WarningDueDatePast, "ignored")
+}
+
+// An all-day todo is due until the end of its date in the zone it was set
+// in; a timed one until its instant
+func TestDueDatePassed(t *testing.T) {
+	kolkata := "Asia/Kolkata"
+	// 2025-03-10 in Asia/Kolkata, as stored
+	allDay := time.Date(2025, 3, 9, 18, 30, 0, 0, time.UTC)
+	timed := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		todo models.Todo
+		now  time.Time
+		want bool
+	}{
+		{"no due date", models.Todo{}, timed, false},
+		{"timed, a second before", models.Todo{DueDate: &timed}, timed.Add(-time.Second), false},
+		{"timed, a second after", models.Todo{DueDate: &timed}, timed.Add(time.Second), true},
+		{"all-day, end of its date", models.Todo{DueDate: &allDay, AllDay: true, DueTimezone: &kolkata}, time.Date(2025, 3, 10, 18, 29, 59, 0, time.UTC), false},
+		{"all-day, the next day", models.Todo{DueDate: &allDay, AllDay: true, DueTimezone: &kolkata}, time.Date(2025, 3, 10, 18, 30, 1, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dueDatePassed(tt.todo, tt.now); got != tt.want {
+				t.Errorf("dueDatePassed = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+// warningsResponse is the part of a mutation response the warning tests read
+type warningsResponse struct {
+	ID       int64            `json:"id"`
+	Warnings []models.Warning `json:"warnings"`
+}
+
+// sendWarned sends a request to engine, fails the test unless it gets
+// status, which a warning never changes, and returns the response's warnings
+func sendWarned(t *testing.T, engine *gin.Engine, method, path, body string, status int) warningsResponse {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != status {
+		t.Fatalf("%s %s: status %d, want %d: %s", method, path, w.Code, status, w.Body)
+	}
+	var response warningsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decoding %s: %v", w.Body, err)
+	}
+	if method == http.MethodPost && status == http.StatusCreated {
+		t.Cleanup(func() { db.Pool.Exec(context.Background(), `DELETE FROM todos WHERE id = $1`, response.ID) })
+	}
+	return response
+}
+
+// assertWarning fails the test unless warnings holds exactly one warning,
+// with code and relatedIDs
+func assertWarning(t *testing.T, warnings []models.Warning, code string, relatedIDs ...int64) {
+	t.Helper()
+	if len(warnings) != 1 || warnings[0].Code != code || warnings[0].Message == "" || !slices.Equal(warnings[0].RelatedIDs, relatedIDs) {
+		t.Errorf("warnings %+v, want only %s about %v", warnings, code, relatedIDs)
+	}
+}
+
+// Each warning code, produced by the request that raises it. None of them
+// changes the status code.
+func TestWarningCodes(t *testing.T) {
+	usePool(t)
+	ctx := context.Background()
+	engine := gin.New()
+	engine.POST("/todos", CreateTodo)
+	engine.PUT("/todos/:id", UpdateTodo)
+	engine.POST("/todos/bulk/move", MoveTodos)
+	todoPath := func(id int64) string { return "/todos/" + strconv.FormatInt(id, 10) }
+	exec := func(t *testing.T, sql string, args ...interface{}) {
+		t.Helper()
+		if _, err := db.Pool.Exec(ctx, sql, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run(models.WarningDueDatePast, func(t *testing.T) {
+		todo := sendWarned(t, engine, http.MethodPost, "/todos", `{"title": "Renew the passport", "due_date": "2020-01-02"}`, http.StatusCreated)
+		assertWarning(t, todo.Warnings, models.WarningDueDatePast, todo.ID)
+
+		future := sendWarned(t, engine, http.MethodPost, "/todos", `{"title": "File the 2030 taxes", "due_date": "2030-01-02"}`, http.StatusCreated)
+		if len(future.Warnings) != 0 {
+			t.Errorf("a future due date warned %+v", future.Warnings)
+		}
+	})
+
+	t.Run(models.WarningStoryPointsOnDone, func(t *testing.T) {
+		todo := testsupport.NewTodoFactory(t, db.Pool).WithTitle("Shipped").WithStatus("done").Create(ctx)
+		updated := sendWarned(t, engine, http.MethodPut, todoPath(todo.ID), `{"title": "Shipped", "story_points": 3}`, http.StatusOK)
+		assertWarning(t, updated.Warnings, models.WarningStoryPointsOnDone, todo.ID)
+	})
+
+	t.Run(models.WarningStoryPointsOffScale, func(t *testing.T) {
+		todo := testsupport.NewTodoFactory(t, db.Pool).WithTitle("Estimated before the scale changed").Create(ctx)
+		exec(t, `UPDATE todos SET story_points = 4 WHERE id = $1`, todo.ID)
+		updated := sendWarned(t, engine, http.MethodPut, todoPath(todo.ID), `{"title": "Estimated before the scale changed, renamed"}`, http.StatusOK)
+		assertWarning(t, updated.Warnings, models.WarningStoryPointsOffScale, todo.ID)
+	})
+
+	t.Run(models.WarningPossibleDuplicate, func(t *testing.T) {
+		original := testsupport.NewTodoFactory(t, db.Pool).WithTitle("Rotate the warnings test signing key").Create(ctx)
+		todo := sendWarned(t, engine, http.MethodPost, "/todos", `{"title": "Rotate the warnings test signing key"}`, http.StatusCreated)
+		if len(todo.Warnings) != 1 || todo.Warnings[0].Code != models.WarningPossibleDuplicate || !slices.Contains(todo.Warnings[0].RelatedIDs, original.ID) {
+			t.Errorf("warnings %+v, want %s naming todo %d", todo.Warnings, models.WarningPossibleDuplicate, original.ID)
+		}
+	})
+
+	t.Run(models.WarningSprintOverCapacity, func(t *testing.T) {
+		var sprintID int64
+		if err := db.Pool.QueryRow(ctx, `
+			INSERT INTO sprints (name, start_date, end_date, capacity) VALUES ('Warnings', '2030-01-07', '2030-01-18', 2)
+			RETURNING id
+		`).Scan(&sprintID); err != nil {
+			t.Fatal(err)
+		}
+		todo := testsupport.NewTodoFactory(t, db.Pool).WithTitle("Too big for the sprint").Create(ctx)
+		exec(t, `UPDATE todos SET story_points = 3 WHERE id = $1`, todo.ID)
+		t.Cleanup(func() {
+			db.Pool.Exec(context.Background(), `UPDATE todos SET sprint_id = NULL WHERE id = $1`, todo.ID)
+			db.Pool.Exec(context.Background(), `DELETE FROM sprints WHERE id = $1`, sprintID)
+		})
+
+		move := `{"todo_ids": [` + strconv.FormatInt(todo.ID, 10) + `], "sprint_id": ` + strconv.FormatInt(sprintID, 10) + `}`
+		result := sendWarned(t, engine, http.MethodPost, "/todos/bulk/move", move, http.StatusOK)
+		assertWarning(t, result.Warnings, models.WarningSprintOverCapacity, sprintID)
+	})
+
+	t.Run(models.WarningDefaultUnavailable, func(t *testing.T) {
+		var status, priority *string
+		var points *int
+		if err := db.Pool.QueryRow(ctx, `SELECT status, priority_key, story_points FROM todo_defaults`).Scan(&status, &priority, &points); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			db.Pool.Exec(context.Background(), `UPDATE todo_defaults SET status = $1, priority_key = $2, story_points = $3`, status, priority, points)
+			todoDefaultsCache.reset()
+		})
+		exec(t, `UPDATE todo_defaults SET status = 'warnings_test_deleted', priority_key = NULL, story_points = NULL`)
+		todoDefaultsCache.reset()
+
+		todo := sendWarned(t, engine, http.MethodPost, "/todos", `{"title": "Created with a stale default"}`, http.StatusCreated)
+		assertWarning(t, todo.Warnings, models.WarningDefaultUnavailable)
+	})
+}
@@ -0,0 +1,548 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/models"
+)
+
+// rowsQuerier is implemented by both db.Pool and pgx.Tx
+type rowsQuerier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+// transitionError is returned when a status change is not allowed by the workflow
+type transitionError struct {
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (e *transitionError) Error() string {
+	return fmt.Sprintf("Moving from %s to %s is not allowed", e.From, e.To)
+}
+
+// allowedTransitions returns the statuses a todo in status from may move to,
+// in board column order
+func allowedTransitions(ctx context.Context, q rowsQuerier, from string) ([]string, error) {
+	rows, err := q.Query(ctx, `
+		SELECT key FROM statuses
+		WHERE key <> $1
+		  AND (NOT EXISTS (SELECT 1 FROM status_transitions WHERE from_status = $1)
+		       OR key IN (SELECT to_status FROM status_transitions WHERE from_status = $1))
+		ORDER BY position, key
+	`, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load allowed transitions: %w", err)
+	}
+	allowed, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to load allowed transitions: %w", err)
+	}
+	return allowed, nil
+}
+
+// checkTransition returns a *transitionError if the workflow does not allow
+// moving from one status to another
+func checkTransition(ctx context.Context, q rowsQuerier, from, to string) error {
+	if from == to {
+		return nil
+	}
+	allowed, err := allowedTransitions(ctx, q, from)
+	if err != nil {
+		return err
+	}
+	for _, key := range allowed {
+		if key == to {
+			return nil
+		}
+	}
+	return &transitionError{From: from, To: to, Allowed: allowed}
+}
+
+// respondTransitionError writes a 409 with the allowed targets if err is a
+// *transitionError and reports whether it did
+func respondTransitionError(c *gin.Context, err error) bool {
+	var transitionErr *transitionError
+	if !errors.As(err, &transitionErr) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": transitionErr.Error(), "allowed_transitions": transitionErr.Allowed})
+	return true
+}
+
+// doneLockedError is returned when the lock_done_todos policy rejects an
+// edit to a done todo
+type doneLockedError struct {
+	Status string
+	Fields []string
+}
+
+func (e *doneLockedError) Error() string {
+	return fmt.Sprintf("Todo is %s and done todos are locked: move it out of %s or send reopen=true to change %s", e.Status, e.Status, strings.Join(e.Fields, ", "))
+}
+
+// respondDoneLockedError writes a 409 naming the locked fields if err is a
+// *doneLockedError and reports whether it did
+func respondDoneLockedError(c *gin.Context, err error) bool {
+	var lockedErr *doneLockedError
+	if !errors.As(err, &lockedErr) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": lockedErr.Error(), "locked_fields": lockedErr.Fields})
+	return true
+}
+
+// doneLocked reports whether the lock_done_todos policy is on and both from
+// and to are done statuses. A todo moving out of done is not locked, so an
+// update may reopen a todo and edit it at once.
+func doneLocked(ctx context.Context, tx pgx.Tx, from, to string) (bool, error) {
+	var locked bool
+	err := tx.QueryRow(ctx, `
+		SELECT lock_done_todos AND $1 IN (`+doneStatusesSQL+`) AND $2 IN (`+doneStatusesSQL+`)
+		FROM todo_policy
+	`, from, to).Scan(&locked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to load todo policy: %w", err)
+	}
+	return locked, nil
+}
+
+// checkDoneLock returns a *doneLockedError if the change from before to
+// after edits a field the lock_done_todos policy locks on a done todo
+func checkDoneLock(ctx context.Context, tx pgx.Tx, before, after models.Todo) error {
+	var fields []string
+	if before.Title != after.Title {
+		fields = append(fields, "title")
+	}
+	if before.Description != after.Description {
+		fields = append(fields, "description")
+	}
+	if !equalEventValues(formatEventInt(before.StoryPoints), formatEventInt(after.StoryPoints)) {
+		fields = append(fields, "story_points")
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	locked, err := doneLocked(ctx, tx, before.Status, after.Status)
+	if err != nil || !locked {
+		return err
+	}
+	return &doneLockedError{Status: before.Status, Fields: fields}
+}
+
+// reopenStatus returns the status a done todo in status from is reopened to:
+// the first status in board order the workflow allows from it that is not
+// done. A *transitionError is returned when there is none.
+func reopenStatus(ctx context.Context, tx pgx.Tx, from string) (string, error) {
+	allowed, err := allowedTransitions(ctx, tx, from)
+	if err != nil {
+		return "", err
+	}
+	statuses, err := getStatuses(ctx)
+	if err != nil {
+		return "", err
+	}
+	done := map[string]bool{}
+	for _, status := range statuses {
+		done[status.Key] = status.IsDone
+	}
+	for _, key := range allowed {
+		if !done[key] {
+			return key, nil
+		}
+	}
+	return "", &transitionError{From: from, To: "a status that is not done", Allowed: allowed}
+}
+
+// unlockTodoSubtasks locks the todo todoID before its subtasks change and
+// checks the change against the lock_done_todos policy. With reopen a done
+// todo is first moved to its reopenStatus, recorded like any status change;
+// otherwise a locked todo fails with a *doneLockedError.
+func unlockTodoSubtasks(ctx context.Context, tx pgx.Tx, c *gin.Context, todoID int64, reopen bool) error {
+	var status string
+	if err := tx.QueryRow(ctx, `SELECT status FROM todos WHERE id = $1 FOR UPDATE`, todoID).Scan(&status); err != nil {
+		return err
+	}
+	if reopen {
+		current, _, err := lookupStatus(ctx, status)
+		if err != nil || !current.IsDone {
+			return err
+		}
+		target, err := reopenStatus(ctx, tx, status)
+		if err != nil {
+			return err
+		}
+		_, err = applyTodoUpdate(ctx, tx, c, nil, todoID, `status = $2, updated_at = NOW()`, target)
+		return err
+	}
+	locked, err := doneLocked(ctx, tx, status, status)
+	if err != nil || !locked {
+		return err
+	}
+	return &doneLockedError{Status: status, Fields: []string{"subtasks"}}
+}
+
+// parseReopen reads the reopen query parameter
+func parseReopen(c *gin.Context) (bool, error) {
+	reopen, err := strconv.ParseBool(c.DefaultQuery("reopen", "false"))
+	if err != nil {
+		return false, errors.New("Invalid reopen. Must be true or false")
+	}
+	return reopen, nil
+}
+
+// noteRulesSQL selects the status and priority note rules as one list with
+// their field, keys and ID
+const noteRulesSQL = `
+	SELECT 'status' AS field, from_status AS from_key, to_status AS to_key, id FROM status_note_rules
+	UNION ALL
+	SELECT 'priority', from_priority, to_priority, id FROM priority_note_rules
+`
+
+// noteRequiredError is returned when a change matches a note rule and no
+// note was given
+type noteRequiredError struct {
+	Rule models.NoteRule
+}
+
+func (e *noteRequiredError) Error() string {
+	side := func(key *string) string {
+		if key == nil {
+			return "any " + e.Rule.Field
+		}
+		return *key
+	}
+	return fmt.Sprintf("Changing %s from %s to %s requires a note", e.Rule.Field, side(e.Rule.From), side(e.Rule.To))
+}
+
+// scanNoteRules reads rows selected from noteRulesSQL
+func scanNoteRules(rows pgx.Rows) ([]models.NoteRule, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.NoteRule, error) {
+		var rule models.NoteRule
+		err := row.Scan(&rule.Field, &rule.From, &rule.To)
+		return rule, err
+	})
+}
+
+// checkNoteRules returns a *noteRequiredError naming the first note rule the
+// change from before to after matches
+func checkNoteRules(ctx context.Context, q rowsQuerier, before, after models.Todo) error {
+	if before.Status == after.Status && before.PriorityKey == after.PriorityKey {
+		return nil
+	}
+	rows, err := q.Query(ctx, `
+		SELECT field, from_key, to_key FROM (`+noteRulesSQL+`) rules
+		WHERE (field = 'status' AND $1 <> $2 AND COALESCE(from_key, $1) = $1 AND COALESCE(to_key, $2) = $2)
+		   OR (field = 'priority' AND $3 <> $4 AND COALESCE(from_key, $3) = $3 AND COALESCE(to_key, $4) = $4)
+		ORDER BY field DESC, id
+		LIMIT 1
+	`, before.Status, after.Status, before.PriorityKey, after.PriorityKey)
+	if err != nil {
+		return fmt.Errorf("failed to load note rules: %w", err)
+	}
+	rules, err := scanNoteRules(rows)
+	if err != nil {
+		return fmt.Errorf("failed to load note rules: %w", err)
+	}
+	if len(rules) > 0 {
+		return &noteRequiredError{Rule: rules[0]}
+	}
+	return nil
+}
+
+// respondNoteRequiredError writes a 422 naming the rule if err is a
+// *noteRequiredError and reports whether it did
+func respondNoteRequiredError(c *gin.Context, err error) bool {
+	var noteErr *noteRequiredError
+	if !errors.As(err, &noteErr) {
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": noteErr.Error(), "rule": noteErr.Rule})
+	return true
+}
+
+// loadNoteRules returns every note rule, status rules first
+func loadNoteRules(ctx context.Context, q rowsQuerier) ([]models.NoteRule, error) {
+	rows, err := q.Query(ctx, `SELECT field, from_key, to_key FROM (`+noteRulesSQL+`) rules ORDER BY field DESC, id`)
+	if err != nil {
+		return nil, err
+	}
+	return scanNoteRules(rows)
+}
+
+// loadWorkflow returns every allowed transition, grouped by source column
+func loadWorkflow(ctx context.Context) (models.Workflow, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT t.from_status, t.to_status
+		FROM status_transitions t
+		JOIN statuses f ON f.key = t.from_status
+		JOIN statuses s ON s.key = t.to_status
+		ORDER BY f.position, s.position
+	`)
+	if err != nil {
+		return models.Workflow{}, err
+	}
+	transitions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.StatusTransition, error) {
+		var transition models.StatusTransition
+		err := row.Scan(&transition.From, &transition.To)
+		return transition, err
+	})
+	if err != nil {
+		return models.Workflow{}, err
+	}
+	return models.Workflow{Transitions: transitions}, nil
+}
+
+// GetWorkflow godoc
+// @Summary      Get the status workflow
+// @Description  Get the allowed status transitions. A status without outgoing transitions may move to any status.
+// @Tags         statuses
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  models.Workflow
+// @Failure      500  {object}  map[string]string
+// @Router       /workflow [get]
+func GetWorkflow(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	workflow, err := loadWorkflow(c.Request.Context())
+	if err != nil {
+		log.Printf("Error fetching workflow: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch workflow", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, workflow)
+}
+
+// UpdateWorkflow godoc
+// @Summary      Replace the status workflow
+// @Description  Replace all allowed status transitions. Send an empty list to allow every transition again.
+// @Tags         statuses
+// @Accept       json
+// @Produce      json
+// @Param        workflow  body      models.UpdateWorkflowRequest  true  "Allowed transitions"
+// @Success      200       {object}  models.Workflow
+// @Failure      400       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /workflow [put]
+func UpdateWorkflow(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.UpdateWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	for i := range req.Transitions {
+		transition := &req.Transitions[i]
+		for _, key := range []*string{&transition.From, &transition.To} {
+			status, ok, err := resolveStatus(ctx, *key)
+			if err != nil {
+				log.Printf("Error fetching statuses: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update workflow", "details": err.Error()})
+				return
+			}
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": invalidStatusError(ctx).Error()})
+				return
+			}
+			*key = status.Key
+		}
+		if transition.From == transition.To {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A transition must go to a different status"})
+			return
+		}
+	}
+
+	err := db.WithTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM status_transitions`); err != nil {
+			return err
+		}
+		for _, transition := range req.Transitions {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO status_transitions (from_status, to_status, created_at)
+				VALUES ($1, $2, NOW())
+				ON CONFLICT DO NOTHING
+			`, transition.From, transition.To)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error updating workflow: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update workflow", "details": err.Error()})
+		return
+	}
+
+	workflow, err := loadWorkflow(ctx)
+	if err != nil {
+		log.Printf("Error fetching workflow: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch workflow", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, workflow)
+}
+
+// GetNoteRules godoc
+// @Summary      Get the note rules
+// @Description  Get the status and priority changes that must come with a note
+// @Tags         settings
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  models.NoteRules
+// @Failure      500  {object}  map[string]string
+// @Router       /settings/note-rules [get]
+func GetNoteRules(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	rules, err := loadNoteRules(c.Request.Context(), db.Pool)
+	if err != nil {
+		log.Printf("Error fetching note rules: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch note rules", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NoteRules{Rules: rules})
+}
+
+// UpdateNoteRules godoc
+// @Summary      Replace the note rules
+// @Description  Replace the status and priority changes that must come with a note. A rule without from or to matches any value on that side. Updates matching a rule without a note get a 422 naming the rule. Send an empty list to drop every rule.
+// @Tags         settings
+// @Accept       json
+// @Produce      json
+// @Param        rules  body      models.UpdateNoteRulesRequest  true  "Note rules"
+// @Success      200    {object}  models.NoteRules
+// @Failure      400    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /settings/note-rules [put]
+func UpdateNoteRules(c *gin.Context) {
+	if db.Pool == nil {
+		log.Printf("Error: database pool is nil")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not initialized"})
+		return
+	}
+
+	var req models.UpdateNoteRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	for i := range req.Rules {
+		rule := &req.Rules[i]
+		if rule.From == nil && rule.To == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A note rule needs from, to or both"})
+			return
+		}
+		for _, key := range []*string{rule.From, rule.To} {
+			if key == nil {
+				continue
+			}
+			if rule.Field == models.NoteRulePriority {
+				resolved, err := resolvePriority(ctx, *key, "")
+				if respondPriorityError(c, err) {
+					return
+				}
+				if err != nil {
+					log.Printf("Error fetching priorities: %v", err)
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update note rules", "details": err.Error()})
+					return
+				}
+				*key = resolved
+				continue
+			}
+			status, ok, err := resolveStatus(ctx, *key)
+			if err != nil {
+				log.Printf("Error fetching statuses: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update note rules", "details": err.Error()})
+				return
+			}
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": invalidStatusError(ctx).Error()})
+				return
+			}
+			*key = status.Key
+		}
+		if rule.From != nil && rule.To != nil && *rule.From == *rule.To {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A note rule must go to a different " + rule.Field})
+			return
+		}
+	}
+
+	var rules []models.NoteRule
+	err := db.WithTx(ctx, func(tx pgx.Tx) error {
+		if err := replaceNoteRules(ctx, tx, req.Rules); err != nil {
+			return err
+		}
+		var err error
+		rules, err = loadNoteRules(ctx, tx)
+		return err
+	})
+	if isForeignKeyViolation(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Status or priority not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating note rules: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update note rules", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NoteRules{Rules: rules})
+}
+
+// noteRuleInserts inserts a note rule's from and to keys, by field
+var noteRuleInserts = map[string]string{
+	models.NoteRuleStatus:   `INSERT INTO status_note_rules (from_status, to_status, created_at) VALUES ($1, $2, NOW())`,
+	models.NoteRulePriority: `INSERT INTO priority_note_rules (from_priority, to_priority, created_at) VALUES ($1, $2, NOW())`,
+}
+
+// replaceNoteRules deletes every note rule and inserts rules in their place
+func replaceNoteRules(ctx context.Context, tx pgx.Tx, rules []models.NoteRule) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM status_note_rules`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM priority_note_rules`); err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if _, err := tx.Exec(ctx, noteRuleInserts[rule.Field], rule.From, rule.To); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"flow-v1/backend/internal/db"
+)
+
+// defaultAuditRetentionDays is used when AUDIT_RETENTION_DAYS is unset
+const defaultAuditRetentionDays = 365
+
+// auditRetentionBatchSize bounds how many rows a single DELETE removes so the
+// purge never holds long locks on audit_log
+const auditRetentionBatchSize = 5000
+
+// AuditRetention returns a job deleting audit_log rows older than
+// AUDIT_RETENTION_DAYS (default 365). A value of 0 keeps entries forever.
+func AuditRetention() (Job, error) {
+	days := defaultAuditRetentionDays
+	if value := os.Getenv("AUDIT_RETENTION_DAYS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return Job{}, fmt.Errorf("invalid AUDIT_RETENTION_DAYS: %q", value)
+		}
+		days = parsed
+	}
+
+	return Job{
+		Name:     "audit_retention",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			if days == 0 {
+				return nil
+			}
+			cutoff := time.Now().UTC().AddDate(0, 0, -days)
+			return purgeAuditLog(ctx, cutoff)
+		},
+	}, nil
+}
+
+// purgeAuditLog deletes entries created before cutoff in batches
+func purgeAuditLog(ctx context.Context, cutoff time.Time) error {
+	var total int64
+	for {
+		result, err := db.Pool.Exec(ctx, `
+			DELETE FROM audit_log
+			WHERE id IN (
+				SELECT id FROM audit_log WHERE created_at < $1 LIMIT $2
+			)
+		`, cutoff, auditRetentionBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to purge audit log: %w", err)
+		}
+		total += result.RowsAffected()
+		if result.RowsAffected() < auditRetentionBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("Purged %d audit log entries older than %s", total, cutoff.Format(time.RFC3339))
+	}
+	return nil
+}
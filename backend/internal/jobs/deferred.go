@@ -0,0 +1,17 @@
+package jobs
+
+import (
+	"time"
+
+	"flow-v1/backend/internal/handlers"
+)
+
+// Deferred returns a job clearing, every hour, the defer date of todos whose
+// date has arrived, so their return shows up in their activity
+func Deferred() Job {
+	return Job{
+		Name:     "deferred",
+		Interval: time.Hour,
+		Run:      handlers.ResurfaceDeferredTodos,
+	}
+}
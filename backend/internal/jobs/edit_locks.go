@@ -0,0 +1,17 @@
+package jobs
+
+import (
+	"time"
+
+	"flow-v1/backend/internal/handlers"
+)
+
+// EditLocks returns a job deleting todo editing locks whose holder stopped
+// sending heartbeats
+func EditLocks() Job {
+	return Job{
+		Name:     "edit_locks",
+		Interval: time.Minute,
+		Run:      handlers.PurgeExpiredEditLocks,
+	}
+}
@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"time"
+
+	"flow-v1/backend/internal/handlers"
+)
+
+// Exports returns a job running queued todo exports every 5 seconds
+func Exports() Job {
+	return Job{
+		Name:     "exports",
+		Interval: 5 * time.Second,
+		Run:      handlers.RunExportJobs,
+	}
+}
+
+// ExportRetention returns a job removing, every hour, the files of exports
+// past their expiry
+func ExportRetention() Job {
+	return Job{
+		Name:     "export_retention",
+		Interval: time.Hour,
+		Run:      handlers.PurgeExpiredExports,
+	}
+}
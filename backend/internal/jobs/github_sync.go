@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"flow-v1/backend/internal/handlers"
+)
+
+// defaultGitHubSyncInterval is used when GITHUB_SYNC_INTERVAL is unset
+const defaultGitHubSyncInterval = 15 * time.Minute
+
+// GitHubSync returns a job refreshing linked GitHub issues every
+// GITHUB_SYNC_INTERVAL (a Go duration, default 15m). The job does nothing
+// unless a GitHub token is configured.
+func GitHubSync() (Job, error) {
+	interval := defaultGitHubSyncInterval
+	if value := os.Getenv("GITHUB_SYNC_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return Job{}, fmt.Errorf("invalid GITHUB_SYNC_INTERVAL: %q", value)
+		}
+		interval = parsed
+	}
+
+	return Job{
+		Name:     "github_sync",
+		Interval: interval,
+		Run:      handlers.SyncGitHubLinks,
+	}, nil
+}
@@ -0,0 +1,17 @@
+package jobs
+
+import (
+	"time"
+
+	"flow-v1/backend/internal/handlers"
+)
+
+// Integrity returns a job running the consistency checks every week. It only
+// reports what they find; cleanups are left to POST /admin/integrity/fix.
+func Integrity() Job {
+	return Job{
+		Name:     "integrity",
+		Interval: 7 * 24 * time.Hour,
+		Run:      handlers.RecordIntegrityReport,
+	}
+}
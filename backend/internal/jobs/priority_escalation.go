@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"flow-v1/backend/internal/handlers"
+)
+
+// defaultPriorityEscalationInterval is used when PRIORITY_ESCALATION_INTERVAL is unset
+const defaultPriorityEscalationInterval = 15 * time.Minute
+
+// PriorityEscalation returns a job applying the priority escalation rule
+// every PRIORITY_ESCALATION_INTERVAL (a Go duration, default 15m). The job
+// does nothing until the rule is enabled through /settings/escalation.
+func PriorityEscalation() (Job, error) {
+	interval := defaultPriorityEscalationInterval
+	if value := os.Getenv("PRIORITY_ESCALATION_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return Job{}, fmt.Errorf("invalid PRIORITY_ESCALATION_INTERVAL: %q", value)
+		}
+		interval = parsed
+	}
+
+	return Job{
+		Name:     "priority_escalation",
+		Interval: interval,
+		Run:      handlers.EscalatePriorities,
+	}, nil
+}
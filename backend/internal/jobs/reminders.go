@@ -0,0 +1,17 @@
+package jobs
+
+import (
+	"time"
+
+	"flow-v1/backend/internal/handlers"
+)
+
+// Reminders returns a job firing the due date reminders whose time has come
+// every minute
+func Reminders() Job {
+	return Job{
+		Name:     "reminders",
+		Interval: time.Minute,
+		Run:      handlers.FireDueReminders,
+	}
+}
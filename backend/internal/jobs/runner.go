@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Job is a unit of background work run on a fixed interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Runner runs registered jobs on their intervals until its context is cancelled
+type Runner struct {
+	jobs []*runnerJob
+	wg   sync.WaitGroup
+	// started is when Start ran, in Unix nanoseconds
+	started atomic.Int64
+}
+
+// runnerJob is a registered job and when it last ran without error, in Unix
+// nanoseconds
+type runnerJob struct {
+	Job
+	lastSuccess atomic.Int64
+}
+
+// NewRunner creates an empty job runner
+func NewRunner() *Runner {
+	return &Runner{}
+}
+
+// Register adds a job to the runner. Jobs must be registered before Start.
+func (r *Runner) Register(job Job) {
+	r.jobs = append(r.jobs, &runnerJob{Job: job})
+}
+
+// Start launches one goroutine per job. Each job runs once immediately and
+// then on every tick of its interval; errors are logged and never stop the loop.
+func (r *Runner) Start(ctx context.Context) {
+	r.started.Store(time.Now().UnixNano())
+	for _, job := range r.jobs {
+		r.wg.Add(1)
+		go func(job *runnerJob) {
+			defer r.wg.Done()
+			ticker := time.NewTicker(job.Interval)
+			defer ticker.Stop()
+
+			for {
+				if err := job.Run(ctx); err != nil {
+					if ctx.Err() == nil {
+						log.Printf("Error running job %s: %v", job.Name, err)
+					}
+				} else {
+					job.lastSuccess.Store(time.Now().UnixNano())
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(job)
+	}
+}
+
+// Lag returns how long the most overdue job is behind. A job that has run
+// without error is due again one interval later; one that has not yet was
+// due at Start, since jobs run at once. Lag is 0 when every job is on time
+// or the runner has not started.
+func (r *Runner) Lag() time.Duration {
+	return r.lag(time.Now())
+}
+
+func (r *Runner) lag(now time.Time) time.Duration {
+	started := r.started.Load()
+	if started == 0 {
+		return 0
+	}
+	var worst time.Duration
+	for _, job := range r.jobs {
+		due := time.Unix(0, started)
+		if last := job.lastSuccess.Load(); last != 0 {
+			due = time.Unix(0, last).Add(job.Interval)
+		}
+		worst = max(worst, now.Sub(due))
+	}
+	return worst
+}
+
+// Wait blocks until every job goroutine has returned after cancellation
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunnerLag(t *testing.T) {
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	r := NewRunner()
+	r.Register(Job{Name: "views", Interval: 5 * time.Second})
+	r.Register(Job{Name: "integrity", Interval: 7 * 24 * time.Hour})
+	r.Register(Job{Name: "reminders", Interval: time.Minute})
+	views, integrity, reminders := r.jobs[0], r.jobs[1], r.jobs[2]
+
+	if lag := r.lag(start); lag != 0 {
+		t.Errorf("before Start: lag %v, want 0", lag)
+	}
+	r.started.Store(start.UnixNano())
+
+	// A job that has not succeeded yet was due at Start
+	views.lastSuccess.Store(start.UnixNano())
+	integrity.lastSuccess.Store(start.UnixNano())
+	if lag := r.lag(start.Add(3 * time.Minute)); lag != 3*time.Minute {
+		t.Errorf("reminders never succeeded: lag %v, want 3m", lag)
+	}
+
+	// The weekly job is on time days after its last run, but the views job
+	// stuck since Start is not hidden by the others succeeding
+	now := start.Add(2 * time.Hour)
+	reminders.lastSuccess.Store(now.Add(-30 * time.Second).UnixNano())
+	if lag := r.lag(now); lag != 2*time.Hour-5*time.Second {
+		t.Errorf("views stuck: lag %v, want 1h59m55s", lag)
+	}
+
+	views.lastSuccess.Store(now.Add(-2 * time.Second).UnixNano())
+	if lag := r.lag(now); lag != 0 {
+		t.Errorf("every job on time: lag %v, want 0", lag)
+	}
+}
@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"flow-v1/backend/internal/handlers"
+)
+
+// SubtaskCounts returns a job recomputing the subtask counters kept on todos
+// and logging any that drifted from their subtasks
+func SubtaskCounts() Job {
+	return Job{
+		Name:     "subtask_counts",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			drifted, err := handlers.RecountSubtasks(ctx)
+			for _, drift := range drifted {
+				log.Printf("Fixed subtask counts of todo %d: stored %d/%d, actual %d/%d",
+					drift.TodoID, drift.StoredCompleted, drift.StoredCount, drift.ActualCompleted, drift.ActualCount)
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,17 @@
+package jobs
+
+import (
+	"time"
+
+	"flow-v1/backend/internal/handlers"
+)
+
+// TodoViews returns a job writing the todo views queued by GET /todos/:id,
+// keeping those writes off the request path
+func TodoViews() Job {
+	return Job{
+		Name:     "todo_views",
+		Interval: 5 * time.Second,
+		Run:      handlers.FlushTodoViews,
+	}
+}
@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"flow-v1/backend/internal/db"
+)
+
+// defaultTombstoneRetentionDays is used when TOMBSTONE_RETENTION_DAYS is unset
+const defaultTombstoneRetentionDays = 90
+
+// TombstoneRetention returns a job deleting todo tombstones older than
+// TOMBSTONE_RETENTION_DAYS (default 90), after which GET /todos/:id answers
+// 404 instead of 410 for those todos. A value of 0 keeps tombstones forever.
+func TombstoneRetention() (Job, error) {
+	days := defaultTombstoneRetentionDays
+	if value := os.Getenv("TOMBSTONE_RETENTION_DAYS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return Job{}, fmt.Errorf("invalid TOMBSTONE_RETENTION_DAYS: %q", value)
+		}
+		days = parsed
+	}
+
+	return Job{
+		Name:     "tombstone_retention",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			if days == 0 {
+				return nil
+			}
+			cutoff := time.Now().UTC().AddDate(0, 0, -days)
+			result, err := db.Pool.Exec(ctx, `DELETE FROM todo_tombstones WHERE deleted_at < $1`, cutoff)
+			if err != nil {
+				return fmt.Errorf("failed to purge todo tombstones: %w", err)
+			}
+			if result.RowsAffected() > 0 {
+				log.Printf("Purged %d todo tombstones older than %s", result.RowsAffected(), cutoff.Format(time.RFC3339))
+			}
+			return nil
+		},
+	}, nil
+}
@@ -0,0 +1,17 @@
+package jobs
+
+import (
+	"time"
+
+	"flow-v1/backend/internal/handlers"
+)
+
+// UsageMetering returns a job writing the API usage counted by
+// handlers.UsageMetering in batches, keeping those writes off the request path
+func UsageMetering() Job {
+	return Job{
+		Name:     "usage_metering",
+		Interval: 5 * time.Second,
+		Run:      handlers.FlushUsage,
+	}
+}
@@ -0,0 +1,218 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// ErrBlockedAddress is returned when a URL resolves to an address that must
+// not be fetched, such as loopback or private networks
+var ErrBlockedAddress = errors.New("address is not publicly routable")
+
+const (
+	fetchTimeout = 5 * time.Second
+	maxRedirects = 3
+	// maxBodyBytes bounds how much of a page is read; metadata lives in the head
+	maxBodyBytes = 512 * 1024
+	maxURLLength = 2048
+)
+
+// carrierGradeNAT is the shared address space (RFC 6598), which net.IP does
+// not classify as private
+var carrierGradeNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Metadata is what a page says about itself
+type Metadata struct {
+	Title      string
+	FaviconURL string
+}
+
+// Fetcher fetches page metadata while refusing to connect to anything but
+// public addresses
+type Fetcher struct {
+	httpClient *http.Client
+}
+
+// NewFetcher creates a fetcher with a strict timeout, a redirect limit and a
+// dialer that checks every resolved address, including after redirects
+func NewFetcher() *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: fetchTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+			}
+			return nil
+		},
+	}
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   fetchTimeout,
+		ResponseHeaderTimeout: fetchTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	return &Fetcher{
+		httpClient: &http.Client{
+			Timeout:   fetchTimeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return fmt.Errorf("refusing redirect to %s URL", req.URL.Scheme)
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// isPublicIP reports whether ip is a globally routable unicast address
+func isPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() &&
+		!ip.IsPrivate() &&
+		!ip.IsLoopback() &&
+		!ip.IsLinkLocalUnicast() &&
+		!carrierGradeNAT.Contains(ip)
+}
+
+// ValidateURL checks that raw is an absolute http or https URL without
+// embedded credentials
+func ValidateURL(raw string) (*url.URL, error) {
+	if len(raw) > maxURLLength {
+		return nil, fmt.Errorf("URL must be at most %d characters", maxURLLength)
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil, errors.New("URL is not valid")
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, errors.New("URL must use http or https")
+	}
+	if parsed.Hostname() == "" {
+		return nil, errors.New("URL must include a host")
+	}
+	if parsed.User != nil {
+		return nil, errors.New("URL must not include credentials")
+	}
+	return parsed, nil
+}
+
+// Fetch downloads the start of an HTML page and extracts its title,
+// preferring og:title, and its favicon, falling back to /favicon.ico
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (Metadata, error) {
+	target, err := ValidateURL(rawURL)
+	if err != nil {
+		return Metadata{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return Metadata{}, err
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "flow-link-preview/1.0")
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Metadata{}, fmt.Errorf("failed to fetch %s: server returned %s", rawURL, resp.Status)
+	}
+	if !strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
+		return Metadata{}, fmt.Errorf("failed to fetch %s: not an HTML page", rawURL)
+	}
+
+	// Relative favicons resolve against the final URL after redirects
+	metadata := parseHead(io.LimitReader(resp.Body, maxBodyBytes), resp.Request.URL)
+	return metadata, nil
+}
+
+// parseHead reads the document head and extracts its metadata
+func parseHead(body io.Reader, base *url.URL) Metadata {
+	var title, ogTitle, favicon string
+	tokenizer := html.NewTokenizer(body)
+	inTitle := false
+
+loop:
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			break loop
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "title":
+				inTitle = title == ""
+			case "meta":
+				if attr(token, "property") == "og:title" && ogTitle == "" {
+					ogTitle = attr(token, "content")
+				}
+			case "link":
+				rel := strings.ToLower(attr(token, "rel"))
+				if favicon == "" && (rel == "icon" || rel == "shortcut icon") {
+					favicon = attr(token, "href")
+				}
+			case "body":
+				break loop
+			}
+		case html.TextToken:
+			if inTitle {
+				title = string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			token := tokenizer.Token()
+			if token.Data == "title" {
+				inTitle = false
+			}
+			if token.Data == "head" {
+				break loop
+			}
+		}
+	}
+
+	metadata := Metadata{Title: strings.TrimSpace(ogTitle)}
+	if metadata.Title == "" {
+		metadata.Title = strings.TrimSpace(title)
+	}
+	if favicon == "" {
+		favicon = "/favicon.ico"
+	}
+	if ref, err := url.Parse(favicon); err == nil {
+		resolved := base.ResolveReference(ref)
+		if resolved.Scheme == "http" || resolved.Scheme == "https" {
+			metadata.FaviconURL = resolved.String()
+		}
+	}
+	return metadata
+}
+
+// attr returns the value of the named attribute of token, if present
+func attr(token html.Token, name string) string {
+	for _, a := range token.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
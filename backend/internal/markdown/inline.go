@@ -0,0 +1,408 @@
+package markdown
+
+import (
+	"html"
+	"strings"
+)
+
+// isPunct reports whether c is ASCII punctuation, which a backslash escapes
+func isPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+// isWordByte reports whether c is part of a word, so an underscore next to
+// it does not open or close emphasis
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 0x80 || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// runLength counts the bytes equal to c from s[i] on
+func runLength(s string, i int, c byte) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+	return n
+}
+
+// unescape resolves backslash escapes and entities in a link destination or
+// title
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isPunct(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return html.UnescapeString(b.String())
+}
+
+// maxLinkParens bounds the parentheses nested in a link destination
+const maxLinkParens = 32
+
+// scanner looks for the closers of inline markup in one string. A scan that
+// finds no closer from a position finds none from any later one either, so
+// failed scans are remembered and no byte is scanned twice for the same
+// kind of closer; without that, text full of unclosed openers takes
+// quadratic time.
+type scanner struct {
+	s string
+	// brackets maps each [ to the ] closing it, or -1, once a link is parsed
+	brackets map[int]int
+	// noCodeSpan maps a backtick run length to the first position from which
+	// no span of that length closes
+	noCodeSpan map[int]int
+	// noCloser does the same for emphasis, by delimiter and run length
+	noCloser map[[2]int]int
+	// noTitleEnd does the same for link titles, by closing byte
+	noTitleEnd map[byte]int
+}
+
+func newScanner(s string) *scanner {
+	return &scanner{s: s, noCodeSpan: map[int]int{}, noCloser: map[[2]int]int{}, noTitleEnd: map[byte]int{}}
+}
+
+// inline renders the inline content of a block as HTML. Emphasis and links
+// nested deeper than maxNesting are written as text.
+func (r *renderer) inline(s string) string {
+	r.inlineDepth++
+	defer func() { r.inlineDepth-- }()
+	nest := r.inlineDepth <= maxNesting
+	sc := newScanner(s)
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			b.WriteString("<br>\n")
+			i += 2
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+		case c == '`':
+			if end, code, ok := sc.codeSpan(i); ok {
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i = end
+			} else {
+				n := runLength(s, i, '`')
+				b.WriteString(s[i : i+n])
+				i += n
+			}
+		case c == '!' && i+1 < len(s) && s[i+1] == '[' && nest:
+			if end, text, dest, title, ok := sc.parseLink(i + 1); ok {
+				b.WriteString(r.image(text, dest, title))
+				i = end
+			} else {
+				b.WriteString("!")
+				i++
+			}
+		case c == '[' && nest:
+			if end, text, dest, title, ok := sc.parseLink(i); ok {
+				b.WriteString(`<a href="` + html.EscapeString(dest) + `"`)
+				if title != "" {
+					b.WriteString(` title="` + html.EscapeString(title) + `"`)
+				}
+				b.WriteString(">" + r.inline(text) + "</a>")
+				i = end
+			} else {
+				b.WriteString("[")
+				i++
+			}
+		case c == '<':
+			if m := uriAutolinkPattern.FindStringSubmatch(s[i:]); m != nil {
+				b.WriteString(`<a href="` + html.EscapeString(m[1]) + `">` + html.EscapeString(m[1]) + "</a>")
+				i += len(m[0])
+			} else if m := emailAutolinkPattern.FindStringSubmatch(s[i:]); m != nil {
+				b.WriteString(`<a href="mailto:` + html.EscapeString(m[1]) + `">` + html.EscapeString(m[1]) + "</a>")
+				i += len(m[0])
+			} else {
+				b.WriteString("&lt;")
+				i++
+			}
+		case (c == '*' || c == '_' || c == '~') && nest:
+			if end, emphasized, ok := r.emphasis(sc, i); ok {
+				b.WriteString(emphasized)
+				i = end
+			} else {
+				n := runLength(s, i, c)
+				b.WriteString(s[i : i+n])
+				i += n
+			}
+		case c == '&':
+			if entity := entityPattern.FindString(s[i:]); entity != "" {
+				b.WriteString(html.EscapeString(html.UnescapeString(entity)))
+				i += len(entity)
+			} else {
+				b.WriteString("&amp;")
+				i++
+			}
+		case c == 'h' && (i == 0 || !isWordByte(s[i-1])):
+			if url := bareURL(s[i:]); url != "" {
+				b.WriteString(`<a href="` + html.EscapeString(url) + `">` + html.EscapeString(url) + "</a>")
+				i += len(url)
+			} else {
+				b.WriteString("h")
+				i++
+			}
+		case c == '*' || c == '_' || c == '~':
+			n := runLength(s, i, c)
+			b.WriteString(s[i : i+n])
+			i += n
+		default:
+			// Copy plain text up to the next byte that may start markup
+			n := strings.IndexAny(s[i+1:], inlineSpecials) + 1
+			if n == 0 {
+				n = len(s) - i
+			}
+			b.WriteString(html.EscapeString(s[i : i+n]))
+			i += n
+		}
+	}
+	return b.String()
+}
+
+// codeSpan parses the code span opened by the backticks at s[i] and returns
+// the index after it and its content. A span is closed by a run of exactly
+// as many backticks.
+func (sc *scanner) codeSpan(i int) (int, string, bool) {
+	s := sc.s
+	n := runLength(s, i, '`')
+	if failed, ok := sc.noCodeSpan[n]; ok && i >= failed {
+		return 0, "", false
+	}
+	for j := i + n; j < len(s); {
+		if s[j] != '`' {
+			j++
+			continue
+		}
+		m := runLength(s, j, '`')
+		if m == n {
+			code := strings.ReplaceAll(s[i+n:j], "\n", " ")
+			if len(code) >= 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+				code = code[1 : len(code)-1]
+			}
+			return j + m, code, true
+		}
+		j += m
+	}
+	sc.noCodeSpan[n] = i
+	return 0, "", false
+}
+
+// matchBrackets finds the ] closing each [ in one pass, skipping escapes
+// and code spans as the inline parser does
+func (sc *scanner) matchBrackets() {
+	s := sc.s
+	sc.brackets = map[int]int{}
+	var open []int
+	for j := 0; j < len(s); {
+		switch s[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			if end, _, ok := sc.codeSpan(j); ok {
+				j = end
+			} else {
+				j += runLength(s, j, '`')
+			}
+			continue
+		case '[':
+			open = append(open, j)
+			sc.brackets[j] = -1
+		case ']':
+			if len(open) > 0 {
+				sc.brackets[open[len(open)-1]] = j
+				open = open[:len(open)-1]
+			}
+		}
+		j++
+	}
+}
+
+// parseLink parses an inline link [text](destination "title") whose bracket
+// is at s[i] and returns the index after it
+func (sc *scanner) parseLink(i int) (end int, text, dest, title string, ok bool) {
+	s := sc.s
+	if sc.brackets == nil {
+		sc.matchBrackets()
+	}
+	closeBracket, matched := sc.brackets[i]
+	if !matched || closeBracket < 0 || closeBracket+1 >= len(s) || s[closeBracket+1] != '(' {
+		return 0, "", "", "", false
+	}
+	text = s[i+1 : closeBracket]
+
+	j := closeBracket + 2
+	skipSpace := func() {
+		for j < len(s) && isSpace(s[j]) {
+			j++
+		}
+	}
+	skipSpace()
+	if j < len(s) && s[j] == '<' {
+		closeAngle := strings.IndexAny(s[j+1:], "<>\n")
+		if closeAngle < 0 || s[j+1+closeAngle] != '>' {
+			return 0, "", "", "", false
+		}
+		dest = s[j+1 : j+1+closeAngle]
+		j += closeAngle + 2
+	} else {
+		start, parens := j, 0
+		for ; j < len(s) && s[j] > ' '; j++ {
+			if s[j] == '\\' && j+1 < len(s) && isPunct(s[j+1]) {
+				j++
+			} else if s[j] == '(' {
+				if parens++; parens > maxLinkParens {
+					return 0, "", "", "", false
+				}
+			} else if s[j] == ')' {
+				if parens == 0 {
+					break
+				}
+				parens--
+			}
+		}
+		if parens != 0 {
+			return 0, "", "", "", false
+		}
+		dest = s[start:j]
+	}
+
+	hadSpace := j < len(s) && isSpace(s[j])
+	skipSpace()
+	if j < len(s) && hadSpace && strings.IndexByte("\"'(", s[j]) >= 0 {
+		closer := s[j]
+		if closer == '(' {
+			closer = ')'
+		}
+		start := j + 1
+		if from, ok := sc.noTitleEnd[closer]; ok && start >= from {
+			return 0, "", "", "", false
+		}
+		for j = start; j < len(s) && s[j] != closer; j++ {
+			if s[j] == '\\' {
+				j++
+			}
+		}
+		if j >= len(s) {
+			sc.noTitleEnd[closer] = start
+			return 0, "", "", "", false
+		}
+		title = s[start:j]
+		j++
+		skipSpace()
+	}
+	if j >= len(s) || s[j] != ')' {
+		return 0, "", "", "", false
+	}
+	return j + 1, text, unescape(dest), unescape(title), true
+}
+
+// image renders an image, or a link to it when its URL is not one images
+// may be loaded from
+func (r *renderer) image(text, dest, title string) string {
+	alt := html.EscapeString(unescape(text))
+	if !allowedImage(dest, r.opts.ImagePrefixes) {
+		label := alt
+		if label == "" {
+			label = html.EscapeString(dest)
+		}
+		return `<a href="` + html.EscapeString(dest) + `">` + label + "</a>"
+	}
+	img := `<img src="` + html.EscapeString(dest) + `" alt="` + alt + `"`
+	if title != "" {
+		img += ` title="` + html.EscapeString(title) + `"`
+	}
+	return img + ">"
+}
+
+// emphasis renders the emphasis, strong emphasis or strikethrough opened by
+// the delimiter run at s[i] and returns the index after it. It is closed by
+// the next run of the same delimiter and length that ends a word.
+func (r *renderer) emphasis(sc *scanner, i int) (int, string, bool) {
+	s := sc.s
+	c := s[i]
+	n := runLength(s, i, c)
+	if n > 3 || (c == '~' && n > 2) || i+n >= len(s) || isSpace(s[i+n]) {
+		return 0, "", false
+	}
+	if c == '_' && i > 0 && isWordByte(s[i-1]) {
+		return 0, "", false
+	}
+	end := sc.findCloser(i+n, c, n)
+	if end < 0 {
+		return 0, "", false
+	}
+	inner := r.inline(s[i+n : end])
+	switch {
+	case c == '~':
+		inner = "<del>" + inner + "</del>"
+	case n == 1:
+		inner = "<em>" + inner + "</em>"
+	case n == 2:
+		inner = "<strong>" + inner + "</strong>"
+	default:
+		inner = "<em><strong>" + inner + "</strong></em>"
+	}
+	return end + n, inner, true
+}
+
+// findCloser returns the index of the delimiter run closing emphasis opened
+// with n times c before s[from], skipping escapes and code spans, or -1
+func (sc *scanner) findCloser(from int, c byte, n int) int {
+	s := sc.s
+	key := [2]int{int(c), n}
+	if failed, ok := sc.noCloser[key]; ok && from >= failed {
+		return -1
+	}
+	for j := from; j < len(s); {
+		switch s[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			if end, _, ok := sc.codeSpan(j); ok {
+				j = end
+			} else {
+				j += runLength(s, j, '`')
+			}
+			continue
+		case c:
+			m := runLength(s, j, c)
+			if m == n && j > from && !isSpace(s[j-1]) && (c != '_' || j+m >= len(s) || !isWordByte(s[j+m])) {
+				return j
+			}
+			j += m
+			continue
+		}
+		j++
+	}
+	sc.noCloser[key] = from
+	return -1
+}
+
+// bareURL returns the http or https URL s starts with, without trailing
+// punctuation, or an empty string
+func bareURL(s string) string {
+	if !strings.HasPrefix(s, "http://") && !strings.HasPrefix(s, "https://") {
+		return ""
+	}
+	end := strings.IndexAny(s, " \t\n<>\"")
+	if end < 0 {
+		end = len(s)
+	}
+	url := strings.TrimRight(s[:end], ".,:;!?*_~'")
+	for unbalanced := strings.Count(url, ")") - strings.Count(url, "("); unbalanced > 0 && strings.HasSuffix(url, ")"); unbalanced-- {
+		url = url[:len(url)-1]
+	}
+	if strings.HasSuffix(url, "://") {
+		return ""
+	}
+	return url
+}
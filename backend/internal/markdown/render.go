@@ -0,0 +1,469 @@
+// Package markdown renders todo descriptions written in CommonMark, with the
+// GitHub extensions for tables, task lists, strikethrough and bare links, as
+// HTML that is safe to embed in a page. Raw HTML in the source is never
+// passed through: it is escaped like any other text. The rendered HTML then
+// goes through an allowlist sanitizer as a second line of defense.
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Options control what rendered HTML may reference
+type Options struct {
+	// ImagePrefixes are the URL prefixes images may be loaded from. Other
+	// images are rendered as a link to their URL.
+	ImagePrefixes []string
+}
+
+var (
+	atxHeadingPattern     = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*))?$`)
+	closingHashesPattern  = regexp.MustCompile(`(?:^|[ \t]+)#+[ \t]*$`)
+	thematicBreakPattern  = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	setextPattern         = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	fencePattern          = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})(.*)$")
+	blockquotePattern     = regexp.MustCompile(`^ {0,3}> ?`)
+	listItemPattern       = regexp.MustCompile(`^( {0,3})([-*+]|\d{1,9}[.)])([ \t]+|$)`)
+	taskPattern           = regexp.MustCompile(`^\[([ xX])\](?:[ \t]+|$)`)
+	tableDelimiterPattern = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(?:\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	languagePattern       = regexp.MustCompile(`^[A-Za-z0-9_+#.-]{1,32}$`)
+	entityPattern         = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+	uriAutolinkPattern    = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^\x00-\x20<>]*)>`)
+	emailAutolinkPattern  = regexp.MustCompile(`^<([A-Za-z0-9.!#$%&'*+/=?^_{|}~-]+@[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*)>`)
+)
+
+// inlineSpecials are the bytes that may start inline markup
+const inlineSpecials = "\\`![<*_~&h\n"
+
+// maxNesting bounds how deeply lists and block quotes, and emphasis and
+// links, nest. Each level parses its content again, so deeper markup is
+// rendered as text to keep rendering linear in the length of the source.
+const maxNesting = 32
+
+// renderer writes the HTML of a document as it parses it
+type renderer struct {
+	opts        Options
+	out         strings.Builder
+	depth       int
+	inlineDepth int
+}
+
+// ToHTML renders a Markdown source as sanitized HTML
+func ToHTML(source string, opts Options) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = strings.ReplaceAll(source, "\r", "\n")
+	source = strings.ReplaceAll(source, "\x00", "\uFFFD")
+	lines := strings.Split(source, "\n")
+	for i, line := range lines {
+		lines[i] = expandIndent(line)
+	}
+
+	r := renderer{opts: opts}
+	r.blocks(lines, false)
+	return Sanitize(r.out.String(), opts)
+}
+
+// expandIndent turns the tabs a line starts with into spaces, to tab stops
+// of four, so indentation can be measured in spaces
+func expandIndent(line string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			b.WriteByte(' ')
+		case '\t':
+			b.WriteString(strings.Repeat(" ", 4-b.Len()%4))
+		default:
+			if b.Len() == i {
+				return line
+			}
+			return b.String() + line[i:]
+		}
+	}
+	return b.String()
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// leadingSpaces counts the spaces a line starts with
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// isFence reports whether m, a match of fencePattern, opens a code fence.
+// The info string of a backtick fence may not contain backticks.
+func isFence(m []string) bool {
+	return m != nil && !(m[2][0] == '`' && strings.Contains(m[3], "`"))
+}
+
+// startsBlock reports whether a line starts a block that ends a paragraph
+// continued lazily inside a list item or block quote
+func startsBlock(line string) bool {
+	return isBlank(line) ||
+		atxHeadingPattern.MatchString(line) ||
+		thematicBreakPattern.MatchString(line) ||
+		isFence(fencePattern.FindStringSubmatch(line)) ||
+		blockquotePattern.MatchString(line) ||
+		listItemPattern.MatchString(line)
+}
+
+// blocks renders lines as a sequence of blocks. In a tight list item,
+// paragraphs are written without <p> tags.
+func (r *renderer) blocks(lines []string, tight bool) {
+	var para []string
+	flush := func() {
+		if len(para) == 0 {
+			return
+		}
+		if !tight {
+			r.out.WriteString("<p>")
+		}
+		r.out.WriteString(r.inline(joinParagraph(para)))
+		if !tight {
+			r.out.WriteString("</p>")
+		}
+		r.out.WriteString("\n")
+		para = nil
+	}
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		if isBlank(line) {
+			flush()
+			i++
+			continue
+		}
+		if len(para) > 0 {
+			if m := setextPattern.FindStringSubmatch(line); m != nil {
+				level := 1
+				if m[1][0] == '-' {
+					level = 2
+				}
+				fmt.Fprintf(&r.out, "<h%d>%s</h%d>\n", level, r.inline(joinParagraph(para)), level)
+				para = nil
+				i++
+				continue
+			}
+		} else if leadingSpaces(line) >= 4 {
+			i = r.indentedCode(lines, i)
+			continue
+		}
+		if m := fencePattern.FindStringSubmatch(line); isFence(m) {
+			flush()
+			i = r.fencedCode(lines, i, m)
+			continue
+		}
+		if m := atxHeadingPattern.FindStringSubmatch(line); m != nil {
+			flush()
+			content := closingHashesPattern.ReplaceAllString(strings.TrimSpace(m[2]), "")
+			fmt.Fprintf(&r.out, "<h%d>%s</h%d>\n", len(m[1]), r.inline(content), len(m[1]))
+			i++
+			continue
+		}
+		if thematicBreakPattern.MatchString(line) {
+			flush()
+			r.out.WriteString("<hr>\n")
+			i++
+			continue
+		}
+		if r.depth < maxNesting && blockquotePattern.MatchString(line) {
+			flush()
+			i = r.blockquote(lines, i)
+			continue
+		}
+		// A list only interrupts a paragraph with an item that has content
+		// and, when ordered, starts at 1
+		if m := listItemPattern.FindStringSubmatch(line); m != nil && r.depth < maxNesting &&
+			(len(para) == 0 || (len(line) > len(m[0]) && (strings.ContainsAny(m[2], "-*+") || listStart(m[2]) == 1))) {
+			flush()
+			i = r.list(lines, i)
+			continue
+		}
+		if len(para) == 0 {
+			if next := r.table(lines, i); next > i {
+				i = next
+				continue
+			}
+		}
+		para = append(para, line)
+		i++
+	}
+	flush()
+}
+
+// joinParagraph joins the lines of a paragraph. A line ending in two or more
+// spaces ends with a hard line break, written as the backslash form.
+func joinParagraph(lines []string) string {
+	for i, line := range lines {
+		line = strings.TrimLeft(line, " \t")
+		if i < len(lines)-1 && strings.HasSuffix(line, "  ") {
+			line = strings.TrimRight(line, " ") + "\\"
+		} else if i == len(lines)-1 {
+			line = strings.TrimRight(line, " \t")
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// indentedCode renders the code block indented by four spaces at lines[i]
+// and returns the index of the line after it
+func (r *renderer) indentedCode(lines []string, i int) int {
+	var code []string
+	for ; i < len(lines) && (isBlank(lines[i]) || leadingSpaces(lines[i]) >= 4); i++ {
+		if isBlank(lines[i]) {
+			code = append(code, strings.TrimPrefix(lines[i], "    "))
+		} else {
+			code = append(code, lines[i][4:])
+		}
+	}
+	for len(code) > 0 && isBlank(code[len(code)-1]) {
+		code = code[:len(code)-1]
+	}
+	r.out.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")+"\n") + "</code></pre>\n")
+	return i
+}
+
+// fencedCode renders the code fence opened at lines[i], matched as m, and
+// returns the index of the line after it. An unclosed fence runs to the end.
+func (r *renderer) fencedCode(lines []string, i int, m []string) int {
+	indent, fence := len(m[1]), m[2]
+	closing := regexp.MustCompile(`^ {0,3}` + regexp.QuoteMeta(fence) + regexp.QuoteMeta(fence[:1]) + `*[ \t]*$`)
+
+	class := ""
+	if fields := strings.Fields(m[3]); len(fields) > 0 {
+		if language := html.UnescapeString(fields[0]); languagePattern.MatchString(language) {
+			class = ` class="language-` + html.EscapeString(language) + `"`
+		}
+	}
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		if closing.MatchString(lines[i]) {
+			i++
+			break
+		}
+		line := lines[i]
+		line = line[min(indent, leadingSpaces(line)):]
+		code = append(code, line)
+	}
+	body := strings.Join(code, "\n")
+	if len(code) > 0 {
+		body += "\n"
+	}
+	r.out.WriteString("<pre><code" + class + ">" + html.EscapeString(body) + "</code></pre>\n")
+	return i
+}
+
+// blockquote renders the block quote starting at lines[i], with its lazy
+// continuation lines, and returns the index of the line after it
+func (r *renderer) blockquote(lines []string, i int) int {
+	var inner []string
+	for ; i < len(lines); i++ {
+		if m := blockquotePattern.FindString(lines[i]); m != "" {
+			inner = append(inner, expandIndent(lines[i][len(m):]))
+		} else if len(inner) > 0 && !isBlank(inner[len(inner)-1]) && !startsBlock(lines[i]) && leadingSpaces(lines[i]) < 4 {
+			inner = append(inner, lines[i])
+		} else {
+			break
+		}
+	}
+	r.out.WriteString("<blockquote>\n")
+	r.depth++
+	r.blocks(inner, false)
+	r.depth--
+	r.out.WriteString("</blockquote>\n")
+	return i
+}
+
+// listItem is an item of a list being parsed
+type listItem struct {
+	lines         []string
+	task, checked bool
+}
+
+// list renders the list starting at lines[i] and returns the index of the
+// line after it. The list is loose, with its paragraphs in <p> tags, when
+// any of its items are separated by or contain blank lines.
+func (r *renderer) list(lines []string, i int) int {
+	first := listItemPattern.FindStringSubmatch(lines[i])
+	marker := first[2]
+	ordered := !strings.ContainsAny(marker, "-*+")
+	kind := marker[len(marker)-1:]
+
+	var items []listItem
+	loose := false
+	endedBlank := false
+	for i < len(lines) {
+		m := listItemPattern.FindStringSubmatch(lines[i])
+		if m == nil || m[2][len(m[2])-1:] != kind || thematicBreakPattern.MatchString(lines[i]) {
+			break
+		}
+		if endedBlank {
+			loose = true
+		}
+
+		// Content more than four spaces past the marker is indented code
+		indent := len(m[1]) + len(m[2]) + len(m[3])
+		content := lines[i][len(m[0]):]
+		if len(m[3]) > 4 {
+			indent = len(m[1]) + len(m[2]) + 1
+			content = m[3][1:] + content
+		} else if content == "" {
+			indent = len(m[1]) + len(m[2]) + 1
+		}
+
+		var item listItem
+		if task := taskPattern.FindStringSubmatch(content); task != nil {
+			item.task, item.checked = true, task[1] != " "
+			content = content[len(task[0]):]
+		}
+		item.lines = []string{content}
+
+		blank := false
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if isBlank(line) {
+				blank = true
+				item.lines = append(item.lines, "")
+				continue
+			}
+			if leadingSpaces(line) >= indent {
+				item.lines = append(item.lines, line[indent:])
+				blank = false
+				continue
+			}
+			if blank || startsBlock(line) {
+				break
+			}
+			item.lines = append(item.lines, line)
+		}
+
+		trimmed := len(item.lines)
+		for trimmed > 1 && item.lines[trimmed-1] == "" {
+			trimmed--
+		}
+		endedBlank = trimmed < len(item.lines)
+		item.lines = item.lines[:trimmed]
+		for _, line := range item.lines[1:] {
+			if line == "" {
+				loose = true
+			}
+		}
+		items = append(items, item)
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+		if start := listStart(marker); start != 1 {
+			fmt.Fprintf(&r.out, "<ol start=\"%d\">\n", start)
+		} else {
+			r.out.WriteString("<ol>\n")
+		}
+	} else {
+		r.out.WriteString("<ul>\n")
+	}
+	for _, item := range items {
+		if item.task {
+			r.out.WriteString(`<li class="task-list-item"><input type="checkbox" disabled`)
+			if item.checked {
+				r.out.WriteString(" checked")
+			}
+			r.out.WriteString("> ")
+		} else {
+			r.out.WriteString("<li>")
+		}
+		body := renderer{opts: r.opts, depth: r.depth + 1}
+		body.blocks(item.lines, !loose)
+		r.out.WriteString(strings.TrimSuffix(body.out.String(), "\n"))
+		r.out.WriteString("</li>\n")
+	}
+	r.out.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// listStart returns the number of an ordered list marker
+func listStart(marker string) int {
+	start, _ := strconv.Atoi(strings.TrimRight(marker, ".)"))
+	return start
+}
+
+// table renders the table whose header row is lines[i] and returns the index
+// of the line after it, or i when lines[i] does not start a table
+func (r *renderer) table(lines []string, i int) int {
+	if i+1 >= len(lines) || !strings.Contains(lines[i], "|") || !tableDelimiterPattern.MatchString(lines[i+1]) {
+		return i
+	}
+	header := splitTableRow(lines[i])
+	delimiters := splitTableRow(lines[i+1])
+	if len(header) != len(delimiters) {
+		return i
+	}
+	aligns := make([]string, len(delimiters))
+	for j, delimiter := range delimiters {
+		left, right := strings.HasPrefix(delimiter, ":"), strings.HasSuffix(delimiter, ":")
+		switch {
+		case left && right:
+			aligns[j] = ` align="center"`
+		case left:
+			aligns[j] = ` align="left"`
+		case right:
+			aligns[j] = ` align="right"`
+		}
+	}
+
+	row := func(cells []string, tag string) {
+		r.out.WriteString("<tr>\n")
+		for j := range header {
+			cell := ""
+			if j < len(cells) {
+				cell = cells[j]
+			}
+			r.out.WriteString("<" + tag + aligns[j] + ">" + r.inline(cell) + "</" + tag + ">\n")
+		}
+		r.out.WriteString("</tr>\n")
+	}
+
+	r.out.WriteString("<table>\n<thead>\n")
+	row(header, "th")
+	r.out.WriteString("</thead>\n")
+	i += 2
+	if i < len(lines) && !startsBlock(lines[i]) {
+		r.out.WriteString("<tbody>\n")
+		for ; i < len(lines) && !startsBlock(lines[i]); i++ {
+			row(splitTableRow(lines[i]), "td")
+		}
+		r.out.WriteString("</tbody>\n")
+	}
+	r.out.WriteString("</table>\n")
+	return i
+}
+
+// splitTableRow returns the trimmed cells of a table row, split on pipes
+// that are not escaped with a backslash
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	start := 0
+	for j := 0; j < len(line); j++ {
+		switch line[j] {
+		case '\\':
+			j++
+		case '|':
+			cells = append(cells, strings.TrimSpace(line[start:j]))
+			start = j + 1
+		}
+	}
+	return append(cells, strings.TrimSpace(line[start:]))
+}
@@ -0,0 +1,68 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// nestedList returns a list nested depth levels deep by indentation
+func nestedList(depth int) string {
+	var b strings.Builder
+	for i := range depth {
+		b.WriteString(strings.Repeat("  ", i) + "- item\n")
+	}
+	return b.String()
+}
+
+// Rendering stays linear in the length of the source for markup that nests
+// deeply or never closes; each of these took minutes when every level or
+// opener rescanned the rest of the source
+func TestToHTMLLinearTime(t *testing.T) {
+	const size = 300_000
+	inputs := map[string]string{
+		"nested list":            nestedList(400),
+		"nested list on a line":  strings.Repeat("- ", size/2) + "a",
+		"nested quote on a line": strings.Repeat("> ", size/2) + "a",
+		"nested quotes":          strings.Repeat(">", size),
+		"unclosed emphasis":      strings.Repeat("*a ", size/3),
+		"nested emphasis":        strings.Repeat("*a _", size/8) + strings.Repeat("a_ a*", size/8),
+		"unclosed brackets":      strings.Repeat("[", size),
+		"unclosed links":         strings.Repeat("[a](", size/4),
+		"unclosed titles":        strings.Repeat("[a](x \"", size/7),
+		"unclosed code spans":    strings.Repeat("`a ``a ```a ", size/12),
+		"nested links":           strings.Repeat("[", size/2) + strings.Repeat("](x)", size/8),
+		"closing parens":         "http://a" + strings.Repeat(")", size),
+	}
+	for name, source := range inputs {
+		start := time.Now()
+		ToHTML(source, testOptions)
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("%s: rendering %d bytes took %v", name, len(source), elapsed)
+		}
+	}
+}
+
+// Lists, block quotes and links nested deeper than maxNesting are rendered
+// as text
+func TestToHTMLNestingLimit(t *testing.T) {
+	out := ToHTML(nestedList(maxNesting+8), testOptions)
+	if n := strings.Count(out, "<ul>"); n != maxNesting {
+		t.Errorf("%d nested lists rendered, want %d", n, maxNesting)
+	}
+	if !strings.Contains(out, "- item") {
+		t.Errorf("the items past the limit were not kept as text: %s", out)
+	}
+
+	out = ToHTML(strings.Repeat(">", maxNesting+8)+" deep", testOptions)
+	if n := strings.Count(out, "<blockquote>"); n != maxNesting {
+		t.Errorf("%d nested block quotes rendered, want %d", n, maxNesting)
+	}
+
+	source := strings.Repeat("[", maxNesting+8) + "x" + strings.Repeat("](/todos/1)", maxNesting+8)
+	out = ToHTML(source, testOptions)
+	if n := strings.Count(out, "<a "); n != maxNesting {
+		t.Errorf("%d nested links rendered, want %d", n, maxNesting)
+	}
+	checkSafe(t, source, out)
+}
@@ -0,0 +1,205 @@
+package markdown
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// codeClassPattern matches the class of a fenced code block's language
+var codeClassPattern = regexp.MustCompile(`^language-[A-Za-z0-9_+#.-]{1,32}$`)
+
+// listStartPattern matches the start number of an ordered list
+var listStartPattern = regexp.MustCompile(`^[0-9]{1,9}$`)
+
+// plainTags are the elements kept without any attributes
+var plainTags = map[string]bool{
+	"p": true, "br": true, "hr": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"strong": true, "em": true, "del": true, "pre": true, "blockquote": true, "ul": true,
+	"table": true, "thead": true, "tbody": true, "tr": true,
+}
+
+// voidTags are the kept elements that have no end tag
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true, "input": true}
+
+// droppedTags are the elements removed with everything inside them. Other
+// elements that are not allowed are removed but keep their content.
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "frame": true, "frameset": true, "object": true, "embed": true,
+	"template": true, "noscript": true, "noembed": true, "noframes": true, "textarea": true, "select": true,
+	"title": true, "xmp": true, "plaintext": true, "svg": true, "math": true,
+}
+
+// safeLinkURL reports whether a link may point at raw: a relative URL or an
+// http, https or mailto one
+func safeLinkURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// allowedImage reports whether an image may be loaded from src: it must be
+// under one of prefixes and may not climb out of it with dot segments or
+// backslashes
+func allowedImage(src string, prefixes []string) bool {
+	if !safeLinkURL(src) || strings.Contains(src, "..") || strings.Contains(src, "\\") {
+		return false
+	}
+	u, err := url.Parse(strings.TrimSpace(src))
+	if err != nil || u.User != nil || u.Opaque != "" || strings.Contains(u.Path, "..") {
+		return false
+	}
+	for _, prefix := range prefixes {
+		if underPrefix(u, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// underPrefix reports whether u is under the URL prefix: the scheme and host
+// are the same, and the path is the prefix's path or continues it after a
+// "/", so https://cdn.example.com does not allow https://cdn.example.com.evil.net
+// and /img does not allow /imgs
+func underPrefix(u *url.URL, prefix string) bool {
+	if prefix == "" {
+		return false
+	}
+	p, err := url.Parse(prefix)
+	if err != nil || p.User != nil || p.Opaque != "" {
+		return false
+	}
+	if !strings.EqualFold(u.Scheme, p.Scheme) || !strings.EqualFold(u.Host, p.Host) {
+		return false
+	}
+	path, prefixPath := u.EscapedPath(), p.EscapedPath()
+	if prefixPath == "" || strings.HasSuffix(prefixPath, "/") {
+		return strings.HasPrefix(path, prefixPath)
+	}
+	return path == prefixPath || strings.HasPrefix(path, prefixPath+"/")
+}
+
+// Sanitize keeps the elements and attributes of an HTML fragment that
+// rendered Markdown needs and removes everything else, including event
+// handlers, styles and links to other schemes than http, https and mailto.
+// Links get rel="noopener noreferrer" and images must load from one of
+// opts.ImagePrefixes.
+func Sanitize(fragment string, opts Options) string {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		return html.EscapeString(fragment)
+	}
+	var b strings.Builder
+	for _, node := range nodes {
+		sanitizeNode(&b, node, opts)
+	}
+	return b.String()
+}
+
+// sanitizeNode writes the allowed parts of node and its children
+func sanitizeNode(b *strings.Builder, node *html.Node, opts Options) {
+	switch node.Type {
+	case html.TextNode:
+		b.WriteString(html.EscapeString(node.Data))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	if node.Namespace != "" || droppedTags[node.Data] {
+		return
+	}
+
+	attrs, ok := allowedAttributes(node, opts)
+	if ok {
+		b.WriteString("<" + node.Data)
+		for _, attr := range attrs {
+			b.WriteString(" " + attr.Key)
+			if attr.Val != "" {
+				b.WriteString(`="` + html.EscapeString(attr.Val) + `"`)
+			}
+		}
+		b.WriteString(">")
+		if voidTags[node.Data] {
+			return
+		}
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		sanitizeNode(b, child, opts)
+	}
+	if ok {
+		b.WriteString("</" + node.Data + ">")
+	}
+}
+
+// attribute returns the value of an attribute of node without a namespace
+func attribute(node *html.Node, key string) (string, bool) {
+	for _, attr := range node.Attr {
+		if attr.Namespace == "" && attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// allowedAttributes returns the attributes node keeps, and false when the
+// element itself is not allowed
+func allowedAttributes(node *html.Node, opts Options) ([]html.Attribute, bool) {
+	if plainTags[node.Data] {
+		return nil, true
+	}
+	var attrs []html.Attribute
+	keep := func(key string, allowed func(string) bool) {
+		if value, ok := attribute(node, key); ok && allowed(value) {
+			attrs = append(attrs, html.Attribute{Key: key, Val: value})
+		}
+	}
+	anyValue := func(string) bool { return true }
+
+	switch node.Data {
+	case "a":
+		keep("href", safeLinkURL)
+		if len(attrs) == 0 {
+			return nil, false
+		}
+		keep("title", anyValue)
+		attrs = append(attrs, html.Attribute{Key: "rel", Val: "noopener noreferrer"})
+	case "img":
+		keep("src", func(src string) bool { return allowedImage(src, opts.ImagePrefixes) })
+		if len(attrs) == 0 {
+			return nil, false
+		}
+		keep("alt", anyValue)
+		keep("title", anyValue)
+	case "code":
+		keep("class", codeClassPattern.MatchString)
+	case "ol":
+		keep("start", listStartPattern.MatchString)
+	case "li":
+		keep("class", func(class string) bool { return class == "task-list-item" })
+	case "th", "td":
+		keep("align", func(align string) bool { return align == "left" || align == "center" || align == "right" })
+	case "input":
+		if kind, _ := attribute(node, "type"); kind != "checkbox" {
+			return nil, false
+		}
+		attrs = append(attrs, html.Attribute{Key: "type", Val: "checkbox"})
+		if _, checked := attribute(node, "checked"); checked {
+			attrs = append(attrs, html.Attribute{Key: "checked"})
+		}
+		attrs = append(attrs, html.Attribute{Key: "disabled"})
+	default:
+		return nil, false
+	}
+	return attrs, true
+}
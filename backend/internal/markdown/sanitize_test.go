@@ -0,0 +1,243 @@
+package markdown
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var testOptions = Options{ImagePrefixes: []string{"https://cdn.example.com", "/uploads/"}}
+
+// allowedElements are the elements rendered Markdown may contain
+var allowedElements = map[string]bool{
+	"a": true, "img": true, "code": true, "ol": true, "li": true, "th": true, "td": true, "input": true,
+}
+
+// checkSafe fails t when out, parsed the way a browser would, has an element
+// or attribute the sanitizer does not allow, or a URL with a scheme other
+// than http, https or mailto
+func checkSafe(t *testing.T, source, out string) {
+	t.Helper()
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(out), context)
+	if err != nil {
+		t.Fatalf("%q rendered unparsable HTML %q: %v", source, out, err)
+	}
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			if !plainTags[node.Data] && !allowedElements[node.Data] || node.Namespace != "" {
+				t.Errorf("%q rendered a <%s> element: %s", source, node.Data, out)
+			}
+			for _, attr := range node.Attr {
+				switch key := strings.ToLower(attr.Key); {
+				case strings.HasPrefix(key, "on") || key == "style" || key == "srcdoc" || key == "formaction":
+					t.Errorf("%q rendered a %s attribute: %s", source, attr.Key, out)
+				case key == "href" || key == "src":
+					u, err := url.Parse(strings.TrimSpace(attr.Val))
+					if err != nil {
+						t.Errorf("%q rendered an unparsable %s %q: %s", source, attr.Key, attr.Val, out)
+						break
+					}
+					switch strings.ToLower(u.Scheme) {
+					case "", "http", "https", "mailto":
+					default:
+						t.Errorf("%q rendered a %s URL: %s", source, u.Scheme, out)
+					}
+				}
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	for _, node := range nodes {
+		walk(node)
+	}
+}
+
+// xssMarkdown are descriptions trying to get script into the rendered HTML
+var xssMarkdown = []string{
+	// javascript: URLs, in every way a link or image can carry one
+	"[x](javascript:alert(1))",
+	"[x](JaVaScRiPt:alert(1))",
+	"[x]( javascript:alert(1) )",
+	"[x](<javascript:alert(1)>)",
+	"[x](javascript&#58;alert(1))",
+	"[x](&#106;avascript:alert(1))",
+	"[x](&#x6A;avascript:alert(1))",
+	"[x](java&Tab;script:alert(1))",
+	"[x](javascript%3Aalert(1))",
+	"[x](\\javascript:alert(1))",
+	"<javascript:alert(1)>",
+	"<JAVASCRIPT:alert(1)>",
+	"![x](javascript:alert(1))",
+	"[x](vbscript:msgbox(1))",
+	"[x](https://example.com \"t\")[y](javascript:alert(1))",
+	// data: URLs
+	"[x](data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==)",
+	"![x](data:image/svg+xml;base64,PHN2ZyBvbmxvYWQ9YWxlcnQoMSk+)",
+	"<data:text/html,<script>alert(1)</script>>",
+	"[x](&#100;ata:text/html,alert(1))",
+	// event handlers and attribute breakouts
+	"<img src=x onerror=alert(1)>",
+	"<a href=\"https://example.com\" onclick=\"alert(1)\">x</a>",
+	"[x](https://example.com \"\\\" onmouseover=\\\"alert(1)\")",
+	"![x\" onerror=\"alert(1)](https://cdn.example.com/a.png)",
+	"![x](https://cdn.example.com/a.png \"\\\" onerror=\\\"alert(1)\")",
+	"[x](https://example.com/\"onmouseover=\"alert(1))",
+	"```js\" onclick=\"alert(1)\ncode\n```",
+	"| a |\n|:-\" onclick=\"alert(1)|\n| b |",
+	// raw HTML
+	"<script>alert(1)</script>",
+	"<SCRIPT SRC=https://evil.example/x.js></SCRIPT>",
+	"<iframe src=\"javascript:alert(1)\"></iframe>",
+	"<svg onload=alert(1)>",
+	"<style>body{background:url(javascript:alert(1))}</style>",
+	"<div style=\"background:url(javascript:alert(1))\">x</div>",
+	"<!-- --><script>alert(1)</script>",
+	"<![CDATA[<script>alert(1)</script>]]>",
+	"&lt;script&gt;alert(1)&lt;/script&gt;",
+	// nested and unclosed tags
+	"<scr<script>ipt>alert(1)</script>",
+	"<a href=\"javascript:alert(1)\"><img src=x onerror=alert(1)>",
+	"> <script>alert(1)\n> </script>",
+	"- <img src=x onerror=alert(1)\n- >",
+	"**<script>**alert(1)</script>",
+	"`<script>`alert(1)</script>",
+	"[<img src=x onerror=alert(1)>](https://example.com)",
+	"[[x](javascript:alert(1))](https://example.com)",
+	"![[x](javascript:alert(1))](https://cdn.example.com/a.png)",
+}
+
+func TestToHTMLBlocksXSS(t *testing.T) {
+	for _, source := range xssMarkdown {
+		checkSafe(t, source, ToHTML(source, testOptions))
+	}
+}
+
+// Raw HTML is escaped as text, never passed through
+func TestToHTMLEscapesRawHTML(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+		{"<img src=x onerror=alert(1)>", "<p>&lt;img src=x onerror=alert(1)&gt;</p>"},
+		{"a <b>bold</b>", "<p>a &lt;b&gt;bold&lt;/b&gt;</p>"},
+	}
+	for _, tt := range tests {
+		if got := strings.TrimSpace(ToHTML(tt.source, testOptions)); got != tt.want {
+			t.Errorf("ToHTML(%q) = %s, want %s", tt.source, got, tt.want)
+		}
+	}
+}
+
+// The sanitizer also holds on its own, for HTML that did not come from the
+// renderer
+func TestSanitizeBlocksXSS(t *testing.T) {
+	fragments := []string{
+		`<a href="javascript:alert(1)">x</a>`,
+		`<a href="  JAVASCRIPT:alert(1)">x</a>`,
+		`<a href="jav&#x09;ascript:alert(1)">x</a>`,
+		`<a href="&#106;&#97;&#118;&#97;&#115;&#99;&#114;&#105;&#112;&#116;&#58;alert(1)">x</a>`,
+		`<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">x</a>`,
+		`<img src="data:image/svg+xml,<svg onload=alert(1)>">`,
+		`<img src="https://cdn.example.com/a.png" onerror="alert(1)">`,
+		`<p onmouseover="alert(1)" style="x">x</p>`,
+		`<input type="checkbox" onfocus="alert(1)" autofocus>`,
+		`<input type="text" value="x">`,
+		`<code class="language-js" onclick="alert(1)">x</code>`,
+		`<td align="left" background="javascript:alert(1)">x</td>`,
+		`<svg><script>alert(1)</script></svg>`,
+		`<math><mtext><table><mglyph><style><img src=x onerror=alert(1)>`,
+		`<noscript><p title="</noscript><img src=x onerror=alert(1)>">`,
+		`<textarea><img src=x onerror=alert(1)></textarea>`,
+		`<template><img src=x onerror=alert(1)></template>`,
+		`<a href="https://example.com"><a href="javascript:alert(1)">x</a></a>`,
+		`<p><a href="javascript:alert(1)"><script>alert(1)`,
+		`<ul><li><img src=x onerror=alert(1)`,
+		`<object data="javascript:alert(1)"></object><embed src="javascript:alert(1)">`,
+		`<form action="javascript:alert(1)"><button formaction="javascript:alert(1)">x</button></form>`,
+		`<base href="javascript:alert(1)//">`,
+		`<meta http-equiv="refresh" content="0;url=javascript:alert(1)">`,
+		`<div><iframe srcdoc="<script>alert(1)</script>"></iframe></div>`,
+		`<a href="https://example.com" xlink:href="javascript:alert(1)">x</a>`,
+	}
+	for _, fragment := range fragments {
+		checkSafe(t, fragment, Sanitize(fragment, testOptions))
+	}
+}
+
+func TestSanitizeKeepsMarkdownHTML(t *testing.T) {
+	for _, fragment := range []string{
+		`<p><a href="https://example.com/a?b=1" title="t" rel="noopener noreferrer">x</a></p>`,
+		`<p><a href="mailto:a@example.com" rel="noopener noreferrer">a</a></p>`,
+		`<p><img src="https://cdn.example.com/a.png" alt="a" title="t"></p>`,
+		`<pre><code class="language-go">x</code></pre>`,
+		`<ol start="3"><li class="task-list-item"><input type="checkbox" checked disabled> x</li></ol>`,
+		`<table><thead><tr><th align="center">a</th></tr></thead><tbody><tr><td align="right">b</td></tr></tbody></table>`,
+	} {
+		if got := Sanitize(fragment, testOptions); got != fragment {
+			t.Errorf("Sanitize(%s) = %s", fragment, got)
+		}
+	}
+}
+
+func TestAllowedImage(t *testing.T) {
+	prefixes := []string{"https://cdn.example.com", "https://files.example.com/team/", "https://img.example.com/a", "/uploads/"}
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"https://cdn.example.com/x.png", true},
+		{"https://cdn.example.com", true},
+		{"https://CDN.example.com/x.png", true},
+		{"https://cdn.example.com.evil.net/x.png", false},
+		{"https://cdn.example.company/x.png", false},
+		{"https://cdn.example.com@evil.net/x.png", false},
+		{"https://user@cdn.example.com/x.png", false},
+		{"https://cdn.example.com:8443/x.png", false},
+		{"http://cdn.example.com/x.png", false},
+		{"//cdn.example.com/x.png", false},
+		{"https://evil.net/https://cdn.example.com/x.png", false},
+		{"https://files.example.com/team/x.png", true},
+		{"https://files.example.com/teams/x.png", false},
+		{"https://files.example.com/team", false},
+		{"https://files.example.com/other/x.png", false},
+		{"https://img.example.com/a", true},
+		{"https://img.example.com/a/x.png", true},
+		{"https://img.example.com/ab/x.png", false},
+		{"https://img.example.com/a/../b/x.png", false},
+		{"https://img.example.com/a/%2e%2e/b/x.png", false},
+		{"https://img.example.com/a\\..\\b/x.png", false},
+		{"/uploads/x.png", true},
+		{"/uploads", false},
+		{"/uploadsx/x.png", false},
+		{"//evil.net/uploads/x.png", false},
+		{"javascript:alert(1)//cdn.example.com", false},
+		{"data:image/png;base64,AAAA", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := allowedImage(tt.src, prefixes); got != tt.want {
+			t.Errorf("allowedImage(%q) = %t, want %t", tt.src, got, tt.want)
+		}
+	}
+	if allowedImage("https://cdn.example.com/x.png", nil) || allowedImage("https://cdn.example.com/x.png", []string{""}) {
+		t.Error("an image is allowed without prefixes")
+	}
+}
+
+// FuzzToHTML checks that no description renders script, whatever it holds
+func FuzzToHTML(f *testing.F) {
+	for _, source := range xssMarkdown {
+		f.Add(source)
+	}
+	f.Fuzz(func(t *testing.T, source string) {
+		checkSafe(t, source, ToHTML(source, testOptions))
+	})
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Todo event types shown in the activity timeline
+const (
+	TodoEventCreated          = "created"
+	TodoEventFieldChanged     = "field_changed"
+	TodoEventSubtaskAdded     = "subtask_added"
+	TodoEventSubtaskRenamed   = "subtask_renamed"
+	TodoEventSubtaskCompleted = "subtask_completed"
+	TodoEventSubtaskReopened  = "subtask_reopened"
+	TodoEventSubtaskDeleted   = "subtask_deleted"
+	TodoEventGitHubConflict   = "github_conflict"
+	TodoEventSplitInto        = "split_into"
+	TodoEventSplitFrom        = "split_from"
+	TodoEventSubtaskMoved     = "subtask_moved"
+	TodoEventLinkAdded        = "link_added"
+	TodoEventLinkRemoved      = "link_removed"
+	TodoEventNote             = "note"
+	TodoEventReminder         = "reminder"
+)
+
+// TodoEvent represents a single entry in a todo's activity timeline.
+// Subtask events keep the subtask title as it was when the event happened
+// so they still render after the subtask is deleted.
+type TodoEvent struct {
+	ID           int64     `json:"id" db:"id"`
+	TodoID       int64     `json:"todo_id" db:"todo_id"`
+	Type         string    `json:"type" db:"type" example:"field_changed"`
+	Actor        *string   `json:"actor,omitempty" db:"actor"`
+	Field        *string   `json:"field,omitempty" db:"field" example:"status"`
+	OldValue     *string   `json:"old_value,omitempty" db:"old_value" example:"todo"`
+	NewValue     *string   `json:"new_value,omitempty" db:"new_value" example:"in_progress"`
+	SubtaskID    *int64    `json:"subtask_id,omitempty" db:"subtask_id"`
+	SubtaskTitle *string   `json:"subtask_title,omitempty" db:"subtask_title"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
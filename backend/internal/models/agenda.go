@@ -0,0 +1,19 @@
+package models
+
+// AgendaDay is one day of a weekly agenda with the todos due that day
+type AgendaDay struct {
+	Date    string `json:"date" example:"2025-03-17"`
+	Weekday string `json:"weekday" example:"Monday"`
+	Todos   []Todo `json:"todos"`
+}
+
+// Agenda is an ISO week of todos grouped by the day they are due in
+// Timezone. InProgress holds started todos without a due date.
+type Agenda struct {
+	Week       string      `json:"week" example:"2025-W12"`
+	Timezone   string      `json:"timezone" example:"Europe/Berlin"`
+	StartDate  string      `json:"start_date" example:"2025-03-17"`
+	EndDate    string      `json:"end_date" example:"2025-03-23"`
+	Days       []AgendaDay `json:"days"`
+	InProgress []Todo      `json:"in_progress"`
+}
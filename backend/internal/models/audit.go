@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit actions recorded for mutations
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// Audit entity types
+const (
+	AuditEntityTodo       = "todo"
+	AuditEntitySubtask    = "subtask"
+	AuditEntitySprint     = "sprint"
+	AuditEntityEpic       = "epic"
+	AuditEntityLink       = "link"
+	AuditEntityEmbed      = "embed"
+	AuditEntityReminder   = "reminder"
+	AuditEntityFlag       = "feature_flag"
+	AuditEntityTodoPolicy = "todo_policy"
+)
+
+// AuditEntry represents a single row of the audit log
+type AuditEntry struct {
+	ID            int64           `json:"id" db:"id"`
+	Actor         *string         `json:"actor,omitempty" db:"actor"`
+	Action        string          `json:"action" db:"action"`
+	EntityType    string          `json:"entity_type" db:"entity_type"`
+	EntityID      int64           `json:"entity_id" db:"entity_id"`
+	Diff          json.RawMessage `json:"diff,omitempty" db:"diff" swaggertype:"object"`
+	DiffTruncated bool            `json:"diff_truncated" db:"diff_truncated"`
+	RequestID     *string         `json:"request_id,omitempty" db:"request_id"`
+	IP            *string         `json:"ip,omitempty" db:"ip"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+}
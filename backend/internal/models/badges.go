@@ -0,0 +1,11 @@
+package models
+
+// Badges are the counts shown in the navigation bar. Overdue todos are open
+// and past their due time, or the end of their date for all-day todos;
+// due_today counts the open todos due later today. Today is taken in
+// Timezone.
+type Badges struct {
+	Overdue  int    `json:"overdue" example:"3"`
+	DueToday int    `json:"due_today" example:"2"`
+	Timezone string `json:"timezone" example:"Europe/Berlin"`
+}
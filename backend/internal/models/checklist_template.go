@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ChecklistTemplate is a named, ordered list of subtask titles, such as a
+// definition of done, that can be applied to any todo
+type ChecklistTemplate struct {
+	ID        int64     `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name" example:"Definition of done"`
+	Items     []string  `json:"items" db:"items" example:"Tests written,Docs updated,Reviewed"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateChecklistTemplateRequest represents the request body for creating a
+// checklist template
+type CreateChecklistTemplateRequest struct {
+	Name  string   `json:"name" binding:"required,max=100" example:"Definition of done"`
+	Items []string `json:"items" binding:"required,min=1,max=100,dive,required,max=255" example:"Tests written,Docs updated,Reviewed"`
+}
+
+// UpdateChecklistTemplateRequest represents the request body for updating a
+// checklist template. Items, when given, replaces the whole list.
+type UpdateChecklistTemplateRequest struct {
+	Name  string   `json:"name,omitempty" binding:"max=100" example:"Definition of done"`
+	Items []string `json:"items,omitempty" binding:"omitempty,min=1,max=100,dive,required,max=255" example:"Tests written,Docs updated,Reviewed"`
+}
+
+// SaveChecklistTemplateRequest represents the request body for saving a
+// todo's subtasks as a checklist template
+type SaveChecklistTemplateRequest struct {
+	Name string `json:"name" binding:"required,max=100" example:"Definition of done"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Credential reports whether an integration credential is configured, never
+// its value. FromEnv is set when its environment variable overrides the
+// stored value.
+type Credential struct {
+	Name       string     `json:"name" example:"github_token"`
+	Configured bool       `json:"configured" example:"true"`
+	FromEnv    bool       `json:"from_env" example:"false"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// SetCredentialRequest represents the request body for storing an
+// integration credential
+type SetCredentialRequest struct {
+	Value string `json:"value" binding:"required,max=4096" example:"ghp_..."`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Custom field types
+const (
+	CustomFieldText   = "text"
+	CustomFieldNumber = "number"
+	CustomFieldDate   = "date"
+	CustomFieldSelect = "select"
+)
+
+// CustomFieldDefinition describes an extra field todos may carry under
+// custom_fields. Options lists the allowed values of a select field.
+type CustomFieldDefinition struct {
+	ID        int64     `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name" example:"environment"`
+	Type      string    `json:"type" db:"type" example:"select"`
+	Options   []string  `json:"options" db:"options" example:"dev,staging,prod"`
+	Required  bool      `json:"required" db:"required"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateCustomFieldRequest represents the request body for defining a custom field
+type CreateCustomFieldRequest struct {
+	Name     string   `json:"name" binding:"required" example:"environment"`
+	Type     string   `json:"type" binding:"required,oneof=text number date select" example:"select"`
+	Options  []string `json:"options,omitempty" example:"dev,staging,prod"`
+	Required bool     `json:"required" example:"false"`
+}
+
+// UpdateCustomFieldRequest represents the request body for updating a custom
+// field definition. Names and types cannot be changed.
+type UpdateCustomFieldRequest struct {
+	Options  []string `json:"options,omitempty" example:"dev,staging,prod"`
+	Required *bool    `json:"required,omitempty" example:"true"`
+}
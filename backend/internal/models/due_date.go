@@ -0,0 +1,157 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DateLayout is the format of date-only values such as all-day due dates
+const DateLayout = "2006-01-02"
+
+// localTimestampLayouts are accepted timestamps without a zone; they are
+// interpreted in the request's timezone
+var localTimestampLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// DueDateInput is a due date as sent by clients: an RFC3339 timestamp, a
+// timestamp without a zone, or a bare date, which makes the todo all-day.
+// Zone-less values are resolved with In once the request's timezone is known.
+type DueDateInput struct {
+	value    time.Time
+	hasZone  bool
+	DateOnly bool
+}
+
+// UnmarshalJSON parses any of the accepted due date formats
+func (d *DueDateInput) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("due_date must be a string")
+	}
+	parsed, err := ParseDateInput(s)
+	if err != nil {
+		return fmt.Errorf("invalid due_date %q, expected an RFC3339 timestamp or a YYYY-MM-DD date", s)
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalJSON writes the due date in the format it was parsed from, so API
+// clients can send a DueDateInput built with ParseDateInput
+func (d DueDateInput) MarshalJSON() ([]byte, error) {
+	switch {
+	case d.DateOnly:
+		return json.Marshal(d.value.Format(DateLayout))
+	case d.hasZone:
+		return json.Marshal(d.value.Format(time.RFC3339Nano))
+	default:
+		return json.Marshal(d.value.Format(localTimestampLayouts[0]))
+	}
+}
+
+// ParseDateInput parses a date or timestamp in any of the formats accepted
+// for due dates
+func ParseDateInput(s string) (DueDateInput, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return DueDateInput{value: t, hasZone: true}, nil
+	}
+	if t, err := time.Parse(DateLayout, s); err == nil {
+		return DueDateInput{value: t, DateOnly: true}, nil
+	}
+	for _, layout := range localTimestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return DueDateInput{value: t}, nil
+		}
+	}
+	return DueDateInput{}, fmt.Errorf("invalid date %q", s)
+}
+
+// In resolves the due date in loc. Dates become midnight in loc; timestamps
+// that carried a zone are returned unchanged.
+func (d DueDateInput) In(loc *time.Location) time.Time {
+	if d.hasZone {
+		return d.value
+	}
+	return time.Date(d.value.Year(), d.value.Month(), d.value.Day(), d.value.Hour(), d.value.Minute(), d.value.Second(), d.value.Nanosecond(), loc)
+}
+
+// locations caches loaded time zones by name
+var locations sync.Map
+
+// LoadLocation is time.LoadLocation with a cache, since the zone database
+// is read from disk on every call
+func LoadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// dueLocation returns the zone an all-day due date was set in, or UTC
+func dueLocation(name *string) *time.Location {
+	if name == nil {
+		return time.UTC
+	}
+	loc, err := LoadLocation(*name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// todoFields has Todo's fields without its JSON methods
+type todoFields Todo
+
+// MarshalJSON writes all-day due dates as a bare date in the zone they were
+// set in and other due dates as RFC3339 timestamps
+func (t Todo) MarshalJSON() ([]byte, error) {
+	var dueDate interface{}
+	if t.DueDate != nil {
+		dueDate = *t.DueDate
+		if t.AllDay {
+			dueDate = t.DueDate.In(dueLocation(t.DueTimezone)).Format(DateLayout)
+		}
+	}
+	return json.Marshal(struct {
+		todoFields
+		DueDate interface{} `json:"due_date,omitempty"`
+	}{todoFields(t), dueDate})
+}
+
+// UnmarshalJSON reads todos written by MarshalJSON, such as revision snapshots
+func (t *Todo) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*todoFields
+		DueDate *string `json:"due_date,omitempty"`
+	}{todoFields: (*todoFields)(t)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	t.DueDate = nil
+	if aux.DueDate == nil {
+		return nil
+	}
+	if parsed, err := time.Parse(time.RFC3339Nano, *aux.DueDate); err == nil {
+		t.DueDate = &parsed
+		return nil
+	}
+	parsed, err := time.ParseInLocation(DateLayout, *aux.DueDate, dueLocation(t.DueTimezone))
+	if err != nil {
+		return fmt.Errorf("invalid due_date %q: %w", *aux.DueDate, err)
+	}
+	parsed = parsed.UTC()
+	t.DueDate = &parsed
+	return nil
+}
@@ -0,0 +1,210 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q): %v", name, err)
+	}
+	return loc
+}
+
+func TestParseDateInput(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	tests := []struct {
+		input    string
+		dateOnly bool
+		want     time.Time // resolved in Europe/Berlin
+	}{
+		{"2025-03-01", true, time.Date(2025, 3, 1, 0, 0, 0, 0, berlin)},
+		{"2025-03-01T09:30:00Z", false, time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)},
+		{"2025-03-01T09:30:00+05:00", false, time.Date(2025, 3, 1, 4, 30, 0, 0, time.UTC)},
+		{"2025-03-01T09:30:00.123Z", false, time.Date(2025, 3, 1, 9, 30, 0, 123000000, time.UTC)},
+		{"2025-03-01T09:30:00", false, time.Date(2025, 3, 1, 9, 30, 0, 0, berlin)},
+		{"2025-03-01T09:30", false, time.Date(2025, 3, 1, 9, 30, 0, 0, berlin)},
+		{"2025-03-01 09:30:00", false, time.Date(2025, 3, 1, 9, 30, 0, 0, berlin)},
+		{"2025-03-01 09:30", false, time.Date(2025, 3, 1, 9, 30, 0, 0, berlin)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parsed, err := ParseDateInput(tt.input)
+			if err != nil {
+				t.Fatalf("ParseDateInput: %v", err)
+			}
+			if parsed.DateOnly != tt.dateOnly {
+				t.Errorf("DateOnly = %t, want %t", parsed.DateOnly, tt.dateOnly)
+			}
+			if got := parsed.In(berlin); !got.Equal(tt.want) {
+				t.Errorf("In(Europe/Berlin) = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateInputRejects(t *testing.T) {
+	for _, input := range []string{"", "tomorrow", "2025-13-01", "2025-02-30", "01/03/2025", "2025-03-01T25:00"} {
+		if _, err := ParseDateInput(input); err == nil {
+			t.Errorf("ParseDateInput(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestDueDateInputJSON(t *testing.T) {
+	for _, input := range []string{`"2025-03-01"`, `"2025-03-01T09:30:00Z"`, `"2025-03-01T09:30:00"`} {
+		var parsed DueDateInput
+		if err := json.Unmarshal([]byte(input), &parsed); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", input, err)
+		}
+		data, err := json.Marshal(parsed)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		if string(data) != input {
+			t.Errorf("round trip of %s gave %s", input, data)
+		}
+	}
+
+	var parsed DueDateInput
+	if err := json.Unmarshal([]byte(`12`), &parsed); err == nil {
+		t.Error("Unmarshal of a number succeeded, want an error")
+	}
+}
+
+// All-day todos are stored as midnight of their date in the zone they were
+// set in. Around a DST change that midnight has a different UTC offset than
+// the day before, which must not shift the date shown back to clients.
+func TestAllDayDueDateRoundTripAcrossDST(t *testing.T) {
+	tests := []struct {
+		zone string
+		date string
+	}{
+		{"America/New_York", "2025-03-08"},
+		{"America/New_York", "2025-03-09"},
+		{"America/New_York", "2025-03-10"},
+		{"America/New_York", "2025-11-02"},
+		{"America/New_York", "2025-11-03"},
+		{"Europe/Berlin", "2025-03-30"},
+		{"Europe/Berlin", "2025-10-26"},
+		// Samoa skipped 2011-12-30 entirely
+		{"Pacific/Apia", "2011-12-31"},
+		{"Australia/Lord_Howe", "2025-04-06"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.zone+"/"+tt.date, func(t *testing.T) {
+			loc := mustLocation(t, tt.zone)
+			input, err := ParseDateInput(tt.date)
+			if err != nil {
+				t.Fatalf("ParseDateInput: %v", err)
+			}
+			stored := input.In(loc).UTC()
+			zone := loc.String()
+			todo := Todo{ID: 1, Title: "t", DueDate: &stored, AllDay: true, DueTimezone: &zone}
+
+			data, err := json.Marshal(todo)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			var shown struct {
+				DueDate string `json:"due_date"`
+			}
+			if err := json.Unmarshal(data, &shown); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if shown.DueDate != tt.date {
+				t.Fatalf("due_date = %q, want %q", shown.DueDate, tt.date)
+			}
+
+			var back Todo
+			if err := json.Unmarshal(data, &back); err != nil {
+				t.Fatalf("Unmarshal into Todo: %v", err)
+			}
+			if back.DueDate == nil || !back.DueDate.Equal(stored) {
+				t.Errorf("due date after round trip = %v, want %s", back.DueDate, stored)
+			}
+		})
+	}
+}
+
+// A zone-less timestamp inside the spring-forward gap does not exist in the
+// zone. time.Date resolves it with one of the two offsets around the gap, so
+// it lands within the hour that was skipped rather than failing.
+func TestZonelessTimestampInDSTGap(t *testing.T) {
+	loc := mustLocation(t, "America/New_York")
+	input, err := ParseDateInput("2025-03-09T02:30:00")
+	if err != nil {
+		t.Fatalf("ParseDateInput: %v", err)
+	}
+	got := input.In(loc).UTC()
+	edt := time.Date(2025, 3, 9, 6, 30, 0, 0, time.UTC)
+	est := time.Date(2025, 3, 9, 7, 30, 0, 0, time.UTC)
+	if !got.Equal(edt) && !got.Equal(est) {
+		t.Errorf("In(America/New_York) = %s, want %s or %s", got, edt, est)
+	}
+}
+
+func TestTimedDueDateMarshalsAsTimestamp(t *testing.T) {
+	due := time.Date(2025, 11, 2, 6, 30, 0, 0, time.UTC)
+	data, err := json.Marshal(Todo{ID: 1, DueDate: &due})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var shown struct {
+		DueDate string `json:"due_date"`
+	}
+	if err := json.Unmarshal(data, &shown); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if shown.DueDate != "2025-11-02T06:30:00Z" {
+		t.Errorf("due_date = %q, want 2025-11-02T06:30:00Z", shown.DueDate)
+	}
+}
+
+// A date set in a zone ahead of UTC is stored as the previous evening in
+// UTC, and a date set in UTC as midnight itself; either way it is shown back
+// as the date that was set. Asia/Kolkata is +05:30 all year, so its midnight
+// is never on a UTC hour.
+func TestAllDayDueDateAtMidnight(t *testing.T) {
+	tests := []struct {
+		zone   string
+		date   string
+		stored time.Time
+	}{
+		{"Asia/Kolkata", "2025-03-10", time.Date(2025, 3, 9, 18, 30, 0, 0, time.UTC)},
+		{"Asia/Kolkata", "2025-01-01", time.Date(2024, 12, 31, 18, 30, 0, 0, time.UTC)},
+		{"UTC", "2025-03-10", time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{"UTC", "2025-01-01", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.zone+"/"+tt.date, func(t *testing.T) {
+			input, err := ParseDateInput(tt.date)
+			if err != nil {
+				t.Fatalf("ParseDateInput: %v", err)
+			}
+			stored := input.In(mustLocation(t, tt.zone)).UTC()
+			if !stored.Equal(tt.stored) {
+				t.Fatalf("stored as %s, want %s", stored, tt.stored)
+			}
+			zone := tt.zone
+			data, err := json.Marshal(Todo{ID: 1, Title: "t", DueDate: &stored, AllDay: true, DueTimezone: &zone})
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			var shown struct {
+				DueDate string `json:"due_date"`
+			}
+			if err := json.Unmarshal(data, &shown); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if shown.DueDate != tt.date {
+				t.Errorf("due_date = %q, want %q", shown.DueDate, tt.date)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Embed is a read-only feed of todos for embedding in another site, such as a
+// wiki page. It is read with a signed token and only from its origin.
+type Embed struct {
+	ID        int64        `json:"id" db:"id"`
+	Name      string       `json:"name" db:"name" example:"Wiki: open billing work"`
+	Origin    string       `json:"origin" db:"origin" example:"https://wiki.example.com"`
+	Filters   EmbedFilters `json:"filters" db:"filters"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+}
+
+// EmbedFilters selects the todos an embed shows. Without statuses it shows
+// the todos that are not done.
+type EmbedFilters struct {
+	Statuses []string `json:"statuses,omitempty" example:"todo,in_progress"`
+	EpicID   *int64   `json:"epic_id,omitempty" example:"2"`
+	SprintID *int64   `json:"sprint_id,omitempty" example:"4"`
+}
+
+// CreatedEmbed is an embed with its token, which is only returned when the
+// embed is created
+type CreatedEmbed struct {
+	Embed
+	Token string `json:"token" example:"7.kq3xU0cPzB9v8m1YtRQe2wLh6nAJfGdVsZo4iTrHbE8"`
+}
+
+// CreateEmbedRequest represents the request body for creating an embed
+type CreateEmbedRequest struct {
+	Name    string       `json:"name" binding:"required,max=100" example:"Wiki: open billing work"`
+	Origin  string       `json:"origin" binding:"required,max=255" example:"https://wiki.example.com"`
+	Filters EmbedFilters `json:"filters"`
+}
+
+// EmbedTodo is the projection of a todo served to embeds. Descriptions and
+// everything else about the todo are left out. All-day due dates are written
+// as a bare date, as for todos.
+type EmbedTodo struct {
+	Title       string     `json:"title" example:"Migrate invoices"`
+	Status      string     `json:"status" example:"in_progress"`
+	DueDate     *time.Time `json:"due_date" swaggertype:"string" example:"2025-03-14"`
+	AllDay      bool       `json:"all_day"`
+	DueTimezone *string    `json:"-"`
+	Priority    *string    `json:"priority" example:"high"`
+}
+
+// embedTodoFields has EmbedTodo's fields without its JSON method
+type embedTodoFields EmbedTodo
+
+// MarshalJSON writes all-day due dates as a bare date in the zone they were
+// set in and other due dates as RFC3339 timestamps
+func (t EmbedTodo) MarshalJSON() ([]byte, error) {
+	var dueDate interface{}
+	if t.DueDate != nil {
+		dueDate = *t.DueDate
+		if t.AllDay {
+			dueDate = t.DueDate.In(dueLocation(t.DueTimezone)).Format(DateLayout)
+		}
+	}
+	return json.Marshal(struct {
+		embedTodoFields
+		DueDate interface{} `json:"due_date"`
+	}{embedTodoFields(t), dueDate})
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Epic statuses
+const (
+	EpicStatusOpen   = "open"
+	EpicStatusClosed = "closed"
+)
+
+// Epic represents a parent grouping of related todos
+type Epic struct {
+	ID          int64         `json:"id" db:"id"`
+	Title       string        `json:"title" db:"title"`
+	Description string        `json:"description" db:"description"`
+	Color       string        `json:"color" db:"color" example:"#6366f1"`
+	Status      string        `json:"status" db:"status"`
+	Progress    *EpicProgress `json:"progress,omitempty" db:"-"`
+	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// EpicProgress is the rolled-up state of an epic's todos. Todos count as done
+// when their status is a done status. PercentComplete is based on story points
+// when any todo has them, otherwise on todo counts. SubtaskProgress is the
+// percent of the todos' subtasks completed, and PointsProgress the percent of
+// story points complete, counting open todos by the share of their subtasks
+// completed; both are null when there is nothing to measure.
+// EstimateMinutes sums the todos' estimates and TrackedSeconds the time
+// tracked on them, including running timers, to compare the two.
+type EpicProgress struct {
+	TodosByStatus   map[string]int `json:"todos_by_status"`
+	TotalTodos      int            `json:"total_todos"`
+	TodosDone       int            `json:"todos_done"`
+	PointsDone      int            `json:"points_done"`
+	PointsTotal     int            `json:"points_total"`
+	PercentComplete int            `json:"percent_complete"`
+	SubtaskProgress *int           `json:"subtask_progress" example:"40"`
+	PointsProgress  *int           `json:"points_progress" example:"55"`
+	EstimateMinutes int            `json:"estimate_minutes" example:"480"`
+	TrackedSeconds  int64          `json:"tracked_seconds" example:"25200"`
+}
+
+// TodoEpic is the slim epic embedded in todo responses
+type TodoEpic struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Color string `json:"color"`
+}
+
+// CreateEpicRequest represents the request body for creating an epic
+type CreateEpicRequest struct {
+	Title       string `json:"title" binding:"required" example:"Billing revamp"`
+	Description string `json:"description" example:"Everything for the new invoicing flow"`
+	Color       string `json:"color,omitempty" example:"#6366f1"`
+}
+
+// UpdateEpicRequest represents the request body for updating an epic
+type UpdateEpicRequest struct {
+	Title       string  `json:"title" example:"Billing revamp"`
+	Description *string `json:"description,omitempty" example:"Everything for the new invoicing flow"`
+	Color       string  `json:"color,omitempty" example:"#6366f1"`
+	Status      string  `json:"status,omitempty" example:"closed" binding:"omitempty,oneof=open closed"`
+}
+
+// EpicTodosRequest represents the request body for adding or removing todos
+// from an epic
+type EpicTodosRequest struct {
+	TodoIDs []int64 `json:"todo_ids" binding:"required,min=1" example:"1,2,3"`
+}
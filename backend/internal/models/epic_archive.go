@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// EpicArchiveVersion is the format version of epic archives, recorded in
+// their manifest.json
+const EpicArchiveVersion = 1
+
+// EpicArchiveManifest is manifest.json of an epic archive: its format version
+// and how many items the other files hold
+type EpicArchiveManifest struct {
+	Version    int       `json:"version" example:"1"`
+	ExportedAt time.Time `json:"exported_at"`
+	EpicID     int64     `json:"epic_id" example:"2"`
+	Todos      int       `json:"todos" example:"14"`
+	Subtasks   int       `json:"subtasks" example:"37"`
+	Links      int       `json:"links" example:"5"`
+}
+
+// EpicArchiveEpic is epic.json of an epic archive
+type EpicArchiveEpic struct {
+	Title       string `json:"title" example:"Billing revamp"`
+	Description string `json:"description"`
+	Color       string `json:"color" example:"#6366f1"`
+	Status      string `json:"status" example:"open"`
+}
+
+// EpicArchiveTodo is one todo of todos.json in an epic archive. IDs are not
+// kept; importing creates new ones.
+type EpicArchiveTodo struct {
+	Title        string                 `json:"title"`
+	Description  string                 `json:"description"`
+	Status       string                 `json:"status"`
+	PriorityKey  *string                `json:"priority_key"`
+	DueDate      *time.Time             `json:"due_date"`
+	AllDay       bool                   `json:"all_day"`
+	DueTimezone  *string                `json:"due_timezone"`
+	StoryPoints  *int                   `json:"story_points"`
+	Estimate     *int                   `json:"estimate_minutes"`
+	CustomFields map[string]interface{} `json:"custom_fields"`
+	CompletedAt  *time.Time             `json:"completed_at"`
+	CreatedAt    time.Time              `json:"created_at"`
+	Subtasks     []EpicArchiveSubtask   `json:"subtasks"`
+	Links        []EpicArchiveLink      `json:"links"`
+}
+
+// EpicArchiveSubtask is a subtask of an archived todo, in display order
+type EpicArchiveSubtask struct {
+	Title       string     `json:"title"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// EpicArchiveLink is a URL attached to an archived todo
+type EpicArchiveLink struct {
+	URL        string  `json:"url"`
+	Title      *string `json:"title"`
+	FaviconURL *string `json:"favicon_url"`
+}
+
+// EpicImportResult is the epic created by importing an archive and how many
+// items were created with it
+type EpicImportResult struct {
+	Epic     Epic `json:"epic"`
+	Todos    int  `json:"todos" example:"14"`
+	Subtasks int  `json:"subtasks" example:"37"`
+	Links    int  `json:"links" example:"5"`
+	DryRun   bool `json:"dry_run" example:"false"`
+}
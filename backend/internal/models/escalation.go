@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// EscalationSettings is the priority escalation rule. When enabled, todos
+// that are not done and are due within DueWithinHours are raised to
+// TargetPriorityKey unless they are already at least that urgent.
+type EscalationSettings struct {
+	Enabled           bool      `json:"enabled"`
+	DueWithinHours    int       `json:"due_within_hours" example:"24"`
+	TargetPriorityKey *string   `json:"target_priority_key" example:"high"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// UpdateEscalationSettingsRequest represents the request body for updating
+// the escalation rule. Omitted fields are left unchanged.
+type UpdateEscalationSettingsRequest struct {
+	Enabled           *bool   `json:"enabled,omitempty" example:"true"`
+	DueWithinHours    *int    `json:"due_within_hours,omitempty" binding:"omitempty,min=1" example:"24"`
+	TargetPriorityKey *string `json:"target_priority_key,omitempty" example:"high"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Sources of an estimate change
+const (
+	EstimateChangeUpdate = "update"
+	EstimateChangeImport = "import"
+)
+
+// EstimateChange is one change of a todo's story points. OldPoints is null
+// when the todo had none, as for imports, and NewPoints when they were
+// cleared. Delta is only set when both are.
+type EstimateChange struct {
+	ID        int64     `json:"id"`
+	TodoID    int64     `json:"todo_id"`
+	OldPoints *int      `json:"old_story_points" example:"3"`
+	NewPoints *int      `json:"new_story_points" example:"5"`
+	Delta     *int      `json:"delta,omitempty" example:"2"`
+	Source    string    `json:"source" example:"update"`
+	Actor     string    `json:"actor" example:"local"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EstimationDrift summarizes the story point changes made in a date range.
+// Grew and Shrank count re-estimations of todos that had points before and
+// after; Set and Cleared count points given to or taken from a todo. The
+// averages are over re-estimations and null when there are none.
+type EstimationDrift struct {
+	From                 *time.Time `json:"from,omitempty"`
+	To                   *time.Time `json:"to,omitempty"`
+	Changes              int64      `json:"changes" example:"42"`
+	Grew                 int64      `json:"grew" example:"25"`
+	Shrank               int64      `json:"shrank" example:"9"`
+	Set                  int64      `json:"set" example:"6"`
+	Cleared              int64      `json:"cleared" example:"2"`
+	AverageDelta         *float64   `json:"average_delta" example:"0.85"`
+	AverageAbsoluteDelta *float64   `json:"average_absolute_delta" example:"2.1"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Export job statuses
+const (
+	ExportQueued    = "queued"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+	ExportExpired   = "expired"
+)
+
+// ExportFilters select the todos an export job writes. Deferred todos are
+// included; unset filters are not applied.
+type ExportFilters struct {
+	Status        *string    `json:"status,omitempty" example:"done"`
+	SprintID      *int64     `json:"sprint_id,omitempty" example:"3"`
+	EpicID        *int64     `json:"epic_id,omitempty" example:"2"`
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+}
+
+// ExportJob is an asynchronous export of todos to a JSON, CSV or ZIP file.
+// RowsTotal is counted when the job starts; Error holds why the last attempt
+// failed.
+type ExportJob struct {
+	ID            int64         `json:"id"`
+	Format        string        `json:"format" example:"csv"`
+	Filters       ExportFilters `json:"filters"`
+	Status        string        `json:"status" example:"running"`
+	RowsTotal     *int64        `json:"rows_total,omitempty" example:"200000"`
+	RowsProcessed int64         `json:"rows_processed" example:"48000"`
+	Bytes         *int64        `json:"bytes,omitempty" example:"10485760"`
+	Error         *string       `json:"error,omitempty"`
+	Attempts      int           `json:"attempts" example:"1"`
+	RequestedBy   string        `json:"requested_by" example:"local"`
+	CreatedAt     time.Time     `json:"created_at"`
+	StartedAt     *time.Time    `json:"started_at,omitempty"`
+	FinishedAt    *time.Time    `json:"finished_at,omitempty"`
+	ExpiresAt     *time.Time    `json:"expires_at,omitempty"`
+}
+
+// CreateExportRequest represents the request body for starting an export job
+type CreateExportRequest struct {
+	Format  string        `json:"format" binding:"required,oneof=json csv zip" example:"csv"`
+	Filters ExportFilters `json:"filters"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// FeatureFlag gates a behavior being rolled out. It is on for a subject, the
+// request's workspace, when Enabled and the subject is in Allowlist or its
+// bucket for the flag is below RolloutPercent.
+type FeatureFlag struct {
+	ID             int64     `json:"id"`
+	Name           string    `json:"name" example:"strict_params"`
+	Description    string    `json:"description" example:"Reject unknown list parameters"`
+	Enabled        bool      `json:"enabled" example:"true"`
+	RolloutPercent int       `json:"rollout_percent" example:"25"`
+	Allowlist      []string  `json:"allowlist" example:"team-a"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// UpdateFeatureFlagRequest represents the request body for creating or
+// replacing a feature flag
+type UpdateFeatureFlagRequest struct {
+	Description    string   `json:"description" binding:"max=500" example:"Reject unknown list parameters"`
+	Enabled        bool     `json:"enabled" example:"true"`
+	RolloutPercent int      `json:"rollout_percent" binding:"min=0,max=100" example:"25"`
+	Allowlist      []string `json:"allowlist" binding:"max=1000,dive,min=1,max=200" example:"team-a"`
+}
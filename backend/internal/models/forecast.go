@@ -0,0 +1,39 @@
+package models
+
+// Forecast statuses
+const (
+	ForecastOK               = "ok"
+	ForecastInsufficientData = "insufficient_data"
+)
+
+// Forecast units: story points, or todos when open work is not fully estimated
+const (
+	ForecastUnitPoints = "points"
+	ForecastUnitTodos  = "todos"
+)
+
+// ForecastWeek is the work completed in one of the weeks a forecast is based on
+type ForecastWeek struct {
+	StartDate string `json:"start_date" example:"2025-03-03"`
+	EndDate   string `json:"end_date" example:"2025-03-09"`
+	Completed int    `json:"completed" example:"13"`
+}
+
+// Forecast projects when the open todos of a scope will be done at the pace
+// of recent weeks. Remaining and velocities are in Unit. The dates are left
+// out with insufficient_data, and PessimisticDate also when the worst week
+// completed nothing.
+type Forecast struct {
+	Scope           string         `json:"scope" example:"epic:3"`
+	Unit            string         `json:"unit" example:"points"`
+	Status          string         `json:"status" example:"ok"`
+	Reason          string         `json:"reason,omitempty"`
+	Remaining       int            `json:"remaining" example:"40"`
+	Velocity        float64        `json:"velocity" example:"10.5"`
+	BestWeek        int            `json:"best_week" example:"15"`
+	WorstWeek       int            `json:"worst_week" example:"6"`
+	ProjectedDate   *string        `json:"projected_date,omitempty" example:"2025-04-14"`
+	OptimisticDate  *string        `json:"optimistic_date,omitempty" example:"2025-04-04"`
+	PessimisticDate *string        `json:"pessimistic_date,omitempty" example:"2025-05-08"`
+	Weeks           []ForecastWeek `json:"weeks"`
+}
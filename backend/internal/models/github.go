@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// GitHubLink represents the GitHub issue a todo mirrors
+type GitHubLink struct {
+	TodoID      int64      `json:"todo_id" db:"todo_id"`
+	Owner       string     `json:"owner" db:"owner" example:"octocat"`
+	Repo        string     `json:"repo" db:"repo" example:"hello-world"`
+	IssueNumber int        `json:"issue_number" db:"issue_number" example:"42"`
+	SyncStatus  bool       `json:"sync_status" db:"sync_status"`
+	IssueTitle  *string    `json:"issue_title,omitempty" db:"issue_title"`
+	IssueState  *string    `json:"issue_state,omitempty" db:"issue_state" example:"open"`
+	SyncedAt    *time.Time `json:"synced_at,omitempty" db:"synced_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateGitHubLinkRequest represents the request body for linking a todo to an issue
+type CreateGitHubLinkRequest struct {
+	Issue      string `json:"issue" binding:"required" example:"octocat/hello-world#42"`
+	SyncStatus bool   `json:"sync_status" example:"true"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Integration health statuses
+const (
+	IntegrationOK            = "ok"
+	IntegrationFailing       = "failing"
+	IntegrationNotConfigured = "not_configured"
+)
+
+// IntegrationHealth is the outcome of the latest check of one integration.
+// LastSuccessAt and LastError carry over from earlier checks so a failing
+// integration shows when it last worked.
+type IntegrationHealth struct {
+	Name          string     `json:"name" example:"github"`
+	Status        string     `json:"status" example:"ok"`
+	LatencyMS     *int64     `json:"latency_ms,omitempty" example:"182"`
+	Message       string     `json:"message,omitempty" example:"4980 of 5000 requests left"`
+	CheckedAt     *time.Time `json:"checked_at,omitempty"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	LastError     *string    `json:"last_error,omitempty"`
+	LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// IntegrityCheckResult is what one consistency check found. IDs lists at
+// most the first 100 offending rows; Count is the full number.
+type IntegrityCheckResult struct {
+	Name        string  `json:"name" example:"todo_completed_at"`
+	Description string  `json:"description" example:"Todos whose completed_at does not match whether their status is done"`
+	Count       int     `json:"count" example:"3"`
+	IDs         []int64 `json:"ids"`
+	Truncated   bool    `json:"truncated" example:"false"`
+}
+
+// IntegrityReport is the result of running the consistency checks
+type IntegrityReport struct {
+	Checks    []IntegrityCheckResult `json:"checks"`
+	CheckedAt time.Time              `json:"checked_at"`
+}
+
+// FixIntegrityRequest selects the consistency checks whose findings to
+// clean up. No checks means all of them.
+type FixIntegrityRequest struct {
+	Checks []string `json:"checks" example:"todo_completed_at"`
+}
+
+// IntegrityFix is how many offending rows a check found and cleaned up
+type IntegrityFix struct {
+	Name  string `json:"name" example:"todo_completed_at"`
+	Found int    `json:"found" example:"3"`
+	Fixed int    `json:"fixed" example:"3"`
+}
+
+// IntegrityFixResult is the result of cleaning up consistency check findings
+type IntegrityFixResult struct {
+	DryRun bool           `json:"dry_run" example:"false"`
+	Fixes  []IntegrityFix `json:"fixes"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Link metadata states. Metadata is fetched in the background after a link
+// is created, so new links start out pending.
+const (
+	LinkMetadataPending = "pending"
+	LinkMetadataFetched = "fetched"
+	LinkMetadataFailed  = "failed"
+	LinkMetadataSkipped = "skipped"
+)
+
+// TodoLink is a URL attached to a todo. Title and FaviconURL come from the
+// page when its metadata could be fetched.
+type TodoLink struct {
+	ID             int64     `json:"id" db:"id"`
+	TodoID         int64     `json:"todo_id" db:"todo_id"`
+	URL            string    `json:"url" db:"url" example:"https://example.com/spec"`
+	Title          *string   `json:"title,omitempty" db:"title" example:"Design spec"`
+	FaviconURL     *string   `json:"favicon_url,omitempty" db:"favicon_url" example:"https://example.com/favicon.ico"`
+	MetadataStatus string    `json:"metadata_status" db:"metadata_status" example:"fetched"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// CreateTodoLinkRequest represents the request body for attaching a link.
+// A given title is kept instead of the fetched one; FetchMetadata defaults
+// to true.
+type CreateTodoLinkRequest struct {
+	URL           string `json:"url" binding:"required" example:"https://example.com/spec"`
+	Title         string `json:"title,omitempty" binding:"max=300" example:"Design spec"`
+	FetchMetadata *bool  `json:"fetch_metadata,omitempty" example:"true"`
+}
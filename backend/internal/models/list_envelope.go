@@ -0,0 +1,20 @@
+package models
+
+// ListMeta describes the page of a list wrapped in a ListEnvelope. Limit is
+// left out for lists that were not paged; NextCursor is passed back to get
+// the next page, as cursor for GET /todos and as offset elsewhere, and is
+// left out on the last one.
+type ListMeta struct {
+	Total      int64   `json:"total" example:"123"`
+	Limit      *int    `json:"limit,omitempty" example:"50"`
+	Offset     int     `json:"offset" example:"0"`
+	NextCursor *string `json:"next_cursor,omitempty" example:"50"`
+}
+
+// ListEnvelope is a list response requested with envelope=true: the items
+// that are otherwise returned as a bare array, with the paging metadata of
+// the X-Total-Count header in the body
+type ListEnvelope struct {
+	Data interface{} `json:"data"`
+	Meta ListMeta    `json:"meta"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// TodoLock is an advisory lock taken while someone edits a todo. It expires
+// unless its holder renews it with a heartbeat.
+type TodoLock struct {
+	TodoID     int64     `json:"todo_id" example:"12"`
+	LockedBy   string    `json:"locked_by" example:"local"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
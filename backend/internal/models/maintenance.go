@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// MaintenanceSettings is the maintenance mode flag. While enabled the API
+// answers 503 to writes, and to reads as well unless ReadOnly is set.
+type MaintenanceSettings struct {
+	Enabled           bool      `json:"enabled"`
+	ReadOnly          bool      `json:"read_only"`
+	Message           string    `json:"message" example:"Upgrading the database, back in five minutes."`
+	RetryAfterSeconds int       `json:"retry_after_seconds" example:"300"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// UpdateMaintenanceRequest represents the request body for toggling
+// maintenance mode. Omitted fields are left unchanged.
+type UpdateMaintenanceRequest struct {
+	Enabled           *bool   `json:"enabled,omitempty" example:"true"`
+	ReadOnly          *bool   `json:"read_only,omitempty" example:"true"`
+	Message           *string `json:"message,omitempty" example:"Upgrading the database, back in five minutes."`
+	RetryAfterSeconds *int    `json:"retry_after_seconds,omitempty" binding:"omitempty,min=1" example:"300"`
+}
@@ -0,0 +1,42 @@
+package models
+
+// Preferences are a user's settings. Stored values are merged over the
+// defaults, so every field is always present.
+type Preferences struct {
+	DefaultSort   SortPreference          `json:"default_sort"`
+	DefaultView   string                  `json:"default_view" example:"list"`
+	PageSize      int                     `json:"page_size" example:"50"`
+	Timezone      string                  `json:"timezone" example:"Europe/Berlin"`
+	WeekStart     string                  `json:"week_start" example:"monday"`
+	Notifications NotificationPreferences `json:"notifications"`
+}
+
+// SortPreference is the sort applied to todo lists when none is requested
+type SortPreference struct {
+	SortBy string `json:"sort_by" example:"due_date"`
+	Order  string `json:"order" example:"asc"`
+}
+
+// NotificationPreferences toggle notifications. DigestHour is the hour of
+// the day, in the user's timezone, at which digests are sent.
+type NotificationPreferences struct {
+	EmailDigest  bool `json:"email_digest"`
+	DueReminders bool `json:"due_reminders"`
+	DigestHour   int  `json:"digest_hour" example:"8"`
+}
+
+// DefaultPreferences returns the preferences of a user who changed nothing
+func DefaultPreferences() Preferences {
+	return Preferences{
+		DefaultSort: SortPreference{SortBy: "created_at", Order: "desc"},
+		DefaultView: "list",
+		PageSize:    50,
+		Timezone:    "UTC",
+		WeekStart:   "monday",
+		Notifications: NotificationPreferences{
+			EmailDigest:  false,
+			DueReminders: true,
+			DigestHour:   8,
+		},
+	}
+}
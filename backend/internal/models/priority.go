@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Priority represents a configurable todo priority. Higher weights are more
+// urgent. Todos without a priority, or whose priority was deleted, use the
+// default one.
+type Priority struct {
+	Key       string    `json:"key" db:"key" example:"critical"`
+	Label     string    `json:"label" db:"label" example:"Critical"`
+	Weight    int       `json:"weight" db:"weight" example:"400"`
+	Color     string    `json:"color" db:"color" example:"#b91c1c"`
+	IsDefault bool      `json:"is_default" db:"is_default"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreatePriorityRequest represents the request body for creating a priority.
+// Without a weight the priority is added as the most urgent.
+type CreatePriorityRequest struct {
+	Key       string `json:"key" binding:"required" example:"critical"`
+	Label     string `json:"label" binding:"required" example:"Critical"`
+	Weight    *int   `json:"weight,omitempty" example:"400"`
+	Color     string `json:"color,omitempty" example:"#b91c1c"`
+	IsDefault bool   `json:"is_default" example:"false"`
+}
+
+// UpdatePriorityRequest represents the request body for updating a priority.
+// Setting IsDefault moves the default from the current default priority.
+type UpdatePriorityRequest struct {
+	Label     string `json:"label" example:"Critical"`
+	Color     string `json:"color,omitempty" example:"#b91c1c"`
+	IsDefault bool   `json:"is_default" example:"false"`
+}
+
+// ReorderPrioritiesRequest lists every priority key from most to least urgent
+type ReorderPrioritiesRequest struct {
+	Keys []string `json:"keys" binding:"required,min=1" example:"critical,high,medium,low"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// TodoReference is a todo named by a #<id> reference in a description, with
+// what a client needs to render it
+type TodoReference struct {
+	ID     int64  `json:"id" example:"42"`
+	Title  string `json:"title" example:"Fix login redirect"`
+	Status string `json:"status" example:"in_progress"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// TodoReminder is a reminder relative to a todo's due date. Offset is an ISO
+// 8601 duration such as -P2D or -PT2H; RemindAt is the due date plus Offset
+// and is left out while the todo has no due date. FiredAt is set once the
+// reminder fired for the current due date; moving the due date clears it.
+type TodoReminder struct {
+	ID        int64      `json:"id" db:"id"`
+	TodoID    int64      `json:"todo_id" db:"todo_id"`
+	Offset    string     `json:"offset" db:"reminder_offset" example:"-P2D"`
+	RemindAt  *time.Time `json:"remind_at,omitempty" db:"-"`
+	FiredAt   *time.Time `json:"fired_at,omitempty" db:"fired_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateTodoReminderRequest represents the request body for adding a
+// reminder to a todo
+type CreateTodoReminderRequest struct {
+	Offset string `json:"offset" binding:"required" example:"-PT2H"`
+}
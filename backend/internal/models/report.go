@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// WeeklyReportTodo is a todo listed in a weekly report. At is when it
+// entered the section: completed, created or due.
+type WeeklyReportTodo struct {
+	ID          int64     `json:"id" example:"42"`
+	Title       string    `json:"title" example:"Ship release notes"`
+	Status      string    `json:"status" example:"done"`
+	StoryPoints *int      `json:"story_points" example:"3"`
+	EpicID      *int64    `json:"epic_id" example:"2"`
+	At          time.Time `json:"at"`
+}
+
+// WeeklyReportSection is a list of todos with their count and story points
+type WeeklyReportSection struct {
+	Count       int                `json:"count" example:"4"`
+	StoryPoints int                `json:"story_points" example:"11"`
+	Todos       []WeeklyReportTodo `json:"todos"`
+}
+
+// WeeklyReportEpic is the todos of one epic completed in the week. EpicID
+// and EpicTitle are null for todos outside any epic.
+type WeeklyReportEpic struct {
+	EpicID      *int64             `json:"epic_id" example:"2"`
+	EpicTitle   *string            `json:"epic_title" example:"Onboarding"`
+	Count       int                `json:"count" example:"3"`
+	StoryPoints int                `json:"story_points" example:"8"`
+	Todos       []WeeklyReportTodo `json:"todos"`
+}
+
+// WeeklyReportCompleted is the todos completed in the week, by epic
+type WeeklyReportCompleted struct {
+	Count       int                `json:"count" example:"5"`
+	StoryPoints int                `json:"story_points" example:"13"`
+	ByEpic      []WeeklyReportEpic `json:"by_epic"`
+}
+
+// WeeklyReportStatus is the work in one started, not done status
+type WeeklyReportStatus struct {
+	Status      string `json:"status" example:"in_progress"`
+	Label       string `json:"label" example:"In Progress"`
+	Count       int    `json:"count" example:"6"`
+	StoryPoints int    `json:"story_points" example:"15"`
+}
+
+// WeeklyReportWIP is the work in progress when the report is made
+type WeeklyReportWIP struct {
+	Count       int                  `json:"count" example:"9"`
+	StoryPoints int                  `json:"story_points" example:"21"`
+	ByStatus    []WeeklyReportStatus `json:"by_status"`
+}
+
+// WeeklyReport summarizes an ISO week in Timezone: the todos completed,
+// created and gone overdue during it, and the current work in progress.
+// Every section is present, with zero counts and empty lists in a quiet week.
+type WeeklyReport struct {
+	Week          string                `json:"week" example:"2025-W12"`
+	Timezone      string                `json:"timezone" example:"Europe/Berlin"`
+	StartDate     string                `json:"start_date" example:"2025-03-17"`
+	EndDate       string                `json:"end_date" example:"2025-03-23"`
+	Completed     WeeklyReportCompleted `json:"completed"`
+	Created       WeeklyReportSection   `json:"created"`
+	BecameOverdue WeeklyReportSection   `json:"became_overdue"`
+	WIP           WeeklyReportWIP       `json:"wip"`
+}
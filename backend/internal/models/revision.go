@@ -0,0 +1,25 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// FieldChange describes the value of a single field before and after a change
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// TodoRevision represents a snapshot of a todo taken before an update.
+// Changes lists the fields that differ between this snapshot and the next
+// version (or the current todo for the most recent revision).
+type TodoRevision struct {
+	ID        int64                  `json:"id" db:"id"`
+	TodoID    int64                  `json:"todo_id" db:"todo_id"`
+	Version   int                    `json:"version" db:"version"`
+	Snapshot  json.RawMessage        `json:"snapshot" db:"snapshot" swaggertype:"object"`
+	Actor     *string                `json:"actor,omitempty" db:"actor"`
+	Changes   map[string]FieldChange `json:"changes" db:"-"`
+	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+}
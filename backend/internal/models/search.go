@@ -0,0 +1,23 @@
+package models
+
+// SearchResult is one item found by GET /search. Type is todo, epic or
+// sprint. Subtitle is the epic of a todo, the number of todos in an epic or
+// the dates of a sprint.
+type SearchResult struct {
+	Type     string  `json:"type" example:"todo"`
+	ID       int64   `json:"id" example:"12"`
+	Title    string  `json:"title" example:"Fix login bug"`
+	Subtitle *string `json:"subtitle,omitempty" example:"Authentication"`
+}
+
+// SearchGroup holds the results of one type, best first
+type SearchGroup struct {
+	Type    string         `json:"type" example:"todo"`
+	Results []SearchResult `json:"results"`
+}
+
+// SearchResults are the results of GET /search grouped by type. Groups are
+// ordered by their best result.
+type SearchResults struct {
+	Groups []SearchGroup `json:"groups"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Service statuses reported by GET /status, from best to worst
+const (
+	ServiceStatusOK       = "ok"
+	ServiceStatusDegraded = "degraded"
+	ServiceStatusDown     = "down"
+)
+
+// ServiceCheck is the outcome of one service health check. Message is meant
+// for a public status page and never carries internal details.
+type ServiceCheck struct {
+	Name    string `json:"name" example:"database"`
+	Status  string `json:"status" example:"ok"`
+	Message string `json:"message" example:"Database is reachable"`
+}
+
+// ServiceStatusSample is the overall status of one past evaluation
+type ServiceStatusSample struct {
+	Status    string    `json:"status" example:"ok"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// ServiceStatus is the public health of the service: the worst status of
+// its checks, and the last evaluations, oldest first, with the share of them
+// that were ok
+type ServiceStatus struct {
+	Status    string                `json:"status" example:"ok"`
+	Checks    []ServiceCheck        `json:"checks"`
+	CheckedAt time.Time             `json:"checked_at"`
+	History   []ServiceStatusSample `json:"history"`
+	Uptime    float64               `json:"uptime" example:"0.98"`
+}
@@ -0,0 +1,91 @@
+package models
+
+import "time"
+
+// SettingsFormatVersion is the version of SettingsDocument written by exports
+const SettingsFormatVersion = 1
+
+// Settings conflict strategies for imports
+const (
+	SettingsConflictSkip      = "skip"
+	SettingsConflictOverwrite = "overwrite"
+)
+
+// Settings import actions
+const (
+	SettingsActionCreate    = "create"
+	SettingsActionUnchanged = "unchanged"
+	SettingsActionSkip      = "skip"
+	SettingsActionOverwrite = "overwrite"
+)
+
+// SettingsStatus is a status in a settings document. Statuses are matched
+// by key; Position orders new statuses after the existing ones.
+type SettingsStatus struct {
+	Key      string `json:"key" example:"review"`
+	Label    string `json:"label" example:"In Review"`
+	Color    string `json:"color" example:"#a855f7"`
+	Position int    `json:"position" example:"3"`
+	IsDone   bool   `json:"is_done"`
+}
+
+// SettingsPriority is a priority in a settings document, matched by key
+type SettingsPriority struct {
+	Key       string `json:"key" example:"critical"`
+	Label     string `json:"label" example:"Critical"`
+	Weight    int    `json:"weight" example:"400"`
+	Color     string `json:"color" example:"#b91c1c"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// SettingsCustomField is a custom field definition in a settings document,
+// matched by name
+type SettingsCustomField struct {
+	Name     string   `json:"name" example:"environment"`
+	Type     string   `json:"type" example:"select"`
+	Options  []string `json:"options" example:"dev,staging,prod"`
+	Required bool     `json:"required"`
+}
+
+// SettingsEscalation is the escalation rule in a settings document
+type SettingsEscalation struct {
+	Enabled           bool    `json:"enabled"`
+	DueWithinHours    int     `json:"due_within_hours" example:"24"`
+	TargetPriorityKey *string `json:"target_priority_key" example:"high"`
+}
+
+// SettingsDocument is the configuration exported by GET /settings/export and
+// applied by POST /settings/import. It holds no todo data. A nil Workflow,
+// Escalation, NoteRules or StoryPoints leaves the existing one alone on
+// import.
+type SettingsDocument struct {
+	Version      int                   `json:"version" example:"1"`
+	ExportedAt   *time.Time            `json:"exported_at,omitempty"`
+	Statuses     []SettingsStatus      `json:"statuses"`
+	Priorities   []SettingsPriority    `json:"priorities"`
+	CustomFields []SettingsCustomField `json:"custom_fields"`
+	Workflow     []StatusTransition    `json:"workflow"`
+	Escalation   *SettingsEscalation   `json:"escalation,omitempty"`
+	NoteRules    []NoteRule            `json:"note_rules"`
+	StoryPoints  []int                 `json:"story_points,omitempty" example:"1,2,3,5,8"`
+}
+
+// SettingsImportItem is what an import does, or would do, with one item.
+// Kind is status, priority, custom_field, workflow, escalation, note_rules or
+// story_points; Key is the
+// status or priority key or the field name. Conflicts lists the fields that
+// differ from the existing item.
+type SettingsImportItem struct {
+	Kind      string   `json:"kind" example:"status"`
+	Key       string   `json:"key,omitempty" example:"review"`
+	Action    string   `json:"action" example:"skip"`
+	Conflicts []string `json:"conflicts,omitempty" example:"label,color"`
+}
+
+// SettingsImportResult lists the outcome of every item of an import. With
+// DryRun nothing was changed.
+type SettingsImportResult struct {
+	DryRun     bool                 `json:"dry_run"`
+	OnConflict string               `json:"on_conflict" example:"skip"`
+	Items      []SettingsImportItem `json:"items"`
+}
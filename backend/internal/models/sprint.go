@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// Sprint states
+const (
+	SprintStatePlanned = "planned"
+	SprintStateActive  = "active"
+	SprintStateClosed  = "closed"
+)
+
+// Sprint represents a time-boxed grouping of todos. Dates are calendar days
+// formatted as YYYY-MM-DD; Capacity is in story points.
+type Sprint struct {
+	ID        int64     `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	StartDate string    `json:"start_date" db:"start_date" example:"2025-03-03"`
+	EndDate   string    `json:"end_date" db:"end_date" example:"2025-03-14"`
+	Capacity  *int      `json:"capacity,omitempty" db:"capacity"`
+	State     string    `json:"state" db:"state"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateSprintRequest represents the request body for creating a sprint
+type CreateSprintRequest struct {
+	Name      string `json:"name" binding:"required" example:"Sprint 12"`
+	StartDate string `json:"start_date" binding:"required" example:"2025-03-03"`
+	EndDate   string `json:"end_date" binding:"required" example:"2025-03-14"`
+	Capacity  *int   `json:"capacity,omitempty" binding:"omitempty,min=0" example:"30"`
+}
+
+// UpdateSprintRequest represents the request body for updating a sprint
+type UpdateSprintRequest struct {
+	Name      string `json:"name" example:"Sprint 12"`
+	StartDate string `json:"start_date" example:"2025-03-03"`
+	EndDate   string `json:"end_date" example:"2025-03-14"`
+	Capacity  *int   `json:"capacity,omitempty" binding:"omitempty,min=0" example:"30"`
+}
+
+// CloseSprintRequest represents the request body for closing a sprint.
+// Incomplete todos move to NextSprintID, or back to the backlog when it is nil.
+type CloseSprintRequest struct {
+	NextSprintID *int64 `json:"next_sprint_id,omitempty" example:"13"`
+}
+
+// CloseSprintResult is returned after closing a sprint
+type CloseSprintResult struct {
+	Sprint       Sprint  `json:"sprint"`
+	MovedTodoIDs []int64 `json:"moved_todo_ids"`
+}
+
+// SprintTodosRequest represents the request body for adding or removing
+// todos from a sprint
+type SprintTodosRequest struct {
+	TodoIDs []int64 `json:"todo_ids" binding:"required,min=1" example:"1,2,3"`
+}
+
+// BurndownDay is the work remaining in a sprint at the end of one day
+type BurndownDay struct {
+	Date            string `json:"date" example:"2025-03-03"`
+	RemainingPoints int    `json:"remaining_points"`
+	RemainingTodos  int    `json:"remaining_todos"`
+}
+
+// Burndown is the day-by-day remaining work of a sprint
+type Burndown struct {
+	SprintID    int64         `json:"sprint_id"`
+	Capacity    *int          `json:"capacity,omitempty"`
+	TotalPoints int           `json:"total_points"`
+	Days        []BurndownDay `json:"days"`
+}
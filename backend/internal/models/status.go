@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Status represents a configurable todo status, shown as a board column.
+// Todos in a status with IsDone set count as completed.
+type Status struct {
+	Key       string    `json:"key" db:"key" example:"review"`
+	Label     string    `json:"label" db:"label" example:"In Review"`
+	Color     string    `json:"color" db:"color" example:"#a855f7"`
+	Position  int       `json:"position" db:"position"`
+	IsDone    bool      `json:"is_done" db:"is_done"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateStatusRequest represents the request body for creating a status.
+// Without a position the status is added as the last column.
+type CreateStatusRequest struct {
+	Key      string `json:"key" binding:"required" example:"review"`
+	Label    string `json:"label" binding:"required" example:"In Review"`
+	Color    string `json:"color,omitempty" example:"#a855f7"`
+	Position *int   `json:"position,omitempty" binding:"omitempty,min=1" example:"3"`
+	IsDone   bool   `json:"is_done" example:"false"`
+}
+
+// UpdateStatusRequest represents the request body for updating a status
+type UpdateStatusRequest struct {
+	Label  string `json:"label" example:"In Review"`
+	Color  string `json:"color,omitempty" example:"#a855f7"`
+	IsDone *bool  `json:"is_done,omitempty" example:"false"`
+}
+
+// ReorderStatusesRequest lists every status key in the new column order
+type ReorderStatusesRequest struct {
+	Keys []string `json:"keys" binding:"required,min=1" example:"todo,in_progress,review,done"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// StoryPointScale is the set of story point values todos may be given, in
+// ascending order. Todos keep values that a later scale left out.
+type StoryPointScale struct {
+	Values    []int     `json:"values" example:"1,2,3,5,8"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UpdateStoryPointScaleRequest represents the request body for replacing the
+// story point scale: 1 to 20 distinct positive values in any order
+type UpdateStoryPointScaleRequest struct {
+	Values []int `json:"values" binding:"required,min=1,max=20,dive,min=1" example:"1,2,3,5,8,13,21"`
+}
@@ -2,14 +2,22 @@ package models
 
 import "time"
 
-// Subtask represents a subtask item belonging to a todo
+// Subtask represents a subtask item belonging to a todo. CompletedAt is when
+// it was last completed and is empty while it is open.
 type Subtask struct {
-	ID        int64     `json:"id" db:"id"`
-	TodoID    int64     `json:"todo_id" db:"todo_id"`
-	Title     string    `json:"title" db:"title"`
-	Completed bool      `json:"completed" db:"completed"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID          int64      `json:"id" db:"id"`
+	TodoID      int64      `json:"todo_id" db:"todo_id"`
+	Title       string     `json:"title" db:"title"`
+	Completed   bool       `json:"completed" db:"completed"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// MatchedSubtask is a subtask whose title matched a todo search
+type MatchedSubtask struct {
+	ID    int64  `json:"id" example:"7"`
+	Title string `json:"title" example:"Renew certificate"`
 }
 
 // CreateSubtaskRequest represents the request body for creating a subtask
@@ -22,3 +30,19 @@ type UpdateSubtaskRequest struct {
 	Title     string `json:"title" example:"Buy organic milk"`
 	Completed bool   `json:"completed" example:"false"`
 }
+
+// SubtaskCountDrift is a todo whose stored subtask counters disagreed with
+// its subtasks. The stored values have been replaced by the actual ones.
+type SubtaskCountDrift struct {
+	TodoID          int64 `json:"todo_id" example:"12"`
+	StoredCount     int   `json:"stored_count" example:"4"`
+	StoredCompleted int   `json:"stored_completed" example:"1"`
+	ActualCount     int   `json:"actual_count" example:"5"`
+	ActualCompleted int   `json:"actual_completed" example:"2"`
+}
+
+// SubtaskCountReconciliation is the result of recomputing every todo's
+// subtask counters
+type SubtaskCountReconciliation struct {
+	Drifted []SubtaskCountDrift `json:"drifted"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// TimeEntry represents a span of time tracked against a todo. A running
+// timer has no EndedAt; its duration is measured up to now.
+type TimeEntry struct {
+	ID              int64      `json:"id" db:"id"`
+	TodoID          int64      `json:"todo_id" db:"todo_id"`
+	StartedAt       time.Time  `json:"started_at" db:"started_at"`
+	EndedAt         *time.Time `json:"ended_at,omitempty" db:"ended_at"`
+	DurationSeconds int64      `json:"duration_seconds" db:"-"`
+	Note            string     `json:"note" db:"note"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// StartTimerRequest represents the request body for starting a timer
+type StartTimerRequest struct {
+	Note string `json:"note" example:"Pairing on the migration"`
+}
+
+// CreateTimeEntryRequest represents the request body for a manual time entry
+type CreateTimeEntryRequest struct {
+	StartedAt time.Time `json:"started_at" binding:"required" example:"2024-12-30T09:00:00Z"`
+	EndedAt   time.Time `json:"ended_at" binding:"required" example:"2024-12-30T10:30:00Z"`
+	Note      string    `json:"note" example:"Code review"`
+}
+
+// UpdateTimeEntryRequest represents the request body for editing a time entry
+type UpdateTimeEntryRequest struct {
+	StartedAt *time.Time `json:"started_at,omitempty" example:"2024-12-30T09:00:00Z"`
+	EndedAt   *time.Time `json:"ended_at,omitempty" example:"2024-12-30T10:30:00Z"`
+	Note      *string    `json:"note,omitempty" example:"Code review"`
+}
+
+// TodoTimeTotal is the tracked time for one todo in a time report
+type TodoTimeTotal struct {
+	TodoID  int64  `json:"todo_id"`
+	Title   string `json:"title"`
+	Seconds int64  `json:"seconds"`
+}
+
+// DayTimeTotal is the tracked time for one calendar day in a time report.
+// Entries spanning midnight contribute to each day they cover.
+type DayTimeTotal struct {
+	Date    string `json:"date" example:"2024-12-30"`
+	Seconds int64  `json:"seconds"`
+}
+
+// TimeReport represents the aggregated time tracking report
+type TimeReport struct {
+	TotalSeconds int64           `json:"total_seconds"`
+	ByTodo       []TodoTimeTotal `json:"by_todo"`
+	ByDay        []DayTimeTotal  `json:"by_day"`
+	Entries      []TimeEntry     `json:"entries"`
+}
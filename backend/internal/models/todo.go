@@ -1,38 +1,299 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
-// Todo represents a todo item
+// Todo represents a todo item. Priority is the label of PriorityKey and is
+// kept for clients written before priorities became configurable. All-day
+// due dates are midnight in DueTimezone and are written as a bare date.
+// DescriptionHTML is the Markdown description rendered as sanitized HTML,
+// only set when a client asks for it.
 type Todo struct {
-	ID              int64      `json:"id" db:"id"`
-	Title           string     `json:"title" db:"title"`
-	Description     string     `json:"description" db:"description"`
-	Status          string     `json:"status" db:"status"`
-	DueDate         *time.Time `json:"due_date,omitempty" db:"due_date"`
-	Priority        string     `json:"priority" db:"priority"`
-	StoryPoints     *int       `json:"story_points,omitempty" db:"story_points"`
-	Subtasks        []Subtask  `json:"subtasks,omitempty" db:"-"`
-	SubtaskProgress string     `json:"subtask_progress,omitempty" db:"-"`
-	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
-}
-
-// CreateTodoRequest represents the request body for creating a todo
+	ID                     int64                  `json:"id" db:"id"`
+	Title                  string                 `json:"title" db:"title"`
+	Description            string                 `json:"description" db:"description"`
+	DescriptionHTML        *string                `json:"description_html,omitempty" db:"-"`
+	Status                 string                 `json:"status" db:"status"`
+	DueDate                *time.Time             `json:"due_date,omitempty" db:"due_date" swaggertype:"string" example:"2025-03-01"`
+	AllDay                 bool                   `json:"all_day" db:"all_day"`
+	DueTimezone            *string                `json:"due_timezone,omitempty" db:"due_timezone" example:"Europe/Berlin"`
+	Priority               string                 `json:"priority" db:"-"`
+	PriorityKey            string                 `json:"priority_key" db:"priority_key"`
+	StoryPoints            *int                   `json:"story_points,omitempty" db:"story_points"`
+	EstimateMinutes        *int                   `json:"estimate_minutes,omitempty" db:"estimate_minutes" example:"90"`
+	SprintID               *int64                 `json:"sprint_id,omitempty" db:"sprint_id"`
+	CompletedAt            *time.Time             `json:"completed_at,omitempty" db:"completed_at"`
+	EscalatedAt            *time.Time             `json:"escalated_at,omitempty" db:"escalated_at"`
+	DeferredUntil          *string                `json:"deferred_until,omitempty" db:"deferred_until" example:"2025-06-01"`
+	LastActivityAt         time.Time              `json:"last_activity_at" db:"last_activity_at"`
+	EpicID                 *int64                 `json:"epic_id,omitempty" db:"epic_id"`
+	Epic                   *TodoEpic              `json:"epic,omitempty" db:"-"`
+	CustomFields           map[string]interface{} `json:"custom_fields" db:"custom_fields"`
+	Subtasks               []Subtask              `json:"subtasks,omitempty" db:"-"`
+	SubtaskProgress        string                 `json:"subtask_progress,omitempty" db:"-"`
+	MatchedSubtasks        []MatchedSubtask       `json:"matched_subtasks,omitempty" db:"-"`
+	LastSubtaskCompletedAt *time.Time             `json:"last_subtask_completed_at,omitempty" db:"-"`
+	GitHubLink             *GitHubLink            `json:"github_link,omitempty" db:"-"`
+	Links                  []TodoLink             `json:"links,omitempty" db:"-"`
+	References             []TodoReference        `json:"references,omitempty" db:"-"`
+	TrackedSeconds         *int64                 `json:"tracked_seconds,omitempty" db:"-"`
+	LockedBy               *string                `json:"locked_by,omitempty" db:"-"`
+	LockExpiresAt          *time.Time             `json:"lock_expires_at,omitempty" db:"-"`
+	AllowedTransitions     []string               `json:"allowed_transitions,omitempty" db:"-"`
+	PossibleDuplicates     []SimilarTodo          `json:"possible_duplicates,omitempty" db:"-"`
+	Warnings               []Warning              `json:"warnings,omitempty" db:"-"`
+	AppliedDefaults        *AppliedTodoDefaults   `json:"applied_defaults,omitempty" db:"-"`
+	CreatedAt              time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt              time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+// CreateTodoRequest represents the request body for creating a todo.
+// Priority is deprecated in favor of PriorityKey; it accepts a key or label.
+// DueDate may be a bare date, which makes the todo all-day. Dates and
+// timestamps without a zone are read in Timezone, falling back to the
+// X-Timezone header, the user's timezone preference and UTC. Description is
+// Markdown of at most 20000 characters.
 type CreateTodoRequest struct {
-	Title       string     `json:"title" binding:"required" example:"Buy groceries"`
-	Description string     `json:"description" example:"Milk, eggs, bread"`
-	Status      string     `json:"status,omitempty" example:"todo" binding:"omitempty,oneof=todo in_progress done"`
-	DueDate     *time.Time `json:"due_date,omitempty" example:"2024-12-31T00:00:00Z"`
-	Priority    string     `json:"priority" example:"Medium" binding:"oneof=High Medium Low"`
-	StoryPoints *int       `json:"story_points,omitempty" example:"5"`
+	Title        string                 `json:"title" binding:"required" example:"Buy groceries"`
+	Description  string                 `json:"description" binding:"max=20000" example:"Milk, eggs, bread"`
+	Status       string                 `json:"status,omitempty" example:"todo"`
+	DueDate      *DueDateInput          `json:"due_date,omitempty" swaggertype:"string" example:"2024-12-31"`
+	Timezone     string                 `json:"timezone,omitempty" example:"Europe/Berlin"`
+	Priority     string                 `json:"priority,omitempty" example:"Medium"`
+	PriorityKey  string                 `json:"priority_key,omitempty" example:"medium"`
+	StoryPoints  *int                   `json:"story_points,omitempty" example:"5"`
+	Estimate     *int                   `json:"estimate_minutes,omitempty" example:"90"`
+	EpicID       *int64                 `json:"epic_id,omitempty" example:"2"`
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 }
 
-// UpdateTodoRequest represents the request body for updating a todo
+// UpdateTodoRequest represents the request body for updating a todo.
+// Priority is deprecated in favor of PriorityKey; it accepts a key or label.
+// Description, DueDate and Timezone behave as in CreateTodoRequest.
+// CustomFields are merged into the todo's values; a null value removes a field.
+// UpdatedAt is the updated_at the client last read; when the todo has
+// changed since, the update still applies but is reported as a conflict.
+// Note explains the change and is required when it matches a note rule.
+// A null Estimate clears the todo's estimate; a missing one leaves it.
 type UpdateTodoRequest struct {
-	Title       string     `json:"title" example:"Buy groceries"`
-	Description string     `json:"description" example:"Milk, eggs, bread"`
-	Status      string     `json:"status,omitempty" example:"in_progress" binding:"omitempty,oneof=todo in_progress done"`
-	DueDate     *time.Time `json:"due_date,omitempty" example:"2024-12-31T00:00:00Z"`
-	Priority    string     `json:"priority" example:"Medium" binding:"oneof=High Medium Low"`
-	StoryPoints *int       `json:"story_points,omitempty" example:"5"`
+	Title        string                 `json:"title" example:"Buy groceries"`
+	Description  string                 `json:"description" binding:"max=20000" example:"Milk, eggs, bread"`
+	Status       string                 `json:"status,omitempty" example:"in_progress"`
+	DueDate      *DueDateInput          `json:"due_date,omitempty" swaggertype:"string" example:"2024-12-31"`
+	Timezone     string                 `json:"timezone,omitempty" example:"Europe/Berlin"`
+	Priority     string                 `json:"priority,omitempty" example:"Medium"`
+	PriorityKey  string                 `json:"priority_key,omitempty" example:"medium"`
+	StoryPoints  *int                   `json:"story_points,omitempty" example:"5"`
+	Estimate     EstimateInput          `json:"estimate_minutes,omitzero" swaggertype:"integer" example:"90"`
+	EpicID       *int64                 `json:"epic_id,omitempty" example:"2"`
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+	UpdatedAt    *time.Time             `json:"updated_at,omitempty" example:"2025-03-01T09:30:00Z"`
+	Note         string                 `json:"note,omitempty" binding:"max=2000" example:"Customer escalation"`
+}
+
+// EstimateInput is the estimate in an UpdateTodoRequest. Set tells an
+// explicit null, which clears the estimate, from a field that was left out.
+type EstimateInput struct {
+	Set     bool
+	Minutes *int
+}
+
+// UnmarshalJSON records that the field was present
+func (e *EstimateInput) UnmarshalJSON(data []byte) error {
+	e.Set = true
+	return json.Unmarshal(data, &e.Minutes)
+}
+
+// MarshalJSON writes the minutes, or null to clear the estimate. A field
+// that is not Set is left out of the request by its omitzero tag.
+func (e EstimateInput) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Minutes)
+}
+
+// ConflictingTodo is a todo updated by a client that had not seen its latest
+// version. Previous is the todo as it was just before the update.
+type ConflictingTodo struct {
+	Todo
+	Previous Todo `json:"previous"`
+}
+
+// MarshalJSON adds previous to the todo, since the embedded Todo's
+// MarshalJSON would otherwise drop it
+func (t ConflictingTodo) MarshalJSON() ([]byte, error) {
+	todo, err := json.Marshal(t.Todo)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(todo, &fields); err != nil {
+		return nil, err
+	}
+	if fields["previous"], err = json.Marshal(t.Previous); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// StaleTodo is a todo without recent activity. InactiveSeconds is the time
+// since LastActivityAt.
+type StaleTodo struct {
+	Todo
+	InactiveSeconds int64 `json:"inactive_seconds"`
+	InactiveDays    int   `json:"inactive_days"`
+}
+
+// MarshalJSON adds the inactivity fields to the todo, since the embedded
+// Todo's MarshalJSON would otherwise drop them
+func (t StaleTodo) MarshalJSON() ([]byte, error) {
+	todo, err := json.Marshal(t.Todo)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(todo, &fields); err != nil {
+		return nil, err
+	}
+	fields["inactive_seconds"], _ = json.Marshal(t.InactiveSeconds)
+	fields["inactive_days"], _ = json.Marshal(t.InactiveDays)
+	return json.Marshal(fields)
+}
+
+// SplitTodoItem describes one todo created by a split. SubtaskIDs are moved
+// from the original todo to the new one.
+type SplitTodoItem struct {
+	Title      string  `json:"title" binding:"required" example:"Design the schema"`
+	SubtaskIDs []int64 `json:"subtask_ids,omitempty"`
+}
+
+// SplitTodoRequest represents the request body for splitting a todo. With
+// CloseOriginal the original todo is moved to the first done status.
+type SplitTodoRequest struct {
+	Todos         []SplitTodoItem `json:"todos" binding:"required,min=1,max=50,dive"`
+	CloseOriginal bool            `json:"close_original" example:"false"`
+}
+
+// SplitTodoResult is the original todo and the todos split from it, each
+// with its subtasks
+type SplitTodoResult struct {
+	Original Todo   `json:"original"`
+	Todos    []Todo `json:"todos"`
+}
+
+// MoveTarget is a sprint or epic ID in a MoveTodosRequest. Set tells an
+// explicit null, which moves todos out, from a field that was left out.
+type MoveTarget struct {
+	Set bool
+	ID  *int64
+}
+
+// UnmarshalJSON records that the field was present
+func (t *MoveTarget) UnmarshalJSON(data []byte) error {
+	t.Set = true
+	return json.Unmarshal(data, &t.ID)
+}
+
+// MoveTodosRequest represents the request body for moving todos to a sprint
+// and/or an epic. A null ID moves the todos out; a missing one leaves it.
+// Note is recorded on every todo the move changes.
+type MoveTodosRequest struct {
+	TodoIDs  []int64    `json:"todo_ids" binding:"required,min=1,max=100" example:"1,2,3"`
+	SprintID MoveTarget `json:"sprint_id" swaggertype:"integer" example:"7"`
+	EpicID   MoveTarget `json:"epic_id" swaggertype:"integer" example:"2"`
+	Note     string     `json:"note,omitempty" binding:"max=2000" example:"Pulled into the release"`
+}
+
+// MoveTodosResult is the todos a move changed, in request order, and
+// warnings such as a sprint going over its capacity. Warnings never block
+// the move.
+type MoveTodosResult struct {
+	Todos    []Todo    `json:"todos"`
+	Warnings []Warning `json:"warnings"`
+	DryRun   bool      `json:"dry_run" example:"false"`
+}
+
+// SimilarTodo is an open todo whose title resembles another title.
+// Similarity is the trigram similarity between 0 and 1.
+type SimilarTodo struct {
+	ID         int64   `json:"id" example:"12"`
+	Title      string  `json:"title" example:"Fix login bug"`
+	Similarity float64 `json:"similarity" example:"0.83"`
+}
+
+// Suggestion is a title matching a type-ahead query. Type is "todo" or "epic".
+type Suggestion struct {
+	Type  string `json:"type" example:"todo"`
+	ID    int64  `json:"id" example:"12"`
+	Title string `json:"title" example:"Fix login bug"`
+}
+
+// TodoChanges counts the todos created, updated or deleted since a point in
+// time. LatestChangeAt is the time of the newest of those changes and is
+// omitted when there are none; pass it as since once the list is refreshed.
+type TodoChanges struct {
+	Count          int64      `json:"count" example:"3"`
+	LatestChangeAt *time.Time `json:"latest_change_at,omitempty" example:"2025-03-01T09:30:00.123456Z"`
+}
+
+// SetDueDatesRequest represents the request body for changing the due dates
+// of several todos: either DueDate, which behaves as in CreateTodoRequest, or
+// Shift, a signed number of days and weeks such as +7d, -2w or +1w3d.
+type SetDueDatesRequest struct {
+	IDs      []int64       `json:"ids" binding:"required,min=1,max=100" example:"1,2,3"`
+	DueDate  *DueDateInput `json:"due_date,omitempty" swaggertype:"string" example:"2025-04-01"`
+	Timezone string        `json:"timezone,omitempty" example:"Europe/Berlin"`
+	Shift    string        `json:"shift,omitempty" example:"+7d"`
+}
+
+// TodoDueDate is a todo's due date after a bulk change, written like a
+// todo's due_date
+type TodoDueDate struct {
+	ID      int64  `json:"id" example:"1"`
+	DueDate string `json:"due_date" example:"2025-04-08"`
+}
+
+// SetDueDatesResult is the new due date of each todo changed, in request
+// order, and the todos a shift skipped because they have no due date
+type SetDueDatesResult struct {
+	Todos    []TodoDueDate `json:"todos"`
+	Skipped  []int64       `json:"skipped"`
+	Warnings []Warning     `json:"warnings"`
+	DryRun   bool          `json:"dry_run" example:"false"`
+}
+
+// TodoSubtasks is the subtasks section of a TodoFull, in display order
+type TodoSubtasks struct {
+	Total     int       `json:"total" example:"4"`
+	Completed int       `json:"completed" example:"1"`
+	Items     []Subtask `json:"items"`
+}
+
+// TodoActivitySummary is the activity section of a TodoFull: the number of
+// events and the latest of them, newest first
+type TodoActivitySummary struct {
+	Total  int64       `json:"total" example:"12"`
+	Latest []TodoEvent `json:"latest"`
+}
+
+// TodoFull is everything a todo's detail card shows. Todo is what GET
+// /todos/:id returns; a section left out with exclude is omitted.
+type TodoFull struct {
+	Todo     Todo                 `json:"todo"`
+	Subtasks *TodoSubtasks        `json:"subtasks,omitempty"`
+	Activity *TodoActivitySummary `json:"activity,omitempty"`
+}
+
+// DeferTodoRequest represents the request body for deferring a todo. Until
+// is a YYYY-MM-DD date or a number of days, weeks or months from today,
+// such as +3d, +2w or +1m.
+type DeferTodoRequest struct {
+	Until string `json:"until" binding:"required" example:"+2w"`
+}
+
+// TodoGone is the body of a 410 for a todo that was deleted. Once its
+// tombstone is purged the todo answers 404 like one that never existed.
+type TodoGone struct {
+	Error     string    `json:"error" example:"Todo was deleted"`
+	DeletedAt time.Time `json:"deleted_at"`
 }
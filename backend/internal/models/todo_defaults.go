@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// TodoDefaults are the values new todos get for fields their create request
+// leaves out. Unset fields keep the built-in behavior: the first board
+// column, the default priority and no story points or estimate.
+type TodoDefaults struct {
+	Status      *string   `json:"status" example:"in_progress"`
+	PriorityKey *string   `json:"priority_key" example:"high"`
+	StoryPoints *int      `json:"story_points" example:"3"`
+	Estimate    *int      `json:"estimate_minutes" example:"60"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// UpdateTodoDefaultsRequest represents the request body for replacing the
+// todo defaults; a missing or null field is unset
+type UpdateTodoDefaultsRequest struct {
+	Status      *string `json:"status" example:"in_progress"`
+	PriorityKey *string `json:"priority_key" example:"high"`
+	StoryPoints *int    `json:"story_points" example:"3"`
+	Estimate    *int    `json:"estimate_minutes" example:"60"`
+}
+
+// AppliedTodoDefaults are the defaults a new todo was given because its
+// create request left the fields out
+type AppliedTodoDefaults struct {
+	Status      *string `json:"status,omitempty" example:"in_progress"`
+	PriorityKey *string `json:"priority_key,omitempty" example:"high"`
+	StoryPoints *int    `json:"story_points,omitempty" example:"3"`
+	Estimate    *int    `json:"estimate_minutes,omitempty" example:"60"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// TodoExportVersion is the schema version of todo exports
+const TodoExportVersion = 1
+
+// TodoExport is a single todo with its subtasks and links as a portable
+// document. ExportID identifies the export, so importing it twice can be
+// detected.
+type TodoExport struct {
+	SchemaVersion int             `json:"schema_version" example:"1"`
+	ExportID      string          `json:"export_id" example:"3KQZ7VYB2M5T4XJ6WN8RCDHFLA"`
+	ExportedAt    time.Time       `json:"exported_at"`
+	Todo          EpicArchiveTodo `json:"todo"`
+}
+
+// TodoImportResult is the todo created by importing an export, or the one
+// an earlier import of the same export created
+type TodoImportResult struct {
+	Todo            Todo `json:"todo"`
+	AlreadyImported bool `json:"already_imported" example:"false"`
+	Subtasks        int  `json:"subtasks" example:"3"`
+	Links           int  `json:"links" example:"1"`
+	DryRun          bool `json:"dry_run" example:"false"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// TodoPolicy holds the editing policies of todos. With LockDoneTodos, the
+// title, description, story points and subtasks of a done todo cannot be
+// changed until it is moved out of its done status.
+type TodoPolicy struct {
+	LockDoneTodos bool      `json:"lock_done_todos" example:"true"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// UpdateTodoPolicyRequest represents the request body for updating the todo
+// policy. Omitted fields are left unchanged.
+type UpdateTodoPolicyRequest struct {
+	LockDoneTodos *bool `json:"lock_done_todos,omitempty" example:"true"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Descriptions are capped so a single todo cannot carry an unbounded
+// Markdown document to render
+func TestTodoRequestDescriptionMax(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		valid       bool
+	}{
+		{"", true},
+		{strings.Repeat("a", 20000), true},
+		{strings.Repeat("é", 20000), true},
+		{strings.Repeat("a", 20001), false},
+	} {
+		create := CreateTodoRequest{Title: "Write docs", Description: tt.description}
+		if err := binding.Validator.ValidateStruct(&create); (err == nil) != tt.valid {
+			t.Errorf("create with %d characters: %v, want valid %t", len([]rune(tt.description)), err, tt.valid)
+		}
+		update := UpdateTodoRequest{Description: tt.description}
+		if err := binding.Validator.ValidateStruct(&update); (err == nil) != tt.valid {
+			t.Errorf("update with %d characters: %v, want valid %t", len([]rune(tt.description)), err, tt.valid)
+		}
+	}
+}
+
+// An update encodes its estimate as the server reads it back: left out,
+// cleared with null, or set
+func TestEstimateInputJSON(t *testing.T) {
+	minutes := 90
+	for _, tt := range []struct {
+		estimate EstimateInput
+		json     string
+	}{
+		{EstimateInput{}, `{"title":"Write docs","description":""}`},
+		{EstimateInput{Set: true}, `{"title":"Write docs","description":"","estimate_minutes":null}`},
+		{EstimateInput{Set: true, Minutes: &minutes}, `{"title":"Write docs","description":"","estimate_minutes":90}`},
+	} {
+		data, err := json.Marshal(UpdateTodoRequest{Title: "Write docs", Estimate: tt.estimate})
+		if err != nil || string(data) != tt.json {
+			t.Errorf("%+v encodes as %s, %v, want %s", tt.estimate, data, err, tt.json)
+			continue
+		}
+		var decoded UpdateTodoRequest
+		if err := json.Unmarshal(data, &decoded); err != nil || decoded.Estimate.Set != tt.estimate.Set ||
+			(decoded.Estimate.Minutes == nil) != (tt.estimate.Minutes == nil) {
+			t.Errorf("%s decodes as %+v, %v, want %+v", data, decoded.Estimate, err, tt.estimate)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package models
+
+// UsageDay is one day of API usage by a workspace through one API key.
+// Token is a fingerprint of the admin token, or empty for requests made
+// without it.
+type UsageDay struct {
+	Date          string `json:"date" example:"2025-03-17"`
+	Token         string `json:"token" example:"3f2a9c41d0e7"`
+	Requests      int64  `json:"requests" example:"1520"`
+	RequestBytes  int64  `json:"request_bytes" example:"48213"`
+	ResponseBytes int64  `json:"response_bytes" example:"1893022"`
+}
+
+// WorkspaceUsage is a workspace's daily API usage. MonthRequests counts the
+// requests made since the start of the current UTC month; MonthlyQuota is
+// omitted when the workspace has no quota.
+type WorkspaceUsage struct {
+	Workspace     string     `json:"workspace" example:"team-a"`
+	MonthRequests int64      `json:"month_requests" example:"20344"`
+	MonthlyQuota  *int64     `json:"monthly_quota,omitempty" example:"100000"`
+	Days          []UsageDay `json:"days"`
+}
+
+// UsageReport is the API usage of every workspace between two dates,
+// inclusive
+type UsageReport struct {
+	From       string           `json:"from" example:"2025-03-01"`
+	To         string           `json:"to" example:"2025-03-31"`
+	Workspaces []WorkspaceUsage `json:"workspaces"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// Warning codes
+const (
+	WarningDueDatePast         = "due_date_past"
+	WarningStoryPointsOnDone   = "story_points_on_done"
+	WarningPossibleDuplicate   = "possible_duplicate"
+	WarningSprintOverCapacity  = "sprint_over_capacity"
+	WarningStoryPointsOffScale = "story_points_off_scale"
+	WarningDefaultUnavailable  = "default_unavailable"
+)
+
+// Warning tells the client about something questionable in a change that
+// was still made. RelatedIDs are the todos, sprints or epics it is about.
+// Warnings never change a response's status code.
+type Warning struct {
+	Code       string  `json:"code" example:"due_date_past"`
+	Message    string  `json:"message" example:"The due date is in the past"`
+	RelatedIDs []int64 `json:"related_ids,omitempty" example:"42"`
+}
@@ -0,0 +1,43 @@
+package models
+
+// StatusTransition allows todos to move from one status to another
+type StatusTransition struct {
+	From string `json:"from" binding:"required" example:"review"`
+	To   string `json:"to" binding:"required" example:"done"`
+}
+
+// Workflow is the set of allowed status transitions. A status that never
+// appears as From may move to any status, so an empty workflow allows
+// every transition.
+type Workflow struct {
+	Transitions []StatusTransition `json:"transitions"`
+}
+
+// UpdateWorkflowRequest replaces the allowed status transitions
+type UpdateWorkflowRequest struct {
+	Transitions []StatusTransition `json:"transitions" binding:"dive"`
+}
+
+// Note rule fields
+const (
+	NoteRuleStatus   = "status"
+	NoteRulePriority = "priority"
+)
+
+// NoteRule requires a note on changes of Field (status or priority) from From
+// to To, given as keys. A missing From or To matches any value, but not both.
+type NoteRule struct {
+	Field string  `json:"field" binding:"required,oneof=status priority" example:"status"`
+	From  *string `json:"from,omitempty" example:"done"`
+	To    *string `json:"to,omitempty" example:"in_progress"`
+}
+
+// NoteRules is the set of changes that must come with a note
+type NoteRules struct {
+	Rules []NoteRule `json:"rules"`
+}
+
+// UpdateNoteRulesRequest replaces the note rules
+type UpdateNoteRulesRequest struct {
+	Rules []NoteRule `json:"rules" binding:"dive"`
+}
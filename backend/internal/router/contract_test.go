@@ -0,0 +1,631 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/testsupport"
+)
+
+// The contract tests hold the swag annotations of the handlers to what the
+// handlers do. They read the annotations, and the models the annotations
+// name, from the source swag generates the OpenAPI document from, so they
+// need neither the generated document nor swag itself.
+
+// contractResponse is a documented response. kind is array, object, string
+// or file, and "" for a response without a body; schema is the Go type the
+// annotation names, such as models.Todo or map[string]string.
+type contractResponse struct {
+	kind   string
+	schema string
+}
+
+// contractParam is a documented parameter
+type contractParam struct {
+	name, in, typ string
+	required      bool
+}
+
+// contractOperation is what the godoc of one handler documents
+type contractOperation struct {
+	handler   string
+	method    string
+	path      string // in gin form, /todos/:id
+	admin     bool   // @Security AdminToken
+	params    []contractParam
+	responses map[int]contractResponse
+}
+
+var (
+	paramAnnotation    = regexp.MustCompile(`^@Param\s+(\S+)\s+(\S+)\s+(\S+)\s+(true|false)\b`)
+	responseAnnotation = regexp.MustCompile(`^@(?:Success|Failure)\s+(\d{3})(?:\s+\{(\w+)\}\s+(\S+))?`)
+	routerAnnotation   = regexp.MustCompile(`^@Router\s+(\S+)\s+\[(\w+)\]`)
+	swagPathParam      = regexp.MustCompile(`\{(\w+)\}`)
+)
+
+// parseSource parses the non-test Go files of the package in dir
+func parseSource(t *testing.T, dir string) []*ast.File {
+	t.Helper()
+	names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range names {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			t.Fatalf("parsing %s: %v", name, err)
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// contractOperations returns the operations documented on the handlers, by
+// handler name
+func contractOperations(t *testing.T) map[string]contractOperation {
+	t.Helper()
+	operations := map[string]contractOperation{}
+	for _, file := range parseSource(t, "../handlers") {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil {
+				continue
+			}
+			op := contractOperation{handler: fn.Name.Name, responses: map[int]contractResponse{}}
+			for _, comment := range fn.Doc.List {
+				line := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+				if m := paramAnnotation.FindStringSubmatch(line); m != nil {
+					op.params = append(op.params, contractParam{name: m[1], in: m[2], typ: m[3], required: m[4] == "true"})
+				} else if m := responseAnnotation.FindStringSubmatch(line); m != nil {
+					code, _ := strconv.Atoi(m[1])
+					if _, ok := op.responses[code]; ok {
+						t.Errorf("%s documents %d twice; swag keeps only the last", fn.Name.Name, code)
+					}
+					op.responses[code] = contractResponse{kind: m[2], schema: m[3]}
+				} else if m := routerAnnotation.FindStringSubmatch(line); m != nil {
+					op.path = swagPathParam.ReplaceAllString(m[1], ":$1")
+					op.method = strings.ToUpper(m[2])
+				} else if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "@Security" && fields[1] == "AdminToken" {
+					op.admin = true
+				}
+			}
+			if op.path != "" {
+				operations[fn.Name.Name] = op
+			}
+		}
+	}
+	return operations
+}
+
+// handlerName returns the name of the handlers function h
+func handlerName(h gin.HandlerFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// Every route is documented with its own method and path, and every
+// documented route is served
+func TestContractRoutes(t *testing.T) {
+	operations := contractOperations(t)
+	served := map[string]bool{}
+	for _, route := range Routes {
+		name := handlerName(route.Handler)
+		served[name] = true
+		op, ok := operations[name]
+		if !ok {
+			t.Errorf("%s %s: %s has no @Router annotation", route.Method, route.Path, name)
+			continue
+		}
+		if op.method != route.Method || op.path != route.Path {
+			t.Errorf("%s %s: %s documents %s %s", route.Method, route.Path, name, op.method, op.path)
+		}
+	}
+	for name, op := range operations {
+		if !served[name] {
+			t.Errorf("%s documents %s %s, which is not in Routes", name, op.method, op.path)
+		}
+	}
+}
+
+// The documented parameters, security and responses agree with the route
+func TestContractAnnotations(t *testing.T) {
+	operations := contractOperations(t)
+	models := contractModels(t)
+	for _, route := range Routes {
+		op, ok := operations[handlerName(route.Handler)]
+		if !ok {
+			continue
+		}
+		name := route.Method + " " + route.Path
+
+		var inPath []string
+		for _, segment := range strings.Split(route.Path, "/") {
+			if param, ok := strings.CutPrefix(segment, ":"); ok {
+				inPath = append(inPath, param)
+			}
+		}
+		var documented []string
+		for _, param := range op.params {
+			switch param.in {
+			case "path":
+				documented = append(documented, param.name)
+				if !param.required {
+					t.Errorf("%s: path parameter %s is documented as optional", name, param.name)
+				}
+			case "body":
+				if route.Method == http.MethodGet {
+					t.Errorf("%s: documents a request body", name)
+				}
+				checkSchemaExists(t, name, param.typ, models)
+			case "query", "header", "formData":
+			default:
+				t.Errorf("%s: parameter %s is in %q", name, param.name, param.in)
+			}
+		}
+		if !slices.Equal(inPath, documented) {
+			t.Errorf("%s: documents path parameters %v, want %v", name, documented, inPath)
+		}
+
+		if admin := isAdminRoute(route.Path); op.admin != admin {
+			t.Errorf("%s: @Security AdminToken documented %t, want %t", name, op.admin, admin)
+		} else if admin {
+			for _, code := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+				if _, ok := op.responses[code]; !ok {
+					t.Errorf("%s: admin route does not document %d", name, code)
+				}
+			}
+		}
+
+		if len(op.responses) == 0 {
+			t.Errorf("%s: documents no responses", name)
+		}
+		for code, response := range op.responses {
+			noBody := code == http.StatusNoContent || code == http.StatusNotModified
+			if noBody != (response.kind == "") {
+				t.Errorf("%s: %d documented as {%s} %s", name, code, response.kind, response.schema)
+			}
+			if response.kind == "object" || response.kind == "array" {
+				checkSchemaExists(t, name, response.schema, models)
+			}
+		}
+	}
+}
+
+// contractModels returns the type expressions declared in the models
+// package, by name
+func contractModels(t *testing.T) map[string]ast.Expr {
+	t.Helper()
+	models := map[string]ast.Expr{}
+	for _, file := range parseSource(t, "../models") {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.TypeSpec)
+				models[spec.Name.Name] = spec.Type
+			}
+		}
+	}
+	return models
+}
+
+// schemaExpr parses the Go type an annotation names, models.Todo becoming
+// Todo
+func schemaExpr(schema string) (ast.Expr, error) {
+	return parser.ParseExpr(strings.ReplaceAll(schema, "models.", ""))
+}
+
+func checkSchemaExists(t *testing.T, operation, schema string, models map[string]ast.Expr) {
+	t.Helper()
+	if _, err := schemaExpr(schema); err != nil {
+		t.Errorf("%s: cannot parse schema %q: %v", operation, schema, err)
+	}
+	for _, name := range regexp.MustCompile(`models\.(\w+)`).FindAllStringSubmatch(schema, -1) {
+		if _, ok := models[name[1]]; !ok {
+			t.Errorf("%s: documents models.%s, which does not exist", operation, name[1])
+		}
+	}
+}
+
+// shapeChecker compares JSON values with the Go types that declare them
+type shapeChecker struct {
+	models   map[string]ast.Expr
+	problems []string
+}
+
+func (s *shapeChecker) problem(path, format string, args ...interface{}) {
+	s.problems = append(s.problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+// check reports where value does not have the shape of the type expr
+func (s *shapeChecker) check(path string, value interface{}, expr ast.Expr) {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		if value != nil {
+			s.check(path, value, expr.X)
+		}
+	case *ast.ArrayType:
+		if value == nil {
+			return
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			if ident, ok := expr.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+				if _, ok := value.(string); ok {
+					return
+				}
+			}
+			s.problem(path, "%s, want an array", describe(value))
+			return
+		}
+		for i, item := range items {
+			s.check(path+"["+strconv.Itoa(i)+"]", item, expr.Elt)
+		}
+	case *ast.MapType:
+		if value == nil {
+			return
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			s.problem(path, "%s, want an object", describe(value))
+			return
+		}
+		for key, item := range object {
+			s.check(path+"."+key, item, expr.Value)
+		}
+	case *ast.InterfaceType:
+	case *ast.StructType:
+		s.checkStruct(path, value, expr)
+	case *ast.SelectorExpr:
+		switch pkg, _ := expr.X.(*ast.Ident); pkg.Name + "." + expr.Sel.Name {
+		case "time.Time":
+			if _, ok := value.(string); !ok {
+				s.problem(path, "%s, want a timestamp", describe(value))
+			}
+		}
+	case *ast.Ident:
+		s.checkIdent(path, value, expr.Name)
+	}
+}
+
+func (s *shapeChecker) checkIdent(path string, value interface{}, name string) {
+	var ok bool
+	switch name {
+	case "string":
+		_, ok = value.(string)
+	case "bool":
+		_, ok = value.(bool)
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+		_, ok = value.(float64)
+	case "any":
+		ok = true
+	default:
+		if expr, known := s.models[name]; known {
+			s.check(path, value, expr)
+		}
+		return
+	}
+	if !ok {
+		s.problem(path, "%s, want %s", describe(value), name)
+	}
+}
+
+// structField is a JSON field of a struct
+type structField struct {
+	typ      ast.Expr
+	required bool
+}
+
+// fields returns the JSON fields of st, including those of embedded structs
+func (s *shapeChecker) fields(st *ast.StructType) map[string]structField {
+	fields := map[string]structField{}
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			unquoted, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(unquoted)
+		}
+		name, options, _ := strings.Cut(tag.Get("json"), ",")
+		if name == "-" && options == "" {
+			continue
+		}
+		typ := field.Type
+		switch tag.Get("swaggertype") {
+		case "string":
+			typ = ast.NewIdent("string")
+		case "integer":
+			typ = ast.NewIdent("int")
+		case "object":
+			typ = &ast.MapType{Key: ast.NewIdent("string"), Value: &ast.InterfaceType{Methods: &ast.FieldList{}}}
+		}
+		if len(field.Names) == 0 && name == "" {
+			// An embedded struct's fields are promoted
+			embedded := field.Type
+			if star, ok := embedded.(*ast.StarExpr); ok {
+				embedded = star.X
+			}
+			if ident, ok := embedded.(*ast.Ident); ok {
+				if inner, ok := s.models[ident.Name].(*ast.StructType); ok {
+					for key, value := range s.fields(inner) {
+						if _, shadowed := fields[key]; !shadowed {
+							fields[key] = value
+						}
+					}
+				}
+			}
+			continue
+		}
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			key := name
+			if key == "" {
+				key = ident.Name
+			}
+			fields[key] = structField{typ: typ, required: !strings.Contains(options, "omitempty")}
+		}
+	}
+	return fields
+}
+
+func (s *shapeChecker) checkStruct(path string, value interface{}, st *ast.StructType) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		s.problem(path, "%s, want an object", describe(value))
+		return
+	}
+	fields := s.fields(st)
+	for key, item := range object {
+		field, ok := fields[key]
+		if !ok {
+			s.problem(path+"."+key, "is not documented")
+			continue
+		}
+		s.check(path+"."+key, item, field.typ)
+	}
+	for key, field := range fields {
+		if _, ok := object[key]; field.required && !ok {
+			s.problem(path+"."+key, "is documented but missing")
+		}
+	}
+}
+
+// describe names the JSON type of value
+func describe(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case []interface{}:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// checkResponse reports where body does not match the documented response
+func (s *shapeChecker) checkResponse(response contractResponse, body []byte) []string {
+	s.problems = nil
+	switch response.kind {
+	case "":
+		if len(body) > 0 {
+			s.problem("body", "%d bytes, want none", len(body))
+		}
+		return s.problems
+	case "string", "file":
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []string{"body is not JSON: " + err.Error()}
+	}
+	schema := response.schema
+	if response.kind == "array" {
+		schema = "[]" + schema
+	}
+	expr, err := schemaExpr(schema)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	s.check("body", value, expr)
+	return s.problems
+}
+
+// contractPath fills the parameters of a route path with values no row has,
+// so writes find nothing to change
+func contractPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if param, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "2147483647"
+			if param == "name" || param == "key" {
+				segments[i] = "contract_test"
+			}
+		}
+	}
+	return BasePath + strings.Join(segments, "/")
+}
+
+// Every route answers with a documented status and a body of the documented
+// shape. Without TEST_DATABASE_URL the handlers that need the database answer
+// their 500, which must be documented too; with it, writes run only against
+// rows that do not exist, so the database is left as it was.
+func TestContractResponses(t *testing.T) {
+	operations := contractOperations(t)
+	shapes := &shapeChecker{models: contractModels(t)}
+	t.Setenv("ADMIN_TOKEN", testAdminToken)
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_WEBHOOK_SECRET", "")
+
+	withDB := false
+	if url := os.Getenv("TEST_DATABASE_URL"); url != "" {
+		pool, err := pgxpool.New(context.Background(), url)
+		if err != nil {
+			t.Fatalf("connecting to TEST_DATABASE_URL: %v", err)
+		}
+		previous := db.Pool
+		db.Pool = pool
+		t.Cleanup(func() {
+			db.Pool = previous
+			pool.Close()
+		})
+		withDB = true
+	}
+	engine := New(Options{})
+
+	for _, route := range Routes {
+		op, ok := operations[handlerName(route.Handler)]
+		if !ok {
+			continue
+		}
+		if withDB && route.Method != http.MethodGet && !strings.Contains(route.Path, ":") {
+			// Without a row to aim at, a write could change the database
+			continue
+		}
+		t.Run(route.Method+" "+route.Path, func(t *testing.T) {
+			var body *strings.Reader
+			if route.Method == http.MethodGet || route.Method == http.MethodDelete {
+				body = strings.NewReader("")
+			} else {
+				body = strings.NewReader("{}")
+			}
+			req := httptest.NewRequest(route.Method, contractPath(route.Path), body)
+			req.Header.Set("Content-Type", "application/json")
+			if isAdminRoute(route.Path) {
+				req.Header.Set("Authorization", "Bearer "+testAdminToken)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			response, ok := op.responses[w.Code]
+			if !ok {
+				t.Fatalf("answered %d, which is not documented: %s", w.Code, w.Body)
+			}
+			for _, problem := range shapes.checkResponse(response, w.Body.Bytes()) {
+				t.Errorf("%d documented as {%s} %s: %s", w.Code, response.kind, response.schema, problem)
+			}
+		})
+	}
+}
+
+// A deleted todo answers the documented 410 on each route that reads it, and
+// an ID that never existed the documented 404. TestContractResponses cannot
+// reach the 410, since it only aims at rows that do not exist.
+func TestContractGone(t *testing.T) {
+	pool := testsupport.UseDatabase(t)
+	ctx := context.Background()
+	operations := contractOperations(t)
+	shapes := &shapeChecker{models: contractModels(t)}
+	engine := New(Options{})
+
+	id := testsupport.NewTodoFactory(t, pool).WithTitle("Contract gone").Create(ctx).ID
+	t.Cleanup(func() { pool.Exec(context.Background(), `DELETE FROM todo_tombstones WHERE todo_id = $1`, id) })
+	todoPath := BasePath + "/todos/" + strconv.FormatInt(id, 10)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, todoPath, nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE %s: %d %s", todoPath, w.Code, w.Body)
+	}
+
+	for _, route := range Routes {
+		if route.Method != http.MethodGet || !strings.HasPrefix(route.Path, "/todos/:id") {
+			continue
+		}
+		op, ok := operations[handlerName(route.Handler)]
+		if _, gone := op.responses[http.StatusGone]; !ok || !gone {
+			continue
+		}
+		t.Run(route.Path, func(t *testing.T) {
+			for _, tt := range []struct {
+				path string
+				want int
+			}{
+				{todoPath + strings.TrimPrefix(route.Path, "/todos/:id"), http.StatusGone},
+				{contractPath(route.Path), http.StatusNotFound},
+			} {
+				w := httptest.NewRecorder()
+				engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+				if w.Code != tt.want {
+					t.Fatalf("GET %s: %d %s, want %d", tt.path, w.Code, w.Body, tt.want)
+				}
+				response, ok := op.responses[w.Code]
+				if !ok {
+					t.Fatalf("GET %s: %d is not documented", tt.path, w.Code)
+				}
+				for _, problem := range shapes.checkResponse(response, w.Body.Bytes()) {
+					t.Errorf("%d documented as {%s} %s: %s", w.Code, response.kind, response.schema, problem)
+				}
+			}
+		})
+	}
+}
+
+// The shape checker holds bodies to exactly the fields their type declares
+func TestShapeChecker(t *testing.T) {
+	models := map[string]ast.Expr{}
+	for name, source := range map[string]string{
+		"Base": "struct { ID int64 `json:\"id\"`; Hidden string `json:\"-\"` }",
+		"Item": "struct { Base; Title string `json:\"title\"`; Due *time.Time `json:\"due,omitempty\"`; Tags []string `json:\"tags\"`; Points Points `json:\"points\" swaggertype:\"integer\"` }",
+	} {
+		expr, err := parser.ParseExpr(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		models[name] = expr
+	}
+	shapes := &shapeChecker{models: models}
+	tests := []struct {
+		response contractResponse
+		body     string
+		problems int
+	}{
+		{contractResponse{"array", "models.Item"}, `[{"id":1,"title":"a","tags":[],"points":3}]`, 0},
+		{contractResponse{"array", "models.Item"}, `[{"id":1,"title":"a","tags":null,"points":3,"due":"2030-01-02T00:00:00Z"}]`, 0},
+		{contractResponse{"array", "models.Item"}, `[{"id":1,"title":"a","tags":[],"points":3,"extra":true}]`, 1},
+		{contractResponse{"array", "models.Item"}, `[{"id":1,"tags":[],"points":3}]`, 1},
+		{contractResponse{"array", "models.Item"}, `[{"id":"1","title":"a","tags":[1],"points":3}]`, 2},
+		{contractResponse{"object", "models.Item"}, `[]`, 1},
+		{contractResponse{"object", "map[string]string"}, `{"error":"Todo not found"}`, 0},
+		{contractResponse{"object", "map[string]string"}, `{"error":"Down","maintenance":true}`, 1},
+		{contractResponse{"object", "map[string]interface{}"}, `{"error":"Down","maintenance":true}`, 0},
+		{contractResponse{"", ""}, ``, 0},
+		{contractResponse{"", ""}, `{}`, 1},
+	}
+	for _, tt := range tests {
+		if problems := shapes.checkResponse(tt.response, []byte(tt.body)); len(problems) != tt.problems {
+			t.Errorf("{%s} %s with %s: %q, want %d problems", tt.response.kind, tt.response.schema, tt.body, problems, tt.problems)
+		}
+	}
+}
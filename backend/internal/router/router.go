@@ -0,0 +1,274 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"flow-v1/backend/internal/handlers"
+)
+
+// BasePath is the prefix every API route is mounted under
+const BasePath = "/api/v1"
+
+// Route is one API endpoint, relative to BasePath
+type Route struct {
+	Method  string
+	Path    string
+	Handler gin.HandlerFunc
+}
+
+// Routes lists every API endpoint. Register adds HEAD for each GET and
+// OPTIONS for each path, so neither is listed here.
+var Routes = []Route{
+	{http.MethodGet, "/todos", handlers.GetTodos},
+	{http.MethodPost, "/todos", handlers.CreateTodo},
+	{http.MethodGet, "/todos/agenda", handlers.GetAgenda},
+	{http.MethodPost, "/todos/bulk/due-dates", handlers.SetTodoDueDates},
+	{http.MethodPost, "/todos/bulk/move", handlers.MoveTodos},
+	{http.MethodGet, "/todos/changes-count", handlers.GetTodoChangesCount},
+	{http.MethodGet, "/todos/forecast", handlers.GetForecast},
+	{http.MethodPost, "/todos/import", handlers.ImportTodo},
+	{http.MethodGet, "/todos/recently-viewed", handlers.GetRecentlyViewedTodos},
+	{http.MethodGet, "/todos/similar", handlers.GetSimilarTodos},
+	{http.MethodGet, "/todos/stale", handlers.GetStaleTodos},
+	{http.MethodGet, "/todos/stats/estimation-drift", handlers.GetEstimationDrift},
+	{http.MethodGet, "/todos/suggest", handlers.GetSuggestions},
+	{http.MethodGet, "/search", handlers.Search},
+	{http.MethodGet, "/todos/:id", handlers.GetTodo},
+	{http.MethodPut, "/todos/:id", handlers.UpdateTodo},
+	{http.MethodDelete, "/todos/:id", handlers.DeleteTodo},
+	{http.MethodGet, "/todos/:id/activity", handlers.GetTodoActivity},
+	{http.MethodGet, "/todos/:id/backlinks", handlers.GetTodoBacklinks},
+	{http.MethodPost, "/todos/:id/defer", handlers.DeferTodo},
+	{http.MethodDelete, "/todos/:id/defer", handlers.UndeferTodo},
+	{http.MethodGet, "/todos/:id/export", handlers.ExportTodo},
+	{http.MethodGet, "/todos/:id/full", handlers.GetTodoFull},
+	{http.MethodPost, "/todos/:id/github-link", handlers.LinkGitHubIssue},
+	{http.MethodDelete, "/todos/:id/github-link", handlers.UnlinkGitHubIssue},
+	{http.MethodPost, "/todos/:id/lock", handlers.AcquireTodoLock},
+	{http.MethodPut, "/todos/:id/lock", handlers.RenewTodoLock},
+	{http.MethodDelete, "/todos/:id/lock", handlers.ReleaseTodoLock},
+	{http.MethodGet, "/todos/:id/links", handlers.GetTodoLinks},
+	{http.MethodPost, "/todos/:id/links", handlers.CreateTodoLink},
+	{http.MethodDelete, "/todos/:id/links/:linkId", handlers.DeleteTodoLink},
+	{http.MethodGet, "/todos/:id/reminders", handlers.GetTodoReminders},
+	{http.MethodPost, "/todos/:id/reminders", handlers.CreateTodoReminder},
+	{http.MethodDelete, "/todos/:id/reminders/:reminderId", handlers.DeleteTodoReminder},
+	{http.MethodGet, "/todos/:id/revisions", handlers.GetTodoRevisions},
+	{http.MethodGet, "/todos/:id/estimate-history", handlers.GetEstimateHistory},
+	{http.MethodPost, "/todos/:id/revisions/:version/restore", handlers.RestoreTodoRevision},
+	{http.MethodPost, "/todos/:id/split", handlers.SplitTodo},
+	{http.MethodGet, "/todos/:id/subtasks", handlers.GetSubtasks},
+	{http.MethodPost, "/todos/:id/subtasks", handlers.CreateSubtask},
+	{http.MethodPost, "/todos/:id/subtasks/apply-template/:templateId", handlers.ApplyChecklistTemplate},
+	{http.MethodPost, "/todos/:id/subtasks/save-as-template", handlers.SaveSubtasksAsTemplate},
+	{http.MethodPut, "/todos/:id/subtasks/:subtaskId", handlers.UpdateSubtask},
+	{http.MethodDelete, "/todos/:id/subtasks/:subtaskId", handlers.DeleteSubtask},
+	{http.MethodGet, "/todos/:id/time-entries", handlers.GetTimeEntries},
+	{http.MethodPost, "/todos/:id/time-entries", handlers.CreateTimeEntry},
+	{http.MethodPut, "/todos/:id/time-entries/:entryId", handlers.UpdateTimeEntry},
+	{http.MethodDelete, "/todos/:id/time-entries/:entryId", handlers.DeleteTimeEntry},
+	{http.MethodPost, "/todos/:id/timer/start", handlers.StartTimer},
+	{http.MethodPost, "/todos/:id/timer/stop", handlers.StopTimer},
+	{http.MethodGet, "/embed/todos", handlers.GetEmbedTodos},
+	{http.MethodGet, "/embeds", handlers.GetEmbeds},
+	{http.MethodPost, "/embeds", handlers.CreateEmbed},
+	{http.MethodDelete, "/embeds/:id", handlers.DeleteEmbed},
+	{http.MethodGet, "/epics", handlers.GetEpics},
+	{http.MethodPost, "/epics", handlers.CreateEpic},
+	{http.MethodPost, "/epics/import", handlers.ImportEpic},
+	{http.MethodGet, "/epics/:id", handlers.GetEpic},
+	{http.MethodPut, "/epics/:id", handlers.UpdateEpic},
+	{http.MethodDelete, "/epics/:id", handlers.DeleteEpic},
+	{http.MethodPost, "/epics/:id/todos", handlers.AddEpicTodos},
+	{http.MethodDelete, "/epics/:id/todos", handlers.RemoveEpicTodos},
+	{http.MethodGet, "/epics/:id/export.zip", handlers.ExportEpic},
+	{http.MethodGet, "/sprints", handlers.GetSprints},
+	{http.MethodPost, "/sprints", handlers.CreateSprint},
+	{http.MethodGet, "/sprints/:id", handlers.GetSprint},
+	{http.MethodPut, "/sprints/:id", handlers.UpdateSprint},
+	{http.MethodDelete, "/sprints/:id", handlers.DeleteSprint},
+	{http.MethodGet, "/sprints/:id/burndown", handlers.GetSprintBurndown},
+	{http.MethodPost, "/sprints/:id/close", handlers.CloseSprint},
+	{http.MethodPost, "/sprints/:id/start", handlers.StartSprint},
+	{http.MethodPost, "/sprints/:id/todos", handlers.AddSprintTodos},
+	{http.MethodDelete, "/sprints/:id/todos", handlers.RemoveSprintTodos},
+	{http.MethodGet, "/checklist-templates", handlers.GetChecklistTemplates},
+	{http.MethodPost, "/checklist-templates", handlers.CreateChecklistTemplate},
+	{http.MethodGet, "/checklist-templates/:id", handlers.GetChecklistTemplate},
+	{http.MethodPut, "/checklist-templates/:id", handlers.UpdateChecklistTemplate},
+	{http.MethodDelete, "/checklist-templates/:id", handlers.DeleteChecklistTemplate},
+	{http.MethodGet, "/statuses", handlers.GetStatuses},
+	{http.MethodPost, "/statuses", handlers.CreateStatus},
+	{http.MethodPut, "/statuses/:key", handlers.UpdateStatus},
+	{http.MethodDelete, "/statuses/:key", handlers.DeleteStatus},
+	{http.MethodPost, "/statuses/reorder", handlers.ReorderStatuses},
+	{http.MethodGet, "/priorities", handlers.GetPriorities},
+	{http.MethodPost, "/priorities", handlers.CreatePriority},
+	{http.MethodPut, "/priorities/:key", handlers.UpdatePriority},
+	{http.MethodDelete, "/priorities/:key", handlers.DeletePriority},
+	{http.MethodPost, "/priorities/reorder", handlers.ReorderPriorities},
+	{http.MethodGet, "/workflow", handlers.GetWorkflow},
+	{http.MethodPut, "/workflow", handlers.UpdateWorkflow},
+	{http.MethodGet, "/custom-fields", handlers.GetCustomFields},
+	{http.MethodPost, "/custom-fields", handlers.CreateCustomField},
+	{http.MethodPut, "/custom-fields/:id", handlers.UpdateCustomField},
+	{http.MethodDelete, "/custom-fields/:id", handlers.DeleteCustomField},
+	{http.MethodGet, "/time-entries", handlers.GetTimeReport},
+	{http.MethodGet, "/reports/weekly", handlers.GetWeeklyReport},
+	{http.MethodGet, "/audit", handlers.GetAuditLog},
+	{http.MethodGet, "/audit/export", handlers.ExportAuditLog},
+	{http.MethodPost, "/exports", handlers.CreateExport},
+	{http.MethodGet, "/exports/:id", handlers.GetExport},
+	{http.MethodGet, "/exports/:id/download", handlers.DownloadExport},
+	{http.MethodPost, "/exports/:id/retry", handlers.RetryExport},
+	{http.MethodGet, "/settings/escalation", handlers.GetEscalationSettings},
+	{http.MethodPut, "/settings/escalation", handlers.UpdateEscalationSettings},
+	{http.MethodGet, "/settings/story-points", handlers.GetStoryPointScale},
+	{http.MethodPut, "/settings/story-points", handlers.UpdateStoryPointScale},
+	{http.MethodGet, "/settings/todo-defaults", handlers.GetTodoDefaults},
+	{http.MethodPut, "/settings/todo-defaults", handlers.UpdateTodoDefaults},
+	{http.MethodGet, "/settings/todo-policy", handlers.GetTodoPolicy},
+	{http.MethodPut, "/settings/todo-policy", handlers.UpdateTodoPolicy},
+	{http.MethodGet, "/settings/export", handlers.ExportSettings},
+	{http.MethodGet, "/settings/note-rules", handlers.GetNoteRules},
+	{http.MethodPut, "/settings/note-rules", handlers.UpdateNoteRules},
+	{http.MethodPost, "/settings/import", handlers.ImportSettings},
+	{http.MethodGet, "/me/badges", handlers.GetBadges},
+	{http.MethodGet, "/me/preferences", handlers.GetPreferences},
+	{http.MethodPatch, "/me/preferences", handlers.UpdatePreferences},
+	{http.MethodPost, "/integrations/github/webhook", handlers.GitHubWebhook},
+	{http.MethodGet, "/admin/maintenance", handlers.GetMaintenance},
+	{http.MethodPut, "/admin/maintenance", handlers.UpdateMaintenance},
+	{http.MethodGet, "/admin/metrics", handlers.GetMetrics},
+	{http.MethodGet, "/admin/flags", handlers.ListFeatureFlags},
+	{http.MethodPut, "/admin/flags/:name", handlers.UpdateFeatureFlag},
+	{http.MethodGet, "/admin/integrations/health", handlers.GetIntegrationHealth},
+	{http.MethodGet, "/admin/credentials", handlers.ListCredentials},
+	{http.MethodPut, "/admin/credentials/:name", handlers.SetCredential},
+	{http.MethodDelete, "/admin/credentials/:name", handlers.DeleteCredential},
+	{http.MethodGet, "/admin/usage", handlers.GetUsage},
+	{http.MethodPost, "/admin/subtask-counts/reconcile", handlers.ReconcileSubtaskCounts},
+	{http.MethodGet, "/admin/integrity", handlers.GetIntegrity},
+	{http.MethodPost, "/admin/integrity/fix", handlers.FixIntegrity},
+	{http.MethodGet, "/metrics/business", handlers.GetBusinessMetrics},
+}
+
+// adminPaths lists the paths outside /admin that are admin routes all the
+// same: the audit log, the business metrics, the settings export and import,
+// which carry the whole configuration, and the todo policy, whose changes
+// lift or set the lock on done todos. Each is matched for every method, so
+// GET /settings/todo-policy needs the token too.
+var adminPaths = map[string]bool{
+	"/audit":                true,
+	"/audit/export":         true,
+	"/metrics/business":     true,
+	"/settings/export":      true,
+	"/settings/import":      true,
+	"/settings/todo-policy": true,
+}
+
+// isAdminRoute reports whether path requires the admin token
+func isAdminRoute(path string) bool {
+	return strings.HasPrefix(path, "/admin/") || adminPaths[path]
+}
+
+// Options configures the engine built by New
+type Options struct {
+	// Middleware runs before every route
+	Middleware []gin.HandlerFunc
+	// Admin runs before admin routes, after the admin token check
+	Admin []gin.HandlerFunc
+}
+
+// New returns an engine serving Routes under BasePath. Requests with a
+// method a known path does not support get a JSON 405 with an Allow header
+// instead of a 404.
+func New(opts Options) *gin.Engine {
+	engine := gin.Default()
+	engine.HandleMethodNotAllowed = true
+	engine.Use(opts.Middleware...)
+	// gin sets the Allow header before the NoMethod handlers run
+	engine.NoMethod(methodNotAllowed)
+	Register(engine.Group(BasePath), opts.Admin...)
+	return engine
+}
+
+// methodNotAllowed answers a request whose method the path does not support
+func methodNotAllowed(c *gin.Context) {
+	c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method " + c.Request.Method + " not allowed. Allowed: " + c.Writer.Header().Get("Allow")})
+}
+
+// Register adds Routes to group behind maintenance mode. Every GET route also
+// answers HEAD, and every path answers OPTIONS with an Allow header listing
+// its methods. Routes under /admin and adminPaths require the admin token,
+// then run admin.
+func Register(group *gin.RouterGroup, admin ...gin.HandlerFunc) {
+	group.Use(handlers.MaintenanceMode)
+	var paths []string
+	allowed := map[string][]string{}
+	for _, route := range Routes {
+		if _, ok := allowed[route.Path]; !ok {
+			paths = append(paths, route.Path)
+		}
+		chain := []gin.HandlerFunc{route.Handler}
+		if isAdminRoute(route.Path) {
+			chain = append(append([]gin.HandlerFunc{handlers.RequireAdmin}, admin...), route.Handler)
+		}
+		group.Handle(route.Method, route.Path, chain...)
+		allowed[route.Path] = append(allowed[route.Path], route.Method)
+		if route.Method == http.MethodGet {
+			group.HEAD(route.Path, append([]gin.HandlerFunc{headOnly}, chain...)...)
+			allowed[route.Path] = append(allowed[route.Path], http.MethodHead)
+		}
+	}
+	for _, path := range paths {
+		group.OPTIONS(path, allow(append(allowed[path], http.MethodOptions)))
+	}
+}
+
+// allow answers OPTIONS for a path supporting methods. CORS preflight
+// requests are answered by the CORS middleware before this runs.
+func allow(methods []string) gin.HandlerFunc {
+	header := strings.Join(methods, ", ")
+	return func(c *gin.Context) {
+		c.Header("Allow", header)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// headWriter counts the body a handler writes instead of sending it
+type headWriter struct {
+	gin.ResponseWriter
+	size int
+}
+
+func (w *headWriter) Write(data []byte) (int, error) {
+	w.size += len(data)
+	return len(data), nil
+}
+
+func (w *headWriter) WriteString(s string) (int, error) {
+	w.size += len(s)
+	return len(s), nil
+}
+
+// headOnly runs a GET handler for a HEAD request, dropping the body but
+// sending the Content-Length the GET response would have had
+func headOnly(c *gin.Context) {
+	writer := &headWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	c.Next()
+	c.Writer = writer.ResponseWriter
+
+	// A handler that flushed has already sent its headers
+	if !c.Writer.Written() {
+		if writer.size > 0 {
+			c.Header("Content-Length", strconv.Itoa(writer.size))
+		}
+		c.Writer.WriteHeaderNow()
+	}
+}
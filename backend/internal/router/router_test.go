@@ -0,0 +1,216 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testAdminToken = "test-admin-token"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// stubEngine returns an engine for Routes whose handlers answer with the
+// method and path of their route, so a test can tell which route served a
+// request without a database
+func stubEngine(t *testing.T) *gin.Engine {
+	t.Helper()
+	t.Setenv("ADMIN_TOKEN", testAdminToken)
+	original := Routes
+	Routes = make([]Route, len(original))
+	for i, route := range original {
+		name := route.Method + " " + route.Path
+		Routes[i] = Route{route.Method, route.Path, func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"route": name})
+		}}
+	}
+	t.Cleanup(func() { Routes = original })
+	return New(Options{})
+}
+
+// concretePath fills the parameters of a route path with 1
+func concretePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "1"
+		}
+	}
+	return BasePath + strings.Join(segments, "/")
+}
+
+// serve sends a request to engine, with the admin token when admin is set
+func serve(engine *gin.Engine, method, path string, admin bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if admin {
+		req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+// routeMethods returns each path of Routes with its methods, in table order
+func routeMethods() ([]string, map[string][]string) {
+	var paths []string
+	methods := map[string][]string{}
+	for _, route := range Routes {
+		if _, ok := methods[route.Path]; !ok {
+			paths = append(paths, route.Path)
+		}
+		methods[route.Path] = append(methods[route.Path], route.Method)
+	}
+	return paths, methods
+}
+
+func TestRoutesAreUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, route := range Routes {
+		name := route.Method + " " + route.Path
+		if seen[name] {
+			t.Errorf("%s is listed twice", name)
+		}
+		seen[name] = true
+		if route.Method == http.MethodHead || route.Method == http.MethodOptions {
+			t.Errorf("%s is added by Register and must not be listed", name)
+		}
+	}
+}
+
+// Every route is served by its own handler, HEAD mirrors GET without a body,
+// and admin routes need the admin token
+func TestRoutes(t *testing.T) {
+	engine := stubEngine(t)
+	for _, route := range Routes {
+		name := route.Method + " " + route.Path
+		t.Run(name, func(t *testing.T) {
+			path := concretePath(route.Path)
+			admin := isAdminRoute(route.Path)
+			w := serve(engine, route.Method, path, admin)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d, want 200: %s", w.Code, w.Body)
+			}
+			var body struct{ Route string }
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Route != name {
+				t.Fatalf("served by %q, want %q", w.Body, name)
+			}
+
+			if route.Method == http.MethodGet {
+				head := serve(engine, http.MethodHead, path, admin)
+				if head.Code != http.StatusOK || head.Body.Len() != 0 {
+					t.Errorf("HEAD answered %d with %d bytes, want 200 without a body", head.Code, head.Body.Len())
+				}
+				if got, want := head.Header().Get("Content-Length"), strconv.Itoa(w.Body.Len()); got != want {
+					t.Errorf("HEAD Content-Length %q, want %q", got, want)
+				}
+			}
+
+			if admin {
+				if w := serve(engine, route.Method, path, false); w.Code != http.StatusUnauthorized {
+					t.Errorf("without the admin token: status %d, want 401", w.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminRoutes(t *testing.T) {
+	for path, want := range map[string]bool{
+		"/admin/flags":          true,
+		"/admin/usage":          true,
+		"/audit":                true,
+		"/audit/export":         true,
+		"/metrics/business":     true,
+		"/settings/export":      true,
+		"/settings/import":      true,
+		"/settings/todo-policy": true,
+		"/settings":             false,
+		"/todos":                false,
+		"/administrators":       false,
+		"/todos/:id/audits":     false,
+	} {
+		if got := isAdminRoute(path); got != want {
+			t.Errorf("isAdminRoute(%q) = %t, want %t", path, got, want)
+		}
+	}
+}
+
+// Every path answers OPTIONS with its methods, and other methods with a JSON
+// 405 whose Allow header names them too
+func TestAllowedMethods(t *testing.T) {
+	engine := stubEngine(t)
+	paths, methods := routeMethods()
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			var want []string
+			for _, method := range methods[path] {
+				want = append(want, method)
+				if method == http.MethodGet {
+					want = append(want, http.MethodHead)
+				}
+			}
+			want = append(want, http.MethodOptions)
+
+			w := serve(engine, http.MethodOptions, concretePath(path), false)
+			if w.Code != http.StatusNoContent {
+				t.Errorf("OPTIONS status %d, want 204", w.Code)
+			}
+			if got := w.Header().Get("Allow"); got != strings.Join(want, ", ") {
+				t.Errorf("OPTIONS Allow %q, want %q", got, strings.Join(want, ", "))
+			}
+
+			var unsupported string
+			for _, method := range []string{http.MethodPatch, http.MethodDelete, http.MethodPut, http.MethodPost, http.MethodGet} {
+				if !slices.Contains(want, method) {
+					unsupported = method
+					break
+				}
+			}
+			if unsupported == "" {
+				return
+			}
+			w = serve(engine, unsupported, concretePath(path), false)
+			if w.Code != http.StatusMethodNotAllowed {
+				t.Fatalf("%s status %d, want 405", unsupported, w.Code)
+			}
+			// The Allow header of a 405 also names the methods of a sibling
+			// parameter route matching the path, such as PUT /todos/:id for
+			// /todos/forecast, since those requests are routed there
+			allow := strings.Split(w.Header().Get("Allow"), ", ")
+			for _, method := range want {
+				if !slices.Contains(allow, method) {
+					t.Errorf("405 Allow %v is missing %s", allow, method)
+				}
+			}
+			if slices.Contains(allow, unsupported) {
+				t.Errorf("405 Allow %v names %s", allow, unsupported)
+			}
+			if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/json") {
+				t.Errorf("405 Content-Type %q, want JSON", got)
+			}
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["error"] == "" {
+				t.Errorf("405 body %q, want a JSON error", w.Body)
+			}
+		})
+	}
+}
+
+func TestUnknownPath(t *testing.T) {
+	engine := stubEngine(t)
+	for _, path := range []string{"/", BasePath, BasePath + "/nope", BasePath + "/todos/1/nope", "/todos"} {
+		if w := serve(engine, http.MethodGet, path, true); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s status %d, want 404", path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/db"
+)
+
+// Column is a text column whose values are stored encrypted. Key is the
+// table's primary key column, used to write rotated values back.
+type Column struct {
+	Table  string
+	Key    string
+	Column string
+}
+
+func (c Column) String() string {
+	return c.Table + "." + c.Column
+}
+
+// Columns lists every encrypted column: for now only the integration
+// credentials (GitHub token and webhook secret). Check and Rotate cover
+// exactly these, so a new encrypted column must be added here.
+var Columns = []Column{
+	{Table: "integration_credentials", Key: "name", Column: "value"},
+}
+
+// rotateBatchSize is how many rows Rotate re-encrypts per transaction
+const rotateBatchSize = 500
+
+// Check fails when encrypted values exist that keys cannot open: because no
+// key is configured, or because a value was sealed with a key that is no
+// longer in the keyring. The server runs it at startup so a missing key is
+// noticed before a request needs one.
+func Check(ctx context.Context, keys *Keyring) error {
+	for _, column := range Columns {
+		rows, err := db.Pool.Query(ctx, fmt.Sprintf(`
+			SELECT DISTINCT split_part(%s, ':', 2) FROM %s WHERE %s LIKE 'enc:%%'
+		`, column.Column, column.Table, column.Column))
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", column, err)
+		}
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %w", column, err)
+		}
+		if len(ids) > 0 && keys == nil {
+			return fmt.Errorf("%s holds encrypted values but %w", column, ErrNoKey)
+		}
+		for _, id := range ids {
+			if _, ok := keys.keys[id]; !ok {
+				return fmt.Errorf("%s holds values encrypted with key %q, which is not in ENCRYPTION_KEYS", column, id)
+			}
+		}
+	}
+	return nil
+}
+
+// Rotate re-encrypts every value of Columns that is not sealed with the
+// current key, including values stored before encryption was turned on. Old
+// keys must stay in the keyring until it finishes; each batch commits on its
+// own, so an interrupted rotation can simply be run again. It returns the
+// number of values rewritten.
+func Rotate(ctx context.Context, keys *Keyring) (int, error) {
+	if keys == nil {
+		return 0, ErrNoKey
+	}
+	current := prefix + keys.current + ":"
+	total := 0
+	for _, column := range Columns {
+		for {
+			var rewritten int
+			err := db.WithTx(ctx, func(tx pgx.Tx) error {
+				rows, err := tx.Query(ctx, fmt.Sprintf(`
+					SELECT %s::text, %s FROM %s
+					WHERE %s IS NOT NULL AND %s NOT LIKE $1
+					LIMIT $2
+					FOR UPDATE
+				`, column.Key, column.Column, column.Table, column.Column, column.Column),
+					strings.ReplaceAll(current, "_", `\_`)+"%", rotateBatchSize)
+				if err != nil {
+					return err
+				}
+				type row struct{ key, value string }
+				batch, err := pgx.CollectRows(rows, func(r pgx.CollectableRow) (row, error) {
+					var item row
+					err := r.Scan(&item.key, &item.value)
+					return item, err
+				})
+				if err != nil {
+					return err
+				}
+
+				for _, item := range batch {
+					plaintext, err := keys.Decrypt(item.value)
+					if err != nil {
+						return fmt.Errorf("%s row %s: %w", column, item.key, err)
+					}
+					sealed, err := keys.Encrypt(plaintext)
+					if err != nil {
+						return err
+					}
+					if _, err := tx.Exec(ctx, fmt.Sprintf(`
+						UPDATE %s SET %s = $1 WHERE %s::text = $2
+					`, column.Table, column.Column, column.Key), sealed, item.key); err != nil {
+						return err
+					}
+				}
+				rewritten = len(batch)
+				return nil
+			})
+			if err != nil {
+				return total, fmt.Errorf("failed to rotate %s: %w", column, err)
+			}
+			total += rewritten
+			if rewritten < rotateBatchSize {
+				break
+			}
+		}
+	}
+	return total, nil
+}
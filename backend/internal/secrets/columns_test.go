@@ -0,0 +1,101 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"flow-v1/backend/internal/db"
+)
+
+// usePool points db.Pool at TEST_DATABASE_URL, a database with the
+// migrations applied, for the test, which is skipped when it is unset
+func usePool(t *testing.T) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatalf("connecting to TEST_DATABASE_URL: %v", err)
+	}
+	previous := db.Pool
+	db.Pool = pool
+	t.Cleanup(func() {
+		db.Pool = previous
+		pool.Close()
+	})
+}
+
+// Rotate moves every value, plaintext or sealed with an old key, to the
+// current key without changing what it decrypts to
+func TestRotate(t *testing.T) {
+	usePool(t)
+	ctx := context.Background()
+	cleanup := func() {
+		db.Pool.Exec(context.Background(), `DELETE FROM integration_credentials WHERE name LIKE 'rotate_test_%'`)
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	old := testKeyring(t, "old", "old")
+	want := map[string]string{}
+	for i, value := range []string{"plaintext token", "sealed token", "another sealed token"} {
+		name := "rotate_test_" + string(rune('a'+i))
+		stored := value
+		if i > 0 {
+			stored, _ = old.Encrypt(value)
+		}
+		if _, err := db.Pool.Exec(ctx, `INSERT INTO integration_credentials (name, value) VALUES ($1, $2)`, name, stored); err != nil {
+			t.Fatalf("storing %s: %v", name, err)
+		}
+		want[name] = value
+	}
+
+	if err := Check(ctx, nil); err == nil {
+		t.Error("Check passed without a key while values are encrypted")
+	}
+	if err := Check(ctx, old); err != nil {
+		t.Errorf("Check with the key in use: %v", err)
+	}
+
+	rotated := testKeyring(t, "new", "old", "new")
+	if _, err := Rotate(ctx, rotated); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	rows, err := db.Pool.Query(ctx, `SELECT name, value FROM integration_credentials WHERE name LIKE 'rotate_test_%'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	seen := 0
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			t.Fatal(err)
+		}
+		seen++
+		if !strings.HasPrefix(value, "enc:new:") {
+			t.Errorf("%s is %q after the rotation, want it sealed with the new key", name, value)
+		}
+		if opened, err := testKeyring(t, "new", "new").Decrypt(value); err != nil || opened != want[name] {
+			t.Errorf("%s opens to %q, %v, want %q", name, opened, err, want[name])
+		}
+	}
+	if seen != len(want) {
+		t.Errorf("found %d credentials after the rotation, want %d", seen, len(want))
+	}
+
+	// The old key can go now, and running the rotation again changes nothing
+	if err := Check(ctx, testKeyring(t, "new", "new")); err != nil {
+		t.Errorf("Check without the old key after the rotation: %v", err)
+	}
+	if n, err := Rotate(ctx, testKeyring(t, "new", "new")); err != nil || n != 0 {
+		t.Errorf("a second rotation rewrote %d values, %v, want none", n, err)
+	}
+}
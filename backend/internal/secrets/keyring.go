@@ -0,0 +1,167 @@
+// Package secrets encrypts sensitive column values, such as integration
+// credentials, before they are stored. Values are sealed with AES-256-GCM and
+// prefixed with the ID of the key that sealed them, so keys can be rotated
+// while rows sealed with an older key stay readable.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// prefix starts every encrypted value: enc:<key ID>:<base64 nonce and ciphertext>
+const prefix = "enc:"
+
+// keyIDPattern matches key IDs; they cannot contain the ':' separator
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// ErrNoKey is returned when encrypting or decrypting without a configured key
+var ErrNoKey = errors.New("no encryption key configured. Set ENCRYPTION_KEYS")
+
+// Keyring seals values with its current key and opens values sealed with
+// any of its keys
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// Keys is the keyring loaded by Init; it is nil when ENCRYPTION_KEYS is unset
+var Keys *Keyring
+
+// Init loads Keys from ENCRYPTION_KEYS
+func Init() error {
+	keys, err := KeyringFromEnv()
+	if err != nil {
+		return err
+	}
+	Keys = keys
+	return nil
+}
+
+// KeyringFromEnv reads ENCRYPTION_KEYS: comma-separated id:key pairs, where
+// each key is 32 base64-encoded bytes. The first key seals new values; the
+// others are kept to open values sealed before a rotation. It returns nil when
+// the variable is unset.
+func KeyringFromEnv() (*Keyring, error) {
+	value := os.Getenv("ENCRYPTION_KEYS")
+	if value == "" {
+		return nil, nil
+	}
+	var ids []string
+	keys := map[string][]byte{}
+	for _, pair := range strings.Split(value, ",") {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid ENCRYPTION_KEYS entry %q, expected id:base64key", pair)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ENCRYPTION_KEYS key %q: not base64", id)
+		}
+		if _, ok := keys[id]; ok {
+			return nil, fmt.Errorf("invalid ENCRYPTION_KEYS: key %q is listed twice", id)
+		}
+		ids = append(ids, id)
+		keys[id] = key
+	}
+	return NewKeyring(ids[0], keys)
+}
+
+// NewKeyring builds a keyring sealing with the key named current. Every key
+// must be 32 bytes, for AES-256.
+func NewKeyring(current string, keys map[string][]byte) (*Keyring, error) {
+	ring := &Keyring{current: current, keys: map[string]cipher.AEAD{}}
+	for id, key := range keys {
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid key ID %q: use letters, digits, '-' and '_'", id)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key %q is %d bytes, AES-256 needs 32", id, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		if ring.keys[id], err = cipher.NewGCM(block); err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+	}
+	if _, ok := ring.keys[current]; !ok {
+		return nil, fmt.Errorf("current key %q is not in the keyring", current)
+	}
+	return ring, nil
+}
+
+// CurrentKeyID returns the ID of the key new values are sealed with
+func (k *Keyring) CurrentKeyID() string {
+	return k.current
+}
+
+// Encrypt seals plaintext with the current key
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if k == nil {
+		return "", ErrNoKey
+	}
+	aead := k.keys[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + k.current + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value sealed by Encrypt with any key in the keyring.
+// Values without the encrypted prefix were stored before encryption was
+// turned on and are returned unchanged.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	id, ok := KeyID(value)
+	if !ok {
+		return value, nil
+	}
+	if k == nil {
+		return "", ErrNoKey
+	}
+	aead, ok := k.keys[id]
+	if !ok {
+		return "", fmt.Errorf("value was encrypted with unknown key %q", id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(value[len(prefix)+len(id)+1:])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value with key %q: %w", id, err)
+	}
+	return string(plaintext), nil
+}
+
+// KeyID returns the ID of the key an encrypted value was sealed with, and
+// false for values that are not encrypted
+func KeyID(value string) (string, bool) {
+	rest, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return "", false
+	}
+	id, _, ok := strings.Cut(rest, ":")
+	return id, ok && id != ""
+}
+
+// Encrypt seals plaintext with Keys. Stores call it on every value written
+// to one of Columns.
+func Encrypt(plaintext string) (string, error) {
+	return Keys.Encrypt(plaintext)
+}
+
+// Decrypt opens a value read from one of Columns with Keys
+func Decrypt(value string) (string, error) {
+	return Keys.Decrypt(value)
+}
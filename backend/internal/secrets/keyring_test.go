@@ -0,0 +1,161 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testKey returns a 32-byte key filled with b
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func testKeyring(t *testing.T, current string, ids ...string) *Keyring {
+	t.Helper()
+	keys := map[string][]byte{}
+	for i, id := range ids {
+		keys[id] = testKey(byte(i + 1))
+	}
+	ring, err := NewKeyring(current, keys)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return ring
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	ring := testKeyring(t, "k1", "k1")
+	for _, plaintext := range []string{"", "ghp_token", "https://hooks.slack.com/services/T0/B0/x", strings.Repeat("é", 1000)} {
+		sealed, err := ring.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt(%q): %v", plaintext, err)
+		}
+		if !strings.HasPrefix(sealed, "enc:k1:") || (plaintext != "" && strings.Contains(sealed, plaintext)) {
+			t.Errorf("Encrypt(%q) = %q, want it sealed with k1", plaintext, sealed)
+		}
+		if id, ok := KeyID(sealed); !ok || id != "k1" {
+			t.Errorf("KeyID(%q) = %q, %t, want k1", sealed, id, ok)
+		}
+		opened, err := ring.Decrypt(sealed)
+		if err != nil || opened != plaintext {
+			t.Errorf("Decrypt(Encrypt(%q)) = %q, %v", plaintext, opened, err)
+		}
+	}
+
+	first, _ := ring.Encrypt("same")
+	second, _ := ring.Encrypt("same")
+	if first == second {
+		t.Error("encrypting a value twice gave the same ciphertext")
+	}
+}
+
+// Values stored before encryption was turned on read as they are
+func TestDecryptPlaintext(t *testing.T) {
+	for _, ring := range []*Keyring{nil, testKeyring(t, "k1", "k1")} {
+		for _, value := range []string{"", "ghp_token", "enc", "enc:", "enc::x"} {
+			if got, err := ring.Decrypt(value); err != nil || got != value {
+				t.Errorf("Decrypt(%q) = %q, %v, want it unchanged", value, got, err)
+			}
+		}
+	}
+}
+
+// After a rotation the new key seals and both keys open, so rows are
+// readable while they are re-encrypted
+func TestRotationKeyring(t *testing.T) {
+	old := testKeyring(t, "old", "old")
+	sealed, err := old.Encrypt("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := testKeyring(t, "new", "old", "new")
+	if rotated.keys["old"] == nil {
+		t.Fatal("the rotated keyring lost the old key")
+	}
+	// testKeyring gives keys by position, so "old" is the same key in both
+	if opened, err := rotated.Decrypt(sealed); err != nil || opened != "secret" {
+		t.Errorf("the rotated keyring opened %q, %v, want the old value", opened, err)
+	}
+	resealed, err := rotated.Encrypt("secret")
+	if err != nil || !strings.HasPrefix(resealed, "enc:new:") {
+		t.Errorf("the rotated keyring sealed %q, %v, want the new key", resealed, err)
+	}
+
+	// Once the old key is dropped, its values no longer open
+	dropped := testKeyring(t, "new", "new")
+	if _, err := dropped.Decrypt(sealed); err == nil || !strings.Contains(err.Error(), `unknown key "old"`) {
+		t.Errorf("a keyring without the old key opened its value: %v", err)
+	}
+}
+
+func TestDecryptRejectsTampering(t *testing.T) {
+	ring := testKeyring(t, "k1", "k1")
+	sealed, _ := ring.Encrypt("secret")
+	raw, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, "enc:k1:"))
+	raw[len(raw)-1] ^= 1
+	for _, value := range []string{
+		"enc:k1:" + base64.StdEncoding.EncodeToString(raw),
+		"enc:k1:not base64",
+		"enc:k1:" + base64.StdEncoding.EncodeToString([]byte("short")),
+		// The right ciphertext labelled with another key
+		strings.Replace(sealed, "enc:k1:", "enc:k2:", 1),
+	} {
+		if got, err := testKeyring(t, "k1", "k1", "k2").Decrypt(value); err == nil {
+			t.Errorf("Decrypt(%q) = %q, want an error", value, got)
+		}
+	}
+}
+
+func TestNoKey(t *testing.T) {
+	var ring *Keyring
+	if _, err := ring.Encrypt("secret"); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Encrypt without a key: %v, want ErrNoKey", err)
+	}
+	if _, err := ring.Decrypt("enc:k1:AAAA"); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Decrypt without a key: %v, want ErrNoKey", err)
+	}
+}
+
+func TestKeyringFromEnv(t *testing.T) {
+	k1 := base64.StdEncoding.EncodeToString(testKey(1))
+	k2 := base64.StdEncoding.EncodeToString(testKey(2))
+	tests := []struct {
+		value   string
+		current string
+		err     bool
+	}{
+		{"", "", false},
+		{"k1:" + k1, "k1", false},
+		{"k2:" + k2 + ", k1:" + k1, "k2", false},
+		{"k1", "", true},
+		{"k1:not-base64!", "", true},
+		{"k1:" + base64.StdEncoding.EncodeToString(testKey(1)[:16]), "", true},
+		{"k1:" + k1 + ",k1:" + k2, "", true},
+		{"bad:id:" + k1, "", true},
+		{"bad id:" + k1, "", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("ENCRYPTION_KEYS", tt.value)
+		ring, err := KeyringFromEnv()
+		if (err != nil) != tt.err {
+			t.Errorf("KeyringFromEnv(%q) error %v, want error %t", tt.value, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if tt.current == "" {
+			if ring != nil {
+				t.Errorf("KeyringFromEnv(%q) = %v, want nil", tt.value, ring)
+			}
+			continue
+		}
+		if ring.CurrentKeyID() != tt.current {
+			t.Errorf("KeyringFromEnv(%q) seals with %q, want %q", tt.value, ring.CurrentKeyID(), tt.current)
+		}
+	}
+}
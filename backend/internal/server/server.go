@@ -0,0 +1,231 @@
+// Package server runs the HTTP API on its configured listeners: TCP with
+// optional TLS, an optional HTTP to HTTPS redirect and an optional Unix
+// domain socket.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// shutdownTimeout bounds how long in-flight requests get to finish on shutdown
+const shutdownTimeout = 10 * time.Second
+
+// defaultSocketMode is used when LISTEN_SOCKET_MODE is unset
+const defaultSocketMode os.FileMode = 0o660
+
+// Config says where and how the API listens
+type Config struct {
+	// Addr is the TCP address, from PORT
+	Addr string
+	// TLSCertFile and TLSKeyFile enable HTTPS on Addr when both are set
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSClientCAFile enables client certificates, which admin routes then
+	// require; other routes accept requests without one
+	TLSClientCAFile string
+	// RedirectAddr, when set with TLS, serves plain HTTP there redirecting to HTTPS
+	RedirectAddr string
+	// Socket is the path of a Unix domain socket to also serve on
+	Socket     string
+	SocketMode os.FileMode
+}
+
+// ConfigFromEnv reads PORT, TLS_CERT_FILE, TLS_KEY_FILE, TLS_CLIENT_CA_FILE,
+// HTTP_REDIRECT_ADDR, LISTEN_SOCKET and LISTEN_SOCKET_MODE (octal, default 0660)
+func ConfigFromEnv() (Config, error) {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	cfg := Config{
+		Addr:            ":" + port,
+		TLSCertFile:     os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:      os.Getenv("TLS_KEY_FILE"),
+		TLSClientCAFile: os.Getenv("TLS_CLIENT_CA_FILE"),
+		RedirectAddr:    os.Getenv("HTTP_REDIRECT_ADDR"),
+		Socket:          os.Getenv("LISTEN_SOCKET"),
+		SocketMode:      defaultSocketMode,
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return cfg, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if !cfg.TLS() && (cfg.TLSClientCAFile != "" || cfg.RedirectAddr != "") {
+		return cfg, errors.New("TLS_CLIENT_CA_FILE and HTTP_REDIRECT_ADDR require TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	if value := os.Getenv("LISTEN_SOCKET_MODE"); value != "" {
+		mode, err := strconv.ParseUint(value, 8, 32)
+		if err != nil || mode > 0o777 {
+			return cfg, fmt.Errorf("invalid LISTEN_SOCKET_MODE: %q", value)
+		}
+		cfg.SocketMode = os.FileMode(mode)
+	}
+	return cfg, nil
+}
+
+// TLS reports whether HTTPS is configured
+func (cfg Config) TLS() bool {
+	return cfg.TLSCertFile != "" && cfg.TLSKeyFile != ""
+}
+
+// TLSConfig returns the TLS settings for cfg: TLS 1.2 or later with forward
+// secret AEAD ciphers only, and client certificates verified against
+// TLSClientCAFile when set
+func (cfg Config) TLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+	}
+	if cfg.TLSClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.TLSClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, nil
+}
+
+// RequireClientCert only lets through requests that presented a client
+// certificate signed by the configured client CA
+func RequireClientCert(c *gin.Context) {
+	if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Client certificate required"})
+		return
+	}
+	c.Next()
+}
+
+// Serve serves handler on every listener in cfg until ctx is cancelled, then
+// shuts them all down, giving in-flight requests shutdownTimeout to finish.
+// It returns the first listener error, if any.
+func Serve(ctx context.Context, handler http.Handler, cfg Config) error {
+	srv := &http.Server{Addr: cfg.Addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	servers := []*http.Server{srv}
+	var serves []func() error
+
+	listener, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", cfg.Addr, err)
+	}
+	if cfg.TLS() {
+		if srv.TLSConfig, err = cfg.TLSConfig(); err != nil {
+			listener.Close()
+			return err
+		}
+		serves = append(serves, func() error { return srv.ServeTLS(listener, cfg.TLSCertFile, cfg.TLSKeyFile) })
+		log.Printf("Serving HTTPS on %s", listener.Addr())
+	} else {
+		serves = append(serves, func() error { return srv.Serve(listener) })
+		log.Printf("Serving HTTP on %s", listener.Addr())
+	}
+
+	if cfg.TLS() && cfg.RedirectAddr != "" {
+		redirect := &http.Server{Addr: cfg.RedirectAddr, Handler: redirectToHTTPS(listener.Addr()), ReadHeaderTimeout: 10 * time.Second}
+		servers = append(servers, redirect)
+		serves = append(serves, redirect.ListenAndServe)
+		log.Printf("Redirecting HTTP on %s to HTTPS", cfg.RedirectAddr)
+	}
+
+	if cfg.Socket != "" {
+		socket, err := listenSocket(cfg.Socket, cfg.SocketMode)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		// The TCP server also owns the socket so one Shutdown closes both
+		serves = append(serves, func() error { return srv.Serve(socket) })
+		log.Printf("Serving HTTP on unix socket %s", cfg.Socket)
+	}
+
+	errs := make(chan error, len(serves))
+	for _, serve := range serves {
+		go func(serve func() error) {
+			if err := serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errs <- err
+			}
+		}(serve)
+	}
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+	case serveErr = <-errs:
+	}
+
+	log.Printf("Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, s := range servers {
+		wg.Add(1)
+		go func(s *http.Server) {
+			defer wg.Done()
+			if err := s.Shutdown(shutdownCtx); err != nil {
+				log.Printf("Error shutting down server on %s: %v", s.Addr, err)
+			}
+		}(s)
+	}
+	wg.Wait()
+	return serveErr
+}
+
+// listenSocket listens on a Unix domain socket at path with the given file
+// mode, replacing a socket left behind by an earlier run
+func listenSocket(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set mode of %s: %w", path, err)
+	}
+	return listener, nil
+}
+
+// redirectToHTTPS redirects every request to the same path on the HTTPS
+// listener at httpsAddr
+func redirectToHTTPS(httpsAddr net.Addr) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr.String())
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,304 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// certificate is a key pair signed by a test CA, written as PEM files
+type certificate struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+// issue creates a certificate for name, signed by parent or self-signed as
+// a CA when parent is nil, and writes it to dir
+func issue(t *testing.T, dir, name string, parent *certificate, usage x509.ExtKeyUsage) *certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		template.ExtKeyUsage = nil
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &certificate{cert: cert, key: key, certFile: filepath.Join(dir, name+".crt"), keyFile: filepath.Join(dir, name+".key")}
+	if err := os.WriteFile(c.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(c.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// freeAddr returns a loopback address with a port nothing listens on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// serve runs Serve with cfg until the test ends, failing it if Serve
+// returns an error, and waits for addr to accept connections
+func serve(t *testing.T, handler http.Handler, cfg Config, addr string) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, handler, cfg) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s is not accepting connections: %v", addr, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// A self-signed TLS listener serves HTTPS with the configured protocol
+// floor, admin routes require a client certificate from the client CA, and
+// plain HTTP is redirected
+func TestServeTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, dir, "ca", nil, 0)
+	serverCert := issue(t, dir, "server", ca, x509.ExtKeyUsageServerAuth)
+	clientCert := issue(t, dir, "client", ca, x509.ExtKeyUsageClientAuth)
+	stranger := issue(t, dir, "stranger", issue(t, dir, "other-ca", nil, 0), x509.ExtKeyUsageClientAuth)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/todos", func(c *gin.Context) { c.String(http.StatusOK, "todos") })
+	engine.GET("/admin/flags", RequireClientCert, func(c *gin.Context) { c.String(http.StatusOK, "flags") })
+
+	addr, redirectAddr := freeAddr(t), freeAddr(t)
+	cfg := Config{
+		Addr:            addr,
+		TLSCertFile:     serverCert.certFile,
+		TLSKeyFile:      serverCert.keyFile,
+		TLSClientCAFile: ca.certFile,
+		RedirectAddr:    redirectAddr,
+	}
+	serve(t, engine, cfg, addr)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := func(tlsConfig *tls.Config) *http.Client {
+		tlsConfig.RootCAs = roots
+		return &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	withCert := func(c *certificate) []tls.Certificate {
+		pair, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []tls.Certificate{pair}
+	}
+
+	tests := []struct {
+		name   string
+		client *http.Client
+		path   string
+		want   int
+	}{
+		{"no client certificate", client(&tls.Config{}), "/todos", http.StatusOK},
+		{"admin without a client certificate", client(&tls.Config{}), "/admin/flags", http.StatusForbidden},
+		{"admin with a client certificate", client(&tls.Config{Certificates: withCert(clientCert)}), "/admin/flags", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.client.Get("https://" + addr + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			io.Copy(io.Discard, resp.Body)
+			if resp.StatusCode != tt.want {
+				t.Errorf("status %d, want %d", resp.StatusCode, tt.want)
+			}
+			if resp.TLS == nil || resp.TLS.Version < tls.VersionTLS12 {
+				t.Errorf("connection state %+v, want TLS 1.2 or later", resp.TLS)
+			}
+		})
+	}
+
+	t.Run("certificate from another CA", func(t *testing.T) {
+		// Sent whatever CAs the server asks for; a client choosing from
+		// Certificates would hold it back
+		stranger := withCert(stranger)[0]
+		resp, err := client(&tls.Config{GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return &stranger, nil
+		}}).Get("https://" + addr + "/todos")
+		if err == nil {
+			resp.Body.Close()
+			t.Errorf("status %d, want the handshake to fail", resp.StatusCode)
+		}
+	})
+
+	t.Run("TLS 1.1", func(t *testing.T) {
+		resp, err := client(&tls.Config{MinVersion: tls.VersionTLS10, MaxVersion: tls.VersionTLS11}).Get("https://" + addr + "/todos")
+		if err == nil {
+			resp.Body.Close()
+			t.Errorf("status %d, want the handshake to fail", resp.StatusCode)
+		}
+	})
+
+	t.Run("plain HTTP redirect", func(t *testing.T) {
+		resp, err := client(&tls.Config{}).Get("http://" + redirectAddr + "/todos?limit=5")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		_, port, _ := net.SplitHostPort(addr)
+		want := "https://127.0.0.1:" + port + "/todos?limit=5"
+		if resp.StatusCode != http.StatusPermanentRedirect || resp.Header.Get("Location") != want {
+			t.Errorf("status %d to %q, want 308 to %q", resp.StatusCode, resp.Header.Get("Location"), want)
+		}
+	})
+}
+
+// The Unix domain socket is served with its mode alongside TCP, replacing a
+// socket left behind by an earlier run
+func TestServeSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "flow.sock")
+	stale, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	addr := freeAddr(t)
+	serve(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}), Config{Addr: addr, Socket: socket, SocketMode: 0o600}, addr)
+
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode %v, want 0600", info.Mode().Perm())
+	}
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	resp, err := client.Get("http://flow/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "ok" {
+		t.Errorf("body %q over the socket, want ok", body)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		err  string
+	}{
+		{"defaults", nil, ""},
+		{"TLS", map[string]string{"TLS_CERT_FILE": "c", "TLS_KEY_FILE": "k", "TLS_CLIENT_CA_FILE": "ca", "HTTP_REDIRECT_ADDR": ":80"}, ""},
+		{"certificate without key", map[string]string{"TLS_CERT_FILE": "c"}, "must be set together"},
+		{"client CA without TLS", map[string]string{"TLS_CLIENT_CA_FILE": "ca"}, "require TLS_CERT_FILE"},
+		{"redirect without TLS", map[string]string{"HTTP_REDIRECT_ADDR": ":80"}, "require TLS_CERT_FILE"},
+		{"socket mode", map[string]string{"LISTEN_SOCKET": "/tmp/s", "LISTEN_SOCKET_MODE": "0600"}, ""},
+		{"socket mode not octal", map[string]string{"LISTEN_SOCKET_MODE": "0689"}, "invalid LISTEN_SOCKET_MODE"},
+		{"socket mode too wide", map[string]string{"LISTEN_SOCKET_MODE": "1777"}, "invalid LISTEN_SOCKET_MODE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"PORT", "TLS_CERT_FILE", "TLS_KEY_FILE", "TLS_CLIENT_CA_FILE", "HTTP_REDIRECT_ADDR", "LISTEN_SOCKET", "LISTEN_SOCKET_MODE"} {
+				t.Setenv(name, tt.env[name])
+			}
+			cfg, err := ConfigFromEnv()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("error %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Addr != ":8080" || cfg.TLS() != (tt.env["TLS_CERT_FILE"] != "") {
+				t.Errorf("config %+v", cfg)
+			}
+			if tt.env["LISTEN_SOCKET_MODE"] == "" && cfg.SocketMode != defaultSocketMode || tt.env["LISTEN_SOCKET_MODE"] == "0600" && cfg.SocketMode != 0o600 {
+				t.Errorf("socket mode %v", cfg.SocketMode)
+			}
+		})
+	}
+}
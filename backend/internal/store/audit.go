@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/models"
+)
+
+// maxAuditDiffBytes caps the size of a stored diff; larger diffs keep only
+// the names of the changed fields and are flagged as truncated.
+const maxAuditDiffBytes = 8 * 1024
+
+// AuditSource is who made an audited change. RequestID and IP are "" for
+// changes made outside a request and are then stored as NULL.
+type AuditSource struct {
+	Actor     string
+	RequestID string
+	IP        string
+}
+
+// RecordAudit writes an audit_log row in tx, the transaction of the change
+// it describes, so the entry commits or rolls back together with it. before
+// is nil for creates and after is nil for deletes.
+func RecordAudit(ctx context.Context, tx pgx.Tx, source AuditSource, action, entityType string, entityID int64, before, after interface{}) error {
+	diff, truncated, err := auditDiff(before, after)
+	if err != nil {
+		return fmt.Errorf("failed to build audit diff: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO audit_log (actor, action, entity_type, entity_id, diff, diff_truncated, request_id, ip, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NOW())
+	`, source.Actor, action, entityType, entityID, diff, truncated, source.RequestID, source.IP)
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// auditDiff builds the JSON stored in audit_log.diff. Creates store the new
+// state under "after", deletes the old state under "before", and updates only
+// the fields that changed as {"field": {"before": x, "after": y}}.
+func auditDiff(before, after interface{}) ([]byte, bool, error) {
+	beforeMap, err := ToJSONMap(before)
+	if err != nil {
+		return nil, false, err
+	}
+	afterMap, err := ToJSONMap(after)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var diff map[string]interface{}
+	switch {
+	case beforeMap == nil:
+		diff = map[string]interface{}{"after": afterMap}
+	case afterMap == nil:
+		diff = map[string]interface{}{"before": beforeMap}
+	default:
+		diff = map[string]interface{}{}
+		for field, change := range DiffJSONMaps(beforeMap, afterMap, "updated_at") {
+			diff[field] = change
+		}
+	}
+
+	encoded, err := json.Marshal(diff)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(encoded) <= maxAuditDiffBytes {
+		return encoded, false, nil
+	}
+
+	fields := make([]string, 0, len(diff))
+	for field := range diff {
+		fields = append(fields, field)
+	}
+	encoded, err = json.Marshal(map[string]interface{}{"fields": fields})
+	if err != nil {
+		return nil, false, err
+	}
+	return encoded, true, nil
+}
+
+// DiffJSONMaps returns the fields whose values differ between before and
+// after, ignoring the fields named in skip
+func DiffJSONMaps(before, after map[string]interface{}, skip ...string) map[string]models.FieldChange {
+	skipped := map[string]bool{}
+	for _, field := range skip {
+		skipped[field] = true
+	}
+
+	changes := map[string]models.FieldChange{}
+	for field, newValue := range after {
+		if skipped[field] {
+			continue
+		}
+		if oldValue := before[field]; !reflect.DeepEqual(oldValue, newValue) {
+			changes[field] = models.FieldChange{Before: oldValue, After: newValue}
+		}
+	}
+	for field, oldValue := range before {
+		if _, ok := after[field]; !ok && !skipped[field] {
+			changes[field] = models.FieldChange{Before: oldValue, After: nil}
+		}
+	}
+	return changes
+}
+
+// ToJSONMap converts a model into the generic map form used for diffing
+func ToJSONMap(v interface{}) (map[string]interface{}, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(encoded, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// Creates keep the new state, deletes the old, updates the changed fields
+// other than updated_at, and oversized diffs only the names of the fields
+func TestAuditDiff(t *testing.T) {
+	type item struct {
+		Title     string `json:"title"`
+		Points    *int   `json:"points"`
+		UpdatedAt string `json:"updated_at"`
+	}
+	three := 3
+	before := item{Title: "Draft", UpdatedAt: "2025-03-10"}
+	after := item{Title: "Final", Points: &three, UpdatedAt: "2025-03-11"}
+	tests := []struct {
+		name          string
+		before, after interface{}
+		want          string
+		truncated     bool
+	}{
+		{"create", nil, after, `{"after":{"points":3,"title":"Final","updated_at":"2025-03-11"}}`, false},
+		{"delete", &before, (*item)(nil), `{"before":{"points":null,"title":"Draft","updated_at":"2025-03-10"}}`, false},
+		{"update", before, after, `{"points":{"before":null,"after":3},"title":{"before":"Draft","after":"Final"}}`, false},
+		{"oversized", before, item{Title: strings.Repeat("x", maxAuditDiffBytes)}, `{"fields":["title"]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, truncated, err := auditDiff(tt.before, tt.after)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(diff) != tt.want || truncated != tt.truncated {
+				t.Errorf("auditDiff = %s, %t, want %s, %t", diff, truncated, tt.want, tt.truncated)
+			}
+		})
+	}
+}
+
+// An entry is written with its actor, and goes when the transaction of its
+// change is rolled back
+func TestRecordAudit(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	const entityType = "audit_test"
+	t.Cleanup(func() { pool.Exec(context.Background(), `DELETE FROM audit_log WHERE entity_type = $1`, entityType) })
+	source := AuditSource{Actor: "local", RequestID: "req-1"}
+
+	for _, commit := range []bool{false, true} {
+		tx, err := pool.Begin(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := RecordAudit(ctx, tx, source, "update", entityType, 1, map[string]bool{"on": false}, map[string]bool{"on": true}); err != nil {
+			tx.Rollback(ctx)
+			t.Fatal(err)
+		}
+		if commit {
+			err = tx.Commit(ctx)
+		} else {
+			err = tx.Rollback(ctx)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var count int
+	var actor, requestID string
+	var ip *string
+	var diff json.RawMessage
+	if err := pool.QueryRow(ctx, `
+		SELECT COUNT(*) OVER (), actor, request_id, ip, diff FROM audit_log WHERE entity_type = $1
+	`, entityType).Scan(&count, &actor, &requestID, &ip, &diff); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || actor != source.Actor || requestID != source.RequestID || ip != nil {
+		t.Errorf("%d entries, the last by %q for request %q from %v, want only the committed one by %q for %q with no IP",
+			count, actor, requestID, ip, source.Actor, source.RequestID)
+	}
+	if string(diff) != `{"on": {"after": true, "before": false}}` {
+		t.Errorf("diff %s, want on from false to true", diff)
+	}
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"flow-v1/backend/internal/secrets"
+)
+
+// Integration credentials that can be stored by name. Each one can also be
+// set with the environment variable of the same name in upper case, which
+// takes precedence.
+const (
+	CredentialGitHubToken         = "github_token"
+	CredentialGitHubWebhookSecret = "github_webhook_secret"
+)
+
+// Credentials lists every credential name, in the order they are listed
+var Credentials = []string{CredentialGitHubToken, CredentialGitHubWebhookSecret}
+
+// GetCredential returns the stored credential name, decrypted, and "" when
+// it is not stored
+func GetCredential(ctx context.Context, q Querier, name string) (string, error) {
+	var value string
+	err := q.QueryRow(ctx, `SELECT value FROM integration_credentials WHERE name = $1`, name).Scan(&value)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return secrets.Decrypt(value)
+}
+
+// SetCredential encrypts value and stores it as the credential name. It
+// fails with secrets.ErrNoKey when no encryption key is configured, so a
+// credential is never stored in plaintext.
+func SetCredential(ctx context.Context, q Querier, name, value string) (time.Time, error) {
+	sealed, err := secrets.Encrypt(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var updatedAt time.Time
+	err = q.QueryRow(ctx, `
+		INSERT INTO integration_credentials (name, value, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (name) DO UPDATE SET value = EXCLUDED.value, updated_at = NOW()
+		RETURNING updated_at
+	`, name, sealed).Scan(&updatedAt)
+	return updatedAt, err
+}
+
+// DeleteCredential removes the stored credential name, reporting whether it
+// was stored
+func DeleteCredential(ctx context.Context, q Querier, name string) (bool, error) {
+	var deleted string
+	err := q.QueryRow(ctx, `DELETE FROM integration_credentials WHERE name = $1 RETURNING name`, name).Scan(&deleted)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// CredentialUpdates returns when each stored credential was last set, by
+// name, without decrypting anything
+func CredentialUpdates(ctx context.Context, q Querier) (map[string]time.Time, error) {
+	rows, err := q.Query(ctx, `SELECT name, updated_at FROM integration_credentials`)
+	if err != nil {
+		return nil, err
+	}
+	updates := map[string]time.Time{}
+	var name string
+	var updatedAt time.Time
+	_, err = pgx.ForEachRow(rows, []any{&name, &updatedAt}, func() error {
+		updates[name] = updatedAt
+		return nil
+	})
+	return updates, err
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"flow-v1/backend/internal/secrets"
+)
+
+// testPool connects to TEST_DATABASE_URL, a database with the migrations
+// applied, skipping the test when it is unset
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatalf("connecting to TEST_DATABASE_URL: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// useKeys sets secrets.Keys to a keyring sealing with current for the test
+func useKeys(t *testing.T, current string) {
+	t.Helper()
+	keys, err := secrets.NewKeyring(current, map[string][]byte{current: bytes.Repeat([]byte(current[:1]), 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := secrets.Keys
+	secrets.Keys = keys
+	t.Cleanup(func() { secrets.Keys = previous })
+}
+
+// Credentials are encrypted in the table and read back in plaintext
+func TestCredentialRoundTrip(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	t.Cleanup(func() {
+		pool.Exec(context.Background(), `DELETE FROM integration_credentials WHERE name = $1`, CredentialGitHubWebhookSecret)
+	})
+
+	previous := secrets.Keys
+	secrets.Keys = nil
+	t.Cleanup(func() { secrets.Keys = previous })
+	if _, err := SetCredential(ctx, pool, CredentialGitHubWebhookSecret, "webhook secret"); !errors.Is(err, secrets.ErrNoKey) {
+		t.Fatalf("storing without a key: %v, want ErrNoKey", err)
+	}
+
+	useKeys(t, "k")
+	const secret = "3f9c1e7a2b"
+	if _, err := SetCredential(ctx, pool, CredentialGitHubWebhookSecret, secret); err != nil {
+		t.Fatalf("SetCredential: %v", err)
+	}
+	var raw string
+	if err := pool.QueryRow(ctx, `SELECT value FROM integration_credentials WHERE name = $1`, CredentialGitHubWebhookSecret).Scan(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(raw, "enc:k:") || strings.Contains(raw, secret) {
+		t.Errorf("stored %q, want it encrypted", raw)
+	}
+	if got, err := GetCredential(ctx, pool, CredentialGitHubWebhookSecret); err != nil || got != secret {
+		t.Errorf("GetCredential = %q, %v, want %q", got, err, secret)
+	}
+	if updates, err := CredentialUpdates(ctx, pool); err != nil || updates[CredentialGitHubWebhookSecret].IsZero() {
+		t.Errorf("CredentialUpdates = %v, %v, want the stored credential", updates, err)
+	}
+
+	if deleted, err := DeleteCredential(ctx, pool, CredentialGitHubWebhookSecret); !deleted || err != nil {
+		t.Errorf("DeleteCredential = %t, %v", deleted, err)
+	}
+	if got, err := GetCredential(ctx, pool, CredentialGitHubWebhookSecret); err != nil || got != "" {
+		t.Errorf("GetCredential after the delete = %q, %v, want nothing", got, err)
+	}
+	if deleted, err := DeleteCredential(ctx, pool, CredentialGitHubWebhookSecret); deleted || err != nil {
+		t.Errorf("deleting twice = %t, %v", deleted, err)
+	}
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidCursor is returned by DecodeTodoCursor for a cursor that is
+// malformed or was issued for a different sort
+var ErrInvalidCursor = errors.New("Invalid cursor. Pass back the next_cursor of the previous page with the same sort_by and order")
+
+// TodoCursor marks the last todo of a page by the values of its sort keys,
+// as text, and its id. The next page is the todos ordered after it, so
+// todos created or deleted in between do not shift the pages.
+type TodoCursor struct {
+	Values []*string
+	ID     int64
+}
+
+// todoCursorToken is the encoded form of a TodoCursor. Sort names the keys
+// the values belong to, so a cursor cannot be used with another sort.
+type todoCursorToken struct {
+	Sort   string    `json:"s"`
+	Values []*string `json:"v"`
+	ID     int64     `json:"id"`
+}
+
+// todoSortSignature names keys with their directions, e.g.
+// "priority:desc,created_at:desc,id:desc"
+func todoSortSignature(keys []orderedTodoKey) string {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.field + ":asc"
+		if key.desc {
+			names[i] = key.field + ":desc"
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// EncodeTodoCursor returns the opaque cursor clients pass back to get the
+// todos after cursor in sort
+func EncodeTodoCursor(sort []TodoSort, cursor TodoCursor) string {
+	data, _ := json.Marshal(todoCursorToken{Sort: todoSortSignature(orderTodoKeys(sort)), Values: cursor.Values, ID: cursor.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeTodoCursor reads a cursor made by EncodeTodoCursor for sort
+func DecodeTodoCursor(sort []TodoSort, encoded string) (TodoCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return TodoCursor{}, ErrInvalidCursor
+	}
+	var token todoCursorToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return TodoCursor{}, ErrInvalidCursor
+	}
+	keys := orderTodoKeys(sort)
+	if token.Sort != todoSortSignature(keys) || len(token.Values) != len(keys)-1 {
+		return TodoCursor{}, ErrInvalidCursor
+	}
+	for i, value := range token.Values {
+		if value == nil && !keys[i].nullable {
+			return TodoCursor{}, ErrInvalidCursor
+		}
+	}
+	return TodoCursor{Values: token.Values, ID: token.ID}, nil
+}
+
+// TodoCursorRow is a row of a query built with SelectCursor. Scanning it
+// fills the destinations as usual and keeps the sort key values that follow
+// them, for Cursor.
+type TodoCursorRow struct {
+	row    pgx.Row
+	values []*string
+}
+
+// NewTodoCursorRow wraps row, selected with params
+func NewTodoCursorRow(row pgx.Row, params ListTodosParams) *TodoCursorRow {
+	return &TodoCursorRow{row: row, values: make([]*string, len(orderTodoKeys(params.Sort))-1)}
+}
+
+// Scan scans the selected columns into dest and the sort key values into r
+func (r *TodoCursorRow) Scan(dest ...any) error {
+	for i := range r.values {
+		dest = append(dest, &r.values[i])
+	}
+	return r.row.Scan(dest...)
+}
+
+// Cursor returns the cursor of the scanned todo, whose id is id
+func (r *TodoCursorRow) Cursor(id int64) TodoCursor {
+	return TodoCursor{Values: slices.Clone(r.values), ID: id}
+}
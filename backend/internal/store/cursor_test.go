@@ -0,0 +1,85 @@
+package store
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestTodoCursorRoundTrip(t *testing.T) {
+	sort := []TodoSort{{Field: "priority"}, {Field: "due_date", Desc: true}}
+	cursor := TodoCursor{Values: []*string{stringPtr("300"), nil, stringPtr("2025-03-01 09:30:00.123456")}, ID: 42}
+
+	encoded := EncodeTodoCursor(sort, cursor)
+	if strings.ContainsAny(encoded, "+/=") {
+		t.Errorf("cursor %q is not URL safe", encoded)
+	}
+	decoded, err := DecodeTodoCursor(sort, encoded)
+	if err != nil {
+		t.Fatalf("DecodeTodoCursor: %v", err)
+	}
+	if decoded.ID != cursor.ID || !slices.EqualFunc(decoded.Values, cursor.Values, func(a, b *string) bool {
+		return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+	}) {
+		t.Errorf("decoded %+v, want %+v", decoded, cursor)
+	}
+}
+
+func TestDecodeTodoCursorRejects(t *testing.T) {
+	sort := []TodoSort{{Field: "title"}}
+	valid := EncodeTodoCursor(sort, TodoCursor{Values: []*string{stringPtr("a"), stringPtr("2025-03-01 09:30:00")}, ID: 1})
+	tests := []struct {
+		name    string
+		sort    []TodoSort
+		encoded string
+	}{
+		{"not base64", sort, "%%%"},
+		{"not JSON", sort, "bm90IGpzb24"},
+		{"other field", []TodoSort{{Field: "status"}}, valid},
+		{"other direction", []TodoSort{{Field: "title", Desc: true}}, valid},
+		{"extra key", []TodoSort{{Field: "title"}, {Field: "due_date"}}, valid},
+		{"missing value", sort, EncodeTodoCursor(sort, TodoCursor{Values: []*string{stringPtr("a")}, ID: 1})},
+		{"NULL title", sort, EncodeTodoCursor(sort, TodoCursor{Values: []*string{nil, stringPtr("2025-03-01 09:30:00")}, ID: 1})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeTodoCursor(tt.sort, tt.encoded); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeTodoCursor error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+// fakeRow scans values positionally into string destinations
+type fakeRow []*string
+
+func (r fakeRow) Scan(dest ...any) error {
+	for i, value := range r {
+		*dest[i].(**string) = value
+	}
+	return nil
+}
+
+func TestTodoCursorRow(t *testing.T) {
+	params := ListTodosParams{Sort: []TodoSort{{Field: "due_date"}}}
+	row := NewTodoCursorRow(fakeRow{stringPtr("Write docs"), nil, stringPtr("2025-03-01 09:30:00")}, params)
+	var title *string
+	if err := row.Scan(&title); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if title == nil || *title != "Write docs" {
+		t.Errorf("title = %v, want Write docs", title)
+	}
+	cursor := row.Cursor(7)
+	if cursor.ID != 7 || len(cursor.Values) != 2 || cursor.Values[0] != nil || *cursor.Values[1] != "2025-03-01 09:30:00" {
+		t.Errorf("Cursor(7) = %+v", cursor)
+	}
+	if _, err := DecodeTodoCursor(params.Sort, EncodeTodoCursor(params.Sort, cursor)); err != nil {
+		t.Errorf("cursor of a todo without a due date does not decode: %v", err)
+	}
+}
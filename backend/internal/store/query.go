@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Querier is implemented by both db.Pool and pgx.Tx
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// QueryBuilder assembles the WHERE, ORDER BY and LIMIT parts of a query while
+// numbering its positional arguments. Values only ever reach the SQL through
+// Arg, so conditions and order terms must be fixed strings.
+type QueryBuilder struct {
+	conditions []string
+	orderBy    []string
+	args       []interface{}
+	limit      int
+	offset     int
+}
+
+// Arg adds value as the next positional argument and returns its placeholder
+func (b *QueryBuilder) Arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// Where adds a condition; all conditions are combined with AND
+func (b *QueryBuilder) Where(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+// OrderBy appends order terms such as "created_at DESC"
+func (b *QueryBuilder) OrderBy(terms ...string) {
+	b.orderBy = append(b.orderBy, terms...)
+}
+
+// Page limits the result to limit rows after skipping offset; a limit of 0
+// returns every row
+func (b *QueryBuilder) Page(limit, offset int) {
+	b.limit, b.offset = limit, offset
+}
+
+// Build appends the clauses to base, which is the query up to and including
+// its FROM clause, and returns the SQL with its arguments
+func (b *QueryBuilder) Build(base string) (string, []interface{}) {
+	var sql strings.Builder
+	sql.WriteString(base)
+	if len(b.conditions) > 0 {
+		sql.WriteString("\nWHERE ")
+		sql.WriteString(strings.Join(b.conditions, " AND "))
+	}
+	if len(b.orderBy) > 0 {
+		sql.WriteString("\nORDER BY ")
+		sql.WriteString(strings.Join(b.orderBy, ", "))
+	}
+	if b.limit > 0 {
+		sql.WriteString("\nLIMIT " + b.Arg(b.limit))
+		if b.offset > 0 {
+			sql.WriteString(" OFFSET " + b.Arg(b.offset))
+		}
+	}
+	return sql.String(), b.args
+}
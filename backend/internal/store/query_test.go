@@ -0,0 +1,33 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	var b QueryBuilder
+	b.Where("a = " + b.Arg("x"))
+	b.Where("b IN (" + b.Arg(1) + ", " + b.Arg(2) + ")")
+	b.OrderBy("a ASC", "b DESC")
+	b.OrderBy("id")
+	b.Page(10, 20)
+	sql, args := b.Build("SELECT *\nFROM t")
+
+	want := "SELECT *\nFROM t\nWHERE a = $1 AND b IN ($2, $3)\nORDER BY a ASC, b DESC, id\nLIMIT $4 OFFSET $5"
+	if sql != want {
+		t.Errorf("sql =\n%s\nwant\n%s", sql, want)
+	}
+	if wantArgs := []interface{}{"x", 1, 2, 10, 20}; !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %#v, want %#v", args, wantArgs)
+	}
+}
+
+func TestQueryBuilderEmpty(t *testing.T) {
+	var b QueryBuilder
+	b.Page(0, 20)
+	sql, args := b.Build("SELECT 1")
+	if sql != "SELECT 1" || args != nil {
+		t.Errorf("Build = %q, %v, want the bare base", sql, args)
+	}
+}
@@ -0,0 +1,344 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PriorityKeySQL is a todo's effective priority key: its own, or the
+// default priority when it has none or its priority was deleted
+const PriorityKeySQL = `COALESCE(todos.priority_key, (SELECT key FROM priorities WHERE is_default))`
+
+// PriorityWeightSQL is the weight of a todo's effective priority
+const PriorityWeightSQL = `(SELECT weight FROM priorities WHERE key = ` + PriorityKeySQL + `)`
+
+// todoSortKey is an expression todos can be ordered by
+type todoSortKey struct {
+	expr string
+	// typ is the SQL type a cursor value of the key is cast back to
+	typ string
+	// nullable keys sort NULLS LAST in both directions, so unset values stay
+	// at the end
+	nullable bool
+	// reversed keys put the largest value first when sorted ascending
+	reversed bool
+}
+
+// todoSortKeys maps each sortable field to the expression it orders by.
+// Ascending priority puts the most urgent (highest weight) priority first.
+// Titles use the title_order collation, which ignores case and sorts
+// accented letters next to their base letter.
+var todoSortKeys = map[string]todoSortKey{
+	"due_date":     {expr: "due_date", typ: "timestamp", nullable: true},
+	"priority":     {expr: PriorityWeightSQL, typ: "integer", nullable: true, reversed: true},
+	"status":       {expr: "(SELECT position FROM statuses WHERE statuses.key = todos.status)", typ: "integer"},
+	"created_at":   {expr: "created_at", typ: "timestamp"},
+	"updated_at":   {expr: "updated_at", typ: "timestamp"},
+	"title":        {expr: "title COLLATE title_order", typ: "text"},
+	"story_points": {expr: "story_points", typ: "integer", nullable: true},
+	"estimate":     {expr: "estimate_minutes", typ: "integer", nullable: true},
+}
+
+// todoIDKey orders todos by id, which is unique and so ends every order
+var todoIDKey = todoSortKey{expr: "id", typ: "bigint"}
+
+// orderedTodoKey is a sort key with the direction it is ordered in
+type orderedTodoKey struct {
+	todoSortKey
+	field string
+	desc  bool
+}
+
+// term returns the ORDER BY term of the key
+func (k orderedTodoKey) term() string {
+	term := k.expr + " ASC"
+	if k.desc {
+		term = k.expr + " DESC"
+	}
+	if k.nullable {
+		term += " NULLS LAST"
+	}
+	return term
+}
+
+// orderTodoKeys returns the keys todos are ordered by for sort. Ties fall
+// back to newest first unless created_at was sorted on, and id always comes
+// last so the order is deterministic.
+func orderTodoKeys(sort []TodoSort) []orderedTodoKey {
+	if len(sort) == 0 {
+		sort = []TodoSort{{Field: "created_at", Desc: true}}
+	}
+	var keys []orderedTodoKey
+	for _, s := range sort {
+		key, ok := todoSortKeys[s.Field]
+		if !ok {
+			continue
+		}
+		keys = append(keys, orderedTodoKey{key, s.Field, s.Desc != key.reversed})
+		if s.Field == "created_at" {
+			// id follows creation order, so it breaks ties the same way
+			return append(keys, orderedTodoKey{todoIDKey, "id", s.Desc})
+		}
+	}
+	return append(keys,
+		orderedTodoKey{todoSortKeys["created_at"], "created_at", true},
+		orderedTodoKey{todoIDKey, "id", true})
+}
+
+// TodoSort is one sort key of a todo list
+type TodoSort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseTodoSort reads comma-separated sort_by fields and their matching
+// comma-separated orders. A field without its own order takes the last order
+// given, defaulting to descending. Unknown fields and orders are ignored, as
+// is a repeated field; without any known field todos sort by created_at.
+func ParseTodoSort(sortBy, order string) []TodoSort {
+	orders := strings.Split(order, ",")
+	var keys []TodoSort
+	seen := map[string]bool{}
+	desc := true
+	for i, field := range strings.Split(sortBy, ",") {
+		if i < len(orders) {
+			switch strings.ToLower(strings.TrimSpace(orders[i])) {
+			case "asc":
+				desc = false
+			case "desc":
+				desc = true
+			}
+		}
+		field = strings.TrimSpace(field)
+		if _, ok := todoSortKeys[field]; !ok || seen[field] {
+			continue
+		}
+		seen[field] = true
+		keys = append(keys, TodoSort{Field: field, Desc: desc})
+	}
+	if len(keys) == 0 {
+		keys = []TodoSort{{Field: "created_at", Desc: desc}}
+	}
+	return keys
+}
+
+// TodoSortFields returns the fields ParseTodoSort knows, sorted
+func TodoSortFields() []string {
+	fields := make([]string, 0, len(todoSortKeys))
+	for field := range todoSortKeys {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// DeferredSQL matches todos deferred until a date that has not arrived yet
+// in UTC. They are left out of the default views.
+const DeferredSQL = `(deferred_until IS NOT NULL AND deferred_until > (NOW() AT TIME ZONE 'UTC')::date)`
+
+// ListTodosParams are the filters, sort and page of a todo list. Nil filters
+// are not applied.
+type ListTodosParams struct {
+	Status         *string
+	StoryPointsMin *int
+	StoryPointsMax *int
+	// EstimateMin and EstimateMax bound the estimate in minutes; todos
+	// without an estimate never match them
+	EstimateMin *int
+	EstimateMax *int
+	// SprintID selects one sprint; NoSprint selects the backlog
+	SprintID *int64
+	NoSprint bool
+	// EpicID selects one epic; NoEpic selects todos outside any epic
+	EpicID *int64
+	NoEpic bool
+	// Deferred selects only deferred todos instead of leaving them out;
+	// AnyDeferred selects todos whether they are deferred or not
+	Deferred    bool
+	AnyDeferred bool
+	// CustomFields is a JSONB document the todo's custom fields must contain
+	CustomFields []byte
+	// Search is an ILIKE pattern matched against the todo's title and
+	// description when SearchTodos is set, and against its subtasks' titles
+	// when SearchSubtasks is set; a todo matching either way is selected once
+	Search         *string
+	SearchTodos    bool
+	SearchSubtasks bool
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	UpdatedAfter   *time.Time
+	UpdatedBefore  *time.Time
+	Sort           []TodoSort
+	Limit          int
+	Offset         int
+	// After selects the todos ordered after a cursor from DecodeTodoCursor
+	// for the same Sort
+	After *TodoCursor
+	// SelectCursor also selects what the cursor of each todo is built from
+	SelectCursor bool
+}
+
+// filterTodos adds the conditions selecting the todos that match params
+func filterTodos(b *QueryBuilder, params ListTodosParams) {
+	if params.Status != nil {
+		b.Where("status = " + b.Arg(*params.Status))
+	}
+	if params.StoryPointsMin != nil {
+		b.Where("story_points >= " + b.Arg(*params.StoryPointsMin))
+	}
+	if params.StoryPointsMax != nil {
+		b.Where("story_points <= " + b.Arg(*params.StoryPointsMax))
+	}
+	if params.EstimateMin != nil {
+		b.Where("estimate_minutes >= " + b.Arg(*params.EstimateMin))
+	}
+	if params.EstimateMax != nil {
+		b.Where("estimate_minutes <= " + b.Arg(*params.EstimateMax))
+	}
+	if params.NoSprint {
+		b.Where("sprint_id IS NULL")
+	} else if params.SprintID != nil {
+		b.Where("sprint_id = " + b.Arg(*params.SprintID))
+	}
+	if params.NoEpic {
+		b.Where("epic_id IS NULL")
+	} else if params.EpicID != nil {
+		b.Where("epic_id = " + b.Arg(*params.EpicID))
+	}
+	if params.Deferred {
+		b.Where(DeferredSQL)
+	} else if !params.AnyDeferred {
+		b.Where("NOT " + DeferredSQL)
+	}
+	if params.CustomFields != nil {
+		b.Where("custom_fields @> " + b.Arg(params.CustomFields) + "::jsonb")
+	}
+	if params.Search != nil {
+		pattern := b.Arg(*params.Search)
+		var matches []string
+		if params.SearchTodos {
+			matches = append(matches, "title ILIKE "+pattern, "description ILIKE "+pattern)
+		}
+		if params.SearchSubtasks {
+			matches = append(matches, "EXISTS (SELECT 1 FROM subtasks WHERE subtasks.todo_id = todos.id AND subtasks.title ILIKE "+pattern+")")
+		}
+		b.Where("(" + strings.Join(matches, " OR ") + ")")
+	}
+	// Lower bounds are inclusive and upper bounds exclusive
+	if params.CreatedAfter != nil {
+		b.Where("created_at >= " + b.Arg(*params.CreatedAfter))
+	}
+	if params.CreatedBefore != nil {
+		b.Where("created_at < " + b.Arg(*params.CreatedBefore))
+	}
+	if params.UpdatedAfter != nil {
+		b.Where("updated_at >= " + b.Arg(*params.UpdatedAfter))
+	}
+	if params.UpdatedBefore != nil {
+		b.Where("updated_at < " + b.Arg(*params.UpdatedBefore))
+	}
+}
+
+// BuildListTodos returns the query selecting columns from the todos that
+// match params, with its arguments. Ties in the sort fall back to newest
+// first unless created_at was sorted on, and id always comes last so the
+// order is deterministic. With SelectCursor the text values of the sort
+// keys follow columns, for TodoCursorRow to read; with After only the todos
+// ordered after that cursor are selected.
+func BuildListTodos(columns string, params ListTodosParams) (string, []interface{}) {
+	var b QueryBuilder
+	filterTodos(&b, params)
+
+	keys := orderTodoKeys(params.Sort)
+	if params.After != nil {
+		b.Where(seekTodos(&b, keys, *params.After))
+	}
+	for _, key := range keys {
+		b.OrderBy(key.term())
+	}
+	b.Page(params.Limit, params.Offset)
+
+	if params.SelectCursor {
+		for _, key := range keys[:len(keys)-1] {
+			columns += ", (" + key.expr + ")::text"
+		}
+	}
+	return b.Build("SELECT " + columns + "\nFROM todos")
+}
+
+// seekTodos returns the condition selecting the todos ordered after cursor.
+// When every key sorts the same way and none can be NULL this is a single
+// row comparison such as (created_at, id) < ($1::timestamp, $2::bigint),
+// which an index on the keys can answer. Otherwise it is spelled out key by
+// key: a todo comes after the cursor when it ties on the keys before one
+// and comes after it on that one, and NULLs come after every value.
+func seekTodos(b *QueryBuilder, keys []orderedTodoKey, cursor TodoCursor) string {
+	id := strconv.FormatInt(cursor.ID, 10)
+	values := append(slices.Clone(cursor.Values), &id)
+	placeholders := make([]string, len(keys))
+	for i, key := range keys {
+		if value := values[i]; value != nil {
+			placeholders[i] = b.Arg(*value) + "::" + key.typ
+		}
+	}
+
+	rowComparison := true
+	for _, key := range keys {
+		if key.nullable || key.desc != keys[0].desc {
+			rowComparison = false
+		}
+	}
+	if rowComparison {
+		exprs := make([]string, len(keys))
+		for i, key := range keys {
+			exprs[i] = key.expr
+		}
+		return "(" + strings.Join(exprs, ", ") + ") " + seekOperator(keys[0].desc) + " (" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	var after, ties []string
+	for i, key := range keys {
+		if placeholders[i] != "" {
+			condition := key.expr + " " + seekOperator(key.desc) + " " + placeholders[i]
+			if key.nullable {
+				condition = "(" + condition + " OR " + key.expr + " IS NULL)"
+			}
+			after = append(after, strings.Join(append(slices.Clone(ties), condition), " AND "))
+			ties = append(ties, key.expr+" = "+placeholders[i])
+		} else {
+			// Nothing sorts after NULL on this key
+			ties = append(ties, key.expr+" IS NULL")
+		}
+	}
+	return "((" + strings.Join(after, ") OR (") + "))"
+}
+
+// seekOperator compares a key to the cursor's value for rows after it
+func seekOperator(desc bool) string {
+	if desc {
+		return "<"
+	}
+	return ">"
+}
+
+// ListTodos runs the query built by BuildListTodos
+func ListTodos(ctx context.Context, q Querier, columns string, params ListTodosParams) (pgx.Rows, error) {
+	sql, args := BuildListTodos(columns, params)
+	return q.Query(ctx, sql, args...)
+}
+
+// CountTodos returns how many todos match params, ignoring its sort and page
+func CountTodos(ctx context.Context, q Querier, params ListTodosParams) (int64, error) {
+	var b QueryBuilder
+	filterTodos(&b, params)
+	sql, args := b.Build("SELECT COUNT(*)\nFROM todos")
+	var count int64
+	err := q.QueryRow(ctx, sql, args...).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,291 @@
+package store
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTodoSort(t *testing.T) {
+	tests := []struct {
+		sortBy, order string
+		want          []TodoSort
+	}{
+		{"", "", []TodoSort{{"created_at", true}}},
+		{"", "asc", []TodoSort{{"created_at", false}}},
+		{"priority", "", []TodoSort{{"priority", true}}},
+		{"priority,due_date", "desc,asc", []TodoSort{{"priority", true}, {"due_date", false}}},
+		{"priority,due_date,title", "asc", []TodoSort{{"priority", false}, {"due_date", false}, {"title", false}}},
+		{" priority , due_date ", "ASC, Desc", []TodoSort{{"priority", false}, {"due_date", true}}},
+		{"priority,bogus,due_date", "asc,desc,sideways", []TodoSort{{"priority", false}, {"due_date", true}}},
+		{"priority,priority", "asc,desc", []TodoSort{{"priority", false}}},
+		{"bogus", "asc", []TodoSort{{"created_at", false}}},
+	}
+	for _, tt := range tests {
+		if got := ParseTodoSort(tt.sortBy, tt.order); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTodoSort(%q, %q) = %v, want %v", tt.sortBy, tt.order, got, tt.want)
+		}
+	}
+}
+
+// orderBy returns the ORDER BY clause of sql
+func orderBy(t *testing.T, sql string) string {
+	t.Helper()
+	_, clause, ok := strings.Cut(sql, "\nORDER BY ")
+	if !ok {
+		t.Fatalf("no ORDER BY in %q", sql)
+	}
+	clause, _, _ = strings.Cut(clause, "\n")
+	return clause
+}
+
+func TestBuildListTodosOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		sort []TodoSort
+		want string
+	}{
+		{"default", nil, "created_at DESC, id DESC"},
+		{"created_at ascending", []TodoSort{{"created_at", false}}, "created_at ASC, id ASC"},
+		{"due date ascending", []TodoSort{{"due_date", false}}, "due_date ASC NULLS LAST, created_at DESC, id DESC"},
+		{"due date descending", []TodoSort{{"due_date", true}}, "due_date DESC NULLS LAST, created_at DESC, id DESC"},
+		{"priority ascending is most urgent first", []TodoSort{{"priority", false}}, PriorityWeightSQL + " DESC NULLS LAST, created_at DESC, id DESC"},
+		{"priority descending", []TodoSort{{"priority", true}}, PriorityWeightSQL + " ASC NULLS LAST, created_at DESC, id DESC"},
+		{"multi-key with nullable keys", []TodoSort{{"story_points", true}, {"estimate", false}},
+			"story_points DESC NULLS LAST, estimate_minutes ASC NULLS LAST, created_at DESC, id DESC"},
+		{"created_at ends the keys", []TodoSort{{"title", false}, {"created_at", false}, {"due_date", false}},
+			"title COLLATE title_order ASC, created_at ASC, id ASC"},
+		{"status", []TodoSort{{"status", false}}, "(SELECT position FROM statuses WHERE statuses.key = todos.status) ASC, created_at DESC, id DESC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _ := BuildListTodos("id", ListTodosParams{Sort: tt.sort})
+			if got := orderBy(t, sql); got != tt.want {
+				t.Errorf("ORDER BY %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildListTodosSelectCursor(t *testing.T) {
+	sql, _ := BuildListTodos("id, title", ListTodosParams{Sort: []TodoSort{{"due_date", false}}, SelectCursor: true, Limit: 10})
+	want := "SELECT id, title, (due_date)::text, (created_at)::text\nFROM todos\n"
+	if !strings.HasPrefix(sql, want) {
+		t.Errorf("sql = %q, want it to start with %q", sql, want)
+	}
+}
+
+func TestBuildListTodosSeek(t *testing.T) {
+	tests := []struct {
+		name      string
+		sort      []TodoSort
+		values    []*string
+		condition string
+		args      []interface{}
+	}{
+		{
+			"default sort compares rows",
+			nil,
+			[]*string{stringPtr("2025-03-01 09:30:00")},
+			"(created_at, id) < ($2::timestamp, $3::bigint)",
+			[]interface{}{"2025-03-01 09:30:00", "42"},
+		},
+		{
+			"ascending title compares rows",
+			[]TodoSort{{"title", false}, {"created_at", false}},
+			[]*string{stringPtr("b"), stringPtr("2025-03-01 09:30:00")},
+			"(title COLLATE title_order, created_at, id) > ($2::text, $3::timestamp, $4::bigint)",
+			[]interface{}{"b", "2025-03-01 09:30:00", "42"},
+		},
+		{
+			"mixed directions are spelled out",
+			[]TodoSort{{"title", false}},
+			[]*string{stringPtr("b"), stringPtr("2025-03-01 09:30:00")},
+			"((title COLLATE title_order > $2::text) OR (title COLLATE title_order = $2::text AND created_at < $3::timestamp) OR " +
+				"(title COLLATE title_order = $2::text AND created_at = $3::timestamp AND id < $4::bigint))",
+			[]interface{}{"b", "2025-03-01 09:30:00", "42"},
+		},
+		{
+			"NULLS LAST follow every due date",
+			[]TodoSort{{"due_date", false}},
+			[]*string{stringPtr("2025-03-09 00:00:00"), stringPtr("2025-03-01 09:30:00")},
+			"(((due_date > $2::timestamp OR due_date IS NULL)) OR (due_date = $2::timestamp AND created_at < $3::timestamp) OR " +
+				"(due_date = $2::timestamp AND created_at = $3::timestamp AND id < $4::bigint))",
+			[]interface{}{"2025-03-09 00:00:00", "2025-03-01 09:30:00", "42"},
+		},
+		{
+			"nothing follows NULL but later NULLs",
+			[]TodoSort{{"due_date", true}},
+			[]*string{nil, stringPtr("2025-03-01 09:30:00")},
+			"((due_date IS NULL AND created_at < $2::timestamp) OR (due_date IS NULL AND created_at = $2::timestamp AND id < $3::bigint))",
+			[]interface{}{"2025-03-01 09:30:00", "42"},
+		},
+		{
+			"ascending priority seeks to lower weights",
+			[]TodoSort{{"priority", false}, {"created_at", true}},
+			[]*string{stringPtr("300"), stringPtr("2025-03-01 09:30:00")},
+			"(((" + PriorityWeightSQL + " < $2::integer OR " + PriorityWeightSQL + " IS NULL)) OR (" +
+				PriorityWeightSQL + " = $2::integer AND created_at < $3::timestamp) OR (" +
+				PriorityWeightSQL + " = $2::integer AND created_at = $3::timestamp AND id < $4::bigint))",
+			[]interface{}{"300", "2025-03-01 09:30:00", "42"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := "todo"
+			params := ListTodosParams{Status: &status, AnyDeferred: true, Sort: tt.sort, Limit: 10, After: &TodoCursor{Values: tt.values, ID: 42}}
+			sql, args := BuildListTodos("id", params)
+			want := "SELECT id\nFROM todos\nWHERE status = $1 AND " + tt.condition + "\nORDER BY "
+			if !strings.HasPrefix(sql, want) {
+				t.Errorf("sql =\n%s\nwant it to start with\n%s", sql, want)
+			}
+			wantArgs := append(append([]interface{}{"todo"}, tt.args...), 10)
+			if !reflect.DeepEqual(args, wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, wantArgs)
+			}
+		})
+	}
+}
+
+// The cursor never affects the total
+func TestCountTodosIgnoresCursor(t *testing.T) {
+	var b QueryBuilder
+	filterTodos(&b, ListTodosParams{AnyDeferred: true, After: &TodoCursor{Values: []*string{stringPtr("x")}, ID: 1}})
+	sql, args := b.Build("SELECT COUNT(*)\nFROM todos")
+	if sql != "SELECT COUNT(*)\nFROM todos" || len(args) != 0 {
+		t.Errorf("count query = %q %v", sql, args)
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func int64Ptr(n int64) *int64 {
+	return &n
+}
+
+func TestBuildListTodos(t *testing.T) {
+	created := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	updated := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		params ListTodosParams
+		sql    string
+		args   []interface{}
+	}{
+		{
+			"no filters leaves deferred todos out",
+			ListTodosParams{},
+			"SELECT id\nFROM todos\nWHERE NOT " + DeferredSQL + "\nORDER BY created_at DESC, id DESC",
+			nil,
+		},
+		{
+			"every filter in argument order",
+			ListTodosParams{
+				Status:         stringPtr("todo"),
+				StoryPointsMin: intPtr(1),
+				StoryPointsMax: intPtr(8),
+				EstimateMin:    intPtr(30),
+				EstimateMax:    intPtr(240),
+				SprintID:       int64Ptr(3),
+				EpicID:         int64Ptr(4),
+				Deferred:       true,
+				CustomFields:   []byte(`{"env":"prod"}`),
+				Search:         stringPtr("%docs%"),
+				SearchTodos:    true,
+				SearchSubtasks: true,
+				CreatedAfter:   &created,
+				CreatedBefore:  &updated,
+				UpdatedAfter:   &created,
+				UpdatedBefore:  &updated,
+				Sort:           []TodoSort{{"due_date", false}},
+				Limit:          50,
+				Offset:         100,
+			},
+			"SELECT id\nFROM todos\nWHERE status = $1 AND story_points >= $2 AND story_points <= $3 AND estimate_minutes >= $4 AND estimate_minutes <= $5" +
+				" AND sprint_id = $6 AND epic_id = $7 AND " + DeferredSQL + " AND custom_fields @> $8::jsonb" +
+				" AND (title ILIKE $9 OR description ILIKE $9 OR EXISTS (SELECT 1 FROM subtasks WHERE subtasks.todo_id = todos.id AND subtasks.title ILIKE $9))" +
+				" AND created_at >= $10 AND created_at < $11 AND updated_at >= $12 AND updated_at < $13" +
+				"\nORDER BY due_date ASC NULLS LAST, created_at DESC, id DESC\nLIMIT $14 OFFSET $15",
+			[]interface{}{"todo", 1, 8, 30, 240, int64(3), int64(4), []byte(`{"env":"prod"}`), "%docs%", created, updated, created, updated, 50, 100},
+		},
+		{
+			"backlog outside any epic, searching subtasks only",
+			ListTodosParams{NoSprint: true, SprintID: int64Ptr(3), NoEpic: true, AnyDeferred: true, Search: stringPtr("%x%"), SearchSubtasks: true, Limit: 10},
+			"SELECT id\nFROM todos\nWHERE sprint_id IS NULL AND epic_id IS NULL" +
+				" AND (EXISTS (SELECT 1 FROM subtasks WHERE subtasks.todo_id = todos.id AND subtasks.title ILIKE $1))" +
+				"\nORDER BY created_at DESC, id DESC\nLIMIT $2",
+			[]interface{}{"%x%", 10},
+		},
+		{
+			"offset without limit is ignored",
+			ListTodosParams{AnyDeferred: true, Offset: 20},
+			"SELECT id\nFROM todos\nORDER BY created_at DESC, id DESC",
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := BuildListTodos("id", tt.params)
+			if sql != tt.sql {
+				t.Errorf("sql =\n%s\nwant\n%s", sql, tt.sql)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+// FuzzBuildListTodos checks that client input only ever reaches the query as
+// arguments: whatever the values, the SQL is the same as for harmless ones,
+// and the sort it is ordered by only holds whitelisted fields.
+func FuzzBuildListTodos(f *testing.F) {
+	f.Add("todo", "%docs%", "priority,due_date", "asc,desc", `{"env":"prod"}`, "2025-03-01 09:30:00")
+	f.Add("'; DROP TABLE todos; --", "%' OR 1=1 --%", "title); DELETE FROM todos; --", "asc; --", `{"a":"') OR TRUE --"}`, "') OR TRUE --")
+	f.Add("$1", "$2::text", "id DESC, $3", "desc,asc,desc", "null", "")
+	f.Add("in progress", "\x00\n\t", "created_at,created_at", "", "{}", "2025-03-01")
+	f.Fuzz(func(t *testing.T, status, search, sortBy, order, customFields, cursorValue string) {
+		sort := ParseTodoSort(sortBy, order)
+		build := func(status, search, customFields, cursorValue string) (string, []interface{}) {
+			keys := orderTodoKeys(sort)
+			values := make([]*string, len(keys)-1)
+			for i := range values {
+				values[i] = &cursorValue
+			}
+			return BuildListTodos("id", ListTodosParams{
+				Status:         &status,
+				Search:         &search,
+				SearchTodos:    true,
+				SearchSubtasks: true,
+				CustomFields:   []byte(customFields),
+				Sort:           sort,
+				Limit:          10,
+				After:          &TodoCursor{Values: values, ID: 1},
+			})
+		}
+		sql, args := build(status, search, customFields, cursorValue)
+		harmless, harmlessArgs := build("s", "q", "{}", "v")
+		if sql != harmless {
+			t.Fatalf("input changed the SQL:\n%s\nwant\n%s", sql, harmless)
+		}
+		if len(args) != len(harmlessArgs) {
+			t.Fatalf("%d args, want %d", len(args), len(harmlessArgs))
+		}
+		// The sort is made of whitelisted fields only, so spelling it out
+		// again gives the same sort and the same SQL
+		var fields, orders []string
+		for _, key := range sort {
+			if _, ok := todoSortKeys[key.Field]; !ok {
+				t.Fatalf("unknown sort field %q was parsed", key.Field)
+			}
+			fields = append(fields, key.Field)
+			orders = append(orders, map[bool]string{false: "asc", true: "desc"}[key.Desc])
+		}
+		if again := ParseTodoSort(strings.Join(fields, ","), strings.Join(orders, ",")); !reflect.DeepEqual(again, sort) {
+			t.Fatalf("sort %v parsed again as %v", sort, again)
+		}
+	})
+}
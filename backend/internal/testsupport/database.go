@@ -0,0 +1,62 @@
+// Package testsupport is what tests build their data and requests with:
+// factories that insert todos through a store.Querier, a Server that
+// answers requests with any http.Handler, and golden files for response
+// bodies.
+//
+// It imports neither handlers nor router, so tests inside the handlers
+// package can use it too. Tests that go through every route pass
+// router.New(router.Options{}) to NewServer from an external test package.
+package testsupport
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"flow-v1/backend/internal/db"
+	"flow-v1/backend/internal/store"
+)
+
+// UseDatabase points db.Pool at TEST_DATABASE_URL, a database with the
+// migrations applied, for the test, which is skipped when it is unset. The
+// pool is returned for factories and queries of the test's own.
+func UseDatabase(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatalf("connecting to TEST_DATABASE_URL: %v", err)
+	}
+	previous := db.Pool
+	db.Pool = pool
+	t.Cleanup(func() {
+		db.Pool = previous
+		pool.Close()
+	})
+	return pool
+}
+
+// SideEffects sums up what a change to todos leaves behind, including the
+// todo's own row, so a test can compare it before and after a request that
+// must not change anything
+func SideEffects(t testing.TB, q store.Querier, id int64) string {
+	t.Helper()
+	var counts string
+	err := q.QueryRow(context.Background(), `
+		SELECT concat_ws(' ',
+			(SELECT COUNT(*) FROM audit_log),
+			(SELECT COUNT(*) FROM todo_events),
+			(SELECT COUNT(*) FROM todo_revisions),
+			(SELECT version FROM board_version),
+			(SELECT row_to_json(todos)::text FROM todos WHERE id = $1))
+	`, id).Scan(&counts)
+	if err != nil {
+		t.Fatalf("counting side effects: %v", err)
+	}
+	return counts
+}
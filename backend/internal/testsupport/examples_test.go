@@ -0,0 +1,84 @@
+package testsupport_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/router"
+	"flow-v1/backend/internal/testsupport"
+)
+
+// These tests are the examples of how a feature's tests use testsupport.
+// Those that need a database skip without TEST_DATABASE_URL, like every
+// other test that does.
+
+// A handler test creates the rows it needs with a factory, which deletes
+// them when the test ends, and sends its requests through the router
+func TestExampleTodoFactory(t *testing.T) {
+	pool := testsupport.UseDatabase(t)
+	ctx := context.Background()
+	todo := testsupport.NewTodoFactory(t, pool).
+		WithTitle("Ship the release").
+		WithStatus("done").
+		WithSubtasks(3).
+		WithCompletedSubtasks(1).
+		Create(ctx)
+
+	server := testsupport.NewServer(t, router.New(router.Options{}))
+	var got models.Todo
+	server.JSON(http.MethodGet, router.BasePath+"/todos/"+strconv.FormatInt(todo.ID, 10), nil, http.StatusOK, &got)
+
+	if got.Title != "Ship the release" || got.Status != "done" || got.CompletedAt == nil {
+		t.Errorf("got %+v, want the done todo", got)
+	}
+	if got.SubtaskProgress != "1/3" || got.LastSubtaskCompletedAt == nil {
+		t.Errorf("subtask progress %q, last completed %v, want 1/3 with a completion", got.SubtaskProgress, got.LastSubtaskCompletedAt)
+	}
+}
+
+// Admin routes need Admin, and a body worth keeping whole goes in a golden
+// file: go test ./internal/testsupport -update rewrites
+// testdata/integration_health.golden after an intended change
+func TestExampleAdminGolden(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	server := testsupport.NewServer(t, router.New(router.Options{}))
+	path := router.BasePath + "/admin/integrations/health"
+
+	if w := server.Get(path); w.Code != http.StatusUnauthorized {
+		t.Errorf("without the admin token: %d, want 401", w.Code)
+	}
+	w := server.Admin().Get(path)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	testsupport.AssertGolden(t, "integration_health", w.Body.Bytes())
+}
+
+// Normalize is what AssertGolden compares: ids are numbered per key and
+// timestamps replaced, so golden files hold what a response means
+func ExampleNormalize() {
+	body, _ := testsupport.Normalize([]byte(`[
+		{"id": 41, "todo_id": 7, "title": "Write docs", "created_at": "2026-10-16T17:40:33.123Z"},
+		{"id": 42, "todo_id": 7, "title": "Review docs", "created_at": "2026-10-16T17:40:34Z"}
+	]`))
+	fmt.Print(string(body))
+	// Output:
+	// [
+	//   {
+	//     "created_at": "<timestamp>",
+	//     "id": "<id 1>",
+	//     "title": "Write docs",
+	//     "todo_id": "<todo_id 1>"
+	//   },
+	//   {
+	//     "created_at": "<timestamp>",
+	//     "id": "<id 2>",
+	//     "title": "Review docs",
+	//     "todo_id": "<todo_id 1>"
+	//   }
+	// ]
+}
@@ -0,0 +1,137 @@
+package testsupport
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"flow-v1/backend/internal/models"
+	"flow-v1/backend/internal/store"
+)
+
+// TodoFactory inserts todos for a test and deletes them when it ends. The
+// With methods set what the todo is created with; everything else is left
+// to the column defaults, as a todo created without it would be:
+//
+//	todo := testsupport.NewTodoFactory(t, pool).WithStatus("done").WithSubtasks(3).Create(ctx)
+type TodoFactory struct {
+	t                 testing.TB
+	q                 store.Querier
+	title             string
+	description       string
+	status            string
+	customFields      map[string]interface{}
+	subtasks          int
+	completedSubtasks int
+}
+
+// NewTodoFactory returns a factory inserting through q, a pool or a
+// transaction
+func NewTodoFactory(t testing.TB, q store.Querier) *TodoFactory {
+	return &TodoFactory{t: t, q: q, title: "Test todo"}
+}
+
+// WithTitle sets the title, "Test todo" by default
+func (f *TodoFactory) WithTitle(title string) *TodoFactory {
+	f.title = title
+	return f
+}
+
+// WithDescription sets the description
+func (f *TodoFactory) WithDescription(description string) *TodoFactory {
+	f.description = description
+	return f
+}
+
+// WithStatus sets the status key. A done status sets completed_at too, as
+// moving the todo there would.
+func (f *TodoFactory) WithStatus(status string) *TodoFactory {
+	f.status = status
+	return f
+}
+
+// WithCustomFields sets the custom field values as stored, without checking
+// them against the field definitions
+func (f *TodoFactory) WithCustomFields(fields map[string]interface{}) *TodoFactory {
+	f.customFields = fields
+	return f
+}
+
+// WithSubtasks adds n open subtasks, titled "Subtask 1" onwards
+func (f *TodoFactory) WithSubtasks(n int) *TodoFactory {
+	f.subtasks = n
+	return f
+}
+
+// WithCompletedSubtasks completes the first n subtasks, adding subtasks
+// when there are fewer
+func (f *TodoFactory) WithCompletedSubtasks(n int) *TodoFactory {
+	f.completedSubtasks = n
+	if f.subtasks < n {
+		f.subtasks = n
+	}
+	return f
+}
+
+// Create inserts the todo and its subtasks, failing the test if it cannot,
+// and returns it with its subtasks. The todo's subtask counts are kept in
+// step as the handlers keep them.
+func (f *TodoFactory) Create(ctx context.Context) models.Todo {
+	f.t.Helper()
+	columns, values := "title, description", "$1, $2"
+	args := []interface{}{f.title, f.description}
+	if f.status != "" {
+		args = append(args, f.status)
+		n := strconv.Itoa(len(args))
+		columns += ", status, completed_at"
+		values += ", $" + n + ", CASE WHEN $" + n + " IN (SELECT key FROM statuses WHERE is_done) THEN NOW() END"
+	}
+	if f.customFields != nil {
+		args = append(args, f.customFields)
+		columns += ", custom_fields"
+		values += ", $" + strconv.Itoa(len(args))
+	}
+
+	var todo models.Todo
+	err := f.q.QueryRow(ctx, `
+		INSERT INTO todos (`+columns+`) VALUES (`+values+`)
+		RETURNING id, title, COALESCE(description, ''), status, completed_at, created_at, updated_at
+	`, args...).Scan(&todo.ID, &todo.Title, &todo.Description, &todo.Status, &todo.CompletedAt, &todo.CreatedAt, &todo.UpdatedAt)
+	if err != nil {
+		f.t.Fatalf("creating a todo: %v", err)
+	}
+	f.t.Cleanup(func() {
+		// The subtasks go with it. A todo the test deleted or a transaction
+		// already ended leave nothing to do, so the error is dropped.
+		var id int64
+		f.q.QueryRow(context.Background(), `DELETE FROM todos WHERE id = $1 RETURNING id`, todo.ID).Scan(&id)
+	})
+
+	for i := range f.subtasks {
+		completed := i < f.completedSubtasks
+		var subtask models.Subtask
+		err := f.q.QueryRow(ctx, `
+			INSERT INTO subtasks (todo_id, title, completed, completed_at)
+			VALUES ($1, $2, $3, CASE WHEN $3 THEN NOW() END)
+			RETURNING id, todo_id, title, completed, completed_at, created_at, updated_at
+		`, todo.ID, "Subtask "+strconv.Itoa(i+1), completed).Scan(
+			&subtask.ID, &subtask.TodoID, &subtask.Title, &subtask.Completed, &subtask.CompletedAt, &subtask.CreatedAt, &subtask.UpdatedAt)
+		if err != nil {
+			f.t.Fatalf("creating subtask %d: %v", i+1, err)
+		}
+		todo.Subtasks = append(todo.Subtasks, subtask)
+	}
+	if f.subtasks > 0 {
+		completed := min(f.completedSubtasks, f.subtasks)
+		err := f.q.QueryRow(ctx, `
+			UPDATE todos SET subtask_count = $2, subtask_completed_count = $3
+			WHERE id = $1
+			RETURNING updated_at
+		`, todo.ID, f.subtasks, completed).Scan(&todo.UpdatedAt)
+		if err != nil {
+			f.t.Fatalf("counting the subtasks: %v", err)
+		}
+		todo.SubtaskProgress = strconv.Itoa(completed) + "/" + strconv.Itoa(f.subtasks)
+	}
+	return todo
+}
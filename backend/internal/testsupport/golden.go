@@ -0,0 +1,123 @@
+package testsupport
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// update rewrites the golden files with the bodies the tests get, for
+// go test ./... -update
+var update = flag.Bool("update", false, "rewrite the golden files under testdata with the responses the tests get")
+
+// AssertGolden compares a JSON response body, normalized, with
+// testdata/<name>.golden in the test's package, and rewrites the file
+// instead when the tests run with -update
+func AssertGolden(t testing.TB, name string, body []byte) {
+	t.Helper()
+	got, err := Normalize(body)
+	if err != nil {
+		t.Fatalf("normalizing %s: %v", body, err)
+	}
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading the golden file: %v (run the test with -update to write it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("the body does not match %s (run the test with -update if the change is intended):\ngot\n%s\nwant\n%s", path, got, want)
+	}
+}
+
+// Normalize returns a JSON body indented, with object keys sorted, and with
+// what changes from run to run replaced: timestamps by "<timestamp>" and the
+// values of id, *_id and *_ids by "<key N>". Ids are numbered per key in
+// order of appearance, so the same todo_id gets the same placeholder
+// wherever it appears.
+func Normalize(body []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	n := normalizer{ids: map[string]string{}, seen: map[string]int{}}
+	value = n.value("", value)
+
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// normalizer numbers the ids it meets, by key and value
+type normalizer struct {
+	ids  map[string]string
+	seen map[string]int
+}
+
+// isIDKey reports whether values under key are ids
+func isIDKey(key string) bool {
+	return key == "id" || strings.HasSuffix(key, "_id") || strings.HasSuffix(key, "_ids")
+}
+
+// value returns value normalized; key is the object key it is under
+func (n normalizer) value(key string, value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		// Sorted, so ids are numbered in the order they are written in
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			value[k] = n.value(k, value[k])
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = n.value(key, item)
+		}
+		return value
+	case json.Number:
+		if !isIDKey(key) {
+			return value
+		}
+		name := strings.TrimSuffix(key, "s")
+		id, ok := n.ids[name+" "+value.String()]
+		if !ok {
+			n.seen[name]++
+			id = "<" + name + " " + strconv.Itoa(n.seen[name]) + ">"
+			n.ids[name+" "+value.String()] = id
+		}
+		return id
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return "<timestamp>"
+		}
+		return value
+	default:
+		return value
+	}
+}
@@ -0,0 +1,56 @@
+package testsupport
+
+import (
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"scalars", `"x"`, "\"x\"\n"},
+		{"timestamps", `{"created_at":"2026-10-16T17:40:33.123456Z","due_date":"2030-01-02","note":"2026"}`,
+			"{\n  \"created_at\": \"<timestamp>\",\n  \"due_date\": \"2030-01-02\",\n  \"note\": \"2026\"\n}\n"},
+		{"ids by key", `[{"id":7,"todo_id":7,"points":7},{"id":9,"todo_id":7},{"todo_ids":[8,7]}]`,
+			`[
+  {
+    "id": "<id 1>",
+    "points": 7,
+    "todo_id": "<todo_id 1>"
+  },
+  {
+    "id": "<id 2>",
+    "todo_id": "<todo_id 1>"
+  },
+  {
+    "todo_ids": [
+      "<todo_id 2>",
+      "<todo_id 1>"
+    ]
+  }
+]
+`},
+		{"no escaping", `{"title":"<b> & </b>"}`, "{\n  \"title\": \"<b> & </b>\"\n}\n"},
+		{"large numbers", `{"total":12345678901234567890}`, "{\n  \"total\": 12345678901234567890\n}\n"},
+	}
+	for _, tt := range tests {
+		got, err := Normalize([]byte(tt.body))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: Normalize(%s) =\n%s\nwant\n%s", tt.name, tt.body, got, tt.want)
+		}
+	}
+	if _, err := Normalize([]byte(`{"id":`)); err == nil {
+		t.Error("Normalize accepted a truncated body")
+	}
+}
+
+// Bodies that differ only in ids and timestamps match the same golden file
+func TestAssertGolden(t *testing.T) {
+	AssertGolden(t, "normalize", []byte(`{"id":3,"title":"Write docs","updated_at":"2026-10-16T17:40:33Z"}`))
+	AssertGolden(t, "normalize", []byte(`{"updated_at":"2030-01-02T00:00:00.5+02:00","title":"Write docs","id":41}`))
+}
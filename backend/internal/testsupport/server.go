@@ -0,0 +1,87 @@
+package testsupport
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// AdminToken is the ADMIN_TOKEN a Server sets for its test
+const AdminToken = "testsupport-admin-token"
+
+// Server sends requests to a handler, usually router.New(router.Options{}),
+// and records the responses
+type Server struct {
+	t       testing.TB
+	handler http.Handler
+	admin   bool
+}
+
+// NewServer returns a server for handler. It sets ADMIN_TOKEN to AdminToken
+// for the test, so admin routes are enabled for requests sent with Admin.
+func NewServer(t testing.TB, handler http.Handler) *Server {
+	t.Setenv("ADMIN_TOKEN", AdminToken)
+	return &Server{t: t, handler: handler}
+}
+
+// Admin returns a copy of the server that sends AdminToken as a bearer token
+func (s *Server) Admin() *Server {
+	admin := *s
+	admin.admin = true
+	return &admin
+}
+
+// Do sends a request and returns the recorded response. A body other than
+// nil is sent as JSON: a string or []byte as it is, anything else marshalled.
+func (s *Server) Do(method, path string, body interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var reader io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(body)
+	case []byte:
+		reader = bytes.NewReader(body)
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("encoding the body of %s %s: %v", method, path, err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.admin {
+		req.Header.Set("Authorization", "Bearer "+AdminToken)
+	}
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, req)
+	return w
+}
+
+// Get sends a GET request
+func (s *Server) Get(path string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	return s.Do(http.MethodGet, path, nil)
+}
+
+// JSON sends a request, fails the test unless it is answered with status,
+// and decodes the response body into out unless out is nil
+func (s *Server) JSON(method, path string, body interface{}, status int, out interface{}) {
+	s.t.Helper()
+	w := s.Do(method, path, body)
+	if w.Code != status {
+		s.t.Fatalf("%s %s: status %d, want %d: %s", method, path, w.Code, status, w.Body)
+	}
+	if out == nil {
+		return
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+		s.t.Fatalf("%s %s: decoding %s: %v", method, path, w.Body, err)
+	}
+}
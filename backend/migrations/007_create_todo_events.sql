@@ -0,0 +1,16 @@
+-- Create todo_events table backing the per-todo activity timeline
+CREATE TABLE IF NOT EXISTS todo_events (
+    id BIGSERIAL PRIMARY KEY,
+    todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    actor VARCHAR(255),
+    field VARCHAR(50),
+    old_value TEXT,
+    new_value TEXT,
+    subtask_id INTEGER,
+    subtask_title VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create index for paginated timeline reads
+CREATE INDEX IF NOT EXISTS idx_todo_events_todo_id_created_at ON todo_events(todo_id, created_at DESC);
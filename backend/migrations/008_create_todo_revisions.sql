@@ -0,0 +1,10 @@
+-- Create todo_revisions table holding the row state before each update
+CREATE TABLE IF NOT EXISTS todo_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    snapshot JSONB NOT NULL,
+    actor VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (todo_id, version)
+);
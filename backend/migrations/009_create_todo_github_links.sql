@@ -0,0 +1,15 @@
+-- Create todo_github_links table linking a todo to a GitHub issue
+CREATE TABLE IF NOT EXISTS todo_github_links (
+    todo_id INTEGER PRIMARY KEY REFERENCES todos(id) ON DELETE CASCADE,
+    owner VARCHAR(100) NOT NULL,
+    repo VARCHAR(100) NOT NULL,
+    issue_number INTEGER NOT NULL,
+    sync_status BOOLEAN NOT NULL DEFAULT FALSE,
+    issue_title TEXT,
+    issue_state VARCHAR(20),
+    synced_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create index for resolving webhook deliveries to linked todos
+CREATE INDEX IF NOT EXISTS idx_todo_github_links_issue ON todo_github_links(LOWER(owner), LOWER(repo), issue_number);
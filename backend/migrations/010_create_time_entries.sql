@@ -0,0 +1,18 @@
+-- Create time_entries table for timers and manual time tracking
+CREATE TABLE IF NOT EXISTS time_entries (
+    id SERIAL PRIMARY KEY,
+    todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP,
+    note TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_time_entry_range CHECK (ended_at IS NULL OR ended_at > started_at)
+);
+
+-- Create indexes for per-todo listing and range reports
+CREATE INDEX IF NOT EXISTS idx_time_entries_todo_id ON time_entries(todo_id);
+CREATE INDEX IF NOT EXISTS idx_time_entries_started_at ON time_entries(started_at);
+
+-- Only one timer may be running at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_time_entries_running ON time_entries((ended_at IS NULL)) WHERE ended_at IS NULL;
@@ -0,0 +1,28 @@
+-- Create sprints table for time-boxed groupings of todos
+CREATE TABLE IF NOT EXISTS sprints (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    capacity INTEGER,
+    state VARCHAR(20) NOT NULL DEFAULT 'planned',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_sprint_state CHECK (state IN ('planned', 'active', 'closed')),
+    CONSTRAINT check_sprint_range CHECK (end_date >= start_date),
+    CONSTRAINT check_sprint_capacity CHECK (capacity IS NULL OR capacity >= 0)
+);
+
+-- Only one sprint may be active at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sprints_active ON sprints(state) WHERE state = 'active';
+
+-- Add sprint membership and completion time to todos
+ALTER TABLE todos
+ADD COLUMN IF NOT EXISTS sprint_id INTEGER REFERENCES sprints(id) ON DELETE SET NULL,
+ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP;
+
+-- Best guess for todos that were already done before completed_at existed
+UPDATE todos SET completed_at = updated_at WHERE status = 'done' AND completed_at IS NULL;
+
+-- Create index for sprint filtering performance
+CREATE INDEX IF NOT EXISTS idx_todos_sprint_id ON todos(sprint_id);
@@ -0,0 +1,18 @@
+-- Create epics table for grouping related todos
+CREATE TABLE IF NOT EXISTS epics (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    color VARCHAR(7) NOT NULL DEFAULT '#64748b',
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_epic_status CHECK (status IN ('open', 'closed'))
+);
+
+-- Add epic membership to todos; an epic cannot be deleted while todos reference it
+ALTER TABLE todos
+ADD COLUMN IF NOT EXISTS epic_id INTEGER REFERENCES epics(id) ON DELETE RESTRICT;
+
+-- Create index for epic filtering and rollups
+CREATE INDEX IF NOT EXISTS idx_todos_epic_id ON todos(epic_id);
@@ -0,0 +1,33 @@
+-- Create statuses table for configurable board columns
+CREATE TABLE IF NOT EXISTS statuses (
+    key VARCHAR(20) PRIMARY KEY,
+    label VARCHAR(50) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#64748b',
+    position INTEGER NOT NULL,
+    is_done BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Seed the statuses that used to be hardcoded
+INSERT INTO statuses (key, label, color, position, is_done) VALUES
+    ('todo', 'To Do', '#94a3b8', 1, FALSE),
+    ('in_progress', 'In Progress', '#3b82f6', 2, FALSE),
+    ('done', 'Done', '#22c55e', 3, TRUE)
+ON CONFLICT (key) DO NOTHING;
+
+-- Replace the fixed check constraints with a reference to the statuses table
+ALTER TABLE todos DROP CONSTRAINT IF EXISTS check_status_valid;
+ALTER TABLE todos DROP CONSTRAINT IF EXISTS check_status_values;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint
+        WHERE conname = 'fk_todos_status'
+    ) THEN
+        ALTER TABLE todos
+        ADD CONSTRAINT fk_todos_status
+        FOREIGN KEY (status) REFERENCES statuses(key);
+    END IF;
+END $$;
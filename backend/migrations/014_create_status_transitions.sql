@@ -0,0 +1,9 @@
+-- Create status_transitions table listing the allowed from -> to status moves.
+-- A status with no rows as from_status may move to any status.
+CREATE TABLE IF NOT EXISTS status_transitions (
+    from_status VARCHAR(20) NOT NULL REFERENCES statuses(key) ON DELETE CASCADE,
+    to_status VARCHAR(20) NOT NULL REFERENCES statuses(key) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (from_status, to_status),
+    CONSTRAINT check_transition_distinct CHECK (from_status <> to_status)
+);
@@ -0,0 +1,18 @@
+-- Create custom_field_definitions table describing the extra fields todos may carry
+CREATE TABLE IF NOT EXISTS custom_field_definitions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    type VARCHAR(20) NOT NULL,
+    options TEXT[] NOT NULL DEFAULT '{}',
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_custom_field_type CHECK (type IN ('text', 'number', 'date', 'select'))
+);
+
+-- Add custom field values to todos
+ALTER TABLE todos
+ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '{}';
+
+-- Create GIN index for custom field containment filters
+CREATE INDEX IF NOT EXISTS idx_todos_custom_fields ON todos USING GIN (custom_fields jsonb_path_ops);
@@ -0,0 +1,19 @@
+-- Revert 016_create_priorities.sql: restore the priority label column from
+-- priority_key, then drop the priorities table. Todos without a priority key
+-- get Medium, the old column's default. Tables referencing priorities, from
+-- 017_create_escalation_settings.sql and 035_create_note_rules.sql, must be
+-- dropped first.
+ALTER TABLE todos
+ADD COLUMN IF NOT EXISTS priority VARCHAR(10) NOT NULL DEFAULT 'Medium';
+
+UPDATE todos
+SET priority = COALESCE(
+    (SELECT LEFT(label, 10) FROM priorities WHERE key = todos.priority_key),
+    'Medium'
+);
+
+CREATE INDEX IF NOT EXISTS idx_todos_priority ON todos(priority);
+
+DROP INDEX IF EXISTS idx_todos_priority_key;
+ALTER TABLE todos DROP COLUMN IF EXISTS priority_key;
+DROP TABLE IF EXISTS priorities;
@@ -0,0 +1,60 @@
+-- Create priorities table for configurable, ordered priorities
+CREATE TABLE IF NOT EXISTS priorities (
+    key VARCHAR(20) PRIMARY KEY,
+    label VARCHAR(50) NOT NULL,
+    weight INTEGER NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#64748b',
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Only one priority may be the default
+CREATE UNIQUE INDEX IF NOT EXISTS idx_priorities_default ON priorities(is_default) WHERE is_default;
+
+-- Seed the priorities that used to be hardcoded; higher weight is more urgent
+INSERT INTO priorities (key, label, weight, color, is_default) VALUES
+    ('high', 'High', 300, '#ef4444', FALSE),
+    ('medium', 'Medium', 200, '#f59e0b', TRUE),
+    ('low', 'Low', 100, '#64748b', FALSE)
+ON CONFLICT (key) DO NOTHING;
+
+-- Reference priorities by key; todos whose priority is deleted fall back to the default
+ALTER TABLE todos
+ADD COLUMN IF NOT EXISTS priority_key VARCHAR(20) REFERENCES priorities(key) ON DELETE SET NULL;
+
+-- Convert existing rows from the old label column, matching keys and labels
+-- ignoring case. Values matching no priority get the default one, and how
+-- many did is reported, so dropping the column loses nothing silently.
+DO $$
+DECLARE
+    unmatched INTEGER;
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'todos' AND column_name = 'priority'
+    ) THEN
+        UPDATE todos
+        SET priority_key = (
+            SELECT key FROM priorities
+            WHERE key = LOWER(TRIM(todos.priority)) OR LOWER(label) = LOWER(TRIM(todos.priority))
+            ORDER BY key = LOWER(TRIM(todos.priority)) DESC
+            LIMIT 1
+        )
+        WHERE priority_key IS NULL;
+
+        UPDATE todos
+        SET priority_key = (SELECT key FROM priorities WHERE is_default)
+        WHERE priority_key IS NULL AND priority IS NOT NULL;
+        GET DIAGNOSTICS unmatched = ROW_COUNT;
+        IF unmatched > 0 THEN
+            RAISE NOTICE '% todos had a priority matching no priority and were given the default priority', unmatched;
+        END IF;
+    END IF;
+END $$;
+
+DROP INDEX IF EXISTS idx_todos_priority;
+ALTER TABLE todos DROP COLUMN IF EXISTS priority;
+
+-- Create index for priority filtering and sorting
+CREATE INDEX IF NOT EXISTS idx_todos_priority_key ON todos(priority_key);
@@ -0,0 +1,20 @@
+-- Create escalation_settings table holding the single priority escalation rule
+CREATE TABLE IF NOT EXISTS escalation_settings (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    due_within_hours INTEGER NOT NULL DEFAULT 24 CHECK (due_within_hours > 0),
+    target_priority_key VARCHAR(20) REFERENCES priorities(key) ON DELETE SET NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Escalation is opt-in; seed the disabled default rule
+INSERT INTO escalation_settings (id, target_priority_key)
+SELECT TRUE, key FROM priorities WHERE key = 'high'
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO escalation_settings (id) VALUES (TRUE)
+ON CONFLICT (id) DO NOTHING;
+
+-- A todo is escalated at most once; a later manual priority change sticks
+ALTER TABLE todos
+ADD COLUMN IF NOT EXISTS escalated_at TIMESTAMP;
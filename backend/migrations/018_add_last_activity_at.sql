@@ -0,0 +1,15 @@
+-- Track the latest activity on a todo, including its subtasks, so stale
+-- todos can be found without aggregating child tables
+ALTER TABLE todos
+ADD COLUMN IF NOT EXISTS last_activity_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+-- Backfill from the todo itself, its subtasks and its timeline
+UPDATE todos
+SET last_activity_at = GREATEST(
+    todos.updated_at,
+    (SELECT MAX(updated_at) FROM subtasks WHERE subtasks.todo_id = todos.id),
+    (SELECT MAX(created_at) FROM todo_events WHERE todo_events.todo_id = todos.id AND actor IS DISTINCT FROM 'system')
+);
+
+-- Create index for stale todo lookups
+CREATE INDEX IF NOT EXISTS idx_todos_last_activity_at ON todos(last_activity_at);
@@ -0,0 +1,5 @@
+-- Enable trigram matching for duplicate detection on todo titles
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Create index for title similarity lookups
+CREATE INDEX IF NOT EXISTS idx_todos_title_trgm ON todos USING GIN (title gin_trgm_ops);
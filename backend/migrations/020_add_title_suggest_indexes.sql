@@ -0,0 +1,6 @@
+-- Create indexes for title prefix matching in suggestions
+CREATE INDEX IF NOT EXISTS idx_todos_title_prefix ON todos(LOWER(title) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_epics_title_prefix ON epics(LOWER(title) text_pattern_ops);
+
+-- Create index for epic title trigram matching; todos already have one
+CREATE INDEX IF NOT EXISTS idx_epics_title_trgm ON epics USING GIN (title gin_trgm_ops);
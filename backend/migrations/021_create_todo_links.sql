@@ -0,0 +1,14 @@
+-- Create todo_links table for URL attachments on todos
+CREATE TABLE IF NOT EXISTS todo_links (
+    id BIGSERIAL PRIMARY KEY,
+    todo_id BIGINT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    title VARCHAR(300),
+    favicon_url TEXT,
+    metadata_status VARCHAR(10) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_metadata_status CHECK (metadata_status IN ('pending', 'fetched', 'failed', 'skipped'))
+);
+
+-- Create index for listing a todo's links
+CREATE INDEX IF NOT EXISTS idx_todo_links_todo_id ON todo_links(todo_id);
@@ -0,0 +1,7 @@
+-- Create user_preferences table; preferences holds only the values a user
+-- changed, defaults are applied by the API
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_key VARCHAR(100) PRIMARY KEY,
+    preferences JSONB NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
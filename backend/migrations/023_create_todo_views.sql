@@ -0,0 +1,10 @@
+-- Create todo_views table holding when each user last viewed each todo
+CREATE TABLE IF NOT EXISTS todo_views (
+    user_key VARCHAR(100) NOT NULL,
+    todo_id BIGINT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    viewed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_key, todo_id)
+);
+
+-- Create index for listing a user's recently viewed todos
+CREATE INDEX IF NOT EXISTS idx_todo_views_user_viewed_at ON todo_views(user_key, viewed_at DESC);
@@ -0,0 +1,5 @@
+-- All-day due dates are stored as midnight in the zone they were set in,
+-- which is kept so the date can be shown back unchanged
+ALTER TABLE todos
+ADD COLUMN IF NOT EXISTS all_day BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS due_timezone VARCHAR(64);
@@ -0,0 +1,6 @@
+-- Create a collation that compares case-insensitively and orders accented
+-- letters next to their base letter, for sorting todos by title
+CREATE COLLATION IF NOT EXISTS title_order (provider = icu, locale = 'und-u-ks-level2', deterministic = false);
+
+-- Create index for updated range filters
+CREATE INDEX IF NOT EXISTS idx_todos_updated_at ON todos(updated_at DESC);
@@ -0,0 +1,13 @@
+-- Create maintenance_settings table holding the single maintenance mode flag,
+-- shared by every API instance
+CREATE TABLE IF NOT EXISTS maintenance_settings (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    read_only BOOLEAN NOT NULL DEFAULT TRUE,
+    message TEXT NOT NULL DEFAULT 'The service is undergoing maintenance. Please try again shortly.',
+    retry_after_seconds INTEGER NOT NULL DEFAULT 300 CHECK (retry_after_seconds > 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO maintenance_settings (id) VALUES (TRUE)
+ON CONFLICT (id) DO NOTHING;
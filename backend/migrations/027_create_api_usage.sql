@@ -0,0 +1,14 @@
+-- Create api_usage table holding daily request and byte totals per
+-- workspace and API token, written in batches by the usage flusher
+CREATE TABLE IF NOT EXISTS api_usage (
+    day DATE NOT NULL,
+    workspace VARCHAR(100) NOT NULL,
+    token VARCHAR(16) NOT NULL DEFAULT '',
+    requests BIGINT NOT NULL DEFAULT 0,
+    request_bytes BIGINT NOT NULL DEFAULT 0,
+    response_bytes BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, workspace, token)
+);
+
+-- Create index for summing a workspace's usage over a range of days
+CREATE INDEX IF NOT EXISTS idx_api_usage_workspace_day ON api_usage(workspace, day);
@@ -0,0 +1,9 @@
+-- Create todo_tombstones table recording when each todo was deleted, so
+-- clients polling for changes also see deletions
+CREATE TABLE IF NOT EXISTS todo_tombstones (
+    todo_id BIGINT PRIMARY KEY,
+    deleted_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create index for counting deletions since a point in time
+CREATE INDEX IF NOT EXISTS idx_todo_tombstones_deleted_at ON todo_tombstones(deleted_at DESC);
@@ -0,0 +1,9 @@
+-- Create checklist_templates table holding named, ordered lists of subtask
+-- titles that can be applied to any todo
+CREATE TABLE IF NOT EXISTS checklist_templates (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    items TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
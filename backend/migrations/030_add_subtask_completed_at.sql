@@ -0,0 +1,15 @@
+-- Record when a subtask was completed; cleared again when it is reopened
+ALTER TABLE subtasks
+ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP;
+
+-- Backfill from the latest completion event, falling back to the last update
+UPDATE subtasks
+SET completed_at = COALESCE(
+    (SELECT MAX(created_at) FROM todo_events
+     WHERE todo_events.subtask_id = subtasks.id AND todo_events.type = 'subtask_completed'),
+    subtasks.updated_at
+)
+WHERE completed AND completed_at IS NULL;
+
+-- Create index for finding a todo's latest completed subtask
+CREATE INDEX IF NOT EXISTS idx_subtasks_todo_id_completed_at ON subtasks(todo_id, completed_at);
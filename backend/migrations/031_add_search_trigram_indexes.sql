@@ -0,0 +1,4 @@
+-- Create trigram indexes so substring search on todos can match
+-- descriptions and subtask titles without scanning; titles already have one
+CREATE INDEX IF NOT EXISTS idx_todos_description_trgm ON todos USING GIN (description gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_subtasks_title_trgm ON subtasks USING GIN (title gin_trgm_ops);
@@ -0,0 +1,16 @@
+-- Keep each todo's subtask totals on the todo itself so lists can show
+-- progress without aggregating subtasks; every subtask write adjusts them
+ALTER TABLE todos
+ADD COLUMN IF NOT EXISTS subtask_count INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS subtask_completed_count INTEGER NOT NULL DEFAULT 0;
+
+-- Backfill from the subtasks table
+UPDATE todos
+SET subtask_count = counts.total,
+    subtask_completed_count = counts.completed
+FROM (
+    SELECT todo_id, COUNT(*) AS total, COUNT(*) FILTER (WHERE completed) AS completed
+    FROM subtasks
+    GROUP BY todo_id
+) counts
+WHERE counts.todo_id = todos.id;
@@ -0,0 +1,12 @@
+-- Create todo_locks table holding advisory editing locks. A lock lapses at
+-- expires_at unless its holder sends a heartbeat; lapsed rows are purged by
+-- the edit_locks job.
+CREATE TABLE IF NOT EXISTS todo_locks (
+    todo_id INTEGER PRIMARY KEY REFERENCES todos(id) ON DELETE CASCADE,
+    holder VARCHAR(255) NOT NULL,
+    acquired_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- Create index for purging lapsed locks
+CREATE INDEX IF NOT EXISTS idx_todo_locks_expires_at ON todo_locks(expires_at);
@@ -0,0 +1,10 @@
+-- Create embeds table holding read-only todo feeds shared with another site.
+-- Each row is the filter set and allowed origin behind a signed token;
+-- deleting the row revokes the token.
+CREATE TABLE IF NOT EXISTS embeds (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    origin VARCHAR(255) NOT NULL,
+    filters JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
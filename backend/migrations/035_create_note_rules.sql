@@ -0,0 +1,18 @@
+-- Create note rule tables listing the status and priority changes that must
+-- come with a note. A NULL from or to side matches any value, so a rule from
+-- done with no target covers every move out of done.
+CREATE TABLE IF NOT EXISTS status_note_rules (
+    id SERIAL PRIMARY KEY,
+    from_status VARCHAR(20) REFERENCES statuses(key) ON DELETE CASCADE,
+    to_status VARCHAR(20) REFERENCES statuses(key) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_status_note_rule_side CHECK (from_status IS NOT NULL OR to_status IS NOT NULL)
+);
+
+CREATE TABLE IF NOT EXISTS priority_note_rules (
+    id SERIAL PRIMARY KEY,
+    from_priority VARCHAR(20) REFERENCES priorities(key) ON DELETE CASCADE,
+    to_priority VARCHAR(20) REFERENCES priorities(key) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_priority_note_rule_side CHECK (from_priority IS NOT NULL OR to_priority IS NOT NULL)
+);
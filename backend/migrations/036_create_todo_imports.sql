@@ -0,0 +1,10 @@
+-- Create todo_imports table recording which todo each imported todo export
+-- became, so importing the same export again returns that todo
+CREATE TABLE IF NOT EXISTS todo_imports (
+    export_id VARCHAR(64) PRIMARY KEY,
+    todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    imported_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create index for the cascade when a todo is deleted
+CREATE INDEX IF NOT EXISTS idx_todo_imports_todo_id ON todo_imports(todo_id);
@@ -0,0 +1,19 @@
+-- Create todo_references table holding the #<id> references in each todo's
+-- description, so a todo's backlinks can be listed. referenced_id has no
+-- foreign key: a reference may name a todo that does not exist (yet).
+CREATE TABLE IF NOT EXISTS todo_references (
+    todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    referenced_id BIGINT NOT NULL,
+    PRIMARY KEY (todo_id, referenced_id)
+);
+
+-- Create index for listing the todos referencing a todo
+CREATE INDEX IF NOT EXISTS idx_todo_references_referenced_id ON todo_references(referenced_id);
+
+-- Backfill from existing descriptions. Unlike the API, this does not skip
+-- references inside code, which are picked out again on the next edit.
+INSERT INTO todo_references (todo_id, referenced_id)
+SELECT DISTINCT t.id, m[1]::bigint
+FROM todos t, regexp_matches(t.description, '(?:^|[^[:alnum:]_&])#([0-9]{1,18})(?![[:alnum:]_])', 'g') AS m
+WHERE t.description IS NOT NULL AND m[1]::bigint <> t.id
+ON CONFLICT DO NOTHING;
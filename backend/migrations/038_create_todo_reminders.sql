@@ -0,0 +1,13 @@
+-- Create todo_reminders table holding reminders relative to a todo's due
+-- date, so moving the due date moves them. fired_for is the due date a
+-- reminder last fired for; a different due date arms it again.
+CREATE TABLE IF NOT EXISTS todo_reminders (
+    id SERIAL PRIMARY KEY,
+    todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    reminder_offset VARCHAR(40) NOT NULL,
+    offset_seconds INTEGER NOT NULL,
+    fired_for TIMESTAMP,
+    fired_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (todo_id, offset_seconds)
+);
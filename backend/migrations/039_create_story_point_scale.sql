@@ -0,0 +1,12 @@
+-- Create story_point_scale table holding the single set of allowed story
+-- point values
+CREATE TABLE IF NOT EXISTS story_point_scale (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    point_values INTEGER[] NOT NULL DEFAULT '{1,2,3,5,8}'
+        CHECK (cardinality(point_values) BETWEEN 1 AND 20 AND 0 < ALL (point_values)),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Seed the Fibonacci scale the API used to hardcode
+INSERT INTO story_point_scale (id) VALUES (TRUE)
+ON CONFLICT (id) DO NOTHING;
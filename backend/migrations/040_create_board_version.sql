@@ -0,0 +1,27 @@
+-- Renumber board columns so no two statuses share a position, keeping the
+-- current order and breaking ties by key
+UPDATE statuses
+SET position = ordered.position
+FROM (SELECT key, ROW_NUMBER() OVER (ORDER BY position, key) AS position FROM statuses) ordered
+WHERE statuses.key = ordered.key AND statuses.position <> ordered.position;
+
+-- Positions are unique once a transaction commits; checking at commit lets
+-- one transaction shift columns past each other
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'statuses_position_unique') THEN
+        ALTER TABLE statuses
+        ADD CONSTRAINT statuses_position_unique UNIQUE (position) DEFERRABLE INITIALLY DEFERRED;
+    END IF;
+END $$;
+
+-- Create board_version holding a counter bumped by every change to the
+-- board columns, so clients can tell their copy is stale
+CREATE TABLE IF NOT EXISTS board_version (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    version BIGINT NOT NULL DEFAULT 1,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO board_version (id) VALUES (TRUE)
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,6 @@
+-- A todo deferred until a date is hidden from the default views until that
+-- date (UTC) arrives
+ALTER TABLE todos
+ADD COLUMN IF NOT EXISTS deferred_until DATE;
+
+CREATE INDEX IF NOT EXISTS idx_todos_deferred_until ON todos(deferred_until) WHERE deferred_until IS NOT NULL;
@@ -0,0 +1,4 @@
+-- Create indexes for sprint name prefix and trigram matching in search, as
+-- todo and epic titles already have
+CREATE INDEX IF NOT EXISTS idx_sprints_name_prefix ON sprints(LOWER(name) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_sprints_name_trgm ON sprints USING GIN (name gin_trgm_ops);
@@ -0,0 +1,9 @@
+-- Effort estimate of a todo in minutes, next to its story points
+ALTER TABLE todos
+ADD COLUMN IF NOT EXISTS estimate_minutes INTEGER;
+
+ALTER TABLE todos DROP CONSTRAINT IF EXISTS todos_estimate_minutes_check;
+ALTER TABLE todos
+ADD CONSTRAINT todos_estimate_minutes_check CHECK (estimate_minutes > 0 AND estimate_minutes <= 43200);
+
+CREATE INDEX IF NOT EXISTS idx_todos_estimate_minutes ON todos(estimate_minutes) WHERE estimate_minutes IS NOT NULL;
@@ -0,0 +1,15 @@
+-- Create todo_defaults table holding the single set of values new todos get
+-- for fields their create request leaves out. Status and priority are not
+-- foreign keys: a deleted one is skipped with a warning when a todo is
+-- created, rather than blocking the delete.
+CREATE TABLE IF NOT EXISTS todo_defaults (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    status TEXT,
+    priority_key TEXT,
+    story_points INTEGER CHECK (story_points > 0),
+    estimate_minutes INTEGER CHECK (estimate_minutes > 0 AND estimate_minutes <= 43200),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO todo_defaults (id) VALUES (TRUE)
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,26 @@
+-- Create export_jobs table for asynchronous todo exports. The finished file
+-- is kept as a large object until expires_at, after which the retention job
+-- unlinks it. The unique index on running jobs lets only one export run at a
+-- time; others wait queued.
+CREATE TABLE IF NOT EXISTS export_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    format TEXT NOT NULL CHECK (format IN ('json', 'csv', 'zip')),
+    filters JSONB NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'completed', 'failed', 'expired')),
+    rows_total BIGINT,
+    rows_processed BIGINT NOT NULL DEFAULT 0,
+    artifact_oid OID,
+    artifact_bytes BIGINT,
+    error TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    requested_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP,
+    heartbeat_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    expires_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_queued ON export_jobs (created_at, id) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS idx_export_jobs_expires_at ON export_jobs (expires_at) WHERE status = 'completed';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_export_jobs_one_running ON export_jobs ((TRUE)) WHERE status = 'running';
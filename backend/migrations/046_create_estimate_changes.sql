@@ -0,0 +1,15 @@
+-- Create estimate_changes table recording every change of a todo's story
+-- points, from updates and imports, so estimate drift can be reported
+CREATE TABLE IF NOT EXISTS estimate_changes (
+    id BIGSERIAL PRIMARY KEY,
+    todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    old_points INTEGER,
+    new_points INTEGER,
+    source TEXT NOT NULL CHECK (source IN ('update', 'import')),
+    actor TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (old_points IS DISTINCT FROM new_points)
+);
+
+CREATE INDEX IF NOT EXISTS idx_estimate_changes_todo_id ON estimate_changes(todo_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_estimate_changes_created_at ON estimate_changes(created_at);
@@ -0,0 +1,11 @@
+-- Create todo_policy table holding the single row of editing policies.
+-- lock_done_todos rejects edits to the title, description, story points and
+-- subtasks of done todos until they are reopened.
+CREATE TABLE IF NOT EXISTS todo_policy (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    lock_done_todos BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO todo_policy (id) VALUES (TRUE)
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,11 @@
+-- Create the table of integration credentials set through the admin API, by
+-- name: the GitHub token and webhook secret. The environment variable of
+-- the same name overrides a stored value. The application encrypts every
+-- value before storing it (internal/secrets), so the column holds
+-- enc:<key ID>:<ciphertext>. It is listed in secrets.Columns, so make
+-- rotate-keys re-encrypts it.
+CREATE TABLE IF NOT EXISTS integration_credentials (
+    name VARCHAR(50) PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
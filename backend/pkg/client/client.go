@@ -0,0 +1,173 @@
+// Package client is a Go client for the Flow REST API. It sends and returns
+// the same request and response types the server uses.
+//
+//	c := client.New("http://localhost:8080/api/v1", os.Getenv("FLOWCTL_TOKEN"))
+//	todos, err := c.ListTodos(ctx, client.ListOptions{Status: "in_progress"})
+//	if errors.Is(err, client.ErrNotFound) { ... }
+//
+// Idempotent requests are retried when the server answers 429 or 5xx or
+// cannot be reached.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the API of a server running locally with default settings
+const DefaultBaseURL = "http://localhost:8080/api/v1"
+
+// maxRetryDelay caps the wait between retries, including one asked for with
+// Retry-After
+const maxRetryDelay = 30 * time.Second
+
+// Client calls the API at BaseURL. Its methods are safe for concurrent use.
+type Client struct {
+	// BaseURL is the API root including the version prefix, e.g. DefaultBaseURL
+	BaseURL string
+	// Token, when set, is sent as a bearer token with every request
+	Token string
+	// HTTPClient sends the requests
+	HTTPClient *http.Client
+	// MaxRetries is how often an idempotent request is retried; 0 disables
+	// retries
+	MaxRetries int
+	// RetryDelay is the wait before the first retry; it doubles for each
+	// further retry unless the server sends Retry-After
+	RetryDelay time.Duration
+}
+
+// New returns a client for the API at baseURL that authenticates with token,
+// which may be empty
+func New(baseURL, token string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		MaxRetries: 3,
+		RetryDelay: 250 * time.Millisecond,
+	}
+}
+
+// response is the part of a successful response callers may need besides
+// the body
+type response struct {
+	header http.Header
+}
+
+// do sends a request to path, relative to BaseURL, with body encoded as JSON
+// and decodes a successful response into out. Error statuses return an
+// *APIError or *ValidationError.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) (response, error) {
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return response{}, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	retries := 0
+	if idempotent(method) {
+		retries = c.MaxRetries
+	}
+	delay := c.RetryDelay
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, target, data)
+		retry := attempt < retries && ctx.Err() == nil &&
+			(err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError)
+		if !retry {
+			if err != nil {
+				return response{}, err
+			}
+			defer resp.Body.Close()
+			return response{header: resp.Header}, decodeResponse(resp, out)
+		}
+
+		wait := delay
+		if err == nil {
+			if seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil && seconds >= 0 {
+				wait = time.Duration(seconds) * time.Second
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if wait > maxRetryDelay {
+			wait = maxRetryDelay
+		}
+		select {
+		case <-ctx.Done():
+			return response{}, ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// send makes one attempt at a request
+func (c *Client) send(ctx context.Context, method, target string, data []byte) (*http.Response, error) {
+	var reader io.Reader
+	if data != nil {
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return c.HTTPClient.Do(req)
+}
+
+// decodeResponse decodes a successful response into out or turns an error
+// response into a typed error
+func decodeResponse(resp *http.Response, out interface{}) error {
+	if resp.StatusCode >= http.StatusBadRequest {
+		var envelope struct {
+			Error     string     `json:"error"`
+			Details   string     `json:"details"`
+			DeletedAt *time.Time `json:"deleted_at"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if json.Unmarshal(data, &envelope) != nil {
+			envelope.Error = strings.TrimSpace(string(data))
+		}
+		return newAPIError(resp.StatusCode, envelope.Error, envelope.Details, envelope.DeletedAt)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// idempotent reports whether repeating a request with method has the same
+// effect as sending it once
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
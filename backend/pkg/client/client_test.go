@@ -0,0 +1,289 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"flow-v1/backend/internal/router"
+	"flow-v1/backend/internal/testsupport"
+	"flow-v1/backend/pkg/client"
+)
+
+// newClient returns a client for a test server running handler, retrying
+// without waiting
+func newClient(t *testing.T, handler http.HandlerFunc) *client.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c := client.New(server.URL+"/api/v1/", "secret-token")
+	c.RetryDelay = time.Millisecond
+	return c
+}
+
+// writeJSON answers with status and value encoded as JSON
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// Each method sends its request to the server's route with the token and a
+// JSON body, and decodes the server's models from the response
+func TestClientRequests(t *testing.T) {
+	type request struct {
+		method, path, query, body string
+	}
+	var got request
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer secret-token" {
+			t.Errorf("%s %s: Authorization %q", r.Method, r.URL.Path, auth)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if len(body) > 0 && r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("%s %s: Content-Type %q", r.Method, r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		got = request{r.Method, r.URL.Path, r.URL.RawQuery, string(body)}
+		switch {
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/api/v1/todos" && r.Method == http.MethodGet:
+			w.Header().Set("X-Total-Count", "7")
+			writeJSON(w, http.StatusOK, []client.Todo{{ID: 1, Title: "First"}, {ID: 2, Title: "Second"}})
+		case r.URL.Path == "/api/v1/todos/3/subtasks" && r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, []client.Subtask{{ID: 9, TodoID: 3, Title: "Step"}})
+		case r.URL.Path == "/api/v1/todos/3/subtasks" || r.URL.Path == "/api/v1/todos/3/subtasks/9":
+			writeJSON(w, http.StatusOK, client.Subtask{ID: 9, TodoID: 3, Title: "Step", Completed: r.Method == http.MethodPut})
+		default:
+			writeJSON(w, http.StatusOK, client.Todo{ID: 3, Title: "Third", Status: "todo"})
+		}
+	})
+	ctx := context.Background()
+
+	todos, total, err := c.ListTodosPage(ctx, client.ListOptions{Status: "todo", SortBy: "priority", Limit: 2, Offset: 4})
+	if err != nil || len(todos) != 2 || todos[1].Title != "Second" || total != 7 {
+		t.Errorf("ListTodosPage = %+v, %d, %v", todos, total, err)
+	}
+	if want := (request{http.MethodGet, "/api/v1/todos", "limit=2&offset=4&sort_by=priority&status=todo", ""}); got != want {
+		t.Errorf("ListTodosPage sent %+v, want %+v", got, want)
+	}
+
+	calls := []struct {
+		name string
+		call func() (interface{}, error)
+		want request
+	}{
+		{"GetTodo", func() (interface{}, error) { return c.GetTodo(ctx, 3) },
+			request{http.MethodGet, "/api/v1/todos/3", "", ""}},
+		{"CreateTodo", func() (interface{}, error) {
+			return c.CreateTodo(ctx, client.CreateTodoRequest{Title: "Third"})
+		}, request{http.MethodPost, "/api/v1/todos", "", `{"title":"Third","description":""}`}},
+		{"UpdateTodo", func() (interface{}, error) {
+			return c.UpdateTodo(ctx, 3, client.UpdateTodoRequest{Title: "Third", Status: "done"})
+		}, request{http.MethodPut, "/api/v1/todos/3", "", `{"title":"Third","description":"","status":"done"}`}},
+		{"DeleteTodo", func() (interface{}, error) { return nil, c.DeleteTodo(ctx, 3) },
+			request{http.MethodDelete, "/api/v1/todos/3", "", ""}},
+		{"ListSubtasks", func() (interface{}, error) { return c.ListSubtasks(ctx, 3) },
+			request{http.MethodGet, "/api/v1/todos/3/subtasks", "", ""}},
+		{"CreateSubtask", func() (interface{}, error) {
+			return c.CreateSubtask(ctx, 3, client.CreateSubtaskRequest{Title: "Step"})
+		}, request{http.MethodPost, "/api/v1/todos/3/subtasks", "", `{"title":"Step"}`}},
+		{"UpdateSubtask", func() (interface{}, error) {
+			return c.UpdateSubtask(ctx, 3, 9, client.UpdateSubtaskRequest{Completed: true})
+		}, request{http.MethodPut, "/api/v1/todos/3/subtasks/9", "", `{"title":"","completed":true}`}},
+		{"DeleteSubtask", func() (interface{}, error) { return nil, c.DeleteSubtask(ctx, 3, 9) },
+			request{http.MethodDelete, "/api/v1/todos/3/subtasks/9", "", ""}},
+	}
+	for _, tt := range calls {
+		result, err := tt.call()
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s sent %+v, want %+v", tt.name, got, tt.want)
+		}
+		switch result := result.(type) {
+		case client.Todo:
+			if result.ID != 3 || result.Title != "Third" {
+				t.Errorf("%s = %+v, want todo 3", tt.name, result)
+			}
+		case client.Subtask:
+			if result.ID != 9 || result.TodoID != 3 {
+				t.Errorf("%s = %+v, want subtask 9", tt.name, result)
+			}
+		}
+	}
+}
+
+// Error responses become typed errors
+func TestClientErrors(t *testing.T) {
+	deletedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/todos/404":
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "Todo not found"})
+		case "/api/v1/todos/410":
+			writeJSON(w, http.StatusGone, map[string]interface{}{"error": "Todo was deleted", "deleted_at": deletedAt})
+		case "/api/v1/todos/500":
+			http.Error(w, "upstream exploded", http.StatusInternalServerError)
+		default:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Key: 'CreateTodoRequest.Title' Error:Field validation for 'Title' failed on the 'required' tag\n" +
+				"Key: 'CreateTodoRequest.StoryPoints' Error:Field validation for 'StoryPoints' failed on the 'min' tag"})
+		}
+	})
+	ctx := context.Background()
+	c.MaxRetries = 0
+
+	_, err := c.GetTodo(ctx, 404)
+	var apiErr *client.APIError
+	if !errors.Is(err, client.ErrNotFound) || errors.Is(err, client.ErrGone) || !errors.As(err, &apiErr) || apiErr.Message != "Todo not found" {
+		t.Errorf("404: %v, want ErrNotFound with the message", err)
+	}
+
+	_, err = c.GetTodo(ctx, 410)
+	if !errors.Is(err, client.ErrGone) || errors.Is(err, client.ErrNotFound) || !errors.As(err, &apiErr) || apiErr.DeletedAt == nil || !apiErr.DeletedAt.Equal(deletedAt) {
+		t.Errorf("410: %v, want ErrGone with when the todo was deleted", err)
+	}
+
+	_, err = c.GetTodo(ctx, 500)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError || apiErr.Message != "upstream exploded" {
+		t.Errorf("500 with a plain body: %v", err)
+	}
+
+	_, err = c.CreateTodo(ctx, client.CreateTodoRequest{})
+	var validation *client.ValidationError
+	if !errors.As(err, &validation) || validation.Fields["title"] != "required" || validation.Fields["story_points"] != "min" || len(validation.Fields) != 2 {
+		t.Errorf("400: %v, want a ValidationError for title and story_points", err)
+	}
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("400: errors.As does not find the APIError of %v", err)
+	}
+}
+
+// Idempotent requests are retried on 429 and 5xx, honoring Retry-After, and
+// others are sent once
+func TestClientRetries(t *testing.T) {
+	var attempts, failures atomic.Int32
+	failures.Store(2)
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n := attempts.Add(1)
+		switch {
+		case n == 1:
+			w.Header().Set("Retry-After", "0")
+			writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "Slow down"})
+		case n <= failures.Load():
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "Unavailable"})
+		default:
+			writeJSON(w, http.StatusOK, client.Todo{ID: 1})
+		}
+	})
+	ctx := context.Background()
+
+	if _, err := c.GetTodo(ctx, 1); err != nil || attempts.Load() != 3 {
+		t.Errorf("GET after two failures: %v after %d attempts, want success after 3", err, attempts.Load())
+	}
+
+	attempts.Store(0)
+	failures.Store(100)
+	var apiErr *client.APIError
+	if _, err := c.GetTodo(ctx, 1); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || attempts.Load() != int32(c.MaxRetries)+1 {
+		t.Errorf("GET failing every time: %v after %d attempts, want a 503 after %d", err, attempts.Load(), c.MaxRetries+1)
+	}
+
+	attempts.Store(1)
+	if _, err := c.CreateTodo(ctx, client.CreateTodoRequest{Title: "Once"}); !errors.As(err, &apiErr) || attempts.Load() != 2 {
+		t.Errorf("POST: %v after %d attempts, want the first error without a retry", err, attempts.Load()-1)
+	}
+
+	attempts.Store(1)
+	c.RetryDelay = time.Hour
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetTodo(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GET cancelled while waiting to retry: %v, want the context's error", err)
+	}
+}
+
+// The iterator fetches pages until the total is reached and stops at an
+// error
+func TestTodoIterator(t *testing.T) {
+	const total = 5
+	var fail atomic.Bool
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if fail.Load() && offset > 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid offset"})
+			return
+		}
+		page := []client.Todo{}
+		for id := offset + 1; id <= min(offset+limit, total); id++ {
+			page = append(page, client.Todo{ID: int64(id), Title: fmt.Sprint("Todo ", id)})
+		}
+		w.Header().Set("X-Total-Count", strconv.Itoa(total))
+		writeJSON(w, http.StatusOK, page)
+	})
+	ctx := context.Background()
+
+	var ids []int64
+	it := c.Todos(client.ListOptions{Limit: 2})
+	for it.Next(ctx) {
+		ids = append(ids, it.Todo().ID)
+	}
+	if it.Err() != nil || fmt.Sprint(ids) != "[1 2 3 4 5]" {
+		t.Errorf("iterated %v, %v, want todos 1 to 5", ids, it.Err())
+	}
+
+	fail.Store(true)
+	ids = nil
+	it = c.Todos(client.ListOptions{Limit: 2})
+	for it.Next(ctx) {
+		ids = append(ids, it.Todo().ID)
+	}
+	var validation *client.ValidationError
+	if !errors.As(it.Err(), &validation) || fmt.Sprint(ids) != "[1 2]" {
+		t.Errorf("iterated %v, %v, want the first page and then the error", ids, it.Err())
+	}
+}
+
+// Against the real router and database, a todo goes through its life cycle
+// and the client sees the server's errors as they are written
+func TestClientIntegration(t *testing.T) {
+	pool := testsupport.UseDatabase(t)
+	server := httptest.NewServer(router.New(router.Options{}))
+	t.Cleanup(server.Close)
+	c := client.New(server.URL+router.BasePath, "")
+	ctx := context.Background()
+
+	todo, err := c.CreateTodo(ctx, client.CreateTodoRequest{Title: "Client integration"})
+	if err != nil {
+		t.Fatalf("CreateTodo: %v", err)
+	}
+	t.Cleanup(func() { pool.Exec(context.Background(), `DELETE FROM todos WHERE id = $1`, todo.ID) })
+	if _, err := c.CreateSubtask(ctx, todo.ID, client.CreateSubtaskRequest{Title: "Step"}); err != nil {
+		t.Fatalf("CreateSubtask: %v", err)
+	}
+	if todo, err = c.CompleteTodo(ctx, todo.ID); err != nil || todo.CompletedAt == nil {
+		t.Fatalf("CompleteTodo = %+v, %v", todo, err)
+	}
+
+	var validation *client.ValidationError
+	if _, err := c.CreateTodo(ctx, client.CreateTodoRequest{}); !errors.As(err, &validation) || validation.Fields["title"] != "required" {
+		t.Errorf("creating a todo without a title: %v, want a ValidationError for title", err)
+	}
+	if err := c.DeleteTodo(ctx, todo.ID); err != nil {
+		t.Fatalf("DeleteTodo: %v", err)
+	}
+	if _, err := c.GetTodo(ctx, todo.ID); !errors.Is(err, client.ErrGone) {
+		t.Errorf("getting the deleted todo: %v, want ErrGone", err)
+	}
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrNotFound matches, with errors.Is, any error for a 404 response
+var ErrNotFound = errors.New("not found")
+
+// ErrGone matches, with errors.Is, any error for a 410 response: a todo that
+// was deleted, as opposed to one that never existed
+var ErrGone = errors.New("gone")
+
+// APIError is a response with an error status. Message and Details come from
+// the error body the server writes, {"error": ..., "details": ...}.
+// DeletedAt is set for a 410 for a deleted todo.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Details    string
+	DeletedAt  *time.Time
+}
+
+func (e *APIError) Error() string {
+	message := e.Message
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+	if e.Details != "" {
+		message += ": " + e.Details
+	}
+	return fmt.Sprintf("%s (HTTP %d)", message, e.StatusCode)
+}
+
+// Is reports whether the error is a 404 when target is ErrNotFound, or a
+// 410 when target is ErrGone
+func (e *APIError) Is(target error) bool {
+	return (target == ErrNotFound && e.StatusCode == http.StatusNotFound) ||
+		(target == ErrGone && e.StatusCode == http.StatusGone)
+}
+
+// ValidationError is a 400 response. Fields maps each request field that
+// failed binding validation, by its JSON name, to the rule it broke, such as
+// "required"; it is empty when the server rejected the request for another
+// reason, which Message then describes.
+type ValidationError struct {
+	APIError
+	Fields map[string]string
+}
+
+// Unwrap returns the underlying APIError so errors.As finds either type
+func (e *ValidationError) Unwrap() error {
+	return &e.APIError
+}
+
+// fieldErrorPattern matches one failed rule in the messages gin writes for
+// binding errors, e.g. "Key: 'CreateTodoRequest.Title' Error:Field
+// validation for 'Title' failed on the 'required' tag"
+var fieldErrorPattern = regexp.MustCompile(`Key: '[^']*' Error:Field validation for '([^']+)' failed on the '([^']+)' tag`)
+
+// newAPIError returns the typed error for an error response
+func newAPIError(statusCode int, message, details string, deletedAt *time.Time) error {
+	apiErr := APIError{StatusCode: statusCode, Message: message, Details: details, DeletedAt: deletedAt}
+	if statusCode != http.StatusBadRequest {
+		return &apiErr
+	}
+	fields := map[string]string{}
+	for _, match := range fieldErrorPattern.FindAllStringSubmatch(message, -1) {
+		fields[jsonFieldName(match[1])] = match[2]
+	}
+	return &ValidationError{APIError: apiErr, Fields: fields}
+}
+
+// jsonFieldName converts a Go field name to the snake_case JSON name the
+// request types use, e.g. StoryPoints to story_points
+func jsonFieldName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 && !(name[i-1] >= 'A' && name[i-1] <= 'Z') {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
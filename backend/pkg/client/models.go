@@ -0,0 +1,24 @@
+package client
+
+import "flow-v1/backend/internal/models"
+
+// The client sends and returns the server's own models. They are aliased
+// here because programs outside this module cannot import internal packages.
+type (
+	Todo                 = models.Todo
+	TodoEpic             = models.TodoEpic
+	CreateTodoRequest    = models.CreateTodoRequest
+	UpdateTodoRequest    = models.UpdateTodoRequest
+	DueDateInput         = models.DueDateInput
+	Subtask              = models.Subtask
+	CreateSubtaskRequest = models.CreateSubtaskRequest
+	UpdateSubtaskRequest = models.UpdateSubtaskRequest
+	Status               = models.Status
+)
+
+// ParseDueDate parses a due date for CreateTodoRequest and
+// UpdateTodoRequest: an RFC3339 timestamp, a timestamp without a zone or a
+// YYYY-MM-DD date, which makes the todo all-day
+func ParseDueDate(s string) (DueDateInput, error) {
+	return models.ParseDateInput(s)
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// ListSubtasks returns the subtasks of a todo
+func (c *Client) ListSubtasks(ctx context.Context, todoID int64) ([]Subtask, error) {
+	var subtasks []Subtask
+	_, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/todos/%d/subtasks", todoID), nil, nil, &subtasks)
+	return subtasks, err
+}
+
+// CreateSubtask adds a subtask to a todo
+func (c *Client) CreateSubtask(ctx context.Context, todoID int64, req CreateSubtaskRequest) (Subtask, error) {
+	var subtask Subtask
+	_, err := c.do(ctx, http.MethodPost, fmt.Sprintf("/todos/%d/subtasks", todoID), nil, req, &subtask)
+	return subtask, err
+}
+
+// UpdateSubtask updates a subtask. An empty Title keeps the current title;
+// Completed is always applied.
+func (c *Client) UpdateSubtask(ctx context.Context, todoID, subtaskID int64, req UpdateSubtaskRequest) (Subtask, error) {
+	var subtask Subtask
+	_, err := c.do(ctx, http.MethodPut, fmt.Sprintf("/todos/%d/subtasks/%d", todoID, subtaskID), nil, req, &subtask)
+	return subtask, err
+}
+
+// DeleteSubtask deletes a subtask
+func (c *Client) DeleteSubtask(ctx context.Context, todoID, subtaskID int64) error {
+	_, err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/todos/%d/subtasks/%d", todoID, subtaskID), nil, nil, nil)
+	return err
+}
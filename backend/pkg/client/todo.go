@@ -0,0 +1,209 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListOptions filters, sorts and pages ListTodos. Empty fields are left to
+// the server's defaults.
+type ListOptions struct {
+	// Status is a status key, label or alias
+	Status string
+	// SortBy is a comma-separated list of sort fields, e.g. "priority,due_date"
+	SortBy string
+	// Order is a comma-separated sort order per field, "asc" or "desc"
+	Order string
+	// SprintID is a sprint ID, or "none" for the backlog
+	SprintID string
+	// EpicID is an epic ID, or "none" for todos outside any epic
+	EpicID string
+	// Search is text the todo or one of its subtasks must contain
+	Search string
+	// SearchIn is where Search looks: "todos", "subtasks" or "all"
+	SearchIn string
+	// Limit is the page size, at most 200; 0 returns every matching todo
+	Limit int
+	// Offset is the number of todos to skip; it needs a Limit
+	Offset int
+}
+
+func (o ListOptions) query() url.Values {
+	query := url.Values{}
+	for name, value := range map[string]string{
+		"status":    o.Status,
+		"sort_by":   o.SortBy,
+		"order":     o.Order,
+		"sprint_id": o.SprintID,
+		"epic_id":   o.EpicID,
+		"q":         o.Search,
+		"search_in": o.SearchIn,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	if o.Limit > 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+		query.Set("offset", strconv.Itoa(o.Offset))
+	}
+	return query
+}
+
+// ListTodos returns the todos matching opts
+func (c *Client) ListTodos(ctx context.Context, opts ListOptions) ([]Todo, error) {
+	todos, _, err := c.ListTodosPage(ctx, opts)
+	return todos, err
+}
+
+// ListTodosPage returns one page of the todos matching opts, which should
+// set Limit, along with the total number of matching todos. Without a Limit
+// the total is the number of todos returned.
+func (c *Client) ListTodosPage(ctx context.Context, opts ListOptions) ([]Todo, int64, error) {
+	var todos []Todo
+	resp, err := c.do(ctx, http.MethodGet, "/todos", opts.query(), nil, &todos)
+	if err != nil {
+		return nil, 0, err
+	}
+	total := int64(len(todos))
+	if value := resp.header.Get("X-Total-Count"); value != "" {
+		if total, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, 0, fmt.Errorf("invalid X-Total-Count %q", value)
+		}
+	}
+	return todos, total, nil
+}
+
+// defaultPageSize is the page size of TodoIterator when opts has no Limit
+const defaultPageSize = 100
+
+// TodoIterator walks every todo matching a ListOptions a page at a time:
+//
+//	it := c.Todos(client.ListOptions{Status: "todo"})
+//	for it.Next(ctx) {
+//		fmt.Println(it.Todo().Title)
+//	}
+//	if err := it.Err(); err != nil { ... }
+//
+// Todos created or deleted while iterating can shift the pages, so a todo
+// may be skipped or seen twice.
+type TodoIterator struct {
+	c     *Client
+	opts  ListOptions
+	page  []Todo
+	index int
+	done  bool
+	err   error
+}
+
+// Todos returns an iterator over the todos matching opts, starting at
+// opts.Offset and fetching opts.Limit todos per request
+func (c *Client) Todos(opts ListOptions) *TodoIterator {
+	if opts.Limit <= 0 {
+		opts.Limit = defaultPageSize
+	}
+	return &TodoIterator{c: c, opts: opts, index: -1}
+}
+
+// Next advances to the next todo, fetching the next page when needed. It
+// returns false at the end or on an error, which Err then returns.
+func (it *TodoIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	it.index++
+	if it.index < len(it.page) {
+		return true
+	}
+	if it.done {
+		return false
+	}
+
+	page, total, err := it.c.ListTodosPage(ctx, it.opts)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.page, it.index = page, 0
+	it.opts.Offset += len(page)
+	it.done = len(page) < it.opts.Limit || int64(it.opts.Offset) >= total
+	return len(page) > 0
+}
+
+// Todo returns the current todo
+func (it *TodoIterator) Todo() Todo {
+	return it.page[it.index]
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *TodoIterator) Err() error {
+	return it.err
+}
+
+// GetTodo returns a todo with its subtasks and links. A deleted todo fails
+// with an error matching ErrGone, whose *APIError has DeletedAt, until its
+// tombstone is purged; after that, and for IDs that never existed, the error
+// matches ErrNotFound.
+func (c *Client) GetTodo(ctx context.Context, id int64) (Todo, error) {
+	var todo Todo
+	_, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/todos/%d", id), nil, nil, &todo)
+	return todo, err
+}
+
+// CreateTodo creates a todo
+func (c *Client) CreateTodo(ctx context.Context, req CreateTodoRequest) (Todo, error) {
+	var todo Todo
+	_, err := c.do(ctx, http.MethodPost, "/todos", nil, req, &todo)
+	return todo, err
+}
+
+// UpdateTodo updates a todo. Empty fields of req keep their current value,
+// except Title, which the caller must always set.
+func (c *Client) UpdateTodo(ctx context.Context, id int64, req UpdateTodoRequest) (Todo, error) {
+	var todo Todo
+	_, err := c.do(ctx, http.MethodPut, fmt.Sprintf("/todos/%d", id), nil, req, &todo)
+	return todo, err
+}
+
+// DeleteTodo deletes a todo
+func (c *Client) DeleteTodo(ctx context.Context, id int64) error {
+	_, err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/todos/%d", id), nil, nil, nil)
+	return err
+}
+
+// ListStatuses returns the configured statuses in board order
+func (c *Client) ListStatuses(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	_, err := c.do(ctx, http.MethodGet, "/statuses", nil, nil, &statuses)
+	return statuses, err
+}
+
+// CompleteTodo moves a todo to the first done status
+func (c *Client) CompleteTodo(ctx context.Context, id int64) (Todo, error) {
+	statuses, err := c.ListStatuses(ctx)
+	if err != nil {
+		return Todo{}, err
+	}
+	done := ""
+	for _, status := range statuses {
+		if status.IsDone {
+			done = status.Key
+			break
+		}
+	}
+	if done == "" {
+		return Todo{}, fmt.Errorf("no done status is configured")
+	}
+
+	todo, err := c.GetTodo(ctx, id)
+	if err != nil {
+		return todo, err
+	}
+	if todo.Status == done {
+		return todo, nil
+	}
+	return c.UpdateTodo(ctx, id, UpdateTodoRequest{Title: todo.Title, Status: done})
+}